@@ -0,0 +1,223 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siegfried
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// ErrStaleCache is returned by LoadCache when a cache was saved with a different signature file, identification
+// settings or hash algorithm.
+var ErrStaleCache = errors.New("siegfried: cache was made with a different signature file, settings or hash algorithm")
+
+// Cache maps the checksums of files to their identification results so that duplicate files (e.g. email
+// attachments and backups) are only identified once. Because identification also depends on a file's name (not just
+// its extension: name matchers can match globs and whole names e.g. README*) and MIME type, results are cached for
+// the checksum, base name and MIME type of each file.
+// Use a cryptographic hash (e.g. sha256) for the checksums: entries are trusted without comparing content.
+// A Cache is safe for concurrent use by multiple goroutines.
+//
+// Example:
+//  c := siegfried.NewCache("sha256")
+//  ids, ok := c.Get(sum, name, "")
+//  if !ok {
+//  	ids, err = s.Identify(r, name, "")
+//  	c.Put(sum, name, "", ids)
+//  }
+type Cache struct {
+	hits, misses int64 // first for 64-bit alignment of atomic access
+
+	hash string
+	mu   sync.RWMutex
+	ids  map[string][]core.Identification
+	sums map[string]string // the key of an entry for each checksum, for Lookup
+}
+
+// NewCache creates an empty Cache for checksums made with the named hash algorithm.
+func NewCache(hash string) *Cache {
+	return &Cache{hash: hash, ids: make(map[string][]core.Identification), sums: make(map[string]string)}
+}
+
+func cacheKey(sum []byte, name, mime string) string {
+	return string(sum) + "\x00" + filepath.Base(name) + "\x00" + mime
+}
+
+// cacheSum returns the checksum of a cache key. Checksums can contain zero bytes, so the key is split from the end.
+func cacheSum(k string) string {
+	if i := strings.LastIndexByte(k, 0); i > 0 {
+		if j := strings.LastIndexByte(k[:i], 0); j >= 0 {
+			return k[:j]
+		}
+	}
+	return k
+}
+
+// Get returns the cached results for a file, if there are any.
+func (c *Cache) Get(sum []byte, name, mime string) ([]core.Identification, bool) {
+	c.mu.RLock()
+	ids, ok := c.ids[cacheKey(sum, name, mime)]
+	c.mu.RUnlock()
+	if ok {
+		atomic.AddInt64(&c.hits, 1)
+	} else {
+		atomic.AddInt64(&c.misses, 1)
+	}
+	return ids, ok
+}
+
+// Lookup returns the cached results for a checksum, whatever the name and MIME type of the file. If files with the
+// checksum were cached with different extensions or MIME types, the results for one of them are returned.
+// Unlike Get, Lookup doesn't count hits and misses.
+func (c *Cache) Lookup(sum []byte) ([]core.Identification, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	k, ok := c.sums[string(sum)]
+	if !ok {
+		return nil, false
+	}
+	return c.ids[k], true
+}
+
+// Put caches the results for a file.
+func (c *Cache) Put(sum []byte, name, mime string, ids []core.Identification) {
+	c.mu.Lock()
+	c.put(string(sum), cacheKey(sum, name, mime), ids)
+	c.mu.Unlock()
+}
+
+func (c *Cache) put(sum, k string, ids []core.Identification) {
+	c.ids[k] = ids
+	if _, ok := c.sums[sum]; !ok {
+		c.sums[sum] = k
+	}
+}
+
+// Len reports the number of entries in the cache.
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.ids)
+}
+
+// Stats reports the number of calls to Get that found (hits) and didn't find (misses) cached results.
+func (c *Cache) Stats() (hits, misses int64) {
+	return atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses)
+}
+
+// cachedID is an identification loaded from a saved cache
+type cachedID struct {
+	str    string
+	known  bool
+	warn   string
+	values []string
+	arc    config.Archive
+}
+
+func (c *cachedID) String() string          { return c.str }
+func (c *cachedID) Known() bool             { return c.known }
+func (c *cachedID) Warn() string            { return c.warn }
+func (c *cachedID) Values() []string        { return c.values }
+func (c *cachedID) Archive() config.Archive { return c.arc }
+
+// cacheSignature identifies the signature file used by s, and the settings that change its results (the runtime
+// overrides of its identifiers' options and the policies for empty, tiny, trailing and macro files), so that stale
+// caches can be detected
+func cacheSignature(s *Siegfried) []string {
+	ret := []string{s.C.UTC().String()}
+	for _, id := range s.Identifiers() {
+		ret = append(ret, id[0], id[1])
+	}
+	var resolve string
+	if m, ok := config.Resolve(); ok {
+		resolve = m.String()
+	}
+	return append(ret, fmt.Sprintf("noext=%t nobyte=%t nocontainer=%t resolve=%s empty=%t tiny=%d trailing=%t macros=%t",
+		config.SkipName(), config.SkipByte(), config.SkipContainer(), resolve, config.Empty(), config.Tiny(), config.Trailing(), config.Macros()))
+}
+
+// Save persists the cache, along with details of the signature file used by s to make the cached results.
+func (c *Cache) Save(w io.Writer, s *Siegfried) error {
+	ls := persist.NewLoadSaver(nil)
+	ls.SaveString(c.hash)
+	ls.SaveStrings(cacheSignature(s))
+	c.mu.RLock()
+	ls.SaveInt(len(c.ids))
+	for k, ids := range c.ids {
+		ls.SaveString(k)
+		ls.SaveSmallInt(len(ids))
+		for _, id := range ids {
+			ls.SaveString(id.String())
+			ls.SaveBool(id.Known())
+			ls.SaveString(id.Warn())
+			ls.SaveStrings(id.Values())
+			ls.SaveTinyInt(int(id.Archive()))
+		}
+	}
+	c.mu.RUnlock()
+	if ls.Err != nil {
+		return ls.Err
+	}
+	_, err := w.Write(ls.Bytes())
+	return err
+}
+
+// LoadCache loads a cache persisted with Save. It returns ErrStaleCache if the cache was made with a different
+// signature file or settings to those used by s, or with a different hash algorithm.
+func LoadCache(r io.Reader, s *Siegfried, hash string) (*Cache, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	ls := persist.NewLoadSaver(buf)
+	h := ls.LoadString()
+	sig := ls.LoadStrings()
+	if ls.Err != nil {
+		return nil, ls.Err
+	}
+	if h != hash || strings.Join(sig, "\x00") != strings.Join(cacheSignature(s), "\x00") {
+		return nil, ErrStaleCache
+	}
+	c := NewCache(hash)
+	n := ls.LoadInt()
+	for i := 0; i < n && ls.Err == nil; i++ {
+		k := ls.LoadString()
+		ids := make([]core.Identification, ls.LoadSmallInt())
+		for j := range ids {
+			ids[j] = &cachedID{
+				str:    ls.LoadString(),
+				known:  ls.LoadBool(),
+				warn:   ls.LoadString(),
+				values: ls.LoadStrings(),
+				arc:    config.Archive(ls.LoadTinyInt()),
+			}
+		}
+		c.put(cacheSum(k), k, ids)
+	}
+	if ls.Err != nil {
+		return nil, ls.Err
+	}
+	return c, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command libsf builds siegfried as a C shared library, so that tools written in other languages (e.g. Python, Java, .NET)
+// can identify files in-process rather than shelling out to sf.
+//
+// Build with:
+//
+//	go build -buildmode=c-shared -o libsiegfried.so ./cmd/libsf
+//
+// This also writes a libsiegfried.h header. The exported functions are:
+//
+//	int sf_load(char* path, char** err);                        // load a signature file; returns a handle, or 0 and sets err
+//	int sf_load_bytes(void* buf, int len, char** err);          // load a signature file from memory
+//	char* sf_identify(int sf, char* path, char** err);          // identify a file; returns JSON results, or NULL and sets err
+//	char* sf_identify_bytes(int sf, void* buf, int len, char* name, char** err); // identify a buffer (name is optional)
+//	void sf_close(int sf);                                      // release a loaded signature file
+//	void sf_free(char* str);                                    // free a string returned by the library (results and errors)
+//
+// Results are JSON arrays of objects with the fields of siegfried.Result. Handles are safe for concurrent use.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"unsafe"
+
+	"github.com/richardlehane/siegfried"
+)
+
+var (
+	mu      sync.RWMutex
+	handles = make(map[C.int]*siegfried.Siegfried)
+	next    C.int
+)
+
+func setErr(err **C.char, msg string) {
+	if err != nil {
+		*err = C.CString(msg)
+	}
+}
+
+func register(s *siegfried.Siegfried) C.int {
+	mu.Lock()
+	defer mu.Unlock()
+	next++
+	handles[next] = s
+	return next
+}
+
+func get(h C.int) *siegfried.Siegfried {
+	mu.RLock()
+	defer mu.RUnlock()
+	return handles[h]
+}
+
+//export sf_load
+func sf_load(path *C.char, err **C.char) C.int {
+	s, e := siegfried.Load(C.GoString(path))
+	if e != nil {
+		setErr(err, e.Error())
+		return 0
+	}
+	return register(s)
+}
+
+//export sf_load_bytes
+func sf_load_bytes(buf unsafe.Pointer, l C.int, err **C.char) C.int {
+	s, e := siegfried.LoadBytes(C.GoBytes(buf, l))
+	if e != nil {
+		setErr(err, e.Error())
+		return 0
+	}
+	return register(s)
+}
+
+// results runs an identification and marshals its results as JSON
+func results(h C.int, err **C.char, fn func(*siegfried.Siegfried) ([]siegfried.Result, error)) *C.char {
+	s := get(h)
+	if s == nil {
+		setErr(err, "siegfried: bad handle; load a signature file with sf_load")
+		return nil
+	}
+	res, e := fn(s)
+	if e != nil {
+		setErr(err, e.Error())
+		return nil
+	}
+	byt, e := json.Marshal(res)
+	if e != nil {
+		setErr(err, e.Error())
+		return nil
+	}
+	return C.CString(string(byt))
+}
+
+//export sf_identify
+func sf_identify(h C.int, path *C.char, err **C.char) *C.char {
+	return results(h, err, func(s *siegfried.Siegfried) ([]siegfried.Result, error) {
+		p := C.GoString(path)
+		f, e := os.Open(p)
+		if e != nil {
+			return nil, e
+		}
+		defer f.Close()
+		ids, e := s.Identify(f, p, "")
+		if e != nil {
+			return nil, e
+		}
+		return s.Results(ids), nil
+	})
+}
+
+//export sf_identify_bytes
+func sf_identify_bytes(h C.int, buf unsafe.Pointer, l C.int, name *C.char, err **C.char) *C.char {
+	return results(h, err, func(s *siegfried.Siegfried) ([]siegfried.Result, error) {
+		var n string
+		if name != nil {
+			n = C.GoString(name)
+		}
+		ids, e := s.IdentifyReaderAt(bytes.NewReader(C.GoBytes(buf, l)), int64(l), n, "")
+		if e != nil {
+			return nil, e
+		}
+		return s.Results(ids), nil
+	})
+}
+
+//export sf_close
+func sf_close(h C.int) {
+	mu.Lock()
+	delete(handles, h)
+	mu.Unlock()
+}
+
+//export sf_free
+func sf_free(str *C.char) {
+	C.free(unsafe.Pointer(str))
+}
+
+func main() {} // required for -buildmode=c-shared
@@ -0,0 +1,125 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/richardlehane/siegfried"
+	"github.com/richardlehane/siegfried/internal/cli"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/sets"
+)
+
+// formats lists the formats of the default signature file and the format sets (e.g. @pdfa), for completing
+// -limit and -exclude and the formats given to roy inspect
+func formats() []string {
+	var ret []string
+	if s, err := siegfried.Load(config.Signature()); err == nil {
+		ret = s.Formats()
+	}
+	for _, k := range sets.Keys() {
+		ret = append(ret, "@"+k)
+	}
+	return ret
+}
+
+func royCommand() *cli.Command {
+	fmts := cli.CommaList(cli.Lazy(formats))
+	multis := cli.List("single", "conclusive", "positive", "comprehensive", "exhaustive")
+	return &cli.Command{
+		Name: "roy",
+		Subcommands: []*cli.Command{
+			{
+				Name:    "build",
+				Summary: "build a signature file e.g. roy build -limit @pdfa pdfa.sig",
+				Flags:   build,
+				Values:  map[string]cli.Values{"limit": fmts, "exclude": fmts, "multi": multis},
+			},
+			{
+				Name:    "add",
+				Summary: "add an identifier to a signature file e.g. roy add -mi tika-mimetypes.xml default.sig",
+				Flags:   build,
+				Values:  map[string]cli.Values{"limit": fmts, "exclude": fmts, "multi": multis},
+			},
+			{
+				Name:    "harvest",
+				Summary: "harvest PRONOM reports or a Wikidata report",
+				Flags:   harvest,
+			},
+			{
+				Name:    "inspect",
+				Summary: "inspect a signature file, matcher or format e.g. roy inspect fmt/40",
+				Flags:   inspect,
+				Values:  map[string]cli.Values{"limit": fmts, "exclude": fmts},
+				Args: cli.Join(
+					cli.List("bytematcher", "containermatcher", "namematcher", "mimematcher", "riffmatcher", "ebmlmatcher",
+						"sqlitematcher", "tiffmatcher", "fontmatcher", "xmlmatcher", "textmatcher", "priorities",
+						"missing-priorities", "implicit-priorities", "releases"),
+					fmts,
+				),
+			},
+			{
+				Name:    "sets",
+				Summary: "make PRONOM format sets, or expand a list of sets e.g. roy sets -list @pdfa",
+				Flags:   setsf,
+				Values:  map[string]cli.Values{"list": cli.CommaList(cli.Lazy(setNames))},
+			},
+			{
+				Name:    "export",
+				Summary: "export a signature file as JSON e.g. roy export default.sig -o default.json",
+				Flags:   exportf,
+			},
+			{
+				Name:    "compare",
+				Summary: "compare results files e.g. roy compare -format summary a.yaml b.csv",
+				Flags:   comparef,
+				Values:  map[string]cli.Values{"format": cli.List("csv", "json", "html", "summary")},
+			},
+			{
+				Name:    "completion",
+				Summary: "write a completion script for a shell e.g. source <(roy completion bash)",
+				Args:    cli.List(cli.Shells...),
+			},
+			{
+				Name:    "help",
+				Summary: "list the subcommands, or the flags about a topic e.g. roy help priorities",
+			},
+		},
+	}
+}
+
+func setNames() []string {
+	keys := sets.Keys()
+	for i, k := range keys {
+		keys[i] = "@" + k
+	}
+	return keys
+}
+
+// complete writes the completions for the words of a command line, one to a line. A -home flag among the words is
+// applied first, so that formats and sets are completed from that home directory.
+func complete(w io.Writer, words []string) {
+	for i := 0; i < len(words)-2; i++ {
+		if strings.TrimLeft(words[i], "-") == "home" {
+			config.SetHome(words[i+1])
+		}
+	}
+	for _, c := range royCommand().Complete(words) {
+		fmt.Fprintln(w, c)
+	}
+}
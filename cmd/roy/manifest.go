@@ -0,0 +1,191 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/richardlehane/siegfried"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+// A build manifest describes one or more identifiers to compile into a single signature file.
+// Manifests are written in a simple subset of YAML. Identifier settings use the same names as the roy build flags:
+//
+//	signature: custom.sig
+//	identifiers:
+//	  - name: pronom-pdf
+//	    limit: "@pdf"
+//	    nocontainer: true
+//	  - name: tika
+//	    mi: tika-mimetypes.xml
+//	    bof: 4096
+//
+// Each identifier starts from the default build settings; top-level command line flags (other than -home) don't apply.
+type buildManifest struct {
+	signature   string
+	home        string
+	identifiers [][][2]string // ordered key, value pairs for each identifier
+}
+
+func parseManifest(r io.Reader) (*buildManifest, error) {
+	m := &buildManifest{}
+	var inList bool
+	scanner := bufio.NewScanner(r)
+	var num int
+	for scanner.Scan() {
+		num++
+		line := strings.TrimRight(scanner.Text(), " \t")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indented := !strings.HasPrefix(line, trimmed)
+		item := strings.HasPrefix(trimmed, "- ") || trimmed == "-"
+		if item {
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+		}
+		if !indented && !item {
+			inList = false
+		}
+		var k, v string
+		if trimmed != "" {
+			idx := strings.Index(trimmed, ":")
+			if idx < 1 {
+				return nil, fmt.Errorf("roy: manifest line %d: expecting key: value, got %q", num, line)
+			}
+			k, v = strings.TrimSpace(trimmed[:idx]), manifestValue(trimmed[idx+1:])
+		}
+		switch {
+		case inList && item:
+			m.identifiers = append(m.identifiers, nil)
+			if k == "" {
+				continue
+			}
+		case inList:
+			if len(m.identifiers) == 0 {
+				return nil, fmt.Errorf("roy: manifest line %d: expecting a list item (- key: value)", num)
+			}
+		case item || indented:
+			return nil, fmt.Errorf("roy: manifest line %d: unexpected indentation or list item outside of identifiers", num)
+		default:
+			switch k {
+			case "identifiers":
+				if v != "" {
+					return nil, fmt.Errorf("roy: manifest line %d: identifiers must be given as a list", num)
+				}
+				inList = true
+			case "signature":
+				m.signature = v
+			case "home":
+				m.home = v
+			default:
+				return nil, fmt.Errorf("roy: manifest line %d: unknown key %q", num, k)
+			}
+			continue
+		}
+		if k == "manifest" || k == "home" {
+			return nil, fmt.Errorf("roy: manifest line %d: %s can't be set for an individual identifier", num, k)
+		}
+		last := len(m.identifiers) - 1
+		m.identifiers[last] = append(m.identifiers[last], [2]string{k, v})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(m.identifiers) == 0 {
+		return nil, fmt.Errorf("roy: manifest doesn't describe any identifiers")
+	}
+	return m, nil
+}
+
+// manifestValue strips quotes and comments from a value, and turns YAML flow lists (e.g. [fmt/1, fmt/2]) into comma separated lists.
+func manifestValue(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) > 1 && (v[0] == '"' || v[0] == '\'') {
+		if end := strings.IndexByte(v[1:], v[0]); end > -1 {
+			return v[1 : end+1]
+		}
+	}
+	if idx := strings.Index(v, " #"); idx > -1 {
+		v = strings.TrimSpace(v[:idx])
+	}
+	if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+		items := strings.Split(v[1:len(v)-1], ",")
+		for i, item := range items {
+			items[i] = manifestValue(item)
+		}
+		v = strings.Join(items, ",")
+	}
+	return v
+}
+
+// resetBuild returns the build flags to their defaults (except -home and -manifest) so that the settings of one identifier don't leak into the next.
+// Flags with defaults that depend on the home directory are reset to the current config values.
+func resetBuild() {
+	build.VisitAll(func(f *flag.Flag) {
+		switch f.Name {
+		case "home", "manifest":
+		case "droid":
+			f.Value.Set(config.Droid())
+		case "container":
+			f.Value.Set(config.Container())
+		case "details":
+			f.Value.Set(config.Details())
+		default:
+			f.Value.Set(f.DefValue)
+		}
+	})
+}
+
+// makeManifest builds each identifier described in a manifest file, adds them to the Siegfried and saves it.
+func makeManifest(s *siegfried.Siegfried, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	m, err := parseManifest(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	// settle the home directory up front so that it holds for every identifier
+	if m.home != "" && *home == config.Home() {
+		*home = m.home
+	}
+	config.SetHome(*home)
+	inspect.Set("home", *home)
+	if m.signature != "" && build.Arg(0) == "" {
+		config.SetSignature(m.signature)
+	}
+	for i, settings := range m.identifiers {
+		config.Reset()()
+		resetBuild()
+		for _, kv := range settings {
+			if err := build.Set(kv[0], kv[1]); err != nil {
+				return fmt.Errorf("roy: manifest identifier %d: %v", i+1, err)
+			}
+		}
+		if err := addIdentifier(s, getOptions()); err != nil {
+			return fmt.Errorf("roy: manifest identifier %d: %v", i+1, err)
+		}
+	}
+	return s.Save(config.HomeFile(config.SignatureBase()))
+}
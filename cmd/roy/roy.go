@@ -17,8 +17,10 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -27,6 +29,8 @@ import (
 
 	"github.com/richardlehane/siegfried"
 	"github.com/richardlehane/siegfried/internal/chart"
+	"github.com/richardlehane/siegfried/internal/cli"
+	"github.com/richardlehane/siegfried/internal/frequency"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/core"
 	"github.com/richardlehane/siegfried/pkg/loc"
@@ -37,6 +41,7 @@ import (
 
 	"github.com/richardlehane/siegfried/pkg/reader"
 	"github.com/richardlehane/siegfried/pkg/sets"
+	"github.com/richardlehane/siegfried/pkg/text"
 )
 
 var usage = `
@@ -47,6 +52,9 @@ Usage:
    roy inspect -help
    roy sets -help
    roy compare -help
+   roy export -help
+   roy completion bash|zsh|fish|powershell
+   roy help [TOPIC]
 `
 
 var inspectUsage = `
@@ -59,7 +67,8 @@ Usage of inspect:
       Inspect  contents of a matcher e.g. roy inspect bytematcher.
       Short aliases work too e.g. roy inspect bm
       Current matchers are bytematcher (or bm), containermatcher (cm),
-      xmlmatcher (xm), riffmatcher (rm), namematcher (nm), textmatcher (tm).
+      xmlmatcher (xm), riffmatcher (rm), ebmlmatcher (em), sqlitematcher (sm),
+      tiffmatcher (fm), fontmatcher (om), namematcher (nm), textmatcher (tm).
    roy inspect INTEGER
       Identify the signatures related to the numerical hits reported by the
       sf debug and slow flags (sf -log d,s). E.g. roy inspect 100
@@ -126,6 +135,7 @@ var (
 	locfdd        = build.Bool("loc", false, "build a LOC FDD signature file")
 	wikidata      = build.Bool("wikidata", false, "build a Wikidata identifier")
 	wikidataDebug = build.Bool("wikidatadebug", false, "build a Wikidata identifier in debug mode")
+	textf         = build.Bool("text", false, "build a text identifier that sniffs structured text formats (JSON, YAML, CSV/TSV and source code)")
 	nopronom      = build.Bool("nopronom", false, "don't include PRONOM sigs with LOC or Wikidata signature file")
 	container     = build.String("container", config.Container(), "set name/path for Droid Container signature file")
 	name          = build.String("name", "", "set identifier name")
@@ -137,6 +147,7 @@ var (
 	bof           = build.Int("bof", 0, "define a maximum BOF offset")
 	eof           = build.Int("eof", 0, "define a maximum EOF offset")
 	noeof         = build.Bool("noeof", false, "ignore EOF segments in signatures")
+	bom           = build.Bool("bom", false, "allow a UTF-8 byte-order mark before text signatures at the beginning of file (e.g. for XML and CSV)")
 	multi         = build.String("multi", "", "control how identifiers treat multiple results")
 	nobyte        = build.Bool("nobyte", false, "skip byte signatures")
 	nocontainer   = build.Bool("nocontainer", false, "skip container signatures")
@@ -145,6 +156,14 @@ var (
 	nomime        = build.Bool("nomime", false, "skip MIME matcher")
 	noxml         = build.Bool("noxml", false, "skip XML matcher")
 	noriff        = build.Bool("noriff", false, "skip RIFF matcher")
+	noebml        = build.Bool("noebml", false, "skip EBML matcher")
+	nosqlite      = build.Bool("nosqlite", false, "skip SQLite matcher")
+	notiff        = build.Bool("notiff", false, "skip TIFF matcher")
+	nofont        = build.Bool("nofont", false, "skip font matcher")
+	metadata      = build.String("metadata", "", "set name/path for a CSV file of extra metadata (e.g. risk level) keyed on format ID, to include in results; a mime column supplies MIME types for formats without one")
+	class         = build.Bool("class", false, "include PRONOM format classes (e.g. Image (Raster), Database) in results, in a class field")
+	freq          = build.String("freq", "", "order signatures by how often formats are identified in a collection, using a feedback file made with sf -freq")
+	manifest      = build.String("manifest", "", "build from a manifest file describing one or more identifiers")
 	noreports     = build.Bool("noreports", false, "build directly from DROID file rather than PRONOM reports")
 	doubleup      = build.Bool("doubleup", false, "include byte signatures for formats that also have container signatures")
 	rng           = build.Int("range", config.Range(), "define a maximum range for segmentation")
@@ -187,11 +206,125 @@ var (
 	setsChanges = setsf.Bool("changes", false, "create a pronom-changes.json sets file")
 	setsList    = setsf.String("list", "", "expand comma separated list of format sets")
 
+	// EXPORT (roy export | roy export mysig.sig -o mysig.json)
+	exportf    = flag.NewFlagSet("export", flag.ExitOnError)
+	exportHome = exportf.String("home", config.Home(), "override the default home directory")
+	exportOut  = exportf.String("o", "", "set the output file (defaults to STDOUT)")
+
 	// COMPARE
-	comparef    = flag.NewFlagSet("compare", flag.ExitOnError)
-	compareJoin = comparef.Int("join", 0, "control which field(s) are used to link results files. Default is 0 (full file path). Other options are 1 (filename), 2, (filename + size), 3 (filename + modified), 4 (filename + hash), 5 (hash)")
+	comparef        = flag.NewFlagSet("compare", flag.ExitOnError)
+	compareJoin     = comparef.Int("join", 0, "control which field(s) are used to link results files. Default is 0 (full file path). Other options are 1 (filename), 2, (filename + size), 3 (filename + modified), 4 (filename + hash), 5 (hash)")
+	compareVersions = comparef.Bool("versions", false, "ignore differences between versions of the same format e.g. PDF 1.4 and PDF 1.5")
+	compareMIME     = comparef.Bool("mime", false, "compare MIME types rather than format IDs e.g. to compare PRONOM results with tika or file(1) results")
+	compareEquiv    = comparef.String("equiv", "", "semicolon separated groups of formats or format sets that are treated as matching e.g. fmt/40,fmt/412;@pdf")
+	compareHome     = comparef.String("home", config.Home(), "override the default home directory")
+	compareFormat   = comparef.String("format", "csv", "set the report format: csv (files that don't match), json or html (all files, with summary counts), or summary (counts and most frequent disagreements for each pair of results files)")
+	compareBaseline = comparef.String("baseline", "", "compare results files with a baseline results file (e.g. a gold standard) and report regressions and improvements relative to the first results file e.g. roy compare -baseline gold.csv current.csv new.csv")
+	compareRebase   = comparef.String("rebase", "", "trim directory prefixes from the paths of results files, so that results for the same content at different mount points can be joined by relative path e.g. /mnt/a,/srv/b or old=/mnt/a,new=/srv/b")
+	compareTop      = comparef.Int("top", 20, "set the number of disagreements listed for each pair of results files in a summary report (0 for all)")
 )
 
+func compare(w io.Writer, paths []string) error {
+	if *compareBaseline != "" {
+		return compareWithBaseline(w, paths)
+	}
+	var write func(io.Writer, int, reader.Normalise, ...string) error
+	switch *compareFormat {
+	case "csv":
+		write = reader.CompareNormalised
+	case "json":
+		write = reader.CompareJSON
+	case "html":
+		write = reader.CompareHTML
+	case "summary":
+		write = func(w io.Writer, join int, norm reader.Normalise, paths ...string) error {
+			pairs, err := reader.ComparePairs(join, norm, paths...)
+			if err != nil {
+				return err
+			}
+			return reader.WritePairs(w, pairs, *compareTop)
+		}
+	default:
+		return fmt.Errorf("unknown report format %s; expecting csv, json, html or summary", *compareFormat)
+	}
+	norm, err := compareNormalise(paths)
+	if err != nil {
+		return err
+	}
+	return write(w, *compareJoin, norm, paths...)
+}
+
+func compareWithBaseline(w io.Writer, paths []string) error {
+	if *compareFormat != "csv" && *compareFormat != "json" {
+		return fmt.Errorf("baseline comparisons can't be reported as %s; expecting csv (for text) or json", *compareFormat)
+	}
+	paths = append([]string{*compareBaseline}, paths...)
+	norm, err := compareNormalise(paths)
+	if err != nil {
+		return err
+	}
+	bl, err := reader.CompareBaseline(0, *compareJoin, norm, paths...)
+	if err != nil {
+		return err
+	}
+	if *compareFormat == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(bl)
+	}
+	return bl.WriteText(w)
+}
+
+func compareNormalise(paths []string) (reader.Normalise, error) {
+	norm := reader.Normalise{Versions: *compareVersions, MIME: *compareMIME}
+	if *compareRebase != "" {
+		var err error
+		if norm.Rebase, err = rebasePrefixes(*compareRebase, paths); err != nil {
+			return norm, err
+		}
+	}
+	if *compareEquiv != "" {
+		config.SetHome(*compareHome)
+		for _, g := range strings.Split(*compareEquiv, ";") {
+			if fmts := sets.Expand(g); len(fmts) > 1 {
+				norm.Equivalents = append(norm.Equivalents, fmts)
+			}
+		}
+	}
+	return norm, nil
+}
+
+// rebasePrefixes parses a comma separated list of directory prefixes for the results files being compared.
+// Prefixes can be given in the order of the results files (e.g. /mnt/a,/srv/b), or labelled with the name of the
+// results file (with or without its extension) or its position (e.g. old=/mnt/a,new=/srv/b for old.csv and new.csv).
+func rebasePrefixes(spec string, paths []string) ([]string, error) {
+	prefixes := make([]string, len(paths))
+	for i, v := range strings.Split(spec, ",") {
+		idx := strings.Index(v, "=")
+		if idx < 0 {
+			if i >= len(paths) {
+				return nil, fmt.Errorf("too many rebase prefixes; there are %d results files", len(paths))
+			}
+			prefixes[i] = v
+			continue
+		}
+		label, prefix := v[:idx], v[idx+1:]
+		j := -1
+		for k, p := range paths {
+			base := filepath.Base(p)
+			if label == p || label == base || label == strings.TrimSuffix(base, filepath.Ext(base)) || label == strconv.Itoa(k+1) {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			return nil, fmt.Errorf("rebase label %s doesn't match any results file", label)
+		}
+		prefixes[j] = prefix
+	}
+	return prefixes, nil
+}
+
 func savereps() error {
 	file, err := os.Open(config.Reports())
 	if err != nil {
@@ -209,6 +342,13 @@ func savereps() error {
 }
 
 func makegob(s *siegfried.Siegfried, opts []config.Option) error {
+	if err := addIdentifier(s, opts); err != nil {
+		return err
+	}
+	return s.Save(config.HomeFile(config.SignatureBase()))
+}
+
+func addIdentifier(s *siegfried.Siegfried, opts []config.Option) error {
 	var id core.Identifier
 	var err error
 	if *mi != "" {
@@ -217,6 +357,8 @@ func makegob(s *siegfried.Siegfried, opts []config.Option) error {
 		id, err = loc.New(opts...)
 	} else if *wikidata || *wikidataDebug {
 		id, err = wd.New(opts...)
+	} else if *textf {
+		id, err = text.New(opts...)
 	} else {
 		id, err = pronom.New(opts...)
 	}
@@ -231,7 +373,7 @@ func makegob(s *siegfried.Siegfried, opts []config.Option) error {
 	} else {
 		log.Println("Identifier returned nil, not adding to a Siegfried")
 	}
-	return s.Save(config.Signature())
+	return nil
 }
 
 func inspectSig(t core.MatcherType) error {
@@ -309,6 +451,30 @@ func blameSig(i int) error {
 	return err
 }
 
+func exportSig(path string) error {
+	if *exportHome != config.Home() {
+		config.SetHome(*exportHome)
+	}
+	if path != "" {
+		config.SetSignature(path)
+	}
+	s, err := siegfried.Load(config.Signature())
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if *exportOut != "" {
+		out, err = os.Create(*exportOut)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
 func viewReleases() error {
 	xm, err := pronom.LoadReleases(config.Local("release-notes.xml"))
 	if err != nil {
@@ -347,6 +513,9 @@ func getOptions() []config.Option {
 	if *wikidataDebug {
 		opts = append(opts, config.SetWikidataDebug())
 	}
+	if *textf {
+		opts = append(opts, config.SetText())
+	}
 	if *nopronom {
 		opts = append(opts, config.SetNoPRONOM())
 		opts = append(opts, config.SetWikidataNoPRONOM())
@@ -360,6 +529,19 @@ func getOptions() []config.Option {
 	if *extend != "" {
 		opts = append(opts, config.SetExtend(sets.Expand(*extend)))
 	}
+	if *metadata != "" {
+		opts = append(opts, config.SetMetadata(*metadata))
+	}
+	if *class {
+		opts = append(opts, config.SetClass())
+	}
+	if *freq != "" {
+		counts, err := frequency.Open(*freq)
+		if err != nil {
+			log.Fatalf("roy: error reading feedback file %s; got %v", *freq, err)
+		}
+		opts = append(opts, config.SetFrequency(counts))
+	}
 	if *extendc != "" {
 		if *extend == "" {
 			fmt.Println(
@@ -384,6 +566,9 @@ the DROID signature file you should also include a regular signature extension
 	if *noeof {
 		opts = append(opts, config.SetNoEOF())
 	}
+	if *bom {
+		opts = append(opts, config.SetBOM())
+	}
 	if *multi != "" {
 		opts = append(opts, config.SetMulti(strings.ToLower(*multi)))
 	}
@@ -408,6 +593,18 @@ the DROID signature file you should also include a regular signature extension
 	if *noriff {
 		opts = append(opts, config.SetNoRIFF())
 	}
+	if *noebml {
+		opts = append(opts, config.SetNoEBML())
+	}
+	if *nosqlite {
+		opts = append(opts, config.SetNoSQLite())
+	}
+	if *notiff {
+		opts = append(opts, config.SetNoTIFF())
+	}
+	if *nofont {
+		opts = append(opts, config.SetNoFont())
+	}
 	if *noreports {
 		opts = append(opts, config.SetNoReports())
 	}
@@ -525,7 +722,11 @@ func main() {
 				config.SetSignature(build.Arg(0))
 			}
 			s := siegfried.New()
-			err = makegob(s, getOptions())
+			if *manifest != "" {
+				err = makeManifest(s, *manifest)
+			} else {
+				err = makegob(s, getOptions())
+			}
 		}
 	case "add":
 		err = build.Parse(os.Args[2:])
@@ -536,7 +737,11 @@ func main() {
 			var s *siegfried.Siegfried
 			s, err = siegfried.Load(config.Signature())
 			if err == nil {
-				err = makegob(s, getOptions())
+				if *manifest != "" {
+					err = makeManifest(s, *manifest)
+				} else {
+					err = makegob(s, getOptions())
+				}
 			}
 		}
 	case "harvest":
@@ -544,7 +749,7 @@ func main() {
 		if err == nil {
 			setHarvestOptions()
 			if *harvestChanges {
-				err = pronom.GetReleases(config.Local("release-notes.xml"))
+				err = pronom.GetReleases(config.HomeFile("release-notes.xml"))
 			} else if *harvestWikidataSig {
 				err = harvestWikidata()
 			} else {
@@ -569,6 +774,14 @@ func main() {
 				err = inspectSig(core.MIMEMatcher)
 			case input == "riffmatcher", input == "rm":
 				err = inspectSig(core.RIFFMatcher)
+			case input == "ebmlmatcher", input == "em":
+				err = inspectSig(core.EBMLMatcher)
+			case input == "sqlitematcher", input == "sm":
+				err = inspectSig(core.SQLiteMatcher)
+			case input == "tiffmatcher", input == "fm":
+				err = inspectSig(core.TIFFMatcher)
+			case input == "fontmatcher", input == "om":
+				err = inspectSig(core.FontMatcher)
 			case input == "xmlmatcher", input == "xm":
 				err = inspectSig(core.XMLMatcher)
 			case input == "textmatcher", input == "tm":
@@ -622,11 +835,31 @@ func main() {
 				err = pronom.ExtensionSet("pronom-extensions.json")
 			}
 		}
+	case "export":
+		err = exportf.Parse(os.Args[2:])
+		if err == nil {
+			sig := exportf.Arg(0)
+			if exportf.NArg() > 1 {
+				err = exportf.Parse(exportf.Args()[1:]) // permit flags after the signature file e.g. roy export default.sig -o default.json
+			}
+			if err == nil {
+				err = exportSig(sig)
+			}
+		}
 	case "compare":
 		err = comparef.Parse(os.Args[2:])
 		if err == nil {
-			err = reader.Compare(os.Stdout, *compareJoin, comparef.Args()...)
+			err = compare(os.Stdout, comparef.Args())
+		}
+	case "completion":
+		if len(os.Args) != 3 {
+			log.Fatal(usage)
 		}
+		err = cli.Script(os.Stdout, os.Args[2], "roy")
+	case "help":
+		cli.Help(os.Stdout, royCommand(), strings.Join(os.Args[2:], " "))
+	case cli.Hook:
+		complete(os.Stdout, os.Args[2:])
 	default:
 		log.Fatal(usage)
 	}
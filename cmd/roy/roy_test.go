@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"flag"
+	"strings"
 	"testing"
 
 	"github.com/richardlehane/siegfried"
@@ -10,6 +13,7 @@ import (
 	"github.com/richardlehane/siegfried/pkg/mimeinfo"
 	"github.com/richardlehane/siegfried/pkg/pronom"
 	"github.com/richardlehane/siegfried/pkg/sets"
+	"github.com/richardlehane/siegfried/pkg/text"
 	wd "github.com/richardlehane/siegfried/pkg/wikidata"
 )
 
@@ -28,6 +32,62 @@ func TestDefault(t *testing.T) {
 	}
 }
 
+func TestExport(t *testing.T) {
+	s := siegfried.New()
+	config.SetHome(*testhome)
+	p, err := pronom.New(config.Clear())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Add(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byt, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exp map[string]interface{}
+	if err = json.Unmarshal(byt, &exp); err != nil {
+		t.Fatal(err)
+	}
+	if ids, ok := exp["identifiers"].([]interface{}); !ok || len(ids) != 1 {
+		t.Fatalf("expecting a single exported identifier, got %v", exp["identifiers"])
+	}
+}
+
+var testManifest = `# two identifiers in one signature file
+signature: custom.sig
+identifiers:
+  - name: pdfs
+    limit: [fmt/14, fmt/15]
+    nocontainer: true
+  - mi: tika-mimetypes.xml
+    bof: "4096"
+`
+
+func TestManifest(t *testing.T) {
+	m, err := parseManifest(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.signature != "custom.sig" {
+		t.Errorf("expecting signature custom.sig, got %s", m.signature)
+	}
+	if len(m.identifiers) != 2 {
+		t.Fatalf("expecting 2 identifiers, got %d", len(m.identifiers))
+	}
+	if m.identifiers[0][1] != [2]string{"limit", "fmt/14,fmt/15"} {
+		t.Errorf("bad limit setting, got %v", m.identifiers[0][1])
+	}
+	if m.identifiers[1][1] != [2]string{"bof", "4096"} {
+		t.Errorf("bad bof setting, got %v", m.identifiers[1][1])
+	}
+	if _, err = parseManifest(strings.NewReader("identifiers:\n  - home: data\n")); err == nil {
+		t.Error("expecting an error setting home for an individual identifier")
+	}
+}
+
 func TestLoc(t *testing.T) {
 	s := siegfried.New()
 	config.SetHome(*testhome)
@@ -161,3 +221,75 @@ func TestArchivematica(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestGeospatial(t *testing.T) {
+	s := siegfried.New()
+	config.SetHome(*testhome)
+	p, err := pronom.New(config.Clear(), config.SetExtend(sets.Expand("@geospatial")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Add(p); err != nil {
+		t.Fatal(err)
+	}
+	las := make([]byte, 227)
+	copy(las, "LASF")
+	las[24], las[25] = 1, 2
+	// the formats of the extension have priority over PRONOM's HDF5 and LAS formats
+	for _, test := range []struct {
+		name   string
+		byts   []byte
+		expect string
+	}{
+		{"test.nc", append([]byte("\x89HDF\r\n\x1a\n\x00\x00\x00\x00"), []byte("_NCProperties")...), "geo-fmt/7"},
+		{"test.laz", append(las, []byte("\x00\x00laszip encoded\x00\x00")...), "geo-fmt/5"},
+		{"test.las", las, "fmt/370"},
+		{"test.fgb", []byte("fgb\x03fgb\x01\x00"), "geo-fmt/2"},
+	} {
+		ids, err := s.Identify(bytes.NewReader(test.byts), test.name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 1 || ids[0].String() != test.expect {
+			t.Errorf("%s: expecting %s, got %v", test.name, test.expect, ids)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	s := siegfried.New()
+	config.SetHome(*testhome)
+	p, err := pronom.New(config.Clear())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Add(p); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := text.New(config.Clear(), config.SetText())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Add(tx); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name   string
+		byts   []byte
+		expect string
+	}{
+		{"test.csv", []byte("name,size\nsf,100\nroy,200\n"), "x-fmt/18 csv"},
+		{"test", []byte("name: sf\ntools:\n  - roy\n"), "x-fmt/111 yaml"},
+		{"test.txt", []byte("It was a dark and stormy night.\n"), "x-fmt/111 UNKNOWN"},
+		{"test.pdf", []byte("%PDF-1.4\n\x00\x01\x02\xff%%EOF"), "fmt/18 UNKNOWN"},
+	} {
+		ids, err := s.Identify(bytes.NewReader(test.byts), test.name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 2 || ids[0].String()+" "+ids[1].String() != test.expect {
+			t.Errorf("%s: expecting %s, got %v", test.name, test.expect, ids)
+		}
+	}
+	config.Clear()()
+}
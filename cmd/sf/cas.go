@@ -0,0 +1,232 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// casNames are the logical names of the files in the content-addressed stores being scanned. It is nil without -cas.
+var casNames *cas
+
+// cas maps the paths of files in content-addressed stores, where files are kept under names made from their
+// checksums or versions, to the names they are known by, so that results are reported with those names. Two
+// layouts are read:
+//   - OCFL objects (in a storage root or on their own): the content files of an object are named with their logical
+//     paths in the newest version of the object that has them, from the object's inventory.json, e.g.
+//     obj/v2/content/data/a.txt is reported as obj/data/a.txt
+//   - git annex repositories: the objects in .git/annex/objects are named with the paths of the symlinks to them
+//     in the working tree. The symlinks aren't reported (as they would be otherwise, with a file-type error).
+//
+// Paths are kept as sf walks them i.e. joined to the scan root.
+type cas struct {
+	sync.RWMutex
+	names map[string]string // logical names, by the paths of content files
+	links map[string]bool   // annex symlinks, which are skipped
+}
+
+func newCAS() *cas {
+	return &cas{names: make(map[string]string), links: make(map[string]bool)}
+}
+
+// setRoot reads the layout of the stores in, or above, a scan root. It warns if there are none.
+func (c *cas) setRoot(root string) {
+	if c == nil {
+		return
+	}
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		log.Printf("[WARN] -cas can't read %s: %v", root, err)
+		return
+	}
+	// the paths of a store are found from its absolute path, and kept joined to the root
+	rel := func(p string) string {
+		if r, err := filepath.Rel(abs, p); err == nil {
+			return filepath.Join(root, r)
+		}
+		return p
+	}
+	// read the stores before locking, so that the printer can name the files of earlier roots meanwhile
+	found := newCAS()
+	if obj := ancestor(abs, isOCFLObject); obj != "" {
+		found.ocfl(obj, rel)
+	} else {
+		filepath.Walk(abs, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.IsDir() && isOCFLObject(path) {
+				found.ocfl(path, rel)
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	}
+	if repo := ancestor(abs, isAnnex); repo != "" {
+		found.annex(repo, rel)
+	}
+	if len(found.names) == 0 {
+		log.Printf("[WARN] -cas found no OCFL objects or git annex objects in %s", root)
+		return
+	}
+	c.Lock()
+	for k, v := range found.names {
+		c.names[k] = v
+	}
+	for k := range found.links {
+		c.links[k] = true
+	}
+	c.Unlock()
+}
+
+// ancestor returns the first of a directory and its parents that is, or the empty string if none are
+func ancestor(dir string, is func(string) bool) string {
+	for {
+		if is(dir) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// an OCFL object root has a NAMASTE file declaring it e.g. 0=ocfl_object_1.0
+func isOCFLObject(dir string) bool {
+	for _, v := range []string{"1.0", "1.1"} {
+		if _, err := os.Stat(filepath.Join(dir, "0=ocfl_object_"+v)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isAnnex(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ".git", "annex"))
+	return err == nil && info.IsDir()
+}
+
+// ocflInventory is the part of an OCFL object's inventory.json that maps content files to logical paths
+type ocflInventory struct {
+	Manifest map[string][]string `json:"manifest"` // content paths, by digest
+	Versions map[string]struct {
+		State map[string][]string `json:"state"` // logical paths, by digest
+	} `json:"versions"`
+}
+
+// ocfl names the content files of an OCFL object with their logical paths
+func (c *cas) ocfl(obj string, rel func(string) string) {
+	byts, err := ioutil.ReadFile(filepath.Join(obj, "inventory.json"))
+	var inv ocflInventory
+	if err == nil {
+		err = json.Unmarshal(byts, &inv)
+	}
+	if err != nil {
+		log.Printf("[WARN] -cas can't read the OCFL inventory of %s: %v", rel(obj), err)
+		return
+	}
+	// newest version first: version names are v followed by a number, which may be zero-padded e.g. v1 or v001
+	versions := make([]string, 0, len(inv.Versions))
+	for v := range inv.Versions {
+		versions = append(versions, v)
+	}
+	num := func(v string) int {
+		n, _ := strconv.Atoi(strings.TrimPrefix(v, "v"))
+		return n
+	}
+	sort.Slice(versions, func(i, j int) bool { return num(versions[i]) > num(versions[j]) })
+	for digest, contents := range inv.Manifest {
+		for _, v := range versions {
+			logical := inv.Versions[v].State[digest]
+			if len(logical) == 0 {
+				continue
+			}
+			// a file with the same content at several logical paths is named with the first
+			sort.Strings(logical)
+			for _, content := range contents {
+				c.names[rel(filepath.Join(obj, filepath.FromSlash(content)))] = rel(filepath.Join(obj, filepath.FromSlash(logical[0])))
+			}
+			break
+		}
+	}
+}
+
+// annex names the objects of a git annex repository with the paths of the symlinks to them
+func (c *cas) annex(repo string, rel func(string) string) {
+	objects := filepath.Join(repo, ".git", "annex", "objects") + string(filepath.Separator)
+	filepath.Walk(repo, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		if strings.HasPrefix(target, objects) {
+			c.names[rel(target)] = rel(path)
+			c.links[rel(path)] = true
+		}
+		return nil
+	})
+}
+
+// name returns the logical name of a file, or its path if it has none. The contents of archives in a store are
+// named for their archive e.g. obj/v1/content/a.zip#b.txt is obj/a.zip#b.txt.
+func (c *cas) name(path string) string {
+	p := filepath.Clean(path)
+	c.RLock()
+	defer c.RUnlock()
+	if n, ok := c.names[p]; ok {
+		return n
+	}
+	for i := 0; i < len(p); i++ {
+		if p[i] != '#' {
+			continue
+		}
+		if n, ok := c.names[p[:i]]; ok {
+			return n + p[i:]
+		}
+	}
+	return path
+}
+
+// skip reports whether a file found in a walk is a git annex symlink, whose object is reported with its name
+func (c *cas) skip(path string) bool {
+	if c == nil {
+		return false
+	}
+	c.RLock()
+	defer c.RUnlock()
+	return c.links[filepath.Clean(path)]
+}
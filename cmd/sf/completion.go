@@ -0,0 +1,132 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardlehane/siegfried"
+	"github.com/richardlehane/siegfried/internal/cli"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/sets"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+var completionUsage = `
+Usage of completion:
+   sf completion SHELL
+      Write a completion script for bash, zsh, fish or powershell. The script
+      completes sf's subcommands and flags, and values for flags like -log
+      (including the formats of the signature file), -format and -hash.
+      E.g. source <(sf completion bash)
+      Each script has a comment saying how to install it.
+   sf help [TOPIC]
+      List sf's subcommands and, with a topic, the flags about it e.g.
+      sf help archive
+`
+
+// formats lists the formats of the signature file and the format sets (e.g. @pdfa), for completing -log
+func formats() []string {
+	var ret []string
+	if s, err := siegfried.Load(config.Signature()); err == nil {
+		ret = s.Formats()
+	}
+	return append(ret, setNames()...)
+}
+
+// setNames lists the format sets (e.g. @pdfa) in the home directory, or none if it has no sets directory (which
+// sets.Keys would stop sf for)
+func setNames() []string {
+	if _, err := os.Stat(config.Local("sets")); err != nil {
+		return nil
+	}
+	keys := sets.Keys()
+	for i, k := range keys {
+		keys[i] = "@" + k
+	}
+	return keys
+}
+
+// signatures lists the signature files in the home directory, for completing -sig
+func signatures() []string {
+	var ret []string
+	if infos, err := ioutil.ReadDir(config.Home()); err == nil {
+		for _, i := range infos {
+			if filepath.Ext(i.Name()) == ".sig" {
+				ret = append(ret, i.Name())
+			}
+		}
+	}
+	return ret
+}
+
+func sfCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "sf",
+		Flags: flag.CommandLine,
+		Files: true,
+		Values: map[string]cli.Values{
+			"sig":     cli.Lazy(signatures),
+			"profile": cli.List(config.Profiles()...),
+			"format":  cli.List(writer.Names()...),
+			"hash":    cli.List("md5", "sha1", "sha256", "sha512", "crc"),
+			"paths":   cli.List("relative", "absolute", "uri"),
+			"resolve": cli.List("single", "conclusive", "positive", "comprehensive", "exhaustive"),
+			"zs":      cli.CommaList(cli.List(strings.Split(config.ListAllArcTypes(), ", ")...)),
+			"log": cli.CommaList(cli.Join(
+				cli.List("stderr", "stdout", "progress", "time", "error", "warning", "debug", "slow", "unknown", "known", "chart"),
+				cli.Lazy(formats),
+			)),
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:    "inspect",
+				Summary: "check, summarise or convert a results file e.g. sf inspect results.yaml",
+				Flags:   inspectf,
+			},
+			{
+				Name:    "completion",
+				Summary: "write a completion script for a shell e.g. source <(sf completion bash)",
+				Args:    cli.List(cli.Shells...),
+			},
+			{
+				Name:    "help",
+				Summary: "list the subcommands, or the flags about a topic e.g. sf help archive",
+			},
+		},
+	}
+}
+
+// complete writes the completions for the words of a command line, one to a line. Any -home and -sig flags among
+// the words are applied first, so that formats are completed from the signature file the command will load.
+func complete(w io.Writer, words []string) {
+	for i := 0; i < len(words)-2; i++ {
+		switch strings.TrimLeft(words[i], "-") {
+		case "home":
+			config.SetHome(words[i+1])
+		case "sig":
+			config.SetSignature(words[i+1])
+		}
+	}
+	for _, c := range sfCommand().Complete(words) {
+		fmt.Fprintln(w, c)
+	}
+}
@@ -19,8 +19,12 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/richardlehane/siegfried/pkg/config"
@@ -28,9 +32,16 @@ import (
 
 var (
 	// list of flags that can be configured
-	setableFlags = []string{"coe", "csv", "droid", "hash", "json", "log", "multi", "nr", "serve", "sig", "throttle", "yaml", "z"}
+	setableFlags = []string{"amqp", "cache", "cachefile", "cas", "checkpoint", "coe", "csv", "deadline", "droid", "elastic", "filetimeout", "format", "freq", "hash", "json", "kafka", "legacy", "links", "log", "maxbytes", "mem", "mmap", "multi", "nest", "normalise", "nr", "paths", "retry", "retrywait", "serve", "session", "sidecarrules", "sidecars", "sig", "throttle", "timing", "toolsmulti", "tooltime", "utc", "webhook", "yaml", "z"}
+	// list of flags that can only be given on the command line, as they run commands or hold credentials: they are
+	// ignored in conf files (which may be found in a parent of an untrusted directory) and environment variables
+	cmdlineFlags = []string{"postgres", "tools", "webhooksecret"}
 	// list of flags that control output - these are exclusive of each other
-	outputFlags = []string{"csv", "droid", "json", "yaml"}
+	outputFlags = []string{"csv", "droid", "format", "json", "yaml"}
+	// list of flags that can be configured in siegfried.toml and siegfried.yaml files, as well as the setable flags
+	fileFlags = []string{"home", "profile", "sourceinline", "zs"}
+	// names of the config files read by fileconf, in order of preference
+	confNames = []string{"siegfried.toml", "siegfried.yaml", "siegfried.yml"}
 )
 
 // also used in sf_test.go
@@ -43,6 +54,17 @@ func check(s string, ss []string) bool {
 	return false
 }
 
+// explicit reports whether a flag was set on the command line
+func explicit(name string) bool {
+	var ret bool
+	flag.Visit(func(fl *flag.Flag) {
+		if fl.Name == name {
+			ret = true
+		}
+	})
+	return ret
+}
+
 // if -setconf flag set, write settable flags to a conf file. Returns flag names set and an error.
 func setconf() (string, error) {
 	buf := &bytes.Buffer{}
@@ -55,16 +77,16 @@ func setconf() (string, error) {
 		settables = append(settables, fl.Name)
 	})
 	if len(settables) > 0 {
-		return strings.Join(settables, ", "), ioutil.WriteFile(config.Conf(), buf.Bytes(), 0644)
+		return strings.Join(settables, ", "), ioutil.WriteFile(config.HomeFile(config.ConfBase()), buf.Bytes(), 0644)
 	}
 	// no flags - so we delete the conf file if it exists
-	if _, err := os.Stat(config.Conf()); err != nil {
+	if _, err := os.Stat(config.HomeFile(config.ConfBase())); err != nil {
 		if os.IsNotExist(err) {
 			return "", nil
 		}
 		return "", err
 	}
-	return "", os.Remove(config.Conf())
+	return "", os.Remove(config.HomeFile(config.ConfBase()))
 }
 
 // if it exists, read defaults from the conf file.
@@ -84,7 +106,7 @@ func getconf() (map[string]string, error) {
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		kv := strings.SplitN(scanner.Text(), ":", 2)
-		if len(kv) != 2 {
+		if len(kv) != 2 || check(kv[0], cmdlineFlags) {
 			continue
 		}
 		ret[kv[0]] = kv[1]
@@ -92,12 +114,177 @@ func getconf() (map[string]string, error) {
 	return ret, nil
 }
 
-// if it exists, read defaults from the conf file. Overwrite defaults with any flags explictly set
-func readconf() error {
+// fileconf reads defaults from siegfried.toml or siegfried.yaml files: one in the user's home directory and one in
+// the current directory or, failing that, the nearest parent directory with one (a project's config file).
+// Settings in the project's file override those in the user's. Returns the settings and the files read.
+func fileconf() (map[string]string, []string, error) {
+	var paths []string
+	if dir, err := os.UserHomeDir(); err == nil {
+		if p := findconf(dir); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if dir, err := os.Getwd(); err == nil {
+		for {
+			if p := findconf(dir); p != "" {
+				if len(paths) == 0 || paths[0] != p {
+					paths = append(paths, p)
+				}
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	ret := make(map[string]string)
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, paths, err
+		}
+		m, err := parseconf(f, filepath.Ext(p) == ".toml")
+		f.Close()
+		if err != nil {
+			return nil, paths, fmt.Errorf("%s: %v", p, err)
+		}
+		mergeconf(ret, m)
+	}
+	return ret, paths, nil
+}
+
+func findconf(dir string) string {
+	for _, n := range confNames {
+		p := filepath.Join(dir, n)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+// parseconf parses the flat subset of TOML (key = value) or YAML (key: value) used by siegfried config files.
+// Keys are the names of flags e.g. hash = "sha256" or multi: 16. Comments start with #.
+func parseconf(r io.Reader, toml bool) (map[string]string, error) {
+	sep := ":"
+	if toml {
+		sep = "="
+	}
+	ret := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || (!toml && line == "---") {
+			continue
+		}
+		if toml && line[0] == '[' {
+			return nil, fmt.Errorf("line %d: tables aren't supported, settings must be top-level keys", i)
+		}
+		if !toml && (scanner.Text()[0] == ' ' || scanner.Text()[0] == '\t') {
+			return nil, fmt.Errorf("line %d: nested values aren't supported, settings must be top-level keys", i)
+		}
+		kv := strings.SplitN(line, sep, 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: expecting key %s value, got %s", i, sep, line)
+		}
+		k := strings.TrimSpace(kv[0])
+		if check(k, cmdlineFlags) {
+			log.Printf("[WARN] ignoring %s on line %d of a config file, it can only be given on the command line", k, i)
+			continue
+		}
+		if !check(k, setableFlags) && !check(k, fileFlags) {
+			return nil, fmt.Errorf("line %d: %s can't be configured; choose from %s", i, k, strings.Join(append(append([]string{}, setableFlags...), fileFlags...), ", "))
+		}
+		v, err := confValue(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+		ret[k] = v
+	}
+	return ret, scanner.Err()
+}
+
+// confValue unquotes a value and strips any trailing comment
+func confValue(v string) (string, error) {
+	if v == "" {
+		return v, nil
+	}
+	switch v[0] {
+	case '"':
+		end := 1
+		for ; end < len(v); end++ {
+			if v[end] == '\\' {
+				end++
+			} else if v[end] == '"' {
+				break
+			}
+		}
+		if end >= len(v) {
+			return "", fmt.Errorf("unterminated string %s", v)
+		}
+		return strconv.Unquote(v[:end+1])
+	case '\'':
+		end := strings.IndexByte(v[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string %s", v)
+		}
+		return v[1 : end+1], nil
+	}
+	if idx := strings.Index(v, " #"); idx > 0 {
+		v = strings.TrimSpace(v[:idx])
+	}
+	return v, nil
+}
+
+// mergeconf copies settings from src to dst. If src has an output flag, any output flags in dst are removed.
+func mergeconf(dst, src map[string]string) {
+	for k := range src {
+		if check(k, outputFlags) {
+			for _, v := range outputFlags {
+				delete(dst, v)
+			}
+			break
+		}
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// envconf reads defaults from SF_ environment variables (see config.Env), ignoring any that don't set a configurable flag.
+func envconf() map[string]string {
+	env := config.Env()
+	for k := range env {
+		if check(k, cmdlineFlags) {
+			log.Printf("[WARN] ignoring environment variable %s%s, -%s can only be given on the command line", config.EnvPrefix, strings.ToUpper(k), k)
+			delete(env, k)
+			continue
+		}
+		if !check(k, setableFlags) && !check(k, fileFlags) {
+			log.Printf("[WARN] ignoring environment variable %s%s, there is no configurable flag %s", config.EnvPrefix, strings.ToUpper(k), k)
+			delete(env, k)
+		}
+	}
+	return env
+}
+
+// if it exists, read defaults from the conf file. These override the defaults from siegfried.toml or siegfried.yaml files
+// (see fileconf) and are overridden by environment variables (see envconf). Overwrite defaults with any flags explictly set
+func readconf(defaults, env map[string]string) error {
 	confFlags, err := getconf()
-	if len(confFlags) == 0 {
+	if err != nil {
 		return err
 	}
+	merged := make(map[string]string)
+	for _, m := range []map[string]string{defaults, confFlags, env} {
+		mergeconf(merged, m)
+	}
+	confFlags = merged
+	if len(confFlags) == 0 {
+		return nil
+	}
 	// remove conf values for any flags explictly set
 	flag.Visit(func(fl *flag.Flag) {
 		// if an output flag has been explicitly set, delete any that may be in the conf file
@@ -17,17 +17,21 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"log"
 	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/richardlehane/siegfried"
 	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/writer"
 )
 
-var fprflag = flag.Bool("fpr", false, "start siegfried fpr server at "+config.Fpr())
+var fprflag = flag.Bool("fpr", false, "start siegfried fpr server at "+config.Fpr()+"; with -format archivematica, it replies with a JSON document for each file rather than a PUID")
 
 func reply(s string) []byte {
 	if len(s) > 1024 {
@@ -63,7 +67,34 @@ func fpridentify(s *siegfried.Siegfried, path string) []byte {
 	}
 }
 
+// fprjson returns the archivematica output format's JSON document for a file, for Archivematica's
+// "Identify using Siegfried" command
+func fprjson(s *siegfried.Siegfried, path string) []byte {
+	buf := &bytes.Buffer{}
+	w := writer.Archivematica(buf)
+	w.Head(config.SignatureBase(), timestamp(time.Now()), s.C, config.Version(), s.Identifiers(), s.Fields(), "")
+	var sz int64
+	var mod string
+	var ids []core.Identification
+	fi, err := os.Open(path)
+	if err == nil {
+		defer fi.Close()
+		var info os.FileInfo
+		if info, err = fi.Stat(); err == nil {
+			sz, mod = info.Size(), timestamp(info.ModTime()).Format(time.RFC3339)
+			ids, err = s.Identify(fi, path, "")
+		}
+	}
+	w.File(path, sz, mod, nil, err, ids)
+	w.Tail()
+	return buf.Bytes()
+}
+
 func serveFpr(addr string, s *siegfried.Siegfried) {
+	identify := fpridentify
+	if outputFormat().Name == "archivematica" {
+		identify = fprjson
+	}
 	// remove the socket file if it exists
 	if _, err := os.Stat(addr); err == nil {
 		os.Remove(addr)
@@ -76,18 +107,21 @@ func serveFpr(addr string, s *siegfried.Siegfried) {
 	if err != nil {
 		log.Fatalf("FPR error: failed to listen: %v", err)
 	}
-	buf := make([]byte, 4024)
 	for {
 		conn, err := lis.Accept()
 		if err != nil {
 			log.Fatalf("FPR error: bad connection: %v", err)
 		}
-		l, err := conn.Read(buf)
-		if err != nil {
-			conn.Write([]byte("error reading from connection: " + err.Error()))
-		} else {
-			conn.Write(fpridentify(s, string(buf[:l])))
-		}
-		conn.Close()
+		// handle connections concurrently, so that Archivematica can identify the files of a transfer in parallel
+		go func(conn net.Conn) {
+			buf := make([]byte, 4024)
+			l, err := conn.Read(buf)
+			if err != nil {
+				conn.Write([]byte("error reading from connection: " + err.Error()))
+			} else {
+				conn.Write(identify(s, string(buf[:l])))
+			}
+			conn.Close()
+		}(conn)
 	}
 }
@@ -0,0 +1,292 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/reader"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+var inspectUsage = `
+Usage of inspect:
+   sf inspect RESULTS
+      Check that a results file is well-formed and summarise it: the number
+      of files, errors and total size, and the known, unknown and most
+      frequent results for each identifier. Results files can be sf YAML,
+      JSON or CSV output, DROID CSV, fido CSV or file(1) output.
+      E.g. sf inspect results.yaml
+   sf inspect -path FILE RESULTS
+      Extract the record for a single file e.g.
+      sf inspect -path /data/report.pdf results.yaml
+   sf inspect -yaml|-json|-csv|-droid RESULTS
+      Convert a results file to another output format e.g.
+      sf inspect -csv results.yaml > results.csv
+   sf inspect -validate RESULTS
+      Validate sf JSON output against the JSON Schema for its schemaVersion
+      e.g. sf inspect -validate results.json
+   sf inspect -schema
+      Print the JSON Schema for sf's JSON output (or, with -legacy, for JSON
+      output with the legacy layout of matches).
+
+Flags:
+`
+
+var (
+	inspectf        = flag.NewFlagSet("inspect", flag.ExitOnError)
+	inspectPath     = inspectf.String("path", "", "extract the record for the file with this path")
+	inspectYAML     = inspectf.Bool("yaml", false, "write records in YAML output format")
+	inspectCSV      = inspectf.Bool("csv", false, "write records in CSV output format")
+	inspectJSON     = inspectf.Bool("json", false, "write records in JSON output format")
+	inspectDroid    = inspectf.Bool("droid", false, "write records in DROID CSV output format")
+	inspectTop      = inspectf.Int("top", 10, "set the number of most frequent results listed for each identifier (0 for all)")
+	inspectValidate = inspectf.Bool("validate", false, "validate JSON results against the JSON Schema for sf's JSON output")
+	inspectSchema   = inspectf.Bool("schema", false, "print the JSON Schema for sf's JSON output")
+	inspectLegacy   = inspectf.Bool("legacy", false, "write matches with the legacy layout; or print the JSON Schema for the legacy layout")
+)
+
+// tally of the results for an identifier
+type idStats struct {
+	name     string
+	known    int
+	unknown  int
+	multiple int
+	warnings int
+	ids      map[string]int
+}
+
+type stats struct {
+	files  int
+	errors int
+	size   int64
+	ids    []*idStats
+	byName map[string]*idStats
+	paths  map[string]bool
+	dupes  []string
+	issues []string
+}
+
+func newStats(hd reader.Head) *stats {
+	st := &stats{
+		ids:    make([]*idStats, len(hd.Identifiers)),
+		byName: make(map[string]*idStats, len(hd.Identifiers)),
+		paths:  make(map[string]bool),
+	}
+	for i, id := range hd.Identifiers {
+		st.ids[i] = &idStats{name: id[0], ids: make(map[string]int)}
+		st.byName[id[0]] = st.ids[i]
+	}
+	return st
+}
+
+func (st *stats) add(f reader.File) {
+	st.files++
+	if f.Err != nil {
+		st.errors++
+	}
+	if f.Size > 0 {
+		st.size += f.Size
+	}
+	if f.Path == "" {
+		st.issues = append(st.issues, fmt.Sprintf("record %d has no path", st.files))
+	} else if st.paths[f.Path] {
+		st.dupes = append(st.dupes, f.Path)
+	}
+	st.paths[f.Path] = true
+	counts := make(map[*idStats]int)
+	for _, id := range f.Identifications() {
+		is := st.byName[id.Namespace]
+		if is == nil && len(st.ids) == 1 && id.Namespace == "" {
+			is = st.ids[0]
+		}
+		if is == nil {
+			st.issues = append(st.issues, fmt.Sprintf("%s has a result for an identifier that isn't in the header (%s)", f.Path, id.Namespace))
+			continue
+		}
+		counts[is]++
+		if counts[is] > 1 {
+			continue
+		}
+		if id.Known {
+			is.known++
+		} else {
+			is.unknown++
+		}
+		if id.Warning != "" {
+			is.warnings++
+		}
+		is.ids[id.ID]++
+	}
+	for is, c := range counts {
+		if c > 1 {
+			is.multiple++
+		}
+	}
+}
+
+func (st *stats) write(w io.Writer, hd reader.Head, top int) {
+	fmt.Fprintf(w, "results     : %s\n", hd.ResultsPath)
+	if hd.Version != [3]int{} {
+		fmt.Fprintf(w, "siegfried   : %d.%d.%d\n", hd.Version[0], hd.Version[1], hd.Version[2])
+	}
+	if hd.SignaturePath != "" {
+		fmt.Fprintf(w, "signature   : %s\n", hd.SignaturePath)
+	}
+	if !hd.Scanned.IsZero() {
+		fmt.Fprintf(w, "scandate    : %s\n", hd.Scanned.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "files       : %d\n", st.files)
+	fmt.Fprintf(w, "errors      : %d\n", st.errors)
+	fmt.Fprintf(w, "total size  : %d\n", st.size)
+	fmt.Fprint(w, "identifiers : \n")
+	for _, is := range st.ids {
+		fmt.Fprintf(w, "  - name     : %s\n", is.name)
+		fmt.Fprintf(w, "    known    : %d\n", is.known)
+		fmt.Fprintf(w, "    unknown  : %d\n", is.unknown)
+		fmt.Fprintf(w, "    multiple : %d\n", is.multiple)
+		fmt.Fprintf(w, "    warnings : %d\n", is.warnings)
+		fmt.Fprint(w, "    top      : \n")
+		ids := make([]string, 0, len(is.ids))
+		for k := range is.ids {
+			ids = append(ids, k)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			if is.ids[ids[i]] != is.ids[ids[j]] {
+				return is.ids[ids[i]] > is.ids[ids[j]]
+			}
+			return ids[i] < ids[j]
+		})
+		if top > 0 && len(ids) > top {
+			ids = ids[:top]
+		}
+		for _, k := range ids {
+			fmt.Fprintf(w, "      - %s: %d\n", k, is.ids[k])
+		}
+	}
+	if len(st.dupes) > 0 {
+		fmt.Fprintf(w, "duplicates  : %d\n", len(st.dupes))
+	}
+	if len(st.issues) > 0 {
+		fmt.Fprint(w, "issues      : \n")
+		for _, v := range st.issues {
+			fmt.Fprintf(w, "  - %s\n", v)
+		}
+	}
+}
+
+func inspectWriter(w io.Writer, hd reader.Head) (writer.Writer, error) {
+	switch {
+	case *inspectCSV:
+		return writer.CSV(w), nil
+	case *inspectJSON:
+		return writer.JSON(w), nil
+	case *inspectDroid:
+		if len(hd.Fields) != 1 || len(hd.Fields[0]) != 7 {
+			return nil, fmt.Errorf("DROID output is limited to results files with a single PRONOM identifier")
+		}
+		return writer.Droid(w), nil
+	}
+	return writer.YAML(w), nil
+}
+
+// inspect summarises and checks a results file or, given the -path flag or an output format flag, writes its records
+func inspect(w io.Writer, args []string) error {
+	if *inspectLegacy {
+		config.SetLegacy(true)
+	}
+	if *inspectSchema {
+		schema := writer.JSONSchema
+		if *inspectLegacy {
+			schema = writer.LegacyJSONSchema
+		}
+		_, err := w.Write(schema)
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("expecting a single results file argument")
+	}
+	if *inspectValidate {
+		return validate(w, args[0])
+	}
+	rdr, err := reader.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("error reading results file %s; got %v", args[0], err)
+	}
+	defer rdr.Close()
+	hd := rdr.Head()
+	var (
+		wr    writer.Writer
+		st    *stats
+		found bool
+	)
+	if *inspectPath != "" || *inspectYAML || *inspectCSV || *inspectJSON || *inspectDroid {
+		if wr, err = inspectWriter(w, hd); err != nil {
+			return err
+		}
+		wr.Head(hd.SignaturePath, hd.Scanned, hd.Created, hd.Version, hd.Identifiers, hd.Fields, hd.HashHeader)
+	} else {
+		st = newStats(hd)
+	}
+	var n int
+	err = reader.Each(rdr, func(f reader.File) error {
+		n++
+		if st != nil {
+			st.add(f)
+			return nil
+		}
+		if *inspectPath != "" && f.Path != *inspectPath {
+			return nil
+		}
+		found = true
+		var mod string
+		if !f.Mod.IsZero() {
+			mod = f.Mod.Format(time.RFC3339)
+		}
+		wr.File(f.Path, f.Size, mod, f.Hash, f.Err, f.IDs)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("results file %s is malformed at record %d; got %v", args[0], n+1, err)
+	}
+	if st != nil {
+		st.write(w, hd, *inspectTop)
+		return nil
+	}
+	wr.Tail()
+	if *inspectPath != "" && !found {
+		return fmt.Errorf("no record for %s in %s", *inspectPath, args[0])
+	}
+	return nil
+}
+
+func validate(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = writer.ValidateJSON(f); err != nil {
+		return fmt.Errorf("%s is not valid sf JSON output; %v", path, err)
+	}
+	fmt.Fprintf(w, "%s is valid sf JSON output\n", path)
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exitLimit is the exit status of a scan stopped by its -deadline or -maxbytes limit
+const exitLimit = 3
+
+// errLimit stops a walk when the scan reaches its -deadline or -maxbytes limit
+var errLimit = errors.New("scan limit reached")
+
+// scanLimits are the -deadline and -maxbytes limits of a scan and the checkpoint it resumes from (see -resume).
+// It is nil if there are no limits and the scan isn't resumed.
+var scanLimits *limits
+
+// a checkpoint records the last file started by a scan that was stopped by a limit. Files are walked in lexical
+// order, so a scan of the same files can resume after it.
+type checkpoint struct {
+	Root    string    `json:"root"`  // the file or directory argument (or line of a -f list) being scanned
+	Path    string    `json:"path"`  // the last file started
+	Files   int       `json:"files"` // the number of files started before the scan stopped
+	Bytes   int64     `json:"bytes"` // their total size
+	Limit   string    `json:"limit"` // the limit reached: deadline or maxbytes
+	Stopped time.Time `json:"stopped"`
+}
+
+type limits struct {
+	deadline time.Time // zero for no deadline
+	maxBytes int64     // zero for no limit
+	resume   *checkpoint
+	past     bool   // the walk is past the checkpoint being resumed
+	root     string // the root being walked
+	last     checkpoint
+}
+
+func newLimits(deadline time.Duration, maxBytes int64, resume *checkpoint) *limits {
+	l := &limits{maxBytes: maxBytes, resume: resume}
+	if resume != nil { // a scan stopped before it starts a file checkpoints where it resumed
+		l.last.Root, l.last.Path = resume.Root, resume.Path
+	}
+	if deadline > 0 {
+		l.deadline = time.Now().Add(deadline)
+	}
+	return l
+}
+
+// setRoot sets the file or directory argument being walked. Methods of a nil *limits do nothing.
+func (l *limits) setRoot(root string) {
+	if l == nil {
+		return
+	}
+	// the roots after the checkpoint's root are past it, even if its path was the root's last file
+	if l.resume != nil && l.root == l.resume.Root && root != l.root {
+		l.past = true
+	}
+	l.root = root
+}
+
+// skip reports whether a path was scanned before the checkpoint being resumed: a path of a root before the
+// checkpoint's root, or of its root up to and including the checkpoint's path.
+func (l *limits) skip(path string) bool {
+	if l == nil || l.resume == nil || l.past {
+		return false
+	}
+	if l.root != l.resume.Root || !after(path, l.resume.Path) {
+		return true
+	}
+	l.past = true
+	return false
+}
+
+// start counts a file about to be identified, or returns errLimit if the scan has reached a limit
+func (l *limits) start(path string, sz int64) error {
+	if l == nil {
+		return nil
+	}
+	switch {
+	case l.last.Limit != "":
+	case !l.deadline.IsZero() && !time.Now().Before(l.deadline):
+		l.last.Limit = "deadline"
+	case l.maxBytes > 0 && l.last.Bytes >= l.maxBytes:
+		l.last.Limit = "maxbytes"
+	default:
+		l.last.Root, l.last.Path = l.root, path
+		l.last.Files++
+		l.last.Bytes += sz
+		return nil
+	}
+	return errLimit
+}
+
+// stopped reports whether the scan was stopped by a limit
+func (l *limits) stopped() bool {
+	return l != nil && l.last.Limit != ""
+}
+
+// save writes a checkpoint, for -resume, to a file
+func (l *limits) save(path string) error {
+	l.last.Stopped = time.Now().UTC()
+	byts, err := json.MarshalIndent(l.last, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, byts, 0644)
+}
+
+// loadCheckpoint reads a checkpoint written by a scan stopped by a limit. It returns nil if the scan stopped before
+// it started a file.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	byts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &checkpoint{}
+	if err = json.Unmarshal(byts, c); err != nil {
+		return nil, err
+	}
+	if c.Path == "" { // the scan stopped before it started a file
+		return nil, nil
+	}
+	return c, nil
+}
+
+// after reports whether path a comes after path b in a lexical walk: their elements are compared in turn, so that
+// a directory's contents come before the entries that follow the directory (e.g. a/b before a.txt)
+func after(a, b string) bool {
+	as, bs := strings.Split(filepath.ToSlash(a), "/"), strings.Split(filepath.ToSlash(b), "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] > bs[i]
+		}
+	}
+	return len(as) > len(bs)
+}
+
+// parseBytes parses a number of bytes with an optional unit: K, M, G, T or P, with or without B or iB, for
+// multiples of 1024 e.g. 2TB
+func parseBytes(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "B"), "I")
+	mult := int64(1)
+	if l := len(str); l > 0 {
+		if i := strings.IndexByte("KMGTP", str[l-1]); i >= 0 {
+			mult = 1 << (10 * uint(i+1))
+			str = strings.TrimSpace(str[:l-1])
+		}
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 || n*float64(mult) > float64(1<<62) {
+		return 0, fmt.Errorf("invalid number of bytes %q; give a number with an optional unit e.g. 500GB or 2TB", s)
+	}
+	return int64(n * float64(mult)), nil
+}
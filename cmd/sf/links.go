@@ -0,0 +1,81 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"sync"
+)
+
+// the field added to each identifier's matches for the link group of hardlinked files (see -links)
+const linkField = "link"
+
+// a linkGroup is a set of hardlinked files. The first file of the group found in the walk is identified, and the
+// printer copies its result to the others.
+type linkGroup struct {
+	name string // name of the identified file, as written in output
+	res  results
+}
+
+// linkGroups are the groups of hardlinked files found so far, by device and inode. It is nil without -links.
+var linkGroups *links
+
+type links struct {
+	sync.Mutex
+	groups map[linkKey]*linkGroup
+}
+
+type linkKey struct {
+	dev uint64
+	ino uint64
+}
+
+func newLinks() *links {
+	return &links{groups: make(map[linkKey]*linkGroup)}
+}
+
+// group returns the link group of a file, and whether the file is the first of its group. Files that have no
+// other links are in no group (nil).
+func (l *links) group(info os.FileInfo) (*linkGroup, bool) {
+	key, ok := hardlink(info)
+	if !ok {
+		return nil, false
+	}
+	l.Lock()
+	defer l.Unlock()
+	if g, ok := l.groups[key]; ok {
+		return g, false
+	}
+	g := &linkGroup{}
+	l.groups[key] = g
+	return g, true
+}
+
+// identifyLinked identifies a file found in a walk, unless it is a hardlink to a file that has already been
+// identified (with -links), in which case the printer writes the result of that file for it
+func identifyLinked(ctx *context, info os.FileInfo, ctxts chan *context, gf getFn) {
+	if linkGroups != nil {
+		if g, first := linkGroups.group(info); g != nil {
+			ctx.link = g
+			if !first {
+				ctx.copy = true
+				ctx.wg.Add(1)
+				ctxts <- ctx
+				return
+			}
+		}
+	}
+	identifyFile(ctx, ctxts, gf)
+}
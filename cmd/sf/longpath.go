@@ -18,8 +18,9 @@ package main
 
 import (
 	"os"
-	"path/filepath"
 	"time"
+
+	"github.com/richardlehane/siegfried/pkg/walk"
 )
 
 func retryOpen(path string, err error) (*os.File, error) {
@@ -31,33 +32,45 @@ func retryStat(path string, err error) (os.FileInfo, error) {
 }
 
 func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bool, gf getFn) error {
-	walkFunc := func(path string, info os.FileInfo, err error) error {
+	opts := walk.Options{Dirs: droid, Retry: backoff}
+	if norecurse {
+		opts.MaxDepth = 1
+	}
+	return walk.Dir(root, opts, func(e walk.Entry) error {
 		if *throttlef > 0 {
 			<-throttle.C
 		}
-		if err != nil {
+		if e.Err != nil {
+			// walk errors are written as results, even when they are fatal
+			printFile(ctxts, gf(e.Path, "", time.Time{}, 0), WalkError{e.Path, e.Err})
 			if coerr {
-				printFile(ctxts, gf(path, "", time.Time{}, 0), WalkError{path, err})
 				return nil
 			}
-			return WalkError{path, err}
+			return WalkError{e.Path, e.Err}
+		}
+		info := e.Info
+		if scanLimits.skip(e.Path) { // scanned before the checkpoint being resumed
+			return nil
 		}
 		if info.IsDir() {
-			if norecurse && path != root {
-				return filepath.SkipDir
-			}
-			if droid {
-				printFile(ctxts, gf(path, "", info.ModTime(), -1), nil)
-			}
+			printFile(ctxts, gf(e.Path, "", info.ModTime(), -1), nil)
 			return nil
 		}
-		// zero user read permissions mask, octal 400 (decimal 256)
-		if !info.Mode().IsRegular() || info.Mode()&256 == 0 {
-			printFile(ctxts, gf(path, "", info.ModTime(), info.Size()), ModeError(info.Mode()))
+		if casNames.skip(e.Path) { // a git annex symlink, whose object is reported with its name
 			return nil
 		}
-		identifyFile(gf(path, "", info.ModTime(), info.Size()), ctxts, gf)
+		if !scannable(info.Mode()) {
+			printFile(ctxts, gf(e.Path, "", info.ModTime(), info.Size()), ModeError(info.Mode()))
+			return nil
+		}
+		if alloc, ok := sparse(info); ok && !*specialf {
+			printFile(ctxts, gf(e.Path, "", info.ModTime(), info.Size()), SparseError{info.Size(), alloc})
+			return nil
+		}
+		if err := scanLimits.start(e.Path, info.Size()); err != nil {
+			return err
+		}
+		identifyLinked(gf(e.Path, "", info.ModTime(), info.Size()), info, ctxts, gf)
 		return nil
-	}
-	return filepath.Walk(root, walkFunc)
+	})
 }
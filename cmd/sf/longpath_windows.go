@@ -75,6 +75,9 @@ func retryOpen(path string, err error) (*os.File, error) {
 	return file, nil
 }
 
+// the number of times directories that couldn't be read have been walked again, after transient errors
+var dirTries = make(map[string]int)
+
 func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bool, gf getFn) error {
 	walkFunc := func(path string, info os.FileInfo, err error) error {
 		var retry bool
@@ -82,11 +85,28 @@ func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bo
 		if *throttlef > 0 {
 			<-throttle.C
 		}
+		if err != nil && transient(err) {
+			if info != nil && info.IsDir() { // walk a directory that couldn't be read again
+				dirTries[path]++
+				if backoff(err, dirTries[path]) {
+					if e := identify(ctxts, path, orig, coerr, norecurse, droid, gf); e != nil {
+						return e
+					}
+					return filepath.SkipDir
+				}
+			} else {
+				err = retryTransient(func() (e error) {
+					info, e = os.Lstat(path)
+					return e
+				})
+			}
+		}
 		if err != nil {
 			info, err = retryStat(path, err) // retry stat in case is a windows long path error
 			if err != nil {
+				// walk errors are written as results, even when they are fatal
+				printFile(ctxts, gf(path, "", time.Time{}, 0), WalkError{path, err})
 				if coerr {
-					printFile(ctxts, gf(path, "", time.Time{}, 0), WalkError{path, err})
 					return nil
 				}
 				return WalkError{path, err}
@@ -94,6 +114,12 @@ func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bo
 			lp, sp = longpath(path), path
 			retry = true
 		}
+		if scanLimits.skip(shortpath(path, orig)) { // scanned before the checkpoint being resumed
+			if info.IsDir() && norecurse && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if info.IsDir() {
 			if norecurse && path != root {
 				return filepath.SkipDir
@@ -101,16 +127,22 @@ func identify(ctxts chan *context, root, orig string, coerr, norecurse, droid bo
 			if retry { // if a dir long path, restart the recursion with a long path as the new root
 				return identify(ctxts, lp, sp, coerr, norecurse, droid, gf)
 			}
-			if droid {
+			if droid && (path != root || dirTries[path] == 0) { // a directory walked again has already been reported
 				printFile(ctxts, gf(shortpath(path, orig), "", info.ModTime(), -1), nil)
 			}
 			return nil
 		}
-		if !info.Mode().IsRegular() {
+		if casNames.skip(path) { // a git annex symlink, whose object is reported with its name
+			return nil
+		}
+		if !info.Mode().IsRegular() && !(*specialf && special(info.Mode())) {
 			printFile(ctxts, gf(path, "", info.ModTime(), info.Size()), ModeError(info.Mode()))
 			return nil
 		}
-		identifyFile(gf(shortpath(path, orig), "", info.ModTime(), info.Size()), ctxts, gf)
+		if err := scanLimits.start(shortpath(path, orig), info.Size()); err != nil {
+			return err
+		}
+		identifyLinked(gf(shortpath(path, orig), "", info.ModTime(), info.Size()), info, ctxts, gf)
 		return nil
 	}
 	return filepath.Walk(root, walkFunc)
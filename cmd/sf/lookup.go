@@ -0,0 +1,133 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/reader"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// lookups answers -lookup queries: the results for files with given checksums, from the results of an earlier scan
+// (a results file or a -cachefile) rather than from reading the files. This suits content-addressed stores, where a
+// file's checksum is its name.
+type lookups struct {
+	hd    *reader.Head           // the head of a results file, or nil for a results cache
+	files map[string]reader.File // the results in a results file, by hex checksum
+	cache *siegfried.Cache
+}
+
+// loadLookups loads the results to look up from a results file (in any format sf can replay, with checksums) or,
+// failing that, from a results cache made with the signature file s and the hash algorithm hash.
+func loadLookups(path string, s *siegfried.Siegfried, hash string) (*lookups, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rdr, rerr := reader.New(f, path)
+	if rerr != nil {
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if hash == "" {
+			return nil, fmt.Errorf("not a results file (%v); to look up checksums in a results cache, give the -hash it was made with", rerr)
+		}
+		c, err := siegfried.LoadCache(f, s, hash)
+		if errors.Is(err, siegfried.ErrStaleCache) {
+			return nil, fmt.Errorf("the results cache was made with a different signature file, settings or -hash; give the -sig, -hash and identification flags (e.g. -noext) it was made with")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("not a results file (%v) or a results cache (%v)", rerr, err)
+		}
+		return &lookups{cache: c}, nil
+	}
+	hd := rdr.Head()
+	if hd.HashHeader == "" {
+		return nil, fmt.Errorf("the results file has no checksums; scan with -hash to make results that can be looked up")
+	}
+	if hash != "" && hash != hd.HashHeader {
+		return nil, fmt.Errorf("the results file has %s checksums, not %s", hd.HashHeader, hash)
+	}
+	l := &lookups{hd: &hd, files: make(map[string]reader.File)}
+	err = reader.Each(rdr, func(rf reader.File) error {
+		k := strings.ToLower(string(rf.Hash))
+		if _, ok := l.files[k]; k != "" && !ok { // duplicates have the same content, so keep the first
+			l.files[k] = rf
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// head writes the head of the output: the head of the results file or, for a results cache, the details of the
+// signature file
+func (l *lookups) head(w writer.Writer, s *siegfried.Siegfried, hash string) {
+	if l.hd != nil {
+		w.Head(l.hd.SignaturePath, timestamp(l.hd.Scanned), l.hd.Created, l.hd.Version, l.hd.Identifiers, l.hd.Fields, l.hd.HashHeader)
+		return
+	}
+	w.Head(config.SignatureBase(), timestamp(time.Now()), s.C, config.Version(), s.Identifiers(), s.Fields(), hash)
+}
+
+// lookup writes the results for a hex checksum, or for each of the checksums (one to a line) in stdin if sum is "-".
+// A file found in a results file is written with its name, size and modified time; a file found in a results cache
+// (which doesn't keep them) is named for its checksum. A checksum that isn't found is written with a not-found error.
+func (l *lookups) lookup(sum string, ctxts chan *context) error {
+	if sum == "-" {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				l.lookup(line, ctxts)
+			}
+		}
+		return scanner.Err()
+	}
+	cs, err := hex.DecodeString(sum)
+	if err != nil {
+		printFile(ctxts, getCtx(sum, "", time.Time{}, 0), fmt.Errorf("not a hex checksum: %v", err))
+		return nil
+	}
+	res := results{cs: cs}
+	ctx := getCtx(sum, "", time.Time{}, 0)
+	if l.cache != nil {
+		var ok bool
+		if res.ids, ok = l.cache.Lookup(cs); !ok {
+			res.err = writer.ClassifyError(writer.ClassNotFound, fmt.Errorf("no results for checksum %s", sum))
+		}
+	} else if rf, ok := l.files[strings.ToLower(sum)]; ok {
+		ctx.path, ctx.mod, ctx.sz = rf.Path, rf.Mod, rf.Size
+		res.err, res.ids = rf.Err, rf.IDs
+	} else {
+		res.err = writer.ClassifyError(writer.ClassNotFound, fmt.Errorf("no results for checksum %s", sum))
+	}
+	ctx.res <- res
+	ctx.wg.Add(1)
+	ctxts <- ctx
+	return nil
+}
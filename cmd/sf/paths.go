@@ -0,0 +1,116 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// options for the -paths flag
+const (
+	pathsRelative = "relative" // relative to the scan root, with / separators
+	pathsAbsolute = "absolute" // absolute, with the OS's separators
+	pathsURI      = "uri"      // file:// URIs, and DROID-style URIs for the contents of archives e.g. zip:file:///a/b.zip!/c.txt
+)
+
+// the root of the current scan, recorded in contexts so that paths can be made relative to it (see getCtx)
+var scanRoot string
+
+func setScanRoot(root string) {
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+	scanRoot = root
+}
+
+// pathFormatter rewrites paths for output. It is used by the printer, which sees archives before their contents,
+// so it can split the paths of archive contents (e.g. a/b.zip#c/d.txt) at the archives it has seen.
+type pathFormatter struct {
+	mode string
+	arcs map[string]config.Archive
+}
+
+func newPathFormatter(mode string) *pathFormatter {
+	return &pathFormatter{mode: mode, arcs: make(map[string]config.Archive)}
+}
+
+func (pf *pathFormatter) format(root, path string, ids []core.Identification) string {
+	if pf.mode == "" {
+		return path
+	}
+	for _, id := range ids {
+		if id.Archive() > config.None {
+			pf.arcs[path] = id.Archive()
+			break
+		}
+	}
+	parts, arcs := pf.split(path)
+	switch pf.mode {
+	case pathsRelative:
+		if root != "" {
+			if rel, err := filepath.Rel(root, parts[0]); err == nil {
+				parts[0] = rel
+			}
+		}
+		return filepath.ToSlash(strings.Join(parts, "#"))
+	case pathsAbsolute:
+		if abs, err := filepath.Abs(parts[0]); err == nil {
+			parts[0] = abs
+		}
+		for i := range parts[1:] {
+			parts[i+1] = filepath.FromSlash(parts[i+1])
+		}
+		return strings.Join(parts, "#")
+	}
+	abs, err := filepath.Abs(parts[0])
+	if err != nil {
+		abs = parts[0]
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") { // windows drives e.g. C:/a
+		abs = "/" + abs
+	}
+	uri := (&url.URL{Scheme: "file", Path: abs}).String()
+	for i, arc := range arcs {
+		uri = strings.ToLower(arc.String()) + ":" + uri + "!" + (&url.URL{Path: "/" + filepath.ToSlash(parts[i+1])}).EscapedPath()
+	}
+	return uri
+}
+
+// split the path of an archive's contents into the path of the outermost archive and the paths within each archive
+func (pf *pathFormatter) split(path string) ([]string, []config.Archive) {
+	var (
+		parts []string
+		arcs  []config.Archive
+		start int
+	)
+	for i := 0; i < len(path); i++ {
+		if path[i] != '#' {
+			continue
+		}
+		if arc, ok := pf.arcs[path[:i]]; ok {
+			parts = append(parts, path[start:i])
+			arcs = append(arcs, arc)
+			start = i + 1
+		}
+	}
+	return append(parts, path[start:]), arcs
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/richardlehane/siegfried"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/reader"
+)
+
+// with sf -replay -sig, replayed files are identified again with the new signature file if their results are
+// unknown or their formats have changed since the signature file of the results file
+var reidentify *reidentifier
+
+var errStaleSig = errors.New("it has been changed since the results were made")
+
+type reidentifier struct {
+	s       *siegfried.Siegfried
+	changed map[string]map[[2]string]bool // formats changed since the signature files of results files, keyed by signature file path and created time
+}
+
+func newReidentifier(s *siegfried.Siegfried) *reidentifier {
+	return &reidentifier{s: s, changed: make(map[string]map[[2]string]bool)}
+}
+
+// changes gets the formats (identifier names and format IDs) that have changed since the signature file of a results file.
+// It is nil if that signature file can't be found (e.g. it has since been updated), in which case every file is
+// identified again.
+func (r *reidentifier) changes(hd reader.Head) map[[2]string]bool {
+	key := hd.SignaturePath + " " + hd.Created.Format(time.RFC3339)
+	if ch, ok := r.changed[key]; ok {
+		return ch
+	}
+	var ch map[[2]string]bool
+	old, err := siegfried.Load(config.Local(hd.SignaturePath))
+	if err == nil && !old.C.Truncate(time.Second).Equal(hd.Created.Truncate(time.Second)) {
+		err = errStaleSig
+	}
+	var changes []siegfried.Change
+	if err == nil {
+		changes, err = siegfried.Diff(old, r.s)
+	}
+	if err != nil {
+		log.Printf("[WARN] can't compare the signature file of results file %s (%s, created %s) with %s: %v; identifying all of its files again",
+			hd.ResultsPath, hd.SignaturePath, hd.Created.Format(time.RFC3339), config.SignatureBase(), err)
+	} else {
+		ch = make(map[[2]string]bool)
+		for _, c := range changes {
+			ch[[2]string{c.Identifier, c.ID}] = true
+		}
+	}
+	r.changed[key] = ch
+	return ch
+}
+
+// needs reports whether a file with the given results should be identified again: if it has no known result or if any
+// of its formats have changed. A nil changes means everything has changed.
+func (r *reidentifier) needs(changes map[[2]string]bool, ids []core.Identification) bool {
+	if changes == nil {
+		return true
+	}
+	known := false
+	for _, id := range ids {
+		if !id.Known() {
+			continue
+		}
+		known = true
+		if changes[[2]string{id.Values()[0], id.String()}] {
+			return true
+		}
+	}
+	return !known
+}
+
+// file identifies a replayed file again, if it is a file on disk. Directories, files inside archives and files that
+// have been moved or deleted keep their previous results.
+func (r *reidentifier) file(rf reader.File, ctxts chan *context) bool {
+	info, err := os.Lstat(rf.Path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	identifyFile(getCtx(rf.Path, "", info.ModTime(), info.Size()), ctxts, getCtx)
+	return true
+}
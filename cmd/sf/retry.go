@@ -0,0 +1,37 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "time"
+
+// backoff reports whether an access that failed with err should be tried again, after waiting.
+// Only transient errors are retried, up to -retry times, with the wait (-retrywait) doubling each time.
+// Tries counts the failed attempts so far. It can be used as a walk.Options Retry function.
+func backoff(err error, tries int) bool {
+	if tries > *retryf || !transient(err) {
+		return false
+	}
+	time.Sleep(*retrywaitf << uint(tries-1))
+	return true
+}
+
+// retryTransient calls fn until it succeeds or backoff gives up, and returns fn's last error
+func retryTransient(fn func() error) error {
+	err := fn()
+	for tries := 1; err != nil && backoff(err, tries); tries++ {
+		err = fn()
+	}
+	return err
+}
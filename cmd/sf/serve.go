@@ -20,6 +20,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -54,60 +55,27 @@ func parseRequest(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfried
 	paramsErr := func(field, expect string) (error, string, writer.Writer, bool, bool, bool, checksum.HashTyp, *siegfried.Siegfried, getFn) {
 		return fmt.Errorf("bad request; in param %s got %s; valid values %s", field, r.FormValue(field), expect), "", nil, false, false, false, -1, nil, nil
 	}
-	var (
-		mime string
-		wr   writer.Writer
-		d    bool
-		frmt int
-	)
-	switch {
-	case *jsono:
-		frmt = 1
-	case *csvo:
-		frmt = 2
-	case *droido:
-		frmt = 3
-	}
+	frmt := outputFormat()
 	if v := r.FormValue("format"); v != "" {
-		switch v {
-		case "yaml":
-			frmt = 0
-		case "json":
-			frmt = 1
-		case "csv":
-			frmt = 2
-		case "droid":
-			frmt = 3
-		default:
-			return paramsErr("format", "yaml, json, csv or droid")
+		var ok bool
+		if frmt, ok = writer.Lookup(v); !ok {
+			return paramsErr("format", strings.Join(writer.Names(), ", "))
 		}
 	}
 	if accept := r.Header.Get("Accept"); accept != "" {
-		switch accept {
-		case "application/x-yaml":
-			frmt = 0
-		case "application/json":
-			frmt = 1
-		case "text/csv", "application/csv":
-			frmt = 2
-		case "application/x-droid":
-			frmt = 3
+		if accept == "application/csv" {
+			accept = "text/csv"
+		}
+		for _, reg := range writer.Registered() {
+			if reg.MIME != "" && reg.MIME == accept {
+				frmt = reg
+				break
+			}
 		}
 	}
-	switch frmt {
-	case 0:
-		wr = writer.YAML(w)
-		mime = "application/x-yaml"
-	case 1:
-		wr = writer.JSON(w)
-		mime = "application/json"
-	case 2:
-		wr = writer.CSV(w)
-		mime = "text/csv"
-	case 3:
-		wr = writer.Droid(w)
-		d = true
-		mime = "application/x-droid"
+	wr, d, mime := frmt.New(w), frmt.Name == "droid" || frmt.Name == "droidfile", frmt.MIME
+	if mime == "" {
+		mime = "application/octet-stream"
 	}
 	// no recurse
 	norec := *nr
@@ -164,7 +132,7 @@ func parseRequest(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfried
 	}
 	gf := func(path, mime string, mod time.Time, sz int64) *context {
 		c := ctxPool.Get().(*context)
-		c.path, c.mime, c.mod, c.sz = path, mime, mod, sz
+		c.path, c.mime, c.mod, c.sz, c.budget = path, mime, mod, sz, nil
 		c.s, c.wg, c.w, c.d, c.z, c.h = sf, wg, wr, d, z, checksum.MakeHash(ht)
 		return c
 	}
@@ -199,7 +167,7 @@ func handleIdentify(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfri
 			sz = r.ContentLength
 		}
 		w.Header().Set("Content-Type", mime)
-		wr.Head(config.SignatureBase(), time.Now(), sf.C, config.Version(), sf.Identifiers(), sf.Fields(), ht.String())
+		wr.Head(config.SignatureBase(), timestamp(time.Now()), sf.C, config.Version(), sf.Identifiers(), sf.Fields(), ht.String())
 		wg.Add(1)
 		ctx := gf(h.Filename, "", mod, sz)
 		ctxts <- ctx
@@ -217,7 +185,7 @@ func handleIdentify(w http.ResponseWriter, r *http.Request, s *siegfried.Siegfri
 			return
 		}
 		w.Header().Set("Content-Type", mime)
-		wr.Head(config.SignatureBase(), time.Now(), sf.C, config.Version(), sf.Identifiers(), sf.Fields(), ht.String())
+		wr.Head(config.SignatureBase(), timestamp(time.Now()), sf.C, config.Version(), sf.Identifiers(), sf.Fields(), ht.String())
 		err = identify(ctxts, path, "", coerr, nrec, d, gf)
 		wg.Wait()
 		wr.Tail()
@@ -0,0 +1,58 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// newSession describes this run of sf for the session block of the output (see -session)
+func newSession() (*writer.Session, error) {
+	sess := &writer.Session{}
+	sess.Host, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		sess.User = u.Username
+	} else if sess.User = os.Getenv("USER"); sess.User == "" {
+		sess.User = os.Getenv("USERNAME")
+	}
+	args := make([]string, len(os.Args))
+	for i, a := range os.Args {
+		if a == "" || strings.ContainsAny(a, " \t\n\"'\\") {
+			a = strconv.Quote(a)
+		}
+		args[i] = a
+	}
+	sess.Command = strings.Join(args, " ")
+	f, err := os.Open(config.Signature())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	sess.SigHash = hex.EncodeToString(h.Sum(nil))
+	return sess, nil
+}
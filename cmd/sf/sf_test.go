@@ -2,18 +2,27 @@ package main
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/richardlehane/siegfried"
 	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
 	"github.com/richardlehane/siegfried/pkg/pronom"
+	"github.com/richardlehane/siegfried/pkg/writer"
 )
 
 var (
@@ -308,6 +317,12 @@ func BenchmarkTIF(bench *testing.B) {
 	}
 }
 
+func BenchmarkWAV(bench *testing.B) {
+	for i := 0; i < bench.N; i++ {
+		benchidentify("wav")
+	}
+}
+
 func BenchmarkXLSX(bench *testing.B) {
 	for i := 0; i < bench.N; i++ {
 		benchidentify("xlsx")
@@ -321,8 +336,685 @@ func BenchmarkXML(bench *testing.B) {
 }
 
 func BenchmarkMulti(bench *testing.B) {
+	setup()
 	dir := filepath.Join(*testdata, "benchmark")
 	for i := 0; i < bench.N; i++ {
 		multiIdentifyT(s, dir)
 	}
 }
+
+// identify the benchmark files as streams (e.g. sf -), so that the BOF and EOF can't be read independently
+func BenchmarkMultiStream(bench *testing.B) {
+	setup()
+	files, err := filepath.Glob(filepath.Join(*testdata, "benchmark", "Benchmark.*"))
+	if err != nil {
+		bench.Fatal(err)
+	}
+	bufs := make([][]byte, len(files))
+	for i, f := range files {
+		if bufs[i], err = ioutil.ReadFile(f); err != nil {
+			bench.Fatal(err)
+		}
+	}
+	bench.ReportAllocs()
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		for j, buf := range bufs {
+			if _, err := s.Identify(struct{ io.Reader }{bytes.NewReader(buf)}, files[j], ""); err != nil {
+				bench.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	results := filepath.Join("..", "..", "pkg", "reader", "examples", "multi", "multi.yaml")
+	buf := &bytes.Buffer{}
+	if err := inspect(buf, []string{results}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "files       : 20\n") || !strings.Contains(buf.String(), "- fdd000132: 20\n") {
+		t.Fatalf("unexpected summary: %s", buf.String())
+	}
+	buf.Reset()
+	*inspectPath, *inspectCSV = "hpap/hpapa1_oc.doc", true
+	defer func() { *inspectPath, *inspectCSV = "", false }()
+	if err := inspect(buf, []string{results}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expecting a header and a record, got: %s", buf.String())
+	}
+	for _, l := range lines[1:] {
+		if !strings.HasPrefix(l, "hpap/hpapa1_oc.doc,") {
+			t.Fatalf("expecting only the record for hpap/hpapa1_oc.doc, got: %s", l)
+		}
+	}
+	*inspectPath = "missing.doc"
+	if err := inspect(ioutil.Discard, []string{results}); err == nil {
+		t.Fatal("expecting an error for a missing record")
+	}
+}
+
+type testArc struct{ core.Identification }
+
+func (t testArc) Archive() config.Archive { return config.Zip }
+
+func TestPaths(t *testing.T) {
+	root := filepath.Join("data", "scan")
+	arc := filepath.Join(root, "a b", "c.zip")
+	abs, _ := filepath.Abs(arc)
+	uri := strings.Replace(filepath.ToSlash(abs), " ", "%20", -1)
+	if !strings.HasPrefix(uri, "/") {
+		uri = "/" + uri
+	}
+	tests := []struct {
+		mode   string
+		expect []string
+	}{
+		{pathsRelative, []string{"a b/c.zip", "a b/c.zip#d/e.txt"}},
+		{pathsAbsolute, []string{abs, abs + "#" + filepath.FromSlash("d/e.txt")}},
+		{pathsURI, []string{"file://" + uri, "zip:file://" + uri + "!/d/e.txt"}},
+	}
+	for _, tt := range tests {
+		pf := newPathFormatter(tt.mode)
+		if got := pf.format(root, arc, []core.Identification{testArc{}}); got != tt.expect[0] {
+			t.Errorf("%s: expecting %s, got %s", tt.mode, tt.expect[0], got)
+		}
+		if got := pf.format(root, arc+"#d/e.txt", nil); got != tt.expect[1] {
+			t.Errorf("%s: expecting %s, got %s", tt.mode, tt.expect[1], got)
+		}
+	}
+	// # in a file name that isn't an archive
+	if got := newPathFormatter(pathsRelative).format(root, filepath.Join(root, "x#y.txt"), nil); got != "x#y.txt" {
+		t.Errorf("expecting x#y.txt, got %s", got)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	loc := time.FixedZone("AEDT", 11*60*60)
+	tm := time.Date(2020, 1, 2, 10, 0, 0, 0, loc)
+	if got := timestamp(tm); got.Location() != time.Local {
+		t.Errorf("expecting a local timestamp, got %s", got)
+	}
+	*utcf = true
+	defer func() { *utcf = false }()
+	if got := timestamp(tm).Format(time.RFC3339); got != "2020-01-01T23:00:00Z" {
+		t.Errorf("expecting a UTC timestamp, got %s", got)
+	}
+}
+
+func TestParseConf(t *testing.T) {
+	toml := "# defaults\nhash = \"sha256\" # checksums\nmulti = 16\nz = true\nhome = '/usr/share/siegfried'\n"
+	yml := "---\nhash: sha256\nmulti: 16 # threads\nz: true\nhome: \"/usr/share/siegfried\"\n"
+	for i, in := range []string{toml, yml} {
+		m, err := parseconf(strings.NewReader(in), i == 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(m) != 4 || m["hash"] != "sha256" || m["multi"] != "16" || m["z"] != "true" || m["home"] != "/usr/share/siegfried" {
+			t.Errorf("bad parse of %s, got %v", in, m)
+		}
+	}
+	for i, bad := range []string{"[server]\nserve = \"localhost:5138\"\n", "server:\n  serve: localhost:5138\n", "bogus = 1\n", "hash = \"sha256\n"} {
+		if _, err := parseconf(strings.NewReader(bad), i%2 == 0); err == nil {
+			t.Errorf("expecting an error parsing %s", bad)
+		}
+	}
+	// flags that run commands or hold credentials are ignored
+	for i, in := range []string{"tools = \"tools.conf\"\nhash = \"md5\"\n", "postgres: postgres://host/db\nwebhooksecret: s\nhash: md5\n"} {
+		m, err := parseconf(strings.NewReader(in), i == 0)
+		if err != nil || len(m) != 1 || m["hash"] != "md5" {
+			t.Errorf("expecting only hash from %s, got %v (%v)", in, m, err)
+		}
+	}
+	os.Setenv("SF_TOOLS", "tools.conf")
+	defer os.Unsetenv("SF_TOOLS")
+	if _, ok := envconf()["tools"]; ok {
+		t.Error("expecting SF_TOOLS to be ignored")
+	}
+	// output flags in a project's file replace those in a user's
+	dst := map[string]string{"json": "true", "hash": "md5"}
+	mergeconf(dst, map[string]string{"csv": "true"})
+	if len(dst) != 2 || dst["csv"] != "true" || dst["hash"] != "md5" {
+		t.Errorf("bad merge, got %v", dst)
+	}
+}
+
+func TestSkipped(t *testing.T) {
+	if writer.ErrorClass(ModeError(os.ModeNamedPipe)) != writer.ClassSkipped || writer.ErrorClass(ModeError(os.ModeSymlink)) != writer.ClassFileType {
+		t.Error("bad error classes for special files")
+	}
+	if scannable(os.ModeNamedPipe | 0644) {
+		t.Error("expecting named pipes to be skipped")
+	}
+	*specialf = true
+	defer func() { *specialf = false }()
+	if !scannable(os.ModeNamedPipe | 0644) {
+		t.Error("expecting named pipes to be read with -special")
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	f, err := ioutil.TempFile("", "sparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	err = f.Truncate(sparseMin * 2)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sparse(info); !ok {
+		t.Skip("file system doesn't support sparse files")
+	}
+	if _, ok := sparse(fileInfo{info, sparseMin - 1}); ok {
+		t.Error("expecting files smaller than sparseMin not to be sparse")
+	}
+}
+
+func TestFileTimeout(t *testing.T) {
+	if writer.ErrorClass(TimeoutError(time.Second)) != writer.ClassTimeout {
+		t.Error("bad error class for a timeout")
+	}
+	var none *claim
+	c := &claim{}
+	if !none.result() || !c.timeout() || c.result() {
+		t.Error("expecting a result to be sent without a timeout, and a claimed timeout to stop the result")
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// opening a named pipe blocks until it has a writer
+	fifo := filepath.Join(t.TempDir(), "stalled")
+	if err := exec.Command("mkfifo", fifo).Run(); err != nil {
+		t.Skipf("can't make a named pipe: %v", err)
+	}
+	ctx := &context{path: fifo, res: make(chan results, 1)}
+	readFileTimeout(ctx, nil, getCtx, 10*time.Millisecond)
+	res := <-ctx.res
+	if _, ok := res.err.(TimeoutError); !ok || !ctx.abandoned {
+		t.Errorf("expecting a timeout error for an abandoned read, got %v", res.err)
+	}
+}
+
+// fileInfo overrides the size of a file
+type fileInfo struct {
+	os.FileInfo
+	sz int64
+}
+
+func (fi fileInfo) Size() int64 { return fi.sz }
+
+func TestRetry(t *testing.T) {
+	*retryf, *retrywaitf = 2, time.Millisecond
+	defer func() { *retryf, *retrywaitf = 0, time.Second }()
+	hiccup := &os.PathError{Op: "open", Path: "share/file", Err: transients[0]}
+	for _, test := range []struct {
+		err   error
+		fails int // number of times the access fails
+		calls int
+	}{
+		{hiccup, 1, 2},
+		{hiccup, 2, 3},
+		{hiccup, 5, 3}, // gives up after 2 retries
+		{os.ErrNotExist, 5, 1},
+	} {
+		var calls int
+		err := retryTransient(func() error {
+			calls++
+			if calls <= test.fails {
+				return test.err
+			}
+			return nil
+		})
+		if calls != test.calls || (err == nil) != (test.fails < calls) {
+			t.Errorf("%v failing %d times: expecting %d calls, got %d calls and %v", test.err, test.fails, test.calls, calls, err)
+		}
+	}
+}
+
+type testMatch struct{ id string }
+
+func (t testMatch) String() string          { return t.id }
+func (t testMatch) Known() bool             { return t.id != "UNKNOWN" }
+func (t testMatch) Warn() string            { return "" }
+func (t testMatch) Values() []string        { return []string{"pronom", t.id} }
+func (t testMatch) Archive() config.Archive { return config.None }
+
+func TestTools(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to run tools with")
+	}
+	dir, err := ioutil.TempDir("", "sftools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	script := filepath.Join(dir, "tool.sh")
+	rules := filepath.Join(dir, "tools.conf")
+	ioutil.WriteFile(script, []byte("echo checked $0\nexit 3\n"), 0644)
+	ioutil.WriteFile(rules, []byte("# comment\n\nfmt/43,fmt/44 "+sh+" {}\nfmt/44 no-such-tool {}\n"), 0644)
+	tl, err := loadTools(rules, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := tl.run(script, []core.Identification{testMatch{"fmt/43"}, testMatch{"fmt/12"}})
+	if v := ids[0].Values(); len(v) != 3 || v[2] != "sh exit 3: checked "+script {
+		t.Errorf("unexpected tools summary %q", v)
+	}
+	if v := ids[1].Values(); len(v) != 3 || v[2] != "" {
+		t.Errorf("expecting an empty tools summary for a match without tools, got %q", v)
+	}
+	ids = tl.run(script, []core.Identification{testMatch{"fmt/44"}})
+	if v := ids[0].Values()[2]; !strings.HasPrefix(v, "sh exit 3: checked ") || !strings.Contains(v, "; no-such-tool failed: ") {
+		t.Errorf("unexpected tools summary %q", v)
+	}
+	if ids = tl.run("", []core.Identification{testMatch{"fmt/43"}}); ids[0].Values()[2] != "" {
+		t.Errorf("expecting no tools for a file without a path, got %q", ids[0].Values()[2])
+	}
+	ioutil.WriteFile(rules, []byte("fmt/43\n"), 0644)
+	if _, err := loadTools(rules, 2); err == nil {
+		t.Error("expecting an error for a rule without a command")
+	}
+}
+
+func TestSign(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "sfsign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	key, sig := filepath.Join(dir, "key.pem"), filepath.Join(dir, "results.sig")
+	ioutil.WriteFile(key, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	sgn, err := loadSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(sgn, "---\nsiegfried   : 1.9.0\n")
+	fmt.Fprint(sgn, "---\nfilename : 'example.doc'\n")
+	if err = sgn.sign(sig); err != nil {
+		t.Fatal(err)
+	}
+	byts, _ := ioutil.ReadFile(sig)
+	if !ed25519.Verify(pub, []byte("---\nsiegfried   : 1.9.0\n---\nfilename : 'example.doc'\n"), byts) {
+		t.Error("expecting the signature to verify")
+	}
+	ioutil.WriteFile(key, []byte("not a key"), 0600)
+	if _, err = loadSigner(key); err == nil {
+		t.Error("expecting an error for a bad key file")
+	}
+}
+
+func TestLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sflinks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a, b, c := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"), filepath.Join(dir, "c.txt")
+	ioutil.WriteFile(a, []byte("hello"), 0644)
+	ioutil.WriteFile(c, []byte("hello"), 0644)
+	if err := os.Link(a, b); err != nil {
+		t.Skipf("can't make a hardlink: %v", err)
+	}
+	l := newLinks()
+	info := func(path string) os.FileInfo {
+		fi, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi
+	}
+	ga, first := l.group(info(a))
+	if ga == nil {
+		t.Skip("hardlinks aren't detected on this platform")
+	}
+	if !first {
+		t.Error("expecting a.txt to be the first of its link group")
+	}
+	if gb, first := l.group(info(b)); gb != ga || first {
+		t.Error("expecting b.txt to be in the link group of a.txt")
+	}
+	if gc, _ := l.group(info(c)); gc != nil {
+		t.Error("expecting no link group for a file without links")
+	}
+	ids := addValue([]core.Identification{testMatch{"fmt/43"}}, a)
+	if v := ids[0].Values(); len(v) != 3 || v[2] != a {
+		t.Errorf("expecting a link field, got %q", v)
+	}
+}
+
+func TestSidecars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sfsidecars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, n := range []string{"roads.shp", "roads.SHX", "roads.dbf", "roads.shp.xml", "lone.shp", "other.dbf",
+		"img.tif", "img.tif.aux.xml", "plain.tif", "clip001.dv", "clip002.dv", "clip005.dv", "clip.dv", "single1.dv"} {
+		ioutil.WriteFile(filepath.Join(dir, n), []byte("hello"), 0644)
+	}
+	rules, err := loadSidecarRules("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newSidecars(rules)
+	ids := []core.Identification{testMatch{"fmt/43"}}
+	for _, test := range []struct {
+		name   string
+		expect string
+	}{
+		{"roads.shp", "shapefile roads.shp"},
+		{"roads.SHX", "shapefile roads.shp"},
+		{"roads.shp.xml", "shapefile roads.shp"},
+		{"lone.shp", "shapefile lone.shp (missing .shx, .dbf)"},
+		{"other.dbf", ""},
+		{"img.tif.aux.xml", "georeferenced raster img.tif"},
+		{"img.tif", "georeferenced raster img.tif"},
+		{"plain.tif", ""},
+		{"clip002.dv", "dv sequence clip001.dv (missing clip003.dv, clip004.dv)"},
+		{"clip.dv", ""},
+		{"single1.dv", ""},
+	} {
+		if got := s.add(filepath.Join(dir, test.name), test.name, ids); got != test.expect {
+			t.Errorf("%s: expecting sidecar set %q, got %q", test.name, test.expect, got)
+		}
+	}
+	if got := s.add("", "", ids); got != "" {
+		t.Errorf("expecting no sidecar set for a file without a path, got %q", got)
+	}
+	groups := s.records()
+	if len(groups) != 4 || groups[0].Primary != "roads.shp" || len(groups[0].Files) != 3 || groups[0].Files[0].ID != "fmt/43" ||
+		groups[3].Primary != "clip001.dv" || len(groups[3].Files) != 1 {
+		t.Errorf("unexpected groups %+v", groups)
+	}
+	if _, err = parseSidecarRules(strings.NewReader("bad = .seq : # .idx\n")); err == nil {
+		t.Error("expecting an error for a sequence rule with other members")
+	}
+	if _, err = parseSidecarRules(strings.NewReader("# comment\n\nnoequals .shp : .dbf\n")); err == nil {
+		t.Error("expecting an error for a rule without a name")
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	config.SetHome(*testhome)
+	defer config.Reset()()
+	byts, err := ioutil.ReadFile(config.Signature())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log := changelog(byts, ""); log != "- no formats added, changed or removed\n" {
+		t.Errorf("expecting no changes for the installed signature file, got %q", log)
+	}
+	p, err := pronom.New(config.Clear(), config.SetLimit([]string{"fmt/1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited := siegfried.New()
+	if err = limited.Add(p); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "limited.sig")
+	if err = limited.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if byts, err = ioutil.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	log := changelog(byts, "  ")
+	if !strings.HasPrefix(log, "  - pronom: 0 added, 0 changed, ") || !strings.Contains(log, "\n    - removed fmt/43 (JPEG File Interchange Format)\n") {
+		t.Errorf("expecting formats removed from pronom, got %.200q", log)
+	}
+}
+
+func TestReidentify(t *testing.T) {
+	r := newReidentifier(nil)
+	changes := map[[2]string]bool{{"pronom", "fmt/43"}: true}
+	for _, c := range []struct {
+		id     string
+		expect bool
+	}{{"fmt/43", true}, {"fmt/44", false}, {"UNKNOWN", true}} {
+		if r.needs(changes, []core.Identification{testMatch{c.id}}) != c.expect {
+			t.Errorf("expecting %t for a file identified as %s", c.expect, c.id)
+		}
+	}
+	if !r.needs(nil, []core.Identification{testMatch{"fmt/44"}}) {
+		t.Error("expecting every file to be identified again without a diff")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, c := range []struct {
+		in     string
+		expect int64
+	}{{"100", 100}, {"2TB", 2 << 40}, {"1.5k", 1536}, {"500 GiB", 500 << 30}} {
+		if n, err := parseBytes(c.in); err != nil || n != c.expect {
+			t.Errorf("%s: expecting %d bytes, got %d %v", c.in, c.expect, n, err)
+		}
+	}
+	if _, err := parseBytes("2XB"); err == nil {
+		t.Error("expecting an error for an unknown unit")
+	}
+	if !after("a.txt", filepath.Join("a", "b")) || !after(filepath.Join("a", "b"), "a") || after("a", "a") {
+		t.Error("expecting a directory's contents before the entries after it")
+	}
+	// a scan stopped by -maxbytes, and a scan that resumes it
+	l := newLimits(0, 10, nil)
+	l.setRoot("dir")
+	var started []string
+	for _, p := range []string{"dir/a", "dir/b", "dir/c"} {
+		if l.skip(p) {
+			continue
+		}
+		if err := l.start(p, 6); err != nil {
+			break
+		}
+		started = append(started, p)
+	}
+	if !l.stopped() || strings.Join(started, ",") != "dir/a,dir/b" || l.last.Path != "dir/b" || l.last.Limit != "maxbytes" {
+		t.Fatalf("expecting the scan to stop after dir/b, got %v and checkpoint %v", started, l.last)
+	}
+	cp := l.last
+	l = newLimits(0, 0, &cp)
+	started = started[:0]
+	for _, r := range [][]string{{"a", "a/x"}, {"dir", "dir/a", "dir/b", "dir/c"}, {"z", "z/y"}} {
+		l.setRoot(r[0])
+		for _, p := range r[1:] {
+			if !l.skip(p) && l.start(p, 6) == nil {
+				started = append(started, p)
+			}
+		}
+	}
+	if strings.Join(started, ",") != "dir/c,z/y" || l.stopped() {
+		t.Errorf("expecting the resumed scan to start after dir/b, got %v", started)
+	}
+}
+
+func TestTUI(t *testing.T) {
+	if got := strings.Join(keys([]byte("j\x1b[B\x1b\r\x7f\x1b[5~/é\x03")), " "); got != "j down esc enter back pgup / é ctrl-c" {
+		t.Errorf("unexpected keys %q", got)
+	}
+	tv := newTUI()
+	tv.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{{"namespace", "id"}}, "md5")
+	tv.File("/data/a.pdf", 10, "", nil, nil, []core.Identification{testMatch{"fmt/18"}})
+	tv.File("/data/b.zip", 20, "", nil, nil, []core.Identification{testArc{testMatch{"x-fmt/263"}}})
+	tv.File("/data/b.zip#c/d#e.txt", 5, "", nil, nil, []core.Identification{testMatch{"UNKNOWN"}})
+	tv.File("/data/x/f.txt", 0, "", nil, errors.New("empty source"), []core.Identification{testMatch{"x-fmt/111"}})
+	tv.Tail()
+	tv.dir = tv.start()
+	if tv.dir.path() != "/data" {
+		t.Fatalf("expecting to start at /data, got %s", tv.dir.path())
+	}
+	rows := func() string {
+		var r []string
+		for _, row := range tv.rows() {
+			r = append(r, row.text)
+		}
+		return strings.Join(r, "|")
+	}
+	if got := rows(); got != "b.zip#/  1 file, 1 unknown|x/  1 file, 1 error|a.pdf  fmt/18|b.zip  x-fmt/263" {
+		t.Errorf("unexpected tree %q", got)
+	}
+	// the contents of the archive
+	tv.key("enter")
+	if got := tv.dir.path() + " " + rows(); got != "/data/b.zip# c/  1 file, 1 unknown" {
+		t.Errorf("unexpected archive %q", got)
+	}
+	tv.key("enter")
+	if got := rows(); got != "d#e.txt  UNKNOWN" {
+		t.Errorf("unexpected archive directory %q", got)
+	}
+	tv.key("enter")
+	if tv.view != fileView || tv.detail.path != "/data/b.zip#c/d#e.txt" {
+		t.Fatalf("expecting the file view for d#e.txt, got %v", tv.detail)
+	}
+	tv.key("left")
+	tv.key("left")
+	tv.key("left")
+	if tv.dir.path() != "/data" || tv.sel != 0 {
+		t.Errorf("expecting to be back at /data with b.zip# selected, got %s %d", tv.dir.path(), tv.sel)
+	}
+	// filters
+	for _, test := range []struct{ keys, expect string }{
+		{"e", "x/  1 file, 1 error"},
+		{"u", "b.zip#/  1 file, 1 unknown"},
+		{"/ f m t / 1 8 enter", "a.pdf  fmt/18"},
+		{"/ f m t / 1 enter", "no files match the filter (c to clear it)"},
+		{"/ @ n o n e enter", "no files match the filter (c to clear it)"},
+		{"c", "b.zip#/  1 file, 1 unknown|x/  1 file, 1 error|a.pdf  fmt/18|b.zip  x-fmt/263"},
+	} {
+		for _, k := range strings.Split(test.keys, " ") {
+			tv.key(k)
+		}
+		lines, _ := tv.render(80, 10)
+		if got := strings.Join(lines[1:len(lines)-2], "|"); !strings.HasPrefix(got, " "+strings.Replace(test.expect, "|", "| ", -1)) {
+			t.Errorf("%s: expecting %q, got %q", test.keys, test.expect, got)
+		}
+	}
+	// formats, most frequent first, and filtering on a format
+	tv.key("tab")
+	if got := rows(); !strings.HasPrefix(got, "fmt/18 ") || strings.Count(got, "|") != 2 {
+		t.Errorf("unexpected formats %q", got)
+	}
+	tv.key("enter")
+	if tv.view != treeView || tv.filter != "fmt/18" || rows() != "a.pdf  fmt/18" {
+		t.Errorf("expecting a tree filtered on fmt/18, got %s %q", tv.filter, rows())
+	}
+	lines, _ := tv.render(40, 6)
+	if len(lines) != 6 || lines[4] != "scanned: 4 files (35 B), 1 unknown, 1 er" {
+		t.Errorf("unexpected screen %q", lines)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	buf := &bytes.Buffer{}
+	w := writer.YAML(buf)
+	w.Head("default.sig", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{{"namespace", "id"}}, "md5")
+	w.File("a.pdf", 10, "", []byte{0xab, 0xcd}, nil, []core.Identification{testMatch{"fmt/18"}})
+	w.File("b.pdf", 10, "", []byte{0xab, 0xcd}, nil, []core.Identification{testMatch{"fmt/18"}})
+	w.File("c.txt", 5, "", []byte{0x01}, nil, []core.Identification{testMatch{"x-fmt/111"}})
+	w.Tail()
+	results := filepath.Join(dir, "results.yaml")
+	if err := ioutil.WriteFile(results, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := loadLookups(results, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.files) != 2 || l.files["abcd"].Path != "a.pdf" || l.files["01"].IDs[0].String() != "x-fmt/111" {
+		t.Errorf("expecting the first results for each checksum, got %v", l.files)
+	}
+	if _, err = loadLookups(results, nil, "sha1"); err == nil {
+		t.Error("expecting an error looking up sha1 checksums in results with md5 checksums")
+	}
+	// a results cache
+	sf := siegfried.New()
+	c := siegfried.NewCache("md5")
+	c.Put([]byte{0xab, 0xcd}, "a.pdf", "", []core.Identification{testMatch{"fmt/18"}})
+	buf.Reset()
+	if err = c.Save(buf, sf); err != nil {
+		t.Fatal(err)
+	}
+	cache := filepath.Join(dir, "sf.cache")
+	if err = ioutil.WriteFile(cache, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = loadLookups(cache, sf, ""); err == nil {
+		t.Error("expecting an error loading a results cache without a -hash")
+	}
+	if l, err = loadLookups(cache, sf, "md5"); err != nil {
+		t.Fatal(err)
+	}
+	if ids, ok := l.cache.Lookup([]byte{0xab, 0xcd}); !ok || ids[0].String() != "fmt/18" {
+		t.Errorf("expecting fmt/18 from the results cache, got %v", ids)
+	}
+}
+
+func TestCAS(t *testing.T) {
+	dir := t.TempDir()
+	write := func(p, content string) {
+		p = filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an OCFL storage root with an object with two versions
+	write("store/0=ocfl_1.1", "ocfl_1.1\n")
+	write("store/ab/obj/0=ocfl_object_1.1", "ocfl_object_1.1\n")
+	write("store/ab/obj/v1/content/a.pdf", "%PDF-1.4")
+	write("store/ab/obj/v2/content/b.txt", "hello")
+	write("store/ab/obj/inventory.json", `{"id":"obj","head":"v2",
+"manifest":{"d1":["v1/content/a.pdf"],"d2":["v2/content/b.txt"],"d3":["v1/content/gone.txt"]},
+"versions":{"v1":{"state":{"d1":["a.pdf"],"d3":["gone.txt"]}},"v2":{"state":{"d1":["docs/a.pdf"],"d2":["z.txt","b.txt"]}}}}`)
+	c := newCAS()
+	c.setRoot(filepath.Join(dir, "store"))
+	obj := filepath.Join(dir, "store", "ab", "obj")
+	for _, n := range []struct{ path, expect string }{
+		{filepath.Join(obj, "v1", "content", "a.pdf"), filepath.Join(obj, "docs", "a.pdf")},
+		{filepath.Join(obj, "v2", "content", "b.txt"), filepath.Join(obj, "b.txt")},
+		{filepath.Join(obj, "v1", "content", "gone.txt"), filepath.Join(obj, "gone.txt")},
+		{filepath.Join(obj, "v1", "content", "a.pdf") + "#c/d.txt", filepath.Join(obj, "docs", "a.pdf") + "#c/d.txt"},
+		{filepath.Join(obj, "inventory.json"), filepath.Join(obj, "inventory.json")},
+	} {
+		if got := c.name(n.path); got != n.expect {
+			t.Errorf("expecting %s to be named %s, got %s", n.path, n.expect, got)
+		}
+	}
+	// a git annex repository, scanned from its objects directory
+	write("repo/.git/annex/objects/Xx/Yy/KEY.txt/KEY.txt", "hello")
+	link := filepath.Join(dir, "repo", "docs", "hello.txt")
+	os.MkdirAll(filepath.Dir(link), 0755)
+	if err := os.Symlink("../.git/annex/objects/Xx/Yy/KEY.txt/KEY.txt", link); err != nil {
+		t.Skipf("can't make a symlink: %v", err)
+	}
+	objects := filepath.Join(dir, "repo", ".git", "annex", "objects")
+	c.setRoot(objects)
+	if got := c.name(filepath.Join(objects, "Xx", "Yy", "KEY.txt", "KEY.txt")); got != filepath.Join(objects, "..", "..", "..", "docs", "hello.txt") {
+		t.Errorf("expecting the annex object to be named for its symlink, got %s", got)
+	}
+	c.setRoot(filepath.Join(dir, "repo"))
+	if !c.skip(link) {
+		t.Error("expecting the annex symlink to be skipped")
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// the field added to each identifier's matches for the sidecar set a file is in (see -sidecars)
+const sidecarField = "sidecar"
+
+// defaultSidecarRules are the rules used by -sidecars, unless a rules file is given with -sidecarrules
+const defaultSidecarRules = `shapefile = .shp : .shx! .dbf! .prj .cpg .qix .sbn .sbx .fbn .fbx .ain .aih .atx .ixs .mxs +.xml
+mapinfo = .tab : .dat .map .id .ind
+georeferenced raster = .tif .tiff .jpg .jpeg .png .gif .bmp .jp2 : .wld .tfw .tifw .jgw .jpgw .pgw .pngw .gfw .bpw .j2w +.aux.xml +.ovr
+cue sheet = .cue : .bin .img .iso .wav .flac .ape
+raw image = .cr2 .cr3 .crw .nef .nrw .arw .srf .sr2 .dng .orf .raf .rw2 .pef .srw .x3f .3fr .iiq : .xmp +.xmp
+dv sequence = .dv : #
+dpx sequence = .dpx : #
+`
+
+// a sidecarRule describes a sidecar set: a format made of a primary file and the files beside it that share its
+// name (e.g. the .shx, .dbf and .prj files of a shapefile), or a sequence of numbered files. Extensions are lower
+// case and include the dot.
+type sidecarRule struct {
+	name     string
+	primary  []string // extensions of the primary file
+	members  []string // extensions that replace the primary's e.g. roads.dbf for roads.shp
+	suffixes []string // suffixes added to the primary's name e.g. roads.shp.xml
+	required []string // members a set must have; a set with no required members needs at least one member
+	sequence bool     // the set is files with a primary extension whose names differ only in a trailing number
+}
+
+// parseSidecarRules reads sidecar rules. Each line of a rules file has the name of a set, an equals sign, the
+// extensions of the set's primary file, a colon, then the extensions of the other files of the set. Members marked
+// with ! are required, members that start with + are added to the primary's name rather than replacing its
+// extension, and a # member makes the set a sequence of numbered files. Blank lines and lines starting with # are
+// ignored. E.g.
+//
+//	# a shapefile must have .shx and .dbf files
+//	shapefile = .shp : .shx! .dbf! .prj .cpg +.xml
+//	dv sequence = .dv : #
+func parseSidecarRules(r io.Reader) ([]sidecarRule, error) {
+	var rules []sidecarRule
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		eq, col := strings.Index(line, "="), strings.LastIndex(line, ":")
+		if eq < 1 || col < eq {
+			return nil, fmt.Errorf("line %d: expecting a name = primary extensions : member extensions, got %q", n, line)
+		}
+		rule := sidecarRule{
+			name:    strings.TrimSpace(line[:eq]),
+			primary: strings.Fields(strings.ToLower(line[eq+1 : col])),
+		}
+		for _, p := range rule.primary {
+			if !strings.HasPrefix(p, ".") {
+				return nil, fmt.Errorf("line %d: expecting an extension starting with a dot, got %q", n, p)
+			}
+		}
+		members := strings.Fields(strings.ToLower(line[col+1:]))
+		for _, m := range members {
+			switch {
+			case m == "#":
+				rule.sequence = true
+			case strings.HasPrefix(m, "+."):
+				rule.suffixes = append(rule.suffixes, m[1:])
+			case strings.HasPrefix(m, "."):
+				if strings.HasSuffix(m, "!") {
+					m = strings.TrimSuffix(m, "!")
+					rule.required = append(rule.required, m)
+				}
+				rule.members = append(rule.members, m)
+			default:
+				return nil, fmt.Errorf("line %d: expecting an extension starting with a dot, or #, got %q", n, m)
+			}
+		}
+		if len(rule.primary) == 0 || len(members) == 0 || (rule.sequence && len(members) > 1) {
+			return nil, fmt.Errorf("line %d: expecting primary and member extensions (or just #, for a sequence), got %q", n, line)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+func loadSidecarRules(path string) ([]sidecarRule, error) {
+	if path == "" {
+		return parseSidecarRules(strings.NewReader(defaultSidecarRules))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rules, err := parseSidecarRules(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s %v", path, err)
+	}
+	return rules, nil
+}
+
+// sidecarSets groups the files of a scan into sidecar sets. It is nil without -sidecars.
+var sidecarSets *sidecars
+
+type sidecars struct {
+	sync.Mutex
+	rules  []sidecarRule
+	dirs   map[string]map[string]string // the names of the files in each directory, by their lower case names
+	groups map[string]*sidecarGroup     // the sets found so far, by directory, rule and stem
+	order  []*sidecarGroup              // the sets, in the order they were found
+}
+
+// a sidecarGroup is a sidecar set in a directory, and the files of it that have been identified
+type sidecarGroup struct {
+	rule    *sidecarRule
+	primary string   // the name of the primary file
+	missing []string // required members that are missing, or the missing numbers of a sequence
+	files   []writer.GroupFile
+	desc    string // the value of the sidecar field for the files of the set
+}
+
+func newSidecars(rules []sidecarRule) *sidecars {
+	return &sidecars{rules: rules, dirs: make(map[string]map[string]string), groups: make(map[string]*sidecarGroup)}
+}
+
+// names lists the files of a directory. It must be called with the lock held.
+func (s *sidecars) names(dir string) map[string]string {
+	if names, ok := s.dirs[dir]; ok {
+		return names
+	}
+	names := make(map[string]string)
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() {
+				names[strings.ToLower(e.Name())] = e.Name()
+			}
+		}
+	}
+	s.dirs[dir] = names
+	return names
+}
+
+// add adds a file to the sidecar set it is in, if it is in one, and returns the set's description e.g.
+// "shapefile roads.shp (missing .prj)". Path is the path of the file on disk (empty for streams and the contents of
+// archives, which are in no set) and name is the name of the file as written in output.
+func (s *sidecars) add(path, name string, ids []core.Identification) string {
+	if path == "" {
+		return ""
+	}
+	s.Lock()
+	defer s.Unlock()
+	g := s.group(path)
+	if g == nil {
+		return ""
+	}
+	var id string
+	if len(ids) > 0 {
+		id = ids[0].String()
+	}
+	g.files = append(g.files, writer.GroupFile{Name: name, ID: id})
+	return g.desc
+}
+
+// group finds the set a file is in. It must be called with the lock held.
+func (s *sidecars) group(path string) *sidecarGroup {
+	dir, base := filepath.Split(path)
+	lower := strings.ToLower(base)
+	for i := range s.rules {
+		r := &s.rules[i]
+		for _, stem := range r.stems(lower) {
+			key := dir + "\x00" + strconv.Itoa(i) + "\x00" + stem
+			g, ok := s.groups[key]
+			if !ok {
+				// cache sets that aren't found too, as finding a sequence lists all the files of a directory
+				g = r.group(s.names(dir), stem)
+				s.groups[key] = g
+				if g != nil {
+					s.order = append(s.order, g)
+				}
+			}
+			if g != nil {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// stems returns the stems of the sets a file could be in: the lower case name of the set's primary file, less its
+// extension (or, for a sequence, less its number and extension)
+func (r *sidecarRule) stems(lower string) []string {
+	if ext := r.ext(lower); ext != "" {
+		stem := lower[:len(lower)-len(ext)]
+		if r.sequence {
+			num := stem
+			if stem = strings.TrimRight(stem, "0123456789"); stem == num {
+				return nil // no number
+			}
+		}
+		return []string{stem}
+	}
+	var stems []string
+	for _, m := range r.members {
+		if strings.HasSuffix(lower, m) {
+			stems = append(stems, lower[:len(lower)-len(m)])
+		}
+	}
+	for _, sfx := range r.suffixes {
+		if full := strings.TrimSuffix(lower, sfx); full != lower {
+			if ext := r.ext(full); ext != "" {
+				stems = append(stems, full[:len(full)-len(ext)])
+			}
+		}
+	}
+	return stems
+}
+
+// ext returns the extension of a primary file, or an empty string if the name isn't a primary file's
+func (r *sidecarRule) ext(lower string) string {
+	for _, p := range r.primary {
+		if strings.HasSuffix(lower, p) && len(lower) > len(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// group returns the set with the given stem in a directory, or nil if there isn't one
+func (r *sidecarRule) group(names map[string]string, stem string) *sidecarGroup {
+	if r.sequence {
+		return r.sequenceGroup(names, stem)
+	}
+	var primary string
+	for _, p := range r.primary {
+		if n, ok := names[stem+p]; ok {
+			primary = n
+			break
+		}
+	}
+	if primary == "" {
+		return nil
+	}
+	g := &sidecarGroup{rule: r, primary: primary}
+	for _, m := range r.required {
+		if _, ok := names[stem+m]; !ok {
+			g.missing = append(g.missing, m)
+		}
+	}
+	if len(r.required) == 0 && !r.hasMember(names, stem, strings.ToLower(primary)) {
+		return nil
+	}
+	g.describe()
+	return g
+}
+
+func (r *sidecarRule) hasMember(names map[string]string, stem, primary string) bool {
+	for _, m := range r.members {
+		if _, ok := names[stem+m]; ok {
+			return true
+		}
+	}
+	for _, sfx := range r.suffixes {
+		if _, ok := names[primary+sfx]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// the most missing files of a sequence that are listed
+const maxMissing = 3
+
+// sequenceGroup returns the sequence of numbered files with the given stem, if there are at least two of them. Its
+// primary file is the first of the sequence, and the numbers missing from the sequence are missing files.
+func (r *sidecarRule) sequenceGroup(names map[string]string, stem string) *sidecarGroup {
+	type numbered struct {
+		n    int
+		name string
+	}
+	var seq []numbered
+	for lower, name := range names {
+		ext := r.ext(lower)
+		if ext == "" || !strings.HasPrefix(lower, stem) {
+			continue
+		}
+		num := lower[len(stem) : len(lower)-len(ext)]
+		if n, err := strconv.Atoi(num); err == nil && n >= 0 && strings.Trim(num, "0123456789") == "" {
+			seq = append(seq, numbered{n, name})
+		}
+	}
+	if len(seq) < 2 {
+		return nil
+	}
+	sort.Slice(seq, func(i, j int) bool { return seq[i].n < seq[j].n })
+	g := &sidecarGroup{rule: r, primary: seq[0].name}
+	// missing files are named like the first file of the sequence, with the same number of digits
+	first := seq[0].name
+	ext := first[len(first)-len(r.ext(strings.ToLower(first))):]
+	prefix, width := first[:len(stem)], len(first)-len(stem)-len(ext)
+	var gaps int
+	for i := 1; i < len(seq); i++ {
+		for n := seq[i-1].n + 1; n < seq[i].n; n++ {
+			if gaps++; gaps <= maxMissing {
+				g.missing = append(g.missing, fmt.Sprintf("%s%0*d%s", prefix, width, n, ext))
+			} else {
+				gaps += seq[i].n - n - 1
+				break
+			}
+		}
+	}
+	if gaps > maxMissing {
+		g.missing = append(g.missing, fmt.Sprintf("and %d more", gaps-maxMissing))
+	}
+	g.describe()
+	return g
+}
+
+func (g *sidecarGroup) describe() {
+	g.desc = g.rule.name + " " + g.primary
+	if len(g.missing) > 0 {
+		g.desc += " (missing " + strings.Join(g.missing, ", ") + ")"
+	}
+}
+
+// records returns a group record for each set that files were added to, in the order they were found
+func (s *sidecars) records() []writer.Group {
+	s.Lock()
+	defer s.Unlock()
+	ret := make([]writer.Group, 0, len(s.order))
+	for _, g := range s.order {
+		files := append([]writer.GroupFile(nil), g.files...)
+		sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+		var primary string
+		for i, f := range files {
+			if filepath.Base(f.Name) == g.primary {
+				primary = f.Name
+				// the primary file first
+				copy(files[1:i+1], files[:i])
+				files[0] = f
+				break
+			}
+		}
+		if primary == "" {
+			primary = g.primary
+		}
+		ret = append(ret, writer.Group{Name: g.rule.name, Primary: primary, Files: files, Missing: g.missing})
+	}
+	return ret
+}
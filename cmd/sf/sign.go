@@ -0,0 +1,63 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+)
+
+// signer makes a detached Ed25519 signature of the results sf writes to stdout (see -sign). Ed25519 signs a whole
+// message, rather than a digest, so the results are kept in memory until they are signed.
+type signer struct {
+	key ed25519.PrivateKey
+	buf bytes.Buffer
+}
+
+// loadSigner reads an Ed25519 private key from a PEM encoded PKCS #8 file, as made by
+// openssl genpkey -algorithm ed25519 -out key.pem
+func loadSigner(path string) (*signer, error) {
+	byts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(byts)
+	if block == nil {
+		return nil, errors.New("expecting a PEM encoded private key")
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("expecting an Ed25519 private key")
+	}
+	return &signer{key: key}, nil
+}
+
+func (s *signer) Write(p []byte) (int, error) {
+	return s.buf.Write(p)
+}
+
+// sign writes the raw 64 byte signature of the results to a file. It can be checked with
+// openssl pkeyutl -verify -pubin -inkey pub.pem -rawin -in results -sigfile results.sig
+func (s *signer) sign(path string) error {
+	return ioutil.WriteFile(path, ed25519.Sign(s.key, s.buf.Bytes()), 0644)
+}
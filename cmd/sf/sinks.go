@@ -0,0 +1,127 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// newSinks makes the sinks set by flags (e.g. -elastic), which are sent results as well as the output writer
+func newSinks() ([]writer.Sink, error) {
+	var sinks []writer.Sink
+	for _, s := range []struct {
+		u   string
+		new func(string) (writer.Sink, error)
+	}{
+		{*elasticf, writer.Elastic},
+		{*kafkaf, writer.Kafka},
+		{*amqpf, writer.AMQP},
+		{*webhookf, func(u string) (writer.Sink, error) { return writer.Webhook(u, *webhooksecretf) }},
+		{*postgresf, newPsql},
+	} {
+		if s.u == "" {
+			continue
+		}
+		sk, err := s.new(s.u)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sk)
+	}
+	if *opexf {
+		sinks = append(sinks, writer.OPEXSidecars())
+	}
+	if *samplef != "" {
+		if *pathsf != "" {
+			return nil, errors.New("-sample can't be used with -paths")
+		}
+		if *samplenf < 1 {
+			return nil, errors.New("-samplen must be at least 1")
+		}
+		sk, err := writer.Sample(*samplef, *samplenf, *samplelinkf)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sk)
+	}
+	if *triagef != "" {
+		if *pathsf != "" {
+			return nil, errors.New("-triage can't be used with -paths")
+		}
+		sk, err := writer.Triage(*triagef, *triagenf, *triagesizef)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sk)
+	}
+	if *rocratef {
+		if flag.NArg() != 1 || *list || *replay || flag.Arg(0) == "-" {
+			return nil, errors.New("-rocrate needs a single dataset directory to scan")
+		}
+		if *pathsf != "" {
+			return nil, errors.New("-rocrate can't be used with -paths")
+		}
+		sk, err := writer.ROCrate(flag.Arg(0))
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sk)
+	}
+	return sinks, nil
+}
+
+// psqlSink loads results into PostgreSQL by piping the postgres output format to psql
+type psqlSink struct {
+	writer.Writer
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	err   error
+}
+
+// newPsql starts psql, connected to the database given by a connection string (e.g. postgres://user@host/db or
+// "host=localhost dbname=archive"). Authentication is psql's: e.g. a ~/.pgpass file or PGPASSWORD variable.
+func newPsql(conn string) (writer.Sink, error) {
+	psql, err := exec.LookPath("psql")
+	if err != nil {
+		return nil, fmt.Errorf("-postgres needs psql: %v", err)
+	}
+	cmd := exec.Command(psql, "-X", "-q", "-v", "ON_ERROR_STOP=1", "-d", conn)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("can't start psql: %v", err)
+	}
+	return &psqlSink{Writer: writer.Postgres(stdin), cmd: cmd, stdin: stdin}, nil
+}
+
+func (p *psqlSink) Tail() {
+	p.Writer.Tail()
+	p.stdin.Close()
+	if err := p.cmd.Wait(); err != nil {
+		p.err = fmt.Errorf("loading results into PostgreSQL failed (psql: %v)", err)
+	}
+}
+
+func (p *psqlSink) Err() error { return p.err }
@@ -0,0 +1,33 @@
+//go:build unix
+// +build unix
+
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// sparse reports whether a file is heavily sparse, and the number of bytes allocated to it
+func sparse(info os.FileInfo) (int64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || info.Size() < sparseMin {
+		return 0, false
+	}
+	alloc := int64(st.Blocks) * 512 // st_blocks is in 512 byte units, whatever the block size
+	return alloc, alloc < info.Size()/sparseRatio
+}
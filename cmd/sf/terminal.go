@@ -0,0 +1,74 @@
+// +build !windows
+
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// tty is the controlling terminal, set with stty to pass key presses straight through without echoing them. It is
+// opened as /dev/tty so that sf -tui works while stdin is a pipe (e.g. with -f - or -).
+type tty struct {
+	*os.File
+	state string // the settings to restore, from stty -g
+}
+
+func openTerminal() (terminal, error) {
+	f, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal: %v", err)
+	}
+	t := &tty{File: f}
+	state, err := t.stty("-g")
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("can't read the terminal settings with stty: %v", err)
+	}
+	t.state = strings.TrimSpace(state)
+	if _, err = t.stty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("can't change the terminal settings with stty: %v", err)
+	}
+	return t, nil
+}
+
+func (t *tty) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.File
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func (t *tty) size() (int, int) {
+	out, err := t.stty("size")
+	if err != nil {
+		return 80, 24
+	}
+	var h, w int
+	if _, err = fmt.Sscan(out, &h, &w); err != nil || w < 1 || h < 1 {
+		return 80, 24
+	}
+	return w, h
+}
+
+func (t *tty) restore() error {
+	_, err := t.stty(t.state)
+	return err
+}
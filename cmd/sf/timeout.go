@@ -0,0 +1,82 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/writer"
+)
+
+// TimeoutError is the error written for a file that took longer than -filetimeout to read and identify
+type TimeoutError time.Duration
+
+func (te TimeoutError) Error() string {
+	return fmt.Sprintf("timed out: not read and identified within %v (-filetimeout)", time.Duration(te))
+}
+
+func (te TimeoutError) ErrorClass() string { return writer.ClassTimeout }
+
+// a claim settles the race between the result of a file and its -filetimeout: only the first to claim it is sent.
+// A nil claim (without -filetimeout) is always won by the result.
+type claim struct {
+	state int32
+}
+
+const (
+	unclaimed int32 = iota
+	claimedResult
+	claimedTimeout
+)
+
+func (c *claim) result() bool {
+	return c == nil || atomic.CompareAndSwapInt32(&c.state, unclaimed, claimedResult)
+}
+
+func (c *claim) timeout() bool {
+	return atomic.CompareAndSwapInt32(&c.state, unclaimed, claimedTimeout)
+}
+
+// readFileTimeout reads and identifies a file in another goroutine, with a copy of its context, and sends a
+// TimeoutError for it if its result doesn't come within the timeout. A read can't be interrupted, so a stalled read
+// is abandoned: its goroutine sends nothing when it ends, and keeps the file's context, which isn't returned to the
+// pool. Once its result is sent, the contents of an archive are identified without a timeout.
+func readFileTimeout(ctx *context, ctxts chan *context, gf getFn, timeout time.Duration) {
+	c := *ctx
+	c.res, c.claim = make(chan results, 1), &claim{}
+	done := make(chan struct{})
+	go func() {
+		readPath(&c, ctxts, gf)
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var res results
+	select {
+	case res = <-c.res:
+	case <-timer.C:
+		if c.claim.timeout() {
+			ctx.abandoned = true
+			ctx.res <- results{TimeoutError(timeout), nil, nil}
+			return
+		}
+		res = <-c.res // the result was claimed as the timer fired
+	}
+	ctx.file, ctx.timed, ctx.took, ctx.read = c.file, c.timed, c.took, c.read
+	ctx.res <- res
+	<-done
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	stdctx "context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// the field added to each identifier's matches for the summaries of external tools (see -tools)
+const toolsField = "tools"
+
+// maximum length of the output of a tool that is kept in its summary
+const toolOutput = 200
+
+// tools are external characterisation tools, run for files with particular formats (see -tools)
+type tools struct {
+	rules map[string][][]string // commands (and their arguments) to run, by format ID
+	sem   chan struct{}         // limits the number of tools running at once
+}
+
+// loadTools reads a rules file. Each line of a rules file has a format ID (e.g. a PUID), or a comma separated list of
+// IDs, then the command to run for files with those formats. In the command's arguments, {} is replaced by the
+// path of the file. Commands are run directly, not by a shell, and arguments are split on spaces. Blank lines and
+// lines starting with # are ignored. E.g.
+//
+//	# run JHOVE for JPEGs and MediaInfo for MP4s
+//	fmt/43,fmt/44  jhove -m JPEG-hul -h text {}
+//	fmt/199        mediainfo {}
+func loadTools(path string, multi int) (*tools, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	t := &tools{rules: make(map[string][][]string), sem: make(chan struct{}, multi)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s line %d: expecting a format ID and a command, got %q", path, n, line)
+		}
+		for _, id := range strings.Split(fields[0], ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				t.rules[id] = append(t.rules[id], fields[1:])
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// run runs the tools for a file's matches, and returns the matches with summaries of the tools' results in a tools
+// field. Matches without tools have an empty tools field, as do all the matches of streams and the contents of
+// archives (which have no path).
+func (t *tools) run(path string, ids []core.Identification) []core.Identification {
+	ret := make([]core.Identification, len(ids))
+	for i, id := range ids {
+		var sums []string
+		if path != "" && id.Known() {
+			for _, cmd := range t.rules[id.String()] {
+				sums = append(sums, t.exec(cmd, path))
+			}
+		}
+		ret[i] = fieldID{id, strings.Join(sums, "; ")}
+	}
+	return ret
+}
+
+// exec runs a command for a file, and summarises its result: its name, exit status and the start of its output
+func (t *tools) exec(command []string, path string) string {
+	t.sem <- struct{}{}
+	defer func() { <-t.sem }()
+	args := make([]string, len(command)-1)
+	for i, a := range command[1:] {
+		args[i] = strings.Replace(a, "{}", path, -1)
+	}
+	name := filepath.Base(command[0])
+	ctx := stdctx.Background()
+	if *tooltimef > 0 {
+		var cancel stdctx.CancelFunc
+		ctx, cancel = stdctx.WithTimeout(ctx, *tooltimef)
+		defer cancel()
+	}
+	out, err := exec.CommandContext(ctx, command[0], args...).CombinedOutput()
+	var status string
+	var exit *exec.ExitError
+	switch {
+	case ctx.Err() == stdctx.DeadlineExceeded:
+		return fmt.Sprintf("%s timed out after %s", name, *tooltimef)
+	case err == nil:
+		status = "exit 0"
+	case errors.As(err, &exit):
+		status = fmt.Sprintf("exit %d", exit.ExitCode())
+	default:
+		return fmt.Sprintf("%s failed: %v", name, err)
+	}
+	out = bytes.Join(bytes.Fields(out), []byte(" "))
+	if len(out) > toolOutput {
+		out = append(out[:toolOutput], "..."...)
+	}
+	if len(out) == 0 {
+		return name + " " + status
+	}
+	return fmt.Sprintf("%s %s: %s", name, status, out)
+}
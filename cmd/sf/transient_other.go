@@ -0,0 +1,39 @@
+//go:build !unix && !windows
+// +build !unix,!windows
+
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// errors that may go away if the access is tried again, on systems (e.g. WASI) without the Unix or Windows
+// system errors for network file systems
+var transients = []error{
+	os.ErrDeadlineExceeded,
+}
+
+// transient reports whether an error accessing a file might be a hiccup of a network file system
+func transient(err error) bool {
+	for _, e := range transients {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
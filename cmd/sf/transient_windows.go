@@ -0,0 +1,44 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// Windows system errors that may go away if the access is tried again, e.g. on SMB shares
+var transients = []syscall.Errno{
+	21,   // ERROR_NOT_READY
+	32,   // ERROR_SHARING_VIOLATION
+	33,   // ERROR_LOCK_VIOLATION
+	53,   // ERROR_BAD_NETPATH
+	54,   // ERROR_NETWORK_BUSY
+	59,   // ERROR_UNEXP_NET_ERR
+	64,   // ERROR_NETNAME_DELETED
+	121,  // ERROR_SEM_TIMEOUT
+	1231, // ERROR_NETWORK_UNREACHABLE
+	1236, // ERROR_CONNECTION_ABORTED
+}
+
+// transient reports whether an error accessing a file might be a hiccup of a network file system
+func transient(err error) bool {
+	for _, e := range transients {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
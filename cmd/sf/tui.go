@@ -0,0 +1,900 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/sets"
+)
+
+// a terminal is the interactive terminal that sf -tui runs in: key presses are read from it and the screen is
+// written to it
+type terminal interface {
+	io.ReadWriter
+	size() (w, h int)
+	restore() error
+}
+
+// views of sf -tui
+const (
+	treeView   = iota // the files and directories of a directory
+	formatView        // the number of files and bytes of each format
+	fileView          // the result for a file
+)
+
+// filters for files with unknown results or errors; other filters are format IDs or sets (e.g. fmt/40 or @pdfa)
+const (
+	unknownFilter = "unknown"
+	errorFilter   = "error"
+)
+
+type tuiFile struct {
+	path string
+	name string
+	size int64
+	mod  string
+	cs   []byte
+	err  error
+	ids  []core.Identification
+}
+
+func (f *tuiFile) unknown() bool {
+	for _, id := range f.ids {
+		if !id.Known() {
+			return true
+		}
+	}
+	return false
+}
+
+type tuiDir struct {
+	name   string
+	parent *tuiDir
+	dirs   map[string]*tuiDir
+	files  []*tuiFile
+}
+
+func (d *tuiDir) path() string {
+	if d.parent == nil {
+		return d.name
+	}
+	p := d.parent.path()
+	if p == "" || strings.HasSuffix(p, "/") || strings.HasSuffix(p, "#") {
+		return p + d.name
+	}
+	return p + "/" + d.name
+}
+
+type tuiFormat struct {
+	id    string
+	label string
+	files int
+	size  int64
+}
+
+// tuiLog keeps the messages logged while sf -tui has the terminal, to write them to stderr when it exits
+type tuiLog struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	n   int
+}
+
+func (l *tuiLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.n++
+	return l.buf.Write(p)
+}
+
+func (l *tuiLog) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.n
+}
+
+// A tui is a writer that shows the results of a scan in an interactive terminal as they come in (see sf -tui): a tree
+// of the scanned files, the number of files of each format, and the result for each file, with filters for formats
+// (or format sets), unknowns and errors.
+type tui struct {
+	mu       sync.Mutex
+	term     terminal
+	logs     *tuiLog
+	hh       string
+	idents   [][2]string
+	fields   [][]string
+	root     *tuiDir
+	archives map[string]bool // paths of archives, whose contents are listed in a directory named for the archive
+	formats  map[string]*tuiFormat
+	files    int
+	unknowns int
+	errors   int
+	size     int64
+	done     bool
+	// the state of the view
+	view   int
+	dir    *tuiDir
+	auto   bool // the directory shown is the top of the tree, which may change as the tree grows (see start)
+	detail *tuiFile
+	sel    int
+	top    int
+	filter string
+	fset   map[string]bool
+	input  *string // the filter being typed
+	dirty  bool
+	quit   chan struct{}
+}
+
+func newTUI() *tui {
+	return &tui{
+		root:     &tuiDir{dirs: make(map[string]*tuiDir)},
+		archives: make(map[string]bool),
+		formats:  make(map[string]*tuiFormat),
+		quit:     make(chan struct{}),
+	}
+}
+
+func (t *tui) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	t.mu.Lock()
+	t.idents, t.fields, t.hh = ids, fields, hh
+	t.mu.Unlock()
+}
+
+func (t *tui) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f := &tuiFile{path: name, size: sz, mod: mod, cs: checksum, err: err, ids: ids}
+	t.add(f)
+	t.files++
+	if sz > 0 {
+		t.size += sz
+	}
+	if err != nil {
+		t.errors++
+	}
+	if f.unknown() {
+		t.unknowns++
+	}
+	for _, id := range ids {
+		if id.Archive() != config.None {
+			t.archives[name] = true
+		}
+		if !id.Known() {
+			continue
+		}
+		key := id.String()
+		if len(t.idents) > 1 {
+			key = id.Values()[0] + " " + key
+		}
+		tf, ok := t.formats[key]
+		if !ok {
+			tf = &tuiFormat{id: key, label: t.label(id)}
+			t.formats[key] = tf
+		}
+		tf.files++
+		if sz > 0 {
+			tf.size += sz
+		}
+	}
+	t.dirty = true
+}
+
+func (t *tui) Tail() {
+	t.mu.Lock()
+	t.done, t.dirty = true, true
+	t.mu.Unlock()
+}
+
+// add puts a file in the tree. The contents of an archive (e.g. a.zip#b/c.txt) are put in a directory named for the
+// archive (a.zip#).
+func (t *tui) add(f *tuiFile) {
+	var b strings.Builder
+	var last int
+	for i, c := range f.path {
+		if c == '#' && t.archives[f.path[:i]] {
+			b.WriteString(f.path[last:i+1] + "/")
+			last = i + 1
+		}
+	}
+	b.WriteString(f.path[last:])
+	p := filepath.ToSlash(b.String())
+	elems := strings.Split(p, "/")
+	if strings.HasPrefix(p, "/") {
+		elems[0] = "/"
+	}
+	d := t.root
+	for _, e := range elems[:len(elems)-1] {
+		if e == "" || e == "." {
+			continue
+		}
+		nd, ok := d.dirs[e]
+		if !ok {
+			nd = &tuiDir{name: e, parent: d, dirs: make(map[string]*tuiDir)}
+			d.dirs[e] = nd
+		}
+		d = nd
+	}
+	f.name = elems[len(elems)-1]
+	d.files = append(d.files, f)
+}
+
+// label is a format's name and version, from the format and version fields of its identifier
+func (t *tui) label(id core.Identification) string {
+	vals := id.Values()
+	for i, ident := range t.idents {
+		if ident[0] != vals[0] || i >= len(t.fields) {
+			continue
+		}
+		var label []string
+		for j, f := range t.fields[i] {
+			if (f == "format" || f == "version") && j < len(vals) && vals[j] != "" {
+				label = append(label, vals[j])
+			}
+		}
+		return strings.Join(label, " ")
+	}
+	return ""
+}
+
+// start shows the top directory of the tree that has more than one entry
+func (t *tui) start() *tuiDir {
+	d := t.root
+	for len(d.files) == 0 && len(d.dirs) == 1 {
+		for _, nd := range d.dirs {
+			d = nd
+		}
+	}
+	return d
+}
+
+func (t *tui) match(f *tuiFile) bool {
+	switch t.filter {
+	case "":
+		return true
+	case unknownFilter:
+		return f.unknown()
+	case errorFilter:
+		return f.err != nil
+	}
+	for _, id := range f.ids {
+		if t.fset[id.String()] {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tui) setFilter(filter string) {
+	t.filter = strings.TrimSpace(filter)
+	t.fset = make(map[string]bool)
+	if t.filter == unknownFilter || t.filter == errorFilter {
+		t.sel, t.top = 0, 0
+		return
+	}
+	var known map[string]bool
+	for _, v := range strings.Split(t.filter, ",") {
+		v = strings.TrimSpace(v)
+		if !strings.HasPrefix(v, "@") {
+			t.fset[v] = true
+			continue
+		}
+		// skip sets that don't exist, rather than let sets.Sets stop sf
+		if known == nil {
+			known = make(map[string]bool)
+			for _, k := range setNames() {
+				known[k] = true
+			}
+		}
+		if known[v] {
+			for _, f := range sets.Sets(v) {
+				t.fset[f] = true
+			}
+		}
+	}
+	t.sel, t.top = 0, 0
+}
+
+// count is the number of files in a directory and its subdirectories that match the filter, with the number of
+// unknowns and errors among them
+func (t *tui) count(d *tuiDir) (files, unknowns, errors int) {
+	for _, f := range d.files {
+		if t.match(f) {
+			files++
+			if f.unknown() {
+				unknowns++
+			}
+			if f.err != nil {
+				errors++
+			}
+		}
+	}
+	for _, nd := range d.dirs {
+		f, u, e := t.count(nd)
+		files, unknowns, errors = files+f, unknowns+u, errors+e
+	}
+	return
+}
+
+// a tuiRow is a line of a view, with the directory, file or format it shows
+type tuiRow struct {
+	text string
+	dir  *tuiDir
+	file *tuiFile
+	fmt  *tuiFormat
+}
+
+func (t *tui) rows() []tuiRow {
+	var rows []tuiRow
+	switch t.view {
+	case treeView:
+		names := make([]string, 0, len(t.dir.dirs))
+		for n := range t.dir.dirs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			d := t.dir.dirs[n]
+			files, unknowns, errors := t.count(d)
+			if files == 0 && t.filter != "" {
+				continue
+			}
+			text := fmt.Sprintf("%s/  %s", strings.TrimSuffix(n, "/"), plural(files, "file"))
+			if unknowns > 0 {
+				text += fmt.Sprintf(", %d unknown", unknowns)
+			}
+			if errors > 0 {
+				text += ", " + plural(errors, "error")
+			}
+			rows = append(rows, tuiRow{text: text, dir: d})
+		}
+		files := make([]*tuiFile, 0, len(t.dir.files))
+		for _, f := range t.dir.files {
+			if t.match(f) {
+				files = append(files, f)
+			}
+		}
+		sort.SliceStable(files, func(i, j int) bool { return files[i].name < files[j].name })
+		for _, f := range files {
+			rows = append(rows, tuiRow{text: f.name + "  " + summary(f), file: f})
+		}
+	case formatView:
+		fmts := make([]*tuiFormat, 0, len(t.formats))
+		for _, f := range t.formats {
+			fmts = append(fmts, f)
+		}
+		sort.Slice(fmts, func(i, j int) bool {
+			if fmts[i].files == fmts[j].files {
+				return fmts[i].id < fmts[j].id
+			}
+			return fmts[i].files > fmts[j].files
+		})
+		for _, f := range fmts {
+			rows = append(rows, tuiRow{text: fmt.Sprintf("%-14s %8d %10s  %s", f.id, f.files, bytesize(f.size), f.label), fmt: f})
+		}
+	case fileView:
+		f := t.detail
+		lines := []string{
+			"filename : " + f.path,
+			fmt.Sprintf("filesize : %d", f.size),
+			"modified : " + f.mod,
+		}
+		if f.err != nil {
+			lines = append(lines, "errors   : "+f.err.Error())
+		}
+		if f.cs != nil {
+			lines = append(lines, fmt.Sprintf("%-8s : %s", t.hh, hex.EncodeToString(f.cs)))
+		}
+		lines = append(lines, "matches  :")
+		idx, this := -1, ""
+		for _, id := range f.ids {
+			vals := id.Values()
+			if vals[0] != this {
+				idx++
+				this = vals[0]
+			}
+			for i, v := range vals {
+				name := fmt.Sprintf("%d", i)
+				if idx < len(t.fields) && i < len(t.fields[idx]) {
+					name = t.fields[idx][i]
+				}
+				prefix := "    "
+				if i == 0 {
+					prefix = "  - "
+				}
+				lines = append(lines, fmt.Sprintf("%s%-9s: %s", prefix, name, v))
+			}
+		}
+		for _, l := range lines {
+			rows = append(rows, tuiRow{text: l})
+		}
+	}
+	return rows
+}
+
+// summary describes the result for a file in a line: its format IDs, or UNKNOWN, and any warning or error
+func summary(f *tuiFile) string {
+	var ids, warns []string
+	for _, id := range f.ids {
+		if id.Known() {
+			ids = append(ids, id.String())
+		} else {
+			ids = append(ids, "UNKNOWN")
+		}
+		if w := id.Warn(); w != "" {
+			warns = append(warns, w)
+		}
+	}
+	ret := strings.Join(ids, ", ")
+	if f.err != nil {
+		ret += "  ERROR: " + f.err.Error()
+	}
+	if len(warns) > 0 {
+		ret += "  (" + strings.Join(warns, "; ") + ")"
+	}
+	return ret
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return "1 " + s
+	}
+	return fmt.Sprintf("%d %ss", n, s)
+}
+
+// bytesize writes a number of bytes with a unit e.g. 1.5 MB
+func bytesize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v, i := float64(n)/1024, 0
+	for ; v >= 1024 && i < 4; i++ {
+		v /= 1024
+	}
+	return fmt.Sprintf("%.1f %cB", v, "KMGTP"[i])
+}
+
+// render draws the view as h lines of (at most) w characters, and returns the index of the line to highlight (or -1)
+func (t *tui) render(w, h int) ([]string, int) {
+	lines := make([]string, 0, h)
+	var title string
+	switch t.view {
+	case treeView:
+		title = t.dir.path()
+		if title == "" {
+			title = "."
+		}
+	case formatView:
+		title = "formats"
+	case fileView:
+		title = t.detail.path
+	}
+	if t.filter != "" {
+		title += "  [filter: " + t.filter + "]"
+	}
+	lines = append(lines, "sf -tui  "+title)
+	rows := t.rows()
+	if t.view == fileView { // wrap long values (e.g. of the basis field) rather than cut them off
+		var wrapped []tuiRow
+		for _, r := range rows {
+			for _, l := range wrap(r.text, w-1, 15) {
+				wrapped = append(wrapped, tuiRow{text: l})
+			}
+		}
+		rows = wrapped
+	}
+	if len(rows) == 0 && t.filter != "" {
+		rows = []tuiRow{{text: "no files match the filter (c to clear it)"}}
+	}
+	list := h - 3
+	if list < 1 {
+		list = 1
+	}
+	if t.view == fileView { // the file view scrolls, rather than moving a selection
+		if t.top > len(rows)-list {
+			t.top = len(rows) - list
+		}
+		if t.top < 0 {
+			t.top = 0
+		}
+	} else {
+		if t.sel >= len(rows) {
+			t.sel = len(rows) - 1
+		}
+		if t.sel < 0 {
+			t.sel = 0
+		}
+		if t.sel < t.top {
+			t.top = t.sel
+		}
+		if t.sel >= t.top+list {
+			t.top = t.sel - list + 1
+		}
+	}
+	hl := -1
+	for i := t.top; i < len(rows) && i < t.top+list; i++ {
+		if i == t.sel && t.view != fileView {
+			hl = len(lines)
+		}
+		lines = append(lines, " "+rows[i].text)
+	}
+	for len(lines) < h-2 {
+		lines = append(lines, "")
+	}
+	status := "scanning: "
+	if t.done {
+		status = "scanned: "
+	}
+	status += fmt.Sprintf("%s (%s), %d unknown, %s, %s", plural(t.files, "file"), bytesize(t.size), t.unknowns, plural(t.errors, "error"), plural(len(t.formats), "format"))
+	if t.logs != nil {
+		if n := t.logs.count(); n > 0 {
+			status += fmt.Sprintf("; %s, shown on exit", plural(n, "log message"))
+		}
+	}
+	lines = append(lines, status)
+	switch {
+	case t.input != nil:
+		lines = append(lines, "filter (a format ID or set e.g. fmt/40 or @pdfa, unknown or error): "+*t.input+"_")
+	case t.view == fileView:
+		lines = append(lines, "up/down scroll  left back  q quit")
+	default:
+		lines = append(lines, "up/down move  enter open  left back  tab tree/formats  / filter  u unknown  e errors  c clear  q quit")
+	}
+	for i, l := range lines {
+		lines[i] = truncate(l, w)
+	}
+	return lines[:h], hl
+}
+
+// wrap splits a line into lines of at most w characters, indenting the lines after the first
+func wrap(s string, w, indent int) []string {
+	r := []rune(s)
+	if w <= indent || len(r) <= w {
+		return []string{s}
+	}
+	ret := []string{string(r[:w])}
+	for r = r[w:]; len(r) > 0; {
+		n := w - indent
+		if n > len(r) {
+			n = len(r)
+		}
+		ret = append(ret, strings.Repeat(" ", indent)+string(r[:n]))
+		r = r[n:]
+	}
+	return ret
+}
+
+func truncate(s string, w int) string {
+	if utf8.RuneCountInString(s) <= w {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:w])
+}
+
+// key handles a key press (a character or a named key e.g. up), and reports whether it quits
+func (t *tui) key(k string) bool {
+	t.dirty = true
+	if t.input != nil {
+		switch k {
+		case "enter":
+			t.setFilter(*t.input)
+			t.input = nil
+			if t.view == formatView {
+				t.view = treeView
+			}
+		case "esc":
+			t.input = nil
+		case "back":
+			if s := *t.input; s != "" {
+				_, n := utf8.DecodeLastRuneInString(s)
+				s = s[:len(s)-n]
+				t.input = &s
+			}
+		default:
+			if utf8.RuneCountInString(k) == 1 {
+				s := *t.input + k
+				t.input = &s
+			}
+		}
+		return false
+	}
+	if t.view == fileView {
+		switch k {
+		case "q", "ctrl-c":
+			return true
+		case "up", "k":
+			t.top--
+		case "down", "j":
+			t.top++
+		case "pgup":
+			t.top -= 10
+		case "pgdn":
+			t.top += 10
+		case "home", "g":
+			t.top = 0
+		case "end", "G":
+			t.top = 1 << 30 // render scrolls back to the end
+		case "left", "back", "h", "esc":
+			t.back()
+		}
+		return false
+	}
+	switch k {
+	case "q", "ctrl-c":
+		return true
+	case "up", "k":
+		t.sel--
+	case "down", "j":
+		t.sel++
+	case "pgup":
+		t.sel -= 10
+	case "pgdn":
+		t.sel += 10
+	case "home", "g":
+		t.sel = 0
+	case "end", "G":
+		t.sel = len(t.rows()) - 1
+	case "enter", "right", "l":
+		t.open()
+	case "left", "back", "h", "esc":
+		t.back()
+	case "tab":
+		if t.view == formatView {
+			t.view = treeView
+		} else {
+			t.view = formatView
+		}
+		t.sel, t.top = 0, 0
+	case "/":
+		var s string
+		t.input = &s
+	case "u":
+		t.setFilter(unknownFilter)
+	case "e":
+		t.setFilter(errorFilter)
+	case "c":
+		t.setFilter("")
+	}
+	return false
+}
+
+func (t *tui) open() {
+	rows := t.rows()
+	if t.sel < 0 || t.sel >= len(rows) {
+		return
+	}
+	switch r := rows[t.sel]; {
+	case r.dir != nil:
+		t.dir, t.auto, t.sel, t.top = r.dir, false, 0, 0
+	case r.file != nil:
+		t.detail, t.view, t.sel, t.top = r.file, fileView, 0, 0
+	case r.fmt != nil:
+		id := r.fmt.id
+		if i := strings.LastIndex(id, " "); i >= 0 {
+			id = id[i+1:]
+		}
+		t.setFilter(id)
+		t.view, t.dir, t.auto = treeView, t.start(), true
+	}
+}
+
+func (t *tui) back() {
+	switch t.view {
+	case fileView:
+		t.view, t.sel = treeView, 0
+		// select the file again
+		for i, r := range t.rows() {
+			if r.file == t.detail {
+				t.sel = i
+			}
+		}
+	case formatView:
+		t.view, t.sel, t.top = treeView, 0, 0
+	case treeView:
+		if t.dir.parent == nil {
+			return
+		}
+		from := t.dir
+		t.dir, t.auto, t.sel, t.top = t.dir.parent, false, 0, 0
+		for i, r := range t.rows() {
+			if r.dir == from {
+				t.sel = i
+			}
+		}
+	}
+}
+
+// keys splits the bytes read from a terminal into key presses
+func keys(b []byte) []string {
+	var ret []string
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			if len(b) == 1 {
+				return append(ret, "esc")
+			}
+			seqs := []struct{ seq, key string }{
+				{"\x1b[A", "up"}, {"\x1b[B", "down"}, {"\x1b[C", "right"}, {"\x1b[D", "left"},
+				{"\x1bOA", "up"}, {"\x1bOB", "down"}, {"\x1bOC", "right"}, {"\x1bOD", "left"},
+				{"\x1b[5~", "pgup"}, {"\x1b[6~", "pgdn"}, {"\x1b[H", "home"}, {"\x1b[F", "end"},
+				{"\x1b[1~", "home"}, {"\x1b[4~", "end"},
+			}
+			var found bool
+			for _, s := range seqs {
+				if bytes.HasPrefix(b, []byte(s.seq)) {
+					ret, b, found = append(ret, s.key), b[len(s.seq):], true
+					break
+				}
+			}
+			if !found { // an escape followed by a key, or a sequence we don't handle
+				ret, b = append(ret, "esc"), b[1:]
+				if len(b) > 0 && (b[0] == '[' || b[0] == 'O') {
+					b = b[1:]
+					for len(b) > 0 && (b[0] < 0x40 || b[0] > 0x7e) {
+						b = b[1:]
+					}
+					if len(b) > 0 {
+						b = b[1:]
+					}
+				}
+			}
+			continue
+		}
+		switch b[0] {
+		case '\r', '\n':
+			ret = append(ret, "enter")
+		case '\t':
+			ret = append(ret, "tab")
+		case 0x7f, 0x08:
+			ret = append(ret, "back")
+		case 0x03:
+			ret = append(ret, "ctrl-c")
+		default:
+			r, n := utf8.DecodeRune(b)
+			if r >= ' ' {
+				ret = append(ret, string(r))
+			}
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return ret
+}
+
+// run takes over a terminal to show the view until the user quits. Messages logged while it runs are kept to write
+// to stderr when it ends. Quitting before the scan is done stops sf.
+func (t *tui) run(term terminal) {
+	t.mu.Lock()
+	t.term, t.logs = term, &tuiLog{}
+	t.dir, t.auto, t.dirty = t.start(), true, true
+	t.mu.Unlock()
+	log.SetOutput(t.logs)
+	io.WriteString(term, "\x1b[?1049h\x1b[?25l") // use the alternate screen and hide the cursor
+	in := make(chan []byte)
+	go func() {
+		for {
+			buf := make([]byte, 64)
+			n, err := term.Read(buf)
+			if n > 0 {
+				in <- buf[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		tick := time.NewTicker(200 * time.Millisecond)
+		defer tick.Stop()
+		var w, h int
+		for {
+			select {
+			case b := <-in:
+				t.mu.Lock()
+				var quit bool
+				for _, k := range keys(b) {
+					if t.key(k) {
+						quit = true
+						break
+					}
+				}
+				done := t.done
+				t.mu.Unlock()
+				if quit {
+					t.stop()
+					if !done {
+						fmt.Fprintln(os.Stderr, "[WARN] sf -tui was quit before the scan finished")
+						os.Exit(1)
+					}
+					close(t.quit)
+					return
+				}
+				t.draw(w, h)
+			case <-tick.C:
+				if nw, nh := term.size(); nw != w || nh != h {
+					w, h = nw, nh
+					t.mu.Lock()
+					t.dirty = true
+					t.mu.Unlock()
+				}
+				t.mu.Lock()
+				dirty := t.dirty
+				t.mu.Unlock()
+				if dirty {
+					t.draw(w, h)
+				}
+			}
+		}
+	}()
+}
+
+func (t *tui) draw(w, h int) {
+	if w < 1 || h < 4 {
+		return
+	}
+	t.mu.Lock()
+	if t.auto {
+		t.dir = t.start()
+	}
+	lines, hl := t.render(w, h)
+	t.dirty = false
+	t.mu.Unlock()
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H")
+	for i, l := range lines {
+		if i == hl {
+			buf.WriteString("\x1b[7m" + l + strings.Repeat(" ", w-utf8.RuneCountInString(l)) + "\x1b[0m")
+		} else {
+			buf.WriteString(l + "\x1b[K")
+		}
+		if i < len(lines)-1 {
+			buf.WriteString("\r\n")
+		}
+	}
+	t.term.Write(buf.Bytes())
+}
+
+// stop gives the terminal back and writes the messages logged while the view ran to stderr
+func (t *tui) stop() {
+	io.WriteString(t.term, "\x1b[?25h\x1b[?1049l")
+	if err := t.term.restore(); err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] failed to restore the terminal: %v\n", err)
+	}
+	log.SetOutput(os.Stderr)
+	t.logs.mu.Lock()
+	os.Stderr.Write(t.logs.buf.Bytes())
+	t.logs.mu.Unlock()
+}
+
+// wait blocks until the user quits the view
+func (t *tui) wait() {
+	t.mu.Lock()
+	t.dirty = true
+	t.mu.Unlock()
+	<-t.quit
+}
@@ -28,6 +28,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/richardlehane/siegfried"
 	"github.com/richardlehane/siegfried/internal/persist"
 	"github.com/richardlehane/siegfried/pkg/config"
 )
@@ -45,10 +46,11 @@ func current(buf []byte, utime string) bool {
 	if err != nil {
 		return false
 	}
-	if len(buf) < len(config.Magic())+2+15 {
+	h, err := siegfried.ReadSignatureHeader(buf)
+	if err != nil || len(buf) < h.Size+15 {
 		return false
 	}
-	rc := flate.NewReader(bytes.NewBuffer(buf[len(config.Magic())+2:]))
+	rc := flate.NewReader(bytes.NewBuffer(buf[h.Size:]))
 	nbuf := make([]byte, 15)
 	if n, _ := rc.Read(nbuf); n < 15 {
 		return false
@@ -95,26 +97,83 @@ func location(base, sig string, args []string) string {
 	return base
 }
 
-func updateSigs(sig string, args []string) (string, error) {
+// latest gets the details of the latest release of a signature file from the update service. If there is no
+// update to get (e.g. the installed signature file is up to date), it returns a message saying why.
+func latest(sig string, args []string) (Update, string, error) {
+	var u Update
 	url, _, _ := config.UpdateOptions()
 	if url == "" {
-		return "Update is not available for this distribution of siegfried", nil
+		return u, "Update is not available for this distribution of siegfried", nil
 	}
 	response, err := getHttp(location(url, sig, args))
 	if err != nil {
-		return "", err
+		return u, "", err
 	}
-	var u Update
 	if err := json.Unmarshal(response, &u); err != nil {
-		return "", err
+		return u, "", err
 	}
 	version := config.Version()
 	if version[0] < u.Version[0] || (version[0] == u.Version[0] && version[1] < u.Version[1]) || // if the version is out of date
 		u.Version == [3]int{0, 0, 0} || u.Created == "" || u.Size == 0 || u.Path == "" { // or if the unmarshalling hasn't worked and we have blank values
-		return "Your version of siegfried is out of date; please install latest from http://www.itforarchivists.com/siegfried before continuing.", nil
+		return u, "Your version of siegfried is out of date; please install latest from http://www.itforarchivists.com/siegfried before continuing.", nil
 	}
 	if uptodate(u.Created, u.Hash, u.Size) {
-		return "You are already up to date!", nil
+		return u, "You are already up to date!", nil
+	}
+	return u, "", nil
+}
+
+// download gets a signature file from the update service and checks its hash
+func download(u Update) ([]byte, error) {
+	response, err := getHttp(u.Path)
+	if err != nil {
+		return nil, fmt.Errorf("Siegfried: error retrieving %s.\nThis may be a network or firewall issue. See https://github.com/richardlehane/siegfried/wiki/Getting-started for manual instructions.\nSystem error: %v", config.SignatureBase(), err)
+	}
+	if !same(response, u.Size, u.Hash) {
+		return nil, fmt.Errorf("Siegfried: error retrieving %s; SHA256 hash of response doesn't match %s", config.SignatureBase(), u.Hash)
+	}
+	return response, nil
+}
+
+// changelog lists the formats added, removed and changed in a new signature file, relative to the installed one,
+// for each identifier, so that you can tell whether to re-run scans. It is empty if there is no installed signature
+// file to compare, or if the new one can't be loaded.
+func changelog(byts []byte, indent string) string {
+	older, err := siegfried.Load(config.Signature())
+	if err != nil {
+		return ""
+	}
+	newer, err := siegfried.LoadBytes(byts)
+	if err != nil {
+		return ""
+	}
+	changes, err := siegfried.Diff(older, newer)
+	if err != nil {
+		return ""
+	}
+	var buf strings.Builder
+	for i := 0; i < len(changes); {
+		name := changes[i].Identifier
+		j := i
+		counts := make(map[string]int)
+		for ; j < len(changes) && changes[j].Identifier == name; j++ {
+			counts[changes[j].Kind]++
+		}
+		fmt.Fprintf(&buf, "%s- %s: %d added, %d changed, %d removed\n", indent, name, counts[siegfried.Added], counts[siegfried.Changed], counts[siegfried.Removed])
+		for ; i < j; i++ {
+			fmt.Fprintf(&buf, "%s  - %s\n", indent, changes[i])
+		}
+	}
+	if buf.Len() == 0 {
+		return indent + "- no formats added, changed or removed\n"
+	}
+	return buf.String()
+}
+
+func updateSigs(sig string, args []string) (string, error) {
+	u, msg, err := latest(sig, args)
+	if err != nil || msg != "" {
+		return msg, err
 	}
 	// this hairy bit of golang exception handling is thanks to Ross! :)
 	if _, err = os.Stat(config.Home()); err != nil {
@@ -128,18 +187,18 @@ func updateSigs(sig string, args []string) (string, error) {
 		}
 	}
 	fmt.Println("... downloading latest signature file ...")
-	response, err = getHttp(u.Path)
+	response, err := download(u)
 	if err != nil {
-		return "", fmt.Errorf("Siegfried: error retrieving %s.\nThis may be a network or firewall issue. See https://github.com/richardlehane/siegfried/wiki/Getting-started for manual instructions.\nSystem error: %v", config.SignatureBase(), err)
+		return "", err
 	}
-	if !same(response, u.Size, u.Hash) {
-		return "", fmt.Errorf("Siegfried: error retrieving %s; SHA256 hash of response doesn't match %s", config.SignatureBase(), u.Hash)
+	if changes := changelog(response, ""); changes != "" {
+		fmt.Print("... changes since your signature file ...\n" + changes)
 	}
-	err = ioutil.WriteFile(config.Signature(), response, os.ModePerm)
+	err = ioutil.WriteFile(config.HomeFile(config.SignatureBase()), response, os.ModePerm)
 	if err != nil {
 		return "", fmt.Errorf("Siegfried: error writing to directory, %v", err)
 	}
-	fmt.Printf("... writing %s ...\n", config.Signature())
+	fmt.Printf("... writing %s ...\n", config.HomeFile(config.SignatureBase()))
 	return "Your signature file has been updated", nil
 }
 
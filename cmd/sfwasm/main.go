@@ -0,0 +1,96 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build js && wasm
+// +build js,wasm
+
+// Command sfwasm exposes siegfried to JavaScript, so that browsers and edge runtimes can identify files client-side.
+//
+// Build with:
+//
+//	GOOS=js GOARCH=wasm go build -o sf.wasm ./cmd/sfwasm
+//
+// and load it with the wasm_exec.js support file that ships with Go. Once running, sf.wasm registers a global
+// siegfried object:
+//
+//	siegfried.load(sigBytes)                  // load a signature file (a Uint8Array); returns an error string or null
+//	siegfried.identify(fileBytes, name, mime) // returns a JSON string of results, or an object with an error property
+//
+// For wasip1, the core identification path compiles too: build sf with GOOS=wasip1 GOARCH=wasm.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/richardlehane/siegfried"
+)
+
+var sf *siegfried.Siegfried
+
+func bytesArg(v js.Value) []byte {
+	buf := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(buf, v)
+	return buf
+}
+
+func stringArg(args []js.Value, i int) string {
+	if len(args) <= i || args[i].Type() != js.TypeString {
+		return ""
+	}
+	return args[i].String()
+}
+
+func errObj(err string) interface{} {
+	return map[string]interface{}{"error": err}
+}
+
+func load(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 {
+		return "siegfried.load takes the bytes of a signature file"
+	}
+	s, err := siegfried.LoadBytes(bytesArg(args[0]))
+	if err != nil {
+		return err.Error()
+	}
+	sf = s
+	return nil
+}
+
+func identify(this js.Value, args []js.Value) interface{} {
+	if sf == nil {
+		return errObj("no signature file loaded; call siegfried.load first")
+	}
+	if len(args) < 1 {
+		return errObj("siegfried.identify takes the bytes of a file, and optionally its name and MIME type")
+	}
+	ids, err := sf.IdentifyReaderAt(bytes.NewReader(bytesArg(args[0])), int64(args[0].Get("length").Int()), stringArg(args, 1), stringArg(args, 2))
+	if err != nil {
+		return errObj(err.Error())
+	}
+	byt, err := json.Marshal(sf.Results(ids))
+	if err != nil {
+		return errObj(err.Error())
+	}
+	return string(byt)
+}
+
+func main() {
+	js.Global().Set("siegfried", js.ValueOf(map[string]interface{}{
+		"load":     js.FuncOf(load),
+		"identify": js.FuncOf(identify),
+	}))
+	select {} // keep running so that the functions stay callable
+}
@@ -0,0 +1,247 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siegfried
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kinds of Change between two signature files.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a format that was added to, removed from, or changed between two signature files.
+type Change struct {
+	Identifier string   // name of the identifier e.g. pronom
+	ID         string   // format ID e.g. fmt/43
+	Name       string   // format name, from the newer signature file (or the older if the format was removed)
+	Kind       string   // Added, Removed or Changed
+	What       []string // for changed formats, the format info fields (e.g. version) and matchers (e.g. bytematcher) that changed
+}
+
+func (c Change) String() string {
+	str := fmt.Sprintf("%s %s", c.Kind, c.ID)
+	if c.Name != "" {
+		str += " (" + c.Name + ")"
+	}
+	if len(c.What) > 0 {
+		str += ": " + strings.Join(c.What, ", ")
+	}
+	return str
+}
+
+// the matchers, in the order of the JSON export of an identifier
+var diffMatchers = []string{"namematcher", "mimematcher", "containermatcher", "xmlmatcher", "bytematcher", "riffmatcher", "textmatcher", "ebmlmatcher", "sqlitematcher", "tiffmatcher", "fontmatcher"}
+
+type diffIndexes struct {
+	Start int      `json:"start"`
+	IDs   []string `json:"ids"`
+}
+
+type diffIdentifier struct {
+	Infos map[string]json.RawMessage `json:"infos"`
+	Base  map[string]json.RawMessage `json:"base"`
+}
+
+type diffExport struct {
+	NameMatcher struct {
+		Extensions map[string][]int `json:"extensions"`
+		Globs      []string         `json:"globs"`
+		GlobIdx    [][]int          `json:"globIdx"`
+	} `json:"namematcher"`
+	MIMEMatcher map[string][]int `json:"mimematcher"`
+	ByteMatcher struct {
+		KeyFrames []json.RawMessage `json:"keyFrames"`
+	} `json:"bytematcher"`
+	Identifiers []diffIdentifier `json:"identifiers"`
+}
+
+// a diffFormat is what is compared of a format: its info and, for each matcher, a description of each of its signatures
+type diffFormat struct {
+	info map[string]interface{}
+	sigs map[string][]string
+}
+
+// Diff lists the formats added, removed and changed in a newer signature file, relative to an older one, for each
+// identifier in either. It compares the JSON exports of the signature files (see MarshalJSON): a format has changed
+// if its info (e.g. name, version or MIME type) has changed, if it has gained or lost signatures in any matcher, or
+// if its extensions, MIME types or the layout of its byte signatures have changed. Changes are in the order of the
+// identifiers in the newer signature file, then removed identifiers, and by format ID within an identifier.
+func Diff(older, newer *Siegfried) ([]Change, error) {
+	o, err := diffFormats(older)
+	if err != nil {
+		return nil, err
+	}
+	n, err := diffFormats(newer)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	names := make([]string, 0, len(newer.ids)+len(older.ids))
+	for _, id := range newer.ids {
+		names = append(names, id.Name())
+	}
+	for _, id := range older.ids {
+		if _, ok := n[id.Name()]; !ok {
+			names = append(names, id.Name())
+		}
+	}
+	for _, name := range names {
+		ofs, nfs := o[name], n[name]
+		ids := make([]string, 0, len(nfs)+len(ofs))
+		for id := range nfs {
+			ids = append(ids, id)
+		}
+		for id := range ofs {
+			if _, ok := nfs[id]; !ok {
+				ids = append(ids, id)
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			of, ook := ofs[id]
+			nf, nok := nfs[id]
+			switch {
+			case !ook:
+				changes = append(changes, Change{name, id, infoName(nf.info), Added, nil})
+			case !nok:
+				changes = append(changes, Change{name, id, infoName(of.info), Removed, nil})
+			default:
+				if what := of.diff(nf); len(what) > 0 {
+					changes = append(changes, Change{name, id, infoName(nf.info), Changed, what})
+				}
+			}
+		}
+	}
+	return changes, nil
+}
+
+func infoName(info map[string]interface{}) string {
+	if name, ok := info["name"].(string); ok {
+		return name
+	}
+	return ""
+}
+
+// diff lists what differs in a newer version of a format
+func (o *diffFormat) diff(n *diffFormat) []string {
+	var what []string
+	keys := make([]string, 0, len(n.info)+len(o.info))
+	for k := range n.info {
+		keys = append(keys, k)
+	}
+	for k := range o.info {
+		if _, ok := n.info[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if fmt.Sprint(o.info[k]) != fmt.Sprint(n.info[k]) {
+			what = append(what, k)
+		}
+	}
+	for _, m := range diffMatchers {
+		if strings.Join(o.sigs[m], "\n") != strings.Join(n.sigs[m], "\n") {
+			what = append(what, m)
+		}
+	}
+	return what
+}
+
+// diffFormats gives the formats of each identifier in a signature file
+func diffFormats(s *Siegfried) (map[string]map[string]*diffFormat, error) {
+	byts, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var exp diffExport
+	if err = json.Unmarshal(byts, &exp); err != nil {
+		return nil, err
+	}
+	// descriptions of each signature of the name, MIME and byte matchers, by index in the matcher
+	descs := map[string]map[int][]string{
+		"namematcher": make(map[int][]string),
+		"mimematcher": make(map[int][]string),
+		"bytematcher": make(map[int][]string),
+	}
+	for ext, idxs := range exp.NameMatcher.Extensions {
+		for _, i := range idxs {
+			descs["namematcher"][i] = append(descs["namematcher"][i], "*."+ext)
+		}
+	}
+	for i, glob := range exp.NameMatcher.Globs {
+		if i < len(exp.NameMatcher.GlobIdx) {
+			for _, j := range exp.NameMatcher.GlobIdx[i] {
+				descs["namematcher"][j] = append(descs["namematcher"][j], glob)
+			}
+		}
+	}
+	for mime, idxs := range exp.MIMEMatcher {
+		for _, i := range idxs {
+			descs["mimematcher"][i] = append(descs["mimematcher"][i], mime)
+		}
+	}
+	for i, kf := range exp.ByteMatcher.KeyFrames {
+		descs["bytematcher"][i] = []string{string(kf)}
+	}
+	ret := make(map[string]map[string]*diffFormat)
+	for i, id := range exp.Identifiers {
+		if i >= len(s.ids) {
+			break
+		}
+		formats := make(map[string]*diffFormat)
+		for fid, raw := range id.Infos {
+			var info map[string]interface{}
+			if json.Unmarshal(raw, &info) != nil {
+				info = map[string]interface{}{"info": string(raw)}
+			}
+			formats[fid] = &diffFormat{info: info, sigs: make(map[string][]string)}
+		}
+		for _, m := range diffMatchers {
+			raw, ok := id.Base[m]
+			if !ok {
+				continue
+			}
+			var idxs diffIndexes
+			if json.Unmarshal(raw, &idxs) != nil {
+				continue
+			}
+			for j, fid := range idxs.IDs {
+				f, ok := formats[fid]
+				if !ok {
+					f = &diffFormat{sigs: make(map[string][]string)}
+					formats[fid] = f
+				}
+				d := descs[m][idxs.Start+j]
+				sort.Strings(d)
+				f.sigs[m] = append(f.sigs[m], strings.Join(d, " "))
+			}
+		}
+		for _, f := range formats {
+			for _, sigs := range f.sigs {
+				sort.Strings(sigs)
+			}
+		}
+		ret[s.ids[i].Name()] = formats
+	}
+	return ret, nil
+}
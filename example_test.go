@@ -0,0 +1,82 @@
+package siegfried
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// promMetrics is an example adaptor that exposes siegfried metrics in the Prometheus text format.
+// With the Prometheus client library, the same methods would update prometheus.Counter and prometheus.Histogram values.
+type promMetrics struct {
+	mu       sync.Mutex
+	files    int
+	errors   int
+	bytes    int64
+	duration time.Duration
+	hits     map[core.MatcherType]int
+	buffers  int
+}
+
+func (p *promMetrics) Identified(name string, bytes int64, d time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files++
+	if err != nil {
+		p.errors++
+	}
+	p.bytes += bytes
+	p.duration += d
+}
+
+func (p *promMetrics) Hit(mt core.MatcherType) {
+	p.mu.Lock()
+	p.hits[mt]++
+	p.mu.Unlock()
+}
+
+func (p *promMetrics) Buffer(get bool) {
+	p.mu.Lock()
+	if get {
+		p.buffers++
+	} else {
+		p.buffers--
+	}
+	p.mu.Unlock()
+}
+
+// Expose writes the metrics in the Prometheus text exposition format (e.g. from a /metrics HTTP handler).
+func (p *promMetrics) Expose(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(w, "siegfried_files_total %d\n", p.files)
+	fmt.Fprintf(w, "siegfried_errors_total %d\n", p.errors)
+	fmt.Fprintf(w, "siegfried_bytes_total %d\n", p.bytes)
+	fmt.Fprintf(w, "siegfried_matcher_hits_total{matcher=\"name\"} %d\n", p.hits[core.NameMatcher])
+	fmt.Fprintf(w, "siegfried_matcher_hits_total{matcher=\"byte\"} %d\n", p.hits[core.ByteMatcher])
+	fmt.Fprintf(w, "siegfried_buffers_in_use %d\n", p.buffers)
+}
+
+func ExampleSiegfried_SetMetrics() {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	m := &promMetrics{hits: make(map[core.MatcherType]int)}
+	s.SetMetrics(m)
+	s.Identify(bytes.NewBufferString("test"), "test.doc", "")
+	buf := &bytes.Buffer{}
+	m.Expose(buf)
+	fmt.Print(buf.String())
+	// Output:
+	// siegfried_files_total 1
+	// siegfried_errors_total 0
+	// siegfried_bytes_total 4
+	// siegfried_matcher_hits_total{matcher="name"} 1
+	// siegfried_matcher_hits_total{matcher="byte"} 2
+	// siegfried_buffers_in_use 0
+}
@@ -2,14 +2,13 @@ module github.com/richardlehane/siegfried
 
 require (
 	github.com/richardlehane/characterize v1.0.0
-	github.com/richardlehane/match v1.0.0
 	github.com/richardlehane/mscfb v1.0.3
 	github.com/richardlehane/webarchive v1.0.0
 	github.com/richardlehane/xmldetect v1.0.2
 	github.com/ross-spencer/spargo v0.0.0-20200323024642-38971d4365a7
 	golang.org/x/image v0.0.0-20200922025426-e59bae62ef32
 	golang.org/x/sys v0.0.0-20200922070232-aee5d888a860
-	golang.org/x/text v0.3.3 // indirect
+	golang.org/x/text v0.3.3
 )
 
-go 1.13
+go 1.16
@@ -0,0 +1,135 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siegfried
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+// SignatureFormat is the version of the signature file format written by Save.
+//
+// Format 1 files have a header of the magic bytes and the major and minor version of the sf that wrote them,
+// followed by the compressed signature content.
+// Format 2 files follow the version with a marker byte (0xFF, which can't start a deflate stream), the format version,
+// and the minimum major and minor version of sf needed to read the content. This lets future versions of sf change the
+// signature content and have older versions fail with an error that says what version of sf is required.
+// Format 3 files include the compiled Aho-Corasick trees of the byte matchers, so that they don't need to be built each time sf starts.
+// Format 4 files save the container, XML, RIFF, byte and text matchers as sections that are loaded when they are first used.
+// Format 5 files add an EBML matcher section, after the RIFF matcher, and EBML indexes to each identifier.
+// Format 6 files add a SQLite matcher section, after the EBML matcher, and SQLite indexes to each identifier.
+// Format 7 files add a TIFF matcher section, after the SQLite matcher, and TIFF indexes to each identifier.
+// Format 8 files add a font matcher section, after the TIFF matcher, and font indexes to each identifier.
+const SignatureFormat = persist.FormatFont
+
+const (
+	formatMarker = 0xFF
+	v1HeaderLen  = 2
+	v2HeaderLen  = 6
+)
+
+// the minimum sf versions needed to read each format i.e. the release that first read it (format 1 files before
+// sf 1.9 have incompatible content)
+var sigFormats = [...][2]int{
+	1: {1, 9},
+	2: {1, 10},
+	3: {1, 10},
+	4: {1, 10},
+	5: {1, 10},
+	6: {1, 10},
+	7: {1, 10},
+	8: {1, 10},
+}
+
+// SignatureHeader describes the header of a signature file.
+type SignatureHeader struct {
+	Format   int    // signature file format version (see SignatureFormat)
+	Version  [2]int // major and minor version of the sf that wrote the file
+	Requires [2]int // minimum major and minor version of sf needed to read the file
+	Size     int    // length of the header in bytes; the compressed signature content follows
+}
+
+// ReadSignatureHeader reads the header of a signature file. It returns ErrNotSignature if buf doesn't start with a signature file header.
+// Use Check to test whether the signature file can be read by this version of siegfried.
+func ReadSignatureHeader(buf []byte) (SignatureHeader, error) {
+	magic := config.Magic()
+	if len(buf) < len(magic)+v1HeaderLen || string(buf[:len(magic)]) != string(magic) {
+		return SignatureHeader{}, ErrNotSignature
+	}
+	h := SignatureHeader{
+		Format:  1,
+		Version: [2]int{int(buf[len(magic)]), int(buf[len(magic)+1])},
+		Size:    len(magic) + v1HeaderLen,
+	}
+	if len(buf) == h.Size || buf[h.Size] != formatMarker {
+		h.Requires = sigFormats[1]
+		return h, nil
+	}
+	if len(buf) < len(magic)+v2HeaderLen {
+		return SignatureHeader{}, ErrNotSignature
+	}
+	h.Format = int(buf[h.Size+1])
+	h.Requires = [2]int{int(buf[h.Size+2]), int(buf[h.Size+3])}
+	h.Size = len(magic) + v2HeaderLen
+	return h, nil
+}
+
+// Check returns an IncompatibleError if the signature file can't be read by this version of siegfried.
+func (h SignatureHeader) Check() error {
+	v := config.Version()
+	switch {
+	case h.Format < 1 || h.Format >= len(sigFormats) || older([2]int{v[0], v[1]}, h.Requires):
+		return IncompatibleError(h)
+	case h.Format == 1 && older(h.Version, sigFormats[1]): // legacy files made by versions of sf with different signature content
+		return IncompatibleError(h)
+	}
+	return nil
+}
+
+func older(a, b [2]int) bool {
+	return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+}
+
+func writeHeader(w io.Writer) error {
+	v := config.Version()
+	req := sigFormats[SignatureFormat]
+	_, err := w.Write(append(config.Magic(), byte(v[0]), byte(v[1]), formatMarker, SignatureFormat, byte(req[0]), byte(req[1])))
+	return err
+}
+
+// IncompatibleError is returned when loading a signature file that can't be read by this version of siegfried.
+// It wraps ErrIncompatibleSignature.
+type IncompatibleError SignatureHeader
+
+func (e IncompatibleError) Error() string {
+	v := config.Version()
+	if e.Format < 1 || e.Format >= len(sigFormats) { // a format this sf doesn't know, so its Requires can't be trusted
+		return fmt.Sprintf("siegfried: signature format %d is newer than this version of sf (%d.%d.%d) supports; upgrade siegfried or run `sf -update` to get a compatible signature file",
+			e.Format, v[0], v[1], v[2])
+	}
+	if older(e.Version, sigFormats[1]) {
+		return fmt.Sprintf("siegfried: signature file was made with sf %d.%d and can't be read by this version of sf (%d.%d.%d); try running `sf -update`",
+			e.Version[0], e.Version[1], v[0], v[1], v[2])
+	}
+	return fmt.Sprintf("siegfried: signature file needs sf >= %d.%d (this is sf %d.%d.%d); upgrade siegfried or run `sf -update` to get a compatible signature file",
+		e.Requires[0], e.Requires[1], v[0], v[1], v[2])
+}
+
+func (e IncompatibleError) Unwrap() error {
+	return ErrIncompatibleSignature
+}
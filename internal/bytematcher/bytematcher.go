@@ -16,14 +16,16 @@
 package bytematcher
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 
-	wac "github.com/richardlehane/match/fwac"
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
 	"github.com/richardlehane/siegfried/internal/persist"
 	"github.com/richardlehane/siegfried/internal/priority"
 	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/internal/wac"
 	"github.com/richardlehane/siegfried/pkg/core"
 )
 
@@ -41,11 +43,12 @@ type Matcher struct {
 	maxBOF     int
 	maxEOF     int
 	priorities *priority.Set
+	// the compiled Aho-Corasick trees are persisted from signature file format 3 (see persist.FormatAutomata)
+	bmu  *sync.Once
+	emu  *sync.Once
+	bAho wac.Wac
+	eAho wac.Wac
 	// remaining fields are not persisted
-	bmu    *sync.Once
-	emu    *sync.Once
-	bAho   wac.Wac
-	eAho   wac.Wac
 	lowmem bool
 }
 
@@ -57,7 +60,7 @@ func Load(ls *persist.LoadSaver) core.Matcher {
 	if !ls.LoadBool() {
 		return nil
 	}
-	return &Matcher{
+	b := &Matcher{
 		keyFrames:  loadKeyFrames(ls),
 		tests:      loadTests(ls),
 		bofFrames:  loadFrameSet(ls),
@@ -72,6 +75,27 @@ func Load(ls *persist.LoadSaver) core.Matcher {
 		bmu:        &sync.Once{},
 		emu:        &sync.Once{},
 	}
+	if ls.Has(persist.FormatAutomata) {
+		b.bAho, b.eAho = wac.Load(ls), wac.Load(ls)
+		// mark the trees as built so they aren't rebuilt on first use
+		if b.bAho != nil {
+			b.bmu.Do(func() {})
+		}
+		if b.eAho != nil {
+			b.emu.Do(func() {})
+		}
+	}
+	return b
+}
+
+// build compiles the Aho-Corasick trees if they haven't been built or loaded already.
+func (b *Matcher) build() {
+	b.bmu.Do(func() {
+		b.bAho = wac.NewWac(b.lowmem, b.bofSeq.set)
+	})
+	b.emu.Do(func() {
+		b.eAho = wac.NewWac(b.lowmem, b.eofSeq.set)
+	})
 }
 
 // Save persists a Matcher.
@@ -93,6 +117,26 @@ func Save(c core.Matcher, ls *persist.LoadSaver) {
 	ls.SaveInt(b.maxBOF)
 	ls.SaveInt(b.maxEOF)
 	b.priorities.Save(ls)
+	b.build()
+	wac.Save(b.bAho, ls)
+	wac.Save(b.eAho, ls)
+}
+
+// MarshalJSON encodes a Matcher for export.
+func (b *Matcher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		KeyFrames  [][]keyFrame  `json:"keyFrames"`
+		Tests      []*testTree   `json:"tests"`
+		BOFFrames  *frameSet     `json:"bofFrames"`
+		EOFFrames  *frameSet     `json:"eofFrames"`
+		BOFSeq     *seqSet       `json:"bofSeq"`
+		EOFSeq     *seqSet       `json:"eofSeq"`
+		KnownBOF   int           `json:"knownBOF"`
+		KnownEOF   int           `json:"knownEOF"`
+		MaxBOF     int           `json:"maxBOF"`
+		MaxEOF     int           `json:"maxEOF"`
+		Priorities *priority.Set `json:"priorities"`
+	}{b.keyFrames, b.tests, b.bofFrames, b.eofFrames, b.bofSeq, b.eofSeq, b.knownBOF, b.knownEOF, b.maxBOF, b.maxEOF, b.priorities})
 }
 
 type sigErrors []error
@@ -128,7 +172,7 @@ func Add(c core.Matcher, ss core.SignatureSet, priorities priority.List) (core.M
 	}
 	sigs, ok := ss.(SignatureSet)
 	if !ok {
-		return nil, -1, fmt.Errorf("Byte matcher: can't convert signature set to BM signature set")
+		return nil, -1, fmt.Errorf("Byte matcher: %w", core.ErrSignatureSet)
 	}
 	if len(sigs) == 0 {
 		return c, len(b.keyFrames), nil // return same matcher as given (may be nil) if no signatures to add
@@ -169,8 +213,17 @@ func Add(c core.Matcher, ss core.SignatureSet, priorities priority.List) (core.M
 //     }
 //   }
 func (b *Matcher) Identify(name string, sb *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	return b.IdentifyContext(context.Background(), name, sb, hints...)
+}
+
+// IdentifyContext is like Identify but stops scanning, and closes the returned channel, when the context is done.
+func (b *Matcher) IdentifyContext(ctx context.Context, name string, sb *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
 	quit, ret := make(chan struct{}), make(chan core.Result)
-	go b.identify(sb, quit, ret, hints...)
+	if err := ctx.Err(); err != nil {
+		close(ret)
+		return ret, err
+	}
+	go b.identify(ctx, sb, quit, ret, hints...)
 	return ret, nil
 }
 
@@ -2,6 +2,7 @@ package bytematcher
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"testing"
 
@@ -34,6 +35,16 @@ func TestIO(t *testing.T) {
 	if string(nsaver.Bytes()) != string(saver.Bytes()) {
 		t.Errorf("Load bytematcher: expecting first bytematcher (%v), to equal second bytematcher (%v)", bm.String(), newbm.String())
 	}
+	// the loaded bytematcher should match using its persisted Aho-Corasick trees
+	buf, _ := siegreader.New().Get(bytes.NewBuffer(TestSample1))
+	res, _ := newbm.Identify("", buf)
+	results := make([]core.Result, 0)
+	for i := range res {
+		results = append(results, i)
+	}
+	if !contains(results, []int{0, 2, 3, 4}) {
+		t.Errorf("Loaded bytematcher: missing result, got: %v, expecting:%v\n", results, newbm)
+	}
 }
 
 func contains(a []core.Result, b []int) bool {
@@ -82,3 +93,65 @@ func TestMatch(t *testing.T) {
 		t.Errorf("Missing result, got: %v, expecting:%v\n", results, bm)
 	}
 }
+
+func TestIdentifyContext(t *testing.T) {
+	bm, _, err := Add(nil, SignatureSet(tests.TestSignatures), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bufs := siegreader.New()
+	buf, err := bufs.Get(bytes.NewBuffer(TestSample1))
+	if err != nil && err != io.EOF {
+		t.Error(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := bm.(*Matcher).IdentifyContext(ctx, "", buf)
+	if err != context.Canceled {
+		t.Errorf("expecting a cancelled error, got %v", err)
+	}
+	for r := range res {
+		t.Errorf("expecting no results from a cancelled context, got %v", r)
+	}
+}
+
+// streams are scanned sequentially and sources that can be read at any offset in parallel: both should give the same results
+func TestStreamAndReaderAt(t *testing.T) {
+	bm, _, err := Add(nil, SignatureSet(tests.TestSignatures), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bufs := siegreader.New()
+	indexes := func(buf *siegreader.Buffer) map[int]bool {
+		res, _ := bm.Identify("", buf)
+		ret := make(map[int]bool)
+		for r := range res {
+			ret[r.Index()] = true
+		}
+		bufs.Put(buf)
+		return ret
+	}
+	buf, err := bufs.Get(struct{ io.Reader }{bytes.NewReader(TestSample2)})
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if !buf.Stream() {
+		t.Fatal("expecting a stream buffer")
+	}
+	stream := indexes(buf)
+	if buf, err = bufs.GetReaderAt(bytes.NewReader(TestSample2), int64(len(TestSample2))); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if buf.Stream() {
+		t.Fatal("expecting a buffer that can be read at any offset")
+	}
+	rdrAt := indexes(buf)
+	if len(stream) != 5 || len(rdrAt) != len(stream) {
+		t.Fatalf("expecting 5 results for both the stream and the ReaderAt, got %v and %v", stream, rdrAt)
+	}
+	for k := range stream {
+		if !rdrAt[k] {
+			t.Errorf("missing result %d for the ReaderAt", k)
+		}
+	}
+}
@@ -17,6 +17,9 @@
 package frames
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"strconv"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
@@ -132,6 +135,55 @@ func TotalLength(f Frame) int {
 	return l + f.Max
 }
 
+// literal returns the byte sequence of a frame whose pattern is a plain or BMH sequence.
+// Frames with literal patterns are matched with bytes.Index, which uses vectorised (e.g. AVX2) search where the
+// platform supports it, rather than testing the pattern at each offset.
+func (f Frame) literal() (patterns.Sequence, bool) {
+	switch p := f.Pattern.(type) {
+	case patterns.Sequence:
+		return p, len(p) > 0
+	case *patterns.BMHSequence:
+		return p.Seq, len(p.Seq) > 0
+	case *patterns.RBMHSequence:
+		return p.Seq, len(p.Seq) > 0
+	}
+	return nil, false
+}
+
+// index returns the offset of the first match of a literal sequence that starts between min and max, or -1
+func index(b []byte, seq patterns.Sequence, min, max int) int {
+	end := max + len(seq)
+	if end > len(b) {
+		end = len(b)
+	}
+	if min > end {
+		return -1
+	}
+	idx := bytes.Index(b[min:end], seq)
+	if idx < 0 {
+		return -1
+	}
+	return min + idx
+}
+
+// indexR returns the offset from the end of b of the last match of a literal sequence that ends between min and max
+// bytes from the end, or -1
+func indexR(b []byte, seq patterns.Sequence, min, max int) int {
+	start := len(b) - max - len(seq)
+	if start < 0 {
+		start = 0
+	}
+	end := len(b) - min
+	if end < start {
+		return -1
+	}
+	idx := bytes.LastIndex(b[start:end], seq)
+	if idx < 0 {
+		return -1
+	}
+	return end - start - idx - len(seq) + min
+}
+
 // Match the enclosed pattern against the byte slice in a L-R direction.
 // Returns a slice of offsets for where a successive match by a related frame should begin.
 func (f Frame) Match(b []byte) []int {
@@ -140,6 +192,20 @@ func (f Frame) Match(b []byte) []int {
 	if max < 0 || max > len(b) {
 		max = len(b)
 	}
+	if seq, ok := f.literal(); ok {
+		for min <= max {
+			if min = index(b, seq, min, max); min < 0 {
+				break
+			}
+			ret = append(ret, min+len(seq))
+			_, adv := f.Test(b[min:])
+			if adv < 1 {
+				break
+			}
+			min += adv
+		}
+		return ret
+	}
 	for min <= max {
 		lengths, adv := f.Test(b[min:])
 		for _, l := range lengths {
@@ -160,6 +226,23 @@ func (f Frame) MatchN(b []byte, n int) (int, int) {
 	if max < 0 || max > len(b) {
 		max = len(b)
 	}
+	if seq, ok := f.literal(); ok {
+		for min <= max {
+			if min = index(b, seq, min, max); min < 0 {
+				break
+			}
+			_, adv := f.Test(b[min:])
+			if i == n {
+				return min + len(seq), min + adv
+			}
+			i++
+			if adv < 1 {
+				break
+			}
+			min += adv
+		}
+		return -1, 0
+	}
 	for min <= max {
 		lengths, adv := f.Test(b[min:])
 		for _, l := range lengths {
@@ -183,6 +266,20 @@ func (f Frame) MatchR(b []byte) []int {
 	if max < 0 || max > len(b) {
 		max = len(b)
 	}
+	if seq, ok := f.literal(); ok {
+		for min <= max {
+			if min = indexR(b, seq, min, max); min < 0 {
+				break
+			}
+			ret = append(ret, min+len(seq))
+			_, adv := f.TestR(b[:len(b)-min])
+			if adv < 1 {
+				break
+			}
+			min += adv
+		}
+		return ret
+	}
 	for min <= max {
 		lengths, adv := f.TestR(b[:len(b)-min])
 		for _, l := range lengths {
@@ -203,6 +300,23 @@ func (f Frame) MatchNR(b []byte, n int) (int, int) {
 	if max < 0 || max > len(b) {
 		max = len(b)
 	}
+	if seq, ok := f.literal(); ok {
+		for min <= max {
+			if min = indexR(b, seq, min, max); min < 0 {
+				break
+			}
+			_, adv := f.TestR(b[:len(b)-min])
+			if i == n {
+				return min + len(seq), min + adv
+			}
+			i++
+			if adv < 1 {
+				break
+			}
+			min += adv
+		}
+		return -1, 0
+	}
 	for min <= max {
 		lengths, adv := f.TestR(b[:len(b)-min])
 		for _, l := range lengths {
@@ -302,6 +416,20 @@ func (f Frame) Save(ls *persist.LoadSaver) {
 	f.Pattern.Save(ls)
 }
 
+// MarshalJSON encodes a frame for export. Alongside the readable pattern description,
+// the pattern's persisted encoding is given (as hex) so that the export is lossless.
+func (f Frame) MarshalJSON() ([]byte, error) {
+	ls := persist.NewLoadSaver(nil)
+	f.Pattern.Save(ls)
+	return json.Marshal(struct {
+		Type     string `json:"type"`
+		Min      int    `json:"min"`
+		Max      int    `json:"max"`
+		Pattern  string `json:"pattern"`
+		Encoding string `json:"encoding"`
+	}{OffString[f.OffType], f.Min, f.Max, f.Pattern.String(), hex.EncodeToString(ls.Bytes())})
+}
+
 func Load(ls *persist.LoadSaver) Frame {
 	return Frame{
 		ls.LoadInt(),
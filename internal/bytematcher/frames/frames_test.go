@@ -1,10 +1,13 @@
 package frames_test
 
 import (
+	"math/rand"
+	"reflect"
 	"testing"
 
 	. "github.com/richardlehane/siegfried/internal/bytematcher/frames"
 	. "github.com/richardlehane/siegfried/internal/bytematcher/frames/tests"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
 	. "github.com/richardlehane/siegfried/internal/bytematcher/patterns/tests"
 )
 
@@ -82,3 +85,51 @@ func TestWildMin(t *testing.T) {
 		t.Errorf("WildMin fail: MaxMatches should have rem value 1, got %d", rem)
 	}
 }
+
+// literal frames are matched with bytes.Index; check they give the same results as testing at each offset (a Choice of one sequence isn't literal)
+func TestLiteralMatch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	rndBytes := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "ab"[rnd.Intn(2)]
+		}
+		return b
+	}
+	for i := 0; i < 2000; i++ {
+		seq := patterns.Sequence(rndBytes(1 + rnd.Intn(4)))
+		b := rndBytes(rnd.Intn(40))
+		min, max := rnd.Intn(10), rnd.Intn(30)-5
+		if max >= 0 && max < min {
+			min, max = max, min
+		}
+		slow := NewFrame(BOF, patterns.Choice{seq}, min, max)
+		for _, pat := range []patterns.Pattern{seq, patterns.NewBMHSequence(seq), patterns.NewRBMHSequence(seq)} {
+			fast := NewFrame(BOF, pat, min, max)
+			if m, n := fast.Match(b), slow.Match(b); !reflect.DeepEqual(m, n) {
+				t.Fatalf("Match %s in %q (%d-%d): expecting %v, got %v", fast, b, min, max, n, m)
+			}
+			if m, n := fast.MatchR(b), slow.MatchR(b); !reflect.DeepEqual(m, n) {
+				t.Fatalf("MatchR %s in %q (%d-%d): expecting %v, got %v", fast, b, min, max, n, m)
+			}
+			if m, _ := fast.MatchN(b, 1); m != func() int { n, _ := slow.MatchN(b, 1); return n }() {
+				t.Fatalf("MatchN %s in %q (%d-%d): got %d", fast, b, min, max, m)
+			}
+			if m, _ := fast.MatchNR(b, 1); m != func() int { n, _ := slow.MatchNR(b, 1); return n }() {
+				t.Fatalf("MatchNR %s in %q (%d-%d): got %d", fast, b, min, max, m)
+			}
+		}
+	}
+}
+
+func BenchmarkWildMatch(b *testing.B) {
+	buf := make([]byte, 1<<20)
+	for i := range buf {
+		buf[i] = byte(i % 251)
+	}
+	f := NewFrame(BOF, patterns.NewBMHSequence(patterns.Sequence("%%EOF")))
+	b.SetBytes(int64(len(buf)))
+	for i := 0; i < b.N; i++ {
+		f.Match(buf)
+	}
+}
@@ -15,17 +15,42 @@
 package bytematcher
 
 import (
+	"context"
 	"fmt"
 
-	wac "github.com/richardlehane/match/fwac"
 	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/internal/wac"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/core"
 )
 
 // identify function - brings a new matcher into existence
-func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan core.Result, hints ...core.Hint) {
+func (b *Matcher) identify(ctx context.Context, buf *siegreader.Buffer, quit chan struct{}, r chan core.Result, hints ...core.Hint) {
 	buf.Quit = quit
+	var (
+		incoming     chan<- strike
+		bchan, echan chan wac.Result
+	)
+	// a panic on a pathological file is reported as a failure. The sequence matchers are drained, so they are done
+	// with the buffer before the results are closed.
+	defer func() {
+		if v := recover(); v != nil {
+			r <- panicked(v)
+			if bchan != nil {
+				for range bchan {
+				}
+			}
+			if echan != nil {
+				for range echan {
+				}
+			}
+			if incoming == nil {
+				close(r)
+				return
+			}
+			close(incoming)
+		}
+	}()
 	waitSet := b.priorities.WaitSet(hints...)
 	maxBOF, maxEOF := b.maxBOF, b.maxEOF
 	if len(hints) > 0 {
@@ -40,11 +65,23 @@ func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan co
 			maxBOF, maxEOF = waitSet.MaxOffsets()
 		}
 	}
-	incoming := b.scorer(buf, waitSet, quit, r)
+	// apply any runtime scan limits set for the buffer
+	var noEOF bool
+	if lb, le := buf.ScanLimits(); lb > 0 || le != 0 {
+		if lb > 0 && (maxBOF < 0 || maxBOF > lb) {
+			maxBOF = lb
+		}
+		if le < 0 {
+			maxEOF, noEOF = 0, true
+		} else if le > 0 && (maxEOF < 0 || maxEOF > le) {
+			maxEOF = le
+		}
+	}
+	incoming = b.scorer(buf, waitSet, quit, r, ctx.Done())
 	rdr := siegreader.LimitReaderFrom(buf, maxBOF)
 	// First test BOF frameset
-	bfchan := b.bofFrames.index(buf, false, quit)
-	for bf := range bfchan {
+	fms := b.bofFrames.index(buf, false, quit, nil)
+	for _, bf := range fms {
 		if config.Debug() {
 			fmt.Fprintln(config.Out(), strike{b.bofFrames.testTreeIndex[bf.idx], 0, bf.off, bf.length, false, true})
 		}
@@ -52,19 +89,27 @@ func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan co
 	}
 	select {
 	case <-quit: // the matcher has called quit
-		for range bfchan {
-		} // drain first
 		close(incoming)
 		return
 	default:
 	}
+	// EOF frames are matched once the EOF is available
+	eofFrames := func() {
+		if noEOF {
+			return
+		}
+		for _, ef := range b.eofFrames.index(buf, true, quit, fms[:0]) {
+			if config.Debug() {
+				fmt.Fprintln(config.Out(), strike{b.eofFrames.testTreeIndex[ef.idx], 0, ef.off, ef.length, true, true})
+			}
+			incoming <- strike{b.eofFrames.testTreeIndex[ef.idx], 0, ef.off, ef.length, true, true}
+		}
+	}
 
 	// start bof matcher if not yet started
 	b.bmu.Do(func() {
 		b.bAho = wac.NewWac(b.lowmem, b.bofSeq.set)
 	})
-	var bchan chan wac.Result
-
 	// Do an initial check of BOF sequences
 	bchan = b.bAho.Index(rdr)
 	for br := range bchan {
@@ -90,24 +135,19 @@ func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan co
 	}
 
 	// Setup EOF tests
-	efchan := b.eofFrames.index(buf, true, quit)
 	b.emu.Do(func() {
 		b.eAho = wac.NewWac(b.lowmem, b.eofSeq.set)
 	})
 	rrdr := siegreader.LimitReverseReaderFrom(buf, maxEOF)
-	echan := b.eAho.Index(rrdr)
+	echan = b.eAho.Index(rrdr)
 
-	// if we have a maximum value on EOF do a sequential search
-	if maxEOF >= 0 {
+	// if we have a maximum value on EOF and the source is a stream do a sequential search
+	stream := buf.Stream()
+	if maxEOF >= 0 && stream {
 		if maxEOF != 0 {
 			_, _ = buf.CanSeek(0, true) // force a full read to enable EOF scan to proceed for streams
 		}
-		for ef := range efchan {
-			if config.Debug() {
-				fmt.Fprintln(config.Out(), strike{b.eofFrames.testTreeIndex[ef.idx], 0, ef.off, ef.length, true, true})
-			}
-			incoming <- strike{b.eofFrames.testTreeIndex[ef.idx], 0, ef.off, ef.length, true, true}
-		}
+		eofFrames()
 		// Scan complete EOF
 		for er := range echan {
 			if er.Index[0] == -1 {
@@ -135,7 +175,11 @@ func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan co
 		close(incoming)
 		return
 	}
-	// If no maximum on EOF do a parallel search
+	// Otherwise do a parallel search: the rest of the BOF is scanned at the same time as the EOF
+	// (the EOF frames of streams are matched when the BOF scan is done)
+	if !stream {
+		eofFrames()
+	}
 	for {
 		select {
 		case br, ok := <-bchan:
@@ -143,6 +187,9 @@ func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan co
 				if maxBOF < 0 && maxEOF != 0 {
 					_, _ = buf.CanSeek(0, true) // if we've a limit BOF reader, force a full read to enable EOF scan to proceed for streams
 				}
+				if stream {
+					eofFrames()
+				}
 				bchan = nil
 			} else {
 				if br.Index[0] == -1 {
@@ -154,17 +201,11 @@ func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan co
 					incoming <- strike{b.bofSeq.testTreeIndex[br.Index[0]], br.Index[1], br.Offset, br.Length, false, false}
 				}
 			}
-		case ef, ok := <-efchan:
-			if !ok {
-				efchan = nil
-			} else {
-				if config.Debug() {
-					fmt.Fprintln(config.Out(), strike{b.eofFrames.testTreeIndex[ef.idx], 0, ef.off, ef.length, true, true})
-				}
-				incoming <- strike{b.eofFrames.testTreeIndex[ef.idx], 0, ef.off, ef.length, true, true}
-			}
 		case er, ok := <-echan:
 			if !ok {
+				if maxEOF >= 0 {
+					incoming <- progressStrike(int64(maxEOF), true) // send a final progress strike with the maximum EOF
+				}
 				echan = nil
 			} else {
 				if er.Index[0] == -1 {
@@ -177,7 +218,7 @@ func (b *Matcher) identify(buf *siegreader.Buffer, quit chan struct{}, r chan co
 				}
 			}
 		}
-		if bchan == nil && efchan == nil && echan == nil {
+		if bchan == nil && echan == nil {
 			close(incoming)
 			return
 		}
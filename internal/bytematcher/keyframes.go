@@ -15,6 +15,7 @@
 package bytematcher
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
@@ -81,6 +82,24 @@ func saveKeyFrames(ls *persist.LoadSaver, kfs [][]keyFrame) {
 
 }
 
+// keyFramePos and keyFrame are marshalled as part of a Matcher export.
+func (kp keyFramePos) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		PMin int64 `json:"pMin"`
+		PMax int64 `json:"pMax"`
+		LMin int   `json:"lMin"`
+		LMax int   `json:"lMax"`
+	}{kp.pMin, kp.pMax, kp.lMin, kp.lMax})
+}
+
+func (kf keyFrame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string      `json:"type"`
+		Seg  keyFramePos `json:"seg"`
+		Key  keyFramePos `json:"key"`
+	}{frames.OffString[kf.typ], kf.seg, kf.key})
+}
+
 func (kf keyFrame) String() string {
 	return fmt.Sprintf("%s Seg Min:%d Seg Max:%d; Abs Min:%d Abs Max:%d", frames.OffString[kf.typ], kf.seg.pMin, kf.seg.pMax, kf.key.pMin, kf.key.pMax)
 }
@@ -115,10 +134,11 @@ func (k *kfFilter) Mark(t bool) {
 	}
 }
 
-func filterKF(kfs []keyFrameID, ws *priority.WaitSet) []keyFrameID {
-	f := &kfFilter{kfs: kfs, nfs: make([]keyFrameID, len(kfs))}
+// filterKF filters kfs in place, re-using f
+func filterKF(f *kfFilter, kfs []keyFrameID, ws *priority.WaitSet) []keyFrameID {
+	f.idx, f.fdx, f.kfs, f.nfs = 0, 0, kfs, kfs
 	ws.ApplyFilter(f)
-	return f.nfs[:f.fdx]
+	return kfs[:f.fdx]
 }
 
 // Turn a signature segment into a keyFrame and left and right frame slices.
@@ -15,14 +15,20 @@
 package bytematcher
 
 import (
+	"bytes"
 	"fmt"
 
-	wac "github.com/richardlehane/match/fwac"
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/wac"
 	"github.com/richardlehane/siegfried/pkg/config"
 )
 
 func (b *Matcher) addSignature(sig frames.Signature) error {
+	// apply config bom option
+	if config.BOM() {
+		sig = bomTolerant(sig)
+	}
 	// todo: add cost to the Segment - or merge segments based on cost?
 	segments := sig.Segment(config.Distance(), config.Range(), config.Cost(), config.Repetition())
 	// apply config no eof option
@@ -112,6 +118,48 @@ func (b *Matcher) addSignature(sig frames.Signature) error {
 	return nil
 }
 
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// bomTolerant returns a signature whose first frame also matches after a UTF-8 byte-order mark, if that frame is
+// a text pattern (e.g. "<?xml") fixed at the beginning of file. Editors that save as "UTF-8 with BOM" otherwise
+// shift text formats such as XML, CSV and source code out of reach of their signatures.
+func bomTolerant(sig frames.Signature) frames.Signature {
+	if len(sig) == 0 || sig[0].Orientation() != frames.BOF || sig[0].Min != 0 || sig[0].Max != 0 {
+		return sig
+	}
+	seqs := sig[0].Sequences()
+	if len(seqs) == 0 {
+		return sig
+	}
+	choice := make(patterns.Choice, 0, len(seqs)*2)
+	for _, seq := range seqs {
+		if !textual(seq) {
+			return sig
+		}
+		choice = append(choice, seq)
+	}
+	for _, seq := range seqs {
+		choice = append(choice, patterns.Sequence(append(append([]byte{}, utf8BOM...), seq...)))
+	}
+	ret := make(frames.Signature, len(sig))
+	copy(ret, sig)
+	ret[0] = frames.NewFrame(frames.BOF, choice, 0, 0)
+	return ret
+}
+
+// textual reports whether a sequence is printable ASCII or whitespace, and so may follow a BOM in a text file
+func textual(seq patterns.Sequence) bool {
+	if len(seq) == 0 || bytes.HasPrefix(seq, utf8BOM) {
+		return false
+	}
+	for _, c := range seq {
+		if (c < 0x20 || c > 0x7E) && c != '\t' && c != '\r' && c != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
 type cluster struct {
 	rev    bool
 	kfs    []keyFrame
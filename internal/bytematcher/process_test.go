@@ -1,13 +1,17 @@
 package bytematcher
 
 import (
+	"bytes"
 	"sync"
 	"testing"
 
-	wac "github.com/richardlehane/match/fwac"
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames/tests"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
 	"github.com/richardlehane/siegfried/internal/persist"
 	"github.com/richardlehane/siegfried/internal/priority"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/internal/wac"
 	"github.com/richardlehane/siegfried/pkg/config"
 )
 
@@ -65,11 +69,11 @@ func TestProcess(t *testing.T) {
 	if len(b.bofSeq.set) != 4 {
 		t.Errorf("Expecting 4 BOF seqs, got %d", len(b.bofSeq.set))
 	}
-	e1 := wac.Seq{[]int64{0}, []wac.Choice{{[]byte{'t', 'e', 's', 't'}}}}
+	e1 := wac.Seq{MaxOffsets: []int64{0}, Choices: []wac.Choice{{[]byte{'t', 'e', 's', 't'}}}}
 	if !seqEquals(b.bofSeq.set[0], e1) {
 		t.Errorf("Expecting %v to equal %v", b.bofSeq.set[0], e1)
 	}
-	e2 := wac.Seq{[]int64{-1}, []wac.Choice{{[]byte{'t', 'e', 's', 't'}}}}
+	e2 := wac.Seq{MaxOffsets: []int64{-1}, Choices: []wac.Choice{{[]byte{'t', 'e', 's', 't'}}}}
 	if seqEquals(b.bofSeq.set[0], e2) {
 		t.Errorf("Not expecting %v to equal %v", b.bofSeq.set[0], e2)
 	}
@@ -143,3 +147,41 @@ func TestProcessFmt363(t *testing.T) {
 		}
 	}
 }
+
+func TestBOM(t *testing.T) {
+	xml := frames.Signature{
+		frames.NewFrame(frames.BOF, patterns.Sequence("<?xml"), 0, 0),
+		frames.NewFrame(frames.PREV, patterns.Sequence("<root"), 0),
+	}
+	png := frames.Signature{frames.NewFrame(frames.BOF, patterns.Sequence("\x89PNG"), 0, 0)}
+	if len(bomTolerant(png)[0].Sequences()) != 1 {
+		t.Error("BOM: expecting a binary signature to be left alone")
+	}
+	config.SetBOM()()
+	defer config.Reset()()
+	bm, _, err := Add(nil, SignatureSet{xml, png}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bufs := siegreader.New()
+	for _, test := range []struct {
+		sample string
+		expect int
+	}{
+		{"<?xml version=\"1.0\"?><root/>", 0},
+		{"\xEF\xBB\xBF<?xml version=\"1.0\"?><root/>", 0},
+		{"\x89PNG", 1},
+		{"\xEF\xBB\xBF\x89PNG", -1},
+		{"abc<?xml version=\"1.0\"?><root/>", -1},
+	} {
+		buf, _ := bufs.Get(bytes.NewBufferString(test.sample))
+		res, _ := bm.Identify("", buf)
+		got := -1
+		for r := range res {
+			got = r.Index()
+		}
+		if got != test.expect {
+			t.Errorf("BOM: expecting %q to match %d, got %d", test.sample, test.expect, got)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package bytematcher
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
 	"github.com/richardlehane/siegfried/internal/priority"
@@ -89,8 +90,25 @@ type hitItem struct {
 	matched       bool         // if we've already matched, mark so don't return
 }
 
+const hitChunk = 16
+
+// scratch space for scoring the strikes of a file, pooled so that it is re-used from file to file
+type scratch struct {
+	filter kfFilter     // filters keyframes against the waitset
+	kfs    []keyFrameID // the keyframes of each strike
+}
+
+var scratchPool = sync.Pool{New: func() interface{} { return &scratch{} }}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // search a set of partials for a complete match
-func searchPartials(partials [][][2]int64, kfs []keyFrame) (bool, string) {
+func searchPartials(partials [][][2]int64, kfs []keyFrame) (bool, [][2]int64) {
 	res := make([][][2]int64, len(partials))
 	idxs := make([][]int, len(partials))
 	prevOff := partials[0]
@@ -104,7 +122,7 @@ func searchPartials(partials [][][2]int64, kfs []keyFrame) (bool, string) {
 		}
 		prevOff, idx, ok = checkRelated(kf, kfs[i], nextKf, partials[i+1], prevOff)
 		if !ok {
-			return false, ""
+			return false, nil
 		}
 		res[i+1] = prevOff
 		idxs[i+1] = idx
@@ -118,7 +136,7 @@ func searchPartials(partials [][][2]int64, kfs []keyFrame) (bool, string) {
 			j = idxs[i-1][j]
 		}
 	}
-	return true, fmt.Sprintf("byte match at %v", basis)
+	return true, basis
 }
 
 // returns the next strike for testing and true if should continue/false if done
@@ -187,9 +205,10 @@ type partial struct {
 }
 
 // result is the bytematcher implementation of the Result interface.
+// The basis is only formatted if it is reported.
 type result struct {
 	index int
-	basis string
+	basis [][2]int64 // offsets and lengths of the matching keyframes
 }
 
 func (r result) Index() int {
@@ -197,10 +216,30 @@ func (r result) Index() int {
 }
 
 func (r result) Basis() string {
-	return r.basis
+	if len(r.basis) == 1 {
+		return fmt.Sprintf("byte match at %d, %d", r.basis[0][0], r.basis[0][1])
+	}
+	return fmt.Sprintf("byte match at %v", r.basis)
+}
+
+// failure is the bytematcher implementation of the FailedResult interface. It is sent, in place of any more results,
+// when a scan panics.
+type failure struct {
+	err error
+}
+
+func (f failure) Index() int { return -1 }
+
+func (f failure) Basis() string { return "" }
+
+func (f failure) Err() error { return f.err }
+
+func panicked(v interface{}) failure {
+	return failure{core.ClassifyError(core.ClassPanic, fmt.Errorf("bytematcher: scan panicked: %v", v))}
 }
 
-func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q chan struct{}, r chan<- core.Result) chan<- strike {
+// the scorer quits if the done channel is closed (e.g. when a context is cancelled); done may be nil
+func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q chan struct{}, r chan<- core.Result, done <-chan struct{}) chan<- strike {
 	incoming := make(chan strike)
 	hits := make(map[int]*hitItem)
 	strikes := make(map[int]*strikeItem)
@@ -214,12 +253,28 @@ func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q ch
 		quitting = true
 	}
 
+	// hitItems, and their slices, are carved from chunks to save allocations
+	var (
+		items []hitItem
+		idxs  []int
+		parts [][][2]int64
+	)
 	newHit := func(i int) *hitItem {
 		l := len(b.keyFrames[i])
-		hit := &hitItem{
-			potentialIdxs: make([]int, l),
-			partials:      make([][][2]int64, l),
+		if len(items) == cap(items) {
+			items = make([]hitItem, 0, hitChunk)
+		}
+		if cap(idxs)-len(idxs) < l {
+			idxs = make([]int, 0, max(l, hitChunk*4))
+			parts = make([][][2]int64, 0, max(l, hitChunk*4))
 		}
+		n := len(idxs)
+		idxs, parts = idxs[:n+l], parts[:n+l]
+		items = append(items, hitItem{
+			potentialIdxs: idxs[n : n+l : n+l],
+			partials:      parts[n : n+l : n+l],
+		})
+		hit := &items[len(items)-1]
 		hits[i] = hit
 		return hit
 	}
@@ -380,10 +435,10 @@ func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q ch
 		return res
 	}
 
-	applyKeyFrame := func(hit kfHit) (bool, string) {
+	applyKeyFrame := func(hit kfHit) (bool, [][2]int64) {
 		kfs := b.keyFrames[hit.id[0]]
 		if len(kfs) == 1 {
-			return true, fmt.Sprintf("byte match at %d, %d", hit.offset, hit.length)
+			return true, [][2]int64{{hit.offset, int64(hit.length)}}
 		}
 		h, ok := hits[hit.id[0]]
 		if !ok {
@@ -396,14 +451,54 @@ func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q ch
 		}
 		for _, p := range h.partials {
 			if p == nil {
-				return false, ""
+				return false, nil
 			}
 		}
 		return searchPartials(h.partials, kfs)
 	}
 
+	// when done is closed, quit and stop listening to it (a nil channel blocks) so we just drain incoming from now on
+	stop := func() {
+		if !quitting {
+			quit()
+		}
+		done = nil
+	}
+
 	go func() {
-		for in := range incoming {
+		sc := scratchPool.Get().(*scratch)
+		defer scratchPool.Put(sc)
+		// a panic on a pathological file is reported as a failure: stop the scan and let the matchers finish
+		defer func() {
+			if v := recover(); v != nil {
+				r <- panicked(v)
+				if !quitting {
+					quit()
+				}
+				for range incoming {
+				}
+				close(r)
+			}
+		}()
+		for {
+			var in strike
+			select {
+			case <-done:
+				stop()
+				continue
+			case i, ok := <-incoming:
+				if !ok {
+					close(r)
+					return
+				}
+				in = i
+				// done takes priority over any strikes that were ready at the same time
+				select {
+				case <-done:
+					stop()
+				default:
+				}
+			}
 			// if we've got a positive result, drain any remaining strikes from the matchers
 			if quitting {
 				continue
@@ -434,7 +529,8 @@ func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q ch
 			}
 			// HANDLE MATCH STRIKES
 			var hasPotential bool
-			potentials := filterKF(b.tests[in.idxa+in.idxb].keyFrames(), waitSet)
+			sc.kfs = b.tests[in.idxa+in.idxb].keyFrames(sc.kfs[:0])
+			potentials := filterKF(&sc.filter, sc.kfs, waitSet)
 			for _, pot := range potentials {
 				// if any of the signatures are single keyframe we can satisfy immediately and skip cache
 				if len(b.keyFrames[pot[0]]) == 1 {
@@ -488,7 +584,7 @@ func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q ch
 					}
 				}
 				// given waitset, check if any potential matches remain to wait for
-				potentials = filterKF(potentials, waitSet)
+				potentials = filterKF(&sc.filter, potentials, waitSet)
 				var ok bool
 				for _, pot := range potentials {
 					in, ok = hits[pot[0]].nextPotential(strikes)
@@ -502,7 +598,6 @@ func (b *Matcher) scorer(buf *siegreader.Buffer, waitSet *priority.WaitSet, q ch
 			}
 		end: // keep looping until incoming is closed
 		}
-		close(r)
 	}()
 	return incoming
 }
@@ -18,7 +18,7 @@ func setup() (chan<- strike, <-chan core.Result) {
 	buf, _ := bufs.Get(bytes.NewBuffer(TestSample1))
 	buf.SizeNow()
 	res := make(chan core.Result)
-	return bm.scorer(buf, bm.priorities.WaitSet(), make(chan struct{}), res), res
+	return bm.scorer(buf, bm.priorities.WaitSet(), make(chan struct{}), res, nil), res
 }
 
 func TestScorer(t *testing.T) {
@@ -31,6 +31,24 @@ func TestScorer(t *testing.T) {
 	}
 }
 
+func TestScorerDone(t *testing.T) {
+	m, _, _ := Add(nil, SignatureSet(tests.TestSignatures), nil)
+	bm := m.(*Matcher)
+	bufs := siegreader.New()
+	buf, _ := bufs.Get(bytes.NewBuffer(TestSample1))
+	buf.SizeNow()
+	res, done := make(chan core.Result), make(chan struct{})
+	scorer := bm.scorer(buf, bm.priorities.WaitSet(), make(chan struct{}), res, done)
+	close(done)
+	scorer <- strike{0, 0, 0, 4, false, false}
+	scorer <- strike{1, 0, 17, 9, true, false}
+	scorer <- strike{1, 1, 30, 5, true, false}
+	close(scorer)
+	for r := range res {
+		t.Errorf("expecting no results once done, got %d", r.Index())
+	}
+}
+
 // 2 Jan 17 BenchmarkScorer   	   20000	    111048 ns/op
 func BenchmarkScorer(bench *testing.B) {
 	for i := 0; i < bench.N; i++ {
@@ -57,7 +75,7 @@ func TestIssue94(t *testing.T) {
 	buf, _ := bufs.Get(bytes.NewBuffer(sheetPDF))
 	buf.SizeNow()
 	res := make(chan core.Result)
-	incoming := bm.scorer(buf, bm.priorities.WaitSet(), make(chan struct{}), res)
+	incoming := bm.scorer(buf, bm.priorities.WaitSet(), make(chan struct{}), res, nil)
 	incoming <- strike{0, 0, 0, 2, false, false}
 	if r := <-res; r.Index() != 0 {
 		t.Errorf("expecing result %d, got %d", 0, r.Index())
@@ -16,12 +16,14 @@ package bytematcher
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 
-	wac "github.com/richardlehane/match/fwac"
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
 	"github.com/richardlehane/siegfried/internal/persist"
 	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/internal/wac"
 )
 
 // Sequence Sets and Frame Sets
@@ -49,6 +51,30 @@ func (ss *seqSet) save(ls *persist.LoadSaver) {
 	ls.SaveInts(ss.testTreeIndex)
 }
 
+// MarshalJSON encodes a sequence set as part of a Matcher export.
+// Byte sequences are stringified (quoted ASCII or hex).
+func (ss *seqSet) MarshalJSON() ([]byte, error) {
+	type seq struct {
+		MaxOffsets []int64    `json:"maxOffsets"`
+		Choices    [][]string `json:"choices"`
+	}
+	set := make([]seq, len(ss.set))
+	for i, v := range ss.set {
+		set[i].MaxOffsets = v.MaxOffsets
+		set[i].Choices = make([][]string, len(v.Choices))
+		for j, w := range v.Choices {
+			set[i].Choices[j] = make([]string, len(w))
+			for k, x := range w {
+				set[i].Choices[j][k] = patterns.Stringify(x)
+			}
+		}
+	}
+	return json.Marshal(struct {
+		Set           []seq `json:"set"`
+		TestTreeIndex []int `json:"testTreeIndex"`
+	}{set, ss.testTreeIndex})
+}
+
 func loadSeqSet(ls *persist.LoadSaver) *seqSet {
 	ret := &seqSet{}
 	le := ls.LoadSmallInt()
@@ -128,6 +154,7 @@ func (ss *seqSet) add(seq wac.Seq, hi int) int {
 type frameSet struct {
 	set           []frames.Frame
 	testTreeIndex []int
+	screens       []prescreen // not persisted: built as frames are added or loaded
 }
 
 func (fs *frameSet) save(ls *persist.LoadSaver) {
@@ -138,6 +165,14 @@ func (fs *frameSet) save(ls *persist.LoadSaver) {
 	ls.SaveInts(fs.testTreeIndex)
 }
 
+// MarshalJSON encodes a frame set as part of a Matcher export.
+func (fs *frameSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Set           []frames.Frame `json:"set"`
+		TestTreeIndex []int          `json:"testTreeIndex"`
+	}{fs.set, fs.testTreeIndex})
+}
+
 func loadFrameSet(ls *persist.LoadSaver) *frameSet {
 	ret := &frameSet{}
 	le := ls.LoadSmallInt()
@@ -146,8 +181,10 @@ func loadFrameSet(ls *persist.LoadSaver) *frameSet {
 		return ret
 	}
 	ret.set = make([]frames.Frame, le)
+	ret.screens = make([]prescreen, le)
 	for i := range ret.set {
 		ret.set[i] = frames.Load(ls)
+		ret.screens[i] = newPrescreen(ret.set[i])
 	}
 	ret.testTreeIndex = ls.LoadInts()
 	return ret
@@ -162,52 +199,128 @@ func (fs *frameSet) add(f frames.Frame, hi int) int {
 	}
 	fs.set = append(fs.set, f)
 	fs.testTreeIndex = append(fs.testTreeIndex, hi)
+	fs.screens = append(fs.screens, newPrescreen(f))
 	return hi
 }
 
+// A prescreen rules out a frame at a fixed offset by testing the first few bytes at that offset (or the last few bytes, for EOF frames)
+// against the bytes that the frame's sequences have in those positions. This is much cheaper than running the full frame match,
+// and rules out most frames for any given file.
+type prescreen struct {
+	n     int // number of bytes screened; 0 if the frame can't be prescreened
+	masks [screenLen][4]uint64
+}
+
+const (
+	screenLen  = 4   // screen up to the first 4 bytes of a frame
+	screenSeqs = 256 // don't screen frames that would need more sequences than this
+)
+
+func newPrescreen(f frames.Frame) prescreen {
+	var p prescreen
+	if f.Min != f.Max || (f.OffType != frames.BOF && f.OffType != frames.EOF) {
+		return p
+	}
+	if num := f.NumSequences(); num < 1 || num > screenSeqs {
+		return p
+	}
+	seqs := f.Sequences()
+	if len(seqs) == 0 {
+		return p
+	}
+	p.n = screenLen
+	for _, seq := range seqs {
+		if len(seq) < p.n {
+			p.n = len(seq)
+		}
+	}
+	for _, seq := range seqs {
+		for i := 0; i < p.n; i++ {
+			c := seq[i]
+			if f.OffType == frames.EOF {
+				c = seq[len(seq)-1-i]
+			}
+			p.masks[i][c>>6] |= 1 << (c & 63)
+		}
+	}
+	return p
+}
+
+// pass reports whether the bytes at a frame's offset could match. For EOF frames, b is read from the right.
+func (p *prescreen) pass(b []byte, rev bool) bool {
+	if len(b) < p.n {
+		return false
+	}
+	for i := 0; i < p.n; i++ {
+		c := b[i]
+		if rev {
+			c = b[len(b)-1-i]
+		}
+		if p.masks[i][c>>6]&(1<<(c&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// screen reports whether the ith frame in the set survives its prescreen (frames that can't be prescreened always do).
+func (fs *frameSet) screen(i int, buf *siegreader.Buffer, rev bool) bool {
+	if i >= len(fs.screens) || fs.screens[i].n == 0 {
+		return true
+	}
+	var slc []byte
+	var err error
+	if rev {
+		slc, err = buf.EofSlice(int64(fs.set[i].Min), fs.screens[i].n)
+	} else {
+		slc, err = buf.Slice(int64(fs.set[i].Min), fs.screens[i].n)
+	}
+	if err != nil && err != io.EOF {
+		return true // leave it to the full match to deal with the error
+	}
+	return fs.screens[i].pass(slc, rev)
+}
+
 type fsmatch struct {
 	idx    int
 	off    int64
 	length int
 }
 
-func (fs *frameSet) index(buf *siegreader.Buffer, rev bool, quit chan struct{}) chan fsmatch {
-	ret := make(chan fsmatch)
-	go func() {
-		for i, f := range fs.set {
-			select {
-			case <-quit:
-				close(ret)
-				return
-			default:
-			}
-			var matches []int
-			if rev {
-				slc, err := buf.EofSlice(0, frames.TotalLength(f))
-				if err != nil && err != io.EOF {
-					close(ret)
-					return
-				}
-				matches = f.MatchR(slc)
-			} else {
-				slc, err := buf.Slice(0, frames.TotalLength(f))
-				if err != nil && err != io.EOF {
-					close(ret)
-					return
-				}
-				matches = f.Match(slc)
+// index matches the frames against the buffer, appending the matches to ret. It stops early if quit is closed.
+func (fs *frameSet) index(buf *siegreader.Buffer, rev bool, quit chan struct{}, ret []fsmatch) []fsmatch {
+	for i, f := range fs.set {
+		select {
+		case <-quit:
+			return ret
+		default:
+		}
+		if !fs.screen(i, buf, rev) {
+			continue
+		}
+		var matches []int
+		if rev {
+			slc, err := buf.EofSlice(0, frames.TotalLength(f))
+			if err != nil && err != io.EOF {
+				return ret
 			}
-			//if len(matches) > 0 { TODO: WTF???
-			//	var min int
-			//	if !rev {
-			//		min, _ = f.Length()
-			//	}
-			for _, off := range matches {
-				ret <- fsmatch{i, int64(f.Min), off - f.Min}
+			matches = f.MatchR(slc)
+		} else {
+			slc, err := buf.Slice(0, frames.TotalLength(f))
+			if err != nil && err != io.EOF {
+				return ret
 			}
-			//}
+			matches = f.Match(slc)
 		}
-		close(ret)
-	}()
+		//if len(matches) > 0 { TODO: WTF???
+		//	var min int
+		//	if !rev {
+		//		min, _ = f.Length()
+		//	}
+		for _, off := range matches {
+			ret = append(ret, fsmatch{i, int64(f.Min), off - f.Min})
+		}
+		//}
+	}
 	return ret
 }
@@ -1,11 +1,14 @@
 package bytematcher
 
 import (
+	"bytes"
 	"testing"
 
-	wac "github.com/richardlehane/match/fwac"
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames/tests"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/internal/wac"
 )
 
 var TestSeqSetBof = &seqSet{
@@ -25,10 +28,10 @@ var TestFrameSetBof = &frameSet{
 
 func TestSeqSet(t *testing.T) {
 	s := &seqSet{}
-	c1 := wac.Seq{[]int64{0}, []wac.Choice{{[]byte{'a', 'p', 'p', 'l', 'e'}}}}
-	c2 := wac.Seq{[]int64{0}, []wac.Choice{{[]byte{'a', 'p', 'p', 'l', 'e'}}}}
-	c3 := wac.Seq{[]int64{-1}, []wac.Choice{{[]byte{'a', 'p', 'p', 'l', 'e'}}}}
-	c4 := wac.Seq{[]int64{-1}, []wac.Choice{{[]byte{'a', 'p', 'p', 'l', 'e', 's'}}}}
+	c1 := wac.Seq{MaxOffsets: []int64{0}, Choices: []wac.Choice{{[]byte{'a', 'p', 'p', 'l', 'e'}}}}
+	c2 := wac.Seq{MaxOffsets: []int64{0}, Choices: []wac.Choice{{[]byte{'a', 'p', 'p', 'l', 'e'}}}}
+	c3 := wac.Seq{MaxOffsets: []int64{-1}, Choices: []wac.Choice{{[]byte{'a', 'p', 'p', 'l', 'e'}}}}
+	c4 := wac.Seq{MaxOffsets: []int64{-1}, Choices: []wac.Choice{{[]byte{'a', 'p', 'p', 'l', 'e', 's'}}}}
 	s.add(c1, 0)
 	i := s.add(c2, 1)
 	if i != 0 {
@@ -56,3 +59,31 @@ func TestFrameSet(t *testing.T) {
 		t.Error("Adding identical frame sequences should return a single TestTree index")
 	}
 }
+
+func TestPrescreen(t *testing.T) {
+	bof, eof := &frameSet{}, &frameSet{}
+	bof.add(frames.NewFrame(frames.BOF, patterns.Sequence("test"), 0, 0), 0)
+	bof.add(frames.NewFrame(frames.BOF, patterns.Sequence("ap"), 2, 2), 1)
+	bof.add(frames.NewFrame(frames.BOF, patterns.Sequence("ap"), 0, 10), 2) // not at a fixed offset, so not screened
+	eof.add(frames.NewFrame(frames.EOF, patterns.Sequence("ple"), 0, 0), 3)
+	eof.add(frames.NewFrame(frames.EOF, patterns.Sequence("test"), 0, 0), 4)
+	if bof.screens[0].n != 4 || bof.screens[1].n != 2 || bof.screens[2].n != 0 {
+		t.Fatalf("unexpected prescreens: %d, %d, %d", bof.screens[0].n, bof.screens[1].n, bof.screens[2].n)
+	}
+	buf, _ := siegreader.New().Get(bytes.NewBuffer([]byte("apapple")))
+	var got []int
+	for _, m := range bof.index(buf, false, make(chan struct{}), nil) {
+		got = append(got, m.idx)
+	}
+	for _, m := range eof.index(buf, true, make(chan struct{}), nil) {
+		got = append(got, m.idx+len(bof.set))
+	}
+	if len(got) != 4 || got[0] != 1 || got[1] != 2 || got[2] != 2 || got[3] != 3 {
+		t.Errorf("expecting matches for frames 1, 2 (twice) and 3, got %v", got)
+	}
+	for i, f := range bof.set {
+		if bof.screen(i, buf, false) != (len(f.Match([]byte("apapple"))) > 0 || bof.screens[i].n == 0) {
+			t.Errorf("prescreen for frame %d disagrees with the frame match", i)
+		}
+	}
+}
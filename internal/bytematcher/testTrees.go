@@ -15,6 +15,7 @@
 package bytematcher
 
 import (
+	"encoding/json"
 	"sort"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
@@ -79,14 +80,26 @@ func loadTests(ls *persist.LoadSaver) []*testTree {
 	return ret
 }
 
+// MarshalJSON encodes a test tree as part of a Matcher export.
+func (t *testTree) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Complete         []keyFrameID `json:"complete"`
+		Incomplete       []followUp   `json:"incomplete"`
+		MaxLeftDistance  int          `json:"maxLeftDistance"`
+		MaxRightDistance int          `json:"maxRightDistance"`
+		Left             []*testNode  `json:"left"`
+		Right            []*testNode  `json:"right"`
+	}{t.complete, t.incomplete, t.maxLeftDistance, t.maxRightDistance, t.left, t.right})
+}
+
 // KeyFrames returns a list of all KeyFrameIDs that are included in the test tree, including completes and incompletes
-func (t *testTree) keyFrames() []keyFrameID {
-	ret := make([]keyFrameID, len(t.complete), len(t.complete)+len(t.incomplete))
-	copy(ret, t.complete)
+// keyFrames appends the test tree's keyframes to buf
+func (t *testTree) keyFrames(buf []keyFrameID) []keyFrameID {
+	buf = append(buf, t.complete...)
 	for _, v := range t.incomplete {
-		ret = append(ret, v.kf)
+		buf = append(buf, v.kf)
 	}
-	return ret
+	return buf
 }
 
 type followUp struct {
@@ -95,6 +108,14 @@ type followUp struct {
 	r  bool // have a right test
 }
 
+func (fu followUp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		KF    keyFrameID `json:"kf"`
+		Left  bool       `json:"left"`
+		Right bool       `json:"right"`
+	}{fu.kf, fu.l, fu.r})
+}
+
 type followupMatch struct {
 	followUp  int
 	distances []int
@@ -106,6 +127,14 @@ type testNode struct {
 	tests   []*testNode
 }
 
+func (n *testNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Frame   frames.Frame `json:"frame"`
+		Success []int        `json:"success"`
+		Tests   []*testNode  `json:"tests"`
+	}{n.Frame, n.success, n.tests})
+}
+
 func saveTestNodes(ls *persist.LoadSaver, tns []*testNode) {
 	ls.SaveSmallInt(len(tns))
 	for _, n := range tns {
@@ -0,0 +1,290 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cli describes the subcommands and flags of the sf and roy commands, so that they can be completed in a
+// shell and searched for help.
+//
+// The completion scripts made by Script call the command back with the words of the command line being completed
+// (e.g. sf __complete -log fmt/1), so that words are completed from the command's own flags, and from the signature
+// file and format sets it loads, rather than from lists fixed in the script.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Hook is the hidden subcommand that completion scripts call with the words of the command line being completed
+const Hook = "__complete"
+
+// Shells are the shells that Script makes completion scripts for
+var Shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// Values completes a word. It returns nothing to leave the shell to complete file names.
+type Values func(prefix string) []string
+
+// Command is a command or a subcommand
+type Command struct {
+	Name        string
+	Summary     string        // a line describing a subcommand, for help
+	Flags       *flag.FlagSet // may be nil for a command without flags
+	Subcommands []*Command
+	Values      map[string]Values // completes the values of flags, by flag name
+	Args        Values            // completes the arguments of the command
+	Files       bool              // the arguments are files: subcommands are only offered to complete a word begun
+}
+
+// Complete completes the last of the words of a command line (the words after the name of the command). The words
+// before it may give a subcommand, flags and their values, and arguments.
+func (c *Command) Complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur, prev := words[len(words)-1], words[:len(words)-1]
+	// the first word may name a subcommand
+	if len(prev) > 0 {
+		for _, sc := range c.Subcommands {
+			if prev[0] == sc.Name {
+				return sc.Complete(words[1:])
+			}
+		}
+	}
+	// the value of a flag
+	if len(prev) > 0 {
+		if name, ok := c.flagName(prev[len(prev)-1]); ok && !strings.Contains(prev[len(prev)-1], "=") && !c.isBool(name) {
+			return c.values(name, cur)
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		if i := strings.Index(cur, "="); i > 0 {
+			name, _ := c.flagName(cur[:i])
+			vals := c.values(name, cur[i+1:])
+			for j, v := range vals {
+				vals[j] = cur[:i+1] + v
+			}
+			return vals
+		}
+		return filter(c.flagNames(), cur)
+	}
+	if c.Files && cur == "" {
+		return nil
+	}
+	var ret []string
+	if len(prev) == 0 { // a subcommand must be the first word
+		for _, sc := range c.Subcommands {
+			ret = append(ret, sc.Name)
+		}
+		ret = filter(ret, cur)
+	}
+	if c.Args != nil {
+		ret = append(ret, c.Args(cur)...)
+	}
+	return ret
+}
+
+// flagName returns the name of a flag given as a word on a command line (e.g. -log, --log or -log=e), if the
+// command has that flag
+func (c *Command) flagName(word string) (string, bool) {
+	if c.Flags == nil || !strings.HasPrefix(word, "-") || word == "-" {
+		return "", false
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(word, "-"), "-")
+	if i := strings.Index(name, "="); i >= 0 {
+		name = name[:i]
+	}
+	return name, c.Flags.Lookup(name) != nil
+}
+
+func (c *Command) isBool(name string) bool {
+	f := c.Flags.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func (c *Command) flagNames() []string {
+	var ret []string
+	if c.Flags != nil {
+		c.Flags.VisitAll(func(f *flag.Flag) { ret = append(ret, "-"+f.Name) })
+	}
+	return ret
+}
+
+func (c *Command) values(name, prefix string) []string {
+	if v, ok := c.Values[name]; ok && v != nil {
+		return v(prefix)
+	}
+	return nil
+}
+
+// List completes a word from a list of words
+func List(words ...string) Values {
+	return func(prefix string) []string {
+		return filter(words, prefix)
+	}
+}
+
+// Join completes a word from the words of all the given Values
+func Join(vs ...Values) Values {
+	return func(prefix string) []string {
+		var ret []string
+		for _, v := range vs {
+			ret = append(ret, v(prefix)...)
+		}
+		return ret
+	}
+}
+
+// Lazy completes a word from a list of words made only when they are needed (e.g. the formats of a signature file
+// that has to be loaded)
+func Lazy(fn func() []string) Values {
+	return func(prefix string) []string {
+		return filter(fn(), prefix)
+	}
+}
+
+// CommaList completes the last item of a comma separated list (e.g. -limit fmt/1,fmt/2)
+func CommaList(v Values) Values {
+	return func(prefix string) []string {
+		i := strings.LastIndex(prefix, ",")
+		vals := v(prefix[i+1:])
+		for j, val := range vals {
+			vals[j] = prefix[:i+1] + val
+		}
+		return vals
+	}
+}
+
+func filter(words []string, prefix string) []string {
+	var ret []string
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			ret = append(ret, w)
+		}
+	}
+	return ret
+}
+
+// Help writes the subcommands of a command and, if a topic is given, the flags of the command and its subcommands
+// whose names or descriptions mention it (e.g. sf help archive).
+func Help(w io.Writer, c *Command, topic string) {
+	if len(c.Subcommands) > 0 {
+		fmt.Fprintln(w, "Subcommands:")
+		for _, sc := range c.Subcommands {
+			fmt.Fprintf(w, "   %s %-12s %s\n", c.Name, sc.Name, sc.Summary)
+		}
+	}
+	if topic == "" {
+		fmt.Fprintf(w, "\nGive a topic, a word in the names or descriptions of flags, to list the flags about it: %s help TOPIC\n", c.Name)
+		return
+	}
+	topic = strings.ToLower(topic)
+	type hit struct{ cmd, flag, usage string }
+	var hits []hit
+	var find func(name string, c *Command)
+	find = func(name string, c *Command) {
+		if c.Flags != nil {
+			c.Flags.VisitAll(func(f *flag.Flag) {
+				if strings.Contains(strings.ToLower(f.Name), topic) || strings.Contains(strings.ToLower(f.Usage), topic) {
+					hits = append(hits, hit{name, f.Name, f.Usage})
+				}
+			})
+		}
+		for _, sc := range c.Subcommands {
+			find(name+" "+sc.Name, sc)
+		}
+	}
+	find(c.Name, c)
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].cmd < hits[j].cmd })
+	fmt.Fprintf(w, "\nFlags about %q:\n", topic)
+	if len(hits) == 0 {
+		fmt.Fprintln(w, "   none")
+	}
+	for _, h := range hits {
+		fmt.Fprintf(w, "   %s -%s\n      %s\n", h.cmd, h.flag, h.usage)
+	}
+}
+
+// Script writes the completion script for a shell. Prog is the name of the command.
+func Script(w io.Writer, shell, prog string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashScript
+	case "zsh":
+		script = zshScript
+	case "fish":
+		script = fishScript
+	case "powershell":
+		script = powershellScript
+	default:
+		return fmt.Errorf("no completion script for %q; choose from %s", shell, strings.Join(Shells, ", "))
+	}
+	_, err := io.WriteString(w, strings.NewReplacer("PROG", prog, "HOOK", Hook).Replace(script))
+	return err
+}
+
+// Scripts fall back to completing file names when the command completes nothing.
+
+const bashScript = `# bash completion for PROG
+# add to ~/.bashrc: source <(PROG completion bash)
+_PROG_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($(PROG HOOK "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _PROG_complete PROG
+`
+
+const zshScript = `#compdef PROG
+# zsh completion for PROG
+# add to ~/.zshrc, after compinit: source <(PROG completion zsh)
+_PROG() {
+    local -a completions
+    completions=("${(@f)$(PROG HOOK "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${completions[1]}" ]]; then
+        compadd -Q -- "${completions[@]}"
+    else
+        _files
+    fi
+}
+compdef _PROG PROG
+`
+
+const fishScript = `# fish completion for PROG
+# save as ~/.config/fish/completions/PROG.fish: PROG completion fish > ~/.config/fish/completions/PROG.fish
+function __PROG_complete
+    set -l words (commandline -opc) (commandline -ct)
+    PROG HOOK $words[2..-1] 2>/dev/null
+end
+complete -c PROG -a '(__PROG_complete)'
+`
+
+const powershellScript = `# PowerShell completion for PROG
+# add to your profile: PROG completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName PROG -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+    $completions = @(& PROG HOOK @words 2>$null | Where-Object { $_ })
+    if ($completions.Count -eq 0) { return }
+    $completions | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
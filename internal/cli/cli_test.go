@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func testCommand() *Command {
+	fs := flag.NewFlagSet("sf", flag.ContinueOnError)
+	fs.Bool("csv", false, "CSV output format")
+	fs.String("log", "", "log errors or formats")
+	fs.String("hash", "", "calculate file checksum")
+	inspect := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	inspect.String("path", "", "extract the record for a file")
+	return &Command{
+		Name:  "sf",
+		Flags: fs,
+		Files: true,
+		Values: map[string]Values{
+			"log":  CommaList(Join(List("error", "warning"), List("fmt/1", "fmt/10"))),
+			"hash": List("md5", "sha1"),
+		},
+		Subcommands: []*Command{
+			{Name: "inspect", Summary: "check a results file", Flags: inspect},
+			{Name: "completion", Args: List(Shells...)},
+		},
+	}
+}
+
+func TestComplete(t *testing.T) {
+	cmd := testCommand()
+	for _, test := range []struct {
+		words  []string
+		expect string
+	}{
+		{[]string{"-"}, "-csv -hash -log"},
+		{[]string{"-h"}, "-hash"},
+		{[]string{"--h"}, ""},
+		{[]string{"-hash", ""}, "md5 sha1"},
+		{[]string{"-hash=s"}, "-hash=sha1"},
+		{[]string{"-csv", "-log", "e,fmt/1"}, "e,fmt/1 e,fmt/10"},
+		{[]string{"-log", "w"}, "warning"},
+		{[]string{""}, ""}, // files
+		{[]string{"i"}, "inspect"},
+		{[]string{"-csv", "i"}, ""}, // a subcommand must be the first word
+		{[]string{"completion", ""}, "bash zsh fish powershell"},
+		{[]string{"completion", "f"}, "fish"},
+		{[]string{"inspect", "-"}, "-path"},
+		{[]string{"inspect", "-path", ""}, ""},
+		{nil, ""},
+	} {
+		if got := strings.Join(cmd.Complete(test.words), " "); got != test.expect {
+			t.Errorf("%q: expecting %q, got %q", test.words, test.expect, got)
+		}
+	}
+}
+
+func TestHelp(t *testing.T) {
+	buf := &bytes.Buffer{}
+	Help(buf, testCommand(), "RECORD")
+	out := buf.String()
+	if !strings.Contains(out, "sf inspect      check a results file") || !strings.Contains(out, "sf inspect -path") ||
+		strings.Contains(out, "-csv") {
+		t.Errorf("unexpected help:\n%s", out)
+	}
+}
+
+func TestScript(t *testing.T) {
+	for _, shell := range Shells {
+		buf := &bytes.Buffer{}
+		if err := Script(buf, shell, "roy"); err != nil {
+			t.Fatal(err)
+		}
+		if out := buf.String(); !strings.Contains(out, "roy "+Hook) || strings.Contains(out, "PROG") {
+			t.Errorf("%s: bad script\n%s", shell, out)
+		}
+	}
+	if err := Script(&bytes.Buffer{}, "tcsh", "sf"); err == nil {
+		t.Error("expecting an error for an unknown shell")
+	}
+}
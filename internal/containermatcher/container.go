@@ -16,6 +16,7 @@ package containermatcher
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -84,7 +85,7 @@ func Add(c core.Matcher, ss core.SignatureSet, l priority.List) (core.Matcher, i
 	}
 	sigs, ok := ss.(SignatureSet)
 	if !ok {
-		return nil, 0, fmt.Errorf("Container matcher error: cannot convert signature set to CM signature set")
+		return nil, 0, fmt.Errorf("Container matcher: %w", core.ErrSignatureSet)
 	}
 	err := m.addSigs(int(sigs.Typ), sigs.NameParts, sigs.SigParts, l)
 	if err != nil {
@@ -171,6 +172,18 @@ func (c *ContainerMatcher) save(ls *persist.LoadSaver) {
 	ls.SaveString(c.extension)
 }
 
+// MarshalJSON encodes a ContainerMatcher for export.
+func (c *ContainerMatcher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		StartIndexes []int             `json:"startIndexes"`
+		ConType      containerType     `json:"conType"`
+		NameCTest    map[string]*cTest `json:"nameCTest"`
+		Parts        []int             `json:"parts"`
+		Priorities   *priority.Set     `json:"priorities"`
+		Extension    string            `json:"extension"`
+	}{c.startIndexes, c.conType, c.nameCTest, c.parts, c.priorities, c.extension})
+}
+
 func (c *ContainerMatcher) String() string {
 	str := "\nContainer matcher:\n"
 	str += fmt.Sprintf("Type: %d\n", c.conType)
@@ -193,16 +206,19 @@ func (c *ContainerMatcher) String() string {
 type ctype struct {
 	trigger func([]byte) bool
 	rdr     func(*siegreader.Buffer) (Reader, error)
+	first   func(*siegreader.Buffer) Reader // optional fast path: a reader of just the first entry of a container, or nil if it can't be read
 }
 
 var ctypes = []ctype{
 	{
 		zipTrigger,
-		zipRdr, // see zip.go
+		zipRdr,   // see zip.go
+		zipFirst, // see zip.go
 	},
 	{
 		mscfbTrigger,
 		mscfbRdr, // see mscfb.go
+		nil,
 	},
 }
 
@@ -272,6 +288,14 @@ func loadCTests(ls *persist.LoadSaver) map[string]*cTest {
 	return ret
 }
 
+func (ct *cTest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Satisfied   []int        `json:"satisfied"`
+		Unsatisfied []int        `json:"unsatisfied"`
+		BM          core.Matcher `json:"bytematcher"`
+	}{ct.satisfied, ct.unsatisfied, ct.bm})
+}
+
 func saveCTests(ls *persist.LoadSaver, ct map[string]*cTest) {
 	ls.SaveSmallInt(len(ct))
 	for k, v := range ct {
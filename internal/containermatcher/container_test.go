@@ -15,7 +15,7 @@ func testTrigger([]byte) bool {
 }
 
 var testContainerMatcher *ContainerMatcher = &ContainerMatcher{
-	ctype:        ctype{testTrigger, newTestReader},
+	ctype:        ctype{testTrigger, newTestReader, nil},
 	conType:      0,
 	nameCTest:    make(map[string]*cTest),
 	priorities:   &priority.Set{},
@@ -26,7 +26,7 @@ var testContainerMatcher *ContainerMatcher = &ContainerMatcher{
 var count int
 
 func TestMatcher(t *testing.T) {
-	ctypes = []ctype{{testTrigger, newTestReader}}
+	ctypes = []ctype{{testTrigger, newTestReader, nil}}
 	// test adding
 	count++
 	testMatcher, _, err := Add(Matcher{testContainerMatcher},
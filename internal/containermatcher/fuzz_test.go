@@ -0,0 +1,67 @@
+//go:build go1.18
+// +build go1.18
+
+package containermatcher
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// seed the container fuzz targets with the zip and OLE2 files of the benchmark corpus
+func seedContainers(f *testing.F, exts ...string) {
+	for _, ext := range exts {
+		buf, err := ioutil.ReadFile(filepath.Join("..", "..", "cmd", "sf", "testdata", "benchmark", "Benchmark."+ext))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf)
+	}
+}
+
+// walk a container, reading each of its entries, as the container matcher does
+func walkContainer(t *testing.T, newReader func(*siegreader.Buffer) (Reader, error), data []byte) {
+	bufs := siegreader.New()
+	buf, err := bufs.Get(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	defer bufs.Put(buf)
+	rdr, err := newReader(buf)
+	if err != nil {
+		return
+	}
+	for i := 0; i < 1000 && rdr.Next() == nil; i++ {
+		_ = rdr.Name()
+		if rdr.IsDir() {
+			continue
+		}
+		ebuf, err := rdr.SetSource(bufs)
+		if err == nil {
+			ebuf.Slice(0, 8)
+			ebuf.Bytes() // read the whole entry
+		}
+		rdr.Close()
+		if ebuf != nil {
+			bufs.Put(ebuf)
+		}
+	}
+}
+
+func FuzzZip(f *testing.F) {
+	seedContainers(f, "docx", "odt", "pptx", "xlsx")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		walkContainer(t, zipRdr, data)
+	})
+}
+
+func FuzzMSCFB(f *testing.F) {
+	seedContainers(f, "msg")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		walkContainer(t, mscfbRdr, data)
+	})
+}
@@ -24,8 +24,16 @@ import (
 	"github.com/richardlehane/siegfried/pkg/core"
 )
 
-func (m Matcher) Identify(n string, b *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
-	res := make(chan core.Result)
+func (m Matcher) Identify(n string, b *siegreader.Buffer, hints ...core.Hint) (res chan core.Result, err error) {
+	// a malformed container shouldn't crash the scan: report the panic as an error for this file
+	defer func() {
+		if r := recover(); r != nil {
+			res = make(chan core.Result)
+			close(res)
+			err = core.ClassifyError(core.ClassCorrupt, fmt.Errorf("containermatcher: malformed container %s: %v", n, r))
+		}
+	}()
+	res = make(chan core.Result)
 	// check trigger
 	buf, err := b.Slice(0, 8)
 	if err != nil {
@@ -35,12 +43,26 @@ func (m Matcher) Identify(n string, b *siegreader.Buffer, hints ...core.Hint) (c
 	divhints := m.divideHints(hints)
 	for i, c := range m {
 		if c.trigger(buf) {
-			rdr, err := c.rdr(b)
-			if err != nil {
-				close(res)
-				return res, err
+			var rs []core.Result
+			var done bool
+			if c.first != nil {
+				if frdr := c.first(b); frdr != nil {
+					rs, done = c.identifyFirst(n, frdr, divhints[i]...)
+				}
+			}
+			if !done {
+				rdr, err := c.rdr(b)
+				if err != nil {
+					close(res)
+					return res, core.ClassifyError(core.ClassCorrupt, err)
+				}
+				rs = c.identify(n, rdr, divhints[i]...)
+			}
+			res = make(chan core.Result, len(rs))
+			for _, r := range rs {
+				res <- r
 			}
-			go c.identify(n, rdr, res, divhints[i]...)
+			close(res)
 			return res, nil
 		}
 	}
@@ -111,6 +133,7 @@ type identifier struct {
 	waitSet      *priority.WaitSet
 	hits         []hit // shared buffer of hits used when matching
 	result       bool
+	results      []core.Result // results collected for sending
 }
 
 func (c *ContainerMatcher) newIdentifier(numParts int, hints ...core.Hint) *identifier {
@@ -120,16 +143,39 @@ func (c *ContainerMatcher) newIdentifier(numParts int, hints ...core.Hint) *iden
 		c.priorities.WaitSet(hints...),
 		make([]hit, 0, 1),
 		false,
+		nil,
 	}
 }
 
-func (c *ContainerMatcher) identify(n string, rdr Reader, res chan core.Result, hints ...core.Hint) {
+func (c *ContainerMatcher) identify(n string, rdr Reader, hints ...core.Hint) []core.Result {
 	// safe to call on a nil matcher (i.e. container matching switched off)
 	if c == nil {
-		close(res)
-		return
+		return nil
 	}
 	id := c.newIdentifier(len(c.parts), hints...)
+	c.match(id, rdr)
+	return c.results(n, id)
+}
+
+// identifyFirst identifies a container from a reader of just its first entry (see ctype.first). It reports whether
+// that was enough i.e. whether matching would have stopped after the first entry anyway. If it wasn't, the container
+// should be identified again with a reader of all its entries.
+func (c *ContainerMatcher) identifyFirst(n string, rdr Reader, hints ...core.Hint) ([]core.Result, bool) {
+	if c == nil {
+		return nil, true
+	}
+	id := c.newIdentifier(len(c.parts), hints...)
+	if !c.match(id, rdr) {
+		return nil, false
+	}
+	if config.Debug() {
+		fmt.Fprintf(config.Out(), "{Container %d identified by its first entry}\n", c.conType)
+	}
+	return c.results(n, id), true
+}
+
+// match tests the entries of a container, reporting whether it stopped early because it could
+func (c *ContainerMatcher) match(id *identifier, rdr Reader) bool {
 	var err error
 	for err = rdr.Next(); err == nil; err = rdr.Next() {
 		ct, ok := c.nameCTest[rdr.Name()]
@@ -142,15 +188,19 @@ func (c *ContainerMatcher) identify(n string, rdr Reader, res chan core.Result,
 		// name has matched, let's test the CTests
 		// ct.identify will generate a slice of hits which pass to
 		// processHits which will return true if we can stop
-		if c.processHits(ct.identify(c, id, rdr, rdr.Name()), id, ct, rdr.Name(), res) {
-			break
+		if c.processHits(ct.identify(c, id, rdr, rdr.Name()), id, ct, rdr.Name()) {
+			return true
 		}
 	}
+	return false
+}
+
+func (c *ContainerMatcher) results(n string, id *identifier) []core.Result {
 	// send a default hit if no result and extension matches
 	if c.extension != "" && !id.result && filepath.Ext(n) == "."+c.extension {
-		res <- defaultHit(-1 - int(c.conType))
+		id.results = append(id.results, defaultHit(-1-int(c.conType)))
 	}
-	close(res)
+	return id.results
 }
 
 func (ct *cTest) identify(c *ContainerMatcher, id *identifier, rdr Reader, name string) []hit {
@@ -178,7 +228,7 @@ func (ct *cTest) identify(c *ContainerMatcher, id *identifier, rdr Reader, name
 
 // process the hits from the ctest: adding hits to the parts matched, checking priorities
 // return true if satisfied and can quit
-func (c *ContainerMatcher) processHits(hits []hit, id *identifier, ct *cTest, name string, res chan core.Result) bool {
+func (c *ContainerMatcher) processHits(hits []hit, id *identifier, ct *cTest, name string) bool {
 	// if there are no hits, rule out any sigs in the ctest
 	if len(hits) == 0 {
 		for _, v := range ct.satisfied {
@@ -194,8 +244,8 @@ func (c *ContainerMatcher) processHits(hits []hit, id *identifier, ct *cTest, na
 		if len(id.partsMatched[h.id]) == c.parts[h.id] {
 			if id.waitSet.Check(h.id) {
 				idx, _ := c.priorities.Index(h.id)
-				res <- toResult(c.startIndexes[idx], id.partsMatched[h.id]) // send a Result here
-				id.result = true                                            // mark id as having a result (for zip default)
+				id.results = append(id.results, toResult(c.startIndexes[idx], id.partsMatched[h.id])) // collect a Result here
+				id.result = true                                                                      // mark id as having a result (for zip default)
 				// set a priority list and return early if can
 				if id.waitSet.Put(h.id) {
 					return true
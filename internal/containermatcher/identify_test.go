@@ -1,18 +1,23 @@
 package containermatcher
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/binary"
+	"hash/crc32"
 	"io"
 	"testing"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames/tests"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/priority"
 	"github.com/richardlehane/siegfried/internal/siegreader"
 	"github.com/richardlehane/siegfried/pkg/core"
 )
 
 func TestIdentify(t *testing.T) {
-	ctypes = []ctype{{testTrigger, newTestReader}}
+	ctypes = []ctype{{testTrigger, newTestReader, nil}}
 	// test adding
 	count++
 	testMatcher, _, err := Add(Matcher{testContainerMatcher},
@@ -45,3 +50,79 @@ func TestIdentify(t *testing.T) {
 		}
 	}
 }
+
+var realCtypes = ctypes // the other tests replace the container types with test types
+
+func testZip(t *testing.T, first string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range [][2]string{{first, "application/epub+zip"}, {"content.opf", "<package/>"}} {
+		w, err := zw.Create(e[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, e[1])
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testStored is the local file header and data of a stored zip entry, as at the start of an EPUB or ODF file
+// (zip.Writer puts the sizes of stored entries in data descriptors)
+func testStored(name, content string) []byte {
+	hdr := make([]byte, 30)
+	binary.LittleEndian.PutUint32(hdr, 0x04034B50)
+	binary.LittleEndian.PutUint16(hdr[4:], 10)
+	binary.LittleEndian.PutUint32(hdr[14:], crc32.ChecksumIEEE([]byte(content)))
+	binary.LittleEndian.PutUint32(hdr[18:], uint32(len(content)))
+	binary.LittleEndian.PutUint32(hdr[22:], uint32(len(content)))
+	binary.LittleEndian.PutUint16(hdr[26:], uint16(len(name)))
+	return append(append(hdr, name...), content...)
+}
+
+func TestIdentifyFirst(t *testing.T) {
+	ctypes = realCtypes
+	m, _, err := Add(nil,
+		SignatureSet{
+			Zip,
+			[][]string{{"mimetype"}},
+			[][]frames.Signature{{frames.Signature{frames.NewFrame(frames.BOF, patterns.Sequence("application/epub+zip"), 0, 0)}}},
+		},
+		priority.List{{}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identify := func(byts []byte) ([]core.Result, error) {
+		b, err := siegreader.New().Get(bytes.NewReader(byts))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		res, err := m.(Matcher).Identify("example.epub", b)
+		var collect []core.Result
+		for r := range res {
+			collect = append(collect, r)
+		}
+		return collect, err
+	}
+	byts := testZip(t, "mimetype")
+	if res, err := identify(byts); err != nil || len(res) != 1 || res[0].Index() != 0 {
+		t.Fatalf("expecting a match for the mimetype entry, got %v %v", res, err)
+	}
+	// without the central directory at the end of the zip, the first entry is still enough
+	for _, byts := range [][]byte{byts[:len(byts)-22], testStored("mimetype", "application/epub+zip")} {
+		if res, err := identify(byts); err != nil || len(res) != 1 || res[0].Index() != 0 {
+			t.Errorf("expecting a match from the first entry of a truncated zip, got %v %v", res, err)
+		}
+	}
+	// a first entry that isn't enough falls back to reading the central directory
+	byts = testZip(t, "META-INF/container.xml")
+	if res, err := identify(byts); err != nil || len(res) != 0 {
+		t.Errorf("expecting no match, got %v %v", res, err)
+	}
+	if _, err := identify(byts[:len(byts)-22]); err == nil {
+		t.Error("expecting an error reading a truncated zip without a match in its first entry")
+	}
+}
@@ -15,6 +15,8 @@
 package containermatcher
 
 import (
+	"encoding/binary"
+	"errors"
 	"strings"
 
 	"github.com/richardlehane/mscfb"
@@ -26,7 +28,32 @@ type mscfbReader struct {
 	entry *mscfb.File
 }
 
+// errCFBHeader is returned for OLE2 headers that claim more sectors than the file holds.
+// The mscfb package allocates for the sectors a header claims, so a small corrupt file could exhaust memory.
+var errCFBHeader = errors.New("containermatcher: corrupt OLE2 header, sector counts exceed the file size")
+
+func checkCFB(b *siegreader.Buffer) error {
+	hdr, err := b.Slice(0, 76)
+	if err != nil {
+		return nil // leave short reads to mscfb
+	}
+	shift := binary.LittleEndian.Uint16(hdr[30:32])
+	if shift != 9 && shift != 12 {
+		return nil // mscfb reports bad sector sizes
+	}
+	sectors := b.SizeNow()>>shift + 1
+	for _, off := range []int{40, 44, 64, 72} { // directory, FAT, mini FAT and DIFAT sector counts
+		if int64(binary.LittleEndian.Uint32(hdr[off:off+4])) > sectors {
+			return errCFBHeader
+		}
+	}
+	return nil
+}
+
 func mscfbRdr(b *siegreader.Buffer) (Reader, error) {
+	if err := checkCFB(b); err != nil {
+		return nil, err
+	}
 	m, err := mscfb.New(siegreader.ReaderFrom(b))
 	if err != nil {
 		return nil, err
@@ -0,0 +1,103 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containermatcher
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// Kinds of macros reported by Objects
+const (
+	VBA = "VBA" // a VBA project
+	XLM = "XLM" // Excel 4.0 macro sheets
+)
+
+var (
+	cfbMagic = []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")
+	zipMagic = []byte("PK\x03\x04")
+)
+
+// Objects lists the entries of an OLE2 or OOXML file and reports the kinds of macros it has and its number of
+// embedded objects. In an OLE2 file, a VBA project is a VBA storage with a _VBA_PROJECT stream (e.g. Macros/VBA in
+// a Word document, _VBA_PROJECT_CUR/VBA in an Excel workbook) and an embedded object is a storage with an \x01Ole or
+// \x01Ole10Native stream (e.g. in a Word document's ObjectPool). In an OOXML file (a zip with a [Content_Types].xml
+// entry), a VBA project is a vbaProject.bin part, XLM macros are parts in xl/macrosheets and embedded objects are
+// parts in an embeddings folder. It returns nothing for other files or files it can't read.
+func Objects(b *siegreader.Buffer) ([]string, int) {
+	hdr, _ := b.Slice(0, len(cfbMagic))
+	var (
+		rdr   Reader
+		err   error
+		ooxml bool
+	)
+	switch {
+	case bytes.Equal(hdr, cfbMagic):
+		rdr, err = mscfbRdr(b)
+	case bytes.HasPrefix(hdr, zipMagic):
+		rdr, err = zipRdr(b)
+		ooxml = true
+	default:
+		return nil, 0
+	}
+	if err != nil {
+		return nil, 0
+	}
+	defer rdr.Close()
+	var (
+		vba, xlm, types bool
+		objects         = make(map[string]bool)
+	)
+	for err = rdr.Next(); err == nil; err = rdr.Next() {
+		name := rdr.Name()
+		if ooxml {
+			switch {
+			case name == "[Content_Types].xml":
+				types = true
+			case rdr.IsDir():
+			case path.Base(name) == "vbaProject.bin":
+				vba = true
+			case strings.HasPrefix(name, "xl/macrosheets/"):
+				xlm = true
+			case strings.Contains(name, "/embeddings/"):
+				objects[name] = true
+			}
+			continue
+		}
+		dir, base := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		switch {
+		case base == "_VBA_PROJECT" && path.Base(dir) == "VBA":
+			vba = true
+		// the \x01 of \x01Ole streams is dropped from their names; a root \x01Ole stream is the document's own
+		case (base == "Ole" || base == "Ole10Native") && dir != "":
+			objects[dir] = true
+		}
+	}
+	if ooxml && !types {
+		return nil, 0
+	}
+	var macros []string
+	if vba {
+		macros = append(macros, VBA)
+	}
+	if xlm {
+		macros = append(macros, XLM)
+	}
+	return macros, len(objects)
+}
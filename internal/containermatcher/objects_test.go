@@ -0,0 +1,136 @@
+package containermatcher
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// cfbEntry is a storage, if it has children, or an empty stream in a compound file
+type cfbEntry struct {
+	name     string
+	children []cfbEntry
+}
+
+// cfb makes a compound file of storages and empty streams, with a FAT in sector 0 and a directory in the sectors after
+func cfb(root []cfbEntry) []byte {
+	var dir [][]byte
+	var add func(e cfbEntry, typ byte) int
+	add = func(e cfbEntry, typ byte) int {
+		idx := len(dir)
+		d := make([]byte, 128)
+		name := utf16.Encode([]rune(e.name + "\x00"))
+		for i, r := range name {
+			binary.LittleEndian.PutUint16(d[i*2:], r)
+		}
+		binary.LittleEndian.PutUint16(d[64:], uint16(len(name)*2))
+		d[66], d[67] = typ, 1
+		for _, off := range []int{68, 72, 76} {
+			binary.LittleEndian.PutUint32(d[off:], 0xFFFFFFFF)
+		}
+		binary.LittleEndian.PutUint32(d[116:], 0xFFFFFFFE)
+		dir = append(dir, d)
+		// children are a chain of right siblings
+		link, field := idx, 76
+		for _, c := range e.children {
+			ctyp := byte(2)
+			if len(c.children) > 0 {
+				ctyp = 1
+			}
+			cidx := add(c, ctyp)
+			binary.LittleEndian.PutUint32(dir[link][field:], uint32(cidx))
+			link, field = cidx, 72
+		}
+		return idx
+	}
+	add(cfbEntry{"Root Entry", root}, 5)
+	for len(dir)%4 != 0 {
+		dir = append(dir, make([]byte, 128))
+	}
+	sectors := len(dir) / 4
+	hdr := make([]byte, 512)
+	copy(hdr, cfbMagic)
+	binary.LittleEndian.PutUint16(hdr[24:], 0x3E)
+	binary.LittleEndian.PutUint16(hdr[26:], 3)
+	binary.LittleEndian.PutUint16(hdr[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(hdr[30:], 9)
+	binary.LittleEndian.PutUint16(hdr[32:], 6)
+	binary.LittleEndian.PutUint32(hdr[44:], 1)
+	binary.LittleEndian.PutUint32(hdr[48:], 1)
+	binary.LittleEndian.PutUint32(hdr[56:], 4096)
+	binary.LittleEndian.PutUint32(hdr[60:], 0xFFFFFFFE)
+	binary.LittleEndian.PutUint32(hdr[68:], 0xFFFFFFFE)
+	for i := 76; i < 512; i += 4 {
+		binary.LittleEndian.PutUint32(hdr[i:], 0xFFFFFFFF)
+	}
+	binary.LittleEndian.PutUint32(hdr[76:], 0)
+	fat := make([]byte, 512)
+	for i := 0; i < 128; i++ {
+		binary.LittleEndian.PutUint32(fat[i*4:], 0xFFFFFFFF)
+	}
+	binary.LittleEndian.PutUint32(fat, 0xFFFFFFFD)
+	for i := 1; i <= sectors; i++ {
+		next := uint32(i + 1)
+		if i == sectors {
+			next = 0xFFFFFFFE
+		}
+		binary.LittleEndian.PutUint32(fat[i*4:], next)
+	}
+	return append(append(hdr, fat...), bytes.Join(dir, nil)...)
+}
+
+func ooxml(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, n := range names {
+		f, err := w.Create(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("content"))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestObjects(t *testing.T) {
+	ole := []cfbEntry{{name: "\x01Ole"}, {name: "\x01CompObj"}}
+	for _, test := range []struct {
+		name   string
+		byts   []byte
+		expect string
+	}{
+		{"doc", cfb([]cfbEntry{
+			{name: "WordDocument"},
+			{"Macros", []cfbEntry{{"VBA", []cfbEntry{{name: "_VBA_PROJECT"}, {name: "dir"}}}}},
+			{"ObjectPool", []cfbEntry{{"_1", ole}, {"_2", ole}}},
+		}), "VBA 2"},
+		{"xls", cfb([]cfbEntry{
+			{name: "Workbook"},
+			{"_VBA_PROJECT_CUR", []cfbEntry{{"VBA", []cfbEntry{{name: "_VBA_PROJECT"}}}}},
+		}), "VBA 0"},
+		{"plain doc", cfb([]cfbEntry{{name: "WordDocument"}, {name: "\x01Ole"}}), " 0"},
+		{"docm", ooxml(t, "[Content_Types].xml", "word/document.xml", "word/vbaProject.bin",
+			"word/embeddings/oleObject1.bin", "word/embeddings/Microsoft_Excel_Worksheet.xlsx"), "VBA 2"},
+		{"xlsm", ooxml(t, "[Content_Types].xml", "xl/workbook.xml", "xl/macrosheets/sheet1.xml"), "XLM 0"},
+		{"zip", ooxml(t, "vbaProject.bin", "a/embeddings/b"), " 0"},
+		{"text", []byte("PK is not a zip"), " 0"},
+	} {
+		b, err := siegreader.New().Get(bytes.NewReader(test.byts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		macros, n := Objects(b)
+		if got := strings.Join(macros, ",") + " " + strconv.Itoa(n); got != test.expect {
+			t.Errorf("%s: expecting %q, got %q", test.name, test.expect, got)
+		}
+	}
+}
@@ -16,7 +16,10 @@ package containermatcher
 
 import (
 	"archive/zip"
+	"compress/flate"
+	"encoding/binary"
 	"io"
+	"strings"
 
 	"github.com/richardlehane/siegfried/internal/siegreader"
 )
@@ -67,3 +70,82 @@ func zipRdr(b *siegreader.Buffer) (Reader, error) {
 	r, err := zip.NewReader(siegreader.ReaderFrom(b), b.SizeNow())
 	return &zipReader{idx: -1, rdr: r}, err
 }
+
+// zipFirstReader reads just the first entry of a zip file, from its local file header at the start of the file,
+// without reading the central directory at the end. ODF and EPUB files must start with a stored "mimetype" entry,
+// and OOXML files usually start with "[Content_Types].xml", so their first entry is often enough to identify them
+// (see identifyFirst). This saves reading the central directory of huge zips.
+type zipFirstReader struct {
+	b      *siegreader.Buffer
+	idx    int
+	name   string
+	method uint16
+	off    int64 // offset of the entry's data
+	size   int64 // compressed size of the entry
+	rc     io.ReadCloser
+}
+
+func (z *zipFirstReader) Next() error {
+	z.idx++
+	if z.idx > 0 {
+		return io.EOF
+	}
+	return nil
+}
+
+func (z *zipFirstReader) Name() string {
+	return z.name
+}
+
+func (z *zipFirstReader) SetSource(bufs *siegreader.Buffers) (*siegreader.Buffer, error) {
+	r := io.NewSectionReader(siegreader.ReaderFrom(z.b), z.off, z.size)
+	if z.method == zip.Deflate {
+		z.rc = flate.NewReader(r)
+	} else {
+		z.rc = io.NopCloser(r)
+	}
+	return bufs.Get(z.rc)
+}
+
+func (z *zipFirstReader) Close() {
+	if z.rc == nil {
+		return
+	}
+	z.rc.Close()
+}
+
+func (z *zipFirstReader) IsDir() bool {
+	return strings.HasSuffix(z.name, "/")
+}
+
+// zipFirst returns a reader of the first entry of a zip file, or nil if it can't be read from its local file header:
+// if it is encrypted, compressed other than by deflate, zip64, or stored with its size in a data descriptor after
+// its data (a deflated entry ends itself, so its size isn't needed).
+func zipFirst(b *siegreader.Buffer) Reader {
+	hdr, err := b.Slice(0, 30)
+	if err != nil || binary.LittleEndian.Uint32(hdr) != 0x04034B50 {
+		return nil
+	}
+	flags, method := binary.LittleEndian.Uint16(hdr[6:]), binary.LittleEndian.Uint16(hdr[8:])
+	size := binary.LittleEndian.Uint32(hdr[18:])
+	nlen, elen := int64(binary.LittleEndian.Uint16(hdr[26:])), int64(binary.LittleEndian.Uint16(hdr[28:]))
+	if flags&0x1 != 0 || (method != zip.Store && method != zip.Deflate) || size == 0xFFFFFFFF || nlen == 0 {
+		return nil
+	}
+	z := &zipFirstReader{b: b, idx: -1, method: method, off: 30 + nlen + elen, size: int64(size)}
+	if flags&0x8 != 0 {
+		if method != zip.Deflate {
+			return nil
+		}
+		z.size = b.SizeNow() - z.off
+	}
+	if z.size < 0 || z.off+z.size > b.SizeNow() {
+		return nil
+	}
+	name, err := b.Slice(30, int(nlen))
+	if err != nil {
+		return nil
+	}
+	z.name = string(name)
+	return z
+}
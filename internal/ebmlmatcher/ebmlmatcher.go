@@ -0,0 +1,231 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ebmlmatcher identifies EBML files (e.g. Matroska and WebM) by the DocType in their EBML header.
+// Matroska and WebM files share the same magic, so byte signatures can only tell them apart if the DocType
+// falls within their windows. The matcher also reports the codec IDs of the tracks of a file.
+package ebmlmatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/priority"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+type Matcher struct {
+	doctypes   map[string][]int
+	priorities *priority.Set
+}
+
+func Load(ls *persist.LoadSaver) core.Matcher {
+	le := ls.LoadSmallInt()
+	if le == 0 {
+		return nil
+	}
+	doctypes := make(map[string][]int)
+	for i := 0; i < le; i++ {
+		k := ls.LoadString()
+		r := make([]int, ls.LoadSmallInt())
+		for j := range r {
+			r[j] = ls.LoadSmallInt()
+		}
+		doctypes[k] = r
+	}
+	return &Matcher{
+		doctypes:   doctypes,
+		priorities: priority.Load(ls),
+	}
+}
+
+func Save(c core.Matcher, ls *persist.LoadSaver) {
+	if c == nil {
+		ls.SaveSmallInt(0)
+		return
+	}
+	m := c.(*Matcher)
+	ls.SaveSmallInt(len(m.doctypes))
+	if len(m.doctypes) == 0 {
+		return
+	}
+	for k, v := range m.doctypes {
+		ls.SaveString(k)
+		ls.SaveSmallInt(len(v))
+		for _, w := range v {
+			ls.SaveSmallInt(w)
+		}
+	}
+	m.priorities.Save(ls)
+}
+
+// SignatureSet is a list of EBML DocTypes e.g. "matroska", "webm".
+type SignatureSet []string
+
+// MarshalJSON encodes a Matcher for export.
+func (m *Matcher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		DocTypes   map[string][]int `json:"doctypes"`
+		Priorities *priority.Set    `json:"priorities"`
+	}{m.doctypes, m.priorities})
+}
+
+func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, int, error) {
+	sigs, ok := ss.(SignatureSet)
+	if !ok {
+		return nil, -1, fmt.Errorf("EBMLmatcher: %w", core.ErrSignatureSet)
+	}
+	if len(sigs) == 0 {
+		return c, 0, nil
+	}
+	var m *Matcher
+	if c == nil {
+		m = &Matcher{
+			doctypes:   make(map[string][]int),
+			priorities: &priority.Set{},
+		}
+	} else {
+		m = c.(*Matcher)
+	}
+	var length int
+	// unless it is a new matcher, calculate current length by iterating through all the result values
+	if len(m.doctypes) > 0 {
+		for _, v := range m.doctypes {
+			for _, w := range v {
+				if w > length {
+					length = w
+				}
+			}
+		}
+		length++ // add one - because the result values are indexes
+	}
+	for i, v := range sigs {
+		m.doctypes[v] = append(m.doctypes[v], i+length)
+	}
+	// add priorities
+	m.priorities.Add(p, len(sigs), 0, 0)
+	return m, length + len(sigs), nil
+}
+
+// DocTypes derives EBML DocTypes from byte signatures, for those signatures that begin with the EBML magic and that
+// include a DocType element (e.g. PRONOM's signature for Matroska includes 4282886D6174726F736B61: the DocType ID,
+// a size of 8 and "matroska"). It returns the DocTypes and the corresponding IDs.
+func DocTypes(sigs []frames.Signature, ids []string) ([]string, []string) {
+	var dts, dids []string
+	for i, sig := range sigs {
+		if len(sig) == 0 {
+			continue
+		}
+		if seq, ok := sig[0].Pattern.(patterns.Sequence); !ok || !bytes.HasPrefix(seq, magic) {
+			continue
+		}
+		for _, f := range sig {
+			seq, ok := f.Pattern.(patterns.Sequence)
+			if !ok {
+				continue
+			}
+			if dt := docType(seq); dt != "" {
+				dts, dids = append(dts, dt), append(dids, ids[i])
+				break
+			}
+		}
+	}
+	return dts, dids
+}
+
+// docType finds a DocType element, with a one byte size, in a sequence
+func docType(seq []byte) string {
+	for i := 0; ; i++ {
+		j := bytes.Index(seq[i:], docTypeID)
+		if j < 0 || i+j+3 > len(seq) {
+			return ""
+		}
+		i += j
+		sz := int(seq[i+2] &^ 0x80)
+		if seq[i+2]&0x80 == 0 || sz == 0 || i+3+sz > len(seq) {
+			continue
+		}
+		if dt := seq[i+3 : i+3+sz]; printable(dt) {
+			return string(dt)
+		}
+	}
+}
+
+func printable(byts []byte) bool {
+	for _, c := range byts {
+		if c < 0x20 || c > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+type result struct {
+	idx     int
+	doctype string
+	codecs  []string
+}
+
+func (r result) Index() int {
+	return r.idx
+}
+
+func (r result) Basis() string {
+	if len(r.codecs) == 0 {
+		return "ebml doctype " + r.doctype
+	}
+	return "ebml doctype " + r.doctype + " (codecs " + strings.Join(r.codecs, ", ") + ")"
+}
+
+func (m Matcher) Identify(na string, b *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	doctype, codecs := parse(b)
+	hits := m.doctypes[doctype]
+	if config.Debug() && doctype != "" {
+		fmt.Fprintf(config.Out(), "ebml doctype %s, codecs %v\n", doctype, codecs)
+	}
+	if doctype == "" || len(hits) == 0 {
+		res := make(chan core.Result)
+		close(res)
+		return res, nil
+	}
+	waitset := m.priorities.WaitSet(hints...)
+	res := make(chan core.Result, len(hits))
+	for _, hit := range hits {
+		if waitset.Check(hit) {
+			res <- result{hit, doctype, codecs}
+			if waitset.Put(hit) {
+				break
+			}
+		}
+	}
+	close(res)
+	return res, nil
+}
+
+func (m Matcher) String() string {
+	keys := make([]string, 0, len(m.doctypes))
+	for k := range m.doctypes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("EBML matcher: %s\n", strings.Join(keys, ", "))
+}
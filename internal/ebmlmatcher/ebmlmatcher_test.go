@@ -0,0 +1,135 @@
+package ebmlmatcher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// elem makes an EBML element with a one or two byte size
+func elem(id []byte, data ...[]byte) []byte {
+	content := bytes.Join(data, nil)
+	ret := append([]byte{}, id...)
+	if len(content) < 0x7F {
+		ret = append(ret, 0x80|byte(len(content)))
+	} else {
+		ret = append(ret, 0x40|byte(len(content)>>8), byte(len(content)))
+	}
+	return append(ret, content...)
+}
+
+var (
+	segment    = []byte{0x18, 0x53, 0x80, 0x67}
+	seekHead   = []byte{0x11, 0x4D, 0x9B, 0x74}
+	info       = []byte{0x15, 0x49, 0xA9, 0x66}
+	tracks     = []byte{0x16, 0x54, 0xAE, 0x6B}
+	trackEntry = []byte{0xAE}
+	trackType  = []byte{0x83}
+	codec      = []byte{0x86}
+	cluster    = []byte{0x1F, 0x43, 0xB6, 0x75}
+)
+
+func track(c string) []byte {
+	return elem(trackEntry, elem(trackType, []byte{1}), elem(codec, []byte(c)))
+}
+
+func webm() []byte {
+	hdr := elem(magic, elem([]byte{0x42, 0x86}, []byte{1}), elem(docTypeID, []byte("webm")))
+	seg := elem(segment,
+		elem(seekHead, bytes.Repeat([]byte{0xEC}, 200)),
+		elem(info, elem([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40})),
+		elem(tracks, track("V_VP9"), track("A_OPUS")),
+		elem(cluster, elem([]byte{0xE7}, []byte{0})),
+	)
+	return append(hdr, seg...)
+}
+
+// a Matroska file with a segment of unknown size (as written when streaming) and a null padded DocType
+func matroska() []byte {
+	hdr := elem(magic, elem(docTypeID, []byte("matroska\x00")))
+	seg := append(append([]byte{}, segment...), 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+	seg = append(seg, elem(tracks, track("V_MPEG4/ISO/AVC"), track("A_AAC"), track("A_AAC"))...)
+	// the codecs of any tracks after the first cluster are ignored
+	seg = append(seg, elem(cluster, elem([]byte{0xE7}, []byte{0}))...)
+	seg = append(seg, elem(tracks, track("V_THEORA"))...)
+	return append(hdr, seg...)
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		byts    []byte
+		doctype string
+		codecs  []string
+	}{
+		{"webm", webm(), "webm", []string{"V_VP9", "A_OPUS"}},
+		{"matroska", matroska(), "matroska", []string{"V_MPEG4/ISO/AVC", "A_AAC"}},
+		{"header only", elem(magic, elem(docTypeID, []byte("webm"))), "webm", nil},
+		{"truncated", webm()[:20], "webm", nil},
+		{"not ebml", []byte("RIFF....WAVEfmt "), "", nil},
+		{"bad size", append(append([]byte{}, magic...), 0, 0, 0, 0, 0x42, 0x82, 0x84, 'w', 'e', 'b', 'm'), "", nil},
+	} {
+		b, err := siegreader.New().Get(bytes.NewReader(test.byts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		doctype, codecs := parse(b)
+		if doctype != test.doctype || strings.Join(codecs, ",") != strings.Join(test.codecs, ",") {
+			t.Errorf("%s: expecting %s %v, got %s %v", test.name, test.doctype, test.codecs, doctype, codecs)
+		}
+	}
+}
+
+func seq(s string) frames.Frame {
+	return frames.NewFrame(frames.PREV, patterns.Sequence(s), 0, 32)
+}
+
+func TestDocTypes(t *testing.T) {
+	sigs := []frames.Signature{
+		{frames.NewFrame(frames.BOF, patterns.Sequence(magic), 0, 1024), seq("\x42\x82\x88matroska\x42\x87")},
+		{frames.NewFrame(frames.BOF, patterns.Sequence(magic), 0), seq("\x42\x82\x84webm\x42\x87")},
+		{frames.NewFrame(frames.BOF, patterns.Sequence("RIFF"), 0), seq("\x42\x82\x84webm")},
+		{frames.NewFrame(frames.BOF, patterns.Sequence(magic), 0), seq("\x42\x82\x84we")},
+	}
+	dts, ids := DocTypes(sigs, []string{"fmt/569", "fmt/573", "fmt/x", "fmt/y"})
+	if strings.Join(dts, ",") != "matroska,webm" || strings.Join(ids, ",") != "fmt/569,fmt/573" {
+		t.Errorf("expecting matroska and webm, got %v %v", dts, ids)
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	m, l, err := Add(nil, SignatureSet{"matroska", "webm"}, nil)
+	if err != nil || l != 2 {
+		t.Fatalf("expecting a matcher with two signatures, got %d %v", l, err)
+	}
+	b, _ := siegreader.New().Get(bytes.NewReader(webm()))
+	res, err := m.Identify("", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hits []core.Result
+	for r := range res {
+		hits = append(hits, r)
+	}
+	if len(hits) != 1 || hits[0].Index() != 1 || hits[0].Basis() != "ebml doctype webm (codecs V_VP9, A_OPUS)" {
+		t.Fatalf("expecting a webm hit, got %v", hits)
+	}
+}
+
+func TestIO(t *testing.T) {
+	em, _, _ := Add(nil, SignatureSet{"matroska", "webm"}, nil)
+	str := em.String()
+	saver := persist.NewLoadSaver(nil)
+	Save(em, saver)
+	loader := persist.NewLoadSaver(saver.Bytes())
+	newem := Load(loader)
+	if str2 := newem.String(); str != str2 {
+		t.Errorf("Load EBML matcher: expecting first matcher (%v), to equal second matcher (%v)", str, str2)
+	}
+}
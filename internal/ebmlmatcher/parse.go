@@ -0,0 +1,211 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ebmlmatcher
+
+import (
+	"bytes"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// EBML and Matroska element IDs
+const (
+	ebmlID       = 0x1A45DFA3
+	docTypeElem  = 0x4282
+	segmentID    = 0x18538067
+	tracksID     = 0x1654AE6B
+	trackEntryID = 0xAE
+	codecID      = 0x86
+	clusterID    = 0x1F43B675
+)
+
+var (
+	magic     = []byte{0x1A, 0x45, 0xDF, 0xA3}
+	docTypeID = []byte{0x42, 0x82}
+)
+
+const (
+	unknownSize = -1
+	maxElements = 1024 // the most elements read before giving up on finding the tracks
+	maxString   = 256  // the longest DocType or codec ID read
+)
+
+type parser struct {
+	b   *siegreader.Buffer
+	off int64
+	n   int // number of elements read
+}
+
+// vint reads an EBML variable length integer. With marker, the length marker is kept (as it is for element IDs).
+func (p *parser) vint(max int, marker bool) (int64, bool) {
+	buf, _ := p.b.Slice(p.off, 1)
+	if len(buf) == 0 || buf[0] == 0 {
+		return 0, false
+	}
+	l := 1
+	for mask := byte(0x80); buf[0]&mask == 0; mask >>= 1 {
+		l++
+	}
+	if l > max {
+		return 0, false
+	}
+	if buf, _ = p.b.Slice(p.off, l); len(buf) < l {
+		return 0, false
+	}
+	p.off += int64(l)
+	v := int64(buf[0])
+	if !marker {
+		v &^= int64(0x80) >> (l - 1)
+	}
+	allOnes := v == int64(0xFF)>>l
+	for _, c := range buf[1:] {
+		v = v<<8 | int64(c)
+		allOnes = allOnes && c == 0xFF
+	}
+	if !marker && allOnes {
+		return unknownSize, true
+	}
+	return v, true
+}
+
+// element reads the ID and size of the next element. The size is unknownSize for elements of unknown size.
+func (p *parser) element() (int64, int64, bool) {
+	p.n++
+	if p.n > maxElements {
+		return 0, 0, false
+	}
+	id, ok := p.vint(4, true)
+	if !ok {
+		return 0, 0, false
+	}
+	sz, ok := p.vint(8, false)
+	return id, sz, ok
+}
+
+// str reads a string element of a given size
+func (p *parser) str(sz int64) (string, bool) {
+	if sz < 0 || sz > maxString {
+		return "", false
+	}
+	buf, _ := p.b.Slice(p.off, int(sz))
+	if int64(len(buf)) < sz {
+		return "", false
+	}
+	p.off += sz
+	// strings may be padded with nulls
+	if i := bytes.IndexByte(buf, 0); i > -1 {
+		buf = buf[:i]
+	}
+	if !printable(buf) {
+		return "", false
+	}
+	return string(buf), true
+}
+
+// children calls fn for each child element of an element that ends at end (or that is of unknown size, if end is
+// unknownSize), until fn returns false. If fn reads an element's content it must leave the offset at the end of the
+// element, elements that fn doesn't read are skipped.
+func (p *parser) children(end int64, fn func(id, sz int64) bool) bool {
+	for end == unknownSize || p.off < end {
+		id, sz, ok := p.element()
+		if !ok {
+			return false
+		}
+		start := p.off
+		if !fn(id, sz) {
+			return false
+		}
+		if p.off == start {
+			if sz == unknownSize {
+				return false
+			}
+			p.off += sz
+		}
+	}
+	return true
+}
+
+// parse reads the DocType from the EBML header of a file and, for Matroska files, the codec IDs of its tracks
+func parse(b *siegreader.Buffer) (string, []string) {
+	buf, err := b.Slice(0, 4)
+	if err != nil || !bytes.Equal(buf, magic) {
+		return "", nil
+	}
+	p := &parser{b: b}
+	id, sz, ok := p.element()
+	if !ok || id != ebmlID || sz == unknownSize {
+		return "", nil
+	}
+	var doctype string
+	hdrEnd := p.off + sz
+	p.children(hdrEnd, func(id, sz int64) bool {
+		if id == docTypeElem {
+			doctype, _ = p.str(sz)
+			return false
+		}
+		return true
+	})
+	if doctype == "" {
+		return "", nil
+	}
+	// the tracks should come before the first cluster
+	p.off = hdrEnd
+	var codecs []string
+	if id, sz, ok = p.element(); !ok || id != segmentID {
+		return doctype, nil
+	}
+	var end int64 = unknownSize
+	if sz != unknownSize {
+		end = p.off + sz
+	}
+	p.children(end, func(id, sz int64) bool {
+		switch id {
+		case clusterID:
+			return false
+		case tracksID:
+			if sz == unknownSize {
+				return false
+			}
+			p.children(p.off+sz, func(id, sz int64) bool {
+				if id != trackEntryID {
+					return true
+				}
+				if sz == unknownSize {
+					return false
+				}
+				return p.children(p.off+sz, func(id, sz int64) bool {
+					if id == codecID {
+						if codec, ok := p.str(sz); ok && codec != "" && !contains(codecs, codec) {
+							codecs = append(codecs, codec)
+						}
+					}
+					return true
+				})
+			})
+			return false
+		}
+		return true
+	})
+	return doctype, codecs
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fontmatcher refines the matches of SFNT based fonts (TrueType and OpenType fonts, collections and WOFF and
+// WOFF2 web fonts) by reading their table directories. It matches the tables of a font (e.g. the glyf table of a
+// TrueType font) wherever they are in its table directory, when byte signatures only find them at the offsets they
+// usually have, and reports the flavor of a font, the number of fonts in a collection and the variation axes of a
+// variable font as the basis of a match.
+package fontmatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/priority"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// Signature identifies a font in a container (a single font, a collection, or a WOFF or WOFF2) that has the flavor
+// of the signature, if it has one, and all its tables. A collection matches if any of its fonts do.
+type Signature struct {
+	Container string   // the magic of the container e.g. "wOFF", or "sfnt" for a single font of any flavor
+	Flavor    string   // the sfnt version of the font e.g. "OTTO"
+	Tables    []string // the tags of the tables the font must have e.g. "glyf"
+}
+
+func (s Signature) String() string {
+	strs := make([]string, 0, 3)
+	strs = append(strs, containerNames[s.Container])
+	if s.Flavor != "" {
+		strs = append(strs, flavorName(s.Flavor)+" flavor")
+	}
+	if len(s.Tables) > 0 {
+		tags := make([]string, len(s.Tables))
+		for i, t := range s.Tables {
+			tags[i] = tagString(t)
+		}
+		strs = append(strs, "tables "+strings.Join(tags, ", "))
+	}
+	return strings.Join(strs, "; ")
+}
+
+func (s Signature) match(f *font) bool {
+	if s.Container != f.container {
+		return false
+	}
+	for _, fc := range f.faces {
+		if s.Flavor != "" && s.Flavor != fc.flavor {
+			continue
+		}
+		var missing bool
+		for _, t := range s.Tables {
+			if _, ok := fc.tables[t]; !ok {
+				missing = true
+				break
+			}
+		}
+		if !missing {
+			return true
+		}
+	}
+	return false
+}
+
+// magic reads the container and flavor of a sequence at the start of a font: an sfnt version, a collection's
+// magic, or a WOFF or WOFF2 magic followed, if at all, by its flavor.
+func magic(seq []byte) (string, string, bool) {
+	if len(seq) < 4 {
+		return "", "", false
+	}
+	m := string(seq[:4])
+	switch {
+	case len(seq) == 4 && flavors[m] != "":
+		return sfntContainer, m, true
+	case len(seq) == 4 && m == ttcContainer:
+		return ttcContainer, "", true
+	case m == woffContainer || m == woff2Container:
+		if len(seq) == 4 {
+			return m, "", true
+		}
+		flavor := string(seq[4:])
+		if len(seq) == 8 && (flavors[flavor] != "" || (m == woff2Container && flavor == ttcContainer)) {
+			return m, flavor, true
+		}
+	}
+	return "", "", false
+}
+
+// Signatures derives font signatures from byte signatures that begin with the magic of a font (an sfnt version,
+// a collection's magic, or a WOFF or WOFF2 magic and flavor), or with a table tag in the table directory of a single
+// font (e.g. the OS/2 tag of PRONOM's TrueType signature, at the offset of a table record). The other sequences of
+// the signature must be table tags: at least one, if the signature doesn't begin with a magic. A signature with any
+// other sequence is left to the byte matcher. It returns the signatures and the corresponding IDs.
+func Signatures(sigs []frames.Signature, ids []string) ([]Signature, []string) {
+	var ret []Signature
+	var rids []string
+	seen := make(map[string]bool)
+	for i, sig := range sigs {
+		if len(sig) == 0 || sig[0].Orientation() != frames.BOF {
+			continue
+		}
+		seq, ok := sig[0].Pattern.(patterns.Sequence)
+		if !ok {
+			continue
+		}
+		var s Signature
+		switch {
+		case sig[0].Min == 0 && sig[0].Max == 0:
+			s.Container, s.Flavor, ok = magic(seq)
+		case sig[0].Min >= sfntHeaderLen && (sig[0].Min-sfntHeaderLen)%sfntRecordLen == 0 && validTag(seq):
+			s.Container, s.Tables = sfntContainer, []string{string(seq)}
+		default:
+			ok = false
+		}
+		if !ok {
+			continue
+		}
+		for _, f := range sig[1:] {
+			if seq, ok = f.Pattern.(patterns.Sequence); !ok || !validTag(seq) {
+				ok = false
+				break
+			}
+			s.Tables = append(s.Tables, string(seq))
+		}
+		// without a magic, a single sequence that looks like a tag (e.g. "M.K." in a MOD) is too little to go on
+		if !ok || (s.Flavor == "" && s.Container == sfntContainer && len(s.Tables) < 2) {
+			continue
+		}
+		// byte signatures may be repeated e.g. for BOF and EOF offsets
+		if key := ids[i] + s.String(); !seen[key] {
+			seen[key] = true
+			ret, rids = append(ret, s), append(rids, ids[i])
+		}
+	}
+	return ret, rids
+}
+
+type Matcher struct {
+	sigs       []Signature
+	priorities *priority.Set
+}
+
+func Load(ls *persist.LoadSaver) core.Matcher {
+	le := ls.LoadSmallInt()
+	if le == 0 {
+		return nil
+	}
+	m := &Matcher{sigs: make([]Signature, le)}
+	for i := range m.sigs {
+		m.sigs[i] = Signature{
+			Container: ls.LoadString(),
+			Flavor:    ls.LoadString(),
+			Tables:    ls.LoadStrings(),
+		}
+	}
+	m.priorities = priority.Load(ls)
+	return m
+}
+
+func Save(c core.Matcher, ls *persist.LoadSaver) {
+	if c == nil {
+		ls.SaveSmallInt(0)
+		return
+	}
+	m := c.(*Matcher)
+	ls.SaveSmallInt(len(m.sigs))
+	if len(m.sigs) == 0 {
+		return
+	}
+	for _, s := range m.sigs {
+		ls.SaveString(s.Container)
+		ls.SaveString(s.Flavor)
+		ls.SaveStrings(s.Tables)
+	}
+	m.priorities.Save(ls)
+}
+
+type SignatureSet []Signature
+
+// MarshalJSON encodes a Matcher for export.
+func (m *Matcher) MarshalJSON() ([]byte, error) {
+	sigs := make([]string, len(m.sigs))
+	for i, s := range m.sigs {
+		sigs[i] = s.String()
+	}
+	return json.Marshal(struct {
+		Signatures []string      `json:"signatures"`
+		Priorities *priority.Set `json:"priorities"`
+	}{sigs, m.priorities})
+}
+
+func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, int, error) {
+	sigs, ok := ss.(SignatureSet)
+	if !ok {
+		return nil, -1, fmt.Errorf("Fontmatcher: %w", core.ErrSignatureSet)
+	}
+	if len(sigs) == 0 {
+		return c, 0, nil
+	}
+	var m *Matcher
+	if c == nil {
+		m = &Matcher{priorities: &priority.Set{}}
+	} else {
+		m = c.(*Matcher)
+	}
+	m.sigs = append(m.sigs, sigs...)
+	// add priorities
+	m.priorities.Add(p, len(sigs), 0, 0)
+	return m, len(m.sigs), nil
+}
+
+type result struct {
+	idx      int
+	sig      Signature
+	features []string
+}
+
+func (r result) Index() int {
+	return r.idx
+}
+
+func (r result) Basis() string {
+	basis := "font " + strings.Join(r.features, ", ")
+	if len(r.sig.Tables) > 0 {
+		basis += " (matched " + r.sig.String() + ")"
+	}
+	return basis
+}
+
+// Specific reports whether the result matched tables, rather than just the magic of a font.
+func (r result) Specific() bool {
+	return len(r.sig.Tables) > 0
+}
+
+func (m Matcher) Identify(na string, b *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	f, ok := parse(b)
+	if !ok {
+		res := make(chan core.Result)
+		close(res)
+		return res, nil
+	}
+	features := f.features()
+	if config.Debug() {
+		fmt.Fprintf(config.Out(), "font %s\n", strings.Join(features, ", "))
+	}
+	waitset := m.priorities.WaitSet(hints...)
+	var hits []core.Result
+	for i, s := range m.sigs {
+		if s.match(f) && waitset.Check(i) {
+			if config.Debug() {
+				fmt.Fprintf(config.Out(), "sending font match %s\n", s)
+			}
+			hits = append(hits, result{i, s, features})
+			if waitset.Put(i) {
+				break
+			}
+		}
+	}
+	res := make(chan core.Result, len(hits))
+	for _, h := range hits {
+		res <- h
+	}
+	close(res)
+	return res, nil
+}
+
+func (m Matcher) String() string {
+	strs := make([]string, len(m.sigs))
+	for i, s := range m.sigs {
+		strs[i] = fmt.Sprintf("%d: %s", i, s)
+	}
+	return fmt.Sprintf("Font matcher:\n%s\n", strings.Join(strs, "\n"))
+}
@@ -0,0 +1,261 @@
+package fontmatcher
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+type tbl struct {
+	tag  string
+	data []byte
+}
+
+func u16(v int) []byte { return []byte{byte(v >> 8), byte(v)} }
+
+func u32(v uint32) []byte {
+	byts := make([]byte, 4)
+	binary.BigEndian.PutUint32(byts, v)
+	return byts
+}
+
+// sfntAt makes a font that will be at an offset in a file (so its table offsets are from the start of the file)
+func sfntAt(off int, flavor string, tables ...tbl) []byte {
+	buf := append([]byte(flavor), u16(len(tables))...)
+	buf = append(buf, make([]byte, 6)...)
+	data := off + sfntHeaderLen + len(tables)*sfntRecordLen
+	var body []byte
+	for _, t := range tables {
+		buf = append(buf, t.tag...)
+		buf = append(buf, make([]byte, 4)...)
+		buf = append(buf, u32(uint32(data+len(body)))...)
+		buf = append(buf, u32(uint32(len(t.data)))...)
+		body = append(body, t.data...)
+	}
+	return append(buf, body...)
+}
+
+func sfntFont(flavor string, tables ...tbl) []byte { return sfntAt(0, flavor, tables...) }
+
+// ttc makes a collection of fonts, each a list of tables with the flavor of its first table's tag
+func ttc(fonts ...[]tbl) []byte {
+	buf := append([]byte("ttcf\x00\x01\x00\x00"), u32(uint32(len(fonts)))...)
+	offs := len(buf) + 4*len(fonts)
+	var body []byte
+	for _, f := range fonts {
+		buf = append(buf, u32(uint32(offs+len(body)))...)
+		body = append(body, sfntAt(offs+len(body), f[0].tag, f[1:]...)...)
+	}
+	return append(buf, body...)
+}
+
+// woffFont makes a WOFF, compressing its tables if that makes them smaller
+func woffFont(flavor string, tables ...tbl) []byte {
+	buf := append([]byte("wOFF"+flavor), make([]byte, 4)...)
+	buf = append(buf, u16(len(tables))...)
+	buf = append(buf, make([]byte, woffHeaderLen-len(buf))...)
+	data := woffHeaderLen + len(tables)*woffRecordLen
+	var body []byte
+	for _, t := range tables {
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		w.Write(t.data)
+		w.Close()
+		if z.Len() >= len(t.data) {
+			z.Reset()
+			z.Write(t.data)
+		}
+		buf = append(buf, t.tag...)
+		buf = append(buf, u32(uint32(data+len(body)))...)
+		buf = append(buf, u32(uint32(z.Len()))...)
+		buf = append(buf, u32(uint32(len(t.data)))...)
+		buf = append(buf, make([]byte, 4)...)
+		body = append(body, z.Bytes()...)
+	}
+	return append(buf, body...)
+}
+
+// woff2Font makes the header and directories of a WOFF2: tables are given by tag and, if a collection, its fonts
+// by flavor and the indexes of their tables
+func woff2Font(flavor string, tags []string, fonts map[string][]byte) []byte {
+	buf := append([]byte("wOF2"+flavor), make([]byte, 4)...)
+	buf = append(buf, u16(len(tags))...)
+	buf = append(buf, make([]byte, woff2HeaderLen-len(buf))...)
+	for _, t := range tags {
+		flags := byte(0x3F)
+		for i, v := range woff2Tags {
+			if t == v {
+				flags = byte(i)
+				break
+			}
+		}
+		buf = append(buf, flags)
+		if flags == 0x3F {
+			buf = append(buf, t...)
+		}
+		buf = append(buf, 0x82, 0x2C) // an original length of 300
+		if t == "glyf" || t == "loca" {
+			buf = append(buf, 0x64) // a transform length of 100
+		}
+	}
+	if flavor == ttcContainer {
+		buf = append(buf, 0, 1, 0, 0, byte(len(fonts)))
+		for _, flavor := range []string{"\x00\x01\x00\x00", "OTTO"} {
+			if idxs, ok := fonts[flavor]; ok {
+				buf = append(buf, byte(len(idxs)))
+				buf = append(buf, flavor...)
+				buf = append(buf, idxs...)
+			}
+		}
+	}
+	return buf
+}
+
+func fvarTable(axes ...axis) []byte {
+	buf := []byte{0, 1, 0, 0, 0, 16, 0, 2}
+	buf = append(buf, u16(len(axes))...)
+	buf = append(buf, 0, 20, 0, 0, 0, 0)
+	fixed := func(f float64) []byte { return u32(uint32(int32(f * 65536))) }
+	for _, a := range axes {
+		buf = append(buf, a.tag...)
+		buf = append(buf, fixed(a.min)...)
+		buf = append(buf, fixed(a.def)...)
+		buf = append(buf, fixed(a.max)...)
+		buf = append(buf, 0, 0, 1, 0)
+	}
+	return buf
+}
+
+var (
+	tt  = "\x00\x01\x00\x00"
+	ttf = sfntFont(tt, tbl{"OS/2", make([]byte, 8)}, tbl{"cmap", make([]byte, 8)}, tbl{"glyf", make([]byte, 8)})
+	otf = sfntFont("OTTO", tbl{"CFF ", make([]byte, 8)}, tbl{"cmap", make([]byte, 8)})
+	vf  = sfntFont(tt, tbl{"OS/2", make([]byte, 8)}, tbl{"fvar", fvarTable(axis{"wght", 100, 400, 900}, axis{"wdth", 75, 100, 100})},
+		tbl{"glyf", make([]byte, 8)})
+	collection = ttc([]tbl{{tag: tt}, {"cmap", make([]byte, 8)}, {"glyf", make([]byte, 8)}},
+		[]tbl{{tag: "OTTO"}, {"CFF ", make([]byte, 8)}})
+	woff  = woffFont(tt, tbl{"fvar", fvarTable(axis{"wght", 300, 400, 700}, axis{"slnt", -12, 0, 0})}, tbl{"glyf", make([]byte, 64)})
+	woff2 = woff2Font("OTTO", []string{"CFF ", "fvar", "Zzzz"}, nil)
+	woffc = woff2Font(ttcContainer, []string{"cmap", "glyf", "loca"}, map[string][]byte{tt: {0, 1, 2}, "OTTO": {0}})
+)
+
+func buffer(t *testing.T, byts []byte) *siegreader.Buffer {
+	b, err := siegreader.New().Get(bytes.NewReader(byts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		byts   []byte
+		expect string
+	}{
+		{"ttf", ttf, "sfnt, TrueType flavor, 3 tables"},
+		{"otf", otf, "sfnt, CFF flavor, 2 tables"},
+		{"variable", vf, "sfnt, TrueType flavor, 3 tables, variable (wght 100..900, wdth 75..100)"},
+		{"ttc", collection, "TTC, 2 fonts, TrueType flavor, CFF flavor"},
+		{"woff", woff, "WOFF, TrueType flavor, 2 tables, variable (wght 300..700, slnt -12..0)"},
+		{"woff2", woff2, "WOFF2, CFF flavor, 3 tables, variable"},
+		{"woff2 collection", woffc, "WOFF2, 2 fonts, TrueType flavor, CFF flavor"},
+		{"truncated", ttf[:30], ""},
+		{"bad tag", sfntFont(tt, tbl{"\x00\x01ab", nil}), ""},
+		{"text", []byte("true or false, that is the question"), ""},
+	} {
+		f, ok := parse(buffer(t, test.byts))
+		var got string
+		if ok {
+			got = strings.Join(f.features(), ", ")
+		}
+		if got != test.expect {
+			t.Errorf("%s: expecting %q, got %q", test.name, test.expect, got)
+		}
+	}
+}
+
+var sigs = SignatureSet{
+	{Container: sfntContainer, Tables: []string{"OS/2", "cmap", "glyf"}},
+	{Container: sfntContainer, Flavor: "OTTO", Tables: []string{"CFF "}},
+	{Container: woffContainer},
+	{Container: woff2Container, Flavor: "OTTO"},
+	{Container: ttcContainer, Tables: []string{"CFF "}},
+}
+
+func TestIdentify(t *testing.T) {
+	m, l, err := Add(nil, sigs, nil)
+	if err != nil || l != len(sigs) {
+		t.Fatalf("expecting a matcher with %d signatures, got %d %v", len(sigs), l, err)
+	}
+	for _, test := range []struct {
+		name   string
+		byts   []byte
+		expect string
+	}{
+		{"ttf", ttf, "0: font sfnt, TrueType flavor, 3 tables (matched sfnt; tables OS/2, cmap, glyf)"},
+		{"otf", otf, "1: font sfnt, CFF flavor, 2 tables (matched sfnt; CFF flavor; tables CFF)"},
+		{"woff", woff, "2: font WOFF, TrueType flavor, 2 tables, variable (wght 300..700, slnt -12..0)"},
+		{"woff2", woff2, "3: font WOFF2, CFF flavor, 3 tables, variable"},
+		{"ttc", collection, "4: font TTC, 2 fonts, TrueType flavor, CFF flavor (matched TTC; tables CFF)"},
+		{"not font", []byte("RIFF....WAVEfmt "), ""},
+	} {
+		res, err := m.Identify("", buffer(t, test.byts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var hits []string
+		for r := range res {
+			hits = append(hits, strconv.Itoa(r.Index())+": "+r.Basis())
+		}
+		if got := strings.Join(hits, " | "); got != test.expect {
+			t.Errorf("%s: expecting %q, got %q", test.name, test.expect, got)
+		}
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	bof := func(s string, min, max int) frames.Frame {
+		return frames.NewFrame(frames.BOF, patterns.Sequence(s), min, max)
+	}
+	prev := func(s string) frames.Frame { return frames.NewFrame(frames.PREV, patterns.Sequence(s), 0, 256) }
+	bsigs := []frames.Signature{
+		{bof("OS/2", 12, 140), prev("cmap"), prev("glyf")},
+		{bof("OTTO", 0, 0), prev("CFF ")},
+		{bof("wOFF", 0, 0)},
+		{bof("wOF2OTTO", 0, 0)},
+		{bof("M.K.", 1080, 1080)},
+		{bof("LWFN", 12, 12)},
+		{bof("OTTO\x00\x0A", 0, 0)},
+		{bof("wOFF", 0, 0), prev("\x00\x01")},
+	}
+	ss, ids := Signatures(bsigs, []string{"x-fmt/453", "fmt/520", "fmt/616", "fmt/x", "x-fmt/y", "fmt/y", "fmt/z", "fmt/zz"})
+	strs := make([]string, len(ss))
+	for i, s := range ss {
+		strs[i] = s.String()
+	}
+	expect := "sfnt; tables OS/2, cmap, glyf | sfnt; CFF flavor; tables CFF | WOFF | WOFF2; CFF flavor"
+	if got := strings.Join(strs, " | "); got != expect || strings.Join(ids, ",") != "x-fmt/453,fmt/520,fmt/616,fmt/x" {
+		t.Errorf("expecting %s, got %s %v", expect, got, ids)
+	}
+}
+
+func TestIO(t *testing.T) {
+	om, _, _ := Add(nil, sigs, nil)
+	str := om.String()
+	saver := persist.NewLoadSaver(nil)
+	Save(om, saver)
+	loader := persist.NewLoadSaver(saver.Bytes())
+	newom := Load(loader)
+	if str2 := newom.String(); str != str2 {
+		t.Errorf("Load font matcher: expecting first matcher (%v), to equal second matcher (%v)", str, str2)
+	}
+}
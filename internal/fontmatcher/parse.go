@@ -0,0 +1,502 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fontmatcher
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// the containers of fonts, by their magic
+const (
+	sfntContainer  = "sfnt" // a single font, with any of the sfnt versions in flavors
+	ttcContainer   = "ttcf" // a TrueType/OpenType collection
+	woffContainer  = "wOFF"
+	woff2Container = "wOF2"
+)
+
+var containerNames = map[string]string{
+	sfntContainer:  "sfnt",
+	ttcContainer:   "TTC",
+	woffContainer:  "WOFF",
+	woff2Container: "WOFF2",
+}
+
+// flavors are the sfnt versions of a font: the magic of a single font, or the flavor of a font in a collection or WOFF
+var flavors = map[string]string{
+	"\x00\x01\x00\x00": "TrueType",
+	"true":             "TrueType (Apple)",
+	"OTTO":             "CFF",
+	"typ1":             "Type 1",
+}
+
+func flavorName(flavor string) string {
+	if flavor == ttcContainer {
+		return "collection"
+	}
+	if n, ok := flavors[flavor]; ok {
+		return n
+	}
+	return fmt.Sprintf("%q", flavor)
+}
+
+// woff2Tags are the tags of the known tables of a WOFF2 table directory, by their index in its flags
+var woff2Tags = [...]string{"cmap", "head", "hhea", "hmtx", "maxp", "name", "OS/2", "post", "cvt ", "fpgm", "glyf",
+	"loca", "prep", "CFF ", "VORG", "EBDT", "EBLC", "gasp", "hdmx", "kern", "LTSH", "PCLT", "VDMX", "vhea", "vmtx",
+	"BASE", "GDEF", "GPOS", "GSUB", "EBSC", "JSTF", "MATH", "CBDT", "CBLC", "COLR", "CPAL", "SVG ", "sbix", "acnt",
+	"avar", "bdat", "bloc", "bsln", "cvar", "fdsc", "feat", "fmtx", "fvar", "gvar", "hsty", "just", "lcar", "mort",
+	"morx", "opbd", "prop", "trak", "Zapf", "Silf", "Glat", "Gloc", "Feat", "Sill"}
+
+const (
+	sfntHeaderLen  = 12
+	sfntRecordLen  = 16
+	woffHeaderLen  = 44
+	woffRecordLen  = 20
+	woff2HeaderLen = 48
+	woff2RecordLen = 15 // the most bytes a WOFF2 table directory entry can have
+	fvarTag        = "fvar"
+)
+
+const (
+	maxFonts  = 64    // the most fonts read in a collection
+	maxTables = 512   // the most tables in a font
+	maxAxes   = 64    // the most variation axes reported
+	maxFvar   = 65536 // the most bytes read of an fvar table
+)
+
+// validTag reports whether a table tag is four printable ASCII characters, padded (if at all) with trailing spaces
+func validTag(tag []byte) bool {
+	if len(tag) != 4 || tag[0] == ' ' {
+		return false
+	}
+	for _, c := range tag {
+		if c < 0x20 || c > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+func tagString(tag string) string {
+	return strings.TrimRight(tag, " ")
+}
+
+type table struct {
+	offset     uint32
+	length     uint32
+	compLength uint32 // the compressed length of a WOFF table (the same as the length if it isn't compressed)
+}
+
+type face struct {
+	flavor string
+	tables map[string]table
+}
+
+type axis struct {
+	tag           string
+	min, def, max float64
+}
+
+func (a axis) String() string {
+	return fmt.Sprintf("%s %g..%g", tagString(a.tag), a.min, a.max)
+}
+
+type font struct {
+	container string
+	numFonts  int    // the number of fonts in a collection, which may be more than the faces read
+	faces     []face // the table directories of the fonts read
+	variable  bool   // whether a font has an fvar table
+	axes      []axis // the variation axes of the first variable font, if its fvar table could be read
+}
+
+// parse reads the header and table directories of a font: a single font (an sfnt), a collection, or a WOFF or
+// WOFF2. It reports false if the file isn't a font or a table directory can't be read.
+func parse(b *siegreader.Buffer) (*font, bool) {
+	buf, _ := b.Slice(0, sfntHeaderLen)
+	if len(buf) < sfntHeaderLen {
+		return nil, false
+	}
+	f := &font{}
+	magic := string(buf[:4])
+	switch {
+	case flavors[magic] != "":
+		fc, ok := sfnt(b, 0)
+		if !ok {
+			return nil, false
+		}
+		f.container, f.numFonts, f.faces = sfntContainer, 1, []face{fc}
+	case magic == ttcContainer:
+		if !f.ttc(b, buf) {
+			return nil, false
+		}
+	case magic == woffContainer:
+		if !f.woff(b) {
+			return nil, false
+		}
+	case magic == woff2Container:
+		if !f.woff2(b) {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	for _, fc := range f.faces {
+		if t, ok := fc.tables[fvarTag]; ok {
+			f.variable = true
+			f.axes = fvar(f.data(b, t))
+			break
+		}
+	}
+	return f, true
+}
+
+// sfnt reads the table directory of a font at an offset
+func sfnt(b *siegreader.Buffer, off int64) (face, bool) {
+	buf, _ := b.Slice(off, sfntHeaderLen)
+	if len(buf) < sfntHeaderLen || flavors[string(buf[:4])] == "" {
+		return face{}, false
+	}
+	fc := face{flavor: string(buf[:4])}
+	n := int(binary.BigEndian.Uint16(buf[4:]))
+	if n == 0 || n > maxTables {
+		return face{}, false
+	}
+	l := n * sfntRecordLen
+	if buf, _ = b.Slice(off+sfntHeaderLen, l); len(buf) < l {
+		return face{}, false
+	}
+	fc.tables = make(map[string]table, n)
+	for i := 0; i < n; i++ {
+		rec := buf[i*sfntRecordLen:]
+		t := table{offset: binary.BigEndian.Uint32(rec[8:]), length: binary.BigEndian.Uint32(rec[12:])}
+		// table data follows the table directory
+		if !validTag(rec[:4]) || t.offset < uint32(sfntHeaderLen+l) {
+			return face{}, false
+		}
+		t.compLength = t.length
+		fc.tables[string(rec[:4])] = t
+	}
+	return fc, true
+}
+
+// ttc reads the header of a collection and the table directories of the fonts in it
+func (f *font) ttc(b *siegreader.Buffer, hdr []byte) bool {
+	if major := binary.BigEndian.Uint16(hdr[4:]); major != 1 && major != 2 {
+		return false
+	}
+	n := binary.BigEndian.Uint32(hdr[8:])
+	if n == 0 || n > 0xFFFF {
+		return false
+	}
+	f.container, f.numFonts = ttcContainer, int(n)
+	if n > maxFonts {
+		n = maxFonts
+	}
+	buf, _ := b.Slice(sfntHeaderLen, int(n)*4)
+	if len(buf) < int(n)*4 {
+		return false
+	}
+	offs := make([]int64, n)
+	for i := range offs {
+		offs[i] = int64(binary.BigEndian.Uint32(buf[i*4:]))
+	}
+	for _, off := range offs {
+		if fc, ok := sfnt(b, off); ok {
+			f.faces = append(f.faces, fc)
+		}
+	}
+	return len(f.faces) > 0
+}
+
+// woff reads the header and table directory of a WOFF
+func (f *font) woff(b *siegreader.Buffer) bool {
+	buf, _ := b.Slice(0, woffHeaderLen)
+	if len(buf) < woffHeaderLen || flavors[string(buf[4:8])] == "" || binary.BigEndian.Uint16(buf[14:]) != 0 {
+		return false
+	}
+	fc := face{flavor: string(buf[4:8])}
+	n := int(binary.BigEndian.Uint16(buf[12:]))
+	if n == 0 || n > maxTables {
+		return false
+	}
+	l := n * woffRecordLen
+	if buf, _ = b.Slice(woffHeaderLen, l); len(buf) < l {
+		return false
+	}
+	fc.tables = make(map[string]table, n)
+	for i := 0; i < n; i++ {
+		rec := buf[i*woffRecordLen:]
+		t := table{
+			offset:     binary.BigEndian.Uint32(rec[4:]),
+			compLength: binary.BigEndian.Uint32(rec[8:]),
+			length:     binary.BigEndian.Uint32(rec[12:]),
+		}
+		if !validTag(rec[:4]) || t.offset < uint32(woffHeaderLen+l) || t.compLength > t.length {
+			return false
+		}
+		fc.tables[string(rec[:4])] = t
+	}
+	f.container, f.numFonts, f.faces = woffContainer, 1, []face{fc}
+	return true
+}
+
+// woff2 reads the header and table directory of a WOFF2 and, if it is a collection, its collection directory.
+// As a WOFF2's tables are compressed together (with Brotli), their data isn't read.
+func (f *font) woff2(b *siegreader.Buffer) bool {
+	buf, _ := b.Slice(0, woff2HeaderLen)
+	if len(buf) < woff2HeaderLen || binary.BigEndian.Uint16(buf[14:]) != 0 {
+		return false
+	}
+	flavor := string(buf[4:8])
+	if flavor != ttcContainer && flavors[flavor] == "" {
+		return false
+	}
+	n := int(binary.BigEndian.Uint16(buf[12:]))
+	if n == 0 || n > maxTables {
+		return false
+	}
+	buf, _ = b.Slice(woff2HeaderLen, n*woff2RecordLen)
+	c := &cursor{buf: buf}
+	tags := make([]string, n)
+	for i := range tags {
+		flags := c.uint8()
+		idx := flags & 0x3F
+		if idx == 0x3F {
+			tag := c.next(4)
+			if c.err || !validTag(tag) {
+				return false
+			}
+			tags[i] = string(tag)
+		} else if int(idx) < len(woff2Tags) {
+			tags[i] = woff2Tags[idx]
+		} else {
+			return false
+		}
+		c.base128() // the original length
+		// a transform version of 0 is the null transform, except for the glyf and loca tables
+		version := flags >> 6
+		if (version == 0) == (tags[i] == "glyf" || tags[i] == "loca") {
+			c.base128() // the transform length
+		}
+		if c.err {
+			return false
+		}
+	}
+	f.container = woff2Container
+	if flavor != ttcContainer {
+		fc := face{flavor: flavor, tables: make(map[string]table, n)}
+		for _, t := range tags {
+			fc.tables[t] = table{}
+		}
+		f.numFonts, f.faces = 1, []face{fc}
+		return true
+	}
+	// a collection directory follows the table directory: each font lists the indexes of its tables
+	off := int64(woff2HeaderLen + c.pos)
+	buf, _ = b.Slice(off, 7+maxFonts*(7+3*n))
+	c = &cursor{buf: buf}
+	if version := c.uint32(); version != 0x00010000 && version != 0x00020000 {
+		return false
+	}
+	f.numFonts = int(c.uint255())
+	if c.err || f.numFonts == 0 {
+		return false
+	}
+	for i := 0; i < f.numFonts && i < maxFonts; i++ {
+		nt := int(c.uint255())
+		fc := face{flavor: string(c.next(4)), tables: make(map[string]table, nt)}
+		for j := 0; j < nt; j++ {
+			idx := int(c.uint255())
+			if idx >= n {
+				return false
+			}
+			fc.tables[tags[idx]] = table{}
+		}
+		if c.err || flavors[fc.flavor] == "" {
+			return false
+		}
+		f.faces = append(f.faces, fc)
+	}
+	return true
+}
+
+// data reads the data of a table, decompressing it if it is a compressed WOFF table. It reads no more than maxFvar
+// bytes and nothing of a WOFF2 table.
+func (f *font) data(b *siegreader.Buffer, t table) []byte {
+	if f.container == woff2Container || t.length == 0 {
+		return nil
+	}
+	if t.compLength == t.length {
+		l := int(t.length)
+		if l > maxFvar {
+			l = maxFvar
+		}
+		buf, _ := b.Slice(int64(t.offset), l)
+		return buf
+	}
+	buf, _ := b.Slice(int64(t.offset), int(t.compLength))
+	if len(buf) < int(t.compLength) {
+		return nil
+	}
+	rdr, err := zlib.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return nil
+	}
+	defer rdr.Close()
+	byts, _ := io.ReadAll(io.LimitReader(rdr, maxFvar))
+	return byts
+}
+
+// fvar reads the variation axes of an fvar table
+func fvar(buf []byte) []axis {
+	if len(buf) < 16 || binary.BigEndian.Uint16(buf) != 1 {
+		return nil
+	}
+	off := int(binary.BigEndian.Uint16(buf[4:]))
+	n := int(binary.BigEndian.Uint16(buf[8:]))
+	sz := int(binary.BigEndian.Uint16(buf[10:]))
+	if sz < 20 {
+		return nil
+	}
+	if n > maxAxes {
+		n = maxAxes
+	}
+	fixed := func(byts []byte) float64 { return float64(int32(binary.BigEndian.Uint32(byts))) / 65536 }
+	ret := make([]axis, 0, n)
+	for i := 0; i < n; i++ {
+		rec := off + i*sz
+		if rec+20 > len(buf) || !validTag(buf[rec:rec+4]) {
+			break
+		}
+		ret = append(ret, axis{
+			tag: string(buf[rec : rec+4]),
+			min: fixed(buf[rec+4:]),
+			def: fixed(buf[rec+8:]),
+			max: fixed(buf[rec+12:]),
+		})
+	}
+	return ret
+}
+
+// cursor reads the variable length fields of a WOFF2 directory. It sets err if it reads past the end of its buffer
+// or a value is invalid.
+type cursor struct {
+	buf []byte
+	pos int
+	err bool
+}
+
+func (c *cursor) next(l int) []byte {
+	if c.err || c.pos+l > len(c.buf) {
+		c.err = true
+		return nil
+	}
+	c.pos += l
+	return c.buf[c.pos-l : c.pos]
+}
+
+func (c *cursor) uint8() byte {
+	if byts := c.next(1); byts != nil {
+		return byts[0]
+	}
+	return 0
+}
+
+func (c *cursor) uint16() uint16 {
+	if byts := c.next(2); byts != nil {
+		return binary.BigEndian.Uint16(byts)
+	}
+	return 0
+}
+
+func (c *cursor) uint32() uint32 {
+	if byts := c.next(4); byts != nil {
+		return binary.BigEndian.Uint32(byts)
+	}
+	return 0
+}
+
+// base128 reads a UIntBase128: up to five bytes of seven bits each, with no leading zeros
+func (c *cursor) base128() uint32 {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		byt := c.uint8()
+		if c.err || (i == 0 && byt == 0x80) || v&0xFE000000 != 0 {
+			c.err = true
+			return 0
+		}
+		v = v<<7 | uint32(byt&0x7F)
+		if byt&0x80 == 0 {
+			return v
+		}
+	}
+	c.err = true
+	return 0
+}
+
+// uint255 reads a 255UInt16: a byte, or a code byte followed by one or two bytes
+func (c *cursor) uint255() uint16 {
+	switch code := c.uint8(); code {
+	case 253:
+		return c.uint16()
+	case 254:
+		return uint16(c.uint8()) + 253*2
+	case 255:
+		return uint16(c.uint8()) + 253
+	default:
+		return uint16(code)
+	}
+}
+
+// features describes a font: its container, the number of fonts in a collection, the flavor of its fonts, the
+// number of tables of a single font and, for a variable font, its variation axes
+func (f *font) features() []string {
+	ret := make([]string, 0, 5)
+	ret = append(ret, containerNames[f.container])
+	switch {
+	case f.numFonts > 1:
+		ret = append(ret, fmt.Sprintf("%d fonts", f.numFonts))
+	case f.container == ttcContainer:
+		ret = append(ret, "1 font")
+	}
+	seen := make(map[string]bool)
+	for _, fc := range f.faces {
+		if !seen[fc.flavor] {
+			seen[fc.flavor] = true
+			ret = append(ret, flavorName(fc.flavor)+" flavor")
+		}
+	}
+	if len(f.faces) == 1 {
+		ret = append(ret, fmt.Sprintf("%d tables", len(f.faces[0].tables)))
+	}
+	if f.variable {
+		if len(f.axes) > 0 {
+			strs := make([]string, len(f.axes))
+			for i, a := range f.axes {
+				strs[i] = a.String()
+			}
+			ret = append(ret, "variable ("+strings.Join(strs, ", ")+")")
+		} else {
+			ret = append(ret, "variable")
+		}
+	}
+	return ret
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package frequency reads and writes feedback files that record how often formats are identified in a collection.
+// Feedback files are written by sf (sf -freq) and used by roy (roy build -freq) to order signatures so that the
+// most common formats in a collection are matched first.
+package frequency
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Counts maps format IDs (e.g. PUIDs) to the number of times they have been identified.
+type Counts map[string]int
+
+// Open reads counts from a feedback file.
+func Open(path string) (Counts, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read reads counts from CSV. The first row is a header and the following rows have a format ID and a count.
+func Read(r io.Reader) (Counts, error) {
+	rdr := csv.NewReader(r)
+	rdr.TrimLeadingSpace = true
+	rdr.Comment = '#'
+	rdr.FieldsPerRecord = 2
+	recs, err := rdr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("frequency: error reading CSV, got %v", err)
+	}
+	if len(recs) == 0 {
+		return nil, fmt.Errorf("frequency: expecting a header row")
+	}
+	c := make(Counts, len(recs)-1)
+	for _, rec := range recs[1:] {
+		n, err := strconv.Atoi(strings.TrimSpace(rec[1]))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("frequency: bad count for %s: %s", rec[0], rec[1])
+		}
+		c[strings.TrimSpace(rec[0])] += n
+	}
+	return c, nil
+}
+
+// Add records an identification of a format.
+func (c Counts) Add(id string) {
+	c[id]++
+}
+
+// IDs returns the format IDs in order of their counts, most common first. IDs with equal counts are sorted alphabetically.
+func (c Counts) IDs() []string {
+	ids := make([]string, 0, len(c))
+	for k := range c {
+		ids = append(ids, k)
+	}
+	sort.Slice(ids, func(i, j int) bool { return c.Less(ids[i], ids[j]) })
+	return ids
+}
+
+// Less reports whether format a should be matched before format b: i.e. if it is more common, or
+// equally common and sorts first alphabetically.
+func (c Counts) Less(a, b string) bool {
+	if c[a] != c[b] {
+		return c[a] > c[b]
+	}
+	return a < b
+}
+
+// Write writes counts as CSV, most common formats first.
+func (c Counts) Write(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "count"})
+	for _, id := range c.IDs() {
+		cw.Write([]string{id, strconv.Itoa(c[id])})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,35 @@
+package frequency
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testCSV = `id,count
+# comments are skipped
+fmt/14,3
+x-fmt/111, 10
+fmt/1,3
+`
+
+func TestReadWrite(t *testing.T) {
+	c, err := Read(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := c.IDs(); len(ids) != 3 || ids[0] != "x-fmt/111" || ids[1] != "fmt/1" || ids[2] != "fmt/14" {
+		t.Errorf("bad order, got %v", ids)
+	}
+	c.Add("fmt/14")
+	buf := &bytes.Buffer{}
+	if err = c.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "id,count\nx-fmt/111,10\nfmt/14,4\nfmt/1,3\n" {
+		t.Errorf("bad CSV, got %s", buf.String())
+	}
+	if _, err = Read(strings.NewReader("id,count\nfmt/1,many\n")); err == nil {
+		t.Error("expecting an error for a bad count")
+	}
+}
@@ -15,6 +15,7 @@
 package identifier
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -22,12 +23,16 @@ import (
 	"github.com/richardlehane/siegfried/internal/bytematcher"
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
 	"github.com/richardlehane/siegfried/internal/containermatcher"
+	"github.com/richardlehane/siegfried/internal/ebmlmatcher"
+	"github.com/richardlehane/siegfried/internal/fontmatcher"
 	"github.com/richardlehane/siegfried/internal/mimematcher"
 	"github.com/richardlehane/siegfried/internal/namematcher"
 	"github.com/richardlehane/siegfried/internal/persist"
 	"github.com/richardlehane/siegfried/internal/priority"
 	"github.com/richardlehane/siegfried/internal/riffmatcher"
+	"github.com/richardlehane/siegfried/internal/sqlitematcher"
 	"github.com/richardlehane/siegfried/internal/textmatcher"
+	"github.com/richardlehane/siegfried/internal/tiffmatcher"
 	"github.com/richardlehane/siegfried/internal/xmlmatcher"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/core"
@@ -35,12 +40,12 @@ import (
 
 // A base identifier that can be embedded in other identifier
 type Base struct {
-	p                                        Parseable
-	name                                     string
-	details                                  string
-	multi                                    config.Multi
-	zipDefault                               bool
-	gids, mids, cids, xids, bids, rids, tids *indexes
+	p                                                                Parseable
+	name                                                             string
+	details                                                          string
+	multi                                                            config.Multi
+	zipDefault                                                       bool
+	gids, mids, cids, xids, bids, rids, tids, eids, sids, fids, oids *indexes
 }
 
 type indexes struct {
@@ -98,6 +103,13 @@ func (ii *indexes) place(i int) (int, int) {
 	return -1, -1
 }
 
+func (ii *indexes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Start int      `json:"start"`
+		IDs   []string `json:"ids"`
+	}{ii.start, ii.ids})
+}
+
 func loadIndexes(ls *persist.LoadSaver) *indexes {
 	return &indexes{
 		start: ls.LoadInt(),
@@ -112,7 +124,7 @@ func New(p Parseable, zip string, extra ...string) *Base {
 		details:    config.Details(extra...),
 		multi:      config.GetMulti(),
 		zipDefault: contains(p.IDs(), zip),
-		gids:       &indexes{}, mids: &indexes{}, cids: &indexes{}, xids: &indexes{}, bids: &indexes{}, rids: &indexes{}, tids: &indexes{},
+		gids:       &indexes{}, mids: &indexes{}, cids: &indexes{}, xids: &indexes{}, bids: &indexes{}, rids: &indexes{}, tids: &indexes{}, eids: &indexes{}, sids: &indexes{}, fids: &indexes{}, oids: &indexes{},
 	}
 }
 
@@ -128,6 +140,10 @@ func (b *Base) Save(ls *persist.LoadSaver) {
 	b.bids.save(ls)
 	b.rids.save(ls)
 	b.tids.save(ls)
+	b.eids.save(ls)
+	b.sids.save(ls)
+	b.fids.save(ls)
+	b.oids.save(ls)
 }
 
 func Load(ls *persist.LoadSaver) *Base {
@@ -143,9 +159,54 @@ func Load(ls *persist.LoadSaver) *Base {
 		bids:       loadIndexes(ls),
 		rids:       loadIndexes(ls),
 		tids:       loadIndexes(ls),
+		eids: func() *indexes {
+			if ls.Has(persist.FormatEBML) {
+				return loadIndexes(ls)
+			}
+			return &indexes{}
+		}(),
+		sids: func() *indexes {
+			if ls.Has(persist.FormatSQLite) {
+				return loadIndexes(ls)
+			}
+			return &indexes{}
+		}(),
+		fids: func() *indexes {
+			if ls.Has(persist.FormatTIFF) {
+				return loadIndexes(ls)
+			}
+			return &indexes{}
+		}(),
+		oids: func() *indexes {
+			if ls.Has(persist.FormatFont) {
+				return loadIndexes(ls)
+			}
+			return &indexes{}
+		}(),
 	}
 }
 
+// MarshalJSON encodes the persisted fields of a Base identifier for export.
+func (b *Base) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name       string   `json:"name"`
+		Details    string   `json:"details"`
+		Multi      string   `json:"multi"`
+		ZipDefault bool     `json:"zipDefault"`
+		Globs      *indexes `json:"namematcher"`
+		MIMEs      *indexes `json:"mimematcher"`
+		Containers *indexes `json:"containermatcher"`
+		XMLs       *indexes `json:"xmlmatcher"`
+		Bytes      *indexes `json:"bytematcher"`
+		RIFFs      *indexes `json:"riffmatcher"`
+		Texts      *indexes `json:"textmatcher"`
+		EBMLs      *indexes `json:"ebmlmatcher"`
+		SQLites    *indexes `json:"sqlitematcher"`
+		TIFFs      *indexes `json:"tiffmatcher"`
+		Fonts      *indexes `json:"fontmatcher"`
+	}{b.name, b.details, b.multi.String(), b.zipDefault, b.gids, b.mids, b.cids, b.xids, b.bids, b.rids, b.tids, b.eids, b.sids, b.fids, b.oids})
+}
+
 func (b *Base) Name() string {
 	return b.name
 }
@@ -162,6 +223,10 @@ func (b *Base) String() string {
 	str += fmt.Sprintf("Number of XML signatures: %d \n", len(b.xids.ids))
 	str += fmt.Sprintf("Number of byte signatures: %d \n", len(b.bids.ids))
 	str += fmt.Sprintf("Number of RIFF signatures: %d \n", len(b.rids.ids))
+	str += fmt.Sprintf("Number of EBML signatures: %d \n", len(b.eids.ids))
+	str += fmt.Sprintf("Number of SQLite signatures: %d \n", len(b.sids.ids))
+	str += fmt.Sprintf("Number of TIFF signatures: %d \n", len(b.fids.ids))
+	str += fmt.Sprintf("Number of font signatures: %d \n", len(b.oids.ids))
 	str += fmt.Sprintf("Number of text signatures: %d \n", len(b.tids.ids))
 	return str
 }
@@ -222,10 +287,14 @@ func implicit(sigs []frames.Signature, ids []string, e error) priority.Map {
 }
 
 func (b *Base) NoPriority() bool {
-	return b.multi >= config.Comprehensive
+	return b.Multi() >= config.Comprehensive
 }
 
+// Multi returns how the identifier reports multiple results: the multi setting it was built with, unless overridden at runtime.
 func (b *Base) Multi() config.Multi {
+	if m, ok := config.Resolve(); ok {
+		return m
+	}
 	return b.multi
 }
 
@@ -249,6 +318,14 @@ func (b *Base) Hit(m core.MatcherType, idx int) (bool, string) {
 		return b.bids.hit(idx)
 	case core.RIFFMatcher:
 		return b.rids.hit(idx)
+	case core.EBMLMatcher:
+		return b.eids.hit(idx)
+	case core.SQLiteMatcher:
+		return b.sids.hit(idx)
+	case core.TIFFMatcher:
+		return b.fids.hit(idx)
+	case core.FontMatcher:
+		return b.oids.hit(idx)
 	case core.TextMatcher:
 		return b.tids.first(idx) // textmatcher is unique as only returns a single hit per identifier
 	}
@@ -270,6 +347,14 @@ func (b *Base) Place(m core.MatcherType, idx int) (int, int) {
 		return b.bids.place(idx)
 	case core.RIFFMatcher:
 		return b.rids.place(idx)
+	case core.EBMLMatcher:
+		return b.eids.place(idx)
+	case core.SQLiteMatcher:
+		return b.sids.place(idx)
+	case core.TIFFMatcher:
+		return b.fids.place(idx)
+	case core.FontMatcher:
+		return b.oids.place(idx)
 	case core.TextMatcher:
 		return b.tids.place(idx)
 	}
@@ -291,6 +376,14 @@ func (b *Base) Lookup(m core.MatcherType, keys []string) []int {
 		return b.bids.find(keys)
 	case core.RIFFMatcher:
 		return b.rids.find(keys)
+	case core.EBMLMatcher:
+		return b.eids.find(keys)
+	case core.SQLiteMatcher:
+		return b.sids.find(keys)
+	case core.TIFFMatcher:
+		return b.fids.find(keys)
+	case core.FontMatcher:
+		return b.oids.find(keys)
 	case core.TextMatcher:
 		return b.tids.find(keys)
 	}
@@ -373,6 +466,38 @@ func (b *Base) Add(m core.Matcher, t core.MatcherType) (core.Matcher, error) {
 			return nil, err
 		}
 		b.rids.start = l - len(b.rids.ids)
+	case core.EBMLMatcher:
+		var doctypes []string
+		doctypes, b.eids.ids = b.p.EBMLs()
+		m, l, err = ebmlmatcher.Add(m, ebmlmatcher.SignatureSet(doctypes), b.p.Priorities().List(b.eids.ids))
+		if err != nil {
+			return nil, err
+		}
+		b.eids.start = l - len(b.eids.ids)
+	case core.SQLiteMatcher:
+		var sigs []sqlitematcher.Signature
+		sigs, b.sids.ids = b.p.SQLites()
+		m, l, err = sqlitematcher.Add(m, sqlitematcher.SignatureSet(sigs), b.p.Priorities().List(b.sids.ids))
+		if err != nil {
+			return nil, err
+		}
+		b.sids.start = l - len(b.sids.ids)
+	case core.TIFFMatcher:
+		var sigs []tiffmatcher.Signature
+		sigs, b.fids.ids = b.p.TIFFs()
+		m, l, err = tiffmatcher.Add(m, tiffmatcher.SignatureSet(sigs), b.p.Priorities().List(b.fids.ids))
+		if err != nil {
+			return nil, err
+		}
+		b.fids.start = l - len(b.fids.ids)
+	case core.FontMatcher:
+		var sigs []fontmatcher.Signature
+		sigs, b.oids.ids = b.p.Fonts()
+		m, l, err = fontmatcher.Add(m, fontmatcher.SignatureSet(sigs), b.p.Priorities().List(b.oids.ids))
+		if err != nil {
+			return nil, err
+		}
+		b.oids.start = l - len(b.oids.ids)
 	case core.TextMatcher:
 		b.tids.ids = b.p.Texts()
 		if len(b.tids.ids) > 0 {
@@ -399,6 +524,14 @@ func (b *Base) Active(m core.MatcherType) bool {
 		return len(b.bids.ids) > 0
 	case core.RIFFMatcher:
 		return len(b.rids.ids) > 0
+	case core.EBMLMatcher:
+		return len(b.eids.ids) > 0
+	case core.SQLiteMatcher:
+		return len(b.sids.ids) > 0
+	case core.TIFFMatcher:
+		return len(b.fids.ids) > 0
+	case core.FontMatcher:
+		return len(b.oids.ids) > 0
 	case core.TextMatcher:
 		return len(b.tids.ids) > 0
 	}
@@ -420,6 +553,14 @@ func (b *Base) Start(m core.MatcherType) int {
 		return b.bids.start
 	case core.RIFFMatcher:
 		return b.rids.start
+	case core.EBMLMatcher:
+		return b.eids.start
+	case core.SQLiteMatcher:
+		return b.sids.start
+	case core.TIFFMatcher:
+		return b.fids.start
+	case core.FontMatcher:
+		return b.oids.start
 	case core.TextMatcher:
 		return b.tids.start
 	}
@@ -441,6 +582,14 @@ func (b *Base) IDs(m core.MatcherType) []string {
 		return b.bids.ids
 	case core.RIFFMatcher:
 		return b.rids.ids
+	case core.EBMLMatcher:
+		return b.eids.ids
+	case core.SQLiteMatcher:
+		return b.sids.ids
+	case core.TIFFMatcher:
+		return b.fids.ids
+	case core.FontMatcher:
+		return b.oids.ids
 	case core.TextMatcher:
 		return b.tids.ids
 	}
@@ -448,6 +597,10 @@ func (b *Base) IDs(m core.MatcherType) []string {
 
 func (b *Base) HasSig(id string, ms ...core.MatcherType) bool {
 	for _, m := range ms {
+		// signatures for matchers skipped at runtime can't have ruled out a match
+		if (m == core.ByteMatcher && config.SkipByte()) || (m == core.ContainerMatcher && config.SkipContainer()) {
+			continue
+		}
 		for _, i := range b.IDs(m) {
 			if id == i {
 				return true
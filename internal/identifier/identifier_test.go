@@ -146,3 +146,21 @@ func TestSorted(t *testing.T) {
 		t.Errorf("Returned: %s expected: %s", ids, idsAfterSort)
 	}
 }
+
+// TestSortedFrequency tests that signatures are sorted by format frequency, when given, and that
+// the signatures of each format keep their order.
+func TestSortedFrequency(t *testing.T) {
+	s := sorted{testParseable{}, map[string]int{"fdd000002": 5, "fmt/2": 2}}
+	sigs, ids, err := s.Signatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectSigs := []frames.Signature{f2, f3, f5, f0, f1, f4, f6}
+	expectIDs := []string{"fdd000002", "fdd000002", "fmt/2", "application/x-elf", "fdd000001", "fmt/1", "text/x-go"}
+	if !reflect.DeepEqual(ids, expectIDs) {
+		t.Errorf("Returned: %s expected: %s", ids, expectIDs)
+	}
+	if !reflect.DeepEqual(sigs, expectSigs) {
+		t.Errorf("Returned: %+v expected: %+v", sigs, expectSigs)
+	}
+}
@@ -19,7 +19,11 @@ import (
 	"strings"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/fontmatcher"
+	"github.com/richardlehane/siegfried/internal/frequency"
 	"github.com/richardlehane/siegfried/internal/priority"
+	"github.com/richardlehane/siegfried/internal/sqlitematcher"
+	"github.com/richardlehane/siegfried/internal/tiffmatcher"
 	"github.com/richardlehane/siegfried/pkg/config"
 )
 
@@ -39,6 +43,10 @@ type Parseable interface {
 	Zips() ([][]string, [][]frames.Signature, []string, error)   // signature set and corresponding IDs for container matcher - Zip
 	MSCFBs() ([][]string, [][]frames.Signature, []string, error) // signature set and corresponding IDs for container matcher - MSCFB
 	RIFFs() ([][4]byte, []string)                                // signature set and corresponding IDs for riffmatcher
+	EBMLs() ([]string, []string)                                 // signature set (DocTypes) and corresponding IDs for ebmlmatcher
+	SQLites() ([]sqlitematcher.Signature, []string)              // signature set and corresponding IDs for sqlitematcher
+	TIFFs() ([]tiffmatcher.Signature, []string)                  // signature set and corresponding IDs for tiffmatcher
+	Fonts() ([]fontmatcher.Signature, []string)                  // signature set and corresponding IDs for fontmatcher
 	Texts() []string                                             // IDs for textmatcher
 	Priorities() priority.Map                                    // priority map
 }
@@ -61,6 +69,10 @@ func inspect(p Parseable, ids ...string) (string, error) {
 		zns, zbs, zids, _    = p.Zips()
 		msns, msbs, msids, _ = p.MSCFBs()
 		rs, rids             = p.RIFFs()
+		es, eids             = p.EBMLs()
+		ss, sids             = p.SQLites()
+		fs, fids             = p.TIFFs()
+		ofs, oids            = p.Fonts()
 		tids                 = p.Texts()
 		pm                   = p.Priorities()
 	)
@@ -124,6 +136,33 @@ func inspect(p Parseable, ids ...string) (string, error) {
 		}
 		return ret
 	}
+	getQ := func(ss []string, qs []sqlitematcher.Signature, s string) []string {
+		ret := make([]string, 0, len(ss))
+		for i, v := range ss {
+			if s == v {
+				ret = append(ret, qs[i].String())
+			}
+		}
+		return ret
+	}
+	getF := func(ss []string, fs []tiffmatcher.Signature, s string) []string {
+		ret := make([]string, 0, len(ss))
+		for i, v := range ss {
+			if s == v {
+				ret = append(ret, fs[i].String())
+			}
+		}
+		return ret
+	}
+	getO := func(ss []string, ofs []fontmatcher.Signature, s string) []string {
+		ret := make([]string, 0, len(ss))
+		for i, v := range ss {
+			if s == v {
+				ret = append(ret, ofs[i].String())
+			}
+		}
+		return ret
+	}
 	for _, id := range ids {
 		lines := make([]string, 0, 10)
 		info, ok := p.Infos()[id]
@@ -154,6 +193,18 @@ func inspect(p Parseable, ids ...string) (string, error) {
 			if has(rids, id) {
 				lines = append(lines, "riffs: "+strings.Join(getR(rids, rs, id), ", "))
 			}
+			if has(eids, id) {
+				lines = append(lines, "ebml doctypes: "+strings.Join(get(eids, es, id), ", "))
+			}
+			if has(sids, id) {
+				lines = append(lines, "sqlite sigs: "+strings.Join(getQ(sids, ss, id), "\n             "))
+			}
+			if has(fids, id) {
+				lines = append(lines, "tiff sigs: "+strings.Join(getF(fids, fs, id), "\n           "))
+			}
+			if has(oids, id) {
+				lines = append(lines, "font sigs: "+strings.Join(getO(oids, ofs, id), "\n           "))
+			}
 			if has(tids, id) {
 				lines = append(lines, "text signature")
 			}
@@ -187,6 +238,10 @@ func (b Blank) Signatures() ([]frames.Signature, []string, error)           { re
 func (b Blank) Zips() ([][]string, [][]frames.Signature, []string, error)   { return nil, nil, nil, nil }
 func (b Blank) MSCFBs() ([][]string, [][]frames.Signature, []string, error) { return nil, nil, nil, nil }
 func (b Blank) RIFFs() ([][4]byte, []string)                                { return nil, nil }
+func (b Blank) EBMLs() ([]string, []string)                                 { return nil, nil }
+func (b Blank) SQLites() ([]sqlitematcher.Signature, []string)              { return nil, nil }
+func (b Blank) TIFFs() ([]tiffmatcher.Signature, []string)                  { return nil, nil }
+func (b Blank) Fonts() ([]fontmatcher.Signature, []string)                  { return nil, nil }
 func (b Blank) Texts() []string                                             { return nil }
 func (b Blank) Priorities() priority.Map                                    { return nil }
 
@@ -304,6 +359,30 @@ func (j joint) RIFFs() ([][4]byte, []string) {
 	return append(a, c...), append(b, d...)
 }
 
+func (j joint) EBMLs() ([]string, []string) {
+	a, b := j.a.EBMLs()
+	c, d := j.b.EBMLs()
+	return append(a, c...), append(b, d...)
+}
+
+func (j joint) SQLites() ([]sqlitematcher.Signature, []string) {
+	a, b := j.a.SQLites()
+	c, d := j.b.SQLites()
+	return append(a, c...), append(b, d...)
+}
+
+func (j joint) TIFFs() ([]tiffmatcher.Signature, []string) {
+	a, b := j.a.TIFFs()
+	c, d := j.b.TIFFs()
+	return append(a, c...), append(b, d...)
+}
+
+func (j joint) Fonts() ([]fontmatcher.Signature, []string) {
+	a, b := j.a.Fonts()
+	c, d := j.b.Fonts()
+	return append(a, c...), append(b, d...)
+}
+
 func (j joint) Texts() []string {
 	txts := make([]string, len(j.a.Texts()), len(j.a.Texts())+len(j.b.Texts()))
 	copy(txts, j.a.Texts())
@@ -461,6 +540,62 @@ func (f filtered) RIFFs() ([][4]byte, []string) {
 	return ret, retp
 }
 
+func (f filtered) EBMLs() ([]string, []string) {
+	ret, retp := make([]string, 0, len(f.IDs())), make([]string, 0, len(f.IDs()))
+	e, p := f.p.EBMLs()
+	for i, v := range p {
+		for _, w := range f.IDs() {
+			if v == w {
+				ret, retp = append(ret, e[i]), append(retp, v)
+				break
+			}
+		}
+	}
+	return ret, retp
+}
+
+func (f filtered) SQLites() ([]sqlitematcher.Signature, []string) {
+	ret, retp := make([]sqlitematcher.Signature, 0, len(f.IDs())), make([]string, 0, len(f.IDs()))
+	s, p := f.p.SQLites()
+	for i, v := range p {
+		for _, w := range f.IDs() {
+			if v == w {
+				ret, retp = append(ret, s[i]), append(retp, v)
+				break
+			}
+		}
+	}
+	return ret, retp
+}
+
+func (f filtered) TIFFs() ([]tiffmatcher.Signature, []string) {
+	ret, retp := make([]tiffmatcher.Signature, 0, len(f.IDs())), make([]string, 0, len(f.IDs()))
+	s, p := f.p.TIFFs()
+	for i, v := range p {
+		for _, w := range f.IDs() {
+			if v == w {
+				ret, retp = append(ret, s[i]), append(retp, v)
+				break
+			}
+		}
+	}
+	return ret, retp
+}
+
+func (f filtered) Fonts() ([]fontmatcher.Signature, []string) {
+	ret, retp := make([]fontmatcher.Signature, 0, len(f.IDs())), make([]string, 0, len(f.IDs()))
+	s, p := f.p.Fonts()
+	for i, v := range p {
+		for _, w := range f.IDs() {
+			if v == w {
+				ret, retp = append(ret, s[i]), append(retp, v)
+				break
+			}
+		}
+	}
+	return ret, retp
+}
+
 func (f filtered) Texts() []string {
 	txts := make([]string, 0, len(f.p.Texts()))
 	for _, t := range f.p.Texts() {
@@ -533,6 +668,22 @@ type noRIFF struct{ Parseable }
 
 func (nr noRIFF) RIFFs() ([][4]byte, []string) { return nil, nil }
 
+type noEBML struct{ Parseable }
+
+func (ne noEBML) EBMLs() ([]string, []string) { return nil, nil }
+
+type noSQLite struct{ Parseable }
+
+func (ns noSQLite) SQLites() ([]sqlitematcher.Signature, []string) { return nil, nil }
+
+type noTIFF struct{ Parseable }
+
+func (nt noTIFF) TIFFs() ([]tiffmatcher.Signature, []string) { return nil, nil }
+
+type noFont struct{ Parseable }
+
+func (nf noFont) Fonts() ([]fontmatcher.Signature, []string) { return nil, nil }
+
 type noText struct{ Parseable }
 
 func (nt noText) Texts() []string { return nil }
@@ -543,37 +694,27 @@ func (np noPriority) Priorities() priority.Map { return nil }
 
 // sorted sorts signatures by their index so that runs of signatures
 // e.g. fmt/1, fmt/1, fmt/2, fmt/1 can be properly placed.
-type sorted struct{ Parseable }
+// If format frequencies are given, the signatures of the most common formats are sorted first.
+type sorted struct {
+	Parseable
+	freqs frequency.Counts
+}
 
 func (s sorted) Signatures() ([]frames.Signature, []string, error) {
 	sigs, ids, err := s.Parseable.Signatures()
 	if err != nil {
 		return sigs, ids, err
 	}
+	order := make([]int, len(ids))
+	for i := range order {
+		order[i] = i
+	}
+	// stable, so that the signatures of a format keep their order
+	sort.SliceStable(order, func(i, j int) bool { return s.freqs.Less(ids[order[i]], ids[order[j]]) })
 	retSigs := make([]frames.Signature, len(sigs))
 	retIds := make([]string, len(ids))
-	copy(retIds, ids)
-	sort.Strings(retIds)
-	var last string
-	var nth int
-	for i, this := range retIds {
-		if this == last {
-			nth++
-		} else {
-			nth = 0
-			last = this
-		}
-		var cursor int
-		for j, str := range ids {
-			if this != str {
-				continue
-			}
-			if cursor == nth {
-				retSigs[i] = sigs[j]
-				break
-			}
-			cursor++
-		}
+	for i, j := range order {
+		retSigs[i], retIds[i] = sigs[j], ids[j]
 	}
 	return retSigs, retIds, nil
 }
@@ -597,6 +738,18 @@ func ApplyConfig(p Parseable) Parseable {
 	if config.NoRIFF() {
 		p = noRIFF{p}
 	}
+	if config.NoEBML() {
+		p = noEBML{p}
+	}
+	if config.NoSQLite() {
+		p = noSQLite{p}
+	}
+	if config.NoTIFF() {
+		p = noTIFF{p}
+	}
+	if config.NoFont() {
+		p = noFont{p}
+	}
 	if config.NoText() {
 		p = noText{p}
 	}
@@ -617,6 +770,6 @@ func ApplyConfig(p Parseable) Parseable {
 		p = Filter(ids, p)
 	}
 	// Sort Parseable so runs of signatures are contiguous.
-	p = sorted{p}
+	p = sorted{p, config.Frequency()}
 	return p
 }
@@ -0,0 +1,196 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metadata attaches extra per-format metadata (e.g. risk level, preservation action, local classification)
+// to the results of an identifier. Metadata sets are read from CSV files keyed on format ID (e.g. PUID) and
+// are persisted in signature files so that policy data travels with identification.
+package metadata
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+)
+
+// MIMEField is the name of a metadata field that supplements, rather than adds to, the results of an identifier:
+// its values are MIME types for formats that the identifier has no MIME type for.
+const MIMEField = "mime"
+
+// Set is a set of metadata fields with values for a number of format IDs.
+type Set struct {
+	fields []string
+	values map[string][]string
+	mimes  map[string]string // values of the MIMEField, which isn't included in fields
+}
+
+// Open reads a metadata set from a CSV file.
+func Open(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read reads a metadata set from CSV. The first row gives the field names.
+// The first column contains format IDs (e.g. PUIDs) and the remaining columns contain values for each field.
+func Read(r io.Reader) (*Set, error) {
+	rdr := csv.NewReader(r)
+	rdr.TrimLeadingSpace = true
+	rdr.Comment = '#'
+	recs, err := rdr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("metadata: error reading CSV, got %v", err)
+	}
+	if len(recs) == 0 || len(recs[0]) < 2 {
+		return nil, fmt.Errorf("metadata: expecting a header row with an ID column and at least one field")
+	}
+	s := &Set{
+		fields: make([]string, len(recs[0])-1),
+		values: make(map[string][]string, len(recs)-1),
+	}
+	for i, v := range recs[0][1:] {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil, fmt.Errorf("metadata: empty field name in column %d", i+2)
+		}
+		s.fields[i] = v
+	}
+	for _, rec := range recs[1:] {
+		id := strings.TrimSpace(rec[0])
+		if _, ok := s.values[id]; ok {
+			return nil, fmt.Errorf("metadata: duplicate entry for %s", id)
+		}
+		s.values[id] = rec[1:]
+	}
+	return s.splitMIME(), nil
+}
+
+// splitMIME moves the values of the MIMEField, if there is one, from the fields to the mimes of the set
+func (s *Set) splitMIME() *Set {
+	idx := -1
+	for i, f := range s.fields {
+		if f == MIMEField {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return s
+	}
+	s.fields = append(s.fields[:idx:idx], s.fields[idx+1:]...)
+	s.mimes = make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		if v[idx] != "" {
+			s.mimes[k] = v[idx]
+		}
+		s.values[k] = append(v[:idx:idx], v[idx+1:]...)
+	}
+	return s
+}
+
+// Add adds a field, with values for a number of format IDs, to a set. If the set is nil, a new set is made.
+func Add(s *Set, field string, values map[string]string) (*Set, error) {
+	if s == nil {
+		s = &Set{values: make(map[string][]string, len(values))}
+	}
+	for _, f := range s.fields {
+		if f == field {
+			return nil, fmt.Errorf("metadata: duplicate field %s", field)
+		}
+	}
+	s.fields = append(s.fields, field)
+	for id, v := range s.values {
+		s.values[id] = append(v, values[id])
+	}
+	for id, v := range values {
+		if _, ok := s.values[id]; !ok {
+			vals := make([]string, len(s.fields))
+			vals[len(vals)-1] = v
+			s.values[id] = vals
+		}
+	}
+	return s, nil
+}
+
+// Fields returns the names of the metadata fields.
+func (s *Set) Fields() []string {
+	return s.fields
+}
+
+// Values returns the metadata values for a format ID. Empty strings are returned for IDs without metadata.
+func (s *Set) Values(id string) []string {
+	if v, ok := s.values[id]; ok {
+		return v
+	}
+	return make([]string, len(s.fields))
+}
+
+// MIME returns the MIME type given for a format ID by the MIMEField, or an empty string.
+func (s *Set) MIME(id string) string {
+	return s.mimes[id]
+}
+
+func (s *Set) String() string {
+	return fmt.Sprintf("Metadata fields: %s (%d formats)\n", strings.Join(s.fields, ", "), len(s.values))
+}
+
+// MarshalJSON encodes a metadata set for export.
+func (s *Set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Fields []string            `json:"fields"`
+		Values map[string][]string `json:"values"`
+		MIMEs  map[string]string   `json:"mimes,omitempty"`
+	}{s.fields, s.values, s.mimes})
+}
+
+// Save persists the set. The MIMEField is saved as the last field, so Load can split it out again.
+func (s *Set) Save(ls *persist.LoadSaver) {
+	fields := s.fields
+	if s.mimes != nil {
+		fields = append(fields[:len(fields):len(fields)], MIMEField)
+	}
+	ls.SaveStrings(fields)
+	ls.SaveSmallInt(len(s.values))
+	for k, v := range s.values {
+		ls.SaveString(k)
+		for _, w := range v {
+			ls.SaveString(w)
+		}
+		if s.mimes != nil {
+			ls.SaveString(s.mimes[k])
+		}
+	}
+}
+
+func Load(ls *persist.LoadSaver) *Set {
+	s := &Set{fields: ls.LoadStrings()}
+	le := ls.LoadSmallInt()
+	s.values = make(map[string][]string, le)
+	for i := 0; i < le; i++ {
+		k := ls.LoadString()
+		v := make([]string, len(s.fields))
+		for j := range v {
+			v[j] = ls.LoadString()
+		}
+		s.values[k] = v
+	}
+	return s.splitMIME()
+}
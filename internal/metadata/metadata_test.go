@@ -0,0 +1,92 @@
+package metadata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+)
+
+var testCSV = `puid,risk,action
+# comments are skipped
+fmt/14,low,retain
+x-fmt/111,"medium, watch",migrate
+`
+
+func TestRead(t *testing.T) {
+	s, err := Read(strings.NewReader(testCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Fields()) != 2 || s.Fields()[0] != "risk" || s.Fields()[1] != "action" {
+		t.Errorf("bad fields, got %v", s.Fields())
+	}
+	if v := s.Values("x-fmt/111"); v[0] != "medium, watch" || v[1] != "migrate" {
+		t.Errorf("bad values, got %v", v)
+	}
+	if v := s.Values("fmt/1"); len(v) != 2 || v[0] != "" {
+		t.Errorf("expecting empty values for a format without metadata, got %v", v)
+	}
+	if _, err = Read(strings.NewReader("puid\nfmt/1\n")); err == nil {
+		t.Error("expecting an error for CSV without any fields")
+	}
+}
+
+func TestSave(t *testing.T) {
+	s, _ := Read(strings.NewReader(testCSV))
+	saver := persist.NewLoadSaver(nil)
+	s.Save(saver)
+	loader := persist.NewLoadSaver(saver.Bytes())
+	l := Load(loader)
+	if loader.Err != nil {
+		t.Fatal(loader.Err)
+	}
+	if loader.More() {
+		t.Error("expecting all data to be loaded")
+	}
+	if l.String() != s.String() || l.Values("fmt/14")[1] != "retain" {
+		t.Errorf("save/load mismatch, got %s", l)
+	}
+}
+
+func TestMIME(t *testing.T) {
+	s, err := Read(strings.NewReader("puid,mime,risk\nfmt/1,audio/x-wav,low\nfmt/2,,high\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Fields()) != 1 || s.Fields()[0] != "risk" || s.Values("fmt/2")[0] != "high" {
+		t.Errorf("expecting the mime field to be split out, got %v and %v", s.Fields(), s.Values("fmt/2"))
+	}
+	saver := persist.NewLoadSaver(nil)
+	s.Save(saver)
+	l := Load(persist.NewLoadSaver(saver.Bytes()))
+	if l.MIME("fmt/1") != "audio/x-wav" || l.MIME("fmt/2") != "" || l.Values("fmt/1")[0] != "low" {
+		t.Errorf("save/load mismatch, got %s, %v", l.MIME("fmt/1"), l.Values("fmt/1"))
+	}
+}
+
+func TestAdd(t *testing.T) {
+	s, err := Add(nil, "class", map[string]string{"fmt/11": "Image (Raster)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Fields()) != 1 || s.Values("fmt/11")[0] != "Image (Raster)" {
+		t.Errorf("bad new set, got %v and %v", s.Fields(), s.Values("fmt/11"))
+	}
+	s, _ = Read(strings.NewReader(testCSV))
+	if s, err = Add(s, "class", map[string]string{"fmt/11": "Image (Raster)", "fmt/14": "Page Description"}); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.Values("fmt/14"); len(v) != 3 || v[0] != "low" || v[2] != "Page Description" {
+		t.Errorf("bad values for an existing format, got %v", v)
+	}
+	if v := s.Values("fmt/11"); len(v) != 3 || v[0] != "" || v[2] != "Image (Raster)" {
+		t.Errorf("bad values for a new format, got %v", v)
+	}
+	if v := s.Values("x-fmt/111"); len(v) != 3 || v[2] != "" {
+		t.Errorf("bad values for a format without a class, got %v", v)
+	}
+	if _, err = Add(s, "risk", nil); err == nil {
+		t.Error("expecting an error for a duplicate field")
+	}
+}
@@ -79,7 +79,7 @@ func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, i
 	}
 	sigs, ok := ss.(SignatureSet)
 	if !ok {
-		return nil, -1, fmt.Errorf("MIMEmatcher: bad signature set")
+		return nil, -1, fmt.Errorf("MIMEmatcher: %w", core.ErrSignatureSet)
 	}
 	var length int
 	// unless it is a new matcher, calculate current length by iterating through all the result values
@@ -17,6 +17,7 @@ package namematcher
 // todo: add a precise map[string][]int to take out bulk of globs which are exact names e.g. README
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"path/filepath"
@@ -87,6 +88,15 @@ func Save(c core.Matcher, ls *persist.LoadSaver) {
 	}
 }
 
+// MarshalJSON encodes a Matcher for export.
+func (m *Matcher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Extensions map[string][]int `json:"extensions"`
+		Globs      []string         `json:"globs"`
+		GlobIdx    [][]int          `json:"globIdx"`
+	}{m.extensions, m.globs, m.globIdx})
+}
+
 type SignatureSet []string
 
 func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, int, error) {
@@ -98,7 +108,7 @@ func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, i
 	}
 	sigs, ok := ss.(SignatureSet)
 	if !ok {
-		return nil, -1, fmt.Errorf("Namematcher: can't cast persist set")
+		return nil, -1, fmt.Errorf("Namematcher: %w", core.ErrSignatureSet)
 	}
 	var length int
 	// unless it is a new matcher, calculate current length by iterating through all the result values
@@ -188,18 +198,22 @@ func (m *Matcher) Identify(s string, na *siegreader.Buffer, hints ...core.Hint)
 		}
 	}
 	res := make(chan core.Result, len(efmts)+len(gfmts))
+	// results are sent as pointers into a single slice, so that common extensions with many formats need few allocations
+	rs := make([]result, 0, len(efmts)+len(gfmts))
 	for _, fmt := range efmts {
-		res <- result{
+		rs = append(rs, result{
 			idx:     fmt,
 			matches: ext,
-		}
+		})
+		res <- &rs[len(rs)-1]
 	}
 	for _, fmt := range gfmts {
-		res <- result{
+		rs = append(rs, result{
 			glob:    true,
 			idx:     fmt,
 			matches: glob,
-		}
+		})
+		res <- &rs[len(rs)-1]
 	}
 	close(res)
 	return res, nil
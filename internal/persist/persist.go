@@ -21,10 +21,22 @@ import (
 	"time"
 )
 
+// Signature file formats that change the content persisted by the matchers (see siegfried.SignatureFormat).
+const (
+	FormatAutomata = 3 // byte matchers persist their compiled Aho-Corasick trees
+	FormatSections = 4 // matchers are saved as sections that can be loaded on demand
+	FormatEBML     = 5 // signature files include an EBML matcher
+	FormatSQLite   = 6 // signature files include a SQLite matcher
+	FormatTIFF     = 7 // signature files include a TIFF matcher
+	FormatFont     = 8 // signature files include a font matcher
+)
+
 type LoadSaver struct {
 	buf []byte
 	i   int
 	Err error
+	// Format is the format of the signature file being loaded. Zero means the current format.
+	Format int
 }
 
 func NewLoadSaver(b []byte) *LoadSaver {
@@ -32,16 +44,24 @@ func NewLoadSaver(b []byte) *LoadSaver {
 		b = make([]byte, 16)
 	}
 	return &LoadSaver{
-		b,
-		0,
-		nil,
+		buf: b,
 	}
 }
 
+// Has reports whether the signature file being loaded is at least the given format.
+func (l *LoadSaver) Has(format int) bool {
+	return l.Format == 0 || l.Format >= format
+}
+
 func (l *LoadSaver) Bytes() []byte {
 	return l.buf[:l.i]
 }
 
+// More reports whether there is any unread data. It allows optional sections to be appended to signature files.
+func (l *LoadSaver) More() bool {
+	return l.Err == nil && l.i < len(l.buf)
+}
+
 func (l *LoadSaver) get(i int) []byte {
 	if l.Err != nil || i == 0 {
 		return nil
@@ -423,6 +443,23 @@ func (l *LoadSaver) SaveBytes(b []byte) {
 	l.putCollection(b)
 }
 
+// LoadSection returns the content of a section saved with SaveSection, without copying it.
+func (l *LoadSaver) LoadSection() []byte {
+	le := l.LoadInt()
+	if le < 0 {
+		l.Err = errors.New("error loading signature file, bad section")
+		return nil
+	}
+	return l.get(le)
+}
+
+// SaveSection saves a block of content prefixed with its length, so that it can be loaded later with its own LoadSaver.
+// Unlike SaveBytes, the content can be larger than 32KB.
+func (l *LoadSaver) SaveSection(b []byte) {
+	l.SaveInt(len(b))
+	l.put(b)
+}
+
 func (l *LoadSaver) LoadString() string {
 	return string(l.getCollection())
 }
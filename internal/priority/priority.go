@@ -18,6 +18,7 @@
 package priority
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"sync"
@@ -257,6 +258,15 @@ func (s *Set) Save(ls *persist.LoadSaver) {
 	}
 }
 
+// MarshalJSON encodes a priority set for export.
+func (s *Set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Idx        []int    `json:"idx"`
+		Lists      []List   `json:"lists"`
+		MaxOffsets [][2]int `json:"maxOffsets"`
+	}{s.idx, s.lists, s.maxOffsets})
+}
+
 func Load(ls *persist.LoadSaver) *Set {
 	set := &Set{}
 	set.idx = ls.LoadInts()
@@ -15,6 +15,7 @@
 package riffmatcher
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -75,10 +76,22 @@ func Save(c core.Matcher, ls *persist.LoadSaver) {
 
 type SignatureSet [][4]byte
 
+// MarshalJSON encodes a Matcher for export.
+func (m *Matcher) MarshalJSON() ([]byte, error) {
+	riffs := make(map[string][]int, len(m.riffs))
+	for k, v := range m.riffs {
+		riffs[string(k[:])] = v
+	}
+	return json.Marshal(struct {
+		RIFFs      map[string][]int `json:"riffs"`
+		Priorities *priority.Set    `json:"priorities"`
+	}{riffs, m.priorities})
+}
+
 func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, int, error) {
 	sigs, ok := ss.(SignatureSet)
 	if !ok {
-		return nil, -1, fmt.Errorf("RIFFmatcher: can't cast persist set")
+		return nil, -1, fmt.Errorf("RIFFmatcher: %w", core.ErrSignatureSet)
 	}
 	if len(sigs) == 0 {
 		return c, 0, nil
@@ -146,7 +159,7 @@ func (m Matcher) Identify(na string, b *siegreader.Buffer, hints ...core.Hint) (
 	}
 	// now make structures for testing
 	uniqs := make(map[riff.FourCC]bool)
-	res := make(chan core.Result)
+	var hits []core.Result
 	waitset := m.priorities.WaitSet(hints...)
 	// send and report if satisified
 	send := func(cc riff.FourCC) bool {
@@ -162,7 +175,7 @@ func (m Matcher) Identify(na string, b *siegreader.Buffer, hints ...core.Hint) (
 				if config.Debug() {
 					fmt.Fprintf(config.Out(), "sending riff match %s\n", string(cc[:]))
 				}
-				res <- result{hit, cc}
+				hits = append(hits, result{hit, cc})
 				if waitset.Put(hit) {
 					return true
 				}
@@ -189,15 +202,15 @@ func (m Matcher) Identify(na string, b *siegreader.Buffer, hints ...core.Hint) (
 			}
 		}
 	}
-	// go time
-	go func() {
-		if send(rcc) {
-			close(res)
-			return
-		}
+	// walk the chunks and then send the hits
+	if !send(rcc) {
 		descend(rrdr)
-		close(res)
-	}()
+	}
+	res := make(chan core.Result, len(hits))
+	for _, h := range hits {
+		res <- h
+	}
+	close(res)
 	return res, nil
 }
 
@@ -0,0 +1,107 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siegreader
+
+import (
+	"context"
+	"sync"
+)
+
+// budget limits the memory held by the Buffers of a pool that are in use.
+// It covers the memory that grows with the size of sources: the growth of stream buffers and small files read into memory.
+// Reservations never block (so nested Buffers, e.g. for the contents of archives, can't deadlock): when a reservation
+// fails, streams spill to temp files and small files are read through a fixed-size buffer instead.
+// Callers apply backpressure by waiting for memory before starting new identifications.
+// A nil budget is unlimited.
+type budget struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	max   int64
+	used  int64
+	waits int64
+}
+
+func newBudget(max int64) *budget {
+	if max <= 0 {
+		return nil
+	}
+	b := &budget{max: max}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// try reserves n bytes if they fit in the budget
+func (b *budget) try(n int64) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used+n > b.max {
+		return false
+	}
+	b.used += n
+	return true
+}
+
+func (b *budget) release(n int64) {
+	if b == nil || n == 0 {
+		return
+	}
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// wait blocks until memory in use is under the budget, or the context is done
+func (b *budget) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used < b.max {
+		return nil
+	}
+	b.waits++
+	if ctx.Done() != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				b.mu.Lock()
+				b.cond.Broadcast()
+				b.mu.Unlock()
+			case <-stop:
+			}
+		}()
+	}
+	for b.used >= b.max && ctx.Err() == nil {
+		b.cond.Wait()
+	}
+	return ctx.Err()
+}
+
+func (b *budget) stats(st *Stats) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	st.Memory = b.used
+	st.Waits = b.waits
+	b.mu.Unlock()
+}
@@ -15,36 +15,108 @@
 package siegreader
 
 import (
+	"context"
 	"io"
 	"os"
+	"sync/atomic"
 )
 
-// Buffers is a combined pool of stream, external and file buffers
+// Buffers is a combined pool of stream, external, memory and file buffers
 type Buffers struct {
+	spills int64 // streams spilled to temp files (first for 64-bit alignment of atomic access)
+
 	spool *pool // Pool of stream Buffers
 	fpool *pool // Pool of file Buffers
 	epool *pool // Pool of external buffers
+	mpool *pool // Pool of memory buffers
 
 	fdatas *datas // file datas
+	limits Limits
 }
 
-// New creates a new pool of stream, external and file buffers
+// New creates a new pool of stream, external, memory and file buffers
 func New() *Buffers {
 	return &Buffers{
 		spool: newPool(newStream),
 		fpool: newPool(newFile),
 		epool: newPool(newExternal),
+		mpool: newPool(newMemory),
 		fdatas: &datas{
 			newPool(newBigFile),
 			newPool(newSmallFile),
 			newPool(newMmap),
+			int64(smallFileSz),
+			0,
+			false,
+			nil,
 		},
+		limits: Limits{SmallFile: int64(smallFileSz), Stream: streamSz},
+	}
+}
+
+// SetLimits sets the limits for Buffers returned by the pool. It should be called before the pool is used.
+func (b *Buffers) SetLimits(l Limits) {
+	if l.SmallFile <= 0 {
+		l.SmallFile = int64(smallFileSz)
+	}
+	if l.Stream <= 0 {
+		l.Stream = streamSz
+	}
+	if l.StreamSlot > 0 {
+		slot := l.StreamSlot
+		if slot < readSz*2 {
+			slot = readSz * 2 // a stream needs room for its initial read
+		}
+		b.spool.mu.Lock()
+		b.spool.fn = func() interface{} { return newStreamSlot(slot) }
+		b.spool.mu.Unlock()
+	}
+	for _, p := range b.pools() {
+		p.setMax(l.PoolSize)
 	}
+	b.fdatas.budget = newBudget(l.Memory)
+	b.limits = l
+	b.fdatas.smallFileSz = l.SmallFile
+	b.fdatas.mmapSz = l.Mmap
+	b.fdatas.noMmap = l.NoMmap
+}
+
+func (b *Buffers) pools() []*pool {
+	return []*pool{b.spool, b.fpool, b.epool, b.mpool, b.fdatas.bfpool, b.fdatas.sfpool, b.fdatas.mpool}
+}
+
+// Stats reports the use of the pool. Hits and misses count the source buffers (streams, files etc.) as well as
+// the data buffers (memory maps, small and big files) used by files.
+func (b *Buffers) Stats() Stats {
+	st := Stats{Spills: atomic.LoadInt64(&b.spills)}
+	for _, p := range b.pools() {
+		p.stats(&st)
+	}
+	b.fdatas.budget.stats(&st)
+	return st
+}
+
+// Wait blocks until the memory used by Buffers in use is under the Memory limit, or the context is done.
+// Call it before getting a Buffer for a new source to apply backpressure (e.g. to a directory walk) when
+// memory is short. It returns immediately if there is no Memory limit.
+func (b *Buffers) Wait(ctx context.Context) error {
+	return b.fdatas.budget.wait(ctx)
+}
+
+// Limits reports the limits for Buffers returned by the pool.
+func (b *Buffers) Limits() Limits {
+	return b.limits
+}
+
+func (b *Buffers) buffer(src bufferSrc) *Buffer {
+	return &Buffer{bof: b.limits.BOF, eof: b.limits.EOF, bufferSrc: src}
 }
 
 // Get returns a Buffer reading from the provided io.Reader.
-// Get returns a Buffer backed by a stream, external or file
+// Get returns a Buffer backed by a stream, external, memory or file
 // source buffer depending on the type of reader.
+// The contents of a *bytes.Buffer or *bytes.Reader are read in place rather than copied:
+// a *bytes.Buffer mustn't be modified until the Buffer is Put.
 // Source buffers are re-cycled where possible.
 func (b *Buffers) Get(src io.Reader) (*Buffer, error) {
 	f, ok := src.(*os.File)
@@ -55,21 +127,52 @@ func (b *Buffers) Get(src io.Reader) (*Buffer, error) {
 		}
 	}
 	if !ok {
+		if buf, ok := inMemory(src); ok {
+			return b.GetBytes(buf)
+		}
 		e, ok := src.(source)
 		if !ok || !e.IsSlicer() {
 			stream := b.spool.get().(*stream)
-			buf := &Buffer{}
+			stream.max = b.limits.Stream
+			stream.spill = &b.spills
+			stream.budget = b.fdatas.budget
+			buf := b.buffer(stream)
 			err := stream.setSource(src, buf)
-			buf.bufferSrc = stream
 			return buf, err
 		}
 		ext := b.epool.get().(*external)
 		err := ext.setSource(e)
-		return &Buffer{bufferSrc: ext}, err
+		return b.buffer(ext), err
 	}
 	fbuf := b.fpool.get().(*file)
 	err := fbuf.setSource(f, b.fdatas)
-	return &Buffer{bufferSrc: fbuf}, err
+	return b.buffer(fbuf), err
+}
+
+// GetBytes returns a Buffer reading from a byte slice that is already in memory. The slice isn't copied and
+// mustn't be modified until the Buffer is Put.
+func (b *Buffers) GetBytes(buf []byte) (*Buffer, error) {
+	m := b.mpool.get().(*memory)
+	err := m.setSource(buf)
+	return b.buffer(m), err
+}
+
+// GetReaderAt returns a Buffer reading from the provided io.ReaderAt, which has size sz.
+// Unlike streams, these sources can be read at any offset: EOF segments are
+// scanned without first buffering the whole source.
+func (b *Buffers) GetReaderAt(src io.ReaderAt, sz int64) (*Buffer, error) {
+	fbuf := b.fpool.get().(*file)
+	var err error
+	if f, ok := src.(*os.File); ok {
+		err = fbuf.setReaderAt(f, sz, b.fdatas)
+	} else {
+		var name string
+		if n, ok := src.(interface{ Name() string }); ok {
+			name = n.Name()
+		}
+		err = fbuf.setReaderAt(readerAt{io.NewSectionReader(src, 0, sz), name}, sz, b.fdatas)
+	}
+	return b.buffer(fbuf), err
 }
 
 // Put returns a Buffer to the pool for re-cycling.
@@ -79,12 +182,16 @@ func (b *Buffers) Put(i *Buffer) {
 		panic("Siegreader: unknown buffer type")
 	case *stream:
 		v.cleanUp()
+		v.release()
 		b.spool.put(v)
 	case *file:
 		b.fdatas.put(v.data)
 		b.fpool.put(v)
 	case *external:
 		b.epool.put(v)
+	case *memory:
+		v.buf = nil
+		b.mpool.put(v)
 	}
 }
 
@@ -94,18 +201,29 @@ type datas struct {
 	bfpool *pool
 	sfpool *pool
 	mpool  *pool
+
+	smallFileSz int64
+	mmapSz      int64
+	noMmap      bool
+	budget      *budget
 }
 
+// get returns a memory map for local files, if they are large enough, and falls back to reading
+// files if the platform or file system can't map them.
 func (d *datas) get(f *file) data {
-	if mmapable(f.sz) {
+	if _, ok := f.src.(*os.File); ok && !d.noMmap && f.sz > 0 && f.sz >= d.mmapSz && mmapable(f.sz) {
 		m := d.mpool.get().(*mmap)
 		if err := m.setSource(f); err == nil {
 			return m
 		}
-		d.mpool.put(m) // replace on error and get big file instead
+		m.buf = nil
+		d.mpool.put(m) // replace on error and get a small or big file instead
 	}
-	if f.sz <= int64(smallFileSz) {
+	if f.sz <= d.smallFileSz && d.budget.try(f.sz) {
 		sf := d.sfpool.get().(*smallfile)
+		if d.budget != nil {
+			sf.reserved = f.sz
+		}
 		sf.setSource(f)
 		return sf
 	}
@@ -124,6 +242,8 @@ func (d *datas) put(i data) {
 	case *bigfile:
 		d.bfpool.put(v)
 	case *smallfile:
+		d.budget.release(v.reserved)
+		v.reserved = 0
 		d.sfpool.put(v)
 	case *mmap:
 		v.reset()
@@ -6,10 +6,25 @@ import (
 	"sync"
 )
 
+// fileSource is satisfied by *os.File. Other io.ReaderAt sources are wrapped by a readerAt to look like one.
+type fileSource interface {
+	io.Reader
+	io.ReaderAt
+	Name() string
+}
+
+// readerAt gives an io.ReaderAt (e.g. a file in an embed.FS) of known size the sequential reads and name of an *os.File.
+type readerAt struct {
+	*io.SectionReader
+	name string
+}
+
+func (r readerAt) Name() string { return r.name }
+
 type file struct {
 	peek [initialRead]byte
 	sz   int64
-	src  *os.File
+	src  fileSource
 	once *sync.Once
 	data
 
@@ -24,16 +39,20 @@ type data interface {
 }
 
 func (f *file) setSource(src *os.File, p *datas) error {
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	return f.setReaderAt(src, info.Size(), p)
+}
+
+func (f *file) setReaderAt(src fileSource, sz int64, p *datas) error {
 	// reset
 	f.once = &sync.Once{}
 	f.data = nil
 	f.pool = p
 	f.src = src
-	info, err := f.src.Stat()
-	if err != nil {
-		return err
-	}
-	f.sz = info.Size()
+	f.sz = sz
 	i, err := f.src.Read(f.peek[:])
 	if i < initialRead && (err == nil || err == io.EOF) {
 		if i == 0 {
@@ -0,0 +1,84 @@
+//go:build go1.18
+// +build go1.18
+
+package siegreader
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// check that a Buffer returns the same slices, and readers the same bytes, as the data it was made from
+func checkBuffer(t *testing.T, name string, b *Buffer, data []byte, off uint16, l uint8) {
+	if b.Stream() {
+		if _, err := b.CanSeek(0, true); err != nil && err != io.EOF { // fill the stream so that EofSlice won't block
+			t.Fatalf("%s: error filling stream: %v", name, err)
+		}
+	}
+	if sz := b.SizeNow(); sz != int64(len(data)) {
+		t.Fatalf("%s: expecting size %d, got %d", name, len(data), sz)
+	}
+	o, n := int(off), int(l)
+	if o+n <= len(data) {
+		slc, err := b.Slice(int64(o), n)
+		if err != nil && err != io.EOF {
+			t.Fatalf("%s: slice error at %d for %d: %v", name, o, n, err)
+		}
+		if !bytes.Equal(slc, data[o:o+n]) {
+			t.Fatalf("%s: bad slice at %d for %d: got %v, expecting %v", name, o, n, slc, data[o:o+n])
+		}
+		slc, err = b.EofSlice(int64(o), n)
+		if err != nil && err != io.EOF {
+			t.Fatalf("%s: EOF slice error at %d for %d: %v", name, o, n, err)
+		}
+		if want := data[len(data)-o-n : len(data)-o]; !bytes.Equal(slc, want) {
+			t.Fatalf("%s: bad EOF slice at %d for %d: got %v, expecting %v", name, o, n, slc, want)
+		}
+	} else {
+		b.Slice(int64(o), n)
+		b.EofSlice(int64(o), n)
+	}
+	got, err := ioutil.ReadAll(ReaderFrom(b))
+	if err != nil {
+		t.Fatalf("%s: read error: %v", name, err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("%s: reader got %d bytes, expecting %d", name, len(got), len(data))
+	}
+	rrdr := ReverseReaderFrom(b)
+	for i := len(data) - 1; i >= 0; i-- {
+		c, err := rrdr.ReadByte()
+		if err != nil {
+			t.Fatalf("%s: reverse reader error at %d: %v", name, i, err)
+		}
+		if c != data[i] {
+			t.Fatalf("%s: reverse reader got %x at %d, expecting %x", name, c, i, data[i])
+		}
+	}
+}
+
+func FuzzBuffer(f *testing.F) {
+	f.Add(testBytes, uint16(10), uint8(5))
+	f.Add(bytes.Repeat(testBytes, 200), uint16(readSz-4), uint8(10)) // slices that cross the first read of a stream
+	f.Fuzz(func(t *testing.T, data []byte, off uint16, l uint8) {
+		if len(data) == 0 {
+			return
+		}
+		b, err := bufs.Get(struct{ io.Reader }{bytes.NewReader(data)}) // hide the bytes.Reader so it is read as a stream
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		b.Quit = make(chan struct{})
+		checkBuffer(t, "stream", b, data, off, l)
+		bufs.Put(b)
+		b, err = bufs.Get(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Quit = make(chan struct{})
+		checkBuffer(t, "bytes", b, data, off, l)
+		bufs.Put(b)
+	})
+}
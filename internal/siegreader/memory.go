@@ -0,0 +1,95 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siegreader
+
+import (
+	"bytes"
+	"io"
+)
+
+// a memory buffer reads directly from a byte slice that is already in memory (e.g. the contents of a bytes.Buffer).
+// Slices are returned without copying.
+type memory struct{ buf []byte }
+
+func newMemory() interface{} { return &memory{} }
+
+func (m *memory) setSource(buf []byte) error {
+	m.buf = buf
+	if len(buf) == 0 {
+		return ErrEmpty
+	}
+	return nil
+}
+
+// Slice returns a byte slice from the buffer that begins at offset off and has length l.
+func (m *memory) Slice(off int64, l int) ([]byte, error) {
+	if off >= int64(len(m.buf)) {
+		return nil, io.EOF
+	}
+	if off+int64(l) > int64(len(m.buf)) {
+		return m.buf[int(off):], io.EOF
+	}
+	return m.buf[int(off) : int(off)+l], nil
+}
+
+// EofSlice returns a slice from the end of the buffer that begins at offset off and has length l.
+func (m *memory) EofSlice(off int64, l int) ([]byte, error) {
+	if off >= int64(len(m.buf)) {
+		return nil, io.EOF
+	}
+	o := len(m.buf) - int(off)
+	if l > o {
+		return m.buf[:o], io.EOF
+	}
+	return m.buf[o-l : o], nil
+}
+
+// Size returns the buffer's size.
+func (m *memory) Size() int64 { return int64(len(m.buf)) }
+
+// SizeNow is a non-blocking Size().
+func (m *memory) SizeNow() int64 { return int64(len(m.buf)) }
+
+func (m *memory) CanSeek(off int64, whence bool) (bool, error) {
+	if int64(len(m.buf)) < off {
+		return false, nil
+	}
+	return true, nil
+}
+
+// inMemory returns the unread contents of readers that hold their data in memory.
+// The contents of a bytes.Buffer are read in place, without draining the bytes.Buffer.
+// A bytes.Reader is drained by WriteTo, which hands over its underlying slice: the slice is immutable for the life of the reader.
+func inMemory(src io.Reader) ([]byte, bool) {
+	switch v := src.(type) {
+	case *bytes.Buffer:
+		return v.Bytes(), true
+	case *bytes.Reader:
+		var c capture
+		if _, err := v.WriteTo(&c); err != nil {
+			return nil, false
+		}
+		return c, true
+	}
+	return nil, false
+}
+
+// capture keeps the slice it is given by a bytes.Reader's WriteTo
+type capture []byte
+
+func (c *capture) Write(p []byte) (int, error) {
+	*c = p
+	return len(p), nil
+}
@@ -1,7 +1,5 @@
 package siegreader
 
-import "log"
-
 type mmap struct {
 	*file
 
@@ -27,11 +25,11 @@ func (m *mmap) eofSlice(off int64, l int) []byte {
 	return m.buf[o-l : o]
 }
 
+// reset unmaps the file. If unmapping fails, the mapping is dropped (it is released when the process exits).
 func (m *mmap) reset() {
-	err := m.unmap()
-	if err != nil {
-		log.Fatalf("Siegfried: fatal error while unmapping: %s; error: %v\n", m.src.Name(), err) // not polite of this package to panic - consider deprecate
+	if m.buf != nil {
+		m.unmap()
 	}
 	m.buf = nil
-	return
+	m.handle = 0
 }
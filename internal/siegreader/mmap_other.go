@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!windows
+
+package siegreader
+
+// memory mapping isn't available on this platform (e.g. js/wasm, wasip1, plan9): files are read with the bigfile and smallfile buffers instead
+
+func mmapable(sz int64) bool {
+	return false
+}
+
+func (m *mmap) mapFile() error {
+	return ErrNoMmap
+}
+
+func (m *mmap) unmap() error {
+	return nil
+}
@@ -6,7 +6,11 @@
 
 package siegreader
 
-import "golang.org/x/sys/unix"
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
 
 func mmapable(sz int64) bool {
 	if int64(int(sz+4095)) != sz+4095 {
@@ -17,7 +21,7 @@ func mmapable(sz int64) bool {
 
 func (m *mmap) mapFile() error {
 	var err error
-	m.buf, err = unix.Mmap(int(m.src.Fd()), 0, int(m.sz), unix.PROT_READ, unix.MAP_SHARED)
+	m.buf, err = unix.Mmap(int(m.src.(*os.File).Fd()), 0, int(m.sz), unix.PROT_READ, unix.MAP_SHARED)
 	return err
 }
 
@@ -19,13 +19,14 @@ func mmapable(sz int64) bool {
 }
 
 func (m *mmap) mapFile() error {
-	h, err := syscall.CreateFileMapping(syscall.Handle(m.src.Fd()), nil, syscall.PAGE_READONLY, uint32(m.sz>>32), uint32(m.sz), nil)
+	h, err := syscall.CreateFileMapping(syscall.Handle(m.src.(*os.File).Fd()), nil, syscall.PAGE_READONLY, uint32(m.sz>>32), uint32(m.sz), nil)
 	if err != nil {
 		return err
 	}
 	m.handle = uintptr(h) // for later unmapping
 	addr, err := syscall.MapViewOfFile(h, syscall.FILE_MAP_READ, 0, 0, 0)
 	if err != nil {
+		syscall.CloseHandle(h)
 		return err
 	}
 	m.buf = []byte{}
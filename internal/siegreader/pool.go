@@ -21,6 +21,10 @@ type pool struct {
 	mu   *sync.Mutex
 	fn   func() interface{}
 	head *item
+
+	idle         int   // number of items in the free list
+	max          int   // maximum number of idle items kept (0 for no limit)
+	hits, misses int64 // gets served from the free list, and gets that allocated a new item
 }
 
 type item struct {
@@ -39,8 +43,11 @@ func (p *pool) get() interface{} {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	if p.head == nil {
+		p.misses++
 		return p.fn()
 	}
+	p.hits++
+	p.idle--
 	ret := p.head.val
 	p.head = p.head.next
 	return ret
@@ -48,6 +55,27 @@ func (p *pool) get() interface{} {
 
 func (p *pool) put(v interface{}) {
 	p.mu.Lock()
-	p.head = &item{p.head, v}
+	if p.max == 0 || p.idle < p.max {
+		p.head = &item{p.head, v}
+		p.idle++
+	}
+	p.mu.Unlock()
+}
+
+// setMax limits the number of idle items kept, dropping any extras
+func (p *pool) setMax(max int) {
+	p.mu.Lock()
+	p.max = max
+	for ; max > 0 && p.idle > max; p.idle-- {
+		p.head = p.head.next
+	}
+	p.mu.Unlock()
+}
+
+func (p *pool) stats(st *Stats) {
+	p.mu.Lock()
+	st.Hits += p.hits
+	st.Misses += p.misses
+	st.Idle += p.idle
 	p.mu.Unlock()
 }
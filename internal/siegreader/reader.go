@@ -105,6 +105,9 @@ func (r *Reader) Read(b []byte) (int, error) {
 
 // ReadAt implements the io.ReaderAt interface.
 func (r *Reader) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrOffset
+	}
 	var slc []byte
 	var err error
 	// if b is already covered by the scratch slice
@@ -134,7 +137,7 @@ func (r *Reader) Seek(offset int64, whence int) (int64, error) {
 	case 2:
 		rev = true
 	default:
-		return 0, fmt.Errorf("Siegreader: Seek error, whence value must be one of 0,1,2 got %v", whence)
+		return 0, fmt.Errorf("%w; got %v", ErrWhence, whence)
 	}
 	success, err := r.CanSeek(offset, rev)
 	if success {
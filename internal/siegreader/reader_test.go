@@ -214,6 +214,7 @@ func TestReverseDrainFile(t *testing.T) {
 	if i := <-lastResults; i != 24040 {
 		t.Errorf("Expecting 24040, got %v", i)
 	}
+	<-firstResults // wait for the forward drain too, so the buffer isn't recycled while it is still being read
 	r.Close()
 	bufs.Put(b)
 }
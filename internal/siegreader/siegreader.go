@@ -32,14 +32,19 @@ package siegreader
 import (
 	"errors"
 	"io"
+	"sync/atomic"
 
 	"github.com/richardlehane/characterize"
 )
 
+// Errors returned by siegreader Buffers and Readers. They can be tested for with errors.Is.
 var (
 	ErrEmpty     = errors.New("empty source")
 	ErrQuit      = errors.New("siegreader: quit chan closed while awaiting EOF")
 	ErrNilBuffer = errors.New("siegreader: attempt to SetSource on a nil buffer")
+	ErrWhence    = errors.New("siegreader: seek error, whence value must be one of 0,1,2")
+	ErrOffset    = errors.New("siegreader: negative offset")
+	ErrNoMmap    = errors.New("siegreader: memory mapping isn't supported on this platform")
 )
 
 const (
@@ -51,6 +56,34 @@ const (
 	streamSz        = smallFileSz * 1024
 )
 
+// Limits tune the memory used by a pool of Buffers and how much of each source is scanned.
+// Zero values mean the defaults are used.
+type Limits struct {
+	BOF       int   // maximum number of bytes the byte matcher scans from the beginning of a source (default is the limit given by the signatures)
+	EOF       int   // maximum number of bytes the byte matcher scans from the end of a source (default is the limit given by the signatures)
+	SmallFile int64 // files up to this size are read into memory, progressively as they are scanned, when they aren't memory mapped (default 64KB)
+	Stream    int   // maximum number of bytes of a stream held in memory before the remainder is spilled to a temp file (default 64MB)
+	Mmap      int64 // only files of at least this size are memory mapped; smaller files are read (default 0 memory maps any local file)
+	NoMmap    bool  // don't memory map files
+	// Pool sizing. Raise StreamSlot for workloads of many streams that are larger than a few KB, lower PoolSize
+	// to cap the memory held by idle buffers after a burst of concurrent identifications.
+	StreamSlot int // initial size of the memory slot allocated for each stream Buffer; slots grow up to Stream as needed (default 8KB)
+	PoolSize   int // maximum number of idle buffers of each type kept for re-use (default 0 keeps all buffers)
+	// Memory is a budget for the memory held by Buffers in use that grows with the size of sources (default 0 for no limit).
+	// Streams that would exceed it spill to temp files; small files that would exceed it are read through a fixed-size buffer.
+	Memory int64
+}
+
+// Stats report the use of a pool of Buffers.
+type Stats struct {
+	Hits   int64 // number of buffers re-used from the pool
+	Misses int64 // number of buffers newly allocated because the pool was empty
+	Spills int64 // number of streams larger than the Stream limit that were spilled to temp files
+	Idle   int   // number of buffers currently held in the pool
+	Memory int64 // bytes currently reserved from the Memory budget
+	Waits  int64 // number of times Wait blocked because the Memory budget was used up
+}
+
 type bufferSrc interface {
 	Slice(off int64, l int) ([]byte, error)
 	EofSlice(off int64, l int) ([]byte, error)
@@ -62,12 +95,88 @@ type bufferSrc interface {
 // Buffer allows multiple readers to read from the same source.
 // Readers include reverse (from EOF) and limit readers.
 type Buffer struct {
-	Quit   chan struct{} // when this channel is closed, readers will return io.EOF
-	texted bool
-	text   characterize.CharType
+	bofRead int64 // furthest offsets read from the beginning and end of the source (first, for atomic access)
+	eofRead int64
+	Quit    chan struct{} // when this channel is closed, readers will return io.EOF
+	bof     int           // scan limits
+	eof     int
+	texted  bool
+	text    characterize.CharType
 	bufferSrc
 }
 
+// ScanLimits returns the maximum number of bytes that should be scanned from the beginning and end of the Buffer.
+// Zero means there is no limit other than those given by the signatures. A negative EOF limit means the end
+// of the Buffer shouldn't be scanned at all.
+func (b *Buffer) ScanLimits() (bof int, eof int) {
+	return b.bof, b.eof
+}
+
+// SetScanLimits overrides the scan limits given by the pool for this Buffer.
+func (b *Buffer) SetScanLimits(bof, eof int) {
+	b.bof, b.eof = bof, eof
+}
+
+// Buffered reports the number of bytes available without further reads of the source:
+// the size of files and external sources, and the number of bytes read so far for streams.
+func (b *Buffer) Buffered() int64 {
+	if s, ok := b.bufferSrc.(*stream); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.sz
+	}
+	return b.SizeNow()
+}
+
+// Slice returns a byte slice from the source at an offset from its beginning.
+func (b *Buffer) Slice(off int64, l int) ([]byte, error) {
+	buf, err := b.bufferSrc.Slice(off, l)
+	reach(&b.bofRead, off+int64(len(buf)))
+	return buf, err
+}
+
+// EofSlice returns a byte slice from the source at an offset from its end.
+func (b *Buffer) EofSlice(off int64, l int) ([]byte, error) {
+	buf, err := b.bufferSrc.EofSlice(off, l)
+	reach(&b.eofRead, off+int64(len(buf)))
+	return buf, err
+}
+
+// reach raises a furthest offset read
+func reach(max *int64, n int64) {
+	for {
+		m := atomic.LoadInt64(max)
+		if n <= m || atomic.CompareAndSwapInt64(max, m, n) {
+			return
+		}
+	}
+}
+
+// BytesRead reports the number of bytes of the source read so far with Slice and EofSlice (and so by Readers): the
+// furthest offset read from the beginning plus the furthest read from the end, up to the size of the source.
+// Use it to see how much of a file identification took.
+func (b *Buffer) BytesRead() int64 {
+	n := atomic.LoadInt64(&b.bofRead) + atomic.LoadInt64(&b.eofRead)
+	if sz := b.SizeNow(); n > sz {
+		return sz
+	}
+	return n
+}
+
+// ResetBytesRead sets the number of bytes read back to zero e.g. to count the bytes read by identification after a
+// checksum has read the whole source.
+func (b *Buffer) ResetBytesRead() {
+	atomic.StoreInt64(&b.bofRead, 0)
+	atomic.StoreInt64(&b.eofRead, 0)
+}
+
+// Stream reports whether the Buffer's source is a stream. The EOF of a stream isn't available
+// until the stream has been read to the end.
+func (b *Buffer) Stream() bool {
+	_, ok := b.bufferSrc.(*stream)
+	return ok
+}
+
 // Bytes returns a byte slice for a full read of the buffered file or stream.
 // Returns nil on error
 func (b *Buffer) Bytes() []byte {
@@ -2,6 +2,7 @@ package siegreader
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 const testString = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
@@ -77,6 +79,27 @@ func TestBytSource(t *testing.T) {
 	}
 }
 
+func TestMemorySource(t *testing.T) {
+	for _, r := range []io.Reader{bytes.NewBuffer(testBytes), bytes.NewReader(testBytes)} {
+		b := setup(r, t)
+		if _, ok := b.bufferSrc.(*memory); !ok {
+			t.Errorf("expecting a memory buffer for a %T, got %T", r, b.bufferSrc)
+		}
+		buf, err := b.Slice(0, 4)
+		if err != nil || &buf[0] != &testBytes[0] {
+			t.Errorf("expecting a slice of the original bytes, got %v", err)
+		}
+		buf, err = b.EofSlice(0, 4)
+		if err != nil || !bytes.Equal(buf, testBytes[len(testBytes)-4:]) {
+			t.Errorf("expecting the last bytes, got %v and %v", buf, err)
+		}
+		if _, err = b.Slice(int64(len(testBytes))-2, 4); err != io.EOF {
+			t.Errorf("expecting an EOF for a slice beyond the end, got %v", err)
+		}
+		bufs.Put(b)
+	}
+}
+
 func TestMMAPFile(t *testing.T) {
 	r, err := os.Open(testfile)
 	defer r.Close()
@@ -127,6 +150,186 @@ func TestSmallFile(t *testing.T) {
 	}
 }
 
+func TestLimits(t *testing.T) {
+	r, err := os.Open(testfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	lbufs := New()
+	lbufs.SetLimits(Limits{BOF: 10, EOF: 20, SmallFile: 100000, NoMmap: true})
+	b, err := lbufs.Get(r)
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer lbufs.Put(b)
+	if bof, eof := b.ScanLimits(); bof != 10 || eof != 20 {
+		t.Errorf("expecting scan limits of 10 and 20, got %d and %d", bof, eof)
+	}
+	if _, err := b.Slice(20000, 10); err != nil { // beyond the initial read
+		t.Fatal(err)
+	}
+	if _, ok := b.bufferSrc.(*file).data.(*smallfile); !ok {
+		t.Errorf("expecting a small file buffer, got %T", b.bufferSrc.(*file).data)
+	}
+	stat, _ := r.Stat()
+	if len(b.Bytes()) != int(stat.Size()) {
+		t.Error("File read: Bytes() error")
+	}
+}
+
+func TestBytesRead(t *testing.T) {
+	b, err := bufs.GetBytes(bytes.Repeat(testBytes, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bufs.Put(b)
+	b.Slice(0, 10)
+	b.Slice(100, 50)
+	b.EofSlice(0, 20)
+	if n := b.BytesRead(); n != 170 {
+		t.Errorf("expecting 170 bytes read, got %d", n)
+	}
+	b.Bytes()
+	if n := b.BytesRead(); n != b.SizeNow() {
+		t.Errorf("expecting all %d bytes read, got %d", b.SizeNow(), n)
+	}
+	b.ResetBytesRead()
+	if n := b.BytesRead(); n != 0 {
+		t.Errorf("expecting no bytes read after a reset, got %d", n)
+	}
+}
+
+type countingReaderAt struct {
+	io.ReaderAt
+	n int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.n += len(p)
+	return c.ReaderAt.ReadAt(p, off)
+}
+
+func TestProgressiveRead(t *testing.T) {
+	src := make([]byte, 100000)
+	rand.Read(src)
+	pbufs := New()
+	pbufs.SetLimits(Limits{SmallFile: int64(len(src))})
+	cr := &countingReaderAt{ReaderAt: bytes.NewReader(src)}
+	b, err := pbufs.GetReaderAt(cr, int64(len(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pbufs.Put(b)
+	slc, _ := b.Slice(int64(initialRead), 10) // just beyond the initial read
+	if _, ok := b.bufferSrc.(*file).data.(*smallfile); !ok {
+		t.Fatalf("expecting a small file buffer, got %T", b.bufferSrc.(*file).data)
+	}
+	if !bytes.Equal(slc, src[initialRead:initialRead+10]) {
+		t.Error("bad slice beyond the initial read")
+	}
+	if cr.n > initialRead*2 {
+		t.Errorf("expecting the window to grow to %d bytes, read %d", initialRead*2, cr.n)
+	}
+	slc, _ = b.EofSlice(100, 10)
+	if !bytes.Equal(slc, src[len(src)-110:len(src)-100]) {
+		t.Error("bad EOF slice")
+	}
+	if cr.n > initialRead*3 {
+		t.Errorf("expecting the window from the end to be %d bytes, read %d", initialRead, cr.n-initialRead*2)
+	}
+	slc, _ = b.Slice(40000, 20000) // spans the unread middle of the file
+	if !bytes.Equal(slc, src[40000:60000]) {
+		t.Error("bad slice in the middle of the file")
+	}
+	if !bytes.Equal(b.Bytes(), src) {
+		t.Error("File read: Bytes() error")
+	}
+	if cr.n != len(src) {
+		t.Errorf("expecting each byte to be read once, read %d of %d", cr.n, len(src))
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	pbufs := New()
+	pbufs.SetLimits(Limits{Stream: readSz * 4, PoolSize: 1})
+	// a stream larger than the Stream limit spills to a temp file
+	b, err := pbufs.Get(strings.NewReader(strings.Repeat("x", readSz*8)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.SizeNow()
+	c, _ := pbufs.Get(strings.NewReader(testString))
+	pbufs.Put(b)
+	pbufs.Put(c) // dropped because the pool is full
+	b, _ = pbufs.Get(strings.NewReader(testString))
+	pbufs.Put(b)
+	if st := pbufs.Stats(); st.Hits != 1 || st.Misses != 2 || st.Spills != 1 || st.Idle != 1 {
+		t.Errorf("bad stats, got %+v", st)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	mbufs := New()
+	mbufs.SetLimits(Limits{Memory: int64(readSz * 2)})
+	b, err := mbufs.Get(strings.NewReader(strings.Repeat("x", readSz*8)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.SizeNow() != int64(readSz*8) {
+		t.Fatalf("expecting the whole stream to be read, got %d", b.SizeNow())
+	}
+	if st := mbufs.Stats(); st.Memory != int64(readSz*2) || st.Spills != 1 {
+		t.Errorf("expecting the stream to use the budget and then spill, got %+v", st)
+	}
+	if buf, _ := b.EofSlice(0, 4); string(buf) != "xxxx" {
+		t.Errorf("expecting to read the end of a spilled stream, got %q", buf)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := mbufs.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expecting Wait to block while the budget is used up, got %v", err)
+	}
+	mbufs.Put(b)
+	if err := mbufs.Wait(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if st := mbufs.Stats(); st.Memory != 0 || st.Waits != 1 {
+		t.Errorf("expecting the budget to be released, got %+v", st)
+	}
+}
+
+func TestMmapSize(t *testing.T) {
+	if !mmapable(1) {
+		t.Skip("memory mapping isn't supported on this platform")
+	}
+	r, err := os.Open(testfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stat, _ := r.Stat()
+	for _, test := range []struct {
+		sz   int64
+		mmap bool
+	}{{0, true}, {stat.Size(), true}, {stat.Size() + 1, false}} {
+		mbufs := New()
+		mbufs.SetLimits(Limits{Mmap: test.sz})
+		r.Seek(0, io.SeekStart)
+		b, err := mbufs.Get(r)
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		if _, err := b.Slice(20000, 10); err != nil { // beyond the initial read
+			t.Fatal(err)
+		}
+		if _, ok := b.bufferSrc.(*file).data.(*mmap); ok != test.mmap {
+			t.Errorf("with a mmap size of %d, expecting memory mapping to be %v, got %T", test.sz, test.mmap, b.bufferSrc.(*file).data)
+		}
+		mbufs.Put(b)
+	}
+}
+
 // The following tests generate temp files filled with random data and compare io.ReadAt()
 // calls with Slice() and EofSlice() calls for the various Buffer types (mmap file, big file,
 // small file, big stream and small stream).
@@ -176,6 +379,26 @@ func TestBigFileRand(t *testing.T) {
 	}
 }
 
+func TestReaderAtRand(t *testing.T) {
+	var sz int64 = 100000
+	tf, err := makeTmp(sz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tf.Name())
+	defer tf.Close()
+	// hide the *os.File so the buffer can't mmap it
+	b, err := bufs.GetReaderAt(struct{ io.ReaderAt }{tf}, sz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Quit = make(chan struct{})
+	defer bufs.Put(b)
+	if err := testBuffer(t, 1000, tf, b); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSmallStreamRand(t *testing.T) {
 	var sz int64 = 100000
 	tf, err := makeTmp(sz)
@@ -1,30 +1,96 @@
 package siegreader
 
-import "log"
+import (
+	"log"
+	"sync"
+)
 
+// A small file is read into memory progressively: it starts with the initial read of the file, and further reads
+// from the beginning or end of the file at least double the window already read. So when the matchers are done
+// after a few KB, which is typical, the rest of the file is never read. This matters on slow (e.g. network) filesystems.
 type smallfile struct {
 	*file
 
-	buf [smallFileSz]byte
+	buf      []byte
+	reserved int64 // memory reserved from the budget
+
+	mu         sync.Mutex
+	head, tail int // number of bytes read from the beginning and end of the file
 }
 
 func newSmallFile() interface{} {
-	return &smallfile{}
+	return &smallfile{buf: make([]byte, smallFileSz)}
 }
 
 func (sf *smallfile) setSource(f *file) {
 	sf.file = f
-	i, err := sf.src.ReadAt(sf.buf[:], 0)
-	if i != int(sf.sz) {
-		log.Fatalf("Siegreader fatal error: failed to read %s, got %d bytes of %d, error: %v\n", sf.src.Name(), i, sf.sz, err)
+	if cap(sf.buf) < int(sf.sz) {
+		sf.buf = make([]byte, int(sf.sz))
 	}
+	sf.buf = sf.buf[:sf.sz]
+	sf.head = copy(sf.buf, f.peek[:])
+	sf.tail = 0
+}
+
+// fill reads from the source so that the first head and last tail bytes of the file are in the buffer.
+func (sf *smallfile) fill(head, tail int) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if head > sf.head {
+		if head < sf.head*2 {
+			head = sf.head * 2
+		}
+		if head > len(sf.buf)-sf.tail {
+			head = len(sf.buf) - sf.tail
+		}
+		sf.read(sf.head, head)
+		sf.head = head
+	}
+	if tail > sf.tail {
+		if tail < sf.tail*2 {
+			tail = sf.tail * 2
+		}
+		if tail < initialRead {
+			tail = initialRead
+		}
+		if tail > len(sf.buf)-sf.head {
+			tail = len(sf.buf) - sf.head
+		}
+		sf.read(len(sf.buf)-tail, len(sf.buf)-sf.tail)
+		sf.tail = tail
+	}
+}
+
+func (sf *smallfile) read(start, end int) {
+	if start >= end {
+		return
+	}
+	i, err := sf.src.ReadAt(sf.buf[start:end], int64(start))
+	if i != end-start {
+		log.Fatalf("Siegreader fatal error: failed to read %s, got %d bytes of %d at offset %d, error: %v\n", sf.src.Name(), i, end-start, start, err)
+	}
+}
+
+// covered reports whether the bytes from start to end have been read
+func (sf *smallfile) covered(start, end int) bool {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	return end <= sf.head || start >= len(sf.buf)-sf.tail || sf.head+sf.tail >= len(sf.buf)
 }
 
 func (sf *smallfile) slice(off int64, l int) []byte {
-	return sf.buf[int(off) : int(off)+l]
+	start, end := int(off), int(off)+l
+	if !sf.covered(start, end) {
+		sf.fill(end, 0)
+	}
+	return sf.buf[start:end]
 }
 
 func (sf *smallfile) eofSlice(off int64, l int) []byte {
-	o := int(sf.sz - off)
-	return sf.buf[o-l : o]
+	end := len(sf.buf) - int(off)
+	start := end - l
+	if !sf.covered(start, end) {
+		sf.fill(0, int(off)+l)
+	}
+	return sf.buf[start:end]
 }
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"sync"
+	"sync/atomic"
 )
 
 type stream struct {
@@ -12,9 +13,15 @@ type stream struct {
 	src   io.Reader
 	sz    int64
 	buf   []byte
-	tf    *os.File // temp backing file - used when stream exceeds streamSz
+	tf    *os.File // temp backing file - used when stream exceeds max
 	tfBuf []byte
 	eofc  chan struct{}
+	max   int    // maximum size of buf
+	slot  int    // initial size of buf
+	spill *int64 // count of streams spilled to temp files, shared by the pool
+
+	budget   *budget // memory budget shared by the pool
+	reserved int64   // growth of buf reserved from the budget
 
 	mu  sync.Mutex
 	i   int // marks how much of buf we have filled
@@ -22,7 +29,11 @@ type stream struct {
 }
 
 func newStream() interface{} {
-	return &stream{buf: make([]byte, readSz*2), tfBuf: make([]byte, readSz)}
+	return newStreamSlot(readSz * 2)
+}
+
+func newStreamSlot(sz int) *stream {
+	return &stream{buf: make([]byte, sz), tfBuf: make([]byte, readSz), max: streamSz, slot: sz}
 }
 
 func (s *stream) setSource(src io.Reader, b *Buffer) error {
@@ -46,6 +57,16 @@ func (s *stream) cleanUp() {
 	s.tf = nil
 }
 
+// release returns memory reserved from the budget, dropping the grown buf so that idle streams don't hold it
+func (s *stream) release() {
+	if s.reserved == 0 {
+		return
+	}
+	s.budget.release(s.reserved)
+	s.reserved = 0
+	s.buf = make([]byte, s.slot)
+}
+
 // Size returns the buffer's size, which is available immediately for files. Must wait for full read for streams.
 func (s *stream) Size() int64 {
 	select {
@@ -69,21 +90,33 @@ func (s *stream) grow() error {
 		return nil
 	}
 	c := cap(s.buf) * 2
-	if c > streamSz {
-		if cap(s.buf) < streamSz {
-			c = streamSz
-		} else { // if we've exceeded streamSz, use a temp file to copy remainder
-			var err error
-			s.tf, err = ioutil.TempFile("", "siegfried")
-			return err
+	if c > s.max {
+		if cap(s.buf) < s.max {
+			c = s.max
+		} else { // if we've exceeded max, use a temp file to copy remainder
+			return s.spillover()
 		}
 	}
+	// or if growing would exceed the memory budget
+	if !s.budget.try(int64(c - cap(s.buf))) {
+		return s.spillover()
+	}
+	s.reserved += int64(c - cap(s.buf))
 	buf := make([]byte, c)
 	copy(buf, s.buf[:s.i]) // don't care about unlocking as grow() is only called by fill()
 	s.buf = buf
 	return nil
 }
 
+func (s *stream) spillover() error {
+	var err error
+	s.tf, err = ioutil.TempFile("", "siegfried")
+	if err == nil && s.spill != nil {
+		atomic.AddInt64(s.spill, 1)
+	}
+	return err
+}
+
 func (s *stream) fill() (int64, error) {
 	// have already scanned to the end of the stream
 	if s.eof {
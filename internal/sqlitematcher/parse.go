@@ -0,0 +1,170 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlitematcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// the layout of a SQLite database header (see https://www.sqlite.org/fileformat.html)
+const (
+	headerLen        = 100
+	pageSizeOffset   = 16
+	reservedOffset   = 20
+	userVersionOff   = 60
+	applicationIDOff = 68
+)
+
+const (
+	interiorTable = 0x05
+	leafTable     = 0x0D
+	maxPages      = 256 // the most schema pages read
+	maxDepth      = 8   // the deepest schema b-tree read
+)
+
+var magic = []byte("SQLite format 3\x00")
+
+type header struct {
+	pageSize      int
+	usable        int
+	userVersion   [4]byte
+	applicationID [4]byte
+}
+
+func readHeader(b *siegreader.Buffer) (header, bool) {
+	buf, err := b.Slice(0, headerLen)
+	if err != nil || !bytes.HasPrefix(buf, magic) {
+		return header{}, false
+	}
+	hdr := header{pageSize: int(binary.BigEndian.Uint16(buf[pageSizeOffset:]))}
+	if hdr.pageSize == 1 {
+		hdr.pageSize = 65536
+	}
+	// page sizes are powers of two between 512 and 65536
+	if hdr.pageSize < 512 || hdr.pageSize&(hdr.pageSize-1) != 0 {
+		return header{}, false
+	}
+	hdr.usable = hdr.pageSize - int(buf[reservedOffset])
+	copy(hdr.userVersion[:], buf[userVersionOff:])
+	copy(hdr.applicationID[:], buf[applicationIDOff:])
+	return hdr, true
+}
+
+// tables reads the names of the tables in a database's schema (the sqlite_master table), lower cased
+func (hdr header) tables(b *siegreader.Buffer) map[string]bool {
+	tables := make(map[string]bool)
+	var pages int
+	var walk func(page, depth int)
+	walk = func(page, depth int) {
+		pages++
+		if page < 1 || depth > maxDepth || pages > maxPages {
+			return
+		}
+		buf, _ := b.Slice(int64(page-1)*int64(hdr.pageSize), hdr.pageSize)
+		if len(buf) < hdr.pageSize {
+			return
+		}
+		buf = buf[:hdr.usable]
+		h := 0
+		if page == 1 {
+			h = headerLen
+		}
+		if len(buf) < h+12 {
+			return
+		}
+		typ, cells := buf[h], int(binary.BigEndian.Uint16(buf[h+3:]))
+		switch typ {
+		case leafTable:
+			for i := 0; i < cells; i++ {
+				if h+8+i*2+2 > len(buf) {
+					return
+				}
+				if typ, name, ok := schemaRow(buf, int(binary.BigEndian.Uint16(buf[h+8+i*2:]))); ok && typ == "table" {
+					tables[strings.ToLower(name)] = true
+				}
+			}
+		case interiorTable:
+			for i := 0; i < cells; i++ {
+				ptr := h + 12 + i*2
+				if ptr+2 > len(buf) {
+					return
+				}
+				if cell := int(binary.BigEndian.Uint16(buf[ptr:])); cell+4 <= len(buf) {
+					walk(int(binary.BigEndian.Uint32(buf[cell:])), depth+1)
+				}
+			}
+			walk(int(binary.BigEndian.Uint32(buf[h+8:])), depth+1)
+		}
+	}
+	walk(1, 0)
+	return tables
+}
+
+// schemaRow reads the type and name columns of a row of the sqlite_master table, from a cell of a table leaf page.
+// Only the part of the row on the page is read: the type and name columns are at its start.
+func schemaRow(page []byte, cell int) (string, string, bool) {
+	if cell >= len(page) {
+		return "", "", false
+	}
+	buf := page[cell:]
+	_, n := varint(buf) // payload size
+	if n == 0 {
+		return "", "", false
+	}
+	buf = buf[n:]
+	if _, n = varint(buf); n == 0 { // rowid
+		return "", "", false
+	}
+	rec := buf[n:]
+	hdrLen, n := varint(rec)
+	if n == 0 || hdrLen < uint64(n) || hdrLen > uint64(len(rec)) {
+		return "", "", false
+	}
+	hdr, body := rec[n:hdrLen], rec[hdrLen:]
+	var cols [2]string
+	for i := range cols {
+		st, n := varint(hdr)
+		// the type and name columns are text (odd serial types of 13 or more)
+		if n == 0 || st < 13 || st%2 == 0 {
+			return "", "", false
+		}
+		hdr = hdr[n:]
+		l := (st - 13) / 2
+		if l > uint64(len(body)) {
+			return "", "", false
+		}
+		cols[i], body = string(body[:l]), body[l:]
+	}
+	return cols[0], cols[1], true
+}
+
+// varint reads a SQLite variable length integer. It returns the number of bytes read, zero if buf is too short.
+func varint(buf []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(buf); i++ {
+		if i == 8 {
+			return v<<8 | uint64(buf[i]), 9
+		}
+		v = v<<7 | uint64(buf[i]&0x7F)
+		if buf[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,294 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlitematcher identifies formats that are SQLite databases (e.g. GeoPackage, MBTiles or Anki decks) by the
+// application_id and user_version in their database header and by the names of the tables in their schema.
+// Table names can be anywhere in a database's first pages, so byte signatures can't reliably match them.
+package sqlitematcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/priority"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// Signature identifies a SQLite database. A database matches if it has the application_id and user_version (unless
+// these are zero) and all the tables (case insensitive) of the signature.
+type Signature struct {
+	ApplicationID [4]byte
+	UserVersion   [4]byte
+	Tables        []string
+}
+
+func (s Signature) String() string {
+	strs := make([]string, 0, 3)
+	if s.ApplicationID != [4]byte{} {
+		strs = append(strs, "application_id "+appID(s.ApplicationID))
+	}
+	if s.UserVersion != [4]byte{} {
+		strs = append(strs, fmt.Sprintf("user_version %d", binary.BigEndian.Uint32(s.UserVersion[:])))
+	}
+	if len(s.Tables) > 0 {
+		strs = append(strs, "tables "+strings.Join(s.Tables, ", "))
+	}
+	return strings.Join(strs, "; ")
+}
+
+// application IDs are usually four ASCII characters e.g. GPKG
+func appID(id [4]byte) string {
+	if printable(id[:]) {
+		return string(id[:])
+	}
+	return fmt.Sprintf("0x%08X", binary.BigEndian.Uint32(id[:]))
+}
+
+func (s Signature) match(hdr header, tables map[string]bool) bool {
+	if s.ApplicationID != [4]byte{} && s.ApplicationID != hdr.applicationID {
+		return false
+	}
+	if s.UserVersion != [4]byte{} && s.UserVersion != hdr.userVersion {
+		return false
+	}
+	for _, t := range s.Tables {
+		if !tables[strings.ToLower(t)] {
+			return false
+		}
+	}
+	return true
+}
+
+// Signatures derives SQLite signatures from byte signatures that begin with the SQLite magic. Sequences at the
+// offsets of the user_version (60) and application_id (68) fields of the database header give those fields.
+// Other sequences that are SQL identifiers (e.g. the FamilyTable of PRONOM's RootsMagic signature) are taken to be
+// table names. It returns the signatures and the corresponding IDs.
+func Signatures(sigs []frames.Signature, ids []string) ([]Signature, []string) {
+	var ret []Signature
+	var rids []string
+	for i, sig := range sigs {
+		if len(sig) == 0 || sig[0].Orientation() != frames.BOF || sig[0].Min != 0 || sig[0].Max != 0 {
+			continue
+		}
+		seq, ok := sig[0].Pattern.(patterns.Sequence)
+		if !ok || !bytes.HasPrefix(seq, magic) {
+			continue
+		}
+		var s Signature
+		off := 0 // the offset of the current frame, or -1 if it isn't fixed
+		for j, f := range sig {
+			seq, ok := f.Pattern.(patterns.Sequence)
+			switch {
+			case j == 0:
+			case f.Orientation() == frames.BOF && f.Min == f.Max:
+				off = f.Min
+			case f.Orientation() == frames.PREV && f.Min == f.Max && off > -1:
+				off += f.Min
+			default:
+				off = -1
+			}
+			if !ok {
+				off = -1
+				continue
+			}
+			switch {
+			case off > -1 && off < headerLen:
+				if off <= userVersionOff && off+len(seq) >= userVersionOff+4 {
+					copy(s.UserVersion[:], seq[userVersionOff-off:])
+				}
+				if off <= applicationIDOff && off+len(seq) >= applicationIDOff+4 {
+					copy(s.ApplicationID[:], seq[applicationIDOff-off:])
+				}
+			case j > 0 && identifier(seq):
+				s.Tables = append(s.Tables, string(seq))
+			}
+			if off > -1 {
+				off += len(seq)
+			}
+		}
+		if s.ApplicationID != [4]byte{} || s.UserVersion != [4]byte{} || len(s.Tables) > 0 {
+			ret, rids = append(ret, s), append(rids, ids[i])
+		}
+	}
+	return ret, rids
+}
+
+func printable(byts []byte) bool {
+	for _, c := range byts {
+		if c < 0x20 || c > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+// identifier reports whether a sequence could be a table name: an SQL identifier of at least three characters
+func identifier(byts []byte) bool {
+	if len(byts) < 3 {
+		return false
+	}
+	for i, c := range byts {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type Matcher struct {
+	sigs       []Signature
+	tables     bool // whether any signature needs the schema to be read
+	priorities *priority.Set
+}
+
+func Load(ls *persist.LoadSaver) core.Matcher {
+	le := ls.LoadSmallInt()
+	if le == 0 {
+		return nil
+	}
+	m := &Matcher{sigs: make([]Signature, le)}
+	for i := range m.sigs {
+		m.sigs[i] = Signature{
+			ApplicationID: ls.LoadFourCC(),
+			UserVersion:   ls.LoadFourCC(),
+			Tables:        ls.LoadStrings(),
+		}
+		m.tables = m.tables || len(m.sigs[i].Tables) > 0
+	}
+	m.priorities = priority.Load(ls)
+	return m
+}
+
+func Save(c core.Matcher, ls *persist.LoadSaver) {
+	if c == nil {
+		ls.SaveSmallInt(0)
+		return
+	}
+	m := c.(*Matcher)
+	ls.SaveSmallInt(len(m.sigs))
+	if len(m.sigs) == 0 {
+		return
+	}
+	for _, s := range m.sigs {
+		ls.SaveFourCC(s.ApplicationID)
+		ls.SaveFourCC(s.UserVersion)
+		ls.SaveStrings(s.Tables)
+	}
+	m.priorities.Save(ls)
+}
+
+type SignatureSet []Signature
+
+// MarshalJSON encodes a Matcher for export.
+func (m *Matcher) MarshalJSON() ([]byte, error) {
+	sigs := make([]string, len(m.sigs))
+	for i, s := range m.sigs {
+		sigs[i] = s.String()
+	}
+	return json.Marshal(struct {
+		Signatures []string      `json:"signatures"`
+		Priorities *priority.Set `json:"priorities"`
+	}{sigs, m.priorities})
+}
+
+func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, int, error) {
+	sigs, ok := ss.(SignatureSet)
+	if !ok {
+		return nil, -1, fmt.Errorf("SQLitematcher: %w", core.ErrSignatureSet)
+	}
+	if len(sigs) == 0 {
+		return c, 0, nil
+	}
+	var m *Matcher
+	if c == nil {
+		m = &Matcher{priorities: &priority.Set{}}
+	} else {
+		m = c.(*Matcher)
+	}
+	for _, s := range sigs {
+		m.sigs = append(m.sigs, s)
+		m.tables = m.tables || len(s.Tables) > 0
+	}
+	// add priorities
+	m.priorities.Add(p, len(sigs), 0, 0)
+	return m, len(m.sigs), nil
+}
+
+type result struct {
+	idx int
+	sig Signature
+}
+
+func (r result) Index() int {
+	return r.idx
+}
+
+func (r result) Basis() string {
+	return "sqlite " + r.sig.String()
+}
+
+func (m Matcher) Identify(na string, b *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	hdr, ok := readHeader(b)
+	if !ok {
+		res := make(chan core.Result)
+		close(res)
+		return res, nil
+	}
+	var tables map[string]bool
+	if m.tables {
+		tables = hdr.tables(b)
+	}
+	if config.Debug() {
+		fmt.Fprintf(config.Out(), "sqlite application_id %s, user_version %d, %d tables\n",
+			appID(hdr.applicationID), binary.BigEndian.Uint32(hdr.userVersion[:]), len(tables))
+	}
+	waitset := m.priorities.WaitSet(hints...)
+	var hits []core.Result
+	for i, s := range m.sigs {
+		if s.match(hdr, tables) && waitset.Check(i) {
+			if config.Debug() {
+				fmt.Fprintf(config.Out(), "sending sqlite match %s\n", s)
+			}
+			hits = append(hits, result{i, s})
+			if waitset.Put(i) {
+				break
+			}
+		}
+	}
+	res := make(chan core.Result, len(hits))
+	for _, h := range hits {
+		res <- h
+	}
+	close(res)
+	return res, nil
+}
+
+func (m Matcher) String() string {
+	strs := make([]string, len(m.sigs))
+	for i, s := range m.sigs {
+		strs[i] = fmt.Sprintf("%d: %s", i, s)
+	}
+	return fmt.Sprintf("SQLite matcher:\n%s\n", strings.Join(strs, "\n"))
+}
@@ -0,0 +1,143 @@
+package sqlitematcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// text makes the serial type and content of a text column
+func text(s string) (byte, []byte) {
+	return byte(len(s)*2 + 13), []byte(s)
+}
+
+// db makes a SQLite database of a single 1024 byte page, with a schema of the given tables
+func db(appID, userVersion string, tables ...string) []byte {
+	page := make([]byte, 1024)
+	copy(page, magic)
+	binary.BigEndian.PutUint16(page[pageSizeOffset:], 1024)
+	copy(page[userVersionOff:], userVersion)
+	copy(page[applicationIDOff:], appID)
+	page[headerLen] = leafTable
+	binary.BigEndian.PutUint16(page[headerLen+3:], uint16(len(tables)))
+	end := len(page)
+	for i, t := range tables {
+		var hdr, body []byte
+		for _, col := range []string{"table", t, t} {
+			st, c := text(col)
+			hdr, body = append(hdr, st), append(body, c...)
+		}
+		hdr, body = append(hdr, 1), append(body, byte(i+2)) // rootpage
+		st, c := text("CREATE TABLE " + t + "(id)")
+		hdr, body = append(hdr, st), append(body, c...)
+		rec := append(append([]byte{byte(len(hdr) + 1)}, hdr...), body...)
+		cell := append([]byte{byte(len(rec)), byte(i + 1)}, rec...)
+		end -= len(cell)
+		copy(page[end:], cell)
+		binary.BigEndian.PutUint16(page[headerLen+8+i*2:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(page[headerLen+5:], uint16(end))
+	return page
+}
+
+func buffer(t *testing.T, byts []byte) *siegreader.Buffer {
+	b, err := siegreader.New().Get(bytes.NewReader(byts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestTables(t *testing.T) {
+	b := buffer(t, db("", "", "notes", "Cards", "col"))
+	hdr, ok := readHeader(b)
+	if !ok {
+		t.Fatal("expecting a SQLite header")
+	}
+	tables := hdr.tables(b)
+	if len(tables) != 3 || !tables["notes"] || !tables["cards"] || !tables["col"] {
+		t.Errorf("expecting notes, cards and col tables, got %v", tables)
+	}
+	if _, ok = readHeader(buffer(t, []byte("SQLite format 2\x00"))); ok {
+		t.Error("expecting no SQLite header")
+	}
+}
+
+var sigs = SignatureSet{
+	{ApplicationID: [4]byte{'G', 'P', 'K', 'G'}},
+	{ApplicationID: [4]byte{'G', 'P', 'K', 'G'}, UserVersion: [4]byte{0, 0, 0x27, 0xD8}},
+	{Tables: []string{"notes", "cards", "col"}},
+	{Tables: []string{"FamilyTable"}},
+}
+
+func identify(t *testing.T, m core.Matcher, byts []byte) []string {
+	res, err := m.Identify("", buffer(t, byts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hits []string
+	for r := range res {
+		hits = append(hits, r.Basis())
+	}
+	return hits
+}
+
+func TestIdentify(t *testing.T) {
+	m, l, err := Add(nil, sigs, nil)
+	if err != nil || l != len(sigs) {
+		t.Fatalf("expecting a matcher with %d signatures, got %d %v", len(sigs), l, err)
+	}
+	for _, test := range []struct {
+		name   string
+		byts   []byte
+		expect string
+	}{
+		{"geopackage", db("GPKG", "\x00\x00\x27\xD8", "gpkg_contents"), "sqlite application_id GPKG | sqlite application_id GPKG; user_version 10200"},
+		{"anki", db("", "\x00\x00\x00\x0B", "col", "notes", "cards", "revlog"), "sqlite tables notes, cards, col"},
+		{"missing table", db("", "", "notes", "cards"), ""},
+		{"plain", db("", ""), ""},
+		{"not sqlite", []byte("** This file contains an SQLite 2.1 database **"), ""},
+	} {
+		if hits := strings.Join(identify(t, m, test.byts), " | "); hits != test.expect {
+			t.Errorf("%s: expecting %q, got %q", test.name, test.expect, hits)
+		}
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	bsigs := []frames.Signature{
+		{frames.NewFrame(frames.BOF, patterns.Sequence(magic), 0, 0), frames.NewFrame(frames.PREV, patterns.Sequence("FamilyTable"), 0, 256)},
+		{frames.NewFrame(frames.BOF, patterns.Sequence(magic), 0, 0), frames.NewFrame(frames.BOF, patterns.Sequence("GPKG"), 68, 68)},
+		{frames.NewFrame(frames.BOF, patterns.Sequence(magic), 0, 0), frames.NewFrame(frames.PREV, patterns.Sequence("\x00\x00\x27\xD8\x00\x00\x00\x00GPKG"), 44, 44)},
+		{frames.NewFrame(frames.BOF, patterns.Sequence(magic), 0, 0)},
+		{frames.NewFrame(frames.BOF, patterns.Sequence("RIFF"), 0, 0), frames.NewFrame(frames.PREV, patterns.Sequence("FamilyTable"), 0, 256)},
+	}
+	ss, ids := Signatures(bsigs, []string{"fmt/1338", "fmt/x", "fmt/y", "fmt/729", "fmt/z"})
+	strs := make([]string, len(ss))
+	for i, s := range ss {
+		strs[i] = s.String()
+	}
+	expect := "tables FamilyTable | application_id GPKG | application_id GPKG; user_version 10200"
+	if got := strings.Join(strs, " | "); got != expect || strings.Join(ids, ",") != "fmt/1338,fmt/x,fmt/y" {
+		t.Errorf("expecting %s, got %s %v", expect, got, ids)
+	}
+}
+
+func TestIO(t *testing.T) {
+	sm, _, _ := Add(nil, sigs, nil)
+	str := sm.String()
+	saver := persist.NewLoadSaver(nil)
+	Save(sm, saver)
+	loader := persist.NewLoadSaver(saver.Bytes())
+	newsm := Load(loader)
+	if str2 := newsm.String(); str != str2 {
+		t.Errorf("Load SQLite matcher: expecting first matcher (%v), to equal second matcher (%v)", str, str2)
+	}
+}
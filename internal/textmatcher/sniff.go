@@ -0,0 +1,669 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package textmatcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/richardlehane/characterize"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// Sniff describes the structured text format of a text file, as recognised by a sniffer
+type Sniff struct {
+	ID    string // e.g. json, yaml, csv, tsv, or a source code language e.g. python; empty if no sniffer recognised the text
+	Basis string // what the sniffer found e.g. the dialect of a CSV file
+}
+
+// Sniffer is implemented by the results of the text matcher. Sniffing reads more of a file than the text matcher
+// does, so it is only done if a recorder asks for it (while the results are being recorded).
+type Sniffer interface {
+	core.Result
+	Sniff() Sniff
+}
+
+// sampleSz is how much of a file the sniffers read
+const sampleSz = 65536
+
+// sniffer sniffs a buffer once, for all the results of the text matcher
+type sniffer struct {
+	once sync.Once
+	buf  *siegreader.Buffer
+	tt   characterize.CharType
+	s    Sniff
+}
+
+func (s *sniffer) sniff() Sniff {
+	s.once.Do(func() {
+		switch s.tt {
+		case characterize.ASCII, characterize.UTF8, characterize.UTF8BOM, characterize.LATIN1, characterize.EXTENDED:
+		default:
+			return // the sniffers only read single byte and UTF-8 text
+		}
+		sample, err := s.buf.Slice(0, sampleSz)
+		if err != nil && err != io.EOF {
+			return
+		}
+		s.s = sniff(bytes.TrimPrefix(sample, []byte("\xEF\xBB\xBF")), err == nil)
+	})
+	return s.s
+}
+
+// sniff runs the sniffers over a sample of text, most particular first. Truncated samples (the start of a larger
+// file) may end part way through a value or record.
+func sniff(sample []byte, truncated bool) Sniff {
+	if s, ok := sniffJSON(sample, truncated); ok {
+		return s
+	}
+	if s, ok := sniffInterpreter(sample); ok {
+		return s
+	}
+	if s, ok := sniffDelimited(sample, truncated); ok {
+		return s
+	}
+	if s, ok := sniffYAML(splitLines(sample, truncated)); ok {
+		return s
+	}
+	if s, ok := sniffSource(sample); ok {
+		return s
+	}
+	return Sniff{}
+}
+
+func splitLines(sample []byte, truncated bool) []string {
+	lines := strings.Split(string(sample), "\n")
+	// drop the partial last line of a truncated sample
+	if truncated && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return "1 " + s
+	}
+	return strconv.Itoa(n) + " " + s + "s"
+}
+
+// JSON
+
+// sniffJSON recognises JSON text that is an object or an array, and JSON Lines (objects or arrays on separate lines)
+func sniffJSON(sample []byte, truncated bool) (Sniff, bool) {
+	sample = bytes.TrimLeft(sample, " \t\r\n")
+	if len(sample) == 0 || (sample[0] != '{' && sample[0] != '[') {
+		return Sniff{}, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(sample))
+	dec.UseNumber()
+	var depth, maxDepth, values int
+	var end int64 // the end of the last top-level value
+	var kind string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if depth > 0 && !truncated {
+				return Sniff{}, false
+			}
+			break
+		}
+		if err != nil {
+			if truncated && errors.Is(err, io.ErrUnexpectedEOF) && values > 0 {
+				break
+			}
+			return Sniff{}, false
+		}
+		d, ok := tok.(json.Delim)
+		if !ok {
+			if depth == 0 {
+				return Sniff{}, false // a scalar at the top level
+			}
+			continue
+		}
+		switch d {
+		case '{', '[':
+			if depth == 0 {
+				// top-level values after the first must start on a new line
+				if values > 0 && bytes.IndexByte(sample[end:dec.InputOffset()], '\n') < 0 {
+					return Sniff{}, false
+				}
+				if values == 0 {
+					kind = "object"
+					if d == '[' {
+						kind = "array"
+					}
+				}
+				values++
+			}
+			if depth++; depth > maxDepth {
+				maxDepth = depth
+			}
+		default:
+			if depth--; depth == 0 {
+				end = dec.InputOffset()
+			}
+		}
+	}
+	if values > 1 {
+		return Sniff{"jsonl", fmt.Sprintf("JSON Lines, %s", plural(values, "record"))}, true
+	}
+	return Sniff{"json", fmt.Sprintf("JSON %s, nesting depth %d", kind, maxDepth)}, true
+}
+
+// CSV and TSV
+
+var delimiters = []byte{',', '\t', ';', '|'}
+
+type dialect struct {
+	delim, quote byte
+	quoted       bool // whether any fields are quoted
+	header       bool
+	fields       int // the number of fields in most records
+	records      int
+	consistent   int // the number of records with that number of fields
+}
+
+func (d dialect) String() string {
+	delim := fmt.Sprintf("'%c'", d.delim)
+	if d.delim == '\t' {
+		delim = "tab"
+	}
+	quoting := "no quoting"
+	if d.quoted {
+		quoting = fmt.Sprintf("quote '%c'", d.quote)
+	}
+	header := "no header row"
+	if d.header {
+		header = "header row"
+	}
+	return fmt.Sprintf("delimiter %s, %s, %s; %s, %s", delim, quoting, header, plural(d.fields, "field"), plural(d.records, "record"))
+}
+
+// sniffDelimited recognises CSV and TSV files by finding a delimiter and quote character that split the records
+// of the sample into a consistent number of fields. It then guesses whether the first record is a header row.
+func sniffDelimited(sample []byte, truncated bool) (Sniff, bool) {
+	var best dialect
+	for _, delim := range delimiters {
+		d, ok := tryDialect(sample, delim, '"', truncated)
+		if !d.quoted {
+			// single quotes are only tried when double quotes aren't used
+			if sd, sok := tryDialect(sample, delim, '\'', truncated); sok && sd.quoted {
+				d, ok = sd, sok
+			}
+		}
+		if !ok {
+			continue
+		}
+		if d.consistent > best.consistent || (d.consistent == best.consistent && d.fields > best.fields) {
+			best = d
+		}
+	}
+	if best.delim == 0 {
+		return Sniff{}, false
+	}
+	id := "csv"
+	if best.delim == '\t' {
+		id = "tsv"
+	}
+	return Sniff{id, best.String()}, true
+}
+
+func tryDialect(sample []byte, delim, quote byte, truncated bool) (dialect, bool) {
+	d := dialect{delim: delim, quote: quote}
+	recs, quoted, ok := parseDelimited(sample, delim, quote, truncated)
+	if !ok || len(recs) < 2 {
+		return d, false
+	}
+	d.quoted, d.records = quoted, len(recs)
+	counts := make(map[int]int)
+	for _, r := range recs {
+		counts[len(r)]++
+	}
+	for n, c := range counts {
+		if c > d.consistent || (c == d.consistent && n > d.fields) {
+			d.fields, d.consistent = n, c
+		}
+	}
+	// most records must have the same number of fields (all of them, for short samples)
+	if d.fields < 2 || (len(recs) < 10 && d.consistent < len(recs)) || d.consistent*10 < len(recs)*9 {
+		return d, false
+	}
+	// a column that is always empty is more likely a terminator (e.g. the semicolons that end lines of code), and a
+	// first column that is mostly empty is more likely indentation (e.g. of XML indented with tabs)
+	for i := 0; i < d.fields; i++ {
+		var empty int
+		for _, r := range recs {
+			if len(r) == d.fields && r[i] == "" {
+				empty++
+			}
+		}
+		if empty == d.consistent || (i == 0 && empty*2 > d.consistent) {
+			return d, false
+		}
+	}
+	d.header = hasHeader(recs, d.fields)
+	return d, true
+}
+
+// parseDelimited splits a sample into records and fields. It reports whether any field was quoted and false if a
+// quoted field is malformed.
+func parseDelimited(sample []byte, delim, quote byte, truncated bool) ([][]string, bool, bool) {
+	var recs [][]string
+	var rec []string
+	var field []byte
+	var quoted, inQuote, wasQuoted bool
+	endRecord := func() {
+		rec = append(rec, string(field))
+		// skip blank lines (or lines of nothing but delimiters)
+		if wasQuoted || strings.Trim(strings.Join(rec, ""), " ") != "" {
+			recs = append(recs, rec)
+		}
+		rec, field, wasQuoted = nil, field[:0], false
+	}
+	for i := 0; i < len(sample); i++ {
+		c := sample[i]
+		if inQuote {
+			if c != quote {
+				field = append(field, c)
+				continue
+			}
+			if i+1 < len(sample) && sample[i+1] == quote {
+				field = append(field, quote)
+				i++
+				continue
+			}
+			inQuote = false
+			// a closing quote must end its field
+			if i+1 < len(sample) {
+				if n := sample[i+1]; n != delim && n != '\n' && n != '\r' {
+					return nil, quoted, false
+				}
+			}
+			continue
+		}
+		switch c {
+		case quote:
+			if len(field) > 0 || wasQuoted {
+				field = append(field, c) // a quote within an unquoted field is just a character
+				continue
+			}
+			inQuote, quoted, wasQuoted = true, true, true
+		case delim:
+			rec = append(rec, string(field))
+			field, wasQuoted = field[:0], false
+		case '\n':
+			endRecord()
+		case '\r':
+			if i+1 < len(sample) && sample[i+1] == '\n' {
+				continue
+			}
+			field = append(field, c)
+		default:
+			field = append(field, c)
+		}
+	}
+	if inQuote && !truncated {
+		return nil, quoted, false
+	}
+	// the last record of a truncated sample may be partial
+	if !truncated && (len(field) > 0 || len(rec) > 0) {
+		endRecord()
+	}
+	return recs, quoted, true
+}
+
+// hasHeader guesses whether the first record is a header row, the way Python's csv.Sniffer does: each column
+// whose values are all numbers, or all the same length, votes for a header if the first record doesn't fit.
+func hasHeader(recs [][]string, fields int) bool {
+	header := recs[0]
+	if len(header) != fields {
+		return false
+	}
+	var votes int
+	for i := 0; i < fields; i++ {
+		numeric, length := true, -1
+		var rows int
+		for _, r := range recs[1:] {
+			if len(r) != fields {
+				continue
+			}
+			rows++
+			if !isNumber(r[i]) {
+				numeric = false
+			}
+			switch {
+			case length == -1:
+				length = len(r[i])
+			case length != len(r[i]):
+				length = -2
+			}
+		}
+		switch {
+		case rows == 0:
+			return false
+		case numeric:
+			if isNumber(header[i]) {
+				votes--
+			} else {
+				votes++
+			}
+		case length >= 0:
+			if len(header[i]) == length {
+				votes--
+			} else {
+				votes++
+			}
+		}
+	}
+	return votes > 0
+}
+
+func isNumber(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// YAML
+
+var (
+	yamlKey  = regexp.MustCompile(`^(?:"[^"]*"|'[^']*'|[^\s#'"{}\[\],&*!|>%@` + "`" + `-][^:#]*?|-[^\s:#][^:#]*?)\s*:(?:\s|$)`)
+	yamlItem = regexp.MustCompile(`^-(?:\s|$)`)
+)
+
+// sniffYAML recognises YAML block mappings and sequences: each line must be a key, a list item, a comment, a
+// document marker or directive, or the continuation of a value (indented more than the key or item it belongs to).
+// It needs a key (or a directive or document marker, to tell a YAML list from e.g. a Markdown one) and either
+// nesting or several keys, so that a line or two of text with a colon isn't mistaken for YAML.
+func sniffYAML(lines []string) (Sniff, bool) {
+	var keys, items, docs int
+	var marked, nested bool
+	var kind string
+	indent := -1 // the indentation of the last key or item
+	for _, l := range lines {
+		trimmed := strings.TrimLeft(l, " ")
+		if strings.TrimSpace(trimmed) == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		in := len(l) - len(trimmed)
+		if strings.HasPrefix(trimmed, "\t") {
+			return Sniff{}, false // YAML isn't indented with tabs
+		}
+		if in == 0 {
+			switch {
+			case l == "---" || strings.HasPrefix(l, "--- "):
+				docs++
+				marked, indent = true, -1
+				continue
+			case l == "...":
+				marked, indent = true, -1
+				continue
+			case strings.HasPrefix(l, "%YAML") || strings.HasPrefix(l, "%TAG"):
+				marked = true
+				continue
+			}
+		}
+		item := yamlItem.MatchString(trimmed)
+		rest := trimmed
+		// an item may start a mapping e.g. "- name: value"
+		for yamlItem.MatchString(rest) {
+			rest = strings.TrimLeft(rest[1:], " ")
+		}
+		key := yamlKey.MatchString(rest)
+		switch {
+		case item || key:
+			if in > 0 {
+				nested = true
+			}
+			if kind == "" {
+				kind = "mapping"
+				if item {
+					kind = "sequence"
+				}
+			}
+			if item {
+				items++
+			}
+			if key {
+				keys++
+			}
+			indent = in
+		case indent >= 0 && in > indent:
+			// the continuation of a value
+		default:
+			return Sniff{}, false
+		}
+	}
+	if (keys == 0 && !marked) || keys+items == 0 || (!nested && !marked && keys+items < 3) {
+		return Sniff{}, false
+	}
+	basis := "YAML " + kind
+	if docs > 1 {
+		basis += ", " + plural(docs, "document")
+	}
+	return Sniff{"yaml", basis}, true
+}
+
+// Source code
+
+// interpreters maps the interpreters of #! lines (without version numbers) to languages
+var interpreters = map[string]string{
+	"sh":      "shell",
+	"bash":    "shell",
+	"dash":    "shell",
+	"ash":     "shell",
+	"ksh":     "shell",
+	"zsh":     "shell",
+	"csh":     "shell",
+	"tcsh":    "shell",
+	"python":  "python",
+	"pypy":    "python",
+	"perl":    "perl",
+	"ruby":    "ruby",
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"deno":    "javascript",
+	"php":     "php",
+	"Rscript": "r",
+	"tclsh":   "tcl",
+	"wish":    "tcl",
+	"lua":     "lua",
+	"awk":     "awk",
+	"gawk":    "awk",
+}
+
+// sniffInterpreter recognises scripts by their #! line (or PHP by its opening tag)
+func sniffInterpreter(sample []byte) (Sniff, bool) {
+	if bytes.HasPrefix(sample, []byte("<?php")) {
+		return Sniff{"php", "PHP opening tag"}, true
+	}
+	if !bytes.HasPrefix(sample, []byte("#!")) {
+		return Sniff{}, false
+	}
+	line := sample[2:]
+	if idx := bytes.IndexByte(line, '\n'); idx > -1 {
+		line = line[:idx]
+	}
+	args := strings.Fields(string(line))
+	if len(args) == 0 {
+		return Sniff{}, false
+	}
+	cmd := args[0][strings.LastIndex(args[0], "/")+1:]
+	// e.g. #!/usr/bin/env -S python3 -u
+	if cmd == "env" {
+		cmd = ""
+		for _, a := range args[1:] {
+			if !strings.HasPrefix(a, "-") && !strings.Contains(a, "=") {
+				cmd = a
+				break
+			}
+		}
+	}
+	lang, ok := interpreters[strings.TrimRight(cmd, "0123456789.")]
+	if !ok {
+		return Sniff{}, false
+	}
+	return Sniff{lang, "#! " + cmd}, true
+}
+
+type cue struct {
+	name   string
+	re     *regexp.Regexp
+	weight int
+}
+
+func cues(c ...interface{}) []cue {
+	ret := make([]cue, len(c)/3)
+	for i := range ret {
+		ret[i] = cue{c[i*3].(string), regexp.MustCompile("(?m)" + c[i*3+1].(string)), c[i*3+2].(int)}
+	}
+	return ret
+}
+
+// languages are recognised by the lines of code that are typical of them. Each cue is given as a name, a pattern
+// (matched against each line) and a weight.
+var languages = []struct {
+	id   string
+	cues []cue
+}{
+	{"c", cues(
+		"#include", `^#include\s*[<"][\w/.]+\.h[>"]`, 3,
+		"preprocessor directive", `^#\s*(define|ifdef|ifndef|endif|pragma)\b`, 2,
+		"function definition", `^(static\s+)?(int|void|char|unsigned|long|double|float|struct \w+)\s*\**\s*\w+\s*\([^;]*\)\s*\{?\s*$`, 2,
+		"libc call", `\b(malloc|free|printf|fprintf|memcpy|sizeof)\s*\(`, 1,
+	)},
+	{"cpp", cues(
+		"#include", `^#include\s*<\w+>`, 3,
+		"std::", `\bstd::`, 3,
+		"namespace or template", `^\s*(template\s*<|namespace \w+|using namespace\b)`, 3,
+		"access specifier", `^\s*(public|private|protected):\s*$`, 2,
+	)},
+	{"go", cues(
+		"package clause", `^package [a-z_][a-z0-9_]*\s*$`, 3,
+		"func", `^func (\([^)]*\) )?\w+\(`, 3,
+		"import block", `^import \($`, 2,
+		"type declaration", `^type \w+ (struct|interface|func)\b`, 2,
+		"err != nil", `\berr != nil\b`, 2,
+		":=", `\w := `, 1,
+	)},
+	{"java", cues(
+		"package statement", `^package [\w.]+;\s*$`, 4,
+		"import statement", `^import (static )?[\w.]+(\.\*)?;\s*$`, 2,
+		"modifiers", `^\s*(public|private|protected)\s+(static\s+)?(final\s+)?(class|interface|enum|void|[A-Z]\w*(<.*>)?)\s+\w+`, 2,
+		"annotation", `^\s*@(Override|Deprecated|SuppressWarnings)\b`, 2,
+		"System.out", `\bSystem\.(out|err)\.print`, 2,
+	)},
+	{"javascript", cues(
+		"require", `^\s*(const|let|var) \w+ = require\(['"]`, 3,
+		"import from", `^\s*import .+ from ['"][^'"]+['"];?\s*$`, 3,
+		"export", `^\s*export (default |const |function |class )`, 3,
+		"function", `\bfunction\s*\w*\s*\([^)]*\)\s*\{`, 2,
+		"console", `\bconsole\.(log|error|warn)\(`, 2,
+		"arrow function", `=>\s*\{`, 1,
+	)},
+	{"perl", cues(
+		"use strict", `^\s*use (strict|warnings);`, 4,
+		"my", `^\s*my [$@%]\w+`, 3,
+		"sub", `^\s*sub \w+\s*\{`, 3,
+	)},
+	{"php", cues(
+		"<?php", `<\?php`, 5,
+		"$this->", `\$this->`, 2,
+	)},
+	{"python", cues(
+		"def", `^\s*def \w+\(.*\)( -> [^:]+)?:\s*$`, 3,
+		"class", `^\s*class \w+(\(.*\))?:\s*$`, 3,
+		"__main__", `^if __name__ == ['"]__main__['"]:`, 4,
+		"import", `^(from [\w.]+ )?import [\w.]+( as \w+)?(, [\w.]+)*\s*$`, 1,
+		"block keyword", `^\s*(elif|except|finally|with)\b.*:\s*$`, 2,
+		"self", `\bself\.\w+`, 1,
+	)},
+	{"ruby", cues(
+		"require", `^\s*require(_relative)? ['"][\w/.]+['"]\s*$`, 2,
+		"def", `^\s*def (self\.)?\w+[?!]?(\(.*\))?\s*$`, 2,
+		"module or class", `^\s*(module|class) [A-Z]\w*( < [\w:]+)?\s*$`, 2,
+		"block", `\.each( do|\s*\{)\s*\|`, 2,
+		"end", `^\s*end\s*$`, 1,
+	)},
+	{"rust", cues(
+		"fn", `^\s*(pub(\(crate\))? )?fn \w+(<.*>)?\(`, 3,
+		"use", `^\s*use \w+(::[\w{}*, ]+)+;`, 2,
+		"let mut", `\blet mut\b`, 3,
+		"impl", `^\s*impl\b`, 2,
+		"attribute", `^\s*#\[(derive|cfg|test)\b`, 3,
+		"macro", `\b(println|format|vec)!\(`, 2,
+	)},
+	{"shell", cues(
+		"test", `^\s*(if|elif|while) \[\[? `, 2,
+		"fi, esac or done", `^\s*(fi|esac|done)\s*$`, 2,
+		"export", `^\s*export \w+=`, 2,
+		"then or do", `^\s*(then|do)\s*$`, 1,
+		"echo", `^\s*echo `, 1,
+	)},
+	{"sql", cues(
+		"CREATE", `(?i)^\s*create\s+(table|view|index|database|schema)\b`, 3,
+		"INSERT", `(?i)^\s*insert\s+into\b`, 3,
+		"SELECT", `(?i)^\s*select\s.+\sfrom\s`, 2,
+		"ALTER or DROP", `(?i)^\s*(alter|drop)\s+table\b`, 3,
+	)},
+}
+
+const (
+	maxCueCount = 3 // the most times a cue is counted, so that a cue repeated throughout a file doesn't decide it
+	minScore    = 5 // the least score of a language
+)
+
+// sniffSource recognises source code by scoring the cues of each language. The best language must have at least
+// minScore and half as much again as any other.
+func sniffSource(sample []byte) (Sniff, bool) {
+	type score struct {
+		id    string
+		score int
+		names []string
+	}
+	scores := make([]score, len(languages))
+	for i, l := range languages {
+		scores[i].id = l.id
+		for _, c := range l.cues {
+			n := len(c.re.FindAllIndex(sample, maxCueCount))
+			if n > 0 {
+				scores[i].score += n * c.weight
+				scores[i].names = append(scores[i].names, c.name)
+			}
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+	if scores[0].score < minScore || scores[0].score*2 < scores[1].score*3 {
+		return Sniff{}, false
+	}
+	return Sniff{scores[0].id, "source code cues: " + strings.Join(scores[0].names, ", ")}, true
+}
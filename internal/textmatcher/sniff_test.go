@@ -0,0 +1,73 @@
+package textmatcher
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+func TestSniff(t *testing.T) {
+	for _, test := range []struct {
+		label     string
+		text      string
+		truncated bool
+		id, basis string
+	}{
+		{"json", `{"a": [1, 2, {"b": null}], "c": "d"}`, false, "json", "JSON object, nesting depth 3"},
+		{"json array", "\n[1, 2, 3]\n", false, "json", "JSON array, nesting depth 1"},
+		{"truncated json", `{"a": [1, 2, {"b": "long str`, true, "json", "JSON object, nesting depth 3"},
+		{"unclosed json", `{"a": [1, 2`, false, "", ""},
+		{"jsonl", "{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3}\n", false, "jsonl", "JSON Lines, 3 records"},
+		{"json scalar", `"just a string"`, false, "", ""},
+		{"csv", "name,age,city\nAnne,32,Perth\nBob,45,\"Alice Springs, NT\"\n", false, "csv",
+			"delimiter ',', quote '\"', header row; 3 fields, 3 records"},
+		{"semicolons", "1;2;3\n4;5;6\n7;8;9\n", false, "csv", "delimiter ';', no quoting, no header row; 3 fields, 3 records"},
+		{"tsv", "id\tvalue\n1\t0.5\n2\t0.75\n3\t1.25\n", false, "tsv", "delimiter tab, no quoting, header row; 2 fields, 4 records"},
+		{"truncated csv", "a,b\n1,2\n3,4\n5,\"unfinished", true, "csv", "delimiter ',', quote '\"', header row; 2 fields, 3 records"},
+		{"yaml", "---\nname: siegfried\nversion: 1.11\nauthors:\n  - name: Richard\n    role: author\n", false, "yaml", "YAML mapping"},
+		{"yaml documents", "--- \n- a\n- b\n---\n- c\n", false, "yaml", "YAML sequence, 2 documents"},
+		{"markdown list", "- one\n- two\n- three\n", false, "", ""},
+		{"tabbed yaml", "a:\n\tb: c\n", false, "", ""},
+		{"shebang", "#!/usr/bin/env python3\nprint('hello')\n", false, "python", "#! python3"},
+		{"shell", "#!/bin/bash\necho hello\n", false, "shell", "#! bash"},
+		{"go", "package main\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {\n\tx := 1\n\tfmt.Println(x)\n}\n", false, "go",
+			"source code cues: package clause, func, import block, :="},
+		{"python", "import os\n\n\nclass A:\n    def f(self):\n        return self.x\n", false, "python",
+			"source code cues: def, class, import, self"},
+		{"c", "#include <stdio.h>\n\nint main(void)\n{\n\tprintf(\"hi\");\n\treturn 0;\n}\n", false, "c",
+			"source code cues: #include, function definition, libc call"},
+		{"sql", "CREATE TABLE t (a int);\nINSERT INTO t VALUES (1);\nSELECT a FROM t;\n", false, "sql",
+			"source code cues: CREATE, INSERT, SELECT"},
+		{"prose", "It was a dark and stormy night; the rain fell in torrents.\nExcept at occasional intervals, when it was checked.\n", false, "", ""},
+	} {
+		s := sniff([]byte(test.text), test.truncated)
+		if s.ID != test.id || s.Basis != test.basis {
+			t.Errorf("%s: expecting %s %q, got %s %q", test.label, test.id, test.basis, s.ID, s.Basis)
+		}
+	}
+}
+
+func TestSniffResult(t *testing.T) {
+	m, _ := new(2)
+	buf, err := siegreader.New().Get(strings.NewReader("\xEF\xBB\xBFa,b\n1,2\n"))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	res, _ := m.Identify("", buf)
+	var n int
+	for r := range res {
+		n++
+		s, ok := r.(Sniffer)
+		if !ok {
+			t.Fatal("expecting text matcher results to be sniffers")
+		}
+		if sn := s.Sniff(); sn.ID != "csv" {
+			t.Errorf("expecting a csv sniff, got %v", sn)
+		}
+	}
+	if n != 2 {
+		t.Errorf("expecting 2 results, got %d", n)
+	}
+}
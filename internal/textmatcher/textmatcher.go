@@ -55,6 +55,7 @@ func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, i
 type result struct {
 	idx   int
 	basis string
+	sn    *sniffer
 }
 
 func (r result) Index() int {
@@ -65,15 +66,22 @@ func (r result) Basis() string {
 	return r.basis
 }
 
+// Sniff runs the sniffers over the file, once for all of the results sent for it.
+func (r result) Sniff() Sniff {
+	return r.sn.sniff()
+}
+
 func (m *Matcher) Identify(na string, buf *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
 	if *m > 0 {
 		tt := buf.Text()
 		if tt != characterize.DATA {
 			res := make(chan core.Result, *m)
+			sn := &sniffer{buf: buf, tt: tt}
 			for i := 1; i < int(*m)+1; i++ {
 				res <- result{
 					idx:   i,
 					basis: "text match " + tt.String(),
+					sn:    sn,
 				}
 			}
 			close(res)
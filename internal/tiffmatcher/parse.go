@@ -0,0 +1,347 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiffmatcher
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// TIFF tags read by the parser or reported in a basis
+const (
+	newSubfileTypeTag = 254
+	imageWidthTag     = 256
+	tileWidthTag      = 322
+	tileLengthTag     = 323
+	subIFDsTag        = 330
+	exifIFDTag        = 34665
+	geoKeyDirTag      = 34735
+	exifVersionTag    = 36864
+	dngVersionTag     = 50706
+)
+
+var tagNames = map[uint16]string{
+	newSubfileTypeTag: "NewSubfileType",
+	imageWidthTag:     "ImageWidth",
+	257:               "ImageLength",
+	tileWidthTag:      "TileWidth",
+	tileLengthTag:     "TileLength",
+	subIFDsTag:        "SubIFDs",
+	33550:             "ModelPixelScale",
+	33922:             "ModelTiepoint",
+	exifIFDTag:        "ExifIFD",
+	geoKeyDirTag:      "GeoKeyDirectory",
+	34853:             "GPSInfo",
+	exifVersionTag:    "ExifVersion",
+	37398:             "TIFF/EPStandardID",
+	dngVersionTag:     "DNGVersion",
+}
+
+func tagName(tag uint16) string {
+	if n, ok := tagNames[tag]; ok {
+		return n
+	}
+	return fmt.Sprintf("tag %d", tag)
+}
+
+// field types, and the size in bytes of each of their values
+const (
+	byteType      = 1
+	asciiType     = 2
+	shortType     = 3
+	longType      = 4
+	sbyteType     = 6
+	undefinedType = 7
+	ifdType       = 13
+	long8Type     = 16
+	ifd8Type      = 18
+)
+
+var typeNames = [...]string{"", "BYTE", "ASCII", "SHORT", "LONG", "RATIONAL", "SBYTE", "UNDEFINED", "SSHORT", "SLONG",
+	"SRATIONAL", "FLOAT", "DOUBLE", "IFD", "", "", "LONG8", "SLONG8", "IFD8"}
+
+var typeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8, 4, 0, 0, 8, 8, 8}
+
+func validType(typ uint16, big bool) bool {
+	if big {
+		return typ > 0 && int(typ) < len(typeNames) && typeNames[typ] != ""
+	}
+	return typ > 0 && typ <= ifdType
+}
+
+// byteValue reports whether the values of a type are single bytes, so can be compared regardless of byte order
+func byteValue(typ uint16) bool {
+	return typ == byteType || typ == asciiType || typ == sbyteType || typ == undefinedType
+}
+
+const (
+	maxIFDs    = 64   // the most IFDs read
+	maxEntries = 4096 // the most entries read in an IFD
+)
+
+type entry struct {
+	typ   uint16
+	count uint64
+	value []byte // the entry's value field: four bytes, or eight in a BigTIFF
+}
+
+type ifd map[uint16]entry
+
+type tiff struct {
+	order binary.ByteOrder
+	big   bool
+	ifds  []ifd // all the IFDs read: IFD0 first, then those chained from it, SubIFDs and EXIF IFDs
+}
+
+// parse reads the header of a TIFF or BigTIFF and the IFDs it can reach from IFD0: by the next IFD offsets, SubIFDs
+// and ExifIFD tags. It reports false if the file isn't a TIFF or IFD0 can't be read.
+func parse(b *siegreader.Buffer) (*tiff, bool) {
+	buf, _ := b.Slice(0, 16)
+	if len(buf) < 8 {
+		return nil, false
+	}
+	t := &tiff{}
+	switch string(buf[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, false
+	}
+	var off uint64
+	switch t.order.Uint16(buf[2:]) {
+	case 42:
+		off = uint64(t.order.Uint32(buf[4:]))
+	case 43:
+		// a BigTIFF has an offset size of 8 and 64 bit offsets
+		if len(buf) < 16 || t.order.Uint16(buf[4:]) != 8 || t.order.Uint16(buf[6:]) != 0 {
+			return nil, false
+		}
+		t.big = true
+		off = t.order.Uint64(buf[8:])
+	default:
+		return nil, false
+	}
+	queue := []uint64{off}
+	seen := make(map[uint64]bool)
+	for len(queue) > 0 && len(t.ifds) < maxIFDs {
+		off, queue = queue[0], queue[1:]
+		if off < 8 || off > math.MaxInt64 || seen[off] {
+			continue
+		}
+		seen[off] = true
+		d, next, ok := t.ifd(b, int64(off))
+		if !ok {
+			if len(t.ifds) == 0 {
+				return nil, false
+			}
+			continue
+		}
+		t.ifds = append(t.ifds, d)
+		queue = append(queue, next)
+		queue = append(queue, t.offsets(b, d[subIFDsTag])...)
+		queue = append(queue, t.offsets(b, d[exifIFDTag])...)
+	}
+	return t, len(t.ifds) > 0
+}
+
+// ifd reads the entries of an IFD and the offset of the next IFD
+func (t *tiff) ifd(b *siegreader.Buffer, off int64) (ifd, uint64, bool) {
+	cntLen, entLen, offLen := 2, 12, 4
+	if t.big {
+		cntLen, entLen, offLen = 8, 20, 8
+	}
+	buf, _ := b.Slice(off, cntLen)
+	if len(buf) < cntLen {
+		return nil, 0, false
+	}
+	var n uint64
+	if t.big {
+		n = t.order.Uint64(buf)
+	} else {
+		n = uint64(t.order.Uint16(buf))
+	}
+	if n == 0 || n > maxEntries {
+		return nil, 0, false
+	}
+	l := int(n) * entLen
+	buf, _ = b.Slice(off+int64(cntLen), l+offLen)
+	if len(buf) < l {
+		return nil, 0, false
+	}
+	buf = append([]byte(nil), buf...)
+	d := make(ifd, n)
+	for i := 0; i < int(n); i++ {
+		e := buf[i*entLen : (i+1)*entLen]
+		tag := t.order.Uint16(e)
+		if _, ok := d[tag]; ok {
+			continue
+		}
+		en := entry{typ: t.order.Uint16(e[2:])}
+		if t.big {
+			en.count, en.value = t.order.Uint64(e[4:]), e[12:]
+		} else {
+			en.count, en.value = uint64(t.order.Uint32(e[4:])), e[8:]
+		}
+		d[tag] = en
+	}
+	var next uint64
+	if len(buf) >= l+offLen {
+		if t.big {
+			next = t.order.Uint64(buf[l:])
+		} else {
+			next = uint64(t.order.Uint32(buf[l:]))
+		}
+	}
+	return d, next, true
+}
+
+// offsets reads the IFD offsets of a SubIFDs or ExifIFD entry
+func (t *tiff) offsets(b *siegreader.Buffer, e entry) []uint64 {
+	if e.count == 0 || e.count > maxIFDs {
+		return nil
+	}
+	var sz int
+	switch e.typ {
+	case longType, ifdType:
+		sz = 4
+	case long8Type, ifd8Type:
+		sz = 8
+	default:
+		return nil
+	}
+	l := int(e.count) * sz
+	buf := e.value
+	// values that don't fit in the value field are at the offset it holds
+	if l > len(buf) {
+		off, ok := t.offset(e.value)
+		if !ok {
+			return nil
+		}
+		if buf, _ = b.Slice(off, l); len(buf) < l {
+			return nil
+		}
+	}
+	ret := make([]uint64, e.count)
+	for i := range ret {
+		if sz == 4 {
+			ret[i] = uint64(t.order.Uint32(buf[i*4:]))
+		} else {
+			ret[i] = t.order.Uint64(buf[i*8:])
+		}
+	}
+	return ret
+}
+
+func (t *tiff) offset(value []byte) (int64, bool) {
+	if t.big {
+		off := t.order.Uint64(value)
+		return int64(off), off <= math.MaxInt64
+	}
+	return int64(t.order.Uint32(value)), true
+}
+
+// uint reads the value of a SHORT, LONG or LONG8 entry with a single value
+func (t *tiff) uint(e entry) (uint64, bool) {
+	if e.count != 1 {
+		return 0, false
+	}
+	switch e.typ {
+	case shortType:
+		return uint64(t.order.Uint16(e.value)), true
+	case longType:
+		return uint64(t.order.Uint32(e.value)), true
+	case long8Type:
+		if t.big {
+			return t.order.Uint64(e.value), true
+		}
+	}
+	return 0, false
+}
+
+func (t *tiff) has(tag uint16) bool {
+	for _, d := range t.ifds {
+		if _, ok := d[tag]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// features describes the structure of a TIFF and the key tags it has, e.g. whether it is tiled or pyramidal,
+// or a GeoTIFF or DNG
+func (t *tiff) features() []string {
+	ret := make([]string, 0, 6)
+	if t.order == binary.LittleEndian {
+		ret = append(ret, "little-endian")
+	} else {
+		ret = append(ret, "big-endian")
+	}
+	if t.big {
+		ret = append(ret, "BigTIFF")
+	}
+	var images, reduced int
+	for _, d := range t.ifds {
+		if _, ok := d[imageWidthTag]; !ok {
+			continue // e.g. an EXIF IFD
+		}
+		images++
+		if v, ok := t.uint(d[newSubfileTypeTag]); ok && v&1 == 1 {
+			reduced++
+		}
+	}
+	if images == 1 {
+		ret = append(ret, "1 image")
+	} else {
+		ret = append(ret, fmt.Sprintf("%d images", images))
+	}
+	ifd0 := t.ifds[0]
+	w, wok := t.uint(ifd0[tileWidthTag])
+	l, lok := t.uint(ifd0[tileLengthTag])
+	if wok && lok {
+		ret = append(ret, fmt.Sprintf("tiled %dx%d", w, l))
+	}
+	// a pyramid is a full resolution image with reduced resolution versions of it (but e.g. a DNG's IFD0 is
+	// itself a reduced resolution preview)
+	if v, _ := t.uint(ifd0[newSubfileTypeTag]); v&1 == 0 && reduced > 0 {
+		ret = append(ret, fmt.Sprintf("pyramidal (%d levels)", reduced+1))
+	}
+	if t.has(geoKeyDirTag) {
+		ret = append(ret, "GeoTIFF")
+	}
+	if e, ok := ifd0[dngVersionTag]; ok && e.typ == byteType && e.count == 4 {
+		ret = append(ret, fmt.Sprintf("DNG %d.%d.%d.%d", e.value[0], e.value[1], e.value[2], e.value[3]))
+	}
+	for _, d := range t.ifds {
+		if e, ok := d[exifVersionTag]; ok && e.typ == undefinedType && e.count == 4 && printable(e.value[:4]) {
+			ret = append(ret, "EXIF "+string(e.value[:4]))
+			break
+		}
+	}
+	return ret
+}
+
+func printable(byts []byte) bool {
+	for _, c := range byts {
+		if c < 0x20 || c > 0x7E {
+			return false
+		}
+	}
+	return true
+}
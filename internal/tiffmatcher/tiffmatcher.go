@@ -0,0 +1,354 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tiffmatcher refines the matches of TIFF based formats (e.g. fmt/353) by reading the IFDs of a TIFF.
+// It matches the IFD entries (e.g. a DNGVersion or GeoKeyDirectory tag) of formats like DNG and GeoTIFF wherever
+// they are in a file, when byte signatures only find them near its start, and reports the structure of a TIFF
+// (BigTIFF, tiled or pyramidal) and its key tags as the basis of a match.
+package tiffmatcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/priority"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// Entry is an IFD entry that a TIFF must have. The count is matched if it isn't zero, and the value if it isn't nil
+// (values are only matched for types with single byte values e.g. BYTE or UNDEFINED).
+type Entry struct {
+	Tag   uint16
+	Type  uint16
+	Count uint64
+	Value []byte
+}
+
+func (e Entry) String() string {
+	str := tagName(e.Tag) + " " + typeNames[e.Type]
+	if e.Count > 0 {
+		str += fmt.Sprintf("[%d]", e.Count)
+	}
+	switch {
+	case e.Value == nil:
+	case (e.Type == asciiType || e.Type == undefinedType) && printable(e.Value):
+		str += fmt.Sprintf(" = %q", e.Value)
+	case e.Type == byteType || e.Type == sbyteType:
+		strs := make([]string, len(e.Value))
+		for i, v := range e.Value {
+			strs[i] = fmt.Sprint(v)
+		}
+		str += " = " + strings.Join(strs, ".")
+	default:
+		str += fmt.Sprintf(" = 0x%X", e.Value)
+	}
+	return str
+}
+
+func (e Entry) match(t *tiff) bool {
+	for _, d := range t.ifds {
+		en, ok := d[e.Tag]
+		if !ok || en.typ != e.Type || (e.Count > 0 && en.count != e.Count) {
+			continue
+		}
+		if e.Value == nil || bytes.HasPrefix(en.value, e.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Signature identifies a TIFF (or, if BigTIFF, a BigTIFF) that has all the IFD entries of the signature, in any IFD.
+// A signature without entries matches any TIFF.
+type Signature struct {
+	BigTIFF bool
+	Entries []Entry
+}
+
+func (s Signature) String() string {
+	strs := make([]string, 0, len(s.Entries)+1)
+	if s.BigTIFF {
+		strs = append(strs, "BigTIFF")
+	}
+	for _, e := range s.Entries {
+		strs = append(strs, e.String())
+	}
+	if len(strs) == 0 {
+		return "TIFF"
+	}
+	return strings.Join(strs, "; ")
+}
+
+func (s Signature) match(t *tiff) bool {
+	if s.BigTIFF != t.big {
+		return false
+	}
+	for _, e := range s.Entries {
+		if !e.match(t) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	headers = [][]byte{[]byte("II*\x00"), []byte("MM\x00*")}
+	// BigTIFF headers, with the offset size of 8
+	bigHeaders = [][]byte{[]byte("II+\x00\x08\x00\x00\x00"), []byte("MM\x00+\x00\x08\x00\x00")}
+)
+
+// header reads the byte order of a TIFF or BigTIFF header (a BigTIFF header may omit its offset size)
+func header(seq []byte) (binary.ByteOrder, bool, bool) {
+	for i, h := range headers {
+		if bytes.Equal(seq, h) {
+			return [2]binary.ByteOrder{binary.LittleEndian, binary.BigEndian}[i], false, true
+		}
+	}
+	for i, h := range bigHeaders {
+		if bytes.Equal(seq, h) || bytes.Equal(seq, h[:4]) {
+			return [2]binary.ByteOrder{binary.LittleEndian, binary.BigEndian}[i], true, true
+		}
+	}
+	return nil, false, false
+}
+
+// Signatures derives TIFF signatures from byte signatures that begin with a TIFF or BigTIFF header. A signature
+// that is just the header (e.g. PRONOM's fmt/353) matches any TIFF. Otherwise, the signature's sequences must be IFD
+// entries in the byte order of the header (e.g. the DNGVersion entry of PRONOM's DNG signatures): at least their
+// tag and type, and then their count and value. Sequences of fewer than four bytes (part of an entry) are ignored,
+// and a signature with any other sequence is left to the byte matcher. It returns the signatures and the
+// corresponding IDs.
+func Signatures(sigs []frames.Signature, ids []string) ([]Signature, []string) {
+	var ret []Signature
+	var rids []string
+	seen := make(map[string]bool)
+	for i, sig := range sigs {
+		if len(sig) == 0 || sig[0].Orientation() != frames.BOF || sig[0].Min != 0 || sig[0].Max != 0 {
+			continue
+		}
+		seq, ok := sig[0].Pattern.(patterns.Sequence)
+		if !ok {
+			continue
+		}
+		order, big, ok := header(seq)
+		if !ok {
+			continue
+		}
+		s := Signature{BigTIFF: big}
+		for _, f := range sig[1:] {
+			seq, ok := f.Pattern.(patterns.Sequence)
+			if !ok {
+				s.Entries = nil
+				break
+			}
+			if len(seq) < 4 {
+				continue
+			}
+			e, ok := entrySeq(seq, order, big)
+			if !ok {
+				s.Entries = nil
+				break
+			}
+			s.Entries = append(s.Entries, e)
+		}
+		if len(sig) > 1 && len(s.Entries) == 0 {
+			continue
+		}
+		// byte signatures are often repeated for each byte order, or for BOF and EOF offsets
+		if key := ids[i] + s.String(); !seen[key] {
+			seen[key] = true
+			ret, rids = append(ret, s), append(rids, ids[i])
+		}
+	}
+	return ret, rids
+}
+
+// entrySeq reads the tag and type and, if the sequence is long enough, the count and value of an IFD entry
+func entrySeq(seq []byte, order binary.ByteOrder, big bool) (Entry, bool) {
+	cntOff, valOff, entLen := 4, 8, 12
+	if big {
+		cntOff, valOff, entLen = 4, 12, 20
+	}
+	e := Entry{Tag: order.Uint16(seq), Type: order.Uint16(seq[2:])}
+	if e.Tag == 0 || !validType(e.Type, big) || len(seq) > entLen {
+		return Entry{}, false
+	}
+	if len(seq) < valOff {
+		return e, true
+	}
+	if big {
+		e.Count = order.Uint64(seq[cntOff:])
+	} else {
+		e.Count = uint64(order.Uint32(seq[cntOff:]))
+	}
+	if byteValue(e.Type) && e.Count > 0 && e.Count <= uint64(entLen-valOff) && len(seq) >= valOff+int(e.Count) {
+		e.Value = append([]byte(nil), seq[valOff:valOff+int(e.Count)]...)
+	}
+	return e, true
+}
+
+type Matcher struct {
+	sigs       []Signature
+	priorities *priority.Set
+}
+
+func Load(ls *persist.LoadSaver) core.Matcher {
+	le := ls.LoadSmallInt()
+	if le == 0 {
+		return nil
+	}
+	m := &Matcher{sigs: make([]Signature, le)}
+	for i := range m.sigs {
+		m.sigs[i].BigTIFF = ls.LoadBool()
+		m.sigs[i].Entries = make([]Entry, ls.LoadSmallInt())
+		for j := range m.sigs[i].Entries {
+			m.sigs[i].Entries[j] = Entry{
+				Tag:   uint16(ls.LoadInt()),
+				Type:  uint16(ls.LoadInt()),
+				Count: uint64(ls.LoadInt()),
+				Value: ls.LoadBytes(),
+			}
+		}
+	}
+	m.priorities = priority.Load(ls)
+	return m
+}
+
+func Save(c core.Matcher, ls *persist.LoadSaver) {
+	if c == nil {
+		ls.SaveSmallInt(0)
+		return
+	}
+	m := c.(*Matcher)
+	ls.SaveSmallInt(len(m.sigs))
+	if len(m.sigs) == 0 {
+		return
+	}
+	for _, s := range m.sigs {
+		ls.SaveBool(s.BigTIFF)
+		ls.SaveSmallInt(len(s.Entries))
+		for _, e := range s.Entries {
+			ls.SaveInt(int(e.Tag))
+			ls.SaveInt(int(e.Type))
+			ls.SaveInt(int(e.Count))
+			ls.SaveBytes(e.Value)
+		}
+	}
+	m.priorities.Save(ls)
+}
+
+type SignatureSet []Signature
+
+// MarshalJSON encodes a Matcher for export.
+func (m *Matcher) MarshalJSON() ([]byte, error) {
+	sigs := make([]string, len(m.sigs))
+	for i, s := range m.sigs {
+		sigs[i] = s.String()
+	}
+	return json.Marshal(struct {
+		Signatures []string      `json:"signatures"`
+		Priorities *priority.Set `json:"priorities"`
+	}{sigs, m.priorities})
+}
+
+func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, int, error) {
+	sigs, ok := ss.(SignatureSet)
+	if !ok {
+		return nil, -1, fmt.Errorf("TIFFmatcher: %w", core.ErrSignatureSet)
+	}
+	if len(sigs) == 0 {
+		return c, 0, nil
+	}
+	var m *Matcher
+	if c == nil {
+		m = &Matcher{priorities: &priority.Set{}}
+	} else {
+		m = c.(*Matcher)
+	}
+	m.sigs = append(m.sigs, sigs...)
+	// add priorities
+	m.priorities.Add(p, len(sigs), 0, 0)
+	return m, len(m.sigs), nil
+}
+
+type result struct {
+	idx      int
+	sig      Signature
+	features []string
+}
+
+func (r result) Index() int {
+	return r.idx
+}
+
+func (r result) Basis() string {
+	basis := "tiff " + strings.Join(r.features, ", ")
+	if len(r.sig.Entries) > 0 {
+		basis += " (matched " + r.sig.String() + ")"
+	}
+	return basis
+}
+
+// Specific reports whether the result matched IFD entries, rather than just a TIFF header.
+func (r result) Specific() bool {
+	return len(r.sig.Entries) > 0
+}
+
+func (m Matcher) Identify(na string, b *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	t, ok := parse(b)
+	if !ok {
+		res := make(chan core.Result)
+		close(res)
+		return res, nil
+	}
+	features := t.features()
+	if config.Debug() {
+		fmt.Fprintf(config.Out(), "tiff %s (%d IFDs)\n", strings.Join(features, ", "), len(t.ifds))
+	}
+	waitset := m.priorities.WaitSet(hints...)
+	var hits []core.Result
+	for i, s := range m.sigs {
+		if s.match(t) && waitset.Check(i) {
+			if config.Debug() {
+				fmt.Fprintf(config.Out(), "sending tiff match %s\n", s)
+			}
+			hits = append(hits, result{i, s, features})
+			if waitset.Put(i) {
+				break
+			}
+		}
+	}
+	res := make(chan core.Result, len(hits))
+	for _, h := range hits {
+		res <- h
+	}
+	close(res)
+	return res, nil
+}
+
+func (m Matcher) String() string {
+	strs := make([]string, len(m.sigs))
+	for i, s := range m.sigs {
+		strs[i] = fmt.Sprintf("%d: %s", i, s)
+	}
+	return fmt.Sprintf("TIFF matcher:\n%s\n", strings.Join(strs, "\n"))
+}
@@ -0,0 +1,190 @@
+package tiffmatcher
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/bytematcher/patterns"
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+type tag struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte // up to four bytes, in the byte order of the file
+}
+
+// tif makes a TIFF with a chain of IFDs
+func tif(order binary.ByteOrder, ifds ...[]tag) []byte {
+	buf := []byte("II*\x00\x08\x00\x00\x00")
+	if order == binary.BigEndian {
+		buf = []byte("MM\x00*\x00\x00\x00\x08")
+	}
+	for i, d := range ifds {
+		ifd := make([]byte, 2+len(d)*12+4)
+		order.PutUint16(ifd, uint16(len(d)))
+		for j, t := range d {
+			e := ifd[2+j*12:]
+			order.PutUint16(e, t.tag)
+			order.PutUint16(e[2:], t.typ)
+			order.PutUint32(e[4:], t.count)
+			copy(e[8:12], t.value)
+		}
+		if i < len(ifds)-1 {
+			order.PutUint32(ifd[len(ifd)-4:], uint32(len(buf)+len(ifd)))
+		}
+		buf = append(buf, ifd...)
+	}
+	return buf
+}
+
+func long(order binary.ByteOrder, tg uint16, v uint32) tag {
+	byts := make([]byte, 4)
+	order.PutUint32(byts, v)
+	return tag{tg, longType, 1, byts}
+}
+
+// a BigTIFF with a single IFD of one entry
+func bigtif() []byte {
+	buf := []byte("II+\x00\x08\x00\x00\x00\x10\x00\x00\x00\x00\x00\x00\x00")
+	ifd := make([]byte, 8+20+8)
+	binary.LittleEndian.PutUint64(ifd, 1)
+	binary.LittleEndian.PutUint16(ifd[8:], imageWidthTag)
+	binary.LittleEndian.PutUint16(ifd[10:], long8Type)
+	binary.LittleEndian.PutUint64(ifd[12:], 1)
+	binary.LittleEndian.PutUint64(ifd[20:], 100000)
+	return append(buf, ifd...)
+}
+
+var (
+	le = binary.LittleEndian
+	be = binary.BigEndian
+
+	pyramid = tif(le,
+		[]tag{long(le, imageWidthTag, 1024), long(le, tileWidthTag, 256), long(le, tileLengthTag, 256)},
+		[]tag{long(le, newSubfileTypeTag, 1), long(le, imageWidthTag, 512)},
+		[]tag{long(le, newSubfileTypeTag, 1), long(le, imageWidthTag, 256)},
+	)
+	dng = tif(be,
+		[]tag{long(be, newSubfileTypeTag, 1), long(be, imageWidthTag, 256), {dngVersionTag, byteType, 4, []byte{1, 4, 0, 0}}},
+		[]tag{long(be, imageWidthTag, 6000)},
+	)
+	geotiff = tif(le, []tag{long(le, imageWidthTag, 100), {geoKeyDirTag, shortType, 32, []byte{0xFF, 0, 0, 0}}})
+	// IFD0 points to an EXIF IFD that follows it
+	exif = tif(le,
+		[]tag{long(le, imageWidthTag, 100), long(le, exifIFDTag, 8+2+2*12+4)},
+		[]tag{{exifVersionTag, undefinedType, 4, []byte("0230")}},
+	)
+)
+
+func buffer(t *testing.T, byts []byte) *siegreader.Buffer {
+	b, err := siegreader.New().Get(bytes.NewReader(byts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		byts   []byte
+		expect string
+	}{
+		{"pyramid", pyramid, "little-endian, 3 images, tiled 256x256, pyramidal (3 levels)"},
+		{"dng", dng, "big-endian, 2 images, DNG 1.4.0.0"},
+		{"geotiff", geotiff, "little-endian, 1 image, GeoTIFF"},
+		{"exif", exif, "little-endian, 1 image, EXIF 0230"},
+		{"bigtiff", bigtif(), "little-endian, BigTIFF, 1 image"},
+		{"truncated", dng[:20], ""},
+		{"not tiff", []byte("II*\x01\x08\x00\x00\x00"), ""},
+	} {
+		tf, ok := parse(buffer(t, test.byts))
+		var got string
+		if ok {
+			got = strings.Join(tf.features(), ", ")
+		}
+		if got != test.expect {
+			t.Errorf("%s: expecting %q, got %q", test.name, test.expect, got)
+		}
+	}
+}
+
+var sigs = SignatureSet{
+	{},
+	{Entries: []Entry{{Tag: dngVersionTag, Type: byteType, Count: 4, Value: []byte{1, 4, 0, 0}}}},
+	{Entries: []Entry{{Tag: dngVersionTag, Type: byteType, Count: 4, Value: []byte{1, 3, 0, 0}}}},
+	{Entries: []Entry{{Tag: geoKeyDirTag, Type: shortType}}},
+	{BigTIFF: true},
+}
+
+func TestIdentify(t *testing.T) {
+	m, l, err := Add(nil, sigs, nil)
+	if err != nil || l != len(sigs) {
+		t.Fatalf("expecting a matcher with %d signatures, got %d %v", len(sigs), l, err)
+	}
+	for _, test := range []struct {
+		name   string
+		byts   []byte
+		expect string
+	}{
+		{"dng", dng, "0: tiff big-endian, 2 images, DNG 1.4.0.0 | 1: tiff big-endian, 2 images, DNG 1.4.0.0 (matched DNGVersion BYTE[4] = 1.4.0.0)"},
+		{"geotiff", geotiff, "0: tiff little-endian, 1 image, GeoTIFF | 3: tiff little-endian, 1 image, GeoTIFF (matched GeoKeyDirectory SHORT)"},
+		{"bigtiff", bigtif(), "4: tiff little-endian, BigTIFF, 1 image"},
+		{"not tiff", []byte("RIFF....WAVEfmt "), ""},
+	} {
+		res, err := m.Identify("", buffer(t, test.byts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var hits []string
+		for r := range res {
+			hits = append(hits, strconv.Itoa(r.Index())+": "+r.Basis())
+		}
+		if got := strings.Join(hits, " | "); got != test.expect {
+			t.Errorf("%s: expecting %q, got %q", test.name, test.expect, got)
+		}
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	bof := func(s string) frames.Frame { return frames.NewFrame(frames.BOF, patterns.Sequence(s), 0, 0) }
+	prev := func(s string) frames.Frame { return frames.NewFrame(frames.PREV, patterns.Sequence(s), 0, 4080) }
+	bsigs := []frames.Signature{
+		{bof("II*\x00")},
+		{bof("II*\x00"), prev("\x12\xc6\x01\x00\x04\x00\x00\x00\x01\x04\x00\x00")},
+		{bof("MM\x00*"), prev("\xc6\x12\x00\x01\x00\x00\x00\x04\x01\x04\x00\x00")},
+		{bof("II*\x00"), prev("\xaf\x87\x03\x00"), frames.NewFrame(frames.PREV, patterns.Sequence("\x00\x00\x00"), 1, 1)},
+		{bof("MM\x00*"), prev("\x87\xaf\x00\x03\x00\x00\x00")},
+		{bof("II+\x00\x08\x00\x00\x00")},
+		{bof("II*\x00"), prev("\x7c\x92"), prev("Nikon\x00")},
+		{bof("II*\x00\x10\x00\x00\x00CR\x02\x00")},
+		{bof("RIFF"), prev("\xaf\x87\x03\x00")},
+	}
+	ss, ids := Signatures(bsigs, []string{"fmt/353", "fmt/730", "fmt/730", "fmt/155", "fmt/155", "fmt/1312", "fmt/202", "fmt/592", "fmt/x"})
+	strs := make([]string, len(ss))
+	for i, s := range ss {
+		strs[i] = s.String()
+	}
+	expect := "TIFF | DNGVersion BYTE[4] = 1.4.0.0 | GeoKeyDirectory SHORT | BigTIFF"
+	if got := strings.Join(strs, " | "); got != expect || strings.Join(ids, ",") != "fmt/353,fmt/730,fmt/155,fmt/1312" {
+		t.Errorf("expecting %s, got %s %v", expect, got, ids)
+	}
+}
+
+func TestIO(t *testing.T) {
+	fm, _, _ := Add(nil, sigs, nil)
+	str := fm.String()
+	saver := persist.NewLoadSaver(nil)
+	Save(fm, saver)
+	loader := persist.NewLoadSaver(saver.Bytes())
+	newfm := Load(loader)
+	if str2 := newfm.String(); str != str2 {
+		t.Errorf("Load TIFF matcher: expecting first matcher (%v), to equal second matcher (%v)", str, str2)
+	}
+}
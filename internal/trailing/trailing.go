@@ -0,0 +1,148 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trailing finds data after the logical end of formats that mark their end: e.g. data appended after a PDF's
+// last %%EOF or after a zip file's end of central directory record. Files with trailing data may be polyglots
+// (valid in more than one format) or may hide content from tools that only read the first format.
+package trailing
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+// Min is the number of bytes, not counting whitespace or NUL padding, that make trailing data significant.
+const Min = 32
+
+const chunk = 1 << 16 // bytes read at a time when searching backwards for an end marker
+
+// an ender finds the logical end of a format, given the size of the file. It returns -1 if it can't find the end.
+type ender func(b *siegreader.Buffer, sz int64) int64
+
+var formats = []struct {
+	name  string
+	magic []byte
+	end   ender
+}{
+	{"PDF", []byte("%PDF-"), pdfEnd},
+	{"zip", []byte("PK\x03\x04"), zipEnd},
+	{"PNG", []byte("\x89PNG\r\n\x1a\n"), pngEnd},
+}
+
+// Check reports the number of bytes of significant trailing data in a file, and the name of the format it follows.
+// It returns 0 if the file isn't one of the formats it knows, or has no significant trailing data.
+func Check(b *siegreader.Buffer) (int64, string) {
+	head, err := b.Slice(0, 8)
+	if err != nil {
+		return 0, ""
+	}
+	for _, f := range formats {
+		if !bytes.HasPrefix(head, f.magic) {
+			continue
+		}
+		sz := b.SizeNow()
+		end := f.end(b, sz)
+		if end < 0 || end >= sz || !significant(b, end, sz) {
+			return 0, ""
+		}
+		return sz - end, f.name
+	}
+	return 0, ""
+}
+
+// significant reports whether the bytes from end to sz are more than Min bytes of padding
+func significant(b *siegreader.Buffer, end, sz int64) bool {
+	if sz-end > chunk {
+		return true
+	}
+	buf, err := b.Slice(end, int(sz-end))
+	if err != nil && len(buf) < int(sz-end) {
+		return false
+	}
+	return len(bytes.Trim(buf, " \t\r\n\f\x00")) > Min
+}
+
+// lastIndex finds the offset of the last instance of marker in the file, searching backwards from the offset before
+func lastIndex(b *siegreader.Buffer, marker []byte, before int64) int64 {
+	for end := before; end >= int64(len(marker)); {
+		start := end - chunk
+		if start < 0 {
+			start = 0
+		}
+		buf, err := b.Slice(start, int(end-start))
+		if err != nil && len(buf) < int(end-start) {
+			return -1
+		}
+		if i := bytes.LastIndex(buf, marker); i >= 0 {
+			return start + int64(i)
+		}
+		if start == 0 {
+			break
+		}
+		end = start + int64(len(marker)) - 1 // overlap chunks so markers aren't split
+	}
+	return -1
+}
+
+// a PDF ends with its last %%EOF marker (incremental updates add more) and an optional end-of-line
+func pdfEnd(b *siegreader.Buffer, sz int64) int64 {
+	i := lastIndex(b, []byte("%%EOF"), sz)
+	if i < 0 {
+		return -1
+	}
+	end := i + 5
+	if eol, _ := b.Slice(end, 2); len(eol) > 0 {
+		switch {
+		case bytes.HasPrefix(eol, []byte("\r\n")):
+			end += 2
+		case eol[0] == '\r' || eol[0] == '\n':
+			end++
+		}
+	}
+	return end
+}
+
+// a zip file ends with its end of central directory record, which is 22 bytes plus a comment
+func zipEnd(b *siegreader.Buffer, sz int64) int64 {
+	for before := sz; ; {
+		i := lastIndex(b, []byte("PK\x05\x06"), before)
+		if i < 0 {
+			return -1
+		}
+		if rec, err := b.Slice(i, 22); err == nil {
+			if end := i + 22 + int64(binary.LittleEndian.Uint16(rec[20:])); end <= sz {
+				return end
+			}
+		}
+		before = i + 3 // a record with a comment that runs past the end of the file isn't the real one
+	}
+}
+
+// a PNG file ends with the CRC of its IEND chunk
+func pngEnd(b *siegreader.Buffer, sz int64) int64 {
+	for off := int64(8); off+12 <= sz; {
+		hdr, err := b.Slice(off, 8)
+		if err != nil {
+			return -1
+		}
+		next := off + 12 + int64(binary.BigEndian.Uint32(hdr))
+		if string(hdr[4:]) == "IEND" {
+			return next
+		}
+		off = next
+	}
+	return -1
+}
@@ -0,0 +1,58 @@
+package trailing
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+)
+
+func check(t *testing.T, data []byte) (int64, string) {
+	bufs := siegreader.New()
+	b, err := bufs.Get(bytes.NewReader(data))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer bufs.Put(b)
+	return Check(b)
+}
+
+func TestCheck(t *testing.T) {
+	payload := []byte(strings.Repeat("hidden payload ", 10))
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n%%EOF\n2 0 obj\n<<>>\nendobj\n%%EOF\r\n")
+	zbuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zbuf)
+	zw.SetComment("a comment")
+	w, _ := zw.Create("a.txt")
+	w.Write([]byte("hello"))
+	zw.Close()
+	png, err := ioutil.ReadFile(filepath.Join("..", "..", "cmd", "sf", "testdata", "benchmark", "Benchmark.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"pdf", pdf, "PDF"},
+		{"zip", zbuf.Bytes(), "zip"},
+		{"png", png, "PNG"},
+	} {
+		if n, _ := check(t, test.data); n != 0 {
+			t.Errorf("%s: expecting no trailing data, got %d bytes", test.name, n)
+		}
+		if n, _ := check(t, append(append([]byte{}, test.data...), make([]byte, 100)...)); n != 0 {
+			t.Errorf("%s: expecting padding not to count, got %d bytes", test.name, n)
+		}
+		n, f := check(t, append(append([]byte{}, test.data...), payload...))
+		if n != int64(len(payload)) || f != test.format {
+			t.Errorf("%s: expecting %d bytes of trailing data after %s, got %d after %s", test.name, len(payload), test.format, n, f)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wac
+
+import "io"
+
+// flat is a wild Aho-Corasick tree restored from a table (see Load). It matches like fwac but its nodes
+// are indexes into slices rather than pointers. This makes the tree much quicker to restore than to build
+// and, because the goto slice holds no pointers, the garbage collector doesn't need to scan it.
+type flat struct {
+	root    int32        // the zero tree starts at node 0
+	transit [][256]int32 // the goto function: 0 for no goto (node 0 can't be a goto)
+	nodes   []flatNode
+	p       *pool
+}
+
+type flatNode struct {
+	fail    int32 // the fail function
+	outMaxL int32
+	outMax  int64
+	output  []out // the output function
+}
+
+func newFlat(t *table, p *pool) *flat {
+	n := len(t.vals)
+	f := &flat{
+		root:    int32(t.zero),
+		transit: make([][256]int32, n),
+		nodes:   make([]flatNode, n),
+		p:       p,
+	}
+	var next, j int
+	for i := range f.nodes {
+		if i == 0 || i == t.zero {
+			next = i + 1
+		}
+		for k := 0; k < t.kids[i]; k++ {
+			f.transit[i][t.vals[next]] = int32(next)
+			next++
+		}
+		nd := &f.nodes[i]
+		nd.fail = int32(t.fails[i])
+		var outMaxL int
+		nd.output, nd.outMax, outMaxL = t.output(j, t.outs[i])
+		nd.outMaxL = int32(outMaxL)
+		j += t.outs[i]
+	}
+	return f
+}
+
+// Index returns a channel of results, these contain the indexes (a double index: index of the Seq and index of the Choice)
+// and offsets (in the input byte slice) of matching sequences.
+func (wac *flat) Index(input io.ByteReader) chan Result {
+	output := make(chan Result)
+	go wac.match(input, output)
+	return output
+}
+
+func (wac *flat) match(input io.ByteReader, results chan Result) {
+	var offset int64
+	var progressResult = Result{Index: [2]int{-1, -1}}
+	precons := wac.p.get()
+	transit, nodes := wac.transit, wac.nodes
+	var curr int32
+	for c, err := input.ReadByte(); err == nil; c, err = input.ReadByte() {
+		offset++
+		if trans := transit[curr][c]; trans != 0 {
+			curr = trans
+		} else {
+			for curr != wac.root {
+				curr = nodes[curr].fail
+				if trans := transit[curr][c]; trans != 0 {
+					curr = trans
+					break
+				}
+			}
+		}
+		if nd := &nodes[curr]; nd.output != nil && (nd.outMax == -1 || nd.outMax >= offset-int64(nd.outMaxL)) {
+			for _, o := range nd.output {
+				if o.max == -1 || o.max >= offset-int64(o.length) {
+					if o.subIndex == 0 || (precons[o.seqIndex][o.subIndex-1] != 0 && offset-int64(o.length) >= precons[o.seqIndex][o.subIndex-1]) {
+						if precons[o.seqIndex][o.subIndex] == 0 {
+							precons[o.seqIndex][o.subIndex] = offset
+						}
+						results <- Result{Index: [2]int{o.seqIndex, o.subIndex}, Offset: offset - int64(o.length), Length: o.length}
+					}
+				}
+			}
+		}
+		if offset&(^offset+1) == offset && offset >= 1024 { // send powers of 2 greater than 512
+			progressResult.Offset = offset
+			results <- progressResult
+		}
+	}
+	wac.p.put(precons)
+	close(results)
+}
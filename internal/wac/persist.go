@@ -0,0 +1,312 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wac
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+)
+
+// A tree is persisted as flat tables of its nodes in breadth first order (the zero tree, if any, followed by the main tree).
+// Because the children of each node are contiguous in this order, a node's gotos can be restored from the number of children it has
+// and the children's values; fails are stored as node indexes.
+type table struct {
+	zero    int // number of nodes in the zero tree
+	vals    []byte
+	kids    []int
+	fails   []int
+	outs    []int // number of outputs for each node
+	seqs    []int
+	subs    []int
+	lengths []int
+	maxs    []int64
+}
+
+func (t *table) addOutputs(op []out) {
+	t.outs = append(t.outs, len(op))
+	for _, o := range op {
+		t.seqs = append(t.seqs, o.seqIndex)
+		t.subs = append(t.subs, o.subIndex)
+		t.lengths = append(t.lengths, o.length)
+		t.maxs = append(t.maxs, o.max)
+	}
+}
+
+// output restores the output function of a node from the table, starting at the jth output
+func (t *table) output(j, n int) ([]out, int64, int) {
+	if n == 0 {
+		return nil, 0, 0
+	}
+	op := make([]out, n)
+	for i := range op {
+		op[i] = out{t.maxs[j+i], t.seqs[j+i], t.subs[j+i], t.lengths[j+i]}
+	}
+	outMax, outMaxL := op[0].max, op[0].length
+	for _, o := range op[1:] { // as for addOutput
+		if outMax > -1 && (o.max == -1 || o.max > outMax) {
+			outMax = o.max
+		}
+		if o.length > outMaxL {
+			outMaxL = o.length
+		}
+	}
+	return op, outMax, outMaxL
+}
+
+func (t *table) valid(template []int) bool {
+	n := len(t.vals)
+	if n == 0 || t.zero >= n || len(t.kids) != n || len(t.fails) != n || len(t.outs) != n ||
+		len(t.subs) != len(t.seqs) || len(t.lengths) != len(t.seqs) || len(t.maxs) != len(t.seqs) {
+		return false
+	}
+	var kids, outs int
+	for i := range t.vals {
+		if t.fails[i] < 0 || t.fails[i] >= n || t.kids[i] < 0 || t.outs[i] < 0 {
+			return false
+		}
+		if i == t.zero && t.zero > 0 {
+			if kids != t.zero-1 { // the zero tree's nodes must all be children within it
+				return false
+			}
+			kids++
+		}
+		kids += t.kids[i]
+		outs += t.outs[i]
+	}
+	for i, s := range t.seqs {
+		if s < 0 || s >= len(template) || t.subs[i] < 0 || t.subs[i] >= template[s] {
+			return false
+		}
+	}
+	return kids == n-1 && outs == len(t.seqs)
+}
+
+func (wac *fwac) table() *table {
+	t := &table{}
+	var nodes []*node
+	ids := make(map[*node]int)
+	for _, start := range []*node{wac.zero, wac.root} {
+		queue := []*node{start}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			ids[n] = len(nodes)
+			nodes = append(nodes, n)
+			for _, k := range n.keys {
+				queue = append(queue, n.transit[k])
+			}
+		}
+		if start == wac.zero {
+			t.zero = len(nodes)
+		}
+	}
+	for _, n := range nodes {
+		t.vals = append(t.vals, n.val)
+		t.kids = append(t.kids, len(n.keys))
+		t.fails = append(t.fails, ids[n.fail])
+		t.addOutputs(n.output)
+	}
+	return t
+}
+
+func (wac *fwaclm) table() *table {
+	t := &table{}
+	ids := make(map[*nodelm]int)
+	nodes := []*nodelm{wac.root}
+	for i := 0; i < len(nodes); i++ {
+		ids[nodes[i]] = i
+		for _, l := range nodes[i].transit {
+			nodes = append(nodes, l.n)
+		}
+	}
+	for _, n := range nodes {
+		t.vals = append(t.vals, n.val)
+		t.kids = append(t.kids, len(n.transit))
+		t.fails = append(t.fails, ids[n.fail])
+		t.addOutputs(n.output)
+	}
+	return t
+}
+
+func newFwaclm(t *table, p *pool) *fwaclm {
+	nodes := make([]nodelm, len(t.vals))
+	links := make([]link, len(t.vals))
+	transit := make(transLM, len(t.vals))
+	for i := range links {
+		links[i] = link{t.vals[i], &nodes[i]}
+		transit[i] = &links[i]
+	}
+	next, j := 1, 0
+	for i := range nodes {
+		n := &nodes[i]
+		n.val = t.vals[i]
+		n.transit = transit[next : next+t.kids[i] : next+t.kids[i]]
+		next += t.kids[i]
+		n.fail = &nodes[t.fails[i]]
+		n.output, n.outMax, n.outMaxL = t.output(j, t.outs[i])
+		j += t.outs[i]
+	}
+	return &fwaclm{root: &nodes[0], p: p}
+}
+
+// lazy is a Wac loaded from a table. The tree is restored from the table when it is first used (as a flat tree,
+// or a low memory tree), so that signature files with many trees load quickly.
+type lazy struct {
+	once sync.Once
+	lm   bool
+	t    *table
+	p    *pool
+	w    Wac
+}
+
+func (l *lazy) Index(input io.ByteReader) chan Result {
+	l.once.Do(func() {
+		if l.lm {
+			l.w = newFwaclm(l.t, l.p)
+		} else {
+			l.w = newFlat(l.t, l.p)
+		}
+	})
+	return l.w.Index(input)
+}
+
+var errTable = errors.New("error loading Aho-Corasick tree, bad table")
+
+// persisted collections are limited in size, so tables are saved in chunks
+const chunk = 4096
+
+func saveInts(ls *persist.LoadSaver, is []int) {
+	ls.SaveInt(len(is))
+	for i := 0; i < len(is); i += chunk {
+		end := i + chunk
+		if end > len(is) {
+			end = len(is)
+		}
+		ls.SaveInts(is[i:end])
+	}
+}
+
+func loadInts(ls *persist.LoadSaver) []int {
+	le := ls.LoadInt()
+	if ls.Err != nil || le == 0 {
+		return nil
+	}
+	ret := make([]int, 0, le)
+	for len(ret) < le && ls.Err == nil {
+		is := ls.LoadInts()
+		if len(is) == 0 {
+			ls.Err = errTable
+			return nil
+		}
+		ret = append(ret, is...)
+	}
+	return ret
+}
+
+func saveBytes(ls *persist.LoadSaver, bs []byte) {
+	ls.SaveInt(len(bs))
+	for i := 0; i < len(bs); i += chunk {
+		end := i + chunk
+		if end > len(bs) {
+			end = len(bs)
+		}
+		ls.SaveBytes(bs[i:end])
+	}
+}
+
+func loadBytes(ls *persist.LoadSaver) []byte {
+	le := ls.LoadInt()
+	if ls.Err != nil || le == 0 {
+		return nil
+	}
+	ret := make([]byte, 0, le)
+	for len(ret) < le && ls.Err == nil {
+		bs := ls.LoadBytes()
+		if len(bs) == 0 {
+			ls.Err = errTable
+			return nil
+		}
+		ret = append(ret, bs...)
+	}
+	return ret
+}
+
+// Save persists a Wac made by New, NewLowMem, NewWac or Load (or a nil Wac).
+func Save(w Wac, ls *persist.LoadSaver) {
+	var t *table
+	var lm bool
+	var p *pool
+	switch w := w.(type) {
+	case *fwac:
+		t, p = w.table(), w.p
+	case *fwaclm:
+		t, p, lm = w.table(), w.p, true
+	case *lazy:
+		t, p, lm = w.t, w.p, w.lm
+	default:
+		ls.SaveBool(false)
+		return
+	}
+	ls.SaveBool(true)
+	ls.SaveBool(lm)
+	saveInts(ls, p.template)
+	ls.SaveInt(t.zero)
+	saveBytes(ls, t.vals)
+	saveInts(ls, t.kids)
+	saveInts(ls, t.fails)
+	saveInts(ls, t.outs)
+	saveInts(ls, t.seqs)
+	saveInts(ls, t.subs)
+	saveInts(ls, t.lengths)
+	maxs := make([]int, len(t.maxs))
+	for i, m := range t.maxs {
+		maxs[i] = int(m)
+	}
+	saveInts(ls, maxs)
+}
+
+// Load loads a Wac persisted with Save. The tree is restored when it is first used.
+// It returns nil if a nil Wac was saved or if there is an error loading (in which case ls.Err is set).
+func Load(ls *persist.LoadSaver) Wac {
+	if !ls.LoadBool() {
+		return nil
+	}
+	lm := ls.LoadBool()
+	p := poolFrom(loadInts(ls))
+	t := &table{
+		zero:    ls.LoadInt(),
+		vals:    loadBytes(ls),
+		kids:    loadInts(ls),
+		fails:   loadInts(ls),
+		outs:    loadInts(ls),
+		seqs:    loadInts(ls),
+		subs:    loadInts(ls),
+		lengths: loadInts(ls),
+	}
+	for _, m := range loadInts(ls) {
+		t.maxs = append(t.maxs, int64(m))
+	}
+	if ls.Err != nil {
+		return nil
+	}
+	if !t.valid(p.template) || lm != (t.zero == 0) {
+		ls.Err = errTable
+		return nil
+	}
+	return &lazy{lm: lm, t: t, p: p}
+}
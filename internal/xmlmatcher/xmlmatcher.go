@@ -15,7 +15,9 @@
 package xmlmatcher
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/richardlehane/xmldetect"
 
@@ -63,6 +65,27 @@ func Save(c core.Matcher, ls *persist.LoadSaver) {
 	}
 }
 
+// MarshalJSON encodes a Matcher for export. As JSON objects can't have array keys,
+// the matcher is given as a list of root, namespace and index entries.
+func (m Matcher) MarshalJSON() ([]byte, error) {
+	type entry struct {
+		Root string `json:"root"`
+		NS   string `json:"ns"`
+		Idx  []int  `json:"idx"`
+	}
+	entries := make([]entry, 0, len(m))
+	for k, v := range m {
+		entries = append(entries, entry{k[0], k[1], v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Root == entries[j].Root {
+			return entries[i].NS < entries[j].NS
+		}
+		return entries[i].Root < entries[j].Root
+	})
+	return json.Marshal(entries)
+}
+
 func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, int, error) {
 	var m Matcher
 	if c == nil {
@@ -72,7 +95,7 @@ func Add(c core.Matcher, ss core.SignatureSet, p priority.List) (core.Matcher, i
 	}
 	sigs, ok := ss.(SignatureSet)
 	if !ok {
-		return nil, -1, fmt.Errorf("Xmlmatcher: can't cast persist set")
+		return nil, -1, fmt.Errorf("Xmlmatcher: %w", core.ErrSignatureSet)
 	}
 	var length int
 	// unless it is a new matcher, calculate current length by iterating through all the result values
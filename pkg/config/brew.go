@@ -2,6 +2,8 @@
 
 package config
 
+// the brew build tag is equivalent to sf -profile brew
+
 func init() {
-	siegfried.home = "/usr/share/siegfried"
+	SetProfile(mustProfile("brew"))
 }
@@ -1,4 +1,5 @@
-// +build !brew,!archivematica
+//go:build !brew && !archivematica && !js && !wasip1
+// +build !brew,!archivematica,!js,!wasip1
 
 // Copyright 2014 Richard Lehane. All rights reserved.
 //
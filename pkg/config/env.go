@@ -0,0 +1,51 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables that configure sf e.g. SF_HOME.
+const EnvPrefix = "SF_"
+
+// environment variables with names that differ from the flags they set
+var envAliases = map[string]string{
+	"signature": "sig",
+	"threads":   "multi",
+}
+
+// Env returns the settings made with SF_ environment variables, keyed by the names of the flags they set.
+// Variables are named for flags e.g. SF_HOME sets -home, SF_HASH sets -hash and SF_FORMAT sets -format,
+// except for SF_THREADS (which sets -multi) and SF_SIGNATURE (an alternative to SF_SIG).
+func Env() map[string]string {
+	ret := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, EnvPrefix) {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(kv, EnvPrefix), "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			continue
+		}
+		k := strings.ToLower(kv[0])
+		if alias, ok := envAliases[k]; ok {
+			k = alias
+		}
+		ret[k] = kv[1]
+	}
+	return ret
+}
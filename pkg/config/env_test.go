@@ -0,0 +1,31 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	for k, v := range map[string]string{"SF_HOME": "/sf", "SF_THREADS": "8", "SF_SIGNATURE": "pronom.sig", "SF_Z": "true"} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	env := Env()
+	if env["home"] != "/sf" || env["multi"] != "8" || env["sig"] != "pronom.sig" || env["z"] != "true" {
+		t.Errorf("bad environment settings, got %v", env)
+	}
+}
@@ -27,6 +27,7 @@ var identifier = struct {
 	maxBOF      int      // maximum offset from beginning of file to scan
 	maxEOF      int      // maximum offset from end of file to scan
 	noEOF       bool     // trim end of file segments from signatures
+	bom         bool     // allow a byte-order mark before text signatures anchored at the beginning of file
 	noByte      bool     // don't build with byte signatures
 	noContainer bool     // don't build with container signatures
 	multi       Multi    // define how many results identifiers should return
@@ -35,15 +36,42 @@ var identifier = struct {
 	noMIME      bool     // don't build with MIME signatures
 	noXML       bool     // don't build with XML signatures
 	noRIFF      bool     // don't build with RIFF signatures
+	noEBML      bool     // don't build with EBML DocType signatures
+	noSQLite    bool     // don't build with SQLite signatures
+	noTIFF      bool     // don't build with TIFF signatures
+	noFont      bool     // don't build with font signatures
 	limit       []string // limit signature to a set of included PRONOM reports
 	exclude     []string // exclude a set of PRONOM reports from the signature
 	extensions  string   // directory where custom signature extensions are stored
 	extend      []string
+	metadata    string         // CSV file of extra per-format metadata (e.g. risk level) to attach to results
+	class       bool           // attach format classes (e.g. PRONOM's format types) to results
+	frequency   map[string]int // how often formats are identified in a collection, used to order signatures
 }{
 	multi:      Conclusive,
 	extensions: "custom",
 }
 
+// copies of the default settings, restored by Reset
+var (
+	identifierDefaults = identifier
+	pronomDefaults     = pronom
+	mimeinfoDefaults   = mimeinfo
+	locDefaults        = loc
+	wikidataDefaults   = wikidata
+	textDefaults       = text
+)
+
+// take the copies again after build-specific init funcs (e.g. in archivematica.go) have run
+func init() {
+	identifierDefaults = identifier
+	pronomDefaults = pronom
+	mimeinfoDefaults = mimeinfo
+	locDefaults = loc
+	wikidataDefaults = wikidata
+	textDefaults = text
+}
+
 // GETTERS
 const emptyNamespace = ""
 
@@ -52,6 +80,8 @@ func Name() string {
 	switch {
 	case identifier.name != emptyNamespace:
 		return identifier.name
+	case text.on:
+		return text.name
 	case mimeinfo.mi != emptyNamespace:
 		return mimeinfo.name
 	case loc.fdd != emptyNamespace:
@@ -72,7 +102,9 @@ func Details(extra ...string) string {
 	}
 	// ... otherwise create a default string based on the identifier settings chosen
 	var str string
-	if len(mimeinfo.mi) > 0 {
+	if text.on {
+		str = "JSON, YAML, CSV/TSV and source code sniffers"
+	} else if len(mimeinfo.mi) > 0 {
 		str = mimeinfo.mi
 	} else if len(loc.fdd) > 0 {
 		str = loc.fdd
@@ -108,6 +140,9 @@ func Details(extra ...string) string {
 	if identifier.noEOF {
 		str += "; no EOF signature parts"
 	}
+	if identifier.bom {
+		str += "; BOM tolerant"
+	}
 	if identifier.noByte {
 		str += "; no byte signatures"
 	}
@@ -132,6 +167,18 @@ func Details(extra ...string) string {
 	if identifier.noRIFF {
 		str += "; no RIFF matcher"
 	}
+	if identifier.noEBML {
+		str += "; no EBML matcher"
+	}
+	if identifier.noSQLite {
+		str += "; no SQLite matcher"
+	}
+	if identifier.noTIFF {
+		str += "; no TIFF matcher"
+	}
+	if identifier.noFont {
+		str += "; no font matcher"
+	}
 	if pronom.reports == "" {
 		str += "; built without reports"
 	}
@@ -150,6 +197,15 @@ func Details(extra ...string) string {
 	if len(pronom.extendc) > 0 {
 		str += "; container extensions: " + strings.Join(pronom.extendc, ", ")
 	}
+	if len(identifier.metadata) > 0 {
+		str += "; metadata: " + filepath.Base(identifier.metadata)
+	}
+	if identifier.class {
+		str += "; format classes"
+	}
+	if len(identifier.frequency) > 0 {
+		str += "; signatures ordered by format frequency"
+	}
 	return str
 }
 
@@ -168,6 +224,11 @@ func NoEOF() bool {
 	return identifier.noEOF
 }
 
+// BOM reports whether text signatures anchored at the beginning of file should also match after a UTF-8 byte-order mark.
+func BOM() bool {
+	return identifier.bom
+}
+
 // NoByte reports whether byte signatures should be omitted.
 func NoByte() bool {
 	return identifier.noByte
@@ -213,6 +274,26 @@ func NoRIFF() bool {
 	return identifier.noRIFF
 }
 
+// NoEBML reports whether EBML DocType signatures should be omitted.
+func NoEBML() bool {
+	return identifier.noEBML
+}
+
+// NoSQLite reports whether SQLite application_id and table signatures should be omitted.
+func NoSQLite() bool {
+	return identifier.noSQLite
+}
+
+// NoTIFF reports whether TIFF IFD entry signatures should be omitted.
+func NoTIFF() bool {
+	return identifier.noTIFF
+}
+
+// NoFont reports whether font table signatures should be omitted.
+func NoFont() bool {
+	return identifier.noFont
+}
+
 // HasLimit reports whether a limited set of signatures has been selected.
 func HasLimit() bool {
 	return len(identifier.limit) > 0
@@ -275,6 +356,24 @@ func Extend() []string {
 	return extensionPaths(identifier.extend)
 }
 
+// Class reports whether the classes of formats are attached to results.
+func Class() bool {
+	return identifier.class
+}
+
+// Metadata returns the location of a CSV file of extra per-format metadata, if one has been set.
+func Metadata() string {
+	if identifier.metadata == "" || filepath.Dir(identifier.metadata) != "." {
+		return identifier.metadata
+	}
+	return filepath.Join(siegfried.home, identifier.metadata)
+}
+
+// Frequency returns the number of times formats have been identified in a collection, if these have been set.
+func Frequency() map[string]int {
+	return identifier.frequency
+}
+
 // Return true if value 'v' is contained in slice 's'.
 func contains(v string, s []string) bool {
 	for _, n := range s {
@@ -313,6 +412,22 @@ func Clear() func() private {
 		identifier.name = ""
 		loc.fdd = ""
 		mimeinfo.mi = ""
+		text.on = false
+		return private{}
+	}
+}
+
+// Reset restores all identifier build settings (including PRONOM, MIME-info, LOC, Wikidata and text settings) to their defaults.
+// Use it to avoid pollution when building several identifiers with different options in the same session.
+// The siegfried home and signature settings are left as they are.
+func Reset() func() private {
+	return func() private {
+		identifier = identifierDefaults
+		pronom = pronomDefaults
+		mimeinfo = mimeinfoDefaults
+		loc = locDefaults
+		wikidata = wikidataDefaults
+		text = textDefaults
 		return private{}
 	}
 }
@@ -358,6 +473,14 @@ func SetNoEOF() func() private {
 	}
 }
 
+// SetBOM will cause text signatures anchored at the beginning of file to also match after a UTF-8 byte-order mark.
+func SetBOM() func() private {
+	return func() private {
+		identifier.bom = true
+		return private{}
+	}
+}
+
 // SetNoByte will cause byte signatures to be omitted.
 func SetNoByte() func() private {
 	return func() private {
@@ -377,20 +500,7 @@ func SetNoContainer() func() private {
 // SetMulti defines how identifiers report multiple results.
 func SetMulti(m string) func() private {
 	return func() private {
-		switch m {
-		case "0", "single", "top":
-			identifier.multi = Single
-		case "1", "conclusive":
-			identifier.multi = Conclusive
-		case "2", "positive":
-			identifier.multi = Positive
-		case "3", "comprehensive":
-			identifier.multi = Comprehensive
-		case "4", "exhaustive":
-			identifier.multi = Exhaustive
-		default:
-			identifier.multi = Conclusive
-		}
+		identifier.multi, _ = ParseMulti(m) // defaults to Conclusive
 		return private{}
 	}
 }
@@ -435,6 +545,38 @@ func SetNoRIFF() func() private {
 	}
 }
 
+// SetNoEBML will cause EBML DocType signatures to be omitted.
+func SetNoEBML() func() private {
+	return func() private {
+		identifier.noEBML = true
+		return private{}
+	}
+}
+
+// SetNoSQLite will cause SQLite application_id and table signatures to be omitted.
+func SetNoSQLite() func() private {
+	return func() private {
+		identifier.noSQLite = true
+		return private{}
+	}
+}
+
+// SetNoTIFF will cause TIFF IFD entry signatures to be omitted.
+func SetNoTIFF() func() private {
+	return func() private {
+		identifier.noTIFF = true
+		return private{}
+	}
+}
+
+// SetNoFont will cause font table signatures to be omitted.
+func SetNoFont() func() private {
+	return func() private {
+		identifier.noFont = true
+		return private{}
+	}
+}
+
 // SetLimit limits the set of signatures built to the list provide.
 func SetLimit(l []string) func() private {
 	return func() private {
@@ -451,6 +593,32 @@ func SetExclude(l []string) func() private {
 	}
 }
 
+// SetClass attaches the classes of formats (PRONOM's format types e.g. "Image (Raster)") to the identifier's results,
+// in a class field. Classes are taken from PRONOM reports, so aren't available for identifiers built with -noreports.
+func SetClass() func() private {
+	return func() private {
+		identifier.class = true
+		return private{}
+	}
+}
+
+// SetMetadata attaches extra per-format metadata, from a CSV file keyed on format ID, to the identifier's results.
+func SetMetadata(m string) func() private {
+	return func() private {
+		identifier.metadata = m
+		return private{}
+	}
+}
+
+// SetFrequency orders signatures by how often formats are identified in a collection (e.g. as recorded by sf -freq),
+// so that the most common formats are matched first.
+func SetFrequency(f map[string]int) func() private {
+	return func() private {
+		identifier.frequency = f
+		return private{}
+	}
+}
+
 // SetExtend adds extension signatures to the build.
 func SetExtend(l []string) func() private {
 	return func() private {
@@ -84,3 +84,19 @@ func TestIsArchiveGreaterThanNone(t *testing.T) {
 		t.Errorf("Archive 0 type should equal zero not %d", noneType)
 	}
 }
+
+// TestReset checks that Reset restores identifier build settings set
+// by earlier options.
+func TestReset(t *testing.T) {
+	SetName("custom")()
+	SetNoByte()()
+	SetBOF(1024)()
+	SetMIMEInfo("tika")()
+	Reset()()
+	if Name() != "pronom" {
+		t.Errorf("Expected name 'pronom' after reset, got '%s'", Name())
+	}
+	if NoByte() || MaxBOF() != 0 || mimeinfo.mi != "" {
+		t.Errorf("Expected default build settings after reset")
+	}
+}
@@ -14,6 +14,8 @@
 
 package config
 
+import "fmt"
+
 // Multi defines how identifiers treat multiple results.
 type Multi int
 
@@ -40,3 +42,20 @@ func (m Multi) String() string {
 	}
 	return ""
 }
+
+// ParseMulti parses a Multi given by name (e.g. "positive") or number (e.g. "2").
+func ParseMulti(m string) (Multi, error) {
+	switch m {
+	case "0", "single", "top":
+		return Single, nil
+	case "1", "conclusive":
+		return Conclusive, nil
+	case "2", "positive":
+		return Positive, nil
+	case "3", "comprehensive":
+		return Comprehensive, nil
+	case "4", "exhaustive":
+		return Exhaustive, nil
+	}
+	return Conclusive, fmt.Errorf("invalid multi setting %q; choose from single, conclusive, positive, comprehensive or exhaustive", m)
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Profile is a named set of defaults for a distribution of siegfried e.g. the home directory and signature file
+// used by Archivematica. Profiles are JSON files: the built-in profiles are in the profiles directory of this package.
+type Profile struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Home        string   `json:"home,omitempty"`       // siegfried home directory
+	Signature   string   `json:"signature,omitempty"`  // name of the signature file
+	Identifier  string   `json:"identifier,omitempty"` // name of the identifier (used by roy build)
+	Extend      []string `json:"extend,omitempty"`     // signature extensions (used by roy build)
+}
+
+//go:embed profiles/*.json
+var profiles embed.FS
+
+// Profiles lists the names of the built-in profiles.
+func Profiles() []string {
+	entries, _ := fs.ReadDir(profiles, "profiles")
+	ret := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ret = append(ret, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// LoadProfile loads a built-in profile by name, or a profile file if given a path to a .json file.
+func LoadProfile(name string) (Profile, error) {
+	var (
+		byt []byte
+		err error
+	)
+	if strings.HasSuffix(name, ".json") {
+		byt, err = os.ReadFile(name)
+	} else {
+		byt, err = profiles.ReadFile(path.Join("profiles", name+".json"))
+		if err != nil {
+			return Profile{}, fmt.Errorf("unknown profile %s; choose from %s, or give the path to a profile file", name, strings.Join(Profiles(), ", "))
+		}
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+	var p Profile
+	if err = json.Unmarshal(byt, &p); err != nil {
+		return Profile{}, fmt.Errorf("bad profile %s: %v", name, err)
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(path.Base(name), ".json")
+	}
+	return p, nil
+}
+
+func mustProfile(name string) Profile {
+	p, err := LoadProfile(name)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// SetProfile applies a profile's settings. Empty fields are left as they are.
+func SetProfile(p Profile) {
+	if p.Home != "" {
+		SetHome(p.Home)
+	}
+	if p.Signature != "" {
+		siegfried.signature = p.Signature
+	}
+	if p.Identifier != "" {
+		identifier.name = p.Identifier
+	}
+	if len(p.Extend) > 0 {
+		identifier.extend = p.Extend
+	}
+}
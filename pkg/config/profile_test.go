@@ -0,0 +1,39 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import "testing"
+
+func TestLoadProfile(t *testing.T) {
+	if len(Profiles()) < 2 {
+		t.Fatalf("expecting built-in profiles, got %v", Profiles())
+	}
+	for _, name := range Profiles() {
+		p, err := LoadProfile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Name != name || p.Home == "" {
+			t.Errorf("bad profile %s: %+v", name, p)
+		}
+	}
+	p, _ := LoadProfile("archivematica")
+	if p.Signature != "archivematica.sig" || len(p.Extend) != 4 {
+		t.Errorf("bad archivematica profile: %+v", p)
+	}
+	if _, err := LoadProfile("bogus"); err == nil {
+		t.Error("expecting an error for an unknown profile")
+	}
+}
@@ -18,16 +18,19 @@ package config
 import (
 	"io"
 	"net/http"
+	"os"
 	"path/filepath"
+	"runtime"
 	"time"
 )
 
 var siegfried = struct {
-	version   [3]int // Siegfried version (i.e. of the sf tool)
-	home      string // Home directory used by both sf and roy tools
-	signature string // Name of signature file
-	conf      string // Name of the conf file
-	magic     []byte // Magic bytes to ID signature file
+	version   [3]int   // Siegfried version (i.e. of the sf tool)
+	home      string   // Home directory used by both sf and roy tools
+	signature string   // Name of signature file
+	conf      string   // Name of the conf file
+	magic     []byte   // Magic bytes to ID signature file
+	paths     []string // Directories searched, after home, for signature and conf files (if nil, the data directories)
 	// Defaults for processing bytematcher signatures. These control the segmentation.
 	distance   int // The acceptable distance between two frames before they will be segmented (default is 8192)
 	rng        int // The acceptable range between two frames before they will be segmented (default is 0-2049)
@@ -46,8 +49,23 @@ var siegfried = struct {
 	out        io.Writer
 	checkpoint int64
 	userAgent  string
+	// Output
+	legacy    bool // write matches in YAML and JSON output with the fields of each identifier (the layout before schema version 2.0)
+	nest      bool // nest the results for the contents of archives under the archive's result in JSON output
+	normalise bool // write paths in output as NFC-normalised UTF-8, with the raw bytes in a rawname field if they differ
+	// Identification
+	trailing bool  // warn of data after the logical end of formats that mark their end e.g. PDF and zip (polyglot files)
+	macros   bool  // warn of VBA macros and embedded objects in OLE2 and OOXML files
+	empty    bool  // report zero-byte files as EMPTY, rather than UNKNOWN or a match on extension
+	tiny     int64 // files smaller than this many bytes may be identified by extension alone, even if their format has signatures
+	// Matching: runtime overrides of the identifier build options, so one signature file can serve different policies
+	skipName      bool  // don't run the filename (extension) matcher
+	skipByte      bool  // don't run the byte matcher
+	skipContainer bool  // don't run the container matcher
+	resolve       Multi // how identifiers report multiple results, overriding the multi setting they were built with if resolveSet
+	resolveSet    bool
 }{
-	version:         [3]int{1, 9, 0},
+	version:         [3]int{1, 10, 0},
 	signature:       "default.sig",
 	conf:            "sf.conf",
 	magic:           []byte{'s', 'f', 0x00, 0xFF},
@@ -76,14 +94,77 @@ func Home() string {
 	return siegfried.home
 }
 
-// Home makes a path local to Home() if it is relative
+// Local makes a path local to Home() if it is relative. If the file isn't in Home(), but is in one of the
+// other directories in HomePaths(), it is made local to that directory instead.
 func Local(base string) string {
+	if filepath.Dir(base) != "." {
+		return base
+	}
+	for _, dir := range HomePaths() {
+		p := filepath.Join(dir, base)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return filepath.Join(siegfried.home, base)
+}
+
+// HomeFile makes a path local to Home() if it is relative, without searching HomePaths().
+// Use it for files that are written e.g. updated signature files.
+func HomeFile(base string) string {
 	if filepath.Dir(base) == "." {
 		return filepath.Join(siegfried.home, base)
 	}
 	return base
 }
 
+// HomePaths reports the directories that are searched for signature and conf files, in order of precedence:
+// Home(), then either the other directories given to SetHome or the data directories: $XDG_DATA_HOME/siegfried
+// (~/.local/share/siegfried), the siegfried directories in $XDG_DATA_DIRS (/usr/local/share:/usr/share) and
+// /usr/share/siegfried. The data directories aren't searched on Windows.
+func HomePaths() []string {
+	paths := siegfried.paths
+	if paths == nil {
+		paths = dataDirs()
+	}
+	ret := make([]string, 0, len(paths)+1)
+	seen := make(map[string]bool)
+	for _, p := range append([]string{siegfried.home}, paths...) {
+		if p == "" || seen[filepath.Clean(p)] {
+			continue
+		}
+		seen[filepath.Clean(p)] = true
+		ret = append(ret, p)
+	}
+	return ret
+}
+
+func dataDirs() []string {
+	if runtime.GOOS == "windows" {
+		return []string{}
+	}
+	var ret []string
+	xdgHome := os.Getenv("XDG_DATA_HOME")
+	if xdgHome == "" {
+		if h, err := os.UserHomeDir(); err == nil {
+			xdgHome = filepath.Join(h, ".local", "share")
+		}
+	}
+	if xdgHome != "" {
+		ret = append(ret, filepath.Join(xdgHome, "siegfried"))
+	}
+	xdgDirs := os.Getenv("XDG_DATA_DIRS")
+	if xdgDirs == "" {
+		xdgDirs = "/usr/local/share:/usr/share"
+	}
+	for _, d := range filepath.SplitList(xdgDirs) {
+		if d != "" {
+			ret = append(ret, filepath.Join(d, "siegfried"))
+		}
+	}
+	return append(ret, "/usr/share/siegfried")
+}
+
 // Signature returns the path to the siegfried signature file.
 func Signature() string {
 	return Local(siegfried.signature)
@@ -94,6 +175,11 @@ func SignatureBase() string {
 	return siegfried.signature
 }
 
+// ConfBase returns the filename of the siegfried configuration file.
+func ConfBase() string {
+	return siegfried.conf
+}
+
 // Conf returns the path to the siegfried configuration file.
 func Conf() string {
 	return Local(siegfried.conf)
@@ -174,11 +260,74 @@ func UserAgent() string {
 	return siegfried.userAgent
 }
 
+// Legacy reports whether matches in YAML and JSON output have the legacy layout.
+func Legacy() bool {
+	return siegfried.legacy
+}
+
+// Nest reports whether the results for the contents of archives are nested under the archive's result in JSON output.
+func Nest() bool {
+	return siegfried.nest
+}
+
+// Normalise reports whether paths in output are normalised to NFC UTF-8.
+func Normalise() bool {
+	return siegfried.normalise
+}
+
+// Trailing reports whether identifications warn of data after the logical end of a file (e.g. polyglot files).
+func Trailing() bool {
+	return siegfried.trailing
+}
+
+// Macros reports whether identifications warn of macros and embedded objects in OLE2 and OOXML files.
+func Macros() bool {
+	return siegfried.macros
+}
+
+// Empty reports whether zero-byte files are identified as EMPTY.
+func Empty() bool {
+	return siegfried.empty
+}
+
+// SkipName reports whether the filename matcher is skipped when identifying files.
+func SkipName() bool {
+	return siegfried.skipName
+}
+
+// SkipByte reports whether the byte matcher is skipped when identifying files.
+func SkipByte() bool {
+	return siegfried.skipByte
+}
+
+// SkipContainer reports whether the container matcher is skipped when identifying files.
+func SkipContainer() bool {
+	return siegfried.skipContainer
+}
+
+// Resolve returns the multi setting that overrides the setting identifiers were built with, and whether it is set.
+func Resolve() (Multi, bool) {
+	return siegfried.resolve, siegfried.resolveSet
+}
+
+// Tiny returns the size below which files may be identified by extension alone. Zero means no extension fallback.
+func Tiny() int64 {
+	return siegfried.tiny
+}
+
 // SETTERS
 
 // SetHome sets the siegfried HOME location (e.g. /usr/home/siegfried).
+// Given a list of directories (e.g. /home/richard/siegfried:/usr/share/siegfried), the first is the HOME location
+// and the others replace the data directories that are searched for signature and conf files (see HomePaths).
 func SetHome(h string) {
-	siegfried.home = h
+	dirs := filepath.SplitList(h)
+	if len(dirs) < 2 {
+		siegfried.home = h
+		return
+	}
+	siegfried.home = dirs[0]
+	siegfried.paths = dirs[1:]
 }
 
 // SetSignature sets the signature filename or filepath.
@@ -245,3 +394,74 @@ func SetSlow() {
 func SetOut(o io.Writer) {
 	siegfried.out = o
 }
+
+// SetLegacy sets whether matches in YAML and JSON output have the legacy layout, with the fields of each identifier.
+func SetLegacy(l bool) {
+	siegfried.legacy = l
+}
+
+// SetNest sets whether the results for the contents of archives are nested under the archive's result in JSON output.
+func SetNest(n bool) {
+	siegfried.nest = n
+}
+
+// SetNormalise sets whether paths in output are normalised to NFC UTF-8 (e.g. so the decomposed names of macOS
+// file systems match the composed names of other systems). Raw bytes that aren't valid UTF-8 are read as Latin-1.
+// When normalising changes a path, the raw bytes are kept in a rawname field.
+func SetNormalise(n bool) {
+	siegfried.normalise = n
+}
+
+// SetTrailing sets whether identifications warn of data after the logical end of PDF, zip and PNG files.
+func SetTrailing(t bool) {
+	siegfried.trailing = t
+}
+
+// SetMacros sets whether identifications warn of VBA macros (and, in OOXML files, XLM macro sheets) and embedded
+// objects in OLE2 and OOXML files, e.g. so that ingest workflows can quarantine documents with macros.
+func SetMacros(m bool) {
+	siegfried.macros = m
+}
+
+// SetEmpty sets whether zero-byte files are identified as EMPTY, rather than as UNKNOWN or by extension.
+func SetEmpty(e bool) {
+	siegfried.empty = e
+}
+
+// SetSkipName sets whether the filename matcher is skipped, so that files aren't identified (or given extension
+// mismatch warnings) by their extensions. It is the runtime equivalent of building with roy's -noname flag.
+func SetSkipName(b bool) {
+	siegfried.skipName = b
+}
+
+// SetSkipByte sets whether the byte matcher is skipped. It is the runtime equivalent of roy's -nobyte flag.
+func SetSkipByte(b bool) {
+	siegfried.skipByte = b
+}
+
+// SetSkipContainer sets whether the container matcher is skipped. It is the runtime equivalent of roy's -nocontainer flag.
+func SetSkipContainer(b bool) {
+	siegfried.skipContainer = b
+}
+
+// SetResolve overrides how identifiers report multiple results (see Multi), whatever multi setting they were built with.
+// Give an empty string to restore the built settings. The priorities compiled into the byte matcher still apply with
+// comprehensive and exhaustive settings, so those are only fully comprehensive if the identifier was built with them.
+func SetResolve(m string) error {
+	if m == "" {
+		siegfried.resolve, siegfried.resolveSet = 0, false
+		return nil
+	}
+	r, err := ParseMulti(m)
+	if err != nil {
+		return err
+	}
+	siegfried.resolve, siegfried.resolveSet = r, true
+	return nil
+}
+
+// SetTiny sets the size below which files may be identified by extension alone, even when their formats have byte or
+// container signatures. Such files are usually too small to hold the bytes the signatures look for.
+func SetTiny(t int64) {
+	siegfried.tiny = t
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHomePaths(t *testing.T) {
+	home, paths := siegfried.home, siegfried.paths
+	defer func() { siegfried.home, siegfried.paths = home, paths }()
+	user, err := ioutil.TempDir("", "sfuser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(user)
+	system, err := ioutil.TempDir("", "sfsystem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(system)
+	if err := ioutil.WriteFile(filepath.Join(system, "test.sig"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetHome(strings.Join([]string{user, system}, string(os.PathListSeparator)))
+	if Home() != user || len(HomePaths()) != 2 {
+		t.Fatalf("bad home paths, got %s and %v", Home(), HomePaths())
+	}
+	if Local("test.sig") != filepath.Join(system, "test.sig") {
+		t.Errorf("expected signature file in the system directory, got %s", Local("test.sig"))
+	}
+	if HomeFile("test.sig") != filepath.Join(user, "test.sig") {
+		t.Errorf("expected updates to be written to home, got %s", HomeFile("test.sig"))
+	}
+	if err := ioutil.WriteFile(filepath.Join(user, "test.sig"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if Local("test.sig") != filepath.Join(user, "test.sig") {
+		t.Errorf("expected signature file in home to take precedence, got %s", Local("test.sig"))
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if _, ok := Resolve(); ok {
+		t.Fatal("expecting no multi override by default")
+	}
+	if err := SetResolve("bogus"); err == nil {
+		t.Error("expecting an error for a bad multi setting")
+	}
+	if err := SetResolve("positive"); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := Resolve(); !ok || m != Positive {
+		t.Errorf("expecting a positive override, got %v", m)
+	}
+	SetResolve("")
+	if _, ok := Resolve(); ok {
+		t.Error("expecting the override to be cleared")
+	}
+}
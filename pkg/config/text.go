@@ -0,0 +1,37 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+var text = struct {
+	on   bool
+	name string
+}{
+	name: "text",
+}
+
+// Text reports whether a text identifier is being built.
+func Text() bool {
+	return text.on
+}
+
+// SetText builds a text identifier, that sniffs structured text formats (e.g. JSON and CSV), rather than a PRONOM identifier.
+func SetText() func() private {
+	return func() private {
+		mimeinfo.mi = "" // reset mimeinfo and loc to prevent pollution
+		loc.fdd = ""
+		text.on = true
+		return private{}
+	}
+}
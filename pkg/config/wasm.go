@@ -0,0 +1,15 @@
+//go:build (js && !brew && !archivematica) || (wasip1 && !brew && !archivematica)
+// +build js,!brew,!archivematica wasip1,!brew,!archivematica
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WebAssembly runtimes may have no user account or file system: use $HOME if it is set, otherwise a relative "siegfried" folder.
+// Signature files are generally loaded from bytes on these platforms (see siegfried.LoadBytes).
+func init() {
+	siegfried.home = filepath.Join(os.Getenv("HOME"), "siegfried")
+}
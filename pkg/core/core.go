@@ -16,7 +16,11 @@
 package core
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/richardlehane/siegfried/internal/persist"
 	"github.com/richardlehane/siegfried/internal/siegreader"
@@ -43,29 +47,107 @@ const (
 	MIMEInfo
 	LOC
 	Wikidata
+	Text // Text sniffs structured text formats e.g. JSON and CSV
+)
+
+// Errors shared by identifiers and matchers. They are wrapped with further detail, so test for them with errors.Is.
+var (
+	ErrSignatureSet      = errors.New("can't convert signature set")          // a matcher was given a signature set of the wrong type
+	ErrRegistered        = errors.New("identifier already registered")        // an identifier's ID or name conflicts with an existing registration
+	ErrUnknownIdentifier = errors.New("no identifier registered for this ID") // a signature file contains an identifier that isn't registered
 )
 
 // IdentifierLoader unmarshals an Identifer from a LoadSaver.
 type IdentifierLoader func(*persist.LoadSaver) Identifier
 
-var loaders = [8]IdentifierLoader{nil, nil, nil, nil, nil, nil, nil, nil}
+// Registration describes an identifier type that can be loaded from signature files.
+// ID is the byte that an identifier writes at the start of its Save method
+// (external identifiers should use values above the built-in types to avoid conflicts).
+type Registration struct {
+	ID          byte
+	Name        string // short name e.g. "pronom"
+	Description string // optional description of the identifier type
+	Loader      IdentifierLoader
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[byte]Registration)
+)
+
+// Register allows external packages to add new identifier types. It returns an error if the ID or
+// name of the registration is already taken.
+func Register(r Registration) error {
+	if r.Loader == nil {
+		return fmt.Errorf("core: identifier registration %d (%s) has no loader", r.ID, r.Name)
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if e, ok := registry[r.ID]; ok {
+		return fmt.Errorf("core: can't register identifier %s; ID %d is already registered to %s: %w", r.Name, r.ID, e.Name, ErrRegistered)
+	}
+	if r.Name != "" {
+		for _, e := range registry {
+			if e.Name == r.Name {
+				return fmt.Errorf("core: can't register identifier %d; name %s is already registered to ID %d: %w", r.ID, r.Name, e.ID, ErrRegistered)
+			}
+		}
+	}
+	registry[r.ID] = r
+	return nil
+}
+
+// MustRegister is like Register but panics if the registration conflicts with an existing one.
+// It is intended to be called from an init function.
+func MustRegister(r Registration) {
+	if err := Register(r); err != nil {
+		panic(err)
+	}
+}
 
 // RegisterIdentifier allows external packages to add new IdentifierLoaders.
+// It panics if the ID is already registered. Use Register to supply a name and description.
 func RegisterIdentifier(id byte, l IdentifierLoader) {
-	loaders[int(id)] = l
+	MustRegister(Registration{ID: id, Loader: l})
+}
+
+// Registered lists the registered identifier types, ordered by ID.
+func Registered() []Registration {
+	registryMu.RLock()
+	ret := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		ret = append(ret, r)
+	}
+	registryMu.RUnlock()
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+	return ret
+}
+
+// LookupIdentifier returns the registration with the given name.
+func LookupIdentifier(name string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, r := range registry {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Registration{}, false
 }
 
 // LoadIdentifier applies the appropriate IdentifierLoader to load an identifier.
 func LoadIdentifier(ls *persist.LoadSaver) Identifier {
 	id := ls.LoadByte()
-	l := loaders[int(id)]
-	if l == nil {
+	registryMu.RLock()
+	r, ok := registry[id]
+	registryMu.RUnlock()
+	if !ok {
 		if ls.Err == nil {
-			ls.Err = errors.New("bad identifier loader")
+			ls.Err = fmt.Errorf("bad identifier loader: %w (%d)", ErrUnknownIdentifier, id)
 		}
 		return nil
 	}
-	return l(ls)
+	return r.Loader(ls)
 }
 
 // Hint is a structure provided by a Recorder before a matcher is run, when asked if it is Satisfied().
@@ -83,6 +165,18 @@ type Recorder interface {
 	Active(MatcherType)                 // Instruct Recorder that can expect results of type MatcherType.
 }
 
+// Classifier is implemented by Identifiers that know the classes of their formats (e.g. PRONOM's format types such
+// as "Image (Raster)" or "Database"), so that classes can be included in results. It returns classes by format ID.
+type Classifier interface {
+	Classes() map[string]string
+}
+
+// SizeRecorder is implemented by Recorders that want to know the size of a file before they report e.g. to relax
+// their rules for files too small to match a signature.
+type SizeRecorder interface {
+	Size(int64)
+}
+
 // Identification is sent by an identifier when a format matches
 type Identification interface {
 	String() string          // short text that is displayed to indicate the format match
@@ -98,6 +192,11 @@ type Matcher interface {
 	String() string
 }
 
+// ContextMatcher is implemented by matchers that can stop matching when a context is cancelled or its deadline passes
+type ContextMatcher interface {
+	IdentifyContext(context.Context, string, *siegreader.Buffer, ...Hint) (chan Result, error) // As Identify, but the returned channel is closed early if the context is done
+}
+
 // MatcherType is used by recorders to tell which type of matcher has sent a result
 type MatcherType int
 
@@ -110,6 +209,10 @@ const (
 	TextMatcher
 	XMLMatcher
 	RIFFMatcher
+	EBMLMatcher
+	SQLiteMatcher
+	TIFFMatcher
+	FontMatcher
 )
 
 // SignatureSet is added to a matcher. It can take any form, depending on the matcher.
@@ -120,3 +223,18 @@ type Result interface {
 	Index() int
 	Basis() string
 }
+
+// FailedResult is a Result that reports that a matcher failed part way through a scan (e.g. it recovered from a panic
+// on a pathological file), rather than a hit. Its Index is -1. The error is reported for the file.
+type FailedResult interface {
+	Result
+	Err() error
+}
+
+// Refinement is a Result from a matcher that refines the matches of earlier matchers (e.g. the TIFF matcher reads the
+// IFDs of a file the byte matcher matched as a TIFF). Specific reports whether it matched more than earlier matchers
+// could have (e.g. IFD entries, not just a header), so may identify a format that they missed.
+type Refinement interface {
+	Result
+	Specific() bool
+}
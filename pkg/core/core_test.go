@@ -0,0 +1,33 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+)
+
+func TestRegister(t *testing.T) {
+	l := func(*persist.LoadSaver) Identifier { return nil }
+	if err := Register(Registration{ID: 200, Name: "test", Loader: l}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(Registration{ID: 200, Name: "other", Loader: l}); !errors.Is(err, ErrRegistered) {
+		t.Errorf("expecting an ID conflict, got %v", err)
+	}
+	if err := Register(Registration{ID: 201, Name: "test", Loader: l}); err == nil {
+		t.Error("expecting a name conflict")
+	}
+	if r, ok := LookupIdentifier("test"); !ok || r.ID != 200 {
+		t.Errorf("expecting to find the test registration, got %v", r)
+	}
+	var found bool
+	for _, r := range Registered() {
+		if r.ID == 200 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expecting the test registration to be listed")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"os"
+)
+
+// Error classes classify the errors reported for files, so that they can be filtered without parsing error messages
+// (they are written in the errclass field of sf's YAML, JSON and CSV output, see the writer package).
+const (
+	ClassPermission = "permission" // a file or directory can't be read because of its permissions
+	ClassNotFound   = "not-found"  // a file or directory vanished before it could be read
+	ClassFileType   = "file-type"  // not a regular file e.g. a symlink
+	ClassSkipped    = "skipped"    // a special file (a named pipe, socket or device) or a sparse file that sf skipped
+	ClassWalk       = "walk"       // other errors walking a directory
+	ClassDecompress = "decompress" // an archive couldn't be decompressed
+	ClassTruncated  = "truncated"  // an archive is cut off, so only some of its contents could be decompressed
+	ClassCorrupt    = "corrupt"    // an archive or container (e.g. an OLE2 file) is malformed
+	ClassEncrypted  = "encrypted"  // an entry in an archive is encrypted
+	ClassAborted    = "aborted"    // decompressing an archive was aborted because it broke the limits for decompression bombs
+	ClassPanic      = "panic"      // identifying a file (or decompressing an archive) panicked; the panic was recovered so the scan could go on
+	ClassTimeout    = "timeout"    // reading and identifying a file took longer than sf's -filetimeout, so sf skipped it
+	ClassError      = "error"      // other errors reading or identifying a file
+)
+
+// Classer is implemented by errors that know their error class.
+type Classer interface {
+	ErrorClass() string
+}
+
+type classError struct {
+	class string
+	err   error
+}
+
+func (ce classError) Error() string      { return ce.err.Error() }
+func (ce classError) Unwrap() error      { return ce.err }
+func (ce classError) ErrorClass() string { return ce.class }
+
+// ClassifyError gives an error an error class.
+func ClassifyError(class string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return classError{class, err}
+}
+
+// ErrorClass returns the error class of an error, or an empty string for a nil error.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var c Classer
+	if errors.As(err, &c) {
+		return c.ErrorClass()
+	}
+	switch {
+	case errors.Is(err, os.ErrPermission):
+		return ClassPermission
+	case errors.Is(err, os.ErrNotExist):
+		return ClassNotFound
+	}
+	return ClassError
+}
@@ -0,0 +1,246 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Errors for malformed archives. Errors returned by New and Next wrap one of these when the archive is malformed.
+var (
+	ErrTruncated = errors.New("truncated archive")
+	ErrCorrupt   = errors.New("corrupt archive")
+)
+
+// classify wraps the errors of the archive readers in ErrTruncated or ErrCorrupt
+func classify(err error) error {
+	var fe flate.CorruptInputError
+	switch {
+	case err == nil, err == io.EOF, errors.Is(err, ErrTruncated), errors.Is(err, ErrCorrupt):
+		return err
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: %v", ErrTruncated, err)
+	case errors.Is(err, zip.ErrFormat), errors.Is(err, zip.ErrChecksum), errors.Is(err, gzip.ErrHeader),
+		errors.Is(err, gzip.ErrChecksum), errors.Is(err, tar.ErrHeader), errors.As(err, &fe):
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return err
+}
+
+// classifyReader classifies the errors of a member's reader
+type classifyReader struct {
+	r io.Reader
+}
+
+func (cr classifyReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	return n, classify(err)
+}
+
+const (
+	zipLocalSig      = 0x04034b50
+	zipDescriptorSig = 0x08074b50
+	zipCentralSig    = 0x02014b50
+	zipLocalLen      = 30
+)
+
+// zipLocal lists the members of a zip file that has lost its central directory (e.g. a truncated file)
+// by reading the local file headers from the start of the file. It stops at the first bad header.
+type zipLocal struct {
+	p       string
+	ra      io.ReaderAt
+	sz      int64
+	off     int64 // offset of the next local header, or -1 if unknown (find it by reading the current member)
+	hdr     zip.FileHeader
+	data    int64 // offset of the current member's data
+	csize   int64 // compressed size of the current member, or -1 if it isn't in the local header
+	written map[string]bool
+}
+
+func newZipLocal(ra io.ReaderAt, path string, sz int64) (*zipLocal, bool) {
+	z := &zipLocal{p: path, ra: ra, sz: sz}
+	var sig [4]byte
+	if _, err := ra.ReadAt(sig[:], 0); err != nil || binary.LittleEndian.Uint32(sig[:]) != zipLocalSig {
+		return nil, false
+	}
+	return z, true
+}
+
+func (z *zipLocal) Next() error {
+	if z.off < 0 {
+		// the member's size isn't known until its deflate stream is read
+		cr := &countReader{r: io.NewSectionReader(z.ra, z.data, z.sz-z.data)}
+		fr := flate.NewReader(cr)
+		_, err := io.Copy(ioutil.Discard, fr)
+		fr.Close()
+		if err != nil {
+			return classify(err)
+		}
+		z.off = z.data + cr.n
+		if z.hdr.Flags&0x8 == 0x8 { // skip the data descriptor
+			var sig [4]byte
+			if _, err := z.ra.ReadAt(sig[:], z.off); err == nil && binary.LittleEndian.Uint32(sig[:]) == zipDescriptorSig {
+				z.off += 4
+			}
+			z.off += 12
+		}
+	}
+	for {
+		if err := z.header(); err != nil {
+			return err
+		}
+		if !z.hdr.FileInfo().IsDir() {
+			break
+		}
+	}
+	if z.hdr.Flags&0x1 == 0x1 {
+		return ErrEncrypted
+	}
+	if z.hdr.Method != zip.Store && z.hdr.Method != zip.Deflate {
+		return zip.ErrAlgorithm
+	}
+	return nil
+}
+
+// header reads the local header at z.off and sets the offset of the next header, if it is known
+func (z *zipLocal) header() error {
+	if z.off >= z.sz {
+		return fmt.Errorf("%w: no members after offset %d and no central directory", ErrTruncated, z.off)
+	}
+	var buf [zipLocalLen]byte
+	if _, err := z.ra.ReadAt(buf[:], z.off); err != nil {
+		return fmt.Errorf("%w: local header at offset %d is cut off", ErrTruncated, z.off)
+	}
+	le := binary.LittleEndian
+	switch le.Uint32(buf[:4]) {
+	case zipLocalSig:
+	case zipCentralSig: // all the members are here, but zip.NewReader couldn't find the end of the central directory
+		return fmt.Errorf("%w: central directory is cut off", ErrTruncated)
+	default:
+		return fmt.Errorf("%w: bad local header at offset %d", ErrCorrupt, z.off)
+	}
+	flags, method := le.Uint16(buf[6:8]), le.Uint16(buf[8:10])
+	csize, usize := int64(le.Uint32(buf[18:22])), int64(le.Uint32(buf[22:26]))
+	nlen, xlen := int64(le.Uint16(buf[26:28])), int64(le.Uint16(buf[28:30]))
+	name := make([]byte, nlen)
+	if _, err := z.ra.ReadAt(name, z.off+zipLocalLen); err != nil {
+		return fmt.Errorf("%w: local header at offset %d is cut off", ErrTruncated, z.off)
+	}
+	z.hdr = zip.FileHeader{
+		Name:               string(name),
+		Flags:              flags,
+		Method:             method,
+		ModifiedTime:       le.Uint16(buf[10:12]),
+		ModifiedDate:       le.Uint16(buf[12:14]),
+		CRC32:              le.Uint32(buf[14:18]),
+		CompressedSize64:   uint64(csize),
+		UncompressedSize64: uint64(usize),
+	}
+	z.data = z.off + zipLocalLen + nlen + xlen
+	z.csize = csize
+	switch {
+	case flags&0x8 == 0 || csize > 0:
+		if z.data+csize > z.sz {
+			return fmt.Errorf("%w: member %s is cut off", ErrTruncated, z.hdr.Name)
+		}
+		z.off = z.data + csize
+		if flags&0x8 == 0x8 {
+			z.off += 12
+			var sig [4]byte
+			if _, err := z.ra.ReadAt(sig[:], z.data+csize); err == nil && le.Uint32(sig[:]) == zipDescriptorSig {
+				z.off += 4
+			}
+		}
+	case strings.HasSuffix(z.hdr.Name, "/"): // directories have no data
+		z.off = z.data
+	case method == zip.Deflate:
+		z.csize, z.off = -1, -1
+	default:
+		// a stored member that gives its size only in a data descriptor can't be recovered
+		return fmt.Errorf("%w: can't find the end of member %s", ErrCorrupt, z.hdr.Name)
+	}
+	return nil
+}
+
+func (z *zipLocal) Reader() io.Reader {
+	n := z.csize
+	if n < 0 {
+		n = z.sz - z.data
+	}
+	var r io.Reader = io.NewSectionReader(z.ra, z.data, n)
+	if z.hdr.Method == zip.Deflate {
+		r = flate.NewReader(r)
+	}
+	return classifyReader{r}
+}
+
+func (z *zipLocal) Path() string {
+	return Arcpath(z.p, filepath.FromSlash(zipName(&z.hdr)))
+}
+
+func (z *zipLocal) MIME() string {
+	return ""
+}
+
+func (z *zipLocal) Size() int64 {
+	return int64(z.hdr.UncompressedSize64)
+}
+
+func (z *zipLocal) Mod() time.Time {
+	return z.hdr.ModTime()
+}
+
+func (z *zipLocal) Dirs() []string {
+	if z.written == nil {
+		z.written = make(map[string]bool)
+	}
+	return dirs(z.p, zipName(&z.hdr), z.written)
+}
+
+// countReader counts the bytes read. It is a flate.Reader, so flate doesn't read ahead of the end of a deflate stream.
+type countReader struct {
+	r *io.SectionReader
+	n int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countReader) ReadByte() (byte, error) {
+	var b [1]byte
+	n, err := cr.r.Read(b[:])
+	cr.n += int64(n)
+	if n == 1 {
+		return b[0], nil
+	}
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+	return 0, err
+}
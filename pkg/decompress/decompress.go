@@ -16,24 +16,34 @@
 package decompress
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
 
-	"github.com/richardlehane/characterize"
 	"github.com/richardlehane/webarchive"
+	"golang.org/x/text/encoding/charmap"
 
 	"github.com/richardlehane/siegfried/internal/siegreader"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/core"
 )
 
+// Errors returned by decompressors. Test for them with errors.Is.
+var (
+	ErrUnknownArchive = errors.New("decompress: unknown archive type")
+	// ErrEncrypted is returned by Next for encrypted entries. These can't be identified, but
+	// the Decompressor can continue to the next entry.
+	ErrEncrypted = errors.New("decompress: entry is encrypted")
+)
+
 // package flag for changing functionality of Arcpath func if droid output flag is used
 var droidOutput bool
 
@@ -74,7 +84,7 @@ func New(arc config.Archive, buf *siegreader.Buffer, path string, sz int64) (Dec
 	case config.WARC:
 		return newWARC(siegreader.ReaderFrom(buf), path)
 	}
-	return nil, fmt.Errorf("Decompress: unknown archive type %v", arc)
+	return nil, fmt.Errorf("%w %v", ErrUnknownArchive, arc)
 }
 
 type zipD struct {
@@ -87,7 +97,14 @@ type zipD struct {
 
 func newZip(ra io.ReaderAt, path string, sz int64) (Decompressor, error) {
 	zr, err := zip.NewReader(ra, sz)
-	return &zipD{idx: -1, p: path, rdr: zr}, err
+	if err != nil {
+		// recover what members we can from the local file headers
+		if zl, ok := newZipLocal(ra, path, sz); ok {
+			return zl, nil
+		}
+		return nil, classify(err)
+	}
+	return &zipD{idx: -1, p: path, rdr: zr}, nil
 }
 
 func (z *zipD) close() {
@@ -107,9 +124,13 @@ func (z *zipD) Next() error {
 	if z.idx >= len(z.rdr.File) {
 		return io.EOF
 	}
+	z.rc = nil
+	if z.rdr.File[z.idx].Flags&0x1 == 0x1 {
+		return ErrEncrypted
+	}
 	var err error
 	z.rc, err = z.rdr.File[z.idx].Open()
-	return err
+	return classify(err)
 }
 
 func (z *zipD) Reader() io.Reader {
@@ -117,7 +138,7 @@ func (z *zipD) Reader() io.Reader {
 }
 
 func (z *zipD) Path() string {
-	return Arcpath(z.p, filepath.FromSlash(characterize.ZipName(z.rdr.File[z.idx].Name)))
+	return Arcpath(z.p, filepath.FromSlash(zipName(&z.rdr.File[z.idx].FileHeader)))
 }
 
 func (z *zipD) MIME() string {
@@ -136,7 +157,20 @@ func (z *zipD) Dirs() []string {
 	if z.written == nil {
 		z.written = make(map[string]bool)
 	}
-	return dirs(z.p, characterize.ZipName(z.rdr.File[z.idx].Name), z.written)
+	return dirs(z.p, zipName(&z.rdr.File[z.idx].FileHeader), z.written)
+}
+
+// zipName decodes the name of a zip member. Names are UTF-8 if the member's language encoding flag is set (bit 11),
+// otherwise they are in the IBM PC code page (CP437), unless they are valid UTF-8 anyway (as many tools write them).
+func zipName(hdr *zip.FileHeader) string {
+	if hdr.Flags&0x800 == 0x800 || utf8.ValidString(hdr.Name) {
+		return hdr.Name
+	}
+	n, err := charmap.CodePage437.NewDecoder().String(hdr.Name)
+	if err != nil {
+		return hdr.Name
+	}
+	return n
 }
 
 type tarD struct {
@@ -155,7 +189,7 @@ func (t *tarD) Next() error {
 	// scan past directories
 	for t.hdr, err = t.rdr.Next(); err == nil && t.hdr.FileInfo().IsDir(); t.hdr, err = t.rdr.Next() {
 	}
-	return err
+	return classify(err)
 }
 
 func (t *tarD) Reader() io.Reader {
@@ -197,11 +231,14 @@ func newGzip(b *siegreader.Buffer, path string) (Decompressor, error) {
 	_ = b.SizeNow()              // in case a stream, force full read
 	buf, err := b.EofSlice(0, 4) // gzip stores uncompressed size in last 4 bytes of the stream
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrTruncated, err)
 	}
 	sz := int64(uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16 | uint32(buf[3])<<24)
 	g, err := gzip.NewReader(siegreader.ReaderFrom(b))
-	return &gzipD{sz: sz, p: path, rdr: g}, err
+	if err != nil {
+		return nil, classify(err)
+	}
+	return &gzipD{sz: sz, p: path, rdr: g}, nil
 }
 
 func (g *gzipD) Next() error {
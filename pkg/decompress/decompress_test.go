@@ -0,0 +1,154 @@
+package decompress
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+func TestErrors(t *testing.T) {
+	zbuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zbuf)
+	w, _ := zw.CreateHeader(&zip.FileHeader{Name: "secret.txt", Flags: 0x1})
+	w.Write([]byte("garbled"))
+	w, _ = zw.Create("plain.txt")
+	w.Write([]byte("hello"))
+	zw.Close()
+	bufs := siegreader.New()
+	b, err := bufs.Get(bytes.NewReader(zbuf.Bytes()))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer bufs.Put(b)
+	if _, err := New(config.Archive(99), b, "test.zip", int64(zbuf.Len())); !errors.Is(err, ErrUnknownArchive) {
+		t.Errorf("expecting an unknown archive error, got %v", err)
+	}
+	d, err := New(config.Zip, b, "test.zip", int64(zbuf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Next(); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("expecting an encrypted error, got %v", err)
+	}
+	if err := d.Next(); err != nil {
+		t.Errorf("expecting to continue past the encrypted entry, got %v", err)
+	}
+	if err := d.Next(); err != io.EOF {
+		t.Errorf("expecting EOF, got %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	zbuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zbuf)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		w, _ := zw.Create(name)
+		w.Write(make([]byte, ratioMin*2))
+	}
+	zw.Close()
+	bufs := siegreader.New()
+	b, err := bufs.Get(bytes.NewReader(zbuf.Bytes()))
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	defer bufs.Put(b)
+	sz := int64(zbuf.Len())
+	for _, test := range []struct {
+		lim     Limits
+		members int // members listed before the limit is broken
+	}{
+		{Limits{}, 3},
+		{Limits{Members: 2}, 2},
+		{Limits{Ratio: 100}, 1},
+	} {
+		d, err := New(config.Zip, b, "test.zip", sz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d = Limit(d, NewBudget(test.lim, sz))
+		var members int
+		for err = d.Next(); err == nil; err = d.Next() {
+			members++
+			io.Copy(ioutil.Discard, d.Reader())
+		}
+		if members != test.members {
+			t.Errorf("expecting %d members with limits %v, got %d", test.members, test.lim, members)
+		}
+		if test.lim == (Limits{}) && err != io.EOF || test.lim != (Limits{}) && !errors.Is(err, ErrBomb) {
+			t.Errorf("bad error with limits %v, got %v", test.lim, err)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	zbuf := &bytes.Buffer{}
+	zw := zip.NewWriter(zbuf)
+	for _, name := range []string{"dir/", "dir/a.txt", "b.txt", "c.txt"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(strings.Repeat(name, 100)))
+	}
+	zw.Close()
+	tbuf := &bytes.Buffer{}
+	tw := tar.NewWriter(tbuf)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		tw.WriteHeader(&tar.Header{Name: name, Size: 1024, Mode: 0644})
+		tw.Write(make([]byte, 1024))
+	}
+	tw.Close()
+	for _, test := range []struct {
+		arc     config.Archive
+		data    []byte
+		members int // members listed before the truncation
+	}{
+		{config.Zip, zbuf.Bytes()[:zbuf.Len()-100], 3}, // central directory cut off
+		{config.Zip, zbuf.Bytes()[:120], 1},            // b.txt's local header cut off
+		{config.Tar, tbuf.Bytes()[:2000], 1},
+	} {
+		bufs := siegreader.New()
+		b, err := bufs.Get(bytes.NewReader(test.data))
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		d, err := New(test.arc, b, "test", int64(len(test.data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var members int
+		for err = d.Next(); err == nil; err = d.Next() {
+			members++
+			io.Copy(ioutil.Discard, d.Reader())
+		}
+		if members != test.members {
+			t.Errorf("expecting %d members of truncated %s, got %d", test.members, test.arc, members)
+		}
+		if !errors.Is(err, ErrTruncated) {
+			t.Errorf("expecting a truncated error for %s, got %v", test.arc, err)
+		}
+		bufs.Put(b)
+	}
+}
+
+func TestZipName(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		flags  uint16
+		expect string
+	}{
+		{"plain.txt", 0, "plain.txt"},
+		{"r\x82sum\x82.txt", 0, "résumé.txt"},           // CP437, decoded in full
+		{"résumé.txt", 0, "résumé.txt"},                 // UTF-8 without the flag
+		{"r\x82sum\x82.txt", 0x800, "r\x82sum\x82.txt"}, // flagged as UTF-8, so left as is
+	} {
+		if got := zipName(&zip.FileHeader{Name: test.name, Flags: test.flags}); got != test.expect {
+			t.Errorf("expecting %q, got %q", test.expect, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+//go:build go1.18
+// +build go1.18
+
+package decompress
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+// walk an archive, reading each of its members, as sf does
+func walkArchive(t *testing.T, arc config.Archive, data []byte) {
+	bufs := siegreader.New()
+	buf, err := bufs.Get(bytes.NewReader(data))
+	if err != nil && err != io.EOF {
+		return
+	}
+	defer bufs.Put(buf)
+	d, err := New(arc, buf, "fuzz", int64(len(data)))
+	if err != nil {
+		return
+	}
+	d = Limit(d, NewBudget(DefaultLimits, int64(len(data))))
+	for i := 0; i < 1000; i++ {
+		if err = d.Next(); err != nil && err != ErrEncrypted {
+			return
+		}
+		_, _, _, _ = d.Path(), d.Size(), d.Mod(), d.Dirs()
+		if err == nil {
+			io.Copy(ioutil.Discard, d.Reader())
+		}
+	}
+}
+
+func FuzzZip(f *testing.F) {
+	for _, ext := range []string{"docx", "odt"} {
+		buf, err := ioutil.ReadFile(filepath.Join("..", "..", "cmd", "sf", "testdata", "benchmark", "Benchmark."+ext))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf)
+		f.Add(buf[:len(buf)/2])
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		walkArchive(t, config.Zip, data)
+	})
+}
+
+func FuzzTar(f *testing.F) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	tw.WriteHeader(&tar.Header{Name: "a/b.txt", Size: 5, Mode: 0644})
+	tw.Write([]byte("hello"))
+	tw.Close()
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		walkArchive(t, config.Tar, data)
+	})
+}
+
+func FuzzGzip(f *testing.F) {
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	gw.Name = "a.txt"
+	gw.Write([]byte("hello"))
+	gw.Close()
+	f.Add(buf.Bytes())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		walkArchive(t, config.Gzip, data)
+	})
+}
+
+func FuzzZipLocal(f *testing.F) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	w, _ := zw.Create("a.txt")
+	w.Write([]byte("hello hello hello"))
+	zw.Close()
+	f.Add(buf.Bytes()[:buf.Len()-22]) // without the end of central directory record
+	f.Fuzz(func(t *testing.T, data []byte) {
+		walkArchive(t, config.Zip, data)
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decompress
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrBomb is returned by a limited Decompressor's Next, and by its Reader, when an archive breaks its Limits.
+var ErrBomb = errors.New("aborted: decompression bomb suspected")
+
+// Limits guard against decompression bombs: archives that expand to exhaust disk, memory or time.
+// They apply to an archive together with any archives nested in it. Zero fields mean no limit.
+type Limits struct {
+	Ratio   int64         // maximum ratio of the bytes decompressed to the size of the archive
+	Members int           // maximum number of members
+	Time    time.Duration // maximum time spent decompressing
+}
+
+// DefaultLimits are generous enough for the most compressible real archives. There is no default time limit.
+var DefaultLimits = Limits{Ratio: 1000, Members: 100000}
+
+// the ratio limit applies only once this many bytes have been decompressed, so that small archives of small,
+// very compressible files aren't mistaken for bombs
+const ratioMin = 1 << 24 // 16MB
+
+// A Budget records the resources used decompressing an archive and its nested archives.
+// Create one for each archive that isn't itself in an archive and share it with Limit for any nested archives.
+type Budget struct {
+	lim     Limits
+	sz      int64
+	start   time.Time
+	members int
+	read    int64
+}
+
+// NewBudget makes a budget for an archive of sz bytes.
+func NewBudget(lim Limits, sz int64) *Budget {
+	return &Budget{lim: lim, sz: sz, start: time.Now()}
+}
+
+func (b *Budget) check() error {
+	switch {
+	case b.lim.Members > 0 && b.members > b.lim.Members:
+		return fmt.Errorf("%w: more than %d members", ErrBomb, b.lim.Members)
+	case b.lim.Ratio > 0 && b.sz > 0 && b.read > ratioMin && b.read/b.sz > b.lim.Ratio:
+		return fmt.Errorf("%w: decompressed %d bytes from a %d byte archive", ErrBomb, b.read, b.sz)
+	case b.lim.Time > 0 && time.Since(b.start) > b.lim.Time:
+		return fmt.Errorf("%w: decompressing for longer than %s", ErrBomb, b.lim.Time)
+	}
+	return nil
+}
+
+// Limit returns a Decompressor that charges the members it lists, and the bytes read from them, to a Budget.
+func Limit(d Decompressor, b *Budget) Decompressor {
+	return &limited{Decompressor: d, b: b}
+}
+
+type limited struct {
+	Decompressor
+	b *Budget
+}
+
+func (l *limited) Next() error {
+	if err := l.b.check(); err != nil {
+		return err
+	}
+	err := l.Decompressor.Next()
+	if err == nil || errors.Is(err, ErrEncrypted) {
+		l.b.members++
+		if berr := l.b.check(); berr != nil {
+			return berr
+		}
+	}
+	return err
+}
+
+func (l *limited) Reader() io.Reader {
+	return &limitedReader{l.Decompressor.Reader(), l.b}
+}
+
+type limitedReader struct {
+	r io.Reader
+	b *Budget
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if err := lr.b.check(); err != nil {
+		return 0, err
+	}
+	n, err := lr.r.Read(p)
+	lr.b.read += int64(n)
+	return n, err
+}
@@ -15,6 +15,7 @@
 package loc
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -26,7 +27,7 @@ import (
 )
 
 func init() {
-	core.RegisterIdentifier(core.LOC, Load)
+	core.MustRegister(core.Registration{ID: core.LOC, Name: "loc", Description: "Library of Congress format descriptions", Loader: Load})
 }
 
 type Identifier struct {
@@ -46,6 +47,15 @@ func (i *Identifier) Save(ls *persist.LoadSaver) {
 	i.Base.Save(ls)
 }
 
+// MarshalJSON encodes the identifier for export.
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string                `json:"type"`
+		Infos map[string]formatInfo `json:"infos"`
+		Base  *identifier.Base      `json:"base"`
+	}{"loc", i.infos, i.Base})
+}
+
 func Load(ls *persist.LoadSaver) core.Identifier {
 	i := &Identifier{}
 	i.infos = make(map[string]formatInfo)
@@ -203,7 +213,7 @@ func (r *Recorder) Satisfied(mt core.MatcherType) (bool, core.Hint) {
 		return false, core.Hint{}
 	}
 	if r.cscore < incScore {
-		if mt == core.ContainerMatcher || mt == core.ByteMatcher || mt == core.XMLMatcher || mt == core.RIFFMatcher {
+		if mt == core.ContainerMatcher || mt == core.ByteMatcher || mt == core.XMLMatcher || mt == core.RIFFMatcher || mt == core.EBMLMatcher || mt == core.SQLiteMatcher || mt == core.TIFFMatcher || mt == core.FontMatcher {
 			return false, core.Hint{}
 		}
 		if len(r.ids) == 0 {
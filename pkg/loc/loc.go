@@ -16,6 +16,7 @@ package loc
 
 import (
 	"archive/zip"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"io/ioutil"
@@ -109,6 +110,14 @@ func (f formatInfo) String() string {
 	return f.name
 }
 
+func (f formatInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name     string `json:"name"`
+		LongName string `json:"longName"`
+		MIMEType string `json:"mimeType"`
+	}{f.name, f.longName, f.mimeType})
+}
+
 // turn generic FormatInfo into fdd formatInfo
 func infos(m map[string]identifier.FormatInfo) map[string]formatInfo {
 	i := make(map[string]formatInfo, len(m))
@@ -15,6 +15,7 @@
 package mimeinfo
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -26,7 +27,7 @@ import (
 )
 
 func init() {
-	core.RegisterIdentifier(core.MIMEInfo, Load)
+	core.MustRegister(core.Registration{ID: core.MIMEInfo, Name: "mimeinfo", Description: "MIME-info signature files (e.g. freedesktop.org, Apache Tika)", Loader: Load})
 }
 
 type Identifier struct {
@@ -47,6 +48,15 @@ func (i *Identifier) Save(ls *persist.LoadSaver) {
 	i.Base.Save(ls)
 }
 
+// MarshalJSON encodes the identifier for export.
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string                `json:"type"`
+		Infos map[string]formatInfo `json:"infos"`
+		Base  *identifier.Base      `json:"base"`
+	}{"mimeinfo", i.infos, i.Base})
+}
+
 func Load(ls *persist.LoadSaver) core.Identifier {
 	i := &Identifier{}
 	i.infos = make(map[string]formatInfo)
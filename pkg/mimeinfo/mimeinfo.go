@@ -17,6 +17,7 @@ package mimeinfo
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"io/ioutil"
@@ -103,6 +104,15 @@ func (f formatInfo) String() string {
 	return f.comment
 }
 
+func (f formatInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Comment      string `json:"comment"`
+		Text         bool   `json:"text"`
+		GlobWeights  []int  `json:"globWeights"`
+		MagicWeights []int  `json:"magicWeights"`
+	}{f.comment, f.text, f.globWeights, f.magicWeights})
+}
+
 // turn generic FormatInfo into mimeinfo formatInfo
 func infos(m map[string]identifier.FormatInfo) map[string]formatInfo {
 	i := make(map[string]formatInfo, len(m))
@@ -0,0 +1,67 @@
+//go:build go1.18
+// +build go1.18
+
+package pronom
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/identifier"
+	"github.com/richardlehane/siegfried/pkg/pronom/internal/mappings"
+)
+
+const fuzzDroid = `<?xml version="1.0" encoding="UTF-8"?>
+<FFSignatureFile DateCreated="2020-01-21T10:10:50" Version="96" xmlns="http://www.nationalarchives.gov.uk/pronom/SignatureFile">
+    <InternalSignatureCollection>
+        <InternalSignature ID="17" Specificity="Specific">
+            <ByteSequence Reference="BOFoffset">
+                <SubSequence MinFragLength="0" Position="1" SubSeqMaxOffset="0" SubSeqMinOffset="0">
+                    <Sequence>474946383961</Sequence>
+                </SubSequence>
+            </ByteSequence>
+        </InternalSignature>
+        <InternalSignature ID="18" Specificity="Specific">
+            <ByteSequence Reference="BOFoffset">
+                <SubSequence MinFragLength="0" Position="1" SubSeqMaxOffset="128" SubSeqMinOffset="0">
+                    <Sequence>4749[46:47]{2}??[!01]*3961</Sequence>
+                    <RightFragment MaxOffset="8" MinOffset="0" Position="1">0A0D</RightFragment>
+                </SubSequence>
+            </ByteSequence>
+            <ByteSequence Reference="EOFoffset">
+                <SubSequence MinFragLength="0" Position="1" SubSeqMaxOffset="0" SubSeqMinOffset="0">
+                    <Sequence>3B</Sequence>
+                </SubSequence>
+            </ByteSequence>
+        </InternalSignature>
+    </InternalSignatureCollection>
+    <FileFormatCollection>
+        <FileFormat ID="1" MIMEType="image/gif" Name="Graphics Interchange Format" PUID="fmt/4" Version="89a">
+            <InternalSignatureID>17</InternalSignatureID>
+            <Extension>gif</Extension>
+        </FileFormat>
+        <FileFormat ID="2" MIMEType="image/gif" Name="Graphics Interchange Format" PUID="fmt/3" Version="87a">
+            <InternalSignatureID>18</InternalSignatureID>
+            <Extension>gif</Extension>
+            <HasPriorityOverFileFormatID>1</HasPriorityOverFileFormatID>
+        </FileFormat>
+    </FileFormatCollection>
+</FFSignatureFile>`
+
+// parse DROID signature files as roy does when building signature files
+func FuzzDroid(f *testing.F) {
+	f.Add([]byte(fuzzDroid))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := &mappings.Droid{}
+		if err := xml.Unmarshal(data, d); err != nil {
+			return
+		}
+		p := &droid{d, identifier.Blank{}}
+		p.IDs()
+		p.Infos()
+		p.Globs()
+		p.MIMEs()
+		p.Priorities()
+		p.Signatures()
+	})
+}
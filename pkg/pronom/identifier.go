@@ -15,8 +15,10 @@
 package pronom
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/richardlehane/siegfried/internal/identifier"
@@ -26,11 +28,12 @@ import (
 )
 
 func init() {
-	core.RegisterIdentifier(core.Pronom, Load)
+	core.MustRegister(core.Registration{ID: core.Pronom, Name: "pronom", Description: "PRONOM file format registry", Loader: Load})
 }
 
 type Identifier struct {
-	infos map[string]formatInfo
+	infos   map[string]formatInfo
+	classes map[string]string // not persisted: only needed when the identifier is added to a signature file
 	*identifier.Base
 }
 
@@ -46,6 +49,15 @@ func (i *Identifier) Save(ls *persist.LoadSaver) {
 	i.Base.Save(ls)
 }
 
+// MarshalJSON encodes the identifier for export.
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string                `json:"type"`
+		Infos map[string]formatInfo `json:"infos"`
+		Base  *identifier.Base      `json:"base"`
+	}{"pronom", i.infos, i.Base})
+}
+
 func Load(ls *persist.LoadSaver) core.Identifier {
 	i := &Identifier{}
 	i.infos = make(map[string]formatInfo)
@@ -65,16 +77,24 @@ func New(opts ...config.Option) (core.Identifier, error) {
 	for _, v := range opts {
 		v()
 	}
-	pronom, err := NewPronom()
+	p, err := newPronom()
 	if err != nil {
 		return nil, err
 	}
+	pronom := identifier.ApplyConfig(p)
 	return &Identifier{
-		infos: infos(pronom.Infos()),
-		Base:  identifier.New(pronom, config.ZipPuid()),
+		infos:   infos(pronom.Infos()),
+		classes: p.classes,
+		Base:    identifier.New(pronom, config.ZipPuid()),
 	}, nil
 }
 
+// Classes returns the classes of the identifier's formats (PRONOM's format types e.g. "Image (Raster)"), by PUID.
+// Classes are only available for identifiers built from PRONOM reports.
+func (i *Identifier) Classes() map[string]string {
+	return i.classes
+}
+
 func (i *Identifier) Fields() []string {
 	return []string{"namespace", "id", "format", "version", "mime", "basis", "warning"}
 }
@@ -89,11 +109,13 @@ func (i *Identifier) Recorder() core.Recorder {
 type Recorder struct {
 	*Identifier
 	ids        pids
+	bases      []basis // backs the bases of ids, so that most matches don't need an allocation
 	cscore     int
 	satisfied  bool
 	extActive  bool
 	mimeActive bool
 	textActive bool
+	tiny       bool // the file is smaller than config.Tiny(), so may be identified by extension alone
 }
 
 const (
@@ -116,20 +138,25 @@ func (r *Recorder) Active(m core.MatcherType) {
 	}
 }
 
+// Size tells the Recorder the size of the file, so that it can identify files smaller than config.Tiny() by extension alone.
+func (r *Recorder) Size(sz int64) {
+	r.tiny = sz < config.Tiny()
+}
+
 func (r *Recorder) Record(m core.MatcherType, res core.Result) bool {
 	switch m {
 	default:
 		return false
 	case core.NameMatcher:
 		if hit, id := r.Hit(m, res.Index()); hit {
-			r.ids = add(r.ids, r.Name(), id, r.infos[id], res.Basis(), extScore)
+			r.add(id, basis{res: res}, extScore)
 			return true
 		} else {
 			return false
 		}
 	case core.MIMEMatcher:
 		if hit, id := r.Hit(m, res.Index()); hit {
-			r.ids = add(r.ids, r.Name(), id, r.infos[id], res.Basis(), mimeScore)
+			r.add(id, basis{res: res}, mimeScore)
 			return true
 		} else {
 			return false
@@ -139,18 +166,14 @@ func (r *Recorder) Record(m core.MatcherType, res core.Result) bool {
 		if res.Index() < 0 {
 			if r.ZipDefault() {
 				r.cscore += incScore
-				r.ids = add(r.ids, r.Name(), config.ZipPuid(), r.infos[config.ZipPuid()], res.Basis(), r.cscore)
+				r.add(config.ZipPuid(), basis{res: res}, r.cscore)
 			}
 			return false
 		}
 		if hit, id := r.Hit(m, res.Index()); hit {
 			r.cscore += incScore
-			basis := res.Basis()
 			p, t := r.Place(core.ContainerMatcher, res.Index())
-			if t > 1 {
-				basis = basis + fmt.Sprintf(" (signature %d/%d)", p, t)
-			}
-			r.ids = add(r.ids, r.Name(), id, r.infos[id], basis, r.cscore)
+			r.add(id, basis{res, p, t}, r.cscore)
 			return true
 		} else {
 			return false
@@ -161,12 +184,38 @@ func (r *Recorder) Record(m core.MatcherType, res core.Result) bool {
 				return true
 			}
 			r.cscore += incScore
-			basis := res.Basis()
 			p, t := r.Place(core.ByteMatcher, res.Index())
-			if t > 1 {
-				basis = basis + fmt.Sprintf(" (signature %d/%d)", p, t)
+			r.add(id, basis{res, p, t}, r.cscore)
+			return true
+		} else {
+			return false
+		}
+	case core.EBMLMatcher, core.SQLiteMatcher:
+		if hit, id := r.Hit(m, res.Index()); hit {
+			if r.satisfied {
+				return true
+			}
+			r.cscore += incScore
+			r.add(id, basis{res: res}, r.cscore)
+			return true
+		} else {
+			return false
+		}
+	case core.TIFFMatcher, core.FontMatcher:
+		if hit, id := r.Hit(m, res.Index()); hit {
+			// the TIFF and font matchers refine the byte matcher's matches: add their basis to a format already matched...
+			for _, v := range r.ids {
+				if v.ID == id && v.confidence >= incScore {
+					r.add(id, basis{res: res}, 0)
+					return true
+				}
+			}
+			// ...or add a format that the byte matcher missed (e.g. a GeoTIFF with its IFD at the end of the file),
+			// unless the matcher has matched no more than a header
+			if ref, ok := res.(core.Refinement); (ok && ref.Specific()) || r.cscore < incScore {
+				r.cscore += incScore
+				r.add(id, basis{res: res}, r.cscore)
 			}
-			r.ids = add(r.ids, r.Name(), id, r.infos[id], basis, r.cscore)
 			return true
 		} else {
 			return false
@@ -176,7 +225,7 @@ func (r *Recorder) Record(m core.MatcherType, res core.Result) bool {
 			if r.satisfied {
 				return true
 			}
-			r.ids = add(r.ids, r.Name(), id, r.infos[id], res.Basis(), textScore)
+			r.add(id, basis{res: res}, textScore)
 			return true
 		} else {
 			return false
@@ -185,14 +234,15 @@ func (r *Recorder) Record(m core.MatcherType, res core.Result) bool {
 }
 
 func (r *Recorder) Satisfied(mt core.MatcherType) (bool, core.Hint) {
-	if r.NoPriority() {
+	// the TIFF and font matchers refine earlier matches, so are never skipped
+	if r.NoPriority() || mt == core.TIFFMatcher || mt == core.FontMatcher {
 		return false, core.Hint{}
 	}
 	if r.cscore < incScore {
 		if len(r.ids) == 0 {
 			return false, core.Hint{}
 		}
-		if mt == core.ContainerMatcher || mt == core.ByteMatcher || mt == core.XMLMatcher || mt == core.RIFFMatcher {
+		if mt == core.ContainerMatcher || mt == core.ByteMatcher || mt == core.XMLMatcher || mt == core.RIFFMatcher || mt == core.EBMLMatcher || mt == core.SQLiteMatcher {
 			if mt == core.ByteMatcher || mt == core.ContainerMatcher {
 				keys := make([]string, len(r.ids))
 				for i, v := range r.ids {
@@ -253,7 +303,7 @@ func (r *Recorder) Report() []core.Identification {
 	if r.Multi() == config.Exhaustive {
 		ret := make([]core.Identification, len(r.ids))
 		for i, v := range r.ids {
-			ret[i] = r.updateWarning(v)
+			ret[i] = r.updateWarning(v.withBasis())
 		}
 		return ret
 	}
@@ -274,8 +324,11 @@ func (r *Recorder) Report() []core.Identification {
 			if v.ID == config.TextPuid() && conf < textScore && r.textActive {
 				continue
 			}
-			// if the match has no corresponding byte or container signature...
-			if ok := r.HasSig(v.ID, core.ContainerMatcher, core.ByteMatcher); !ok {
+			// if the match has no corresponding byte or container signature (or the file is too small to match one)...
+			if ok := r.HasSig(v.ID, core.ContainerMatcher, core.ByteMatcher); !ok || r.tiny {
+				if ok {
+					v.Warning = "file too small for a signature match"
+				}
 				// break immediately if more than one match
 				if len(nids) > 0 {
 					nids = nids[:0]
@@ -329,7 +382,7 @@ func (r *Recorder) Report() []core.Identification {
 				}
 			}
 		}
-		ret[i] = r.updateWarning(v)
+		ret[i] = r.updateWarning(v.withBasis())
 	}
 	return ret
 }
@@ -381,6 +434,33 @@ type Identification struct {
 	Warning    string
 	archive    config.Archive
 	confidence int
+	bases      []basis // turned into Basis strings when the identification is reported
+}
+
+// A basis is kept for each match but only turned into a string for the identifications that are reported:
+// the name matcher alone can give dozens of matches for a file.
+type basis struct {
+	res  core.Result
+	p, t int // place of the matching signature and total signatures for the format
+}
+
+func (b basis) String() string {
+	if b.t > 1 {
+		return b.res.Basis() + " (signature " + strconv.Itoa(b.p) + "/" + strconv.Itoa(b.t) + ")"
+	}
+	return b.res.Basis()
+}
+
+func (id Identification) withBasis() Identification {
+	if len(id.bases) == 0 {
+		return id
+	}
+	id.Basis = make([]string, len(id.bases))
+	for i, b := range id.bases {
+		id.Basis[i] = b.String()
+	}
+	id.bases = nil
+	return id
 }
 
 func (id Identification) String() string {
@@ -423,13 +503,16 @@ func (p pids) Less(i, j int) bool { return p[j].confidence < p[i].confidence }
 
 func (p pids) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
 
-func add(p pids, id string, f string, info formatInfo, basis string, c int) pids {
-	for i, v := range p {
+func (r *Recorder) add(f string, b basis, c int) {
+	for i, v := range r.ids {
 		if v.ID == f {
-			p[i].confidence += c
-			p[i].Basis = append(p[i].Basis, basis)
-			return p
+			r.ids[i].confidence += c
+			r.ids[i].bases = append(r.ids[i].bases, b)
+			return
 		}
 	}
-	return append(p, Identification{id, f, info.name, info.version, info.mimeType, []string{basis}, "", config.IsArchive(f), c})
+	r.bases = append(r.bases, b)
+	n := len(r.bases)
+	info := r.infos[f]
+	r.ids = append(r.ids, Identification{r.Name(), f, info.name, info.version, info.mimeType, nil, "", config.IsArchive(f), c, r.bases[n-1 : n : n]})
 }
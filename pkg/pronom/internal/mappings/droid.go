@@ -64,4 +64,7 @@ type FileFormat struct {
 	Extensions []string `xml:"Extension"`
 	Signatures []int    `xml:"InternalSignatureID"`
 	Priorities []int    `xml:"HasPriorityOverFileFormatID"`
+	// PUIDPriorities are priorities over formats in other signature files (a siegfried extension of the DROID
+	// schema, so that the formats of a signature extension can have priority over PRONOM formats)
+	PUIDPriorities []string `xml:"HasPriorityOverPUID"`
 }
@@ -15,6 +15,7 @@
 package pronom
 
 import (
+	"encoding/json"
 	"strings"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
@@ -34,6 +35,14 @@ func (f formatInfo) String() string {
 	return f.name
 }
 
+func (f formatInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		MIMEType string `json:"mimeType"`
+	}{f.name, f.version, f.mimeType})
+}
+
 // turn generic FormatInfo into PRONOM formatInfo
 func infos(m map[string]identifier.FormatInfo) map[string]formatInfo {
 	i := make(map[string]formatInfo, len(m))
@@ -103,6 +112,17 @@ func (r *reports) FamilyTypes() (map[string][]string, map[string][]string) {
 	return retf, rett
 }
 
+// Classes returns the format types of the reports (e.g. "Image (Raster)"), by PUID.
+func (r *reports) Classes() map[string]string {
+	ret := make(map[string]string, len(r.r))
+	for i, v := range r.r {
+		if t := strings.TrimSpace(v.Types); t != "" {
+			ret[r.p[i]] = t
+		}
+	}
+	return ret
+}
+
 func (r *reports) Labels() []string {
 	ret := make([]string, len(r.p))
 	for i, v := range r.r {
@@ -117,12 +137,33 @@ func (r *reports) IDs() []string {
 
 func (r *reports) Infos() map[string]identifier.FormatInfo {
 	infos := make(map[string]identifier.FormatInfo)
+	idx := make(map[int]int, len(r.r)) // PRONOM IDs to indexes of reports
 	for i, v := range r.r {
-		infos[r.p[i]] = formatInfo{v.Name, strings.TrimSpace(v.Version), v.MIME()}
+		idx[v.Id] = i
+	}
+	for i, v := range r.r {
+		infos[r.p[i]] = formatInfo{v.Name, strings.TrimSpace(v.Version), r.mime(i, idx)}
 	}
 	return infos
 }
 
+// mime returns the MIME type of the report at index i. Many PRONOM reports don't give a MIME type, so if it has none
+// it is taken from a format it is a direct subtype of. Other versions and equivalents aren't followed, as they often
+// have MIME types of their own that don't apply (e.g. KML's equivalent is KMZ).
+func (r *reports) mime(i int, idx map[int]int) string {
+	if m := r.r[i].MIME(); m != "" {
+		return m
+	}
+	for _, rel := range r.r[i].Relations {
+		if j, ok := idx[rel.Id]; ok && rel.Typ == "Is subtype of" {
+			if m := r.r[j].MIME(); m != "" {
+				return m
+			}
+		}
+	}
+	return ""
+}
+
 func globify(s []string) []string {
 	ret := make([]string, 0, len(s))
 	for _, v := range s {
@@ -292,6 +333,9 @@ func (d *droid) Priorities() priority.Map {
 			subordinate := idsPuids[w]
 			pMap.Add(subordinate, superior)
 		}
+		for _, w := range v.PUIDPriorities {
+			pMap.Add(w, superior)
+		}
 	}
 	pMap.Complete()
 	return pMap
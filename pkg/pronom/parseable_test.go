@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/pronom/internal/mappings"
 )
 
 // DROID parsing is tested by comparing it against Report parsing
@@ -43,3 +44,41 @@ func TestParseDroid(t *testing.T) {
 		}
 	}
 }
+
+// formats without a MIME type only take one from a format they are a direct subtype of
+func TestMIME(t *testing.T) {
+	config.SetHome(filepath.Join("..", "..", "cmd", "roy", "data"))
+	// KML is equivalent to KMZ, and X3D's versions are related to VRML's: neither have their MIME types
+	none := []string{"fmt/244", "fmt/579", "fmt/580", "fmt/581", "fmt/582"}
+	r, err := newReports(append([]string{"fmt/724", "fmt/94", "fmt/101"}, none...), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos := r.Infos()
+	for _, puid := range none {
+		if m := infos[puid].(formatInfo).mimeType; m != "" {
+			t.Errorf("expecting no MIME type for %s, got %s", puid, m)
+		}
+	}
+	if m := infos["fmt/724"].(formatInfo).mimeType; m != "application/vnd.google-earth.kmz" {
+		t.Errorf("expecting KMZ to keep its own MIME type, got %s", m)
+	}
+	mime := func(m string) []mappings.FormatIdentifier {
+		return []mappings.FormatIdentifier{{Typ: "MIME", Id: m}}
+	}
+	subtype := func(id int) []mappings.RelatedFormat {
+		return []mappings.RelatedFormat{{Typ: "Is subtype of", Id: id}}
+	}
+	r = &reports{p: []string{"a", "b", "c"}, r: []*mappings.Report{
+		{Id: 1, Identifiers: mime("application/xml")},
+		{Id: 2, Relations: subtype(1)},
+		{Id: 3, Relations: subtype(2)},
+	}}
+	infos = r.Infos()
+	if m := infos["b"].(formatInfo).mimeType; m != "application/xml" {
+		t.Errorf("expecting a subtype to take its supertype's MIME type, got %s", m)
+	}
+	if m := infos["c"].(formatInfo).mimeType; m != "" {
+		t.Errorf("expecting only a direct supertype's MIME type, got %s", m)
+	}
+}
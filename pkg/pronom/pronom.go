@@ -30,14 +30,19 @@ import (
 	"time"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher/frames"
+	"github.com/richardlehane/siegfried/internal/ebmlmatcher"
+	"github.com/richardlehane/siegfried/internal/fontmatcher"
 	"github.com/richardlehane/siegfried/internal/identifier"
+	"github.com/richardlehane/siegfried/internal/sqlitematcher"
+	"github.com/richardlehane/siegfried/internal/tiffmatcher"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/pronom/internal/mappings"
 )
 
 type pronom struct {
 	identifier.Parseable
-	c identifier.Parseable
+	c       identifier.Parseable
+	classes map[string]string // format classes (PRONOM's format types) by PUID, if built from reports
 }
 
 // add container IDs to the DROID IDs (this ensures container extensions register)
@@ -67,8 +72,54 @@ func (p *pronom) MSCFBs() ([][]string, [][]frames.Signature, []string, error) {
 	return p.c.MSCFBs()
 }
 
+// EBMLs derives EBML DocTypes (e.g. matroska and webm) from the byte signatures of formats that include the DocType
+// element of the EBML header.
+func (p *pronom) EBMLs() ([]string, []string) {
+	sigs, ids, err := p.Parseable.Signatures()
+	if err != nil {
+		return nil, nil
+	}
+	return ebmlmatcher.DocTypes(sigs, ids)
+}
+
+// SQLites derives SQLite signatures (application_ids and table names) from the byte signatures of formats that are
+// SQLite databases.
+func (p *pronom) SQLites() ([]sqlitematcher.Signature, []string) {
+	sigs, ids, err := p.Parseable.Signatures()
+	if err != nil {
+		return nil, nil
+	}
+	return sqlitematcher.Signatures(sigs, ids)
+}
+
+// TIFFs derives TIFF signatures (IFD entries) from the byte signatures of formats that are TIFFs.
+func (p *pronom) TIFFs() ([]tiffmatcher.Signature, []string) {
+	sigs, ids, err := p.Parseable.Signatures()
+	if err != nil {
+		return nil, nil
+	}
+	return tiffmatcher.Signatures(sigs, ids)
+}
+
+// Fonts derives font signatures (magic, flavor and table tags) from the byte signatures of formats that are fonts.
+func (p *pronom) Fonts() ([]fontmatcher.Signature, []string) {
+	sigs, ids, err := p.Parseable.Signatures()
+	if err != nil {
+		return nil, nil
+	}
+	return fontmatcher.Signatures(sigs, ids)
+}
+
 // Pronom creates a pronom object
 func NewPronom() (identifier.Parseable, error) {
+	p, err := newPronom()
+	if err != nil {
+		return nil, err
+	}
+	return identifier.ApplyConfig(p), nil
+}
+
+func newPronom() (*pronom, error) {
 	p := &pronom{
 		c: identifier.Blank{},
 	}
@@ -81,7 +132,7 @@ func NewPronom() (identifier.Parseable, error) {
 	if err := p.setParseables(); err != nil {
 		return nil, err
 	}
-	return identifier.ApplyConfig(p), nil
+	return p, nil
 }
 
 // set identifiers joins signatures in the DROID signature file with any extra reports and adds that to the pronom object
@@ -107,6 +158,7 @@ func (p *pronom) setParseables() error {
 			return fmt.Errorf("Pronom: error loading reports; got %s\nYou must download PRONOM reports to build a signature (unless you use the -noreports flag). You can use `roy harvest` to download reports", err)
 		}
 		p.Parseable = r
+		p.classes = r.Classes()
 	}
 	// add extensions
 	for _, v := range config.Extend() {
@@ -304,7 +356,7 @@ func ReleaseSet(path string, releases *mappings.Releases) error {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filepath.Join(config.Local("sets"), path), out, 0666)
+	return ioutil.WriteFile(filepath.Join(config.HomeFile("sets"), path), out, 0666)
 }
 
 // TypeSets writes three sets files based on PRONOM reports:
@@ -324,21 +376,21 @@ func TypeSets(p1, p2, p3 string) error {
 	if err != nil {
 		return err
 	}
-	if err := ioutil.WriteFile(filepath.Join(config.Local("sets"), p1), out, 0666); err != nil {
+	if err := ioutil.WriteFile(filepath.Join(config.HomeFile("sets"), p1), out, 0666); err != nil {
 		return err
 	}
 	out, err = json.MarshalIndent(families, "", "  ")
 	if err != nil {
 		return err
 	}
-	if err := ioutil.WriteFile(filepath.Join(config.Local("sets"), p2), out, 0666); err != nil {
+	if err := ioutil.WriteFile(filepath.Join(config.HomeFile("sets"), p2), out, 0666); err != nil {
 		return err
 	}
 	out, err = json.MarshalIndent(types, "", "  ")
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filepath.Join(config.Local("sets"), p3), out, 0666)
+	return ioutil.WriteFile(filepath.Join(config.HomeFile("sets"), p3), out, 0666)
 }
 
 // Extension set writes a sets file that links extensions to IDs.
@@ -363,7 +415,7 @@ func ExtensionSet(path string) error {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filepath.Join(config.Local("sets"), path), out, 0666)
+	return ioutil.WriteFile(filepath.Join(config.HomeFile("sets"), path), out, 0666)
 }
 
 func openXML(path string, els interface{}) error {
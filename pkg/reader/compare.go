@@ -15,16 +15,16 @@
 package reader
 
 import (
-	"encoding/csv"
-	"fmt"
 	"io"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
 )
 
+// Join options for comparisons control which fields are used to link the files in different results files.
 const (
 	Path int = iota
 	Filename
@@ -71,76 +71,122 @@ func keygen(join int, fi File) string {
 	}
 }
 
-func idStr(fi File) string {
-	ids := make([]string, len(fi.IDs))
-	for i, id := range fi.IDs {
-		ids[i] = id.String()
-	}
-	sort.Strings(ids)
-	return strings.Join(ids, ";")
+// Normalise controls how results are normalised before they are compared, so that a comparison reports real
+// differences rather than noise.
+type Normalise struct {
+	Versions    bool       // treat different versions of the same format (e.g. PDF 1.4 and PDF 1.5) as matching
+	MIME        bool       // compare MIME types rather than format IDs e.g. to compare PRONOM results with file(1) output
+	Equivalents [][]string // groups of format IDs that are treated as matching e.g. {{"fmt/40", "fmt/412"}}
+	// Rebase has a directory prefix for each results file (or an empty string for none). The prefixes are trimmed from
+	// the paths of the files, so that results for the same content at different mount points can be joined by relative path.
+	Rebase []string
 }
 
-func matches(res []string) bool {
-	if len(res) < 3 {
-		return false
+// rebase trims a directory prefix from a path and makes the relative path's separators slashes
+func rebase(path, prefix string) string {
+	if prefix == "" || !strings.HasPrefix(path, prefix) {
+		return path
 	}
-	m := res[1]
-	for _, r := range res[2:] {
-		if r != m {
-			return false
+	rel := path[len(prefix):]
+	if rel != "" && !isSep(rel[0]) && !isSep(prefix[len(prefix)-1]) {
+		return path // the prefix isn't a whole directory name e.g. /mnt/a for /mnt/ab/c
+	}
+	return strings.TrimLeft(strings.Replace(rel, "\\", "/", -1), "/")
+}
+
+type normaliser struct {
+	Normalise
+	equivs map[string]string
+}
+
+func newNormaliser(norm Normalise) *normaliser {
+	n := &normaliser{norm, make(map[string]string)}
+	for _, g := range norm.Equivalents {
+		for _, id := range g {
+			n.equivs[id] = g[0]
 		}
 	}
-	return true
+	return n
 }
 
-func Compare(w io.Writer, join int, paths ...string) error {
-	if len(paths) < 2 {
-		return fmt.Errorf("at least two results files must be provided for comparison; got %d", len(paths))
+func (n *normaliser) id(id core.Identification) string {
+	str := id.String()
+	if eq, ok := n.equivs[str]; ok {
+		return eq
 	}
-	readers := make([]Reader, len(paths))
-	for i, v := range paths {
-		f, err := os.Open(v)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		rdr, err := New(f, v)
-		if err != nil {
-			return err
+	did, ok := id.(*defaultID)
+	if !ok || !id.Known() {
+		return str
+	}
+	if n.MIME {
+		if mime := did.value("mime"); mime != "" {
+			return strings.ToLower(mime)
 		}
-		readers[i] = rdr
+		return str
 	}
-	files := make([]string, 0, 1000)
-	results := make(map[string][]string)
-	for i, rdr := range readers {
-		for f, e := rdr.Next(); e == nil; f, e = rdr.Next() {
-			key := keygen(join, f)
-			_, ok := results[key]
-			if !ok {
-				files = append(files, key)
-				def := make([]string, len(readers)+1)
-				def[0] = f.Path
-				for i := range def[1:] {
-					def[i+1] = "MISSING"
-				}
-				results[key] = def
+	if n.Versions {
+		if name := did.format(); name != "" {
+			if v := did.value("version"); v != "" {
+				name = strings.Replace(name, v, "", 1)
 			}
-			results[key][i+1] = idStr(f)
+			return strings.Join(strings.Fields(name), " ")
 		}
 	}
-	wrt := csv.NewWriter(w)
-	var complete bool = true
-	for _, f := range files {
-		if !matches(results[f]) {
-			complete = false
-			if err := wrt.Write(results[f]); err != nil {
-				return err
-			}
+	return str
+}
+
+func idStr(fi File, n *normaliser) string {
+	ids := make([]string, 0, len(fi.IDs))
+	seen := make(map[string]bool, len(fi.IDs))
+	for _, id := range fi.IDs {
+		str := id.String()
+		if n != nil {
+			str = n.id(id)
 		}
+		if !seen[str] {
+			ids = append(ids, str)
+			seen[str] = true
+		}
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ";")
+}
+
+func matches(ids []string) bool {
+	if len(ids) < 2 {
+		return false
 	}
-	wrt.Flush()
-	if complete {
-		fmt.Fprint(w, "COMPLETE MATCH\n")
+	m := ids[0]
+	for _, r := range ids[1:] {
+		if r != m {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare compares two or more results files, writing the paths and IDs of the files that don't match as CSV.
+func Compare(w io.Writer, join int, paths ...string) error {
+	return CompareNormalised(w, join, Normalise{}, paths...)
+}
+
+// CompareNormalised is like Compare, but normalises results before they are compared.
+// Results files are compared as they are read, using temp files for huge results files, so that the comparison
+// doesn't need to hold them in memory. Files are reported in the order of their keys (e.g. their paths).
+func CompareNormalised(w io.Writer, join int, norm Normalise, paths ...string) error {
+	return stream(join, norm, paths, false, newCSVSink(w))
+}
+
+// NewComparison reads and compares two or more results files, holding the results for every file in memory.
+// Use the Compare functions (e.g. CompareJSON) to compare huge results files.
+func NewComparison(join int, norm Normalise, paths ...string) (*Comparison, error) {
+	c := &Comparison{Results: paths, Files: make([]ComparedFile, 0, 1000)}
+	if err := walk(join, norm, paths, true, func(cf ComparedFile) error {
+		c.Summary.add(cf.Status)
+		c.Files = append(c.Files, cf)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
-	return nil
+	return c, nil
 }
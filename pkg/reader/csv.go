@@ -23,6 +23,8 @@ import (
 type sfCSV struct {
 	rdr         *csv.Reader
 	hh          string
+	errclass    bool // has an errclass column (the last column)
+	rawname     bool // has a rawname column (sf -normalise)
 	path        string
 	fields      [][]string
 	identifiers [][2]string
@@ -45,8 +47,16 @@ func newCSV(r io.Reader, path string) (Reader, error) {
 		fieldIdx   = -1
 		fields     = make([][]string, 0, 1)
 	)
+	if rec[len(rec)-1] == "errclass" {
+		sfc.errclass = true
+		rec = rec[:len(rec)-1]
+	}
+	if rec[fieldStart] == "rawname" {
+		sfc.rawname = true
+		fieldStart++
+	}
 	if rec[fieldStart] != "namespace" {
-		sfc.hh = rec[4]
+		sfc.hh = rec[fieldStart]
 		fieldStart++
 	}
 	if rec[fieldStart] != "namespace" {
@@ -65,9 +75,9 @@ func newCSV(r io.Reader, path string) (Reader, error) {
 	if err != nil {
 		return nil, fmt.Errorf("bad CSV, no results; got %v", err)
 	}
-	for i, v := range sfc.peek {
-		if rec[i] == "namespace" {
-			sfc.identifiers = append(sfc.identifiers, [2]string{v, ""})
+	for i, v := range rec {
+		if v == "namespace" {
+			sfc.identifiers = append(sfc.identifiers, [2]string{sfc.peek[i], ""})
 		}
 	}
 	return sfc, nil
@@ -87,6 +97,13 @@ func (sfc *sfCSV) Next() (File, error) {
 		return File{}, sfc.err
 	}
 	fieldStart := 4
+	var class string
+	if sfc.errclass {
+		class = sfc.peek[len(sfc.peek)-1]
+	}
+	if sfc.rawname {
+		fieldStart++
+	}
 	var hash string
 	if sfc.hh != "" {
 		hash = sfc.peek[fieldStart]
@@ -96,6 +113,7 @@ func (sfc *sfCSV) Next() (File, error) {
 	if err != nil {
 		return file, err
 	}
+	file.Err = classify(file.Err, class)
 	fn := sfc.peek[0]
 	for {
 		idStart := fieldStart
@@ -20,6 +20,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -101,8 +102,8 @@ func (dr *droid) Next() (File, error) {
 	if dr.peek == nil || dr.err != nil {
 		return File{}, dr.err
 	}
-	file, err := newFile(dr.peek[3], dr.peek[7], dr.peek[10], dr.peek[12], "")
-	fn := dr.peek[3]
+	file, err := newFile(droidPath(dr.peek[2], dr.peek[3]), dr.peek[7], dr.peek[10], dr.peek[12], "")
+	uri := dr.peek[2]
 	for {
 		file.IDs = append(file.IDs, newDefaultID(droidFields[0],
 			didVals(dr.peek[14], dr.peek[16], dr.peek[17], dr.peek[15], dr.peek[5], dr.peek[11])))
@@ -118,13 +119,50 @@ func (dr *droid) Next() (File, error) {
 		}
 		// multi line multi ids
 		err := dr.nextFile()
-		if err != nil || fn != dr.peek[3] {
+		if err != nil || uri != dr.peek[2] {
 			break
 		}
 	}
 	return file, err
 }
 
+// droidPath returns the path of a resource in a DROID profile. Resources within containers (e.g. zip files)
+// have URIs like zip:file:/home/x.zip!/dir/y.txt and their paths are given in sf's hash notation (/home/x.zip#dir/y.txt)
+// so that they can be compared with the results of sf -z.
+func droidPath(uri, path string) string {
+	parts := strings.Split(uri, "!/")
+	if len(parts) < 2 {
+		return path
+	}
+	// strip the container schemes (e.g. zip:, tar:, gzip:)
+	base := parts[0]
+	for !strings.HasPrefix(base, "file:") {
+		idx := strings.Index(base, ":")
+		if idx < 0 {
+			return path
+		}
+		base = base[idx+1:]
+	}
+	base = unescape(strings.TrimPrefix(base, "file:"))
+	// windows paths e.g. /C:/dir/x.zip
+	if len(base) > 2 && base[0] == '/' && base[2] == ':' {
+		base = strings.Replace(base[1:], "/", "\\", -1)
+	} else if strings.HasPrefix(base, "//") { // UNC paths
+		base = strings.Replace(base, "/", "\\", -1)
+	}
+	for i, p := range parts[1:] {
+		parts[i+1] = unescape(p)
+	}
+	return base + "#" + strings.Join(parts[1:], "#")
+}
+
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
 type droidNp struct {
 	buf  *bufio.Reader
 	path string
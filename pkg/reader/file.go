@@ -0,0 +1,89 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// results of the file(1) command e.g. `file --mime-type -r *`, with one "path: mime/type" line per file
+var (
+	fileToolIDs    = [][2]string{{"file", ""}}
+	fileToolFields = [][]string{{"ns", "id", "warning"}}
+)
+
+type fileTool struct {
+	scan *bufio.Scanner
+	path string
+}
+
+func newFileTool(r io.Reader, path string) (Reader, error) {
+	return &fileTool{bufio.NewScanner(r), path}, nil
+}
+
+// split a line of file(1) output into the path and MIME type; ok is false if the line isn't file(1) output
+func fileToolLine(line string) (path, mime string, ok bool) {
+	idx := strings.LastIndex(line, ": ")
+	if idx < 1 {
+		return "", "", false
+	}
+	path, mime = line[:idx], strings.TrimSpace(line[idx+2:])
+	if i := strings.Index(mime, ";"); i > 0 { // file -i adds the charset e.g. text/plain; charset=us-ascii
+		mime = mime[:i]
+	}
+	slash := strings.Index(mime, "/")
+	if slash < 1 || slash == len(mime)-1 || strings.ContainsAny(mime, " ,") {
+		return "", "", false
+	}
+	return path, mime, true
+}
+
+// isFileTool reports whether the first line of a results file is file(1) output
+func isFileTool(line string) bool {
+	_, _, ok := fileToolLine(strings.TrimRight(line, "\r\n"))
+	return ok
+}
+
+func (ft *fileTool) Head() Head {
+	return Head{
+		ResultsPath: ft.path,
+		Identifiers: fileToolIDs,
+		Fields:      fileToolFields,
+	}
+}
+
+func (ft *fileTool) Next() (File, error) {
+	for ft.scan.Scan() {
+		path, mime, ok := fileToolLine(ft.scan.Text())
+		if !ok || mime == "inode/directory" {
+			continue
+		}
+		var warn string
+		switch mime {
+		case "application/octet-stream", "inode/x-empty":
+			mime = "UNKNOWN"
+			warn = unknownWarn
+		}
+		file, err := newFile(path, "", "", "", "")
+		file.IDs = append(file.IDs, newDefaultID(fileToolFields[0], []string{fileToolIDs[0][0], mime, warn}))
+		return file, err
+	}
+	if err := ft.scan.Err(); err != nil {
+		return File{}, err
+	}
+	return File{}, io.EOF
+}
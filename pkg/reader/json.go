@@ -27,16 +27,23 @@ type sfJSON struct {
 	err  error
 }
 
-func next(dec *json.Decoder) ([]string, []string, error) {
+// next reads keys and values up to the start or end of an array. When reading a file's attributes, the children
+// arrays of nested results (sf -nest) are skipped, so that the contents of archives are read as the following files.
+func next(dec *json.Decoder, attrs bool) ([]string, []string, error) {
 	var (
-		tok json.Token
-		err error
-		i   int
+		tok      json.Token
+		err      error
+		i        int
+		children bool
 	)
 	keys, vals := make([]string, 0, 10), make([]string, 0, 10)
 	for tok, err = dec.Token(); err == nil; tok, err = dec.Token() {
 		switch tok := tok.(type) {
 		case string:
+			if attrs && i%2 == 0 && tok == "children" {
+				children = true
+				continue
+			}
 			if i%2 == 0 {
 				keys = append(keys, tok)
 			} else {
@@ -47,7 +54,17 @@ func next(dec *json.Decoder) ([]string, []string, error) {
 			i++
 			vals = append(vals, strconv.FormatFloat(tok, 'f', 0, 32))
 		case json.Delim:
-			if tok.String() == "[" || tok.String() == "]" {
+			switch tok.String() {
+			case "[":
+				if children {
+					children = false
+					continue
+				}
+				return keys, vals, nil
+			case "]":
+				if attrs {
+					continue
+				}
 				return keys, vals, nil
 			}
 		}
@@ -56,7 +73,7 @@ func next(dec *json.Decoder) ([]string, []string, error) {
 }
 
 func jsonRecord(dec *json.Decoder) (record, error) {
-	keys, vals, err := next(dec)
+	keys, vals, err := next(dec, true)
 	if err != nil {
 		return record{}, err
 	}
@@ -64,7 +81,7 @@ func jsonRecord(dec *json.Decoder) (record, error) {
 	for i, v := range vals {
 		m[keys[i]] = v
 	}
-	keys, vals, err = next(dec)
+	keys, vals, err = next(dec, false)
 	if err != nil {
 		return record{}, err
 	}
@@ -82,7 +99,7 @@ func newJSON(r io.Reader, path string) (Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-	next(sfj.dec) // throw away "files": [
+	next(sfj.dec, false) // throw away "files": [
 	sfj.peek, sfj.err = jsonRecord(sfj.dec)
 	sfj.head.HashHeader = getHash(sfj.peek.attributes)
 	sfj.head.Fields = getFields(sfj.peek.listFields, sfj.peek.listValues)
@@ -0,0 +1,284 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// Results files are compared with an external merge sort, so that huge results files can be compared without
+// holding them in memory. The rows of each results file are sorted by key in chunks of up to chunkRows rows.
+// Chunks are spilled to temp files unless a results file fits in a single chunk, and then merged.
+var chunkRows = 250000
+
+type row struct {
+	key     string
+	path    string
+	ids     string
+	details []string
+}
+
+// sortedRows returns the rows of a results file in key order. Where a results file has more than one row for a key
+// (e.g. files with the same name when joining by filename), the last row is returned.
+type sortedRows struct {
+	mem    []row // results that fit in a single chunk are sorted in memory
+	chunks chunkHeap
+	files  []*os.File
+}
+
+func newSortedRows(rdr Reader, join int, n *normaliser, prefix string, withDetails bool) (*sortedRows, error) {
+	s := &sortedRows{}
+	buf := make([]row, 0, 1024)
+	for f, e := rdr.Next(); e == nil; f, e = rdr.Next() {
+		f.Path = rebase(f.Path, prefix)
+		r := row{key: keygen(join, f), path: f.Path, ids: idStr(f, n)}
+		if withDetails {
+			r.details = details(f)
+		}
+		buf = append(buf, r)
+		if len(buf) >= chunkRows {
+			if err := s.spill(buf); err != nil {
+				s.close()
+				return nil, err
+			}
+			buf = buf[:0]
+		}
+	}
+	sort.SliceStable(buf, func(i, j int) bool { return buf[i].key < buf[j].key })
+	if len(s.files) == 0 {
+		s.mem = dedupe(buf)
+		return s, nil
+	}
+	if err := s.spill(buf); err != nil {
+		s.close()
+		return nil, err
+	}
+	for i, f := range s.files {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			s.close()
+			return nil, err
+		}
+		c := &chunk{idx: i, rdr: csv.NewReader(bufio.NewReader(f))}
+		c.rdr.FieldsPerRecord = -1
+		if err := c.advance(); err != nil {
+			s.close()
+			return nil, err
+		}
+		if c.ok {
+			s.chunks = append(s.chunks, c)
+		}
+	}
+	heap.Init(&s.chunks)
+	return s, nil
+}
+
+// keep the last of any rows with the same key
+func dedupe(rows []row) []row {
+	ret := rows[:0]
+	for i, r := range rows {
+		if i+1 < len(rows) && rows[i+1].key == r.key {
+			continue
+		}
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// spill sorts a chunk and writes it to a temp file
+func (s *sortedRows) spill(rows []row) error {
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].key < rows[j].key })
+	f, err := ioutil.TempFile("", "sfcompare")
+	if err != nil {
+		return err
+	}
+	s.files = append(s.files, f)
+	w := bufio.NewWriter(f)
+	cw := csv.NewWriter(w)
+	for _, r := range dedupe(rows) {
+		if err := cw.Write(append([]string{r.key, r.path, r.ids}, r.details...)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// next returns the next row in key order; ok is false when there are no more rows
+func (s *sortedRows) next() (r row, ok bool, err error) {
+	if s.chunks == nil {
+		if len(s.mem) == 0 {
+			return r, false, nil
+		}
+		r, s.mem = s.mem[0], s.mem[1:]
+		return r, true, nil
+	}
+	for len(s.chunks) > 0 && (!ok || s.chunks[0].head.key == r.key) {
+		c := s.chunks[0]
+		r, ok = c.head, true
+		if err = c.advance(); err != nil {
+			return r, false, err
+		}
+		if c.ok {
+			heap.Fix(&s.chunks, 0)
+		} else {
+			heap.Pop(&s.chunks)
+		}
+	}
+	return r, ok, nil
+}
+
+func (s *sortedRows) close() {
+	for _, f := range s.files {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	s.files = nil
+}
+
+type chunk struct {
+	idx  int
+	rdr  *csv.Reader
+	head row
+	ok   bool
+}
+
+func (c *chunk) advance() error {
+	rec, err := c.rdr.Read()
+	if err == io.EOF {
+		c.ok = false
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading compare temp file: %v", err)
+	}
+	c.head = row{key: rec[0], path: rec[1], ids: rec[2]}
+	if len(rec) > 3 {
+		c.head.details = rec[3:]
+	}
+	c.ok = true
+	return nil
+}
+
+// chunks are ordered by their next key, then by the order they were written, so rows for the same key are
+// merged in the order they were read
+type chunkHeap []*chunk
+
+func (h chunkHeap) Len() int { return len(h) }
+func (h chunkHeap) Less(i, j int) bool {
+	if h[i].head.key != h[j].head.key {
+		return h[i].head.key < h[j].head.key
+	}
+	return h[i].idx < h[j].idx
+}
+func (h chunkHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x interface{}) { *h = append(*h, x.(*chunk)) }
+func (h *chunkHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// walk compares results files, calling fn for each file in key order. The Details of compared files are only
+// populated if withDetails is true.
+func walk(join int, norm Normalise, paths []string, withDetails bool, fn func(ComparedFile) error) error {
+	if len(paths) < 2 {
+		return fmt.Errorf("at least two results files must be provided for comparison; got %d", len(paths))
+	}
+	var n *normaliser
+	if norm.Versions || norm.MIME || len(norm.Equivalents) > 0 {
+		n = newNormaliser(norm)
+	}
+	sorted := make([]*sortedRows, len(paths))
+	defer func() {
+		for _, s := range sorted {
+			if s != nil {
+				s.close()
+			}
+		}
+	}()
+	for i, v := range paths {
+		f, err := os.Open(v)
+		if err != nil {
+			return err
+		}
+		rdr, err := New(f, v)
+		if err == nil {
+			var prefix string
+			if i < len(norm.Rebase) {
+				prefix = norm.Rebase[i]
+			}
+			sorted[i], err = newSortedRows(rdr, join, n, prefix, withDetails)
+		}
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	heads := make([]row, len(paths))
+	oks := make([]bool, len(paths))
+	for i, s := range sorted {
+		var err error
+		if heads[i], oks[i], err = s.next(); err != nil {
+			return err
+		}
+	}
+	for {
+		key, found := "", false
+		for i, ok := range oks {
+			if ok && (!found || heads[i].key < key) {
+				key, found = heads[i].key, true
+			}
+		}
+		if !found {
+			return nil
+		}
+		cf := ComparedFile{IDs: make([]string, len(paths))}
+		if withDetails {
+			cf.Details = make([][]string, len(paths))
+		}
+		for i := range paths {
+			if !oks[i] || heads[i].key != key {
+				cf.IDs[i] = "MISSING"
+				continue
+			}
+			if cf.Path == "" {
+				cf.Path = heads[i].path
+			}
+			cf.IDs[i] = heads[i].ids
+			if withDetails {
+				cf.Details[i] = heads[i].details
+			}
+			var err error
+			if heads[i], oks[i], err = sorted[i].next(); err != nil {
+				return err
+			}
+		}
+		cf.setStatus()
+		if err := fn(cf); err != nil {
+			return err
+		}
+	}
+}
@@ -12,11 +12,30 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package reader parses results files: the YAML, JSON and CSV output of sf, DROID's CSV exports and
+// no profile output, fido's CSV output and the output of file(1) (e.g. file --mime-type -r).
+//
+// Example:
+//
+//	rdr, err := reader.Open("results.yaml")
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	defer rdr.Close()
+//	err = reader.Each(rdr, func(f reader.File) error {
+//	  for _, id := range f.Identifications() {
+//	    fmt.Println(f.Path, id.ID, id.Format)
+//	  }
+//	  return nil
+//	})
 package reader
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -24,6 +43,7 @@ import (
 	"github.com/richardlehane/siegfried/internal/checksum"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/writer"
 )
 
 const (
@@ -32,11 +52,13 @@ const (
 	extMismatch = "extension mismatch"
 )
 
+// Reader reads the files in a results file. Next returns io.EOF when there are no more files.
 type Reader interface {
 	Head() Head
 	Next() (File, error)
 }
 
+// Head describes a results file and the identifiers used to make it.
 type Head struct {
 	ResultsPath   string
 	SignaturePath string
@@ -46,8 +68,10 @@ type Head struct {
 	Identifiers   [][2]string
 	Fields        [][]string
 	HashHeader    string
+	SchemaVersion string // schemaVersion of sf's JSON output; empty for other results files and for JSON output before schema versions
 }
 
+// File is an identified file in a results file.
 type File struct {
 	Path string
 	Size int64
@@ -57,6 +81,89 @@ type File struct {
 	IDs  []core.Identification
 }
 
+// Identification is a result for a file. Results files have different fields (e.g. fido and file(1) results
+// don't have versions, and sf's PRONOM results can have a class field), so all the fields of a result are in Fields.
+type Identification struct {
+	Namespace string
+	ID        string
+	Format    string
+	Version   string
+	MIME      string
+	Basis     string
+	Warning   string
+	Known     bool
+	Fields    map[string]string
+}
+
+// Identifications returns typed results for the file.
+func (f File) Identifications() []Identification {
+	ret := make([]Identification, len(f.IDs))
+	for i, id := range f.IDs {
+		ret[i] = Identification{ID: id.String(), Warning: id.Warn(), Known: id.Known()}
+		did, ok := id.(*defaultID)
+		if !ok {
+			continue
+		}
+		ret[i].Fields = make(map[string]string, len(did.fields))
+		for j, field := range did.fields {
+			if j < len(did.values) {
+				ret[i].Fields[field] = did.values[j]
+			}
+		}
+		ret[i].Namespace = did.value("ns")
+		if ret[i].Namespace == "" {
+			ret[i].Namespace = did.value("namespace")
+		}
+		ret[i].Format = did.format()
+		ret[i].Version = did.value("version")
+		ret[i].MIME = did.value("mime")
+		ret[i].Basis = did.value("basis")
+	}
+	return ret
+}
+
+// Results is a results file opened with Open.
+type Results struct {
+	Reader
+	f *os.File
+}
+
+// Close closes the results file.
+func (r *Results) Close() error {
+	return r.f.Close()
+}
+
+// Open opens a results file, detecting its format. Close the results file when done.
+func Open(path string) (*Results, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	rdr, err := New(f, path)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Results{rdr, f}, nil
+}
+
+// Each calls fn for each file read by rdr. It returns the first error returned by fn or by the reader
+// (other than io.EOF at the end of the results file).
+func Each(rdr Reader, fn func(File) error) error {
+	for {
+		f, err := rdr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(f); err != nil {
+			return err
+		}
+	}
+}
+
 type record struct {
 	attributes map[string]string
 	listFields []string
@@ -89,7 +196,9 @@ func getHead(rec record) (Head, error) {
 }
 
 func newHeadMap(m map[string]string) (Head, error) {
-	return newHead(m["results"], m["signature"], m["scandate"], m["created"], m["siegfried"])
+	h, err := newHead(m["results"], m["signature"], m["scandate"], m["created"], m["siegfried"])
+	h.SchemaVersion = m["schemaVersion"]
+	return h, err
 }
 
 func newHead(resultsPath, sigPath, scanned, created, version string) (Head, error) {
@@ -157,9 +266,10 @@ func getFile(rec record) (File, error) {
 	if err != nil {
 		return f, err
 	}
+	f.Err = classify(f.Err, rec.attributes["errclass"])
 	var sidx, eidx int
 	for i, v := range rec.listFields {
-		if v == "ns" {
+		if v == "ns" || v == "namespace" {
 			eidx = i
 			if eidx > sidx {
 				f.IDs = append(f.IDs, newDefaultID(rec.listFields[sidx:eidx], rec.listValues[sidx:eidx]))
@@ -171,6 +281,14 @@ func getFile(rec record) (File, error) {
 	return f, nil
 }
 
+// classify gives an error from a results file its error class, so that it is written again when results are replayed
+func classify(err error, class string) error {
+	if err == nil || class == "" {
+		return err
+	}
+	return writer.ClassifyError(class, err)
+}
+
 func getIdentifiers(vals []string) [][2]string {
 	ret := make([][2]string, 0, len(vals)/2)
 	for i, v := range vals {
@@ -214,55 +332,50 @@ func getFields(keys, vals []string) [][]string {
 	return ret
 }
 
-type peekReader struct {
-	unread bool
-	peek   byte
-	rdr    io.Reader
-}
-
-func (pr *peekReader) Read(b []byte) (int, error) {
-	if pr.unread {
-		if len(b) < 1 {
-			return 0, nil
-		}
-		b[0] = pr.peek
-		pr.unread = false
-		if len(b) == 1 {
-			return 1, nil
-		}
-		i, e := pr.rdr.Read(b[1:])
-		return i + 1, e
-	}
-	return pr.rdr.Read(b)
-}
-
+// New returns a Reader for a results file. The format of the file (sf YAML, CSV or JSON; fido; DROID; or file(1))
+// is detected from its first line.
 func New(rdr io.Reader, path string) (Reader, error) {
-	buf := make([]byte, 1)
-	if _, err := rdr.Read(buf); err != nil {
+	br := bufio.NewReader(rdr)
+	buf, err := br.Peek(1)
+	if err != nil {
 		return nil, err
 	}
-	pr := &peekReader{true, buf[0], rdr}
+	switch buf[0] {
+	case '-', '{', '"':
+	default:
+		if line, _ := br.Peek(br.Size()); isFileTool(firstLine(line)) {
+			return newFileTool(br, path)
+		}
+	}
 	switch buf[0] {
 	case '-':
-		return newYAML(pr, path)
+		return newYAML(br, path)
 	case 'f':
-		return newCSV(pr, path)
+		return newCSV(br, path)
 	case '{':
-		return newJSON(pr, path)
+		return newJSON(br, path)
 	case 'O', 'K':
-		return newFido(pr, path)
+		return newFido(br, path)
 	case 'D':
-		return newDroidNp(pr, path)
+		return newDroidNp(br, path)
 	case '"':
-		return newDroid(pr, path)
+		return newDroid(br, path)
 	}
 	return nil, fmt.Errorf("not a valid results file, bad char %d", int(buf[0]))
 }
 
+func firstLine(buf []byte) string {
+	if idx := bytes.IndexByte(buf, '\n'); idx >= 0 {
+		buf = buf[:idx]
+	}
+	return string(buf)
+}
+
 type defaultID struct {
 	id     int
 	warn   int
 	known  bool
+	fields []string
 	values []string
 }
 
@@ -277,8 +390,26 @@ func (did *defaultID) Warn() string {
 func (did *defaultID) Values() []string        { return did.values }
 func (did *defaultID) Archive() config.Archive { return config.None }
 
+// format returns the format name: a format field in the legacy layout and in results from other tools, or a name field
+func (did *defaultID) format() string {
+	if f := did.value("format"); f != "" {
+		return f
+	}
+	return did.value("name")
+}
+
+// value returns the value of a named field, or an empty string if the ID has no such field
+func (did *defaultID) value(field string) string {
+	for i, v := range did.fields {
+		if v == field && i < len(did.values) {
+			return did.values[i]
+		}
+	}
+	return ""
+}
+
 func newDefaultID(fields, values []string) *defaultID {
-	did := &defaultID{values: values}
+	did := &defaultID{fields: fields, values: values}
 	for i, v := range fields {
 		switch v {
 		case "id", "identifier", "ID":
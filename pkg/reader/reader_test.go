@@ -2,8 +2,21 @@ package reader
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/writer"
 )
 
 const (
@@ -79,6 +92,76 @@ func TestDroid(t *testing.T) {
 	testRdr(t, "examples/ipresShowcase/droid-np.csv", ipresFiles, ipresDroidNpIDs)
 }
 
+const droidContainers = `"ID","PARENT_ID","URI","FILE_PATH","NAME","METHOD","STATUS","SIZE","TYPE","EXT","LAST_MODIFIED","EXTENSION_MISMATCH","HASH","FORMAT_COUNT","PUID","MIME_TYPE","FORMAT_NAME","FORMAT_VERSION"
+"2","0","file:/home/richard/test/","/home/richard/test","test",,"Done","","Folder",,"2015-08-30T21:43:29","false",,"",,"","",""
+"3","2","file:/home/richard/test/my%20files.zip","/home/richard/test/my files.zip","my files.zip","Signature","Done","320","Container","zip","2015-01-21T03:13:44","false",,"1","x-fmt/263","application/zip","ZIP Format",""
+"4","3","zip:file:/home/richard/test/my%20files.zip!/docs/a%20b.txt",,"a b.txt","Extension","Done","12","File","txt","2015-01-21T03:13:44","false",,"1","x-fmt/111","text/plain","Plain Text File",""
+"5","3","zip:file:/home/richard/test/my%20files.zip!/inner.zip",,"inner.zip","Signature","Done","100","Container","zip","2015-01-21T03:13:44","false",,"1","x-fmt/263","application/zip","ZIP Format",""
+"6","5","zip:file:/home/richard/test/my%20files.zip!/inner.zip!/c.txt",,"c.txt","Extension","Done","12","File","txt","2015-01-21T03:13:44","false",,"1","x-fmt/111","text/plain","Plain Text File",""
+`
+
+func TestDroidContainers(t *testing.T) {
+	rdr, err := newDroid(strings.NewReader(droidContainers), "droid.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		"/home/richard/test/my files.zip",
+		"/home/richard/test/my files.zip#docs/a b.txt",
+		"/home/richard/test/my files.zip#inner.zip",
+		"/home/richard/test/my files.zip#inner.zip#c.txt",
+	}
+	var i int
+	for f, e := rdr.Next(); e == nil; f, e = rdr.Next() {
+		if i >= len(expect) || f.Path != expect[i] || len(f.IDs) != 1 {
+			t.Fatalf("bad file %d: %s (%d IDs)", i, f.Path, len(f.IDs))
+		}
+		i++
+	}
+	if i != len(expect) {
+		t.Fatalf("expecting %d files, got %d", len(expect), i)
+	}
+	if p := droidPath("zip:file:/C:/My%20Documents/x.zip!/y.txt", ""); p != `C:\My Documents\x.zip#y.txt` {
+		t.Errorf("bad windows path, got %s", p)
+	}
+}
+
+const fileToolOut = `testdata/dir:         inode/directory
+testdata/a.pdf:     application/pdf
+testdata/Fido: out.csv: text/plain; charset=us-ascii
+testdata/empty:     inode/x-empty
+testdata/b.bin:     application/octet-stream
+`
+
+func TestFileTool(t *testing.T) {
+	rdr, err := New(strings.NewReader(fileToolOut), "file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rdr.(*fileTool); !ok {
+		t.Fatalf("expecting a file(1) reader, got %T", rdr)
+	}
+	expect := [][2]string{
+		{"testdata/a.pdf", "application/pdf"},
+		{"testdata/Fido: out.csv", "text/plain"},
+		{"testdata/empty", "UNKNOWN"},
+		{"testdata/b.bin", "UNKNOWN"},
+	}
+	var i int
+	for f, e := rdr.Next(); e == nil; f, e = rdr.Next() {
+		if i >= len(expect) || f.Path != expect[i][0] || len(f.IDs) != 1 || f.IDs[0].String() != expect[i][1] {
+			t.Fatalf("bad file %d: %v", i, f)
+		}
+		if f.IDs[0].Known() != (expect[i][1] != "UNKNOWN") {
+			t.Errorf("bad known for %s", f.Path)
+		}
+		i++
+	}
+	if i != len(expect) {
+		t.Fatalf("expecting %d files, got %d", len(expect), i)
+	}
+}
+
 func TestCompare(t *testing.T) {
 	w := &bytes.Buffer{}
 	if err := Compare(w, 0, "examples/ipresShowcase/droid-gui-m.csv", "examples/ipresShowcase/droid-gui-s.csv"); err != nil {
@@ -88,3 +171,298 @@ func TestCompare(t *testing.T) {
 		t.Fatalf("expecting a complete match; got %s", string(w.Bytes()))
 	}
 }
+
+func droidRow(id, path, puid, mime, format, version string) string {
+	return `"` + id + `","0","file:` + path + `","` + path + `","x","Signature","Done","10","File","","2015-01-21T03:13:44","false",,"1","` +
+		puid + `","` + mime + `","` + format + `","` + version + `"` + "\n"
+}
+
+func TestCompareNormalised(t *testing.T) {
+	head := `"ID","PARENT_ID","URI","FILE_PATH","NAME","METHOD","STATUS","SIZE","TYPE","EXT","LAST_MODIFIED","EXTENSION_MISMATCH","HASH","FORMAT_COUNT","PUID","MIME_TYPE","FORMAT_NAME","FORMAT_VERSION"` + "\n"
+	a := head + droidRow("1", "/a.pdf", "fmt/19", "application/pdf", "Acrobat PDF 1.5 - Portable Document Format", "1.5") +
+		droidRow("2", "/b.doc", "fmt/40", "application/msword", "Microsoft Word Document", "97-2003")
+	b := head + droidRow("1", "/a.pdf", "fmt/20", "application/pdf", "Acrobat PDF 1.6 - Portable Document Format", "1.6") +
+		droidRow("2", "/b.doc", "fmt/412", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "Microsoft Word for Windows", "2007 onwards")
+	c := "/a.pdf: application/pdf\n/b.doc: application/msword\n"
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i, v := range []string{a, b, c} {
+		paths[i] = filepath.Join(dir, strconv.Itoa(i))
+		if err := os.WriteFile(paths[i], []byte(v), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, test := range []struct {
+		norm   Normalise
+		paths  []string
+		expect int // number of mismatched files
+	}{
+		{Normalise{}, paths[:2], 2},
+		{Normalise{Versions: true}, paths[:2], 1},
+		{Normalise{Versions: true, Equivalents: [][]string{{"fmt/40", "fmt/412"}}}, paths[:2], 0},
+		{Normalise{}, []string{paths[0], paths[2]}, 2},
+		{Normalise{MIME: true}, []string{paths[0], paths[2]}, 0},
+	} {
+		w := &bytes.Buffer{}
+		if err := CompareNormalised(w, 0, test.norm, test.paths...); err != nil {
+			t.Fatal(err)
+		}
+		out := w.String()
+		if test.expect == 0 {
+			if out != "COMPLETE MATCH\n" {
+				t.Errorf("%+v: expecting a complete match, got %s", test.norm, out)
+			}
+		} else if n := strings.Count(out, "\n"); n != test.expect {
+			t.Errorf("%+v: expecting %d mismatches, got %s", test.norm, test.expect, out)
+		}
+	}
+}
+
+func TestComparisonReports(t *testing.T) {
+	c, err := NewComparison(Filename, Normalise{}, "examples/ipresShowcase/droid-gui-m.csv", "examples/ipresShowcase/fido.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Summary.Agree+c.Summary.Disagree+c.Summary.Missing != len(c.Files) || c.Summary.Disagree == 0 {
+		t.Fatalf("bad summary: %+v", c.Summary)
+	}
+	w := &bytes.Buffer{}
+	if err := c.WriteJSON(w); err != nil {
+		t.Fatal(err)
+	}
+	var d Comparison
+	if err := json.Unmarshal(w.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Summary != c.Summary || len(d.Files) != len(c.Files) || len(d.Files[0].Details) != 2 {
+		t.Errorf("bad JSON report: %+v", d.Summary)
+	}
+	pairs := c.Pairs()
+	if len(pairs) != 1 || pairs[0].Agree != c.Summary.Agree || pairs[0].Disagree != c.Summary.Disagree || len(pairs[0].Confusions) == 0 {
+		t.Fatalf("bad pairs: %+v", pairs)
+	}
+	var n int
+	for i, cf := range pairs[0].Confusions {
+		n += cf.Count
+		if i > 0 && cf.Count > pairs[0].Confusions[i-1].Count {
+			t.Errorf("confusions aren't sorted by frequency")
+		}
+	}
+	if n != pairs[0].Disagree {
+		t.Errorf("expecting confusions to count %d disagreements, got %d", pairs[0].Disagree, n)
+	}
+	w.Reset()
+	if err := c.WriteHTML(w); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(w.Bytes(), []byte(`<tr class="disagree">`)) {
+		t.Error("expecting disagreements in the HTML report")
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	head := `"ID","PARENT_ID","URI","FILE_PATH","NAME","METHOD","STATUS","SIZE","TYPE","EXT","LAST_MODIFIED","EXTENSION_MISMATCH","HASH","FORMAT_COUNT","PUID","MIME_TYPE","FORMAT_NAME","FORMAT_VERSION"` + "\n"
+	gold := head + droidRow("1", "/a", "fmt/1", "", "", "") + droidRow("2", "/b", "fmt/2", "", "", "") + droidRow("3", "/c", "fmt/3", "", "", "")
+	ref := head + droidRow("1", "/a", "fmt/1", "", "", "") + droidRow("2", "/b", "fmt/5", "", "", "") + droidRow("3", "/c", "fmt/3", "", "", "")
+	cand := head + droidRow("1", "/a", "fmt/1", "", "", "") + droidRow("2", "/b", "fmt/2", "", "", "") + droidRow("3", "/c", "fmt/4", "", "", "")
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i, v := range []string{gold, ref, cand} {
+		paths[i] = filepath.Join(dir, strconv.Itoa(i))
+		if err := os.WriteFile(paths[i], []byte(v), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := NewComparison(0, Normalise{}, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bl, err := c.Baseline(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bl.Reference != paths[1] || len(bl.Candidates) != 2 {
+		t.Fatalf("bad baseline report: %+v", bl)
+	}
+	if r := bl.Candidates[0]; r.Agree != 2 || r.Disagree != 1 || len(r.Regressions) != 0 {
+		t.Errorf("bad reference candidate: %+v", r)
+	}
+	r := bl.Candidates[1]
+	if r.Agree != 2 || r.Disagree != 1 || len(r.Regressions) != 1 || r.Regressions[0].Path != "/c" ||
+		len(r.Improvements) != 1 || r.Improvements[0].Path != "/b" {
+		t.Errorf("bad candidate: %+v", r)
+	}
+	if _, err := c.Baseline(3); err == nil {
+		t.Error("expecting an error for a bad baseline")
+	}
+}
+
+func TestRebase(t *testing.T) {
+	head := `"ID","PARENT_ID","URI","FILE_PATH","NAME","METHOD","STATUS","SIZE","TYPE","EXT","LAST_MODIFIED","EXTENSION_MISMATCH","HASH","FORMAT_COUNT","PUID","MIME_TYPE","FORMAT_NAME","FORMAT_VERSION"` + "\n"
+	a := head + droidRow("1", "/mnt/a/x/1.pdf", "fmt/1", "", "", "") + droidRow("2", "/mnt/a/2.pdf", "fmt/2", "", "", "")
+	b := head + droidRow("1", "/srv/b/x/1.pdf", "fmt/1", "", "", "") + droidRow("2", "/srv/b/2.pdf", "fmt/2", "", "", "")
+	dir := t.TempDir()
+	paths := make([]string, 2)
+	for i, v := range []string{a, b} {
+		paths[i] = filepath.Join(dir, strconv.Itoa(i))
+		if err := os.WriteFile(paths[i], []byte(v), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := NewComparison(0, Normalise{}, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Summary.Missing != 4 {
+		t.Fatalf("expecting all files to be missing without rebasing, got %+v", c.Summary)
+	}
+	c, err = NewComparison(0, Normalise{Rebase: []string{"/mnt/a", "/srv/b/"}}, paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Summary.Agree != 2 || c.Files[1].Path != "x/1.pdf" {
+		t.Fatalf("expecting rebased files to agree, got %+v", c.Summary)
+	}
+	if p := rebase("/mnt/ab/c", "/mnt/a"); p != "/mnt/ab/c" {
+		t.Errorf("expecting a prefix that isn't a whole directory to be ignored, got %s", p)
+	}
+	if p := rebase(`D:\data\c\d.txt`, `D:\data`); p != "c/d.txt" {
+		t.Errorf("bad windows rebase, got %s", p)
+	}
+}
+
+// comparisons of results files that are spilled to temp files should be the same as those sorted in memory
+func TestSpill(t *testing.T) {
+	paths := []string{"examples/ipresShowcase/droid-gui-m.csv", "examples/ipresShowcase/fido.csv", "examples/ipresShowcase/sf.csv"}
+	mem := &bytes.Buffer{}
+	if err := CompareJSON(mem, Filename, Normalise{}, paths...); err != nil {
+		t.Fatal(err)
+	}
+	defer func(rows int) { chunkRows = rows }(chunkRows)
+	chunkRows = 7
+	spilled := &bytes.Buffer{}
+	if err := CompareJSON(spilled, Filename, Normalise{}, paths...); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mem.Bytes(), spilled.Bytes()) {
+		t.Fatal("comparison with temp files doesn't match the comparison in memory")
+	}
+	var c Comparison
+	if err := json.Unmarshal(spilled.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Files) == 0 || c.Summary.Agree+c.Summary.Disagree+c.Summary.Missing != len(c.Files) {
+		t.Fatalf("bad comparison: %d files, %+v", len(c.Files), c.Summary)
+	}
+	for i := range c.Files[1:] {
+		if Base(c.Files[i].Path) > Base(c.Files[i+1].Path) {
+			t.Fatalf("files aren't sorted: %s before %s", c.Files[i].Path, c.Files[i+1].Path)
+		}
+	}
+}
+
+func TestIdentifications(t *testing.T) {
+	for _, p := range []string{"examples/ipresShowcase/sf.csv", "examples/ipresShowcase/sf.json", "examples/ipresShowcase/droid-gui-m.csv", "examples/ipresShowcase/fido.csv"} {
+		rdr, err := Open(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := rdr.Next()
+		rdr.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := f.Identifications()
+		if len(ids) == 0 || ids[0].Namespace == "" || ids[0].ID == "" || ids[0].Fields["id"] != ids[0].ID {
+			t.Errorf("%s: bad identification %+v", p, ids)
+		}
+	}
+}
+
+func ExampleEach() {
+	rdr, err := Open("examples/ipresShowcase/sf.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rdr.Close()
+	var n int
+	err = Each(rdr, func(f File) error {
+		if n++; n > 3 {
+			return nil
+		}
+		for _, id := range f.Identifications() {
+			fmt.Printf("%s: %s %s (%s)\n", Base(f.Path), id.Namespace, id.ID, id.Format)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// 0-0-0.jpg: pronom fmt/41 (Raw JPEG Stream)
+	// 0000008.bmp: pronom fmt/116 (Windows Bitmap)
+	// 0000009.bmp: pronom fmt/116 (Windows Bitmap)
+}
+
+func TestErrClass(t *testing.T) {
+	fields := []string{"namespace", "id", "format", "version", "mime", "basis", "warning"}
+	id := newDefaultID(fields, []string{"pronom", "fmt/1", "Broadcast WAVE", "0 Generic", "audio/x-wav", "byte match at 0, 4", ""})
+	for _, w := range []func(io.Writer) writer.Writer{writer.YAML, writer.JSON, writer.CSV} {
+		buf := &bytes.Buffer{}
+		wr := w(buf)
+		wr.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{fields}, "")
+		wr.File("a.wav", 10, "2015-05-24T16:59:13+10:00", nil, nil, []core.Identification{id})
+		wr.File("b.zip", 10, "2015-05-24T16:59:13+10:00", nil, writer.ClassifyError(writer.ClassDecompress, errors.New("bad zip")), []core.Identification{id})
+		wr.Tail()
+		out := buf.String()
+		if strings.HasPrefix(out, "filename,") && !strings.HasPrefix(out, "filename,filesize,modified,errors,namespace,") {
+			t.Errorf("expecting the errclass column to follow the existing CSV columns, got %s", out)
+		}
+		rdr, err := New(buf, "results")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var classes []string
+		if err = Each(rdr, func(f File) error {
+			classes = append(classes, writer.ErrorClass(f.Err))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(classes) != 2 || classes[0] != "" || classes[1] != writer.ClassDecompress {
+			t.Errorf("expecting error classes to be read, got %v from:\n%s", classes, buf.String())
+		}
+	}
+}
+
+func TestNested(t *testing.T) {
+	config.SetNest(true)
+	defer config.SetNest(false)
+	fields := []string{"namespace", "id", "format", "version", "mime", "basis", "warning"}
+	id := newDefaultID(fields, []string{"pronom", "x-fmt/263", "ZIP Format", "", "application/zip", "byte match at 0, 4", ""})
+	buf := &bytes.Buffer{}
+	wr := writer.JSON(buf)
+	wr.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{fields}, "")
+	for _, name := range []string{"a.zip", "a.zip#b.zip", "a.zip#b.zip#c.txt", "a.zip#d.txt", "e.txt"} {
+		wr.File(name, 10, "2015-05-24T16:59:13+10:00", nil, nil, []core.Identification{nestID{id}})
+	}
+	wr.Tail()
+	rdr, err := New(buf, "results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	if err = Each(rdr, func(f File) error {
+		names = append(names, f.Path)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "a.zip,a.zip#b.zip,a.zip#b.zip#c.txt,a.zip#d.txt,e.txt" {
+		t.Errorf("expecting nested results to be read in order, got %v from:\n%s", names, buf.String())
+	}
+}
+
+type nestID struct{ *defaultID }
+
+func (n nestID) Archive() config.Archive { return config.Zip }
@@ -0,0 +1,574 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reader
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Status of a file in a comparison.
+const (
+	Agree    = "agree"    // all results files have the same results for the file
+	Disagree = "disagree" // the results files have different results for the file
+	Missing  = "missing"  // the file is missing from at least one results file
+)
+
+// Comparison is the result of comparing two or more results files.
+type Comparison struct {
+	Results []string       `json:"results"` // paths of the results files compared
+	Summary Summary        `json:"summary"`
+	Files   []ComparedFile `json:"files"`
+}
+
+// Summary counts the files in a comparison by their status.
+type Summary struct {
+	Agree    int `json:"agree"`
+	Disagree int `json:"disagree"`
+	Missing  int `json:"missing"`
+}
+
+func (s *Summary) add(status string) {
+	switch status {
+	case Agree:
+		s.Agree++
+	case Disagree:
+		s.Disagree++
+	case Missing:
+		s.Missing++
+	}
+}
+
+// ComparedFile has the results for a file from each of the results files in a comparison.
+type ComparedFile struct {
+	Path    string     `json:"path"`
+	Status  string     `json:"status"`
+	IDs     []string   `json:"ids"`     // the (normalised) IDs given by each results file, or MISSING
+	Details [][]string `json:"details"` // the full results given by each results file, one string per ID
+}
+
+func (cf *ComparedFile) setStatus() {
+	switch {
+	case matches(cf.IDs):
+		cf.Status = Agree
+	case missing(cf.IDs):
+		cf.Status = Missing
+	default:
+		cf.Status = Disagree
+	}
+}
+
+func missing(ids []string) bool {
+	for _, id := range ids {
+		if id == "MISSING" {
+			return true
+		}
+	}
+	return false
+}
+
+// details describes each of a file's IDs with the values of its fields e.g. "fmt/19 (Acrobat PDF 1.5; application/pdf; signature)"
+func details(f File) []string {
+	ret := make([]string, len(f.IDs))
+	for i, id := range f.IDs {
+		vals := make([]string, 0, len(id.Values()))
+		for j, v := range id.Values() {
+			if j == 0 || v == "" || v == id.String() { // skip the namespace and the ID itself
+				continue
+			}
+			vals = append(vals, v)
+		}
+		ret[i] = id.String()
+		if len(vals) > 0 {
+			ret[i] += " (" + strings.Join(vals, "; ") + ")"
+		}
+	}
+	return ret
+}
+
+// a sink reports compared files as they are walked
+type sink interface {
+	add(cf ComparedFile) error
+	end(s Summary) error
+}
+
+// feed reports the files of an in-memory comparison to a sink
+func (c *Comparison) feed(sk sink) error {
+	for _, f := range c.Files {
+		if err := sk.add(f); err != nil {
+			return err
+		}
+	}
+	return sk.end(c.Summary)
+}
+
+// stream walks a comparison of results files, reporting each file to a sink
+func stream(join int, norm Normalise, paths []string, withDetails bool, sk sink) error {
+	var s Summary
+	if err := walk(join, norm, paths, withDetails, func(cf ComparedFile) error {
+		s.add(cf.Status)
+		return sk.add(cf)
+	}); err != nil {
+		return err
+	}
+	return sk.end(s)
+}
+
+type csvSink struct {
+	w  io.Writer
+	cw *csv.Writer
+}
+
+func newCSVSink(w io.Writer) *csvSink {
+	return &csvSink{w, csv.NewWriter(w)}
+}
+
+func (cs *csvSink) add(cf ComparedFile) error {
+	if cf.Status == Agree {
+		return nil
+	}
+	return cs.cw.Write(append([]string{cf.Path}, cf.IDs...))
+}
+
+func (cs *csvSink) end(s Summary) error {
+	cs.cw.Flush()
+	if err := cs.cw.Error(); err != nil {
+		return err
+	}
+	if s.Disagree == 0 && s.Missing == 0 {
+		_, err := fmt.Fprint(cs.w, "COMPLETE MATCH\n")
+		return err
+	}
+	return nil
+}
+
+// the files are written before the summary, so that the JSON can be streamed
+type jsonSink struct {
+	w   *bufio.Writer
+	n   int
+	err error
+}
+
+func newJSONSink(w io.Writer, results []string) *jsonSink {
+	js := &jsonSink{w: bufio.NewWriter(w)}
+	byts, _ := json.MarshalIndent(results, "  ", "  ")
+	js.write([]byte("{\n  \"results\": "), byts, []byte(",\n  \"files\": ["))
+	return js
+}
+
+func (js *jsonSink) write(bs ...[]byte) {
+	for _, b := range bs {
+		if js.err == nil {
+			_, js.err = js.w.Write(b)
+		}
+	}
+}
+
+func (js *jsonSink) add(cf ComparedFile) error {
+	byts, err := json.MarshalIndent(cf, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if js.n == 0 {
+		sep = ""
+	}
+	js.n++
+	js.write([]byte(sep+"\n    "), byts)
+	return js.err
+}
+
+func (js *jsonSink) end(s Summary) error {
+	byts, _ := json.MarshalIndent(s, "  ", "  ")
+	if js.n > 0 {
+		js.write([]byte("\n  "))
+	}
+	js.write([]byte("],\n  \"summary\": "), byts, []byte("\n}\n"))
+	if js.err != nil {
+		return js.err
+	}
+	return js.w.Flush()
+}
+
+type htmlSink struct {
+	w       *bufio.Writer
+	results []string
+	err     error
+}
+
+func newHTMLSink(w io.Writer, results []string) *htmlSink {
+	hs := &htmlSink{w: bufio.NewWriter(w), results: results}
+	hs.err = htmlReport.ExecuteTemplate(hs.w, "head", results)
+	return hs
+}
+
+func (hs *htmlSink) add(cf ComparedFile) error {
+	if hs.err == nil {
+		hs.err = htmlReport.ExecuteTemplate(hs.w, "row", struct {
+			Results []string
+			ComparedFile
+		}{hs.results, cf})
+	}
+	return hs.err
+}
+
+func (hs *htmlSink) end(s Summary) error {
+	if hs.err == nil {
+		hs.err = htmlReport.ExecuteTemplate(hs.w, "foot", struct {
+			Files int
+			Summary
+		}{s.Agree + s.Disagree + s.Missing, s})
+	}
+	if hs.err != nil {
+		return hs.err
+	}
+	return hs.w.Flush()
+}
+
+// WriteCSV writes the paths and IDs of the files that don't agree, or COMPLETE MATCH if all files agree.
+func (c *Comparison) WriteCSV(w io.Writer) error {
+	return c.feed(newCSVSink(w))
+}
+
+// WriteJSON writes the full comparison as JSON.
+func (c *Comparison) WriteJSON(w io.Writer) error {
+	return c.feed(newJSONSink(w, c.Results))
+}
+
+// WriteHTML writes the full comparison as a HTML report with a sortable table of files. The full results
+// for each file can be shown by clicking on its path.
+func (c *Comparison) WriteHTML(w io.Writer) error {
+	return c.feed(newHTMLSink(w, c.Results))
+}
+
+// CompareJSON compares results files, writing the full comparison as JSON as the files are compared.
+func CompareJSON(w io.Writer, join int, norm Normalise, paths ...string) error {
+	return stream(join, norm, paths, true, newJSONSink(w, paths))
+}
+
+// CompareHTML compares results files, writing the full comparison as a HTML report as the files are compared.
+func CompareHTML(w io.Writer, join int, norm Normalise, paths ...string) error {
+	return stream(join, norm, paths, true, newHTMLSink(w, paths))
+}
+
+// Pair summarises the comparison of two results files.
+type Pair struct {
+	Results    [2]string   // paths of the two results files
+	Agree      int         // number of files with the same results
+	Disagree   int         // number of files with different results
+	Missing    [2]int      // number of files missing from each results file
+	Confusions []Confusion // the disagreements, most frequent first
+}
+
+// Confusion counts the files for which one results file gave one result, and the other results file another.
+type Confusion struct {
+	IDs   [2]string
+	Count int
+}
+
+type pairSink struct {
+	pairs      []Pair
+	idxs       [][2]int
+	confusions []map[[2]string]int
+}
+
+func newPairSink(results []string) *pairSink {
+	ps := &pairSink{}
+	for i := 0; i < len(results); i++ {
+		for j := i + 1; j < len(results); j++ {
+			ps.pairs = append(ps.pairs, Pair{Results: [2]string{results[i], results[j]}})
+			ps.idxs = append(ps.idxs, [2]int{i, j})
+			ps.confusions = append(ps.confusions, make(map[[2]string]int))
+		}
+	}
+	return ps
+}
+
+func (ps *pairSink) add(f ComparedFile) error {
+	for x, idx := range ps.idxs {
+		p := &ps.pairs[x]
+		a, b := f.IDs[idx[0]], f.IDs[idx[1]]
+		switch {
+		case a == "MISSING" && b == "MISSING":
+		case a == "MISSING":
+			p.Missing[0]++
+		case b == "MISSING":
+			p.Missing[1]++
+		case a == b:
+			p.Agree++
+		default:
+			p.Disagree++
+			ps.confusions[x][[2]string{a, b}]++
+		}
+	}
+	return nil
+}
+
+func (ps *pairSink) end(Summary) error {
+	for x := range ps.pairs {
+		p := &ps.pairs[x]
+		for k, v := range ps.confusions[x] {
+			p.Confusions = append(p.Confusions, Confusion{k, v})
+		}
+		sort.Slice(p.Confusions, func(x, y int) bool {
+			if p.Confusions[x].Count != p.Confusions[y].Count {
+				return p.Confusions[x].Count > p.Confusions[y].Count
+			}
+			if p.Confusions[x].IDs[0] != p.Confusions[y].IDs[0] {
+				return p.Confusions[x].IDs[0] < p.Confusions[y].IDs[0]
+			}
+			return p.Confusions[x].IDs[1] < p.Confusions[y].IDs[1]
+		})
+	}
+	return nil
+}
+
+// Pairs summarises the comparison for each pair of results files.
+func (c *Comparison) Pairs() []Pair {
+	ps := newPairSink(c.Results)
+	c.feed(ps)
+	return ps.pairs
+}
+
+// ComparePairs compares results files and summarises the comparison for each pair of results files.
+func ComparePairs(join int, norm Normalise, paths ...string) ([]Pair, error) {
+	ps := newPairSink(paths)
+	if err := stream(join, norm, paths, false, ps); err != nil {
+		return nil, err
+	}
+	return ps.pairs, nil
+}
+
+// WriteSummary writes summary counts for each pair of results files, and a confusion matrix of their most frequent
+// disagreements (up to max per pair; 0 for all).
+func (c *Comparison) WriteSummary(w io.Writer, max int) error {
+	return WritePairs(w, c.Pairs(), max)
+}
+
+// WritePairs writes summary counts for pairs of results files, and a confusion matrix of their most frequent
+// disagreements (up to max per pair; 0 for all).
+func WritePairs(w io.Writer, pairs []Pair, max int) error {
+	for i, p := range pairs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s v %s\n", p.Results[0], p.Results[1])
+		fmt.Fprintf(w, "  agree: %d\n  disagree: %d\n", p.Agree, p.Disagree)
+		fmt.Fprintf(w, "  missing from %s: %d\n  missing from %s: %d\n", p.Results[0], p.Missing[0], p.Results[1], p.Missing[1])
+		if len(p.Confusions) == 0 {
+			continue
+		}
+		confusions := p.Confusions
+		if max > 0 && len(confusions) > max {
+			confusions = confusions[:max]
+		}
+		fmt.Fprintf(w, "  most frequent disagreements (%d of %d):\n", len(confusions), len(p.Confusions))
+		for _, cf := range confusions {
+			if _, err := fmt.Fprintf(w, "  %6d  %s -> %s\n", cf.Count, cf.IDs[0], cf.IDs[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Baseline compares candidate results files with a baseline results file (e.g. a gold standard).
+// Regressions and improvements are relative to the reference candidate (e.g. the results of the current
+// signature release, when evaluating a new release).
+type Baseline struct {
+	Baseline   string      `json:"baseline"`  // path of the baseline results file
+	Reference  string      `json:"reference"` // path of the reference candidate
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Candidate reports how a results file compares with a baseline.
+type Candidate struct {
+	Results      string   `json:"results"`
+	Agree        int      `json:"agree"`                  // number of files with the same results as the baseline
+	Disagree     int      `json:"disagree"`               // number of files with different results to the baseline
+	Missing      int      `json:"missing"`                // number of files in the baseline that are missing from the candidate
+	Regressions  []Change `json:"regressions,omitempty"`  // files the reference agreed with the baseline on, but the candidate doesn't
+	Improvements []Change `json:"improvements,omitempty"` // files the candidate agrees with the baseline on, but the reference didn't
+}
+
+// Change is a file with different results in a candidate and the reference.
+type Change struct {
+	Path      string `json:"path"`
+	Baseline  string `json:"baseline"`
+	Reference string `json:"reference"`
+	Candidate string `json:"candidate"`
+}
+
+type baselineSink struct {
+	bl      *Baseline
+	b, ref  int
+	indexes []int // indexes of the candidates
+}
+
+func newBaselineSink(results []string, b int) (*baselineSink, error) {
+	if b < 0 || b >= len(results) {
+		return nil, fmt.Errorf("bad baseline %d; there are %d results files", b, len(results))
+	}
+	bs := &baselineSink{b: b}
+	if b == 0 {
+		bs.ref = 1
+	}
+	bs.bl = &Baseline{Baseline: results[b], Reference: results[bs.ref]}
+	for i := range results {
+		if i != b {
+			bs.bl.Candidates = append(bs.bl.Candidates, Candidate{Results: results[i]})
+			bs.indexes = append(bs.indexes, i)
+		}
+	}
+	return bs, nil
+}
+
+func (bs *baselineSink) add(f ComparedFile) error {
+	base := f.IDs[bs.b]
+	if base == "MISSING" {
+		return nil
+	}
+	for x, i := range bs.indexes {
+		cand, id := &bs.bl.Candidates[x], f.IDs[i]
+		switch {
+		case id == "MISSING":
+			cand.Missing++
+		case id == base:
+			cand.Agree++
+		default:
+			cand.Disagree++
+		}
+		if i == bs.ref {
+			continue
+		}
+		change := Change{f.Path, base, f.IDs[bs.ref], id}
+		if change.Reference == base && id != base {
+			cand.Regressions = append(cand.Regressions, change)
+		} else if change.Reference != base && id == base {
+			cand.Improvements = append(cand.Improvements, change)
+		}
+	}
+	return nil
+}
+
+func (bs *baselineSink) end(Summary) error { return nil }
+
+// Baseline compares each of the other results files with the results file at index b. The reference candidate
+// is the first of the other results files.
+func (c *Comparison) Baseline(b int) (*Baseline, error) {
+	bs, err := newBaselineSink(c.Results, b)
+	if err != nil {
+		return nil, err
+	}
+	c.feed(bs)
+	return bs.bl, nil
+}
+
+// CompareBaseline compares each of the other results files with the results file at index b.
+func CompareBaseline(b, join int, norm Normalise, paths ...string) (*Baseline, error) {
+	bs, err := newBaselineSink(paths, b)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream(join, norm, paths, false, bs); err != nil {
+		return nil, err
+	}
+	return bs.bl, nil
+}
+
+// WriteText writes the counts for each candidate and lists their regressions and improvements.
+func (bl *Baseline) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "baseline: %s\nreference: %s\n", bl.Baseline, bl.Reference)
+	for _, cand := range bl.Candidates {
+		fmt.Fprintf(w, "\n%s\n  agree: %d\n  disagree: %d\n  missing: %d\n", cand.Results, cand.Agree, cand.Disagree, cand.Missing)
+		if cand.Results == bl.Reference {
+			continue
+		}
+		for _, l := range []struct {
+			label   string
+			changes []Change
+		}{{"regressions", cand.Regressions}, {"improvements", cand.Improvements}} {
+			fmt.Fprintf(w, "  %s: %d\n", l.label, len(l.changes))
+			for _, ch := range l.changes {
+				fmt.Fprintf(w, "    %s: baseline %s, reference %s, candidate %s\n", ch.Path, ch.Baseline, ch.Reference, ch.Candidate)
+			}
+		}
+	}
+	return nil
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Comparison of {{range $i, $r := .}}{{if $i}}, {{end}}{{$r}}{{end}}</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; cursor: pointer; }
+tr.agree td.status { background: #dfd; }
+tr.disagree td.status { background: #fdd; }
+tr.missing td.status { background: #ffd; }
+summary { cursor: pointer; }
+ul { margin: 4px 0; }
+</style>
+</head>
+<body>
+<h1>Comparison</h1>
+<ol>{{range .}}<li>{{.}}</li>{{end}}</ol>
+<p><label><input type="checkbox" id="hide" onchange="hide(this.checked)"> hide files that agree</label></p>
+<table id="files">
+<thead><tr><th onclick="sort(0)">path</th><th onclick="sort(1)">status</th>{{range $i, $r := .}}<th onclick="sort({{$i}} + 2)">{{$r}}</th>{{end}}</tr></thead>
+<tbody>
+{{end}}{{define "row"}}<tr class="{{.Status}}"><td><details><summary>{{.Path}}</summary>{{range $i, $d := .Details}}<p>{{index $.Results $i}}:</p><ul>{{range $d}}<li>{{.}}</li>{{else}}<li>MISSING</li>{{end}}</ul>{{end}}</details></td><td class="status">{{.Status}}</td>{{range .IDs}}<td>{{.}}</td>{{end}}</tr>
+{{end}}{{define "foot"}}</tbody>
+</table>
+<p id="summary">{{.Files}} files: {{.Agree}} agree, {{.Disagree}} disagree, {{.Missing}} missing from at least one results file.</p>
+<script>
+// the summary is written after the files so that the report can be streamed; show it above them
+var table = document.getElementById("files");
+table.parentNode.insertBefore(document.getElementById("summary"), table.previousElementSibling);
+var asc = [];
+function text(cell) {
+	var s = cell.querySelector("summary"); // sort paths without their details
+	return s ? s.textContent : cell.textContent;
+}
+function sort(col) {
+	var tbody = document.getElementById("files").tBodies[0];
+	var rows = Array.prototype.slice.call(tbody.rows);
+	asc[col] = !asc[col];
+	rows.sort(function(a, b) {
+		var x = text(a.cells[col]), y = text(b.cells[col]);
+		return (x < y ? -1 : x > y ? 1 : 0) * (asc[col] ? 1 : -1);
+	});
+	rows.forEach(function(r) { tbody.appendChild(r); });
+}
+function hide(h) {
+	var rows = document.getElementById("files").tBodies[0].rows;
+	for (var i = 0; i < rows.length; i++) {
+		if (rows[i].className === "agree") { rows[i].style.display = h ? "none" : ""; }
+	}
+}
+</script>
+</body>
+</html>
+{{end}}`))
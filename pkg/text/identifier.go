@@ -0,0 +1,263 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package text implements an identifier for structured text formats. Byte signatures can't tell much about text
+// files: PRONOM matches most of them as plain text (x-fmt/111). The text identifier sniffs the files that the text
+// matcher finds are text, with lightweight grammars for JSON, YAML, CSV/TSV (reporting their dialect: delimiter,
+// quoting and header row) and the source code of common programming languages.
+//
+// It is built with `roy build -text`, or added to a PRONOM signature file with `roy add -text`.
+package text
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/textmatcher"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+func init() {
+	core.MustRegister(core.Registration{ID: core.Text, Name: "text", Description: "structured text formats (JSON, YAML, CSV/TSV and source code)", Loader: Load})
+}
+
+type formatInfo struct {
+	name     string
+	mimeType string
+}
+
+// formats are the structured text formats that the sniffers recognise, by ID
+var formats = map[string]formatInfo{
+	"json":       {"JSON", "application/json"},
+	"jsonl":      {"JSON Lines", "application/jsonl"},
+	"yaml":       {"YAML", "application/yaml"},
+	"csv":        {"Comma-separated values", "text/csv"},
+	"tsv":        {"Tab-separated values", "text/tab-separated-values"},
+	"awk":        {"awk script", "text/x-awk"},
+	"c":          {"C source code", "text/x-c"},
+	"cpp":        {"C++ source code", "text/x-c++"},
+	"go":         {"Go source code", "text/x-go"},
+	"java":       {"Java source code", "text/x-java"},
+	"javascript": {"JavaScript source code", "text/javascript"},
+	"lua":        {"Lua source code", "text/x-lua"},
+	"perl":       {"Perl source code", "text/x-perl"},
+	"php":        {"PHP source code", "application/x-httpd-php"},
+	"python":     {"Python source code", "text/x-python"},
+	"r":          {"R source code", "text/x-r"},
+	"ruby":       {"Ruby source code", "text/x-ruby"},
+	"rust":       {"Rust source code", "text/rust"},
+	"shell":      {"Shell script", "application/x-sh"},
+	"sql":        {"SQL", "application/sql"},
+	"tcl":        {"Tcl script", "text/x-tcl"},
+}
+
+type Identifier struct {
+	name    string
+	details string
+	idx     int // the index of the text matcher results for this identifier
+}
+
+// New creates a text identifier.
+func New(opts ...config.Option) (core.Identifier, error) {
+	for _, v := range opts {
+		v()
+	}
+	return &Identifier{
+		name:    config.Name(),
+		details: config.Details(),
+	}, nil
+}
+
+func (i *Identifier) Save(ls *persist.LoadSaver) {
+	ls.SaveByte(core.Text)
+	ls.SaveString(i.name)
+	ls.SaveString(i.details)
+	ls.SaveSmallInt(i.idx)
+}
+
+func Load(ls *persist.LoadSaver) core.Identifier {
+	return &Identifier{
+		name:    ls.LoadString(),
+		details: ls.LoadString(),
+		idx:     ls.LoadSmallInt(),
+	}
+}
+
+// MarshalJSON encodes the identifier for export.
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type    string `json:"type"`
+		Name    string `json:"name"`
+		Details string `json:"details"`
+		Text    int    `json:"textmatcher"`
+	}{"text", i.name, i.details, i.idx})
+}
+
+// Add adds the identifier to the text matcher. It has no signatures for the other matchers.
+func (i *Identifier) Add(m core.Matcher, t core.MatcherType) (core.Matcher, error) {
+	if t != core.TextMatcher {
+		return m, nil
+	}
+	var err error
+	m, i.idx, err = textmatcher.Add(m, textmatcher.SignatureSet{}, nil)
+	return m, err
+}
+
+func (i *Identifier) Name() string {
+	return i.name
+}
+
+func (i *Identifier) Details() string {
+	return i.details
+}
+
+func (i *Identifier) Fields() []string {
+	return []string{"namespace", "id", "format", "mime", "basis", "warning"}
+}
+
+func ids() []string {
+	ret := make([]string, 0, len(formats))
+	for k := range formats {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (i *Identifier) String() string {
+	return fmt.Sprintf("Name: %s\nDetails: %s\nSniffed formats: %s\n", i.name, i.details, strings.Join(ids(), ", "))
+}
+
+// Inspect describes the formats the sniffers recognise (all of them, if no IDs are given).
+func (i *Identifier) Inspect(fids ...string) (string, error) {
+	if len(fids) == 0 {
+		fids = ids()
+	}
+	strs := make([]string, len(fids))
+	for j, id := range fids {
+		f, ok := formats[id]
+		if !ok {
+			return "", fmt.Errorf("text: no format with ID %s", id)
+		}
+		strs[j] = fmt.Sprintf("%s: %s (%s)", id, f.name, f.mimeType)
+	}
+	return strings.Join(strs, "\n"), nil
+}
+
+func (i *Identifier) GraphP(int) string {
+	return "no priorities set"
+}
+
+func (i *Identifier) Recognise(m core.MatcherType, idx int) (bool, string) {
+	if m == core.TextMatcher && idx == i.idx {
+		return true, i.name + ": text sniffers"
+	}
+	return false, ""
+}
+
+func (i *Identifier) Recorder() core.Recorder {
+	return &Recorder{Identifier: i}
+}
+
+type Recorder struct {
+	*Identifier
+	text  bool // whether the text matcher found the file is text
+	basis string
+	sniff textmatcher.Sniff
+}
+
+func (r *Recorder) Active(core.MatcherType) {}
+
+func (r *Recorder) Record(m core.MatcherType, res core.Result) bool {
+	if m != core.TextMatcher || res.Index() != r.idx {
+		return false
+	}
+	r.text, r.basis = true, res.Basis()
+	if s, ok := res.(textmatcher.Sniffer); ok {
+		r.sniff = s.Sniff()
+	}
+	return true
+}
+
+// Satisfied reports false: the text identifier has nothing to report until the text matcher, the last of them, has run.
+// As it has no signatures for the other matchers, it gives no hints.
+func (r *Recorder) Satisfied(core.MatcherType) (bool, core.Hint) {
+	return false, core.Hint{}
+}
+
+func (r *Recorder) Report() []core.Identification {
+	switch {
+	case !r.text:
+		return []core.Identification{Identification{
+			Namespace: r.Name(),
+			ID:        "UNKNOWN",
+			Warning:   "no match",
+		}}
+	case r.sniff.ID == "":
+		return []core.Identification{Identification{
+			Namespace: r.Name(),
+			ID:        "UNKNOWN",
+			Basis:     r.basis,
+			Warning:   "no structured text format matched",
+		}}
+	}
+	f := formats[r.sniff.ID]
+	return []core.Identification{Identification{
+		Namespace: r.Name(),
+		ID:        r.sniff.ID,
+		Name:      f.name,
+		MIME:      f.mimeType,
+		Basis:     r.basis + "; " + r.sniff.Basis,
+	}}
+}
+
+type Identification struct {
+	Namespace string
+	ID        string
+	Name      string
+	MIME      string
+	Basis     string
+	Warning   string
+}
+
+func (id Identification) String() string {
+	return id.ID
+}
+
+func (id Identification) Known() bool {
+	return id.ID != "UNKNOWN"
+}
+
+func (id Identification) Warn() string {
+	return id.Warning
+}
+
+func (id Identification) Values() []string {
+	return []string{
+		id.Namespace,
+		id.ID,
+		id.Name,
+		id.MIME,
+		id.Basis,
+		id.Warning,
+	}
+}
+
+func (id Identification) Archive() config.Archive {
+	return config.None
+}
@@ -0,0 +1,75 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/internal/textmatcher"
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+func identify(t *testing.T, i core.Identifier, m core.Matcher, s string) core.Identification {
+	buf, err := siegreader.New().Get(strings.NewReader(s))
+	if buf == nil {
+		t.Fatal(err)
+	}
+	res, _ := m.Identify("", buf)
+	rec := i.Recorder()
+	for r := range res {
+		rec.Record(core.TextMatcher, r)
+	}
+	ids := rec.Report()
+	if len(ids) != 1 {
+		t.Fatalf("expecting one identification, got %v", ids)
+	}
+	return ids[0]
+}
+
+func TestIdentify(t *testing.T) {
+	i, _ := New(config.Clear(), config.SetText())
+	defer config.Clear()()
+	// the text identifier follows another identifier in the text matcher
+	m, _, _ := textmatcher.Add(nil, textmatcher.SignatureSet{}, nil)
+	m, err := i.Add(m, core.TextMatcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := i.Recognise(core.TextMatcher, 1); ok {
+		t.Error("the text identifier shouldn't recognise the first identifier's result")
+	}
+	for _, test := range []struct {
+		text   string
+		expect string
+	}{
+		{"a\tb\n1\t2\n", "text tsv Tab-separated values text/tab-separated-values text match ASCII; delimiter tab, no quoting, header row; 2 fields, 2 records "},
+		{"plain text\n", "text UNKNOWN   text match ASCII no structured text format matched"},
+		{"\x00\x01\x02\x03", "text UNKNOWN    no match"},
+	} {
+		if got := strings.Join(identify(t, i, m, test.text).Values(), " "); got != test.expect {
+			t.Errorf("expecting %q, got %q", test.expect, got)
+		}
+	}
+}
+
+func TestIO(t *testing.T) {
+	i, _ := New(config.Clear(), config.SetText())
+	defer config.Clear()()
+	m, _, _ := textmatcher.Add(nil, textmatcher.SignatureSet{}, nil)
+	i.Add(m, core.TextMatcher)
+	saver := persist.NewLoadSaver(nil)
+	i.Save(saver)
+	loader := persist.NewLoadSaver(saver.Bytes())
+	j := core.LoadIdentifier(loader)
+	if loader.Err != nil {
+		t.Fatal(loader.Err)
+	}
+	if i.String() != j.String() || i.Name() != "text" {
+		t.Errorf("expecting the loaded identifier %s to equal %s", j, i)
+	}
+	if ok, _ := j.Recognise(core.TextMatcher, 2); !ok {
+		t.Error("expecting the loaded identifier to recognise its text matcher result")
+	}
+}
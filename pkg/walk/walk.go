@@ -0,0 +1,327 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package walk walks file systems for identification.
+// It is used by the sf command and by the siegfried library's IdentifyStream walkers, and works with any fs.FS
+// (e.g. os.DirFS, an embed.FS, a zip.Reader or a cloud storage FS).
+//
+// Example:
+//
+//	err := walk.FS(os.DirFS("/home/me"), ".", walk.Options{MaxDepth: 2}, func(e walk.Entry) error {
+//		if e.Err != nil {
+//			return e.Err
+//		}
+//		fmt.Println(e.Path, e.Info.Size())
+//		return nil
+//	})
+package walk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MaxLinks is the maximum number of symbolic links followed in a single path when following symlinks.
+// It guards against link cycles in file systems that can't report whether two directories are the same file.
+const MaxLinks = 16
+
+// ErrLoop is reported for a symbolic link to a directory that is one of its own ancestors, or that would exceed MaxLinks.
+var ErrLoop = errors.New("walk: symbolic link loop")
+
+// SkipDir can be returned by a WalkFunc, when called for a directory, to skip that directory's contents.
+var SkipDir = fs.SkipDir
+
+// Entry is a file or directory found during a walk.
+// If Err is set, the entry couldn't be accessed and Info is nil.
+type Entry struct {
+	Path string      // path of the entry: slash-separated for FS, an OS path (joined with the root) for Dir
+	Info fs.FileInfo // for symlinks that are followed, this is the info for the link target
+	Err  error
+}
+
+// A WalkFunc is called for each entry in a walk. Walking stops if it returns an error other than SkipDir.
+// Errors accessing entries are reported to the WalkFunc: return nil to carry on walking or the error to stop.
+type WalkFunc func(e Entry) error
+
+// Options control a walk. The zero value walks the whole tree, reporting files only and not following symlinks.
+type Options struct {
+	// Filter, if set, is called for each entry (other than the root) before it is reported.
+	// Files are reported only if Filter returns true; directories are skipped entirely if Filter returns false.
+	Filter func(path string, d fs.DirEntry) bool
+	// MaxDepth limits the depth of the walk: 1 reports the contents of the root only, 2 includes the contents of
+	// its immediate subdirectories, and so on. 0 means no limit.
+	MaxDepth int
+	// FollowSymlinks reports symbolic links as their targets and descends into linked directories.
+	// Otherwise symbolic links are reported as entries of type fs.ModeSymlink.
+	FollowSymlinks bool
+	// Dirs reports directories (including the root) as well as files.
+	Dirs bool
+	// Retry, if set, is called when a directory can't be read, with the error and the number of times the directory
+	// has been tried. If it returns true, the directory is read again (Retry can wait first, e.g. to back off from a
+	// network share that is briefly unavailable). Otherwise the error is reported. Dir also retries its root.
+	Retry func(err error, tries int) bool
+}
+
+// Match returns a Filter that reports files with base names matching any of the glob patterns (see path.Match).
+// Directories are always walked.
+func Match(patterns ...string) func(string, fs.DirEntry) bool {
+	return func(p string, d fs.DirEntry) bool {
+		if d.IsDir() {
+			return true
+		}
+		for _, pat := range patterns {
+			if ok, _ := path.Match(pat, d.Name()); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FS walks the file tree rooted at root in fsys in lexical order, calling fn for each entry.
+func FS(fsys fs.FS, root string, opts Options, fn WalkFunc) error {
+	w := &walker{
+		walkDir: func(p string, wfn fs.WalkDirFunc) error { return fs.WalkDir(fsys, p, wfn) },
+		stat:    func(p string) (fs.FileInfo, error) { return fs.Stat(fsys, p) },
+		dir:     path.Dir,
+		rel: func(p string) string {
+			if root == "." {
+				return p
+			}
+			return strings.TrimPrefix(p, root+"/")
+		},
+		sep:  "/",
+		root: root,
+		opts: opts,
+		fn:   fn,
+	}
+	err := w.walk(root, 0)
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+// Dir walks a directory tree on disk. Paths are reported as OS paths joined with root.
+// Root may also be a file, in which case just that file is reported.
+// Like filepath.Walk, a root that is a symbolic link isn't followed unless FollowSymlinks is set.
+// Unlike FS with an os.DirFS, Dir walks names that aren't valid fs.FS paths (e.g. names that aren't UTF-8).
+func Dir(root string, opts Options, fn WalkFunc) error {
+	stat := func() (fs.FileInfo, error) {
+		info, err := os.Lstat(root)
+		if err == nil && opts.FollowSymlinks && info.Mode()&fs.ModeSymlink != 0 {
+			info, err = os.Stat(root)
+		}
+		return info, err
+	}
+	info, err := stat()
+	for tries := 1; err != nil && opts.Retry != nil && opts.Retry(err, tries); tries++ {
+		info, err = stat()
+	}
+	if err != nil {
+		return fn(Entry{Path: root, Err: err})
+	}
+	if !info.IsDir() {
+		return fn(Entry{Path: root, Info: info})
+	}
+	w := &walker{
+		walkDir: walkDir,
+		stat:    os.Stat,
+		dir:     filepath.Dir,
+		rel: func(p string) string {
+			r, _ := filepath.Rel(root, p)
+			return r
+		},
+		sep:  string(filepath.Separator),
+		root: root,
+		opts: opts,
+		fn:   fn,
+	}
+	err = w.walk(root, 0)
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+// walkDir is fs.WalkDir for OS paths. Like fs.WalkDir (and unlike filepath.WalkDir), a root that is a symbolic link
+// to a directory is walked, so that the walker can descend into linked directories.
+func walkDir(root string, fn fs.WalkDirFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDirEntry(root, statDirEntry{info}, fn)
+	}
+	if err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+func walkDirEntry(p string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(p, d, nil); err != nil || !d.IsDir() {
+		if err == SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		// report the read error; the entries read before it are walked unless the directory is skipped
+		if err = fn(p, d, err); err != nil {
+			if err == SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, e := range entries {
+		if err := walkDirEntry(filepath.Join(p, e.Name()), e, fn); err != nil {
+			if err == SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// statDirEntry is a DirEntry for the root of a walk, from its FileInfo
+type statDirEntry struct{ info fs.FileInfo }
+
+func (d statDirEntry) Name() string               { return d.info.Name() }
+func (d statDirEntry) IsDir() bool                { return d.info.IsDir() }
+func (d statDirEntry) Type() fs.FileMode          { return d.info.Mode().Type() }
+func (d statDirEntry) Info() (fs.FileInfo, error) { return d.info, nil }
+
+// walker walks an fs.FS (with slash-separated paths) or the disk (with OS paths)
+type walker struct {
+	walkDir func(string, fs.WalkDirFunc) error
+	stat    func(string) (fs.FileInfo, error)
+	dir     func(string) string // the parent directory of a path
+	rel     func(string) string // a path relative to the root
+	sep     string
+
+	root  string
+	opts  Options
+	fn    WalkFunc
+	tries map[string]int // the number of times directories that couldn't be read have been tried
+}
+
+// retry reports whether a directory that couldn't be read should be tried again
+func (w *walker) retry(p string, err error) bool {
+	if w.opts.Retry == nil {
+		return false
+	}
+	if w.tries == nil {
+		w.tries = make(map[string]int)
+	}
+	w.tries[p]++
+	return w.opts.Retry(err, w.tries[p])
+}
+
+// depth returns the depth of p below the root
+func (w *walker) depth(p string) int {
+	if p == w.root {
+		return 0
+	}
+	return strings.Count(w.rel(p), w.sep) + 1
+}
+
+// loop reports whether a directory is the same file as one of the ancestors of path p.
+// This relies on os.SameFile so only detects loops on disk (with Dir, or FS with an os.DirFS).
+func (w *walker) loop(p string, info fs.FileInfo) bool {
+	for dir := w.dir(p); ; dir = w.dir(dir) {
+		if di, err := w.stat(dir); err == nil && os.SameFile(di, info) {
+			return true
+		}
+		if w.dir(dir) == dir {
+			return false
+		}
+	}
+}
+
+func (w *walker) walk(start string, links int) error {
+	return w.walkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// walk a directory that couldn't be read again, if it should be retried. Its contents are walked
+			// afresh, as they aren't walked after a read error.
+			if d != nil && d.IsDir() && w.retry(p, err) {
+				if err = w.walk(p, links); err != nil {
+					return err
+				}
+				return SkipDir
+			}
+			// report the error; if it was a directory that couldn't be read, skip it
+			if ferr := w.fn(Entry{Path: p, Err: err}); ferr != nil {
+				return ferr
+			}
+			if d != nil && d.IsDir() {
+				return SkipDir
+			}
+			return nil
+		}
+		depth := w.depth(p)
+		if p != start && w.opts.Filter != nil && !w.opts.Filter(p, d) {
+			if d.IsDir() {
+				return SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if p != w.root && w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth {
+				return SkipDir
+			}
+			// the target of a followed link, or a directory being retried, has already been reported
+			if !w.opts.Dirs || (p == start && (p != w.root || w.tries[p] > 0)) {
+				return nil
+			}
+		}
+		var info fs.FileInfo
+		if d.Type()&fs.ModeSymlink != 0 && w.opts.FollowSymlinks {
+			info, err = w.stat(p)
+			if err != nil {
+				return w.fn(Entry{Path: p, Err: err})
+			}
+			if info.IsDir() {
+				if links >= MaxLinks || w.loop(p, info) {
+					return w.fn(Entry{Path: p, Err: &fs.PathError{Op: "walk", Path: p, Err: ErrLoop}})
+				}
+				if w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth {
+					return nil
+				}
+				if w.opts.Dirs {
+					if err = w.fn(Entry{Path: p, Info: info}); err != nil {
+						if err == SkipDir {
+							return nil
+						}
+						return err
+					}
+				}
+				if err = w.walk(p, links+1); err == SkipDir {
+					return nil
+				}
+				return err
+			}
+		} else if info, err = d.Info(); err != nil {
+			return w.fn(Entry{Path: p, Err: err})
+		}
+		return w.fn(Entry{Path: p, Info: info})
+	})
+}
@@ -0,0 +1,212 @@
+package walk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"testing/fstest"
+)
+
+var testFS = fstest.MapFS{
+	"a.txt":       {Data: []byte("a")},
+	"b.pdf":       {Data: []byte("b")},
+	"sub/c.txt":   {Data: []byte("c")},
+	"sub/d/e.txt": {Data: []byte("e")},
+}
+
+func paths(t *testing.T, fsys fs.FS, root string, opts Options) []string {
+	var ret []string
+	err := FS(fsys, root, opts, func(e Entry) error {
+		if e.Err != nil {
+			return e.Err
+		}
+		ret = append(ret, e.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func TestFS(t *testing.T) {
+	tests := []struct {
+		opts Options
+		root string
+		want []string
+	}{
+		{Options{}, ".", []string{"a.txt", "b.pdf", "sub/c.txt", "sub/d/e.txt"}},
+		{Options{}, "sub", []string{"sub/c.txt", "sub/d/e.txt"}},
+		{Options{MaxDepth: 1}, ".", []string{"a.txt", "b.pdf"}},
+		{Options{MaxDepth: 2}, ".", []string{"a.txt", "b.pdf", "sub/c.txt"}},
+		{Options{MaxDepth: 1}, "sub", []string{"sub/c.txt"}},
+		{Options{Dirs: true, MaxDepth: 2}, ".", []string{".", "a.txt", "b.pdf", "sub", "sub/c.txt"}},
+		{Options{Filter: Match("*.txt")}, ".", []string{"a.txt", "sub/c.txt", "sub/d/e.txt"}},
+		{Options{Filter: func(p string, d fs.DirEntry) bool { return p != "sub/d" }}, ".", []string{"a.txt", "b.pdf", "sub/c.txt"}},
+	}
+	for i, test := range tests {
+		if got := paths(t, testFS, test.root, test.opts); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("test %d: expecting %v, got %v", i, test.want, got)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var got []string
+	err := FS(testFS, "missing", Options{}, func(e Entry) error {
+		if e.Err != nil {
+			got = append(got, e.Path)
+		}
+		return nil
+	})
+	if err != nil || len(got) != 1 || got[0] != "missing" {
+		t.Errorf("expecting the error to be reported for missing, got %v and %v", got, err)
+	}
+	stop := errors.New("stop")
+	err = FS(testFS, ".", Options{}, func(e Entry) error { return stop })
+	if err != stop {
+		t.Errorf("expecting the walk to stop with the WalkFunc's error, got %v", err)
+	}
+}
+
+// flakyFS fails to read a directory the first n times
+type flakyFS struct {
+	fstest.MapFS
+	dir string
+	n   int
+}
+
+var errFlaky = errors.New("network hiccup")
+
+func (f *flakyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == f.dir && f.n > 0 {
+		f.n--
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errFlaky}
+	}
+	return f.MapFS.ReadDir(name)
+}
+
+func TestRetry(t *testing.T) {
+	var tried []int
+	retry := func(err error, tries int) bool {
+		tried = append(tried, tries)
+		return errors.Is(err, errFlaky) && tries <= 2
+	}
+	for _, dir := range []string{"sub", "."} {
+		tried = nil
+		fsys := &flakyFS{testFS, dir, 2}
+		want := []string{".", "a.txt", "b.pdf", "sub", "sub/c.txt", "sub/d", "sub/d/e.txt"}
+		if got := paths(t, fsys, ".", Options{Dirs: true, Retry: retry}); !reflect.DeepEqual(got, want) || !reflect.DeepEqual(tried, []int{1, 2}) {
+			t.Errorf("retrying %s: expecting %v after 2 tries, got %v after %v", dir, want, got, tried)
+		}
+	}
+	// give up after the retries are exhausted
+	tried = nil
+	var errs int
+	err := FS(&flakyFS{testFS, "sub", 3}, ".", Options{Retry: retry}, func(e Entry) error {
+		if e.Err != nil {
+			errs++
+		}
+		return nil
+	})
+	if err != nil || errs != 1 || !reflect.DeepEqual(tried, []int{1, 2, 3}) {
+		t.Errorf("expecting one error after 3 tries, got %d errors and %v after %v", errs, err, tried)
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, p := range []string{"a.txt", filepath.Join("sub", "b.txt")} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), 0755)
+		if err := os.WriteFile(filepath.Join(dir, p), []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	collect := func(e Entry) error {
+		if e.Err != nil {
+			if !errors.Is(e.Err, ErrLoop) {
+				t.Error(e.Err)
+			}
+			got = append(got, "loop:"+e.Path)
+			return nil
+		}
+		if e.Info.Mode()&fs.ModeSymlink != 0 {
+			got = append(got, "link:"+e.Path)
+			return nil
+		}
+		got = append(got, e.Path)
+		return nil
+	}
+	if err := Dir(dir, Options{}, collect); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "sub", "b.txt")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expecting %v, got %v", want, got)
+	}
+	// a single file
+	got = nil
+	Dir(want[0], Options{}, collect)
+	if !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("expecting %v, got %v", want[:1], got)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// symlinks, including a loop
+	if err := os.Symlink("sub", filepath.Join(dir, "link")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink("..", filepath.Join(dir, "sub", "up")); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	Dir(dir, Options{}, collect)
+	want = []string{filepath.Join(dir, "a.txt"), "link:" + filepath.Join(dir, "link"), filepath.Join(dir, "sub", "b.txt"), "link:" + filepath.Join(dir, "sub", "up")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expecting %v, got %v", want, got)
+	}
+	got = nil
+	Dir(dir, Options{FollowSymlinks: true}, collect)
+	want = []string{
+		filepath.Join(dir, "a.txt"),
+		filepath.Join(dir, "link", "b.txt"),
+		"loop:" + filepath.Join(dir, "link", "up"),
+		filepath.Join(dir, "sub", "b.txt"),
+		"loop:" + filepath.Join(dir, "sub", "up"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expecting %v, got %v", want, got)
+	}
+}
+
+// Dir walks names that aren't valid fs.FS paths, as filepath.Walk did
+func TestDirNames(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad\xffdir")
+	if err := os.Mkdir(bad, 0755); err != nil {
+		t.Skipf("can't make a directory with a name that isn't UTF-8: %v", err)
+	}
+	for _, p := range []string{filepath.Join(bad, "a.txt"), filepath.Join(dir, "z.txt")} {
+		if err := os.WriteFile(p, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	err := Dir(dir, Options{}, func(e Entry) error {
+		if e.Err != nil {
+			return e.Err
+		}
+		got = append(got, e.Path)
+		return nil
+	})
+	want := []string{filepath.Join(bad, "a.txt"), filepath.Join(dir, "z.txt")}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("expecting %q, got %q and %v", want, got, err)
+	}
+}
@@ -33,7 +33,7 @@ const identifierDateFormat = "2006-01-02"
 
 // Initialize the variables needed by this file.
 func init() {
-	core.RegisterIdentifier(core.Wikidata, Load)
+	core.MustRegister(core.Registration{ID: core.Wikidata, Name: "wikidata", Description: "Wikidata file format records", Loader: Load})
 }
 
 // Identifier contains a set of Wikidata records and an implementation
@@ -275,7 +275,11 @@ func (recorder *Recorder) Satisfied(mt core.MatcherType) (bool, core.Hint) {
 		if mt == core.ContainerMatcher ||
 			mt == core.ByteMatcher ||
 			mt == core.XMLMatcher ||
-			mt == core.RIFFMatcher {
+			mt == core.RIFFMatcher ||
+			mt == core.EBMLMatcher ||
+			mt == core.SQLiteMatcher ||
+			mt == core.TIFFMatcher ||
+			mt == core.FontMatcher {
 			if mt == core.ByteMatcher ||
 				mt == core.ContainerMatcher {
 				keys := make([]string, len(recorder.ids))
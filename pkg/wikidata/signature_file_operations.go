@@ -21,6 +21,7 @@
 package wikidata
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -61,6 +62,16 @@ func (i *Identifier) Save(ls *persist.LoadSaver) {
 	i.Base.Save(ls)
 }
 
+// MarshalJSON encodes the Wikidata identifier for export, e.g. via
+// `roy export`.
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string                `json:"type"`
+		Infos map[string]formatInfo `json:"infos"`
+		Base  *identifier.Base      `json:"base"`
+	}{"wikidata", i.infos, i.Base})
+}
+
 // Load back into memory from the signature file the same information
 // that we wrote to the file using Save().
 func Load(ls *persist.LoadSaver) core.Identifier {
@@ -99,6 +110,16 @@ type formatInfo struct {
 	sources []string
 }
 
+// MarshalJSON encodes formatInfo for export.
+func (f formatInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name    string   `json:"name"`
+		URI     string   `json:"uri"`
+		MIME    string   `json:"mime"`
+		Sources []string `json:"sources"`
+	}{f.name, f.uri, f.mime, f.sources})
+}
+
 // infos turns the generic formatInfo into the structure that will be
 // written into the Siegfried identifier.
 func infos(formatInfoMap parseableFormatInfo) map[string]formatInfo {
@@ -0,0 +1,104 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+type archivematicaWriter struct {
+	w    *bufio.Writer
+	head []byte // the start of each document
+	keys [][]string
+	idxs [][]int
+}
+
+// Archivematica writes a JSON document for each file, on its own line, in the form that Archivematica's
+// "Identify using Siegfried" command parses: the JSON output of sf for a single file, with matches in the legacy
+// layout (ns, id, format etc.), whatever the -legacy setting. Archivematica reads the PUID from the id field of
+// the match with the pronom ns in files[0].matches.
+func Archivematica(w io.Writer) Writer {
+	return &archivematicaWriter{w: bufio.NewWriter(w)}
+}
+
+func (a *archivematicaWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	a.keys, a.idxs = make([][]string, len(fields)), make([][]int, len(fields))
+	for i, f := range fields {
+		a.keys[i], a.idxs[i] = legacyLayout(f)
+	}
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "{\"siegfried\":\"%d.%d.%d\",\"scandate\":%s,\"signature\":%s,\"created\":%s,\"identifiers\":[",
+		version[0], version[1], version[2], jsonString(scanned.Format(time.RFC3339)), jsonString(path), jsonString(created.Format(time.RFC3339)))
+	for i, id := range ids {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, "{\"name\":%s,\"details\":%s}", jsonString(id[0]), jsonString(id[1]))
+	}
+	buf.WriteString("],\"files\":[")
+	a.head = buf.Bytes()
+}
+
+func (a *archivematicaWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	var errStr string
+	if err != nil {
+		errStr = err.Error()
+	}
+	a.w.Write(a.head)
+	fmt.Fprintf(a.w, "{\"filename\":%s,\"filesize\":%d,\"modified\":%s,\"errors\":%s,\"matches\":[",
+		jsonString(name), sz, jsonString(mod), jsonString(errStr))
+	var thisName string
+	idx := -1
+	for i, id := range ids {
+		values := id.Values()
+		if values[0] != thisName {
+			idx++
+			thisName = values[0]
+		}
+		if idx >= len(a.keys) {
+			break
+		}
+		if i > 0 {
+			a.w.WriteByte(',')
+		}
+		a.w.WriteByte('{')
+		for j, k := range a.keys[idx] {
+			if j > 0 {
+				a.w.WriteByte(',')
+			}
+			var v string
+			if a.idxs[idx][j] < len(values) {
+				v = values[a.idxs[idx][j]]
+			}
+			fmt.Fprintf(a.w, "%s:%s", jsonString(k), jsonString(v))
+		}
+		a.w.WriteByte('}')
+	}
+	a.w.WriteString("]}]}\n")
+}
+
+func (a *archivematicaWriter) Tail() { a.w.Flush() }
+
+func jsonString(s string) string {
+	byts, _ := json.Marshal(s)
+	return string(byts)
+}
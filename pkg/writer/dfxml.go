@@ -0,0 +1,186 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+const (
+	// DFXMLNamespace is the namespace of Digital Forensics XML (DFXML).
+	DFXMLNamespace = "http://www.forensicswiki.org/wiki/Category:Digital_Forensics_XML"
+	// DFXMLSiegfriedNamespace is the namespace of the siegfried elements (the signature file, identifiers and
+	// matches) in DFXML output.
+	DFXMLSiegfriedNamespace = "https://www.itforarchivists.com/siegfried/dfxml"
+	dfxmlVersion            = "1.2.0"
+)
+
+type dfxmlCreator struct {
+	XMLName     xml.Name         `xml:"creator"`
+	Version     string           `xml:"version,attr"`
+	Program     string           `xml:"program"`
+	ProgVersion string           `xml:"version"`
+	Env         dfxmlEnvironment `xml:"execution_environment"`
+	Signature   dfxmlSignature   `xml:"sf:signature"`
+	Identifiers []dfxmlIdent     `xml:"sf:identifier"`
+}
+
+type dfxmlEnvironment struct {
+	Host        string `xml:"host,omitempty"`
+	CommandLine string `xml:"command_line,omitempty"`
+	Username    string `xml:"username,omitempty"`
+	StartTime   string `xml:"start_time"`
+}
+
+type dfxmlSignature struct {
+	Created string `xml:"created,attr"`
+	SHA256  string `xml:"sha256,attr,omitempty"`
+	Name    string `xml:",chardata"`
+}
+
+type dfxmlIdent struct {
+	Name    string `xml:"name,attr"`
+	Details string `xml:",chardata"`
+}
+
+type dfxmlFileObject struct {
+	XMLName  xml.Name       `xml:"fileobject"`
+	Filename string         `xml:"filename"`
+	Error    string         `xml:"error,omitempty"`
+	NameType string         `xml:"name_type"`
+	Filesize *int64         `xml:"filesize,omitempty"`
+	Mtime    string         `xml:"mtime,omitempty"`
+	ByteRuns *dfxmlByteRuns `xml:"byte_runs,omitempty"`
+	Hash     *dfxmlHash     `xml:"hashdigest,omitempty"`
+	Matches  []dfxmlMatch   `xml:"sf:match"`
+}
+
+type dfxmlByteRuns struct {
+	Run []dfxmlByteRun `xml:"byte_run"`
+}
+
+type dfxmlByteRun struct {
+	FileOffset int64 `xml:"file_offset,attr"`
+	Len        int64 `xml:"len,attr"`
+}
+
+type dfxmlHash struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type dfxmlMatch struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type dfxmlWriter struct {
+	w    *bufio.Writer
+	hh   string
+	keys [][]string
+	idxs [][]int
+}
+
+// DFXML writes Digital Forensics XML, as written by fiwalk and read by DFXML tools: a fileobject element for each
+// file, with its name, size, modification time, byte run (the whole file, from offset 0) and checksum (with sf
+// -hash), any error and, in the siegfried namespace, a match element for each match, with the fields of the match
+// as attributes. Directories have a name_type of d, and other files of r. The creator element has the
+// signature file and identifiers and, with SetSession, the host, user and command line of the scan.
+func DFXML(w io.Writer) Writer {
+	return &dfxmlWriter{w: bufio.NewWriter(w)}
+}
+
+func (d *dfxmlWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	d.hh = hh
+	d.keys, d.idxs = make([][]string, len(fields)), make([][]int, len(fields))
+	for i, f := range fields {
+		d.keys[i], d.idxs[i] = matchLayout(f)
+	}
+	creator := dfxmlCreator{
+		Version:     "1.0",
+		Program:     "siegfried",
+		ProgVersion: fmt.Sprintf("%d.%d.%d", version[0], version[1], version[2]),
+		Env:         dfxmlEnvironment{StartTime: scanned.Format(time.RFC3339)},
+		Signature:   dfxmlSignature{Created: created.Format(time.RFC3339), Name: path},
+		Identifiers: make([]dfxmlIdent, len(ids)),
+	}
+	if session != nil {
+		creator.Env.Host, creator.Env.CommandLine, creator.Env.Username = session.Host, session.Command, session.User
+		creator.Signature.SHA256 = session.SigHash
+	}
+	for i, id := range ids {
+		creator.Identifiers[i] = dfxmlIdent{id[0], id[1]}
+	}
+	fmt.Fprintf(d.w, "%s<dfxml version=\"%s\" xmlns=\"%s\" xmlns:dc=\"http://purl.org/dc/elements/1.1/\" xmlns:sf=\"%s\">\n"+
+		"  <metadata>\n    <dc:type>File system walk</dc:type>\n  </metadata>\n",
+		xml.Header, dfxmlVersion, DFXMLNamespace, DFXMLSiegfriedNamespace)
+	byts, _ := xml.MarshalIndent(creator, "  ", "  ")
+	d.w.Write(byts)
+	d.w.WriteByte('\n')
+}
+
+func (d *dfxmlWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	name, _ = normalise(name)
+	fo := dfxmlFileObject{Filename: name, NameType: "r", Mtime: mod}
+	if err != nil {
+		fo.Error = err.Error()
+	}
+	if sz < 0 {
+		fo.NameType = "d"
+	} else {
+		fo.Filesize = &sz
+		if sz > 0 {
+			fo.ByteRuns = &dfxmlByteRuns{[]dfxmlByteRun{{0, sz}}}
+		}
+	}
+	if checksum != nil {
+		fo.Hash = &dfxmlHash{d.hh, hex.EncodeToString(checksum)}
+	}
+	var thisName string
+	idx := -1
+	for _, id := range ids {
+		values := id.Values()
+		if values[0] != thisName {
+			idx++
+			thisName = values[0]
+		}
+		if idx >= len(d.keys) {
+			break
+		}
+		m := dfxmlMatch{make([]xml.Attr, 0, len(d.keys[idx]))}
+		for i, k := range d.keys[idx] {
+			var v string
+			if j := d.idxs[idx][i]; j >= 0 && j < len(values) {
+				v = values[j]
+			}
+			m.Attrs = append(m.Attrs, xml.Attr{Name: xml.Name{Local: k}, Value: v})
+		}
+		fo.Matches = append(fo.Matches, m)
+	}
+	byts, _ := xml.MarshalIndent(fo, "  ", "  ")
+	d.w.Write(byts)
+	d.w.WriteByte('\n')
+}
+
+func (d *dfxmlWriter) Tail() {
+	d.w.WriteString("</dfxml>\n")
+	d.w.Flush()
+}
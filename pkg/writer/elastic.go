@@ -0,0 +1,134 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// Results are sent to Elasticsearch in bulk requests of up to elasticBatch documents or elasticBytes bytes.
+const (
+	elasticBatch = 500
+	elasticBytes = 5 << 20
+)
+
+type elasticWriter struct {
+	docs
+	bulk  string // URL of the index's bulk API
+	batch bytes.Buffer
+	n     int // number of documents in the batch
+	err   error
+}
+
+// Elastic returns a Sink that indexes a document for each file in an Elasticsearch or OpenSearch index, using the
+// bulk API. Give the URL of the index e.g. http://localhost:9200/siegfried (with a username and password in the URL,
+// if needed). Documents have the fields of files in JSON output, with the siegfried version, scan date and signature
+// file of the scan. Bulk requests are retried, with backoff, when the server is unavailable or busy.
+func Elastic(u string) (Sink, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("writer: bad elasticsearch URL %s: %v", u, err)
+	}
+	index := strings.Trim(pu.Path, "/")
+	if (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" || index == "" || strings.Contains(index, "/") {
+		return nil, fmt.Errorf("writer: bad elasticsearch URL %s: expecting the URL of an index e.g. http://localhost:9200/siegfried", u)
+	}
+	pu.Path = "/" + index + "/_bulk"
+	return &elasticWriter{bulk: pu.String()}, nil
+}
+
+func (e *elasticWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	e.head(path, scanned, version, fields, hh)
+}
+
+func (e *elasticWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	if e.err != nil {
+		return
+	}
+	doc, derr := e.doc(name, sz, mod, checksum, err, ids)
+	if derr != nil {
+		e.err = fmt.Errorf("writer: can't index %s in elasticsearch: %v", name, derr)
+		return
+	}
+	e.batch.WriteString("{\"index\":{}}\n")
+	e.batch.Write(doc)
+	e.batch.WriteByte('\n')
+	e.n++
+	if e.n >= elasticBatch || e.batch.Len() >= elasticBytes {
+		e.flush()
+	}
+}
+
+func (e *elasticWriter) Tail() {
+	if e.err == nil && e.n > 0 {
+		e.flush()
+	}
+}
+
+func (e *elasticWriter) Err() error { return e.err }
+
+// flush sends the batch and reports any documents that weren't indexed
+func (e *elasticWriter) flush() {
+	n := e.n
+	byts := e.batch.Bytes()
+	body, err := post(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, e.bulk, bytes.NewReader(byts))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-ndjson")
+		}
+		return req, err
+	})
+	e.batch.Reset()
+	e.n = 0
+	if err != nil {
+		e.err = fmt.Errorf("writer: elasticsearch bulk request to %s failed: %v", e.bulk, err)
+		return
+	}
+	var res struct {
+		Errors bool
+		Items  []map[string]struct {
+			Status int
+			Error  json.RawMessage
+		}
+	}
+	if err = json.Unmarshal(body, &res); err != nil {
+		e.err = fmt.Errorf("writer: bad response to elasticsearch bulk request: %v", err)
+		return
+	}
+	if !res.Errors {
+		return
+	}
+	var failed int
+	var first string
+	for _, item := range res.Items {
+		for _, v := range item {
+			if v.Status/100 != 2 {
+				failed++
+				if first == "" {
+					first = string(v.Error)
+				}
+			}
+		}
+	}
+	e.err = fmt.Errorf("writer: elasticsearch didn't index %d of %d documents, e.g. %s", failed, n, first)
+}
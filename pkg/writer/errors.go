@@ -0,0 +1,61 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import "github.com/richardlehane/siegfried/pkg/core"
+
+// Error classes are written in the errclass field of YAML, JSON and CSV output (and as the status of DROID output),
+// so that errors can be filtered without parsing error messages. They are defined in the core package.
+const (
+	ClassPermission = core.ClassPermission
+	ClassNotFound   = core.ClassNotFound
+	ClassFileType   = core.ClassFileType
+	ClassSkipped    = core.ClassSkipped
+	ClassWalk       = core.ClassWalk
+	ClassDecompress = core.ClassDecompress
+	ClassTruncated  = core.ClassTruncated
+	ClassCorrupt    = core.ClassCorrupt
+	ClassEncrypted  = core.ClassEncrypted
+	ClassAborted    = core.ClassAborted
+	ClassPanic      = core.ClassPanic
+	ClassTimeout    = core.ClassTimeout
+	ClassError      = core.ClassError
+)
+
+// Classer is implemented by errors that know their error class.
+type Classer = core.Classer
+
+// ClassifyError gives an error an error class.
+func ClassifyError(class string, err error) error {
+	return core.ClassifyError(class, err)
+}
+
+// ErrorClass returns the error class of an error, or an empty string for a nil error.
+func ErrorClass(err error) string {
+	return core.ErrorClass(err)
+}
+
+// DROID's statuses for error classes
+func droidStatus(err error) string {
+	switch ErrorClass(err) {
+	case "":
+		return "Done"
+	case ClassPermission:
+		return "Access denied"
+	case ClassNotFound:
+		return "Not found"
+	}
+	return "Error"
+}
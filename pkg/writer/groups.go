@@ -0,0 +1,99 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Group is a logical object made of several files, e.g. the .shp, .shx and .dbf files of a shapefile or the
+// numbered files of a DV sequence. Groups are found once the files of a scan are identified.
+type Group struct {
+	Name    string      // the kind of group e.g. shapefile
+	Primary string      // the name of the group's primary file, as written in output
+	Files   []GroupFile // the files of the group that were scanned
+	Missing []string    // files the group should have, but doesn't
+}
+
+// GroupFile is a file in a group, with the format it is identified as by the first identifier
+type GroupFile struct {
+	Name string
+	ID   string
+}
+
+var groups func() []Group
+
+// SetGroups adds a group record for each of the groups returned by fn to the end of YAML and JSON output. The other
+// output formats are tables, or a document for each file, that have no place for them. fn is called by a writer's
+// Tail, once all the files are written; nil (the default) leaves the records out.
+func SetGroups(fn func() []Group) {
+	groups = fn
+}
+
+func getGroups() []Group {
+	if groups == nil {
+		return nil
+	}
+	return groups()
+}
+
+func (y *yamlWriter) groups() {
+	for _, g := range getGroups() {
+		fmt.Fprintf(y.w, "---\ngroup    : '%s'\nprimary  : '%s'\nmissing  : %s\nfiles    :\n",
+			y.replacer.Replace(g.Name), y.replacer.Replace(g.Primary), yamlList(y.replacer, g.Missing))
+		for _, f := range g.Files {
+			fmt.Fprintf(y.w, "  - filename : '%s'\n    id       : '%s'\n", y.replacer.Replace(f.Name), y.replacer.Replace(f.ID))
+		}
+	}
+}
+
+func yamlList(r *strings.Replacer, l []string) string {
+	if len(l) == 0 {
+		return "[]"
+	}
+	strs := make([]string, len(l))
+	for i, s := range l {
+		strs[i] = "'" + r.Replace(s) + "'"
+	}
+	return "[" + strings.Join(strs, ", ") + "]"
+}
+
+func (j *jsonWriter) groups() {
+	gs := getGroups()
+	if len(gs) == 0 {
+		return
+	}
+	j.w.WriteString(",\"groups\":[")
+	for i, g := range gs {
+		if i > 0 {
+			j.w.WriteString(",")
+		}
+		missing := make([]string, len(g.Missing))
+		for k, m := range g.Missing {
+			missing[k] = "\"" + j.replacer.Replace(m) + "\""
+		}
+		fmt.Fprintf(j.w, "{\"group\":\"%s\",\"primary\":\"%s\",\"missing\":[%s],\"files\":[",
+			j.replacer.Replace(g.Name), j.replacer.Replace(g.Primary), strings.Join(missing, ","))
+		for k, f := range g.Files {
+			if k > 0 {
+				j.w.WriteString(",")
+			}
+			fmt.Fprintf(j.w, "{\"filename\":\"%s\",\"id\":\"%s\"}", j.replacer.Replace(f.Name), j.replacer.Replace(f.ID))
+		}
+		j.w.WriteString("]}")
+	}
+	j.w.WriteString("]")
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+// normalise returns the path written in output for name and, if that isn't name itself, name's raw bytes.
+// Without config.Normalise, it returns name unchanged.
+func normalise(name string) (string, string) {
+	if !config.Normalise() {
+		return name, ""
+	}
+	n := NormalisePath(name)
+	if n == name {
+		return n, ""
+	}
+	return n, RawPath(name)
+}
+
+// NormalisePath returns a path in Unicode normalization form C. Bytes that aren't valid UTF-8 (e.g. in file names
+// written by legacy Linux programs) are read as Latin-1, so different raw names stay distinct.
+func NormalisePath(p string) string {
+	if !utf8.ValidString(p) {
+		var b strings.Builder
+		for i := 0; i < len(p); {
+			r, sz := utf8.DecodeRuneInString(p[i:])
+			if r == utf8.RuneError && sz == 1 {
+				r = rune(p[i])
+			}
+			b.WriteRune(r)
+			i += sz
+		}
+		p = b.String()
+	}
+	return norm.NFC.String(p)
+}
+
+// RawPath returns the bytes of a path with '%' and all bytes outside printable ASCII percent-encoded (e.g. %C3%A9),
+// so that raw names can be kept in output that must be valid UTF-8.
+func RawPath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		if c := p[i]; c < 0x20 || c > 0x7E || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,246 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+const (
+	// OPEXNamespace is the namespace of Open Preservation Exchange (OPEX) metadata, as used by Preservica ingest.
+	OPEXNamespace = "http://www.openpreservationexchange.org/opex/v1.2"
+	// OPEXIdentificationNamespace is the namespace of the identification fragment in the DescriptiveMetadata of OPEX
+	// metadata written by siegfried.
+	OPEXIdentificationNamespace = "https://www.itforarchivists.com/siegfried/opex"
+)
+
+// OPEX fixity types for the hash algorithms of sf -hash (OPEX has no CRC fixity)
+var opexTypes = map[string]string{"md5": "MD5", "sha1": "SHA-1", "sha256": "SHA-256", "sha512": "SHA-512"}
+
+type opexMetadata struct {
+	XMLName     xml.Name            `xml:"opex:OPEXMetadata"`
+	Xmlns       string              `xml:"xmlns:opex,attr"`
+	Transfer    *opexTransfer       `xml:"opex:Transfer,omitempty"`
+	Title       string              `xml:"opex:Properties>opex:Title"`
+	Description *opexIdentification `xml:"opex:DescriptiveMetadata>Identification"`
+}
+
+type opexTransfer struct {
+	SourceID string        `xml:"opex:SourceID,omitempty"`
+	Fixities *opexFixities `xml:"opex:Fixities,omitempty"`
+}
+
+type opexFixities struct {
+	Fixity []opexFixity `xml:"opex:Fixity"`
+}
+
+type opexFixity struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type opexIdentification struct {
+	Xmlns     string       `xml:"xmlns,attr"`
+	Tool      string       `xml:"Tool"`
+	Signature string       `xml:"Signature"`
+	Scandate  string       `xml:"Scandate"`
+	Size      int64        `xml:"Size"`
+	Modified  string       `xml:"Modified,omitempty"`
+	Error     string       `xml:"Error,omitempty"`
+	Formats   []opexFormat `xml:"Format"`
+}
+
+// opexSession is the session of the scan, at the end of OPEX output
+type opexSession struct {
+	XMLName xml.Name `xml:"Session"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Host    string   `xml:"Host"`
+	User    string   `xml:"User"`
+	Command string   `xml:"Command"`
+	SigHash string   `xml:"SignatureHash"`
+	Start   string   `xml:"Start"`
+	End     string   `xml:"End"`
+	Files   int      `xml:"Files"`
+	Errors  int      `xml:"Errors"`
+	Unknown int      `xml:"Unknown"`
+}
+
+type opexFormat struct {
+	Namespace string `xml:"Namespace"`
+	ID        string `xml:"ID"`
+	Name      string `xml:"Name,omitempty"`
+	Version   string `xml:"Version,omitempty"`
+	MIME      string `xml:"MIME,omitempty"`
+	Basis     string `xml:"Basis,omitempty"`
+	Warning   string `xml:"Warning,omitempty"`
+}
+
+// opex makes the OPEX metadata for files
+type opex struct {
+	tool      string
+	signature string
+	scandate  string
+	fixity    string
+	idxs      [][]int
+}
+
+func (o *opex) head(path string, scanned time.Time, version [3]int, fields [][]string, hh string) {
+	o.tool = fmt.Sprintf("siegfried %d.%d.%d", version[0], version[1], version[2])
+	o.signature, o.scandate, o.fixity = path, scanned.Format(time.RFC3339), opexTypes[hh]
+	o.idxs = make([][]int, len(fields))
+	for i, f := range fields {
+		_, o.idxs[i] = matchLayout(f)
+	}
+}
+
+func (o *opex) metadata(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) *opexMetadata {
+	name, _ = normalise(name)
+	md := &opexMetadata{
+		Xmlns:    OPEXNamespace,
+		Transfer: &opexTransfer{SourceID: name},
+		Title:    filepath.Base(name),
+		Description: &opexIdentification{
+			Xmlns:     OPEXIdentificationNamespace,
+			Tool:      o.tool,
+			Signature: o.signature,
+			Scandate:  o.scandate,
+			Size:      sz,
+			Modified:  mod,
+		},
+	}
+	if checksum != nil && o.fixity != "" {
+		md.Transfer.Fixities = &opexFixities{[]opexFixity{{o.fixity, hex.EncodeToString(checksum)}}}
+	}
+	if err != nil {
+		md.Description.Error = err.Error()
+	}
+	var thisName string
+	idx := -1
+	for _, id := range ids {
+		values := id.Values()
+		if values[0] != thisName {
+			idx++
+			thisName = values[0]
+		}
+		if idx >= len(o.idxs) {
+			break
+		}
+		v := make([]string, len(matchFields))
+		for i := range v {
+			if j := o.idxs[idx][i]; j >= 0 && j < len(values) {
+				v[i] = values[j]
+			}
+		}
+		md.Description.Formats = append(md.Description.Formats, opexFormat{v[0], v[1], v[2], v[3], v[4], v[5], v[6]})
+	}
+	return md
+}
+
+type opexWriter struct {
+	opex
+	w    *bufio.Writer
+	sess *tally
+}
+
+// OPEX writes an XML document of OPEX metadata fragments for Preservica ingest: an OPEXMetadata element for each
+// file, with the file's checksum (if files are hashed with md5, sha1, sha256 or sha512) as a Transfer fixity and
+// the file's identification in DescriptiveMetadata. The fragments are wrapped in an opex:Fragments element, and can
+// be split into .opex sidecar files for ingest (see OPEXSidecars). With SetSession, a Session element follows the
+// fragments.
+func OPEX(w io.Writer) Writer {
+	return &opexWriter{w: bufio.NewWriter(w)}
+}
+
+func (o *opexWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	o.head(path, scanned, version, fields, hh)
+	o.sess = newTally(scanned)
+	fmt.Fprintf(o.w, "%s<opex:Fragments xmlns:opex=\"%s\">\n", xml.Header, OPEXNamespace)
+}
+
+func (o *opexWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	o.sess.count(sz, err, ids)
+	byts, _ := xml.MarshalIndent(o.metadata(name, sz, mod, checksum, err, ids), "  ", "  ")
+	o.w.Write(byts)
+	o.w.WriteByte('\n')
+}
+
+func (o *opexWriter) Tail() {
+	if o.sess != nil {
+		byts, _ := xml.MarshalIndent(opexSession{
+			Xmlns:   OPEXIdentificationNamespace,
+			Host:    o.sess.Host,
+			User:    o.sess.User,
+			Command: o.sess.Command,
+			SigHash: o.sess.SigHash,
+			Start:   o.sess.start.Format(time.RFC3339),
+			End:     o.sess.end().Format(time.RFC3339),
+			Files:   o.sess.files,
+			Errors:  o.sess.errors,
+			Unknown: o.sess.unknown,
+		}, "  ", "  ")
+		o.w.Write(byts)
+		o.w.WriteByte('\n')
+	}
+	o.w.WriteString("</opex:Fragments>\n")
+	o.w.Flush()
+}
+
+type opexSidecars struct {
+	opex
+	err error
+}
+
+// OPEXSidecars returns a Sink that writes OPEX metadata for each file in a sidecar file next to it, named for the
+// file with an .opex extension (e.g. image.jpg.opex), as Preservica's OPEX incremental ingest expects. Sidecars
+// aren't written for the contents of archives, or for the sidecars themselves.
+func OPEXSidecars() Sink {
+	return &opexSidecars{}
+}
+
+func (o *opexSidecars) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	o.head(path, scanned, version, fields, hh)
+}
+
+func (o *opexSidecars) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	if filepath.Ext(name) == ".opex" {
+		return
+	}
+	if fi, serr := os.Lstat(name); serr != nil || !fi.Mode().IsRegular() {
+		return
+	}
+	md := o.metadata(name, sz, mod, checksum, err, ids)
+	// sidecars describe the file they are next to
+	if md.Transfer.SourceID = ""; md.Transfer.Fixities == nil {
+		md.Transfer = nil
+	}
+	byts, _ := xml.MarshalIndent(md, "", "  ")
+	if werr := ioutil.WriteFile(name+".opex", append([]byte(xml.Header), append(byts, '\n')...), 0644); werr != nil && o.err == nil {
+		o.err = fmt.Errorf("writer: can't write OPEX sidecar for %s: %v", name, werr)
+	}
+}
+
+func (o *opexSidecars) Tail() {}
+
+func (o *opexSidecars) Err() error { return o.err }
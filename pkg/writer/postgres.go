@@ -0,0 +1,235 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// pgMigrations are the steps that bring the database schema up to date. Step i takes the schema to version i+1.
+// Add a step to change the schema: never edit a step that has been released.
+var pgMigrations = []string{
+	`CREATE TABLE sf_scans (
+  id          serial PRIMARY KEY,
+  siegfried   text NOT NULL,
+  scandate    timestamptz NOT NULL,
+  signature   text NOT NULL,
+  created     timestamptz,
+  identifiers jsonb NOT NULL,
+  hash        text
+);
+CREATE TABLE sf_files (
+  id       bigserial PRIMARY KEY,
+  scan     integer NOT NULL REFERENCES sf_scans ON DELETE CASCADE,
+  seq      bigint NOT NULL,
+  filename text NOT NULL,
+  rawname  text,
+  filesize bigint NOT NULL,
+  modified text,
+  errors   text,
+  errclass text,
+  hash     text,
+  UNIQUE (scan, seq)
+);
+CREATE INDEX sf_files_hash ON sf_files (hash);
+CREATE TABLE sf_matches (
+  file      bigint NOT NULL REFERENCES sf_files ON DELETE CASCADE,
+  seq       integer NOT NULL,
+  namespace text NOT NULL,
+  id        text,
+  name      text,
+  version   text,
+  mime      text,
+  basis     text,
+  warning   text,
+  extra     jsonb,
+  PRIMARY KEY (file, seq)
+);
+CREATE INDEX sf_matches_id ON sf_matches (id);`,
+	`ALTER TABLE sf_scans ADD COLUMN session jsonb;`,
+}
+
+type postgresWriter struct {
+	w    *bufio.Writer
+	keys [][]string
+	idxs [][]int
+	seq  int64
+	sess *tally
+}
+
+// Postgres writes a SQL script for psql that loads results into a PostgreSQL database e.g.
+// sf -format postgres DIR | psql -v ON_ERROR_STOP=1 mydb. The script creates the sf_scans, sf_files and
+// sf_matches tables, or migrates them to the current schema, then adds a scan, bulk loading its files and matches
+// with COPY. Each script is a transaction, so a scan is loaded completely or not at all. With SetSession, the
+// scan's session is in the session column of sf_scans.
+func Postgres(w io.Writer) Writer {
+	return &postgresWriter{w: bufio.NewWriter(w)}
+}
+
+func (p *postgresWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	p.keys, p.idxs = make([][]string, len(fields)), make([][]int, len(fields))
+	for i, f := range fields {
+		p.keys[i], p.idxs[i] = matchLayout(f)
+	}
+	p.sess = newTally(scanned)
+	p.w.WriteString("-- siegfried results: create or migrate the schema\nBEGIN;\n" +
+		"CREATE TABLE IF NOT EXISTS sf_schema (version integer NOT NULL);\n" +
+		"LOCK TABLE sf_schema IN EXCLUSIVE MODE;\n")
+	for i, m := range pgMigrations {
+		fmt.Fprintf(p.w, "DO $migrate$ BEGIN\nIF NOT EXISTS (SELECT FROM sf_schema WHERE version >= %d) THEN\n%s\nDELETE FROM sf_schema;\nINSERT INTO sf_schema VALUES (%d);\nEND IF;\nEND $migrate$;\n", i+1, m, i+1)
+	}
+	p.w.WriteString("COMMIT;\n\n-- load the scan\nBEGIN;\n")
+	idents := make([]map[string]string, len(ids))
+	for i, id := range ids {
+		idents[i] = map[string]string{"name": id[0], "details": id[1]}
+	}
+	identsJSON, _ := json.Marshal(idents)
+	var hash string
+	if hh != "" {
+		hash = pgQuote(hh)
+	} else {
+		hash = "NULL"
+	}
+	fmt.Fprintf(p.w, "INSERT INTO sf_scans (siegfried, scandate, signature, created, identifiers, hash) VALUES (%s, %s, %s, %s, %s, %s);\n",
+		pgQuote(fmt.Sprintf("%d.%d.%d", version[0], version[1], version[2])),
+		pgQuote(scanned.Format(time.RFC3339)),
+		pgQuote(path),
+		pgQuote(created.Format(time.RFC3339)),
+		pgQuote(string(identsJSON)),
+		hash)
+	// files and their matches are copied to a staging table, with a row for each match (or a row for a file with no matches)
+	p.w.WriteString("CREATE TEMPORARY TABLE sf_load (seq bigint, filename text, rawname text, filesize bigint, modified text, errors text, errclass text, hash text, " +
+		"mseq integer, namespace text, id text, name text, version text, mime text, basis text, warning text, extra jsonb) ON COMMIT DROP;\n" +
+		"COPY sf_load FROM stdin;\n")
+}
+
+func (p *postgresWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	p.seq++
+	p.sess.count(sz, err, ids)
+	name, raw := normalise(name)
+	var errStr, hash string
+	if err != nil {
+		errStr = err.Error()
+	}
+	if checksum != nil {
+		hash = hex.EncodeToString(checksum)
+	}
+	file := strings.Join([]string{
+		strconv.FormatInt(p.seq, 10),
+		pgCopy(name),
+		pgCopyNull(raw),
+		strconv.FormatInt(sz, 10),
+		pgCopyNull(mod),
+		pgCopyNull(errStr),
+		pgCopyNull(ErrorClass(err)),
+		pgCopyNull(hash),
+	}, "\t")
+	var thisName string
+	var n int
+	idx := -1
+	for _, id := range ids {
+		values := id.Values()
+		if values[0] != thisName {
+			idx++
+			thisName = values[0]
+		}
+		if idx >= len(p.keys) {
+			break
+		}
+		n++
+		row := make([]string, len(matchFields)+1)
+		extra := make(map[string]string)
+		for i, j := range p.idxs[idx] {
+			var v string
+			if j >= 0 && j < len(values) {
+				v = values[j]
+			}
+			if i < len(matchFields) {
+				if j < 0 {
+					row[i] = `\N`
+				} else {
+					row[i] = pgCopy(v)
+				}
+			} else {
+				extra[p.keys[idx][i]] = v
+			}
+		}
+		row[len(matchFields)] = `\N`
+		if len(extra) > 0 {
+			byts, _ := json.Marshal(extra)
+			row[len(matchFields)] = pgCopy(string(byts))
+		}
+		fmt.Fprintf(p.w, "%s\t%d\t%s\n", file, n, strings.Join(row, "\t"))
+	}
+	if n == 0 {
+		p.w.WriteString(file + strings.Repeat("\t\\N", len(matchFields)+2) + "\n")
+	}
+}
+
+func (p *postgresWriter) Tail() {
+	p.w.WriteString("\\.\n" +
+		"INSERT INTO sf_files (scan, seq, filename, rawname, filesize, modified, errors, errclass, hash)\n" +
+		"  SELECT DISTINCT ON (seq) currval(pg_get_serial_sequence('sf_scans', 'id')), seq, filename, rawname, filesize, modified, errors, errclass, hash\n" +
+		"  FROM sf_load ORDER BY seq;\n" +
+		"INSERT INTO sf_matches (file, seq, namespace, id, name, version, mime, basis, warning, extra)\n" +
+		"  SELECT f.id, l.mseq, l.namespace, l.id, l.name, l.version, l.mime, l.basis, l.warning, l.extra\n" +
+		"  FROM sf_load l JOIN sf_files f ON f.scan = currval(pg_get_serial_sequence('sf_scans', 'id')) AND f.seq = l.seq\n" +
+		"  WHERE l.mseq IS NOT NULL;\n")
+	if p.sess != nil {
+		byts, _ := json.Marshal(map[string]interface{}{
+			"host":    p.sess.Host,
+			"user":    p.sess.User,
+			"command": p.sess.Command,
+			"sighash": p.sess.SigHash,
+			"start":   p.sess.start.Format(time.RFC3339),
+			"end":     p.sess.end().Format(time.RFC3339),
+			"files":   p.sess.files,
+			"errors":  p.sess.errors,
+			"unknown": p.sess.unknown,
+		})
+		fmt.Fprintf(p.w, "UPDATE sf_scans SET session = %s WHERE id = currval(pg_get_serial_sequence('sf_scans', 'id'));\n", pgQuote(string(byts)))
+	}
+	p.w.WriteString("COMMIT;\n")
+	p.w.Flush()
+}
+
+// pgQuote quotes a string as a SQL literal
+func pgQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+var pgCopyReplacer = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+// pgCopy escapes a value for the text format of COPY
+func pgCopy(s string) string {
+	return pgCopyReplacer.Replace(s)
+}
+
+// pgCopyNull is like pgCopy, but empty strings are NULL
+func pgCopyNull(s string) string {
+	if s == "" {
+		return `\N`
+	}
+	return pgCopy(s)
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ErrRegistered is returned when an output format's name is already registered.
+var ErrRegistered = errors.New("output format already registered")
+
+// Registration describes an output format. Register output formats in an init function, and select them
+// with sf -format NAME.
+type Registration struct {
+	Name        string                 // short name e.g. "yaml"
+	Description string                 // optional description of the output format
+	MIME        string                 // optional MIME type, used as the Content-Type of sf server responses
+	New         func(io.Writer) Writer // returns a Writer for the output format
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Registration)
+)
+
+func init() {
+	MustRegister(Registration{Name: "yaml", Description: "YAML output format", MIME: "application/x-yaml", New: YAML})
+	MustRegister(Registration{Name: "json", Description: "JSON output format", MIME: "application/json", New: JSON})
+	MustRegister(Registration{Name: "csv", Description: "CSV output format", MIME: "text/csv", New: CSV})
+	MustRegister(Registration{Name: "droid", Description: "DROID CSV output format", MIME: "application/x-droid", New: Droid})
+	MustRegister(Registration{Name: "archivematica", Description: "a JSON document for each file, as parsed by Archivematica's Identify using Siegfried command", MIME: "application/x-ndjson", New: Archivematica})
+	MustRegister(Registration{Name: "opex", Description: "OPEX metadata fragments (fixity and identification) for Preservica ingest", MIME: "application/xml", New: OPEX})
+	MustRegister(Registration{Name: "postgres", Description: "SQL script for psql that loads results into PostgreSQL", MIME: "application/sql", New: Postgres})
+	MustRegister(Registration{Name: "droidfile", Description: "DROID CSV output format, with a row for each file", MIME: "application/x-droid", New: DroidFile})
+	MustRegister(Registration{Name: "dfxml", Description: "Digital Forensics XML, with a fileobject for each file", MIME: "application/xml", New: DFXML})
+	MustRegister(Registration{Name: "mime", Description: "file paths and MIME types, tab separated", MIME: "text/tab-separated-values", New: MIME})
+}
+
+// Register allows external packages to add new output formats. It returns an error if the name of
+// the registration is already taken.
+func Register(r Registration) error {
+	if r.Name == "" || r.New == nil {
+		return fmt.Errorf("writer: output format registration %q needs a name and a New function", r.Name)
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[r.Name]; ok {
+		return fmt.Errorf("writer: can't register output format %s: %w", r.Name, ErrRegistered)
+	}
+	registry[r.Name] = r
+	return nil
+}
+
+// MustRegister is like Register but panics if the registration conflicts with an existing one.
+// It is intended to be called from an init function.
+func MustRegister(r Registration) {
+	if err := Register(r); err != nil {
+		panic(err)
+	}
+}
+
+// Registered lists the registered output formats, ordered by name.
+func Registered() []Registration {
+	registryMu.RLock()
+	ret := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		ret = append(ret, r)
+	}
+	registryMu.RUnlock()
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+// Names lists the names of the registered output formats, ordered by name.
+func Names() []string {
+	regs := Registered()
+	ret := make([]string, len(regs))
+	for i, r := range regs {
+		ret[i] = r.Name
+	}
+	return ret
+}
+
+// Lookup returns the registration with the given name.
+func Lookup(name string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, ok := registry[name]
+	return r, ok
+}
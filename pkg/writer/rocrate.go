@@ -0,0 +1,222 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// ROCrateMetadata is the name of an RO-Crate's metadata file, in the root directory of the crate.
+const ROCrateMetadata = "ro-crate-metadata.json"
+
+const (
+	roCrateContext = "https://w3id.org/ro/crate/1.1/context"
+	roCrateSpec    = "https://w3id.org/ro/crate/1.1"
+	pronomURL      = "https://www.nationalarchives.gov.uk/PRONOM/"
+)
+
+var puidRe = regexp.MustCompile(`^(x-)?fmt/\d+$`)
+
+type roCrateWriter struct {
+	root    string // absolute path of the crate's directory
+	context interface{}
+	hh      string
+	mimes   []int // index of the mime field for each identifier, or -1
+	graph   []map[string]interface{}
+	ents    map[string]map[string]interface{} // the entities in the graph, by @id
+	dataset map[string]interface{}            // the root data entity
+	err     error
+}
+
+// ROCrate returns a Sink that writes an RO-Crate metadata file (ro-crate-metadata.json) for a dataset directory,
+// with a File entity for each file in the directory that has encodingFormat (MIME type and, for PRONOM matches,
+// a link to the format's PRONOM entry), contentSize, dateModified and, if files are hashed, a checksum in a
+// property named for the hash algorithm (e.g. sha256). If the directory already has a metadata file, its
+// entities are kept: File entities for scanned files are updated and other entities are left as they are.
+// The file is written by Tail.
+func ROCrate(dir string) (Sink, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("writer: can't make RO-Crate for %s: %v", dir, err)
+	}
+	if fi, err := os.Stat(root); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("writer: can't make RO-Crate for %s: expecting a directory", dir)
+	}
+	r := &roCrateWriter{root: root, ents: make(map[string]map[string]interface{})}
+	byts, err := ioutil.ReadFile(filepath.Join(root, ROCrateMetadata))
+	switch {
+	case err == nil:
+		var crate struct {
+			Context interface{}              `json:"@context"`
+			Graph   []map[string]interface{} `json:"@graph"`
+		}
+		if err = json.Unmarshal(byts, &crate); err != nil {
+			return nil, fmt.Errorf("writer: can't read %s: %v", filepath.Join(root, ROCrateMetadata), err)
+		}
+		r.context, r.graph = crate.Context, crate.Graph
+	case os.IsNotExist(err):
+		r.context = roCrateContext
+		r.graph = []map[string]interface{}{
+			{
+				"@id":        ROCrateMetadata,
+				"@type":      "CreativeWork",
+				"conformsTo": map[string]interface{}{"@id": roCrateSpec},
+				"about":      map[string]interface{}{"@id": "./"},
+			},
+			{
+				"@id":           "./",
+				"@type":         "Dataset",
+				"datePublished": time.Now().UTC().Format(time.RFC3339),
+				"hasPart":       []interface{}{},
+			},
+		}
+	default:
+		return nil, fmt.Errorf("writer: can't read %s: %v", filepath.Join(root, ROCrateMetadata), err)
+	}
+	for _, e := range r.graph {
+		if id, ok := e["@id"].(string); ok {
+			r.ents[id] = e
+		}
+	}
+	if r.dataset = r.ents["./"]; r.dataset == nil {
+		return nil, fmt.Errorf("writer: can't read %s: no root data entity (./)", filepath.Join(root, ROCrateMetadata))
+	}
+	return r, nil
+}
+
+func (r *roCrateWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	r.hh = hh
+	r.mimes = make([]int, len(fields))
+	for i, f := range fields {
+		r.mimes[i] = -1
+		for j, v := range f {
+			if v == "mime" {
+				r.mimes[i] = j
+				break
+			}
+		}
+	}
+}
+
+func (r *roCrateWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	abs, aerr := filepath.Abs(name)
+	if aerr != nil {
+		return
+	}
+	rel, rerr := filepath.Rel(r.root, abs)
+	// only files in the crate's directory (not the contents of archives) are File entities
+	if rerr != nil || rel == ROCrateMetadata || strings.HasPrefix(rel, "..") {
+		return
+	}
+	if fi, serr := os.Lstat(abs); serr != nil || !fi.Mode().IsRegular() {
+		return
+	}
+	segs := strings.Split(filepath.ToSlash(rel), "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	id := strings.Join(segs, "/")
+	ent, ok := r.ents[id]
+	if !ok {
+		ent = map[string]interface{}{"@id": id, "@type": "File", "name": filepath.Base(rel)}
+		r.add(ent)
+		parts, _ := r.dataset["hasPart"].([]interface{})
+		r.dataset["hasPart"] = append(parts, map[string]interface{}{"@id": id})
+	}
+	ent["contentSize"] = fmt.Sprint(sz)
+	if mod != "" {
+		ent["dateModified"] = mod
+	}
+	if checksum != nil && r.hh != "" {
+		ent[r.hh] = hex.EncodeToString(checksum)
+	}
+	var formats []interface{}
+	seen := make(map[string]bool)
+	var thisName string
+	idx := -1
+	for _, id := range ids {
+		values := id.Values()
+		if values[0] != thisName {
+			idx++
+			thisName = values[0]
+		}
+		if !id.Known() || idx >= len(r.mimes) {
+			continue
+		}
+		if r.mimes[idx] >= 0 {
+			if mime := strings.TrimSpace(strings.Split(values[r.mimes[idx]], ",")[0]); mime != "" && !seen[mime] {
+				seen[mime] = true
+				formats = append(formats, mime)
+			}
+		}
+		if puid := id.String(); puidRe.MatchString(puid) && !seen[puid] {
+			seen[puid] = true
+			u := pronomURL + puid
+			formats = append(formats, map[string]interface{}{"@id": u})
+			if _, ok := r.ents[u]; !ok && len(values) > 2 {
+				r.add(map[string]interface{}{"@id": u, "@type": "WebSite", "name": values[2]})
+			}
+		}
+	}
+	if len(formats) > 0 {
+		ent["encodingFormat"] = formats
+	} else {
+		delete(ent, "encodingFormat")
+	}
+}
+
+func (r *roCrateWriter) add(ent map[string]interface{}) {
+	r.graph = append(r.graph, ent)
+	r.ents[ent["@id"].(string)] = ent
+}
+
+// Tail writes the metadata file, via a temporary file so that an existing file isn't lost if writing fails
+func (r *roCrateWriter) Tail() {
+	byts, err := json.MarshalIndent(map[string]interface{}{"@context": r.context, "@graph": r.graph}, "", "  ")
+	if err == nil {
+		var f *os.File
+		if f, err = ioutil.TempFile(r.root, ".ro-crate-*.json"); err == nil {
+			_, err = f.Write(append(byts, '\n'))
+			if err == nil {
+				err = f.Chmod(0644)
+			}
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err == nil {
+				err = os.Rename(f.Name(), filepath.Join(r.root, ROCrateMetadata))
+			}
+			if err != nil {
+				os.Remove(f.Name())
+			}
+		}
+	}
+	if err != nil {
+		r.err = fmt.Errorf("writer: can't write %s: %v", filepath.Join(r.root, ROCrateMetadata), err)
+	}
+}
+
+func (r *roCrateWriter) Err() error { return r.err }
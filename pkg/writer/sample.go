@@ -0,0 +1,129 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+type sampleWriter struct {
+	dir    string // absolute path of the sample directory
+	n      int
+	link   bool
+	counts map[string]int // number of samples of each format, by directory
+	err    error
+}
+
+// Sample returns a Sink that copies up to n example files of each format into a sample directory, in a directory
+// for each identifier and format ID e.g. sample/pronom/fmt_43/1_photo.jpg. With link, files are hardlinked rather
+// than copied where they can be (hardlinked samples share their contents with the original files: change one and
+// the other changes too). Only files on disk are sampled, not the contents of archives.
+func Sample(dir string, n int, link bool) (Sink, error) {
+	abs, err := filepath.Abs(dir)
+	if err == nil {
+		err = os.MkdirAll(abs, 0755)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("writer: can't make sample directory %s: %v", dir, err)
+	}
+	return &sampleWriter{dir: abs, n: n, link: link, counts: make(map[string]int)}, nil
+}
+
+func (s *sampleWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+}
+
+func (s *sampleWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	if sz < 0 {
+		return
+	}
+	abs, aerr := filepath.Abs(name)
+	// don't sample the samples
+	if aerr != nil || strings.HasPrefix(abs, s.dir+string(filepath.Separator)) {
+		return
+	}
+	if fi, serr := os.Lstat(abs); serr != nil || !fi.Mode().IsRegular() {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if !id.Known() {
+			continue
+		}
+		fdir := filepath.Join(s.dir, sampleName(id.Values()[0]), sampleName(id.String()))
+		if seen[fdir] || s.counts[fdir] >= s.n {
+			continue
+		}
+		seen[fdir] = true
+		if serr := s.sample(abs, fdir, s.counts[fdir]+1); serr != nil {
+			if s.err == nil {
+				s.err = fmt.Errorf("writer: can't sample %s: %v", name, serr)
+			}
+			continue
+		}
+		s.counts[fdir]++
+	}
+}
+
+// sample copies (or links) a file into a format's directory, as its ith sample
+func (s *sampleWriter) sample(path, dir string, i int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	dst := filepath.Join(dir, fmt.Sprintf("%d_%s", i, filepath.Base(path)))
+	// replace a sample from an earlier scan by removing it first: if it is a hardlink, writing to it would
+	// overwrite the original file
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if s.link && os.Link(path, dst) == nil {
+		return nil
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, src)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *sampleWriter) Tail() {}
+
+func (s *sampleWriter) Err() error { return s.err }
+
+var sampleReplacer = strings.NewReplacer("/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_")
+
+// sampleName makes an identifier name or format ID (e.g. fmt/43) safe to use as a directory name
+func sampleName(s string) string {
+	s = sampleReplacer.Replace(s)
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+)
+
+// JSONSchemaVersion is the schemaVersion of JSON output. It is MAJOR.MINOR: the minor version changes when fields
+// are added, the major version when fields are removed, renamed or change type.
+// JSON output with the legacy layout of matches (config.SetLegacy) has LegacySchemaVersion.
+const (
+	JSONSchemaVersion   = "2.5"
+	LegacySchemaVersion = "1.5"
+)
+
+var (
+	// JSONSchema is a JSON Schema document describing JSON output.
+	//
+	//go:embed sf.schema.json
+	JSONSchema []byte
+	// LegacyJSONSchema is a JSON Schema document describing JSON output with the legacy layout of matches.
+	//
+	//go:embed sf.v1.schema.json
+	LegacyJSONSchema []byte
+)
+
+func schemaVersion() string {
+	if config.Legacy() {
+		return LegacySchemaVersion
+	}
+	return JSONSchemaVersion
+}
+
+// ValidateJSON checks JSON output against JSONSchema, or LegacyJSONSchema if its schemaVersion is 1.x.
+// It reports the location of the first problem e.g. files[3].filesize: expecting integer.
+func ValidateJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	byts := JSONSchema
+	if m, ok := doc.(map[string]interface{}); ok {
+		if v, ok := m["schemaVersion"].(string); ok && strings.HasPrefix(v, "1.") {
+			byts = LegacyJSONSchema
+		}
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(byts, &schema); err != nil {
+		return fmt.Errorf("bad schema: %v", err)
+	}
+	return validate(schema, schema, doc, "")
+}
+
+// validate supports the subset of JSON Schema used by JSONSchema: type, required, properties,
+// additionalProperties, items, pattern, enum and local $refs (#/definitions/name).
+func validate(root, schema map[string]interface{}, v interface{}, loc string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		defs, _ := root["definitions"].(map[string]interface{})
+		def, ok := defs[strings.TrimPrefix(ref, "#/definitions/")].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("bad schema: unknown $ref %s", ref)
+		}
+		return validate(root, def, v, loc)
+	}
+	errf := func(format string, a ...interface{}) error {
+		l := loc
+		if l == "" {
+			l = "document"
+		}
+		return fmt.Errorf("%s: %s", l, fmt.Sprintf(format, a...))
+	}
+	if typ, ok := schema["type"].(string); ok && !isType(typ, v) {
+		return errf("expecting %s", typ)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		var found bool
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errf("%v is not one of %v", v, enum)
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		if pat, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pat)
+			if err != nil {
+				return fmt.Errorf("bad schema: %v", err)
+			}
+			if !re.MatchString(v) {
+				return errf("%q doesn't match %s", v, pat)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validate(root, items, item, fmt.Sprintf("%s[%d]", loc, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		if req, ok := schema["required"].([]interface{}); ok {
+			for _, r := range req {
+				if _, ok := v[r.(string)]; !ok {
+					return errf("missing %s", r)
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			l := k
+			if loc != "" {
+				l = loc + "." + k
+			}
+			if p, ok := props[k].(map[string]interface{}); ok {
+				if err := validate(root, p, v[k], l); err != nil {
+					return err
+				}
+				continue
+			}
+			switch add := schema["additionalProperties"].(type) {
+			case bool:
+				if !add {
+					return errf("unexpected field %s", k)
+				}
+			case map[string]interface{}:
+				if err := validate(root, add, v[k], l); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isType(typ string, v interface{}) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "null":
+		return v == nil
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// Session describes where, by whom and how a scan was run, for audit trails and to make reports reproducible.
+// The versions of the identifiers are in the identifiers of the header of each output, and writers add the start
+// and end of the scan and totals of the files scanned.
+type Session struct {
+	Host    string
+	User    string
+	Command string // the command line
+	SigHash string // sha256 checksum of the signature file, hex encoded
+}
+
+var session *Session
+
+// SetSession adds a session block to the output of the YAML, JSON, OPEX and postgres writers, and fills in the
+// execution environment of DFXML output. The other output formats are tables, or a document for each file, that
+// have no place for one. Call it before a writer's Head; nil (the default) leaves the block out.
+func SetSession(s *Session) {
+	session = s
+}
+
+// tally is the session of a writer, with totals of the files it has written. A nil tally is no session.
+type tally struct {
+	*Session
+	start   time.Time
+	files   int
+	errors  int
+	unknown int // files without a match from any identifier
+}
+
+func newTally(scanned time.Time) *tally {
+	if session == nil {
+		return nil
+	}
+	return &tally{Session: session, start: scanned}
+}
+
+// count adds a file to the totals. Directories (which have a negative size) aren't counted.
+func (t *tally) count(sz int64, err error, ids []core.Identification) {
+	if t == nil || sz < 0 {
+		return
+	}
+	t.files++
+	if err != nil {
+		t.errors++
+	}
+	for _, id := range ids {
+		if id.Known() {
+			return
+		}
+	}
+	t.unknown++
+}
+
+// end is the time the scan ended, in the time zone of its start (see sf -utc)
+func (t *tally) end() time.Time {
+	return time.Now().In(t.start.Location())
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// A Sink is a Writer that sends results to another system (e.g. a search index) rather than to an io.Writer.
+// Sending may fail part way through a scan: Err reports the first failure, and should be checked after Tail.
+type Sink interface {
+	Writer
+	Err() error
+}
+
+// Tee returns a Writer that writes to each of the given Writers in turn, e.g. to send results to sinks as well as
+// writing them to stdout.
+func Tee(ws ...Writer) Writer {
+	return tee(ws)
+}
+
+type tee []Writer
+
+func (t tee) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	for _, w := range t {
+		w.Head(path, scanned, created, version, ids, fields, hh)
+	}
+}
+
+func (t tee) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	for _, w := range t {
+		w.File(name, sz, mod, checksum, err, ids)
+	}
+}
+
+func (t tee) Tail() {
+	for _, w := range t {
+		w.Tail()
+	}
+}
+
+// Sinks retry failed requests this many times, waiting sinkWait before the first retry and doubling the wait after that.
+var (
+	sinkRetries = 5
+	sinkWait    = time.Second
+)
+
+// sinkClient is the http.Client used by sinks
+var sinkClient = &http.Client{Timeout: time.Minute}
+
+// post sends a request made by req, retrying it after network errors and responses with retryable statuses
+// (429 Too Many Requests and 502, 503 and 504 gateway errors). It returns the body of a successful response.
+func post(req func() (*http.Request, error)) ([]byte, error) {
+	var err error
+	wait := sinkWait
+	for tries := 0; ; tries++ {
+		if tries > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		var r *http.Request
+		if r, err = req(); err != nil {
+			return nil, err
+		}
+		var resp *http.Response
+		resp, err = sinkClient.Do(r)
+		if err == nil {
+			body, rerr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			switch {
+			case resp.StatusCode/100 == 2 && rerr == nil:
+				return body, nil
+			case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusBadGateway,
+				resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout, rerr != nil:
+				err = fmt.Errorf("%s: %s", resp.Status, body)
+			default:
+				return nil, fmt.Errorf("%s: %s", resp.Status, body)
+			}
+		}
+		if tries >= sinkRetries {
+			return nil, err
+		}
+	}
+}
+
+// docs makes self-contained JSON documents for the results of files, for sinks that send a document per file.
+// Documents have the fields of files in JSON output, with the matches laid out in the same way, as well as the
+// siegfried version, scan date and signature file of the scan.
+type docs struct {
+	version   string
+	scandate  string
+	signature string
+	hh        string
+	keys      [][]string
+	idxs      [][]int
+}
+
+func (d *docs) head(path string, scanned time.Time, version [3]int, fields [][]string, hh string) {
+	d.version = fmt.Sprintf("%d.%d.%d", version[0], version[1], version[2])
+	d.scandate, d.signature, d.hh = scanned.Format(time.RFC3339), path, hh
+	d.keys, d.idxs = make([][]string, len(fields)), make([][]int, len(fields))
+	for i, f := range fields {
+		d.keys[i], d.idxs[i] = layout(f)
+	}
+}
+
+func (d *docs) doc(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) ([]byte, error) {
+	name, raw := normalise(name)
+	var errStr string
+	if err != nil {
+		errStr = err.Error()
+	}
+	doc := map[string]interface{}{
+		"siegfried": d.version,
+		"scandate":  d.scandate,
+		"signature": d.signature,
+		"filename":  name,
+		"filesize":  sz,
+		"modified":  mod,
+		"errors":    errStr,
+		"errclass":  ErrorClass(err),
+	}
+	if raw != "" {
+		doc["rawname"] = raw
+	}
+	if checksum != nil {
+		doc[d.hh] = hex.EncodeToString(checksum)
+	}
+	matches := make([]map[string]string, 0, len(ids))
+	var thisName string
+	idx := -1
+	for _, id := range ids {
+		values := id.Values()
+		if values[0] != thisName {
+			idx++
+			thisName = values[0]
+		}
+		if idx >= len(d.keys) {
+			break
+		}
+		m := make(map[string]string, len(d.keys[idx]))
+		for i, j := range d.idxs[idx] {
+			if j >= 0 && j < len(values) {
+				m[d.keys[idx][i]] = values[j]
+			} else {
+				m[d.keys[idx][i]] = ""
+			}
+		}
+		matches = append(matches, m)
+	}
+	doc["matches"] = matches
+	return json.Marshal(doc)
+}
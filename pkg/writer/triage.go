@@ -0,0 +1,146 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// TriageResults is the name of the file of sf results (YAML) in a triage bundle.
+const TriageResults = "results.yaml"
+
+type triageWriter struct {
+	path    string
+	f       *os.File
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	files   int   // maximum number of files to bundle
+	size    int64 // maximum total size of the files bundled
+	n       int
+	total   int64
+	results *bytes.Buffer
+	yaml    Writer // writes the results of the bundled files
+	err     error
+}
+
+// Triage returns a Sink that bundles unknown files (files without a match from any identifier) into a tar file,
+// with their results in a results.yaml file, e.g. to send to PRONOM or to develop signatures for them. Files are
+// bundled until there are maxFiles of them or the next would take their total size over maxSize bytes (larger
+// files are skipped, smaller ones may still fit). Files are stored under their absolute paths, without a leading / or
+// volume name, as tar does. The tar file is gzipped if its name ends in .gz or .tgz. Only files on disk are
+// bundled, not the contents of archives.
+func Triage(path string, maxFiles int, maxSize int64) (Sink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("writer: can't make triage bundle: %v", err)
+	}
+	t := &triageWriter{path: path, f: f, files: maxFiles, size: maxSize, results: &bytes.Buffer{}}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".gz" || ext == ".tgz" {
+		t.gz = gzip.NewWriter(f)
+		t.tw = tar.NewWriter(t.gz)
+	} else {
+		t.tw = tar.NewWriter(f)
+	}
+	t.yaml = YAML(t.results)
+	return t, nil
+}
+
+func (t *triageWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	t.yaml.Head(path, scanned, created, version, ids, fields, hh)
+}
+
+func (t *triageWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	if t.err != nil || sz < 0 || t.n >= t.files {
+		return
+	}
+	for _, id := range ids {
+		if id.Known() {
+			return
+		}
+	}
+	fi, serr := os.Lstat(name)
+	if serr != nil || !fi.Mode().IsRegular() || t.total+fi.Size() > t.size {
+		return
+	}
+	f, oerr := os.Open(name)
+	if oerr != nil {
+		return
+	}
+	defer f.Close()
+	hdr := &tar.Header{
+		Name:    tarName(name),
+		Mode:    0644,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	}
+	if werr := t.tw.WriteHeader(hdr); werr != nil {
+		t.err = fmt.Errorf("writer: can't write triage bundle %s: %v", t.path, werr)
+		return
+	}
+	if _, werr := io.CopyN(t.tw, f, fi.Size()); werr != nil {
+		t.err = fmt.Errorf("writer: can't write %s to triage bundle %s: %v", name, t.path, werr)
+		return
+	}
+	t.n++
+	t.total += fi.Size()
+	t.yaml.File(name, sz, mod, checksum, err, ids)
+}
+
+// Tail adds the results of the bundled files and closes the bundle
+func (t *triageWriter) Tail() {
+	t.yaml.Tail()
+	err := t.err
+	if err == nil {
+		err = t.tw.WriteHeader(&tar.Header{Name: TriageResults, Mode: 0644, Size: int64(t.results.Len()), ModTime: time.Now()})
+		if err == nil {
+			_, err = t.tw.Write(t.results.Bytes())
+		}
+	}
+	if cerr := t.tw.Close(); err == nil {
+		err = cerr
+	}
+	if t.gz != nil {
+		if cerr := t.gz.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := t.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil && t.err == nil {
+		t.err = fmt.Errorf("writer: can't write triage bundle %s: %v", t.path, err)
+	}
+}
+
+func (t *triageWriter) Err() error { return t.err }
+
+// tarName is the name of a file in a tar file: a relative, slash separated path
+func tarName(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = strings.TrimPrefix(path, filepath.VolumeName(path))
+	return strings.TrimLeft(filepath.ToSlash(path), "/")
+}
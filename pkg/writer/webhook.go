@@ -0,0 +1,159 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package writer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// Results are posted to a webhook in batches of up to webhookBatch files.
+const webhookBatch = 100
+
+// WebhookSignature is the header that carries the HMAC-SHA256 signature of a webhook's body, as "sha256=" and the
+// signature in hex. Receivers should compute the signature of the body with the shared secret and compare.
+const WebhookSignature = "X-Siegfried-Signature"
+
+// WebhookEvent is the header that names the event a webhook is for: "results" for a batch of results or
+// "complete" for the summary posted at the end of a scan.
+const WebhookEvent = "X-Siegfried-Event"
+
+type webhookWriter struct {
+	docs
+	url     string
+	secret  []byte
+	batch   []json.RawMessage
+	files   int
+	errs    int
+	unknown int
+	formats map[string]int
+	err     error
+}
+
+// Webhook returns a Sink that posts results to a URL as JSON: batches of documents for files (the same documents
+// that Elastic indexes) and, at the end of the scan, a summary with counts of files, errors, unknowns and formats.
+// If secret isn't empty, each request is signed with an HMAC-SHA256 of its body in the WebhookSignature header.
+func Webhook(u string, secret string) (Sink, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("writer: bad webhook URL %s: %v", u, err)
+	}
+	if (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+		return nil, fmt.Errorf("writer: bad webhook URL %s: expecting an http or https URL", u)
+	}
+	return &webhookWriter{url: pu.String(), secret: []byte(secret), formats: make(map[string]int)}, nil
+}
+
+func (wh *webhookWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	wh.head(path, scanned, version, fields, hh)
+}
+
+func (wh *webhookWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	wh.files++
+	if err != nil {
+		wh.errs++
+	}
+	var unknown bool
+	for _, id := range ids {
+		if id.Known() {
+			wh.formats[id.String()]++
+		} else {
+			unknown = true
+		}
+	}
+	if unknown {
+		wh.unknown++
+	}
+	if wh.err != nil {
+		return
+	}
+	doc, derr := wh.doc(name, sz, mod, checksum, err, ids)
+	if derr != nil {
+		wh.err = fmt.Errorf("writer: can't post %s to webhook: %v", name, derr)
+		return
+	}
+	wh.batch = append(wh.batch, doc)
+	if len(wh.batch) >= webhookBatch {
+		wh.flush()
+	}
+}
+
+func (wh *webhookWriter) Tail() {
+	if wh.err == nil && len(wh.batch) > 0 {
+		wh.flush()
+	}
+	if wh.err != nil {
+		return
+	}
+	wh.send("complete", struct {
+		Siegfried string         `json:"siegfried"`
+		Scandate  string         `json:"scandate"`
+		Signature string         `json:"signature"`
+		Files     int            `json:"files"`
+		Errors    int            `json:"errors"`
+		Unknown   int            `json:"unknown"`
+		Formats   map[string]int `json:"formats"`
+	}{wh.version, wh.scandate, wh.signature, wh.files, wh.errs, wh.unknown, wh.formats})
+}
+
+func (wh *webhookWriter) Err() error { return wh.err }
+
+func (wh *webhookWriter) flush() {
+	wh.send("results", struct {
+		Siegfried string            `json:"siegfried"`
+		Scandate  string            `json:"scandate"`
+		Signature string            `json:"signature"`
+		Files     []json.RawMessage `json:"files"`
+	}{wh.version, wh.scandate, wh.signature, wh.batch})
+	wh.batch = wh.batch[:0]
+}
+
+// send posts v as JSON, signed if there is a secret
+func (wh *webhookWriter) send(event string, v interface{}) {
+	byts, err := json.Marshal(v)
+	if err != nil {
+		wh.err = fmt.Errorf("writer: can't post to webhook: %v", err)
+		return
+	}
+	var sig string
+	if len(wh.secret) > 0 {
+		mac := hmac.New(sha256.New, wh.secret)
+		mac.Write(byts)
+		sig = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	_, err = post(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, wh.url, bytes.NewReader(byts))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(WebhookEvent, event)
+			if sig != "" {
+				req.Header.Set(WebhookSignature, sig)
+			}
+		}
+		return req, err
+	})
+	if err != nil {
+		wh.err = fmt.Errorf("writer: posting %s to webhook %s failed: %v", event, wh.url, err)
+	}
+}
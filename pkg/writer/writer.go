@@ -50,6 +50,7 @@ func (n null) Tail() {}
 type csvWriter struct {
 	recs  [][]string
 	names []string
+	raw   bool // has a rawname column (config.Normalise)
 	w     *csv.Writer
 }
 
@@ -59,7 +60,11 @@ func CSV(w io.Writer) Writer {
 
 func (c *csvWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
 	c.names = make([]string, len(fields))
-	l := 4
+	c.raw = config.Normalise()
+	l := 5 // the errclass column is last, so that the columns of earlier versions of sf keep their positions
+	if c.raw {
+		l++
+	}
 	if hh != "" {
 		l++
 	}
@@ -69,10 +74,14 @@ func (c *csvWriter) Head(path string, scanned, created time.Time, version [3]int
 	}
 	c.recs = make([][]string, 1)
 	c.recs[0] = make([]string, l)
-	c.recs[0][0], c.recs[0][1], c.recs[0][2], c.recs[0][3] = "filename", "filesize", "modified", "errors"
+	c.recs[0][0], c.recs[0][1], c.recs[0][2], c.recs[0][3], c.recs[0][l-1] = "filename", "filesize", "modified", "errors", "errclass"
 	idx := 4
+	if c.raw {
+		c.recs[0][idx] = "rawname"
+		idx++
+	}
 	if hh != "" {
-		c.recs[0][4] = hh
+		c.recs[0][idx] = hh
 		idx++
 	}
 	for _, f := range fields {
@@ -87,18 +96,24 @@ func (c *csvWriter) File(name string, sz int64, mod string, checksum []byte, err
 	if err != nil {
 		errStr = err.Error()
 	}
-	c.recs[0][0], c.recs[0][1], c.recs[0][2], c.recs[0][3] = name, strconv.FormatInt(sz, 10), mod, errStr
+	name, raw := normalise(name)
+	last := len(c.recs[0]) - 1
+	c.recs[0][0], c.recs[0][1], c.recs[0][2], c.recs[0][3], c.recs[0][last] = name, strconv.FormatInt(sz, 10), mod, errStr, ErrorClass(err)
 	idx := 4
+	if c.raw {
+		c.recs[0][idx] = raw
+		idx++
+	}
 	if checksum != nil {
-		c.recs[0][4] = hex.EncodeToString(checksum)
+		c.recs[0][idx] = hex.EncodeToString(checksum)
 		idx++
 	}
 	if len(ids) == 0 {
-		empty := make([]string, len(c.recs[0])-idx)
+		empty := make([]string, last-idx)
 		if checksum != nil {
-			c.recs[0][4] = ""
+			c.recs[0][idx-1] = ""
 		}
-		copy(c.recs[0][idx:], empty)
+		copy(c.recs[0][idx:last], empty)
 		c.w.Write(c.recs[0])
 		return
 	}
@@ -120,6 +135,7 @@ func (c *csvWriter) File(name string, sz int64, mod string, checksum []byte, err
 			c.recs = append(c.recs, make([]string, len(c.recs[0])))
 			copy(c.recs[rowIdx][:idx], c.recs[0][:idx])
 		}
+		c.recs[rowIdx][last] = c.recs[0][last]
 		copy(c.recs[rowIdx][colIdx:], fields)
 	}
 	for _, r := range c.recs {
@@ -136,7 +152,9 @@ type yamlWriter struct {
 	w        *bufio.Writer
 	hh       string
 	hstrs    []string
+	idxs     [][]int
 	vals     [][]interface{}
+	sess     *tally
 }
 
 func YAML(w io.Writer) Writer {
@@ -146,19 +164,64 @@ func YAML(w io.Writer) Writer {
 	}
 }
 
-func header(fields []string) string {
-	headings := make([]string, len(fields))
-	var max int
-	for _, v := range fields {
-		if v != "namespace" && len(v) > max {
-			max = len(v)
-		}
+// the fields of matches in YAML and JSON output. Each match has these fields, in this order, followed by any other
+// fields an identifier has (e.g. the URI and source fields of the wikidata identifier). Identifiers' format fields are
+// written as name. The legacy layout (config.SetLegacy) has just the fields of each identifier, with the namespace
+// field written as ns.
+var matchFields = []string{"namespace", "id", "name", "version", "mime", "basis", "warning"}
+
+// layout returns the keys for an identifier's matches and, for each key, the index of its value in the identifier's
+// fields (or -1 if the identifier doesn't have that field)
+func layout(fields []string) ([]string, []int) {
+	if config.Legacy() {
+		return legacyLayout(fields)
 	}
-	pad := fmt.Sprintf("%%-%ds", max)
+	return matchLayout(fields)
+}
+
+// legacyLayout is the legacy layout of matches: each identifier's own fields, with namespace written as ns
+func legacyLayout(fields []string) ([]string, []int) {
+	keys, idxs := make([]string, len(fields)), make([]int, len(fields))
 	for i, v := range fields {
 		if v == "namespace" {
 			v = "ns"
 		}
+		keys[i], idxs[i] = v, i
+	}
+	return keys, idxs
+}
+
+// matchLayout is the default layout of matches: the matchFields, then any extra fields
+func matchLayout(fields []string) ([]string, []int) {
+	keys, idxs := append([]string{}, matchFields...), make([]int, len(matchFields))
+	used := make([]bool, len(fields))
+	for i, k := range matchFields {
+		idxs[i] = -1
+		for j, v := range fields {
+			if !used[j] && (v == k || (k == "name" && v == "format")) {
+				idxs[i], used[j] = j, true
+				break
+			}
+		}
+	}
+	for j, v := range fields {
+		if !used[j] {
+			keys, idxs = append(keys, v), append(idxs, j)
+		}
+	}
+	return keys, idxs
+}
+
+func header(keys []string) string {
+	headings := make([]string, len(keys))
+	var max int
+	for _, v := range keys {
+		if len(v) > max {
+			max = len(v)
+		}
+	}
+	pad := fmt.Sprintf("%%-%ds", max)
+	for i, v := range keys {
 		headings[i] = fmt.Sprintf(pad, v)
 	}
 	return "  - " + strings.Join(headings, " : %v\n    ") + " : %v\n"
@@ -167,17 +230,29 @@ func header(fields []string) string {
 func (y *yamlWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
 	y.hh = hh
 	y.hstrs = make([]string, len(fields))
+	y.idxs = make([][]int, len(fields))
 	y.vals = make([][]interface{}, len(fields))
 	for i, f := range fields {
-		y.hstrs[i] = header(f)
-		y.vals[i] = make([]interface{}, len(f))
+		var keys []string
+		keys, y.idxs[i] = layout(f)
+		y.hstrs[i] = header(keys)
+		y.vals[i] = make([]interface{}, len(keys))
 	}
 	fmt.Fprintf(y.w,
-		"---\nsiegfried   : %d.%d.%d\nscandate    : %v\nsignature   : %s\ncreated     : %v\nidentifiers : \n",
+		"---\nsiegfried   : %d.%d.%d\nscandate    : %v\nsignature   : %s\ncreated     : %v\n",
 		version[0], version[1], version[2],
 		scanned.Format(time.RFC3339),
 		y.replacer.Replace(path),
 		created.Format(time.RFC3339))
+	if y.sess = newTally(scanned); y.sess != nil {
+		fmt.Fprintf(y.w, "session     :\n  host      : '%s'\n  user      : '%s'\n  command   : '%s'\n  sighash   : %s\n  start     : %s\n",
+			y.replacer.Replace(y.sess.Host),
+			y.replacer.Replace(y.sess.User),
+			y.replacer.Replace(y.sess.Command),
+			y.sess.SigHash,
+			y.sess.start.Format(time.RFC3339))
+	}
+	y.w.WriteString("identifiers : \n")
 	for _, id := range ids {
 		fmt.Fprintf(y.w, "  - name    : '%v'\n    details : '%v'\n", id[0], id[1])
 	}
@@ -193,28 +268,42 @@ func (y *yamlWriter) File(name string, sz int64, mod string, checksum []byte, er
 	if err != nil {
 		errStr = "'" + y.replacer.Replace(err.Error()) + "'"
 	}
+	y.sess.count(sz, err, ids)
 	if checksum != nil {
 		h = fmt.Sprintf("%-8s : %s\n", y.hh, hex.EncodeToString(checksum))
 	}
-	fmt.Fprintf(y.w, "---\nfilename : '%s'\nfilesize : %d\nmodified : %s\nerrors   : %s\n%smatches  :\n", y.replacer.Replace(name), sz, mod, errStr, h)
+	name, raw := normalise(name)
+	if raw != "" {
+		h = fmt.Sprintf("rawname  : '%s'\n", y.replacer.Replace(raw)) + h
+	}
+	fmt.Fprintf(y.w, "---\nfilename : '%s'\nfilesize : %d\nmodified : %s\nerrors   : %s\nerrclass : %s\n%smatches  :\n", y.replacer.Replace(name), sz, mod, errStr, ErrorClass(err), h)
 	for _, id := range ids {
 		values := id.Values()
 		if values[0] != thisName {
 			idx++
 			thisName = values[0]
 		}
-		for i, v := range values {
-			if v == "" {
+		for i, j := range y.idxs[idx] {
+			if j < 0 || j >= len(values) || values[j] == "" {
 				y.vals[idx][i] = ""
 				continue
 			}
-			y.vals[idx][i] = "'" + y.replacer.Replace(v) + "'"
+			y.vals[idx][i] = "'" + y.replacer.Replace(values[j]) + "'"
 		}
 		fmt.Fprintf(y.w, y.hstrs[idx], y.vals[idx]...)
 	}
 }
 
-func (y *yamlWriter) Tail() { y.w.Flush() }
+// Tail ends the output with a document for each group of files (see SetGroups) and a session document (if there is
+// a session) with the end time and totals of the scan
+func (y *yamlWriter) Tail() {
+	y.groups()
+	if y.sess != nil {
+		fmt.Fprintf(y.w, "---\nsession  :\n  end     : %s\n  files   : %d\n  errors  : %d\n  unknown : %d\n",
+			y.sess.end().Format(time.RFC3339), y.sess.files, y.sess.errors, y.sess.unknown)
+	}
+	y.w.Flush()
+}
 
 type jsonWriter struct {
 	subs     bool
@@ -222,6 +311,16 @@ type jsonWriter struct {
 	w        *bufio.Writer
 	hh       string
 	hstrs    []func([]string) string
+	nest     bool
+	open     []jsonParent // with config.Nest, the records that haven't been closed yet, outermost first
+	sess     *tally
+}
+
+type jsonParent struct {
+	name     string
+	checksum []byte
+	arc      bool // only archives get children
+	children bool // a children array has been opened
 }
 
 func JSON(w io.Writer) Writer {
@@ -232,17 +331,18 @@ func JSON(w io.Writer) Writer {
 }
 
 func jsonizer(fields []string) func([]string) string {
-	for i, v := range fields {
-		if v == "namespace" {
-			fields[i] = "\"ns\":\""
-			continue
-		}
-		fields[i] = "\"" + v + "\":\""
+	keys, idxs := layout(fields)
+	for i, v := range keys {
+		keys[i] = "\"" + v + "\":\""
 	}
-	vals := make([]string, len(fields))
+	vals := make([]string, len(keys))
 	return func(values []string) string {
-		for i, v := range values {
-			vals[i] = fields[i] + v
+		for i, j := range idxs {
+			var v string
+			if j >= 0 && j < len(values) {
+				v = values[j]
+			}
+			vals[i] = keys[i] + v
 		}
 		return "{" + strings.Join(vals, "\",") + "\"}"
 	}
@@ -250,13 +350,15 @@ func jsonizer(fields []string) func([]string) string {
 
 func (j *jsonWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
 	j.hh = hh
+	j.nest = config.Nest()
 	j.hstrs = make([]func([]string) string, len(fields))
 	for i, f := range fields {
 		j.hstrs[i] = jsonizer(f)
 	}
 	fmt.Fprintf(j.w,
-		"{\"siegfried\":\"%d.%d.%d\",\"scandate\":\"%v\",\"signature\":\"%s\",\"created\":\"%v\",\"identifiers\":[",
+		"{\"siegfried\":\"%d.%d.%d\",\"schemaVersion\":\"%s\",\"scandate\":\"%v\",\"signature\":\"%s\",\"created\":\"%v\",\"identifiers\":[",
 		version[0], version[1], version[2],
+		schemaVersion(),
 		scanned.Format(time.RFC3339),
 		path,
 		created.Format(time.RFC3339))
@@ -266,26 +368,46 @@ func (j *jsonWriter) Head(path string, scanned, created time.Time, version [3]in
 		}
 		fmt.Fprintf(j.w, "{\"name\":\"%s\",\"details\":\"%s\"}", id[0], id[1])
 	}
-	j.w.WriteString("],\"files\":[")
+	j.w.WriteString("],")
+	if j.sess = newTally(scanned); j.sess != nil {
+		fmt.Fprintf(j.w, "\"session\":{\"host\":\"%s\",\"user\":\"%s\",\"command\":\"%s\",\"sighash\":\"%s\",\"start\":\"%s\"},",
+			j.replacer.Replace(j.sess.Host),
+			j.replacer.Replace(j.sess.User),
+			j.replacer.Replace(j.sess.Command),
+			j.sess.SigHash,
+			j.sess.start.Format(time.RFC3339))
+	}
+	j.w.WriteString("\"files\":[")
 }
 
 func (j *jsonWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
-	if j.subs {
-		j.w.WriteString(",")
-	}
 	var (
 		errStr   string
 		h        string
 		thisName string
 		idx      int = -1
 	)
+	j.sess.count(sz, err, ids)
+	name, raw := normalise(name)
+	if j.nest {
+		depth, parent := j.enter(name)
+		h = fmt.Sprintf("\"depth\": %d,", depth)
+		if parent != nil {
+			h += fmt.Sprintf("\"parent\":\"%s\",", hex.EncodeToString(parent))
+		}
+	} else if j.subs {
+		j.w.WriteString(",")
+	}
 	if err != nil {
-		errStr = err.Error()
+		errStr = j.replacer.Replace(err.Error())
 	}
 	if checksum != nil {
-		h = fmt.Sprintf("\"%s\":\"%s\",", j.hh, hex.EncodeToString(checksum))
+		h = fmt.Sprintf("\"%s\":\"%s\",", j.hh, hex.EncodeToString(checksum)) + h
 	}
-	fmt.Fprintf(j.w, "{\"filename\":\"%s\",\"filesize\": %d,\"modified\":\"%s\",\"errors\": \"%s\",%s\"matches\": [", j.replacer.Replace(name), sz, mod, errStr, h)
+	if raw != "" {
+		h = fmt.Sprintf("\"rawname\":\"%s\",", j.replacer.Replace(raw)) + h
+	}
+	fmt.Fprintf(j.w, "{\"filename\":\"%s\",\"filesize\": %d,\"modified\":\"%s\",\"errors\": \"%s\",\"errclass\":\"%s\",%s\"matches\": [", j.replacer.Replace(name), sz, mod, errStr, ErrorClass(err), h)
 	for i, id := range ids {
 		if i > 0 {
 			j.w.WriteString(",")
@@ -297,13 +419,81 @@ func (j *jsonWriter) File(name string, sz int64, mod string, checksum []byte, er
 		}
 		j.w.WriteString(j.hstrs[idx](values))
 	}
-	j.w.WriteString("]}")
 	j.subs = true
+	if j.nest {
+		// leave the record open in case the next files are its contents
+		j.w.WriteString("]")
+		var arc bool
+		for _, id := range ids {
+			if id.Archive() > config.None {
+				arc = true
+				break
+			}
+		}
+		j.open = append(j.open, jsonParent{name: name, checksum: checksum, arc: arc})
+		return
+	}
+	j.w.WriteString("]}")
 	return
 }
 
+// enter closes the open records that don't contain the named file, and writes the separator before its record:
+// either a comma or, for the first of an archive's contents, the start of the archive's children array.
+// It returns the depth of the file and the checksum of the archive that contains it.
+func (j *jsonWriter) enter(name string) (int, []byte) {
+	for len(j.open) > 0 {
+		p := j.open[len(j.open)-1]
+		if p.arc && isChild(p.name, name) {
+			break
+		}
+		j.close()
+	}
+	depth := len(j.open)
+	if depth == 0 {
+		if j.subs {
+			j.w.WriteString(",")
+		}
+		return 0, nil
+	}
+	p := &j.open[depth-1]
+	if p.children {
+		j.w.WriteString(",")
+	} else {
+		j.w.WriteString(",\"children\":[")
+		p.children = true
+	}
+	return depth, p.checksum
+}
+
+func (j *jsonWriter) close() {
+	if j.open[len(j.open)-1].children {
+		j.w.WriteString("]")
+	}
+	j.w.WriteString("}")
+	j.open = j.open[:len(j.open)-1]
+}
+
+// isChild reports whether a name is the name of a file within the named archive. Names of archive contents are
+// separated by a # (e.g. a/b.zip#c.txt), or with sf -paths uri, are DROID-style URIs (e.g. zip:file:///a/b.zip!/c.txt).
+func isChild(arc, name string) bool {
+	if strings.HasPrefix(name, arc+"#") {
+		return true
+	}
+	idx := strings.Index(name, ":"+arc+"!/")
+	return idx > 0 && !strings.ContainsAny(name[:idx], ":/")
+}
+
 func (j *jsonWriter) Tail() {
-	j.w.WriteString("]}\n")
+	for len(j.open) > 0 {
+		j.close()
+	}
+	j.w.WriteString("]")
+	j.groups()
+	if j.sess != nil {
+		fmt.Fprintf(j.w, ",\"summary\":{\"end\":\"%s\",\"files\":%d,\"errors\":%d,\"unknown\":%d}",
+			j.sess.end().Format(time.RFC3339), j.sess.files, j.sess.errors, j.sess.unknown)
+	}
+	j.w.WriteString("}\n")
 	j.w.Flush()
 }
 
@@ -312,6 +502,8 @@ type droidWriter struct {
 	parents map[string]parent
 	rec     []string
 	w       *csv.Writer
+	q       *bufio.Writer // if set, write records with every field quoted, as DROID does, rather than to w
+	onefile bool          // write a row for each file, rather than for each format
 }
 
 type parent struct {
@@ -329,11 +521,40 @@ func Droid(w io.Writer) Writer {
 }
 
 // "identifier", "id", "format name", "format version", "mimetype", "basis", "warning"
+// DroidFile writes DROID CSV output with a row for each file, like DROID's "one row per file" export: a file with
+// more than one format has extra PUID, MIME_TYPE, FORMAT_NAME and FORMAT_VERSION columns for each format after the
+// first. Every field is quoted, as in DROID's own exports. (DROID profiles (.droid files) are Derby databases, which
+// sf can't write.)
+func DroidFile(w io.Writer) Writer {
+	return &droidWriter{
+		parents: make(map[string]parent),
+		rec:     make([]string, 18),
+		q:       bufio.NewWriter(w),
+		onefile: true,
+	}
+}
+
+func (d *droidWriter) write(rec []string) {
+	if d.q == nil {
+		d.w.Write(rec)
+		return
+	}
+	for i, v := range rec {
+		if i > 0 {
+			d.q.WriteByte(',')
+		}
+		d.q.WriteByte('"')
+		d.q.WriteString(strings.Replace(v, `"`, `""`, -1))
+		d.q.WriteByte('"')
+	}
+	d.q.WriteByte('\n')
+}
+
 func (d *droidWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
 	if hh == "" {
 		hh = "no"
 	}
-	d.w.Write([]string{
+	d.write([]string{
 		"ID", "PARENT_ID", "URI", "FILE_PATH", "NAME",
 		"METHOD", "STATUS", "SIZE", "TYPE", "EXT",
 		"LAST_MODIFIED", "EXTENSION_MISMATCH", strings.ToUpper(hh) + "_HASH", "FORMAT_COUNT",
@@ -342,10 +563,8 @@ func (d *droidWriter) Head(path string, scanned, created time.Time, version [3]i
 
 func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
 	d.id++
-	d.rec[0], d.rec[6], d.rec[10] = strconv.Itoa(d.id), "Done", mod
-	if err != nil {
-		d.rec[6] = err.Error()
-	}
+	d.rec[0], d.rec[6], d.rec[10] = strconv.Itoa(d.id), droidStatus(err), mod
+	p, _ = normalise(p)
 	d.rec[1], d.rec[2], d.rec[3], d.rec[4], d.rec[9] = d.processPath(p)
 	// if folder (has sz -1) or error
 	if sz < 0 || ids == nil {
@@ -357,7 +576,7 @@ func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err
 			d.rec[8], d.rec[11] = "", ""
 		}
 		d.rec[3] = clearArchivePath(d.rec[2], d.rec[3])
-		d.w.Write(d.rec)
+		d.write(d.rec)
 		return
 	}
 	// size
@@ -372,10 +591,30 @@ func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err
 		d.rec[5], d.rec[8], d.rec[11], d.rec[13] = "", "File", "FALSE", "0"
 		d.rec[14], d.rec[15], d.rec[16], d.rec[17] = "", "", "", ""
 		d.rec[3] = clearArchivePath(d.rec[2], d.rec[3])
-		d.w.Write(d.rec)
+		d.write(d.rec)
 		return
 	}
 	d.rec[13] = strconv.Itoa(len(ids))
+	if d.onefile {
+		d.rec[8] = "File"
+		for _, id := range ids {
+			if id.Archive() > config.None {
+				d.rec[8] = "Container"
+				d.parents[d.rec[3]] = parent{d.id, d.rec[2], id.Archive().String()}
+			}
+		}
+		fields := ids[0].Values()
+		d.rec[5], d.rec[11] = getMethod(fields[5]), mismatch(fields[6])
+		d.rec[14], d.rec[15], d.rec[16], d.rec[17] = fields[1], fields[4], fields[2], fields[3]
+		d.rec[3] = clearArchivePath(d.rec[2], d.rec[3])
+		rec := d.rec
+		for _, id := range ids[1:] {
+			fields = id.Values()
+			rec = append(rec, fields[1], fields[4], fields[2], fields[3])
+		}
+		d.write(rec)
+		return
+	}
 	for _, id := range ids {
 		if id.Archive() > config.None {
 			d.rec[8] = "Container"
@@ -387,12 +626,18 @@ func (d *droidWriter) File(p string, sz int64, mod string, checksum []byte, err
 		d.rec[5], d.rec[11] = getMethod(fields[5]), mismatch(fields[6])
 		d.rec[14], d.rec[15], d.rec[16], d.rec[17] = fields[1], fields[4], fields[2], fields[3]
 		d.rec[3] = clearArchivePath(d.rec[2], d.rec[3])
-		d.w.Write(d.rec)
+		d.write(d.rec)
 	}
 	return
 }
 
-func (d *droidWriter) Tail() { d.w.Flush() }
+func (d *droidWriter) Tail() {
+	if d.q != nil {
+		d.q.Flush()
+		return
+	}
+	d.w.Flush()
+}
 
 func (d *droidWriter) processPath(p string) (parent, uri, path, name, ext string) {
 	path, _ = filepath.Abs(p)
@@ -486,3 +731,57 @@ func mismatch(warning string) string {
 	}
 	return "FALSE"
 }
+
+// DefaultMIME is the MIME type written by the MIME writer for files without a match that has a MIME type.
+const DefaultMIME = "application/octet-stream"
+
+type mimeWriter struct {
+	w    *bufio.Writer
+	idxs []int // index of the mime field for each identifier, or -1
+}
+
+// MIME writes a line for each file with its path and MIME type, separated by a tab, for tools that just need
+// Content-Type values. The MIME type is the first given by the first match that has one, or DefaultMIME.
+func MIME(w io.Writer) Writer {
+	return &mimeWriter{w: bufio.NewWriter(w)}
+}
+
+func (m *mimeWriter) Head(path string, scanned, created time.Time, version [3]int, ids [][2]string, fields [][]string, hh string) {
+	m.idxs = make([]int, len(fields))
+	for i, f := range fields {
+		m.idxs[i] = -1
+		for j, v := range f {
+			if v == "mime" {
+				m.idxs[i] = j
+				break
+			}
+		}
+	}
+}
+
+func (m *mimeWriter) File(name string, sz int64, mod string, checksum []byte, err error, ids []core.Identification) {
+	if sz < 0 {
+		return
+	}
+	mime := DefaultMIME
+	var thisName string
+	idx := -1
+	for _, id := range ids {
+		values := id.Values()
+		if values[0] != thisName {
+			idx++
+			thisName = values[0]
+		}
+		if !id.Known() || idx >= len(m.idxs) || m.idxs[idx] < 0 {
+			continue
+		}
+		if v := strings.TrimSpace(strings.Split(values[m.idxs[idx]], ",")[0]); v != "" {
+			mime = v
+			break
+		}
+	}
+	name, _ = normalise(name)
+	fmt.Fprintf(m.w, "%s\t%s\n", name, mime)
+}
+
+func (m *mimeWriter) Tail() { m.w.Flush() }
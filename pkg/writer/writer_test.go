@@ -15,9 +15,25 @@
 package writer
 
 import (
+	"archive/tar"
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -56,11 +72,30 @@ func makeFields() []string {
 }
 
 func TestYAMLHeader(t *testing.T) {
-	expect := "  - ns      : %v\n    id      : %v\n    format  : %v\n    version : %v\n    mime    : %v\n    basis   : %v\n    warning : %v\n"
-	ret := header(makeFields())
+	expect := "  - namespace : %v\n    id        : %v\n    name      : %v\n    version   : %v\n    mime      : %v\n    basis     : %v\n    warning   : %v\n"
+	keys, _ := layout(makeFields())
+	ret := header(keys)
 	if expect != ret {
 		t.Errorf("Expecting header to return %s\nGot: %s", expect, ret)
 	}
+	config.SetLegacy(true)
+	defer config.SetLegacy(false)
+	expect = "  - ns      : %v\n    id      : %v\n    format  : %v\n    version : %v\n    mime    : %v\n    basis   : %v\n    warning : %v\n"
+	keys, _ = layout(makeFields())
+	ret = header(keys)
+	if expect != ret {
+		t.Errorf("Expecting legacy header to return %s\nGot: %s", expect, ret)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	// e.g. a wikidata identifier, with no version field and extra URI and source fields
+	keys, idxs := layout([]string{"namespace", "id", "format", "URI", "mime", "basis", "source", "warning"})
+	expectKeys := []string{"namespace", "id", "name", "version", "mime", "basis", "warning", "URI", "source"}
+	expectIdxs := []int{0, 1, 2, -1, 4, 5, 7, 3, 6}
+	if strings.Join(keys, ",") != strings.Join(expectKeys, ",") || fmt.Sprint(idxs) != fmt.Sprint(expectIdxs) {
+		t.Errorf("Expecting layout %v %v, got %v %v", expectKeys, expectIdxs, keys, idxs)
+	}
 }
 
 func ExampleYAML() {
@@ -75,14 +110,15 @@ func ExampleYAML() {
 	// filesize : 1
 	// modified : 2015-05-24T16:59:13+10:00
 	// errors   : 'mscfb: bad OLE'
+	// errclass : error
 	// matches  :
-	//   - ns      : 'pronom'
-	//     id      : 'fmt/43'
-	//     format  : 'JPEG File Interchange Format'
-	//     version : '1.01'
-	//     mime    : 'image/jpeg'
-	//     basis   : 'extension match jpg; byte match at [[[0 14]] [[75201 2]]]'
-	//     warning :
+	//   - namespace : 'pronom'
+	//     id        : 'fmt/43'
+	//     name      : 'JPEG File Interchange Format'
+	//     version   : '1.01'
+	//     mime      : 'image/jpeg'
+	//     basis     : 'extension match jpg; byte match at [[[0 14]] [[75201 2]]]'
+	//     warning   :
 }
 
 func ExampleJSON() {
@@ -92,5 +128,761 @@ func ExampleJSON() {
 	js.File("example.doc", 1, "2015-05-24T16:59:13+10:00", nil, testErr{}, []core.Identification{testID{}})
 	js.Tail()
 	// Output:
-	// {"filename":"example.doc","filesize": 1,"modified":"2015-05-24T16:59:13+10:00","errors": "mscfb: bad OLE","matches": [{"ns":"pronom","id":"fmt/43","format":"JPEG File Interchange Format","version":"1.01","mime":"image/jpeg","basis":"extension match jpg; byte match at [[[0 14]] [[75201 2]]]","warning":""}]}]}
+	// {"filename":"example.doc","filesize": 1,"modified":"2015-05-24T16:59:13+10:00","errors": "mscfb: bad OLE","errclass":"error","matches": [{"namespace":"pronom","id":"fmt/43","name":"JPEG File Interchange Format","version":"1.01","mime":"image/jpeg","basis":"extension match jpg; byte match at [[[0 14]] [[75201 2]]]","warning":""}]}]}
+}
+
+func TestValidateJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	js := JSON(buf)
+	js.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "md5")
+	js.File("example.doc", 1, "2015-05-24T16:59:13+10:00", []byte{0xab}, testErr{}, []core.Identification{testID{}})
+	js.File("example2.doc", 2, "2015-05-24T16:59:13+10:00", []byte{0xcd}, nil, []core.Identification{testID{}})
+	js.Tail()
+	if err := ValidateJSON(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("expecting valid JSON output, got %v\n%s", err, buf.String())
+	}
+	bad := strings.Replace(buf.String(), `"filesize": 2`, `"filesize": "2"`, 1)
+	err := ValidateJSON(strings.NewReader(bad))
+	if err == nil || !strings.HasPrefix(err.Error(), "files[1].filesize:") {
+		t.Fatalf("expecting an error for files[1].filesize, got %v", err)
+	}
+	bad = strings.Replace(buf.String(), `"schemaVersion":"`+JSONSchemaVersion+`",`, "", 1)
+	if err = ValidateJSON(strings.NewReader(bad)); err == nil {
+		t.Fatal("expecting an error for missing schemaVersion")
+	}
+	// the legacy layout is validated against the legacy schema
+	config.SetLegacy(true)
+	defer config.SetLegacy(false)
+	buf.Reset()
+	js = JSON(buf)
+	js.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	js.File("example.doc", 1, "2015-05-24T16:59:13+10:00", nil, nil, []core.Identification{testID{}})
+	js.Tail()
+	if !strings.Contains(buf.String(), `"schemaVersion":"`+LegacySchemaVersion+`"`) || !strings.Contains(buf.String(), `{"ns":"pronom","id":"fmt/43","format":`) {
+		t.Fatalf("expecting legacy JSON output, got %s", buf.String())
+	}
+	if err := ValidateJSON(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("expecting valid legacy JSON output, got %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register(Registration{Name: "json", New: JSON}); !errors.Is(err, ErrRegistered) {
+		t.Fatalf("expecting ErrRegistered for a duplicate name, got %v", err)
+	}
+	if err := Register(Registration{Name: "null"}); err == nil {
+		t.Fatal("expecting an error for a registration without a New function")
+	}
+	if err := Register(Registration{Name: "null", New: func(io.Writer) Writer { return Null() }}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Lookup("null"); !ok {
+		t.Fatal("expecting to find the null output format")
+	}
+	if names := strings.Join(Names(), ","); names != "archivematica,csv,dfxml,droid,droidfile,json,mime,null,opex,postgres,yaml" {
+		t.Fatalf("expecting sorted names, got %s", names)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	_, invalid := os.Open(string([]byte{0})) // an invalid path, rather than a permission or not found error
+	tests := []struct {
+		err   error
+		class string
+	}{
+		{nil, ""},
+		{&os.PathError{Op: "open", Path: "x", Err: os.ErrPermission}, ClassPermission},
+		{fmt.Errorf("walking: %w", &os.PathError{Op: "lstat", Path: "x", Err: os.ErrNotExist}), ClassNotFound},
+		{ClassifyError(ClassDecompress, errors.New("bad zip")), ClassDecompress},
+		{fmt.Errorf("wrapped: %w", ClassifyError(ClassEncrypted, errors.New("encrypted"))), ClassEncrypted},
+		{invalid, ClassError},
+		{testErr{}, ClassError},
+	}
+	for _, tt := range tests {
+		if got := ErrorClass(tt.err); got != tt.class {
+			t.Errorf("expecting class %q for %v, got %q", tt.class, tt.err, got)
+		}
+	}
+	if ClassifyError(ClassWalk, nil) != nil {
+		t.Error("expecting a nil error to stay nil")
+	}
+}
+
+type testArc struct{ testID }
+
+func (t testArc) Archive() config.Archive { return config.Zip }
+
+func TestNest(t *testing.T) {
+	config.SetNest(true)
+	defer config.SetNest(false)
+	buf := &bytes.Buffer{}
+	js := JSON(buf)
+	js.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "md5")
+	arc, plain := []core.Identification{testArc{}}, []core.Identification{testID{}}
+	js.File("a.zip", 1, "", []byte{0x01}, nil, arc)
+	js.File("a.zip#b.txt", 1, "", []byte{0x02}, nil, plain)
+	js.File("a.zip#c.zip", 1, "", []byte{0x03}, nil, arc)
+	js.File("a.zip#c.zip#d.txt", 1, "", []byte{0x04}, nil, plain)
+	js.File("a.zip#e.txt", 1, "", []byte{0x05}, nil, plain)
+	js.File("a.zip#f.txt", 1, "", []byte{0x06}, nil, plain) // not an archive, so has no children
+	js.File("a.zip#f.txt#g.txt", 1, "", []byte{0x07}, nil, plain)
+	js.File("h.txt", 1, "", []byte{0x08}, nil, plain)
+	js.Tail()
+	if err := ValidateJSON(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("expecting valid JSON output, got %v\n%s", err, buf.String())
+	}
+	type file struct {
+		Filename string
+		Depth    int
+		Parent   string
+		Children []file
+	}
+	var doc struct{ Files []file }
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	var walk func([]file)
+	walk = func(fs []file) {
+		for _, f := range fs {
+			got = append(got, fmt.Sprintf("%s %d %s", f.Filename, f.Depth, f.Parent))
+			walk(f.Children)
+		}
+	}
+	walk(doc.Files)
+	expect := []string{"a.zip 0 ", "a.zip#b.txt 1 01", "a.zip#c.zip 1 01", "a.zip#c.zip#d.txt 2 03", "a.zip#e.txt 1 01", "a.zip#f.txt 1 01", "a.zip#f.txt#g.txt 1 01", "h.txt 0 "}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") || len(doc.Files) != 2 || len(doc.Files[0].Children) != 5 {
+		t.Errorf("expecting:\n%s\ngot:\n%s\nfrom %s", strings.Join(expect, "\n"), strings.Join(got, "\n"), buf.String())
+	}
+	for _, tt := range []struct {
+		arc, name string
+		child     bool
+	}{
+		{"a.zip", "a.zip#b.txt", true},
+		{"a.zip", "a.zipper", false},
+		{"file:///a.zip", "zip:file:///a.zip!/b.txt", true},
+		{"zip:file:///a.zip!/b.zip", "zip:zip:file:///a.zip!/b.zip!/c.txt", true},
+		{"file:///a.zip", "zip:file:///b/file:///a.zip!/c.txt", false},
+	} {
+		if isChild(tt.arc, tt.name) != tt.child {
+			t.Errorf("expecting isChild(%s, %s) to be %v", tt.arc, tt.name, tt.child)
+		}
+	}
+}
+
+func TestMIME(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := MIME(buf)
+	w.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	w.File("a.jpg", 10, "", nil, nil, []core.Identification{testID{}})
+	w.File("dir", -1, "", nil, nil, nil)
+	w.File("b", 0, "", nil, testErr{}, nil)
+	w.Tail()
+	if expect := "a.jpg\timage/jpeg\nb\tapplication/octet-stream\n"; buf.String() != expect {
+		t.Errorf("expecting %q, got %q", expect, buf.String())
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	for _, tt := range []struct{ in, norm, raw string }{
+		{"a/caf\u00e9.txt", "a/caf\u00e9.txt", ""},                  // already NFC
+		{"a/cafe\u0301.txt", "a/caf\u00e9.txt", "a/cafe%CC%81.txt"}, // NFD (e.g. macOS)
+		{"a/caf\xe9.txt", "a/caf\u00e9.txt", "a/caf%E9.txt"},        // Latin-1 bytes (e.g. Linux)
+		{"100%\xff", "100%\u00ff", "100%25%FF"},
+	} {
+		if n := NormalisePath(tt.in); n != tt.norm {
+			t.Errorf("expecting %q to normalise to %q, got %q", tt.in, tt.norm, n)
+		}
+		if tt.raw != "" && RawPath(tt.in) != tt.raw {
+			t.Errorf("expecting raw path %q, got %q", tt.raw, RawPath(tt.in))
+		}
+	}
+	config.SetNormalise(true)
+	defer config.SetNormalise(false)
+	buf := &bytes.Buffer{}
+	js := JSON(buf)
+	js.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	js.File("caf\xe9.doc", 1, "", nil, nil, []core.Identification{testID{}})
+	js.File("plain.doc", 1, "", nil, nil, []core.Identification{testID{}})
+	js.Tail()
+	if err := ValidateJSON(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("expecting valid JSON output, got %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "{\"filename\":\"caf\u00e9.doc\",\"filesize\": 1,\"modified\":\"\",\"errors\": \"\",\"errclass\":\"\",\"rawname\":\"caf%E9.doc\",") ||
+		strings.Count(buf.String(), "rawname") != 1 {
+		t.Errorf("expecting a rawname field for the first file only, got %s", buf.String())
+	}
+	buf.Reset()
+	c := CSV(buf)
+	c.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "md5")
+	c.File("cafe\u0301.doc", 1, "", []byte{0xab}, nil, []core.Identification{testID{}})
+	c.Tail()
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasPrefix(lines[0], "filename,filesize,modified,errors,rawname,md5,namespace") || !strings.HasSuffix(lines[0], ",errclass") ||
+		!strings.HasPrefix(lines[1], "caf\u00e9.doc,1,,,cafe%CC%81.doc,ab,pronom") {
+		t.Errorf("expecting a rawname column, got %s", buf.String())
+	}
+}
+
+func TestElastic(t *testing.T) {
+	defer func(w time.Duration) { sinkWait = w }(sinkWait)
+	sinkWait = time.Millisecond
+	var reqs int
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs++
+		if reqs == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/sf/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("unexpected bulk request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}},{"index":{"status":201}}]}`))
+	}))
+	defer srv.Close()
+	if _, err := Elastic(srv.URL); err == nil {
+		t.Fatal("expecting an error for an elasticsearch URL without an index")
+	}
+	el, err := Elastic(srv.URL + "/sf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	el.Head("default.sig", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), time.Time{}, [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "md5")
+	el.File("example.doc", 1, "2015-05-24T16:59:13+10:00", []byte{0xab}, testErr{}, []core.Identification{testID{}})
+	el.File("example2.doc", 2, "2015-05-24T16:59:13+10:00", nil, nil, []core.Identification{testID{}})
+	el.Tail()
+	if err := el.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if reqs != 2 {
+		t.Errorf("expecting the bulk request to be retried once, got %d requests", reqs)
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 4 || lines[0] != `{"index":{}}` {
+		t.Fatalf("unexpected bulk request body:\n%s", body)
+	}
+	var doc struct {
+		Siegfried string
+		Scandate  string
+		Filename  string
+		Filesize  int64
+		Errclass  string
+		MD5       string
+		Matches   []map[string]string
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Siegfried != "1.9.0" || doc.Scandate != "2026-01-02T03:04:05Z" || doc.Filename != "example.doc" || doc.Filesize != 1 ||
+		doc.Errclass != "error" || doc.MD5 != "ab" || len(doc.Matches) != 1 || doc.Matches[0]["id"] != "fmt/43" {
+		t.Errorf("unexpected document %s", lines[1])
+	}
+}
+
+func TestElasticFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`))
+	}))
+	defer srv.Close()
+	el, _ := Elastic(srv.URL + "/sf")
+	el.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	el.File("example.doc", 1, "", nil, nil, []core.Identification{testID{}})
+	el.File("example2.doc", 2, "", nil, nil, []core.Identification{testID{}})
+	el.Tail()
+	if err := el.Err(); err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Errorf("expecting an error for 1 of 2 documents, got %v", err)
+	}
+}
+
+func TestKafka(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/sf" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			t.Errorf("unexpected kafka request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		byts, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(byts))
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1}]}`))
+	}))
+	defer srv.Close()
+	if _, err := Kafka(srv.URL + "/sf"); err == nil {
+		t.Fatal("expecting an error for a kafka URL without a topic")
+	}
+	k, err := Kafka(srv.URL + "/topics/sf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	for i := 0; i < kafkaBatch+1; i++ {
+		k.File(fmt.Sprintf("example%d.doc", i), 1, "", nil, nil, []core.Identification{testID{}})
+	}
+	k.Tail()
+	if err := k.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("expecting two batches, got %d", len(bodies))
+	}
+	var recs struct {
+		Records []struct {
+			Value struct{ Filename string }
+		}
+	}
+	if err := json.Unmarshal([]byte(bodies[1]), &recs); err != nil {
+		t.Fatal(err)
+	}
+	if len(recs.Records) != 1 || recs.Records[0].Value.Filename != fmt.Sprintf("example%d.doc", kafkaBatch) {
+		t.Errorf("unexpected kafka records %s", bodies[1])
+	}
+}
+
+func TestAMQP(t *testing.T) {
+	var msgs []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/exchanges/%2F/amq.topic/publish" {
+			t.Errorf("unexpected AMQP request %s", r.URL.EscapedPath())
+		}
+		if u, p, ok := r.BasicAuth(); !ok || u != "guest" || p != "pw" {
+			t.Errorf("expecting basic auth, got %s %s", u, p)
+		}
+		var msg map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&msg)
+		msgs = append(msgs, msg)
+		w.Write([]byte(`{"routed":true}`))
+	}))
+	defer srv.Close()
+	a, err := AMQP(strings.Replace(srv.URL, "http://", "http://guest:pw@", 1) + "/api/exchanges/%2F/amq.topic?key=sf.results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	a.File("example.doc", 1, "", nil, nil, []core.Identification{testID{}})
+	a.File("example2.doc", 2, "", nil, nil, []core.Identification{testID{}})
+	a.Tail()
+	if err := a.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0]["routing_key"] != "sf.results" || !strings.Contains(msgs[1]["payload"].(string), `"filename":"example2.doc"`) {
+		t.Errorf("unexpected AMQP messages %v", msgs)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	type req struct {
+		event, sig string
+		body       []byte
+	}
+	var reqs []req
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		byts, _ := ioutil.ReadAll(r.Body)
+		reqs = append(reqs, req{r.Header.Get(WebhookEvent), r.Header.Get(WebhookSignature), byts})
+	}))
+	defer srv.Close()
+	wh, err := Webhook(srv.URL, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wh.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	wh.File("example.doc", 1, "", nil, testErr{}, []core.Identification{testID{}})
+	wh.File("example2.doc", 2, "", nil, nil, []core.Identification{testID{}})
+	wh.Tail()
+	if err := wh.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 2 || reqs[0].event != "results" || reqs[1].event != "complete" {
+		t.Fatalf("expecting a results and a complete request, got %v", reqs)
+	}
+	for _, r := range reqs {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(r.body)
+		if expect := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.sig != expect {
+			t.Errorf("bad signature for %s: expecting %s, got %s", r.event, expect, r.sig)
+		}
+	}
+	var summary struct {
+		Files, Errors int
+		Formats       map[string]int
+	}
+	if err := json.Unmarshal(reqs[1].body, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Files != 2 || summary.Errors != 1 || summary.Formats["fmt/43"] != 2 {
+		t.Errorf("unexpected summary %s", reqs[1].body)
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	buf := &bytes.Buffer{}
+	pg := Postgres(buf)
+	pg.Head("default.sig", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), time.Time{}, [3]int{1, 9, 0}, [][2]string{{"pronom", "it's"}}, [][]string{makeFields()}, "md5")
+	pg.File("a\tb.doc", 1, "2015-05-24T16:59:13+10:00", []byte{0xab}, testErr{}, []core.Identification{testID{}})
+	pg.File("c.doc", 2, "", nil, nil, nil)
+	pg.Tail()
+	script := buf.String()
+	for _, expect := range []string{
+		"IF NOT EXISTS (SELECT FROM sf_schema WHERE version >= 1) THEN\nCREATE TABLE sf_scans",
+		`'[{"details":"it''s","name":"pronom"}]', 'md5');`,
+		"COPY sf_load FROM stdin;\n1\ta\\tb.doc\t\\N\t1\t2015-05-24T16:59:13+10:00\tmscfb: bad OLE\terror\tab\t1\tpronom\tfmt/43\tJPEG File Interchange Format\t1.01\timage/jpeg\textension match jpg; byte match at [[[0 14]] [[75201 2]]]\t\t\\N\n",
+		"\n2\tc.doc\t\\N\t2\t\\N\t\\N\t\\N\t\\N\t\\N\t\\N\t\\N\t\\N\t\\N\t\\N\t\\N\t\\N\t\\N\n\\.\n",
+		"COMMIT;\n",
+	} {
+		if !strings.Contains(script, expect) {
+			t.Errorf("expecting the script to contain %q, got:\n%s", expect, script)
+		}
+	}
+}
+
+func TestROCrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rocrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	existing := `{"@context":["https://w3id.org/ro/crate/1.1/context",{"@vocab":"http://schema.org/"}],"@graph":[` +
+		`{"@id":"ro-crate-metadata.json","@type":"CreativeWork","about":{"@id":"./"}},` +
+		`{"@id":"./","@type":"Dataset","name":"My data","hasPart":[{"@id":"a%20b.jpg"}]},` +
+		`{"@id":"a%20b.jpg","@type":"File","description":"a photo"},` +
+		`{"@id":"#me","@type":"Person","name":"Me"}]}`
+	if err := ioutil.WriteFile(filepath.Join(dir, ROCrateMetadata), []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"a b.jpg", "c.jpg"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), []byte("jpeg"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rc, err := ROCrate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "md5")
+	rc.File(filepath.Join(dir, "a b.jpg"), 4, "2015-05-24T16:59:13+10:00", []byte{0xab}, nil, []core.Identification{testID{}})
+	rc.File(filepath.Join(dir, "c.jpg"), 4, "2015-05-24T16:59:13+10:00", []byte{0xcd}, nil, []core.Identification{testID{}})
+	rc.File(filepath.Join(dir, "c.jpg#d.txt"), 1, "", nil, nil, nil) // archive contents aren't File entities
+	rc.File(filepath.Join(dir, ROCrateMetadata), 1, "", nil, nil, nil)
+	rc.Tail()
+	if err := rc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	byts, err := ioutil.ReadFile(filepath.Join(dir, ROCrateMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var crate struct {
+		Context []interface{}            `json:"@context"`
+		Graph   []map[string]interface{} `json:"@graph"`
+	}
+	if err := json.Unmarshal(byts, &crate); err != nil {
+		t.Fatal(err)
+	}
+	ents := make(map[string]map[string]interface{})
+	for _, e := range crate.Graph {
+		ents[e["@id"].(string)] = e
+	}
+	if len(crate.Context) != 2 || len(crate.Graph) != 6 || ents["#me"] == nil {
+		t.Fatalf("expecting the crate's context and entities to be kept, with a new File and a PRONOM entity, got:\n%s", byts)
+	}
+	ab := ents["a%20b.jpg"]
+	if ab["description"] != "a photo" || ab["md5"] != "ab" || ab["contentSize"] != "4" || fmt.Sprint(ab["encodingFormat"]) != "[image/jpeg map[@id:https://www.nationalarchives.gov.uk/PRONOM/fmt/43]]" {
+		t.Errorf("unexpected File entity %v", ab)
+	}
+	if parts := fmt.Sprint(ents["./"]["hasPart"]); parts != "[map[@id:a%20b.jpg] map[@id:c.jpg]]" {
+		t.Errorf("unexpected hasPart %s", parts)
+	}
+	if ents["https://www.nationalarchives.gov.uk/PRONOM/fmt/43"]["name"] != "JPEG File Interchange Format" {
+		t.Errorf("expecting a PRONOM entity, got:\n%s", byts)
+	}
+}
+
+func ExampleArchivematica() {
+	am := Archivematica(os.Stdout)
+	am.Head("default.sig", time.Time{}, time.Time{}, [3]int{1, 9, 0}, [][2]string{{"pronom", "v96"}}, [][]string{makeFields()}, "")
+	am.File("example.doc", 1, "2015-05-24T16:59:13+10:00", nil, testErr{}, []core.Identification{testID{}})
+	am.Tail()
+	// Output:
+	// {"siegfried":"1.9.0","scandate":"0001-01-01T00:00:00Z","signature":"default.sig","created":"0001-01-01T00:00:00Z","identifiers":[{"name":"pronom","details":"v96"}],"files":[{"filename":"example.doc","filesize":1,"modified":"2015-05-24T16:59:13+10:00","errors":"mscfb: bad OLE","matches":[{"ns":"pronom","id":"fmt/43","format":"JPEG File Interchange Format","version":"1.01","mime":"image/jpeg","basis":"extension match jpg; byte match at [[[0 14]] [[75201 2]]]","warning":""}]}]}
+}
+
+func TestOPEX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	op := OPEX(buf)
+	op.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "sha256")
+	op.File("dir/example.doc", 1, "2015-05-24T16:59:13+10:00", []byte{0xab}, testErr{}, []core.Identification{testID{}})
+	op.File("dir/example2.doc", 2, "", nil, nil, nil)
+	op.Tail()
+	var frags struct {
+		Metadata []struct {
+			SourceID string `xml:"Transfer>SourceID"`
+			Fixity   struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:"value,attr"`
+			} `xml:"Transfer>Fixities>Fixity"`
+			Title string   `xml:"Properties>Title"`
+			IDs   []string `xml:"DescriptiveMetadata>Identification>Format>ID"`
+			Error string   `xml:"DescriptiveMetadata>Identification>Error"`
+		} `xml:"OPEXMetadata"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &frags); err != nil {
+		t.Fatalf("expecting well-formed XML, got %v\n%s", err, buf.String())
+	}
+	if len(frags.Metadata) != 2 {
+		t.Fatalf("expecting two OPEXMetadata fragments, got:\n%s", buf.String())
+	}
+	md := frags.Metadata[0]
+	if md.SourceID != "dir/example.doc" || md.Title != "example.doc" || md.Fixity.Type != "SHA-256" || md.Fixity.Value != "ab" ||
+		len(md.IDs) != 1 || md.IDs[0] != "fmt/43" || md.Error != "mscfb: bad OLE" {
+		t.Errorf("unexpected OPEX metadata %+v", md)
+	}
+	if strings.Contains(buf.String(), "<opex:Fixities></opex:Fixities>") {
+		t.Errorf("expecting no fixities for a file without a checksum, got:\n%s", buf.String())
+	}
+}
+
+type testID2 struct{ testID }
+
+func (t testID2) String() string { return "fmt/44" }
+func (t testID2) Values() []string {
+	return []string{"pronom", "fmt/44", "JPEG File Interchange Format", "1.02", "image/jpeg", "byte match", ""}
+}
+
+func TestDroidFile(t *testing.T) {
+	buf := &bytes.Buffer{}
+	df := DroidFile(buf)
+	df.Head("", time.Time{}, time.Time{}, [3]int{}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "md5")
+	df.File("a \"b\".jpg", 1, "2015-05-24T16:59:13+10:00", []byte{0xab}, nil, []core.Identification{testID{}, testID2{}})
+	df.Tail()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `"ID","PARENT_ID","URI",`) || !strings.HasSuffix(lines[0], `"MD5_HASH","FORMAT_COUNT","PUID","MIME_TYPE","FORMAT_NAME","FORMAT_VERSION"`) {
+		t.Fatalf("expecting a header and a row, got:\n%s", buf.String())
+	}
+	expect := `"a ""b"".jpg","Signature","Done","1","File","jpg","2015-05-24T16:59:13+10:00","FALSE","ab","2","fmt/43","image/jpeg","JPEG File Interchange Format","1.01","fmt/44","image/jpeg","JPEG File Interchange Format","1.02"`
+	if !strings.HasSuffix(lines[1], expect) {
+		t.Errorf("expecting a row ending %s, got %s", expect, lines[1])
+	}
+}
+
+func TestSession(t *testing.T) {
+	SetSession(&Session{Host: "archive01", User: "rl", Command: `sf -session "my dir"`, SigHash: "ab12"})
+	defer SetSession(nil)
+	buf := &bytes.Buffer{}
+	js := JSON(buf)
+	js.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	js.File("example.doc", 1, "2015-05-24T16:59:13+10:00", nil, testErr{}, []core.Identification{testID{}})
+	js.File("dir", -1, "2015-05-24T16:59:13+10:00", nil, nil, nil)
+	js.File("example2.doc", 2, "2015-05-24T16:59:13+10:00", nil, nil, nil)
+	js.Tail()
+	if err := ValidateJSON(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("expecting valid JSON output, got %v\n%s", err, buf.String())
+	}
+	var doc struct {
+		Session map[string]string
+		Summary map[string]interface{}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Session["host"] != "archive01" || doc.Session["command"] != `sf -session "my dir"` || doc.Session["sighash"] != "ab12" || doc.Session["start"] == "" {
+		t.Fatalf("bad session, got %v", doc.Session)
+	}
+	if doc.Summary["files"] != 2.0 || doc.Summary["errors"] != 1.0 || doc.Summary["unknown"] != 1.0 || doc.Summary["end"] == nil {
+		t.Fatalf("bad summary, got %v", doc.Summary)
+	}
+	buf.Reset()
+	yml := YAML(buf)
+	yml.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	yml.File("example.doc", 1, "2015-05-24T16:59:13+10:00", nil, nil, []core.Identification{testID{}})
+	yml.Tail()
+	if !strings.Contains(buf.String(), "  user      : 'rl'\n") || !strings.Contains(buf.String(), "  files   : 1\n  errors  : 0\n  unknown : 0\n") {
+		t.Fatalf("expecting a session in YAML output, got %s", buf.String())
+	}
+	buf.Reset()
+	pg := Postgres(buf)
+	pg.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	pg.Tail()
+	if !strings.Contains(buf.String(), `UPDATE sf_scans SET session = '{"command":"sf -session \"my dir\"",`) {
+		t.Fatalf("expecting a session in postgres output, got %s", buf.String())
+	}
+}
+
+func TestGroups(t *testing.T) {
+	SetGroups(func() []Group {
+		return []Group{{
+			Name:    "shapefile",
+			Primary: `dir\roads.shp`,
+			Files:   []GroupFile{{`dir\roads.shp`, "x-fmt/235"}, {`dir\roads.shx`, "fmt/277"}},
+			Missing: []string{".dbf"},
+		}}
+	})
+	defer SetGroups(nil)
+	buf := &bytes.Buffer{}
+	js := JSON(buf)
+	js.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	js.File(`dir\roads.shp`, 1, "2015-05-24T16:59:13+10:00", nil, nil, []core.Identification{testID{}})
+	js.Tail()
+	if err := ValidateJSON(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("expecting valid JSON output, got %v\n%s", err, buf.String())
+	}
+	var doc struct {
+		Groups []struct {
+			Group, Primary string
+			Missing        []string
+			Files          []struct{ Filename, ID string }
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Groups) != 1 || doc.Groups[0].Primary != `dir\roads.shp` || len(doc.Groups[0].Files) != 2 ||
+		doc.Groups[0].Files[1].ID != "fmt/277" || len(doc.Groups[0].Missing) != 1 {
+		t.Fatalf("bad groups, got %+v", doc.Groups)
+	}
+	buf.Reset()
+	yml := YAML(buf)
+	yml.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	yml.Tail()
+	if !strings.Contains(buf.String(), "---\ngroup    : 'shapefile'\nprimary  : 'dir\\roads.shp'\nmissing  : ['.dbf']\nfiles    :\n  - filename : 'dir\\roads.shp'\n    id       : 'x-fmt/235'\n") {
+		t.Fatalf("expecting a group in YAML output, got %s", buf.String())
+	}
+}
+
+func TestDFXML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	df := DFXML(buf)
+	df.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", "DROID_SignatureFile_V96.xml"}}, [][]string{makeFields()}, "md5")
+	df.File("dir", -1, "2015-05-24T16:59:13+10:00", nil, nil, nil)
+	df.File("dir/example.doc", 3, "2015-05-24T16:59:13+10:00", []byte{0xab}, testErr{}, []core.Identification{testID{}})
+	df.Tail()
+	var doc struct {
+		Program     string `xml:"creator>program"`
+		Identifiers []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"creator>identifier"`
+		Files []struct {
+			Filename string `xml:"filename"`
+			Error    string `xml:"error"`
+			NameType string `xml:"name_type"`
+			Filesize string `xml:"filesize"`
+			Runs     []struct {
+				Offset string `xml:"file_offset,attr"`
+				Len    string `xml:"len,attr"`
+			} `xml:"byte_runs>byte_run"`
+			Hash struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"hashdigest"`
+			Matches []struct {
+				ID    string `xml:"id,attr"`
+				Basis string `xml:"basis,attr"`
+			} `xml:"match"`
+		} `xml:"fileobject"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("expecting well-formed XML, got %v\n%s", err, buf.String())
+	}
+	if doc.Program != "siegfried" || len(doc.Identifiers) != 1 || doc.Identifiers[0].Name != "pronom" || len(doc.Files) != 2 {
+		t.Fatalf("unexpected DFXML:\n%s", buf.String())
+	}
+	if d := doc.Files[0]; d.NameType != "d" || d.Filesize != "" || len(d.Runs) != 0 {
+		t.Errorf("unexpected fileobject for a directory %+v", d)
+	}
+	f := doc.Files[1]
+	if f.NameType != "r" || f.Filesize != "3" || len(f.Runs) != 1 || f.Runs[0].Offset != "0" || f.Runs[0].Len != "3" ||
+		f.Hash.Type != "md5" || f.Hash.Value != "ab" || f.Error != "mscfb: bad OLE" {
+		t.Errorf("unexpected fileobject %+v", f)
+	}
+	if len(f.Matches) != 1 || f.Matches[0].ID != "fmt/43" || f.Matches[0].Basis != testValues[5] {
+		t.Errorf("unexpected matches %+v", f.Matches)
+	}
+}
+
+func TestSample(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sfsample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, n := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		ioutil.WriteFile(filepath.Join(dir, n), []byte(n), 0644)
+	}
+	samples := filepath.Join(dir, "samples")
+	sk, err := Sample(samples, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	for _, n := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		sk.File(filepath.Join(dir, n), 5, "", nil, nil, []core.Identification{testID{}})
+	}
+	sk.File(filepath.Join(dir, "a.jpg#member.jpg"), 5, "", nil, nil, []core.Identification{testID{}})
+	sk.Tail()
+	if err := sk.Err(); err != nil {
+		t.Fatal(err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(samples, "pronom", "fmt_43", "*"))
+	if len(matches) != 2 || filepath.Base(matches[0]) != "1_a.jpg" || filepath.Base(matches[1]) != "2_b.jpg" {
+		t.Fatalf("expecting two samples of fmt/43, got %v", matches)
+	}
+	if byts, _ := ioutil.ReadFile(matches[1]); string(byts) != "b.jpg" {
+		t.Errorf("expecting a copy of b.jpg, got %q", byts)
+	}
+}
+
+type testUnknown struct{ testID }
+
+func (t testUnknown) Known() bool { return false }
+
+func TestTriage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sftriage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for n, sz := range map[string]int{"a.unk": 4, "b.unk": 10, "c.unk": 3, "d.jpg": 1} {
+		ioutil.WriteFile(filepath.Join(dir, n), make([]byte, sz), 0644)
+	}
+	bundle := filepath.Join(dir, "unknowns.tgz")
+	sk, err := Triage(bundle, 10, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk.Head("default.sig", time.Now(), time.Now(), [3]int{1, 9, 0}, [][2]string{{"pronom", ""}}, [][]string{makeFields()}, "")
+	for _, n := range []string{"a.unk", "b.unk", "c.unk", "d.jpg"} {
+		id := core.Identification(testUnknown{})
+		if n == "d.jpg" {
+			id = testID{}
+		}
+		sk.File(filepath.Join(dir, n), 0, "", nil, nil, []core.Identification{id})
+	}
+	sk.Tail()
+	if err := sk.Err(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	var results string
+	for hdr, err := tr.Next(); err == nil; hdr, err = tr.Next() {
+		names = append(names, path.Base(hdr.Name))
+		if hdr.Name == TriageResults {
+			byts, _ := ioutil.ReadAll(tr)
+			results = string(byts)
+		}
+	}
+	// b.unk is too big to fit, and d.jpg isn't unknown
+	if strings.Join(names, ",") != "a.unk,c.unk,"+TriageResults {
+		t.Fatalf("unexpected triage bundle contents %v", names)
+	}
+	if strings.Count(results, "filename : ") != 2 || !strings.Contains(results, "c.unk'") {
+		t.Errorf("unexpected triage results:\n%s", results)
+	}
 }
@@ -0,0 +1,133 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siegfried
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/richardlehane/siegfried/internal/persist"
+	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/pkg/core"
+)
+
+// From signature file format 4, the container, XML, RIFF, byte and text matchers (and, from formats 5 to 8, the
+// EBML, SQLite, TIFF and font matchers) are saved as sections
+// that are loaded when they are first used. This makes loading quicker and saves memory when some matchers
+// aren't needed: e.g. the container matcher when identifying with the Header strategy, or all of them
+// when identifying with the NameOnly strategy.
+//
+// A section is saved as a bool (is there a matcher) followed by the matcher's content prefixed with its length.
+
+func saveSection(ls *persist.LoadSaver, m core.Matcher, save func(core.Matcher, *persist.LoadSaver)) {
+	ls.SaveBool(m != nil)
+	if m == nil {
+		return
+	}
+	sls := persist.NewLoadSaver(nil)
+	save(m, sls)
+	if sls.Err != nil {
+		ls.Err = sls.Err
+		return
+	}
+	ls.SaveSection(sls.Bytes())
+}
+
+func loadSection(ls *persist.LoadSaver, load func(*persist.LoadSaver) core.Matcher) core.Matcher {
+	if !ls.LoadBool() {
+		return nil
+	}
+	return &lazyMatcher{buf: ls.LoadSection(), format: ls.Format, load: load}
+}
+
+// lazyMatcher is a section of a signature file that is loaded when the matcher is first used.
+// Errors loading the section are returned when identifying.
+type lazyMatcher struct {
+	once   sync.Once
+	buf    []byte
+	format int
+	load   func(*persist.LoadSaver) core.Matcher
+	m      core.Matcher
+	err    error
+}
+
+func (l *lazyMatcher) matcher() (core.Matcher, error) {
+	l.once.Do(func() {
+		ls := persist.NewLoadSaver(l.buf)
+		ls.Format = l.format
+		l.m = l.load(ls)
+		if ls.Err != nil {
+			l.err = &sectionError{ls.Err}
+		}
+		l.buf = nil
+	})
+	return l.m, l.err
+}
+
+// sectionError is returned when a section of a signature file can't be loaded.
+type sectionError struct {
+	err error
+}
+
+func (e *sectionError) Error() string {
+	return fmt.Errorf(errOpening, e.err).Error()
+}
+
+func (e *sectionError) Unwrap() error {
+	return e.err
+}
+
+// loaded returns a matcher, loading it first if it is a section that hasn't been used.
+func loaded(m core.Matcher) (core.Matcher, error) {
+	if l, ok := m.(*lazyMatcher); ok {
+		return l.matcher()
+	}
+	return m, nil
+}
+
+func (l *lazyMatcher) Identify(name string, buf *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	return l.IdentifyContext(context.Background(), name, buf, hints...)
+}
+
+func (l *lazyMatcher) IdentifyContext(ctx context.Context, name string, buf *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	m, err := l.matcher()
+	if err != nil || m == nil {
+		ret := make(chan core.Result)
+		close(ret)
+		return ret, err
+	}
+	return identify(ctx, m, name, buf, hints...)
+}
+
+func (l *lazyMatcher) String() string {
+	m, err := l.matcher()
+	if err != nil {
+		return err.Error()
+	}
+	if m == nil {
+		return ""
+	}
+	return m.String()
+}
+
+func (l *lazyMatcher) MarshalJSON() ([]byte, error) {
+	m, err := l.matcher()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
@@ -31,32 +31,51 @@
 //  for _, id := range ids {
 //  	fmt.Println(id)
 //  }
+//
+// Concurrency: a *Siegfried is safe for concurrent use by multiple goroutines. The matchers and identifiers
+// loaded from a signature file are shared and read-only, while each call to Identify gets its own buffer (from
+// an internal pool) and its own recorders. There is no need to load a copy of the signature file per goroutine.
+// Identifiers can be added while identifications are running: Add waits for running identifications to finish.
+// Settings in the config package (e.g. debug logging) are global and should be set before identification starts.
 package siegfried
 
 import (
 	"bytes"
 	"compress/flate"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/richardlehane/siegfried/internal/bytematcher"
 	"github.com/richardlehane/siegfried/internal/containermatcher"
+	"github.com/richardlehane/siegfried/internal/ebmlmatcher"
+	"github.com/richardlehane/siegfried/internal/fontmatcher"
+	"github.com/richardlehane/siegfried/internal/metadata"
 	"github.com/richardlehane/siegfried/internal/mimematcher"
 	"github.com/richardlehane/siegfried/internal/namematcher"
 	"github.com/richardlehane/siegfried/internal/persist"
 	"github.com/richardlehane/siegfried/internal/riffmatcher"
 	"github.com/richardlehane/siegfried/internal/siegreader"
+	"github.com/richardlehane/siegfried/internal/sqlitematcher"
 	"github.com/richardlehane/siegfried/internal/textmatcher"
+	"github.com/richardlehane/siegfried/internal/tiffmatcher"
+	"github.com/richardlehane/siegfried/internal/trailing"
 	"github.com/richardlehane/siegfried/internal/xmlmatcher"
 	"github.com/richardlehane/siegfried/pkg/config"
 	"github.com/richardlehane/siegfried/pkg/core"
 	"github.com/richardlehane/siegfried/pkg/loc"
 	"github.com/richardlehane/siegfried/pkg/mimeinfo"
 	"github.com/richardlehane/siegfried/pkg/pronom"
+	"github.com/richardlehane/siegfried/pkg/text"
 
 	// Load Wikidata into a Siegfried...
 	"github.com/richardlehane/siegfried/pkg/wikidata"
@@ -66,6 +85,7 @@ var ( // for side effect - register their patterns/ signature loaders
 	_ = pronom.Range{}
 	_ = mimeinfo.Int8(0)
 	_ = loc.Identifier{}
+	_ = text.Identifier{}
 
 	// Is this what we want to do here..?
 	_ = wikidata.Identifier{}
@@ -75,6 +95,7 @@ var ( // for side effect - register their patterns/ signature loaders
 // used to identify file formats.
 // They contain three matchers as well as a slice of identifiers. When identifiers
 // are added to a Siegfried struct, they are registered with each matcher.
+// A Siegfried is safe for concurrent use by multiple goroutines.
 type Siegfried struct {
 	// immutable fields
 	C  time.Time    // signature create time
@@ -83,10 +104,18 @@ type Siegfried struct {
 	cm core.Matcher // containermatcher
 	xm core.Matcher // bytematcher
 	rm core.Matcher // riffmatcher
+	em core.Matcher // ebmlmatcher
+	sm core.Matcher // sqlitematcher
+	fm core.Matcher // tiffmatcher
+	om core.Matcher // fontmatcher
 	bm core.Matcher // bytematcher
 	tm core.Matcher // textmatcher
 	// mutatable fields
+	mu      sync.RWMutex      // guards the matchers and identifiers while they are mutated by Add
 	ids     []core.Identifier // identifiers
+	mds     []*metadata.Set   // metadata for each identifier (nil if none)
+	hooks   Hooks             // runtime callbacks, not persisted
+	mtrcs   Metrics           // runtime instrumentation, not persisted
 	buffers *siegreader.Buffers
 }
 
@@ -110,14 +139,274 @@ func New() *Siegfried {
 	}
 }
 
+// Hooks are callbacks fired during identification. They let embedders implement custom logging,
+// progress reporting or early-abort policies. Any hook may be nil.
+// Hooks are called from the goroutine that calls Identify, so they must be safe for concurrent use
+// if a Siegfried is shared between goroutines.
+type Hooks struct {
+	// FileStart is called with the name of a file before it is identified.
+	FileStart func(name string)
+	// Result is called for each result reported by a matcher, before it is given to the identifiers.
+	// Returning an error aborts identification of the file: no identifications are returned and
+	// the error is returned by Identify.
+	Result func(name string, mt core.MatcherType, res core.Result) error
+	// Identified is called with the final identifications for a file and any error, including
+	// when identification fails, is aborted or is cancelled.
+	Identified func(name string, ids []core.Identification, err error)
+}
+
+// SetHooks registers callbacks fired during identification. Hooks aren't persisted when a Siegfried is saved.
+func (s *Siegfried) SetHooks(h Hooks) {
+	s.mu.Lock()
+	s.hooks = h
+	s.mu.Unlock()
+}
+
+// Metrics receives measurements from a Siegfried so that embedders can observe its performance
+// (e.g. by exporting them to Prometheus). Its methods are called from the goroutines that call Identify,
+// so implementations must be safe for concurrent use.
+type Metrics interface {
+	// Identified is called after each identification with the name of the file, the number of bytes read
+	// (for files, their size), the time taken and any error.
+	Identified(name string, bytes int64, d time.Duration, err error)
+	// Hit is called for each result reported by a matcher.
+	Hit(mt core.MatcherType)
+	// Buffer is called when a buffer is taken from the pool (get is true) and when it is returned.
+	Buffer(get bool)
+}
+
+// NopMetrics is the default Metrics: it discards all measurements.
+type NopMetrics struct{}
+
+func (NopMetrics) Identified(string, int64, time.Duration, error) {}
+func (NopMetrics) Hit(core.MatcherType)                           {}
+func (NopMetrics) Buffer(bool)                                    {}
+
+// PoolMetrics is a Metrics that also receives statistics for the buffer pool.
+// Pool is called with the current statistics each time a buffer is returned to the pool.
+type PoolMetrics interface {
+	Metrics
+	Pool(st PoolStats)
+}
+
+// PoolStats report the use of a Siegfried's buffer pool. Use them to tune the PoolSize, StreamSlot, SmallFile and
+// StreamBuffer options: many misses suggest buffers are being dropped by a small PoolSize; many spills suggest
+// raising StreamBuffer.
+type PoolStats struct {
+	Hits   int64 // number of buffers re-used from the pool
+	Misses int64 // number of buffers newly allocated because the pool was empty
+	Spills int64 // number of streams spilled to temp files because they were larger than StreamBuffer
+	Idle   int   // number of buffers currently held in the pool
+	Memory int64 // bytes currently used under the MemoryBudget
+	Waits  int64 // number of times WaitForMemory blocked because the MemoryBudget was used up
+}
+
+// PoolStats reports the use of the buffer pool.
+func (s *Siegfried) PoolStats() PoolStats {
+	return PoolStats(s.buffers.Stats())
+}
+
+// WaitForMemory blocks until memory used for buffers is under the MemoryBudget, or the context is done.
+// It returns immediately if there is no MemoryBudget.
+func (s *Siegfried) WaitForMemory(ctx context.Context) error {
+	return s.buffers.Wait(ctx)
+}
+
+// SetMetrics registers a Metrics to instrument identification, or removes it if m is nil. It may be called while files
+// are being identified.
+// Metrics aren't persisted when a Siegfried is saved.
+func (s *Siegfried) SetMetrics(m Metrics) {
+	s.mu.Lock()
+	s.mtrcs = m
+	s.mu.Unlock()
+}
+
+func (s *Siegfried) metrics() Metrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lockedMetrics()
+}
+
+// lockedMetrics is metrics for callers that already hold s.mu
+func (s *Siegfried) lockedMetrics() Metrics {
+	if s.mtrcs == nil {
+		return NopMetrics{}
+	}
+	return s.mtrcs
+}
+
+// Strategy selects the matchers that are run when identifying a file.
+type Strategy int
+
+const (
+	Full     Strategy = iota // run all matchers (the default)
+	NameOnly                 // match on file name and MIME type only; the contents of files aren't scanned (identifiers like PRONOM may report name matches for formats with byte signatures as possibilities in the warning)
+	Header                   // also match the first bytes of files; EOF signatures and container formats aren't scanned
+)
+
+// DefaultHeaderSize is the number of bytes scanned by the Header strategy if the policy doesn't give a size.
+const DefaultHeaderSize = 65536
+
+// Policy sets the matching strategy for an identification. Attach a policy to the context given to
+// IdentifyContext, IdentifyBufferContext or IdentifyStream with WithPolicy.
+//
+// Example:
+//
+//	ctx := siegfried.WithPolicy(context.Background(), siegfried.Policy{Strategy: siegfried.Header, First: true})
+//	ids, err := s.IdentifyContext(ctx, f, "file.pdf", "")
+type Policy struct {
+	Strategy   Strategy
+	HeaderSize int  // bytes scanned by the Header strategy (default is DefaultHeaderSize)
+	First      bool // skip the remaining matchers once any identifier has a positive identification
+}
+
+func (p Policy) headerSize() int {
+	if p.HeaderSize > 0 {
+		return p.HeaderSize
+	}
+	return DefaultHeaderSize
+}
+
+// first reports whether the policy stops at the first positive identification and any recorder is satisfied
+func (p Policy) first(mt core.MatcherType, recs []core.Recorder) bool {
+	if !p.First {
+		return false
+	}
+	for _, rec := range recs {
+		if ok, _ := rec.Satisfied(mt); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type policyKey struct{}
+
+// WithPolicy returns a copy of ctx that carries a matching policy.
+func WithPolicy(ctx context.Context, p Policy) context.Context {
+	return context.WithValue(ctx, policyKey{}, p)
+}
+
+func policyFrom(ctx context.Context) Policy {
+	p, _ := ctx.Value(policyKey{}).(Policy)
+	return p
+}
+
+// Option tunes the memory used by a Siegfried, and how much of each file it scans, at runtime.
+// Options aren't persisted when a Siegfried is saved.
+type Option func(*siegreader.Limits)
+
+// ScanBOF limits the number of bytes the byte matcher scans from the beginning of a file.
+// Smaller limits are faster but may miss signatures with variable offsets.
+func ScanBOF(n int) Option {
+	return func(l *siegreader.Limits) { l.BOF = n }
+}
+
+// ScanEOF limits the number of bytes the byte matcher scans from the end of a file.
+func ScanEOF(n int) Option {
+	return func(l *siegreader.Limits) { l.EOF = n }
+}
+
+// SmallFile sets the size of files that are read into memory when they aren't memory mapped.
+// Small files are read progressively, as the matchers need more of them. Larger files are read through a fixed-size buffer.
+func SmallFile(n int64) Option {
+	return func(l *siegreader.Limits) { l.SmallFile = n }
+}
+
+// StreamBuffer sets the number of bytes of a stream that are held in memory. The remainder of larger streams is spilled
+// to a temp file.
+func StreamBuffer(n int) Option {
+	return func(l *siegreader.Limits) { l.Stream = n }
+}
+
+// Mmap memory maps only files of at least n bytes. Smaller files are read into memory (see SmallFile) or
+// read through a fixed-size buffer. Memory mapping lets large files be scanned from both ends, by several matchers,
+// without copying them into memory; files that can't be mapped (e.g. on some network file systems) are read instead.
+func Mmap(n int64) Option {
+	return func(l *siegreader.Limits) { l.Mmap = n }
+}
+
+// StreamSlot sets the initial size of the memory allocated for each stream buffer. Slots grow, up to the StreamBuffer
+// limit, as needed: a larger slot avoids regrowing for workloads of many mid-sized streams.
+func StreamSlot(n int) Option {
+	return func(l *siegreader.Limits) { l.StreamSlot = n }
+}
+
+// MemoryBudget limits the memory used by concurrent identifications for buffers that grow with the size of files.
+// When the budget is used up, streams spill to temp files and small files are read through a fixed-size buffer rather
+// than in full. IdentifyStream also waits for memory before starting to identify each file; other callers that
+// identify files concurrently can do the same with WaitForMemory.
+// Use it in containers with small memory limits. Memory mapped files and fixed-size buffers aren't counted.
+func MemoryBudget(n int64) Option {
+	return func(l *siegreader.Limits) { l.Memory = n }
+}
+
+// PoolSize limits the number of idle buffers of each type that are kept for re-use. Without a limit,
+// the pool keeps as many buffers as have been in use at once.
+func PoolSize(n int) Option {
+	return func(l *siegreader.Limits) { l.PoolSize = n }
+}
+
+// NoMmap stops files from being memory mapped.
+func NoMmap() Option {
+	return func(l *siegreader.Limits) { l.NoMmap = true }
+}
+
+// Configure applies runtime options. It should be called before identification starts.
+//
+// Example:
+//
+//	s, err := siegfried.Load("default.sig")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	s.Configure(siegfried.ScanBOF(65536), siegfried.StreamBuffer(1<<20))
+func (s *Siegfried) Configure(opts ...Option) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.buffers.Limits()
+	for _, o := range opts {
+		o(&l)
+	}
+	s.buffers.SetLimits(l)
+}
+
 // Add adds an identifier to a Siegfried struct.
 func (s *Siegfried) Add(i core.Identifier) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, v := range s.ids {
 		if v.Name() == i.Name() {
 			return fmt.Errorf("siegfried: identifiers must have unique names, you already have an identifier named %s. Use the -name flag to assign a new name e.g. `roy add -name richard`", i.Name())
 		}
 	}
+	var md *metadata.Set
 	var err error
+	if config.Metadata() != "" {
+		if md, err = metadata.Open(config.Metadata()); err != nil {
+			return err
+		}
+	}
+	if c, ok := i.(core.Classifier); ok && config.Class() {
+		if md, err = metadata.Add(md, "class", c.Classes()); err != nil {
+			return err
+		}
+	}
+	if md != nil {
+		for _, f := range md.Fields() {
+			for _, g := range i.Fields() {
+				if f == g {
+					return fmt.Errorf("siegfried: metadata field %s clashes with a field of the %s identifier", f, i.Name())
+				}
+			}
+		}
+	}
+	// sections of a loaded signature file must be loaded before they can be added to
+	for _, m := range []*core.Matcher{&s.cm, &s.xm, &s.rm, &s.em, &s.sm, &s.fm, &s.om, &s.bm, &s.tm} {
+		if *m, err = loaded(*m); err != nil {
+			return err
+		}
+	}
 	if s.nm, err = i.Add(s.nm, core.NameMatcher); err != nil {
 		return err
 	}
@@ -133,6 +422,18 @@ func (s *Siegfried) Add(i core.Identifier) error {
 	if s.rm, err = i.Add(s.rm, core.RIFFMatcher); err != nil {
 		return err
 	}
+	if s.em, err = i.Add(s.em, core.EBMLMatcher); err != nil {
+		return err
+	}
+	if s.sm, err = i.Add(s.sm, core.SQLiteMatcher); err != nil {
+		return err
+	}
+	if s.fm, err = i.Add(s.fm, core.TIFFMatcher); err != nil {
+		return err
+	}
+	if s.om, err = i.Add(s.om, core.FontMatcher); err != nil {
+		return err
+	}
 	if s.bm, err = i.Add(s.bm, core.ByteMatcher); err != nil {
 		return err
 	}
@@ -140,9 +441,19 @@ func (s *Siegfried) Add(i core.Identifier) error {
 		return err
 	}
 	s.ids = append(s.ids, i)
+	s.mds = append(s.mds, md)
 	return nil
 }
 
+// Errors returned when loading signature files and identifying files. Other errors are wrapped so that
+// these can be tested for with errors.Is.
+var (
+	ErrNotSignature          = errors.New("siegfried: not a siegfried signature file; try running `sf -update`")
+	ErrIncompatibleSignature = errors.New("siegfried: signature file is incompatible with this version of sf; try running `sf -update`")
+)
+
+const errOpening = "siegfried: error opening signature file, got %w; try running `sf -update`"
+
 // Save persists a Siegfried struct to disk (path)
 func (s *Siegfried) Save(path string) error {
 	f, err := os.Create(path)
@@ -150,8 +461,7 @@ func (s *Siegfried) Save(path string) error {
 		return err
 	}
 	defer f.Close()
-	_, err = f.Write(append(config.Magic(), byte(config.Version()[0]), byte(config.Version()[1])))
-	if err != nil {
+	if err = writeHeader(f); err != nil {
 		return err
 	}
 	z, err := flate.NewWriter(f, 1)
@@ -165,19 +475,46 @@ func (s *Siegfried) Save(path string) error {
 
 // SaveWriter persists a Siegfried struct to an io.Writer
 func (s *Siegfried) SaveWriter(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	ls := persist.NewLoadSaver(nil)
 	ls.SaveTime(s.C)
 	namematcher.Save(s.nm, ls)
 	mimematcher.Save(s.mm, ls)
-	containermatcher.Save(s.cm, ls)
-	xmlmatcher.Save(s.xm, ls)
-	riffmatcher.Save(s.rm, ls)
-	bytematcher.Save(s.bm, ls)
-	textmatcher.Save(s.tm, ls)
+	for _, sec := range []struct {
+		m    core.Matcher
+		save func(core.Matcher, *persist.LoadSaver)
+	}{
+		{s.cm, containermatcher.Save},
+		{s.xm, xmlmatcher.Save},
+		{s.rm, riffmatcher.Save},
+		{s.em, ebmlmatcher.Save},
+		{s.sm, sqlitematcher.Save},
+		{s.fm, tiffmatcher.Save},
+		{s.om, fontmatcher.Save},
+		{s.bm, bytematcher.Save},
+		{s.tm, textmatcher.Save},
+	} {
+		m, err := loaded(sec.m)
+		if err != nil {
+			return err
+		}
+		saveSection(ls, m, sec.save)
+	}
 	ls.SaveTinyUInt(len(s.ids))
 	for _, i := range s.ids {
 		i.Save(ls)
 	}
+	// metadata is an optional section at the end of the file, so that older signature files still load
+	if s.hasMetadata() {
+		for i := range s.ids {
+			md := s.metadata(i)
+			ls.SaveBool(md != nil)
+			if md != nil {
+				md.Save(ls)
+			}
+		}
+	}
 	if ls.Err != nil {
 		return ls.Err
 	}
@@ -185,32 +522,101 @@ func (s *Siegfried) SaveWriter(w io.Writer) error {
 	return err
 }
 
+// MarshalJSON gives a complete representation of a Siegfried struct (its matchers and identifiers) as JSON.
+// It is used by the `roy export` command to allow signature files to be diffed and audited.
+func (s *Siegfried) MarshalJSON() ([]byte, error) {
+	v := config.Version()
+	return json.Marshal(struct {
+		Version          string            `json:"version"`
+		Created          time.Time         `json:"created"`
+		NameMatcher      core.Matcher      `json:"namematcher"`
+		MIMEMatcher      core.Matcher      `json:"mimematcher"`
+		ContainerMatcher core.Matcher      `json:"containermatcher"`
+		XMLMatcher       core.Matcher      `json:"xmlmatcher"`
+		RIFFMatcher      core.Matcher      `json:"riffmatcher"`
+		EBMLMatcher      core.Matcher      `json:"ebmlmatcher"`
+		SQLiteMatcher    core.Matcher      `json:"sqlitematcher"`
+		TIFFMatcher      core.Matcher      `json:"tiffmatcher"`
+		FontMatcher      core.Matcher      `json:"fontmatcher"`
+		ByteMatcher      core.Matcher      `json:"bytematcher"`
+		TextMatcher      core.Matcher      `json:"textmatcher"`
+		Identifiers      []core.Identifier `json:"identifiers"`
+		Metadata         []*metadata.Set   `json:"metadata"`
+	}{
+		Version:          fmt.Sprintf("%d.%d", v[0], v[1]),
+		Created:          s.C,
+		NameMatcher:      s.nm,
+		MIMEMatcher:      s.mm,
+		ContainerMatcher: s.cm,
+		XMLMatcher:       s.xm,
+		RIFFMatcher:      s.rm,
+		EBMLMatcher:      s.em,
+		SQLiteMatcher:    s.sm,
+		TIFFMatcher:      s.fm,
+		FontMatcher:      s.om,
+		ByteMatcher:      s.bm,
+		TextMatcher:      s.tm,
+		Identifiers:      s.ids,
+		Metadata:         s.mds,
+	})
+}
+
 // Load creates a Siegfried struct and loads content from path
 func Load(path string) (*Siegfried, error) {
-	errOpening := "siegfried: error opening signature file, got %v; try running `sf -update`"
-	errNotSig := "siegfried: not a siegfried signature file; try running `sf -update`"
-	errUpdateSig := "siegfried: signature file is incompatible with this version of sf; try running `sf -update`"
 	fbuf, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf(errOpening, err)
 	}
-	if len(fbuf) < len(config.Magic())+2 {
-		return nil, fmt.Errorf(errNotSig)
+	return LoadBytes(fbuf)
+}
+
+// LoadFS creates a Siegfried struct and loads content from the named signature file in fsys.
+// Use it with an embed.FS to ship a signature file inside a binary:
+//
+//	//go:embed default.sig
+//	var sigs embed.FS
+//
+//	s, err := siegfried.LoadFS(sigs, "default.sig")
+func LoadFS(fsys fs.FS, name string) (*Siegfried, error) {
+	fbuf, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf(errOpening, err)
+	}
+	return LoadBytes(fbuf)
+}
+
+// LoadFrom creates a Siegfried struct and loads content from a reader that supplies a signature file
+// (for example, a signature file fetched over the network).
+// Unlike LoadReader, it expects the file format written by Save rather than the raw content written by SaveWriter.
+func LoadFrom(r io.Reader) (*Siegfried, error) {
+	fbuf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf(errOpening, err)
 	}
-	if string(fbuf[:len(config.Magic())]) != string(config.Magic()) {
-		return nil, fmt.Errorf(errNotSig)
+	return LoadBytes(fbuf)
+}
+
+// LoadBytes creates a Siegfried struct and loads content from the bytes of a signature file.
+// Signature files in the current and previous formats can be loaded (see SignatureFormat);
+// an IncompatibleError is returned for signature files that need a different version of siegfried.
+// The content matchers of current format files are loaded when they are first used, so errors in their
+// content are returned by the first identification that needs them.
+func LoadBytes(fbuf []byte) (*Siegfried, error) {
+	h, err := ReadSignatureHeader(fbuf)
+	if err != nil {
+		return nil, err
 	}
-	if major, minor := fbuf[len(config.Magic())], fbuf[len(config.Magic())+1]; major < byte(config.Version()[0]) || (major == byte(config.Version()[0]) && minor < byte(config.Version()[1])) {
-		return nil, fmt.Errorf(errUpdateSig)
+	if err = h.Check(); err != nil {
+		return nil, err
 	}
-	r := bytes.NewBuffer(fbuf[len(config.Magic())+2:])
+	r := bytes.NewBuffer(fbuf[h.Size:])
 	rc := flate.NewReader(r)
 	buf, err := ioutil.ReadAll(rc)
 	rc.Close()
 	if err != nil {
 		return nil, fmt.Errorf(errOpening, err)
 	}
-	return load(buf)
+	return load(buf, h.Format)
 }
 
 // LoadReader creates a Siegfried struct and loads content from a reader
@@ -219,20 +625,51 @@ func LoadReader(r io.Reader) (*Siegfried, error) {
 	if err != nil {
 		return nil, err
 	}
-	return load(buf)
+	return load(buf, SignatureFormat)
 }
 
-func load(buf []byte) (*Siegfried, error) {
+func load(buf []byte, format int) (*Siegfried, error) {
 	ls := persist.NewLoadSaver(buf)
-	return &Siegfried{
+	ls.Format = format
+	matcher := func(load func(*persist.LoadSaver) core.Matcher) core.Matcher {
+		if ls.Has(persist.FormatSections) {
+			return loadSection(ls, load)
+		}
+		return load(ls)
+	}
+	s := &Siegfried{
 		C:  ls.LoadTime(),
 		nm: namematcher.Load(ls),
 		mm: mimematcher.Load(ls),
-		cm: containermatcher.Load(ls),
-		xm: xmlmatcher.Load(ls),
-		rm: riffmatcher.Load(ls),
-		bm: bytematcher.Load(ls),
-		tm: textmatcher.Load(ls),
+		cm: matcher(containermatcher.Load),
+		xm: matcher(xmlmatcher.Load),
+		rm: matcher(riffmatcher.Load),
+		em: func() core.Matcher {
+			if ls.Has(persist.FormatEBML) {
+				return matcher(ebmlmatcher.Load)
+			}
+			return nil
+		}(),
+		sm: func() core.Matcher {
+			if ls.Has(persist.FormatSQLite) {
+				return matcher(sqlitematcher.Load)
+			}
+			return nil
+		}(),
+		fm: func() core.Matcher {
+			if ls.Has(persist.FormatTIFF) {
+				return matcher(tiffmatcher.Load)
+			}
+			return nil
+		}(),
+		om: func() core.Matcher {
+			if ls.Has(persist.FormatFont) {
+				return matcher(fontmatcher.Load)
+			}
+			return nil
+		}(),
+		bm: matcher(bytematcher.Load),
+		tm: matcher(textmatcher.Load),
 		ids: func() []core.Identifier {
 			ids := make([]core.Identifier, ls.LoadTinyUInt())
 			for i := range ids {
@@ -241,11 +678,22 @@ func load(buf []byte) (*Siegfried, error) {
 			return ids
 		}(),
 		buffers: siegreader.New(),
-	}, ls.Err
+	}
+	s.mds = make([]*metadata.Set, len(s.ids))
+	if ls.More() {
+		for i := range s.mds {
+			if ls.LoadBool() {
+				s.mds[i] = metadata.Load(ls)
+			}
+		}
+	}
+	return s, ls.Err
 }
 
 // Identifiers returns a slice of the names and details of each identifier.
 func (s *Siegfried) Identifiers() [][2]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	ret := make([][2]string, len(s.ids))
 	for i, v := range s.ids {
 		ret[i][0] = v.Name()
@@ -256,16 +704,209 @@ func (s *Siegfried) Identifiers() [][2]string {
 
 // Fields returns a slice of the names of the fields in each identifier.
 func (s *Siegfried) Fields() [][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	ret := make([][]string, len(s.ids))
 	for i, v := range s.ids {
 		ret[i] = v.Fields()
+		if md := s.metadata(i); md != nil {
+			ret[i] = append(append([]string{}, ret[i]...), md.Fields()...)
+		}
+	}
+	return ret
+}
+
+// Formats returns a sorted list of the IDs of the formats (e.g. PRONOM PUIDs) with signatures in any identifier.
+func (s *Siegfried) Formats() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	uniq := make(map[string]bool)
+	for _, v := range s.ids {
+		ider, ok := v.(interface{ IDs(core.MatcherType) []string })
+		if !ok {
+			continue
+		}
+		for m := core.NameMatcher; m <= core.FontMatcher; m++ {
+			for _, id := range ider.IDs(m) {
+				uniq[id] = true
+			}
+		}
+	}
+	ret := make([]string, 0, len(uniq))
+	for id := range uniq {
+		ret = append(ret, id)
 	}
+	sort.Strings(ret)
 	return ret
 }
 
+// enriched identifications have extra metadata values appended
+type enriched struct {
+	core.Identification
+	md []string
+}
+
+func (e enriched) Values() []string {
+	return append(e.Identification.Values(), e.md...)
+}
+
+// metadata returns the metadata set for the identifier at index idx, or nil if it has none
+func (s *Siegfried) metadata(idx int) *metadata.Set {
+	if idx < len(s.mds) {
+		return s.mds[idx]
+	}
+	return nil
+}
+
+func (s *Siegfried) hasMetadata() bool {
+	for _, md := range s.mds {
+		if md != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// warned identifications have an extra warning e.g. of trailing data
+type warned struct {
+	core.Identification
+	idx  int // index of the warning field in Values(), or -1
+	warn string
+}
+
+func (w warned) Warn() string {
+	if prev := w.Identification.Warn(); prev != "" {
+		return prev + "; " + w.warn
+	}
+	return w.warn
+}
+
+func (w warned) Values() []string {
+	vals := w.Identification.Values()
+	if w.idx < 0 || w.idx >= len(vals) {
+		return vals
+	}
+	vals = append([]string{}, vals...)
+	vals[w.idx] = w.Warn()
+	return vals
+}
+
+// warn adds a warning to the positive identifications of the identifier at index idx
+func (s *Siegfried) warn(idx int, warning string, ids []core.Identification) []core.Identification {
+	if warning == "" {
+		return ids
+	}
+	wi := s.field(idx, "warning")
+	for i, id := range ids {
+		if id.Known() {
+			ids[i] = warned{id, wi, warning}
+		}
+	}
+	return ids
+}
+
+// objects describes the macros and embedded objects of an OLE2 or OOXML file, as a warning
+func objects(b *siegreader.Buffer) string {
+	macros, n := containermatcher.Objects(b)
+	strs := make([]string, 0, len(macros)+1)
+	for _, m := range macros {
+		strs = append(strs, m+" macros")
+	}
+	switch {
+	case n == 1:
+		strs = append(strs, "1 embedded object")
+	case n > 1:
+		strs = append(strs, fmt.Sprintf("%d embedded objects", n))
+	}
+	if len(strs) == 0 {
+		return ""
+	}
+	return "macros/embedded objects: " + strings.Join(strs, ", ")
+}
+
+// emptied identifications report a zero-byte file as EMPTY, rather than as UNKNOWN or a match on extension
+type emptied struct {
+	core.Identification
+	vals []string
+}
+
+func (e emptied) String() string          { return "EMPTY" }
+func (e emptied) Known() bool             { return true }
+func (e emptied) Warn() string            { return "" }
+func (e emptied) Values() []string        { return e.vals }
+func (e emptied) Archive() config.Archive { return config.None }
+
+// empty replaces the identifications of the identifier at index idx with a single EMPTY identification
+func (s *Siegfried) empty(idx int, ids []core.Identification) []core.Identification {
+	if len(ids) == 0 {
+		return ids
+	}
+	orig := ids[0].Values()
+	vals := make([]string, len(orig))
+	for i, f := range s.ids[idx].Fields() {
+		if i >= len(vals) {
+			break
+		}
+		switch f {
+		case "namespace":
+			vals[i] = orig[i]
+		case "id":
+			vals[i] = "EMPTY"
+		case "basis":
+			vals[i] = "empty file"
+		}
+	}
+	return []core.Identification{emptied{ids[0], vals}}
+}
+
+// enrich attaches any metadata for the identifier at index idx to its identifications, and fills in the MIME types
+// given by the metadata for formats the identifier has none for
+func (s *Siegfried) enrich(idx int, ids []core.Identification) []core.Identification {
+	md := s.metadata(idx)
+	if md == nil {
+		return ids
+	}
+	mi := s.field(idx, "mime")
+	for i, id := range ids {
+		// the second value is always the format ID (after the namespace)
+		vals := id.Values()
+		if mi >= 0 && vals[mi] == "" {
+			if m := md.MIME(vals[1]); m != "" {
+				id = valued{id, mi, m}
+			}
+		}
+		ids[i] = enriched{id, md.Values(vals[1])}
+	}
+	return ids
+}
+
+// valued identifications have a value (e.g. a MIME type from a metadata set) that their identifier left empty
+type valued struct {
+	core.Identification
+	idx int
+	val string
+}
+
+func (v valued) Values() []string {
+	vals := append([]string{}, v.Identification.Values()...)
+	vals[v.idx] = v.val
+	return vals
+}
+
+// field returns the index of a named field of the identifier at index idx, or -1
+func (s *Siegfried) field(idx int, name string) int {
+	for i, f := range s.ids[idx].Fields() {
+		if f == name {
+			return i
+		}
+	}
+	return -1
+}
+
 // Buffer gets a siegreader buffer from the pool
 func (s *Siegfried) Buffer(r io.Reader) (*siegreader.Buffer, error) {
 	buffer, err := s.buffers.Get(r)
+	s.metrics().Buffer(true)
 	if err == io.EOF {
 		err = nil
 	}
@@ -274,7 +915,12 @@ func (s *Siegfried) Buffer(r io.Reader) (*siegreader.Buffer, error) {
 
 // Put returns a siegreader buffer to the pool
 func (s *Siegfried) Put(buffer *siegreader.Buffer) {
+	m := s.metrics()
+	m.Buffer(false)
 	s.buffers.Put(buffer)
+	if pm, ok := m.(PoolMetrics); ok {
+		pm.Pool(s.PoolStats())
+	}
 }
 
 func satisfied(mt core.MatcherType, recs []core.Recorder) (bool, []core.Hint) {
@@ -304,19 +950,72 @@ func satisfied(mt core.MatcherType, recs []core.Recorder) (bool, []core.Hint) {
 
 // IdentifyBuffer identifies a siegreader buffer. Supply the error from Get as the second argument.
 func (s *Siegfried) IdentifyBuffer(buffer *siegreader.Buffer, err error, name, mime string) ([]core.Identification, error) {
+	return s.IdentifyBufferContext(context.Background(), buffer, err, name, mime)
+}
+
+// identify uses a matcher's IdentifyContext method, if it has one
+func identify(ctx context.Context, m core.Matcher, name string, buffer *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	if cm, ok := m.(core.ContextMatcher); ok {
+		return cm.IdentifyContext(ctx, name, buffer, hints...)
+	}
+	return m.Identify(name, buffer, hints...)
+}
+
+// IdentifyBufferContext is like IdentifyBuffer but stops when the context is cancelled or its deadline passes.
+// In that case no identifications are returned and the error is the context's error.
+func (s *Siegfried) IdentifyBufferContext(ctx context.Context, buffer *siegreader.Buffer, err error, name, mime string) ([]core.Identification, error) {
+	start := time.Now()
+	ids, err := s.recoverIdentify(ctx, buffer, err, name, mime)
+	var sz int64
+	if buffer != nil {
+		sz = buffer.Buffered()
+	}
+	s.metrics().Identified(name, sz, time.Since(start), err)
+	s.mu.RLock()
+	identified := s.hooks.Identified
+	s.mu.RUnlock()
+	if identified != nil {
+		identified(name, ids, err)
+	}
+	return ids, err
+}
+
+// recoverIdentify recovers from a panic while identifying a file (e.g. one triggered by a pathological file), so that
+// the panic is reported as an error for that file rather than ending a scan.
+func (s *Siegfried) recoverIdentify(ctx context.Context, buffer *siegreader.Buffer, err error, name, mime string) (ids []core.Identification, ierr error) {
+	defer func() {
+		if v := recover(); v != nil {
+			ids, ierr = nil, core.ClassifyError(core.ClassPanic, fmt.Errorf("siegfried: identification of %s panicked: %v", name, v))
+		}
+	}()
+	return s.identifyBuffer(ctx, buffer, err, name, mime)
+}
+
+func (s *Siegfried) identifyBuffer(ctx context.Context, buffer *siegreader.Buffer, err error, name, mime string) ([]core.Identification, error) {
 	if err != nil && err != siegreader.ErrEmpty {
-		return nil, fmt.Errorf("siegfried: error reading file; got %v", err)
+		return nil, fmt.Errorf("siegfried: error reading file; got %w", err)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p := policyFrom(ctx)
+	scan := p.Strategy != NameOnly
+	if p.Strategy == Header && buffer != nil {
+		bof, _ := buffer.ScanLimits()
+		if n := p.headerSize(); bof <= 0 || bof > n {
+			bof = n
+		}
+		buffer.SetScanLimits(bof, -1)
 	}
 	recs := make([]core.Recorder, len(s.ids))
 	for i, v := range s.ids {
 		recs[i] = v.Recorder()
-		if name != "" {
+		if name != "" && !config.SkipName() {
 			recs[i].Active(core.NameMatcher)
 		}
 		if mime != "" {
 			recs[i].Active(core.MIMEMatcher)
 		}
-		if err == nil {
+		if err == nil && scan {
 			recs[i].Active(core.XMLMatcher)
 			recs[i].Active(core.TextMatcher)
 		}
@@ -325,113 +1024,250 @@ func (s *Siegfried) IdentifyBuffer(buffer *siegreader.Buffer, err error, name, m
 	if config.Debug() || config.Slow() {
 		fmt.Fprintf(config.Out(), "[FILE] %s\n", name)
 	}
-	// Name Matcher
-	if len(name) > 0 && s.nm != nil {
-		nms, _ := s.nm.Identify(name, nil) // we don't care about an error here
-		for v := range nms {
+	hooks, m := s.hooks, s.lockedMetrics()
+	if hooks.FileStart != nil {
+		hooks.FileStart(name)
+	}
+	// a Result hook can abort identification by returning an error: cancel the context so the byte matcher stops scanning
+	var herr error
+	// a matcher that fails part way through a scan (see core.FailedResult) reports an error for the file
+	var ferr error
+	cancel := func() {}
+	if hooks.Result != nil {
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+	}
+	record := func(mt core.MatcherType, results chan core.Result) {
+		// if recording panics, let the matcher finish with the buffer before the panic is recovered
+		defer func() {
+			if v := recover(); v != nil {
+				for range results {
+				}
+				panic(v)
+			}
+		}()
+		for v := range results {
+			if f, ok := v.(core.FailedResult); ok {
+				if ferr == nil {
+					ferr = f.Err()
+				}
+				continue
+			}
+			if herr != nil {
+				continue // drain remaining results
+			}
+			if hooks.Result != nil {
+				if herr = hooks.Result(name, mt, v); herr != nil {
+					cancel()
+					continue
+				}
+			}
+			m.Hit(mt)
 			for _, rec := range recs {
-				if rec.Record(core.NameMatcher, v) {
+				if rec.Record(mt, v) {
 					break
 				}
 			}
 		}
 	}
+	stopped := func() error {
+		if herr != nil {
+			return herr
+		}
+		return ctx.Err()
+	}
+	// Name Matcher
+	if len(name) > 0 && s.nm != nil && !config.SkipName() {
+		nms, _ := s.nm.Identify(name, nil) // we don't care about an error here
+		record(core.NameMatcher, nms)
+	}
 	// MIME Matcher
 	if len(mime) > 0 && s.mm != nil {
 		mms, _ := s.mm.Identify(mime, nil) // we don't care about an error here
-		for v := range mms {
-			for _, rec := range recs {
-				if rec.Record(core.MIMEMatcher, v) {
-					break
-				}
-			}
-		}
+		record(core.MIMEMatcher, mms)
 	}
 	// Container Matcher
 	_, hints := satisfied(core.ContainerMatcher, recs)
-	if s.cm != nil {
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
+	if s.cm != nil && p.Strategy == Full && !config.SkipContainer() {
 		if config.Debug() {
 			fmt.Fprintln(config.Out(), ">>START CONTAINER MATCHER")
 		}
-		cms, cerr := s.cm.Identify(name, buffer, hints...)
-		for v := range cms {
-			for _, rec := range recs {
-				if rec.Record(core.ContainerMatcher, v) {
-					break
-				}
-			}
-		}
+		cms, cerr := identify(ctx, s.cm, name, buffer, hints...)
+		record(core.ContainerMatcher, cms)
 		if err == nil {
 			err = cerr
 		}
 	}
 	sat, _ := satisfied(core.XMLMatcher, recs)
+	sat = sat || !scan || p.first(core.XMLMatcher, recs)
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
 	// XML Matcher
 	if s.xm != nil && !sat {
 		if config.Debug() {
 			fmt.Fprintln(config.Out(), ">>START XML MATCHER")
 		}
-		xms, xerr := s.xm.Identify("", buffer)
-		for v := range xms {
-			for _, rec := range recs {
-				if rec.Record(core.XMLMatcher, v) {
-					break
-				}
-			}
-		}
+		xms, xerr := identify(ctx, s.xm, "", buffer)
+		record(core.XMLMatcher, xms)
 		if err == nil {
 			err = xerr
 		}
 	}
 	sat, _ = satisfied(core.RIFFMatcher, recs)
+	sat = sat || !scan || p.first(core.RIFFMatcher, recs)
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
 	// RIFF Matcher
 	if s.rm != nil && !sat {
 		if config.Debug() {
 			fmt.Fprintln(config.Out(), ">>START RIFF MATCHER")
 		}
-		rms, rerr := s.rm.Identify("", buffer)
-		for v := range rms {
-			for _, rec := range recs {
-				if rec.Record(core.RIFFMatcher, v) {
-					break
-				}
-			}
-		}
+		rms, rerr := identify(ctx, s.rm, "", buffer)
+		record(core.RIFFMatcher, rms)
 		if err == nil {
 			err = rerr
 		}
 	}
+	sat, _ = satisfied(core.EBMLMatcher, recs)
+	sat = sat || !scan || p.first(core.EBMLMatcher, recs)
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
+	// EBML Matcher
+	if s.em != nil && !sat {
+		if config.Debug() {
+			fmt.Fprintln(config.Out(), ">>START EBML MATCHER")
+		}
+		ems, eerr := identify(ctx, s.em, "", buffer)
+		record(core.EBMLMatcher, ems)
+		if err == nil {
+			err = eerr
+		}
+	}
+	sat, _ = satisfied(core.SQLiteMatcher, recs)
+	sat = sat || !scan || p.first(core.SQLiteMatcher, recs)
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
+	// SQLite Matcher
+	if s.sm != nil && !sat {
+		if config.Debug() {
+			fmt.Fprintln(config.Out(), ">>START SQLITE MATCHER")
+		}
+		sms, serr := identify(ctx, s.sm, "", buffer)
+		record(core.SQLiteMatcher, sms)
+		if err == nil {
+			err = serr
+		}
+	}
 	sat, hints = satisfied(core.ByteMatcher, recs)
+	sat = sat || !scan || p.first(core.ByteMatcher, recs)
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
 	// Byte Matcher
-	if s.bm != nil && !sat {
+	if s.bm != nil && !sat && !config.SkipByte() {
 		if config.Debug() {
 			fmt.Fprintln(config.Out(), ">>START BYTE MATCHER")
 		}
-		ids, _ := s.bm.Identify("", buffer, hints...) // we don't care about an error here
-		for v := range ids {
-			for _, rec := range recs {
-				if rec.Record(core.ByteMatcher, v) {
-					break
-				}
-			}
+		ids, berr := identify(ctx, s.bm, "", buffer, hints...) // we don't care about an error here, unless the matcher couldn't be loaded
+		record(core.ByteMatcher, ids)
+		if err == nil && errors.As(berr, new(*sectionError)) {
+			err = berr
+		}
+	}
+	sat, _ = satisfied(core.TIFFMatcher, recs)
+	sat = sat || !scan || p.first(core.TIFFMatcher, recs)
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
+	// TIFF Matcher (after the byte matcher, as it refines its matches)
+	if s.fm != nil && !sat {
+		if config.Debug() {
+			fmt.Fprintln(config.Out(), ">>START TIFF MATCHER")
+		}
+		fms, fmerr := identify(ctx, s.fm, "", buffer)
+		record(core.TIFFMatcher, fms)
+		if err == nil {
+			err = fmerr
+		}
+	}
+	sat, _ = satisfied(core.FontMatcher, recs)
+	sat = sat || !scan || p.first(core.FontMatcher, recs)
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
+	// Font Matcher (likewise after the byte matcher)
+	if s.om != nil && !sat {
+		if config.Debug() {
+			fmt.Fprintln(config.Out(), ">>START FONT MATCHER")
+		}
+		oms, omerr := identify(ctx, s.om, "", buffer)
+		record(core.FontMatcher, oms)
+		if err == nil {
+			err = omerr
 		}
 	}
 	sat, _ = satisfied(core.TextMatcher, recs)
+	sat = sat || !scan || p.first(core.TextMatcher, recs)
+	// check again, as a cancelled byte matcher may close its results early
+	if serr := stopped(); serr != nil {
+		return nil, serr
+	}
 	// Text Matcher
 	if s.tm != nil && !sat {
-		ids, _ := s.tm.Identify("", buffer) // we don't care about an error here
-		for v := range ids {
-			for _, rec := range recs {
-				if rec.Record(core.TextMatcher, v) {
-					break
-				}
+		ids, terr := identify(ctx, s.tm, "", buffer) // we don't care about an error here, unless the matcher couldn't be loaded
+		record(core.TextMatcher, ids)
+		if err == nil && errors.As(terr, new(*sectionError)) {
+			err = terr
+		}
+	}
+	if herr != nil {
+		return nil, herr
+	}
+	if err == nil {
+		err = ferr
+	}
+	var warnings []string
+	if config.Trailing() && buffer != nil && err == nil && scan {
+		if n, f := trailing.Check(buffer); n > 0 {
+			warnings = append(warnings, fmt.Sprintf("polyglot/trailing data: %d bytes after the end of the %s", n, f))
+		}
+	}
+	if config.Macros() && buffer != nil && err == nil && scan {
+		if w := objects(buffer); w != "" {
+			warnings = append(warnings, w)
+		}
+	}
+	warning := strings.Join(warnings, "; ")
+	// zero-byte files are EMPTY, if set, and tiny files may be identified by extension alone
+	empty := err == siegreader.ErrEmpty && config.Empty()
+	if empty {
+		err = nil
+	}
+	if buffer != nil && config.Tiny() > 0 {
+		sz := buffer.SizeNow()
+		for _, rec := range recs {
+			if sr, ok := rec.(core.SizeRecorder); ok {
+				sr.Size(sz)
 			}
 		}
 	}
-	if len(recs) < 2 {
-		return recs[0].Report(), err
+	report := func(idx int, rec core.Recorder) []core.Identification {
+		if empty {
+			return s.enrich(idx, s.empty(idx, rec.Report()))
+		}
+		return s.enrich(idx, s.warn(idx, warning, rec.Report()))
 	}
 	var res []core.Identification
+	if len(recs) < 2 {
+		return report(0, recs[0]), err
+	}
 	for idx, rec := range recs {
 		if config.Slow() || config.Debug() {
 			for _, id := range rec.Report() {
@@ -439,10 +1275,10 @@ func (s *Siegfried) IdentifyBuffer(buffer *siegreader.Buffer, err error, name, m
 			}
 		}
 		if idx == 0 {
-			res = rec.Report()
+			res = report(idx, rec)
 			continue
 		}
-		res = append(res, rec.Report()...)
+		res = append(res, report(idx, rec)...)
 	}
 	return res, err
 }
@@ -451,11 +1287,54 @@ func (s *Siegfried) IdentifyBuffer(buffer *siegreader.Buffer, err error, name, m
 // It takes an io.Reader and the name and mimetype of the file/stream (if unknown, give empty strings).
 // It returns a slice of identifications and an error.
 func (s *Siegfried) Identify(r io.Reader, name, mime string) ([]core.Identification, error) {
+	return s.IdentifyContext(context.Background(), r, name, mime)
+}
+
+// IdentifyContext is like Identify but takes a context.Context. Identification stops if the context is cancelled
+// or its deadline passes e.g. when scanning very large files or slow network streams.
+// In that case no identifications are returned and the error is the context's error.
+func (s *Siegfried) IdentifyContext(ctx context.Context, r io.Reader, name, mime string) ([]core.Identification, error) {
 	buffer, err := s.Buffer(r)
-	defer s.buffers.Put(buffer)
+	defer s.Put(buffer)
+	return s.IdentifyBufferContext(ctx, buffer, err, name, mime)
+}
+
+// IdentifyReaderAt identifies a source that can be read at any offset, such as an in-memory *bytes.Reader or
+// a section of a larger file. It takes the size of the source, and the name and mimetype of the file/stream
+// (if unknown, give empty strings). Because the source is read directly, EOF signatures can be tested
+// without first buffering the whole source.
+func (s *Siegfried) IdentifyReaderAt(r io.ReaderAt, size int64, name, mime string) ([]core.Identification, error) {
+	buffer, err := s.buffers.GetReaderAt(r, size)
+	s.metrics().Buffer(true)
+	if err == io.EOF {
+		err = nil
+	}
+	defer s.Put(buffer)
 	return s.IdentifyBuffer(buffer, err, name, mime)
 }
 
+// IdentifyFile identifies an fs.File e.g. a file opened from an embed.FS or a zip.Reader.
+// Regular files that implement io.ReaderAt are identified with IdentifyReaderAt, others are read as streams.
+func (s *Siegfried) IdentifyFile(f fs.File, name, mime string) ([]core.Identification, error) {
+	if ra, ok := f.(io.ReaderAt); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			return s.IdentifyReaderAt(ra, info.Size(), name, mime)
+		}
+	}
+	return s.Identify(f, name, mime)
+}
+
+// IdentifyFS opens and identifies the named file within a file system.
+// The mimetype of the file can be given too (if unknown, give an empty string).
+func (s *Siegfried) IdentifyFS(fsys fs.FS, name, mime string) ([]core.Identification, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("siegfried: error opening file; got %w", err)
+	}
+	defer f.Close()
+	return s.IdentifyFile(f, name, mime)
+}
+
 // Label takes the values of a core.Identification and returns a slice that pairs these values with the
 // relevant identifier's field labels.
 func (s *Siegfried) Label(id core.Identification) [][2]string {
@@ -472,9 +1351,105 @@ func (s *Siegfried) Label(id core.Identification) [][2]string {
 	return nil
 }
 
+// Result is a structured form of an identification, for library consumers that need its values
+// without parsing one of the sf output formats. Its fields are taken from the identification's values,
+// labelled by the fields of the identifier that produced it.
+type Result struct {
+	Namespace string            `json:"namespace"`
+	ID        string            `json:"id"`
+	Format    string            `json:"format,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	MIME      string            `json:"mime,omitempty"`
+	Basis     []string          `json:"basis,omitempty"`
+	Warnings  []string          `json:"warnings,omitempty"`
+	Known     bool              `json:"known"`
+	Extra     map[string]string `json:"extra,omitempty"` // other fields e.g. "full" for LOC, "URI" for Wikidata, and metadata fields
+}
+
+// Result returns a structured form of an identification.
+func (s *Siegfried) Result(id core.Identification) Result {
+	r := Result{Known: id.Known()}
+	lbls := s.Label(id)
+	if lbls == nil {
+		vals := id.Values()
+		if len(vals) > 0 {
+			r.Namespace = vals[0]
+		}
+		r.ID = id.String()
+		r.Warnings = split(id.Warn())
+		return r
+	}
+	for _, l := range lbls {
+		switch l[0] {
+		case "namespace":
+			r.Namespace = l[1]
+		case "id":
+			r.ID = l[1]
+		case "format":
+			r.Format = l[1]
+		case "version":
+			r.Version = l[1]
+		case "mime":
+			r.MIME = l[1]
+		case "basis":
+			r.Basis = split(l[1])
+		case "warning":
+			r.Warnings = split(l[1])
+		default:
+			if r.Extra == nil {
+				r.Extra = make(map[string]string)
+			}
+			r.Extra[l[0]] = l[1]
+		}
+	}
+	return r
+}
+
+// Results returns structured forms of a slice of identifications.
+func (s *Siegfried) Results(ids []core.Identification) []Result {
+	ret := make([]Result, len(ids))
+	for i, id := range ids {
+		ret[i] = s.Result(id)
+	}
+	return ret
+}
+
+// split separates the "; " delimited basis and warning values
+func split(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return strings.Split(str, "; ")
+}
+
+func (s *Siegfried) byteMatcher() (*bytematcher.Matcher, error) {
+	m, err := loaded(s.bm)
+	if err != nil {
+		return nil, err
+	}
+	bm, ok := m.(*bytematcher.Matcher)
+	if !ok {
+		return nil, errors.New("siegfried: signature file has no byte matcher")
+	}
+	return bm, nil
+}
+
+// containerMatcher loads the container matcher, for Blame
+func (s *Siegfried) containerMatcher() (containermatcher.Matcher, error) {
+	m, err := loaded(s.cm)
+	if err != nil {
+		return nil, err
+	}
+	cm, ok := m.(containermatcher.Matcher)
+	if !ok {
+		return nil, errors.New("siegfried: signature file has no container matcher")
+	}
+	return cm, nil
+}
+
 // Blame checks with the byte matcher to see what identification results subscribe to a particular result or test
 // tree index. It can be used when identifying in a debug mode to check which identification results trigger
-// which strikes.
+// which strikes. If the matcher can't be loaded, Blame returns the error.
 func (s *Siegfried) Blame(idx, ct int, cn string) string {
 	toID := func(i int, typ core.MatcherType) string {
 		for _, id := range s.ids {
@@ -491,17 +1466,19 @@ func (s *Siegfried) Blame(idx, ct int, cn string) string {
 		}
 		return res
 	}
+	bm, err := s.byteMatcher()
 	if idx < 0 {
+		if err != nil {
+			return err.Error()
+		}
 		buf := &bytes.Buffer{}
 		if idx < -1 {
 			fmt.Fprint(buf, "KEY FRAMES\n")
-			bm := s.bm.(*bytematcher.Matcher)
 			for i := 0; i < bm.KeyFramesLen(); i++ {
 				fmt.Fprintf(buf, "---\n%s\n%s\n", toID(i, core.ByteMatcher), strings.Join(bm.DescribeKeyFrames(i), "\n"))
 			}
 		} else {
 			fmt.Fprint(buf, "TEST TREES\n")
-			bm := s.bm.(*bytematcher.Matcher)
 			for i := 0; i < bm.TestTreeLen(); i++ {
 				cres, ires, maxL, maxR, maxLM, maxRM := bm.DescribeTestTree(i)
 				fmt.Fprintf(buf, "---\nTest Tree %d\nCompletes: %s\nIncompletes: %s\nMax Left Distance: %d\nMax Right Distance: %d\nMax Left Matches: %d\nMax Right Matches: %d\n",
@@ -510,20 +1487,21 @@ func (s *Siegfried) Blame(idx, ct int, cn string) string {
 		}
 		return buf.String()
 	}
-	matcher := "BYTE MATCHER"
-	var ttis []int
 	if cn != "" {
-		matcher = "CONTAINER MATCHER"
-		cm := s.cm.(containermatcher.Matcher)
-		ttis = cm.InspectTestTree(ct, cn, idx)
-		res := toIDs(ttis, core.ContainerMatcher)
+		cm, err := s.containerMatcher()
+		if err != nil {
+			return err.Error()
+		}
+		res := toIDs(cm.InspectTestTree(ct, cn, idx), core.ContainerMatcher)
 		ttiNames := "not recognised"
 		if len(res) > 0 {
 			ttiNames = strings.Join(res, ",")
 		}
-		return fmt.Sprintf("%s\nHits at %d: %s (identifies hits reported by -debug)", matcher, idx, ttiNames)
+		return fmt.Sprintf("CONTAINER MATCHER\nHits at %d: %s (identifies hits reported by -debug)", idx, ttiNames)
+	}
+	if err != nil {
+		return err.Error()
 	}
-	bm := s.bm.(*bytematcher.Matcher)
 	resName := "not recognised"
 	for _, id := range s.ids {
 		if ok, str := id.Recognise(core.ByteMatcher, idx); ok {
@@ -536,7 +1514,7 @@ func (s *Siegfried) Blame(idx, ct int, cn string) string {
 	if len(res) > 0 {
 		ttiNames = strings.Join(res, ",")
 	}
-	return fmt.Sprintf("%s\nResults at %d: %s (identifies results reported by -slow)\nHits at %d: %s (identifies hits reported by -debug)", matcher, idx, resName, idx, ttiNames)
+	return fmt.Sprintf("BYTE MATCHER\nResults at %d: %s (identifies results reported by -slow)\nHits at %d: %s (identifies hits reported by -debug)", idx, resName, idx, ttiNames)
 }
 
 // Inspect returns a string containing detail about the various matchers in the Siegfried struct.
@@ -562,6 +1540,22 @@ func (s *Siegfried) Inspect(t core.MatcherType) string {
 		if s.rm != nil {
 			return s.rm.String()
 		}
+	case core.EBMLMatcher:
+		if s.em != nil {
+			return s.em.String()
+		}
+	case core.SQLiteMatcher:
+		if s.sm != nil {
+			return s.sm.String()
+		}
+	case core.TIFFMatcher:
+		if s.fm != nil {
+			return s.fm.String()
+		}
+	case core.FontMatcher:
+		if s.om != nil {
+			return s.om.String()
+		}
 	case core.TextMatcher:
 		if s.tm != nil {
 			return s.tm.String()
@@ -574,8 +1568,11 @@ func (s *Siegfried) Inspect(t core.MatcherType) string {
 		return fmt.Sprintf("Identifiers\n%s",
 			func() string {
 				var str string
-				for _, i := range s.ids {
+				for idx, i := range s.ids {
 					str += i.String()
+					if md := s.metadata(idx); md != nil {
+						str += md.String()
+					}
 				}
 				return str
 			}())
@@ -1,9 +1,22 @@
 package siegfried
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/richardlehane/siegfried/internal/metadata"
 	"github.com/richardlehane/siegfried/internal/persist"
 	"github.com/richardlehane/siegfried/internal/siegreader"
 	"github.com/richardlehane/siegfried/pkg/config"
@@ -24,6 +37,280 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+func TestLoadFS(t *testing.T) {
+	s, err := LoadFS(os.DirFS("./cmd/roy/data"), "default.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Identifiers()) == 0 {
+		t.Error("expecting at least one identifier")
+	}
+	if _, err = LoadBytes([]byte("not a signature file")); !errors.Is(err, ErrNotSignature) {
+		t.Errorf("expecting a not signature error loading bad bytes, got %v", err)
+	}
+	if _, err = LoadFS(fstest.MapFS{}, "missing.sig"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expecting a not exist error loading a missing signature file, got %v", err)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	s, err := LoadFS(os.DirFS("./cmd/roy/data"), "default.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmts := s.Formats()
+	if !sort.StringsAreSorted(fmts) {
+		t.Error("expecting formats to be sorted")
+	}
+	if i := sort.SearchStrings(fmts, "fmt/40"); i == len(fmts) || fmts[i] != "fmt/40" {
+		t.Error("expecting fmt/40 among the formats of the default signature file")
+	}
+	for i := 1; i < len(fmts); i++ {
+		if fmts[i] == fmts[i-1] {
+			t.Fatalf("duplicate format %s", fmts[i])
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	config.SetHome("./cmd/roy/data")
+	defer config.Reset()()
+	build := func(opts ...config.Option) *Siegfried {
+		s := New()
+		p, err := pronom.New(append([]config.Option{config.Clear()}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Add(p); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	older := build(config.SetLimit([]string{"fmt/1", "fmt/2"}))
+	newer := build(config.SetLimit([]string{"fmt/2", "fmt/3"}), config.SetNoName())
+	changes, err := Diff(older, newer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"removed fmt/1", "changed fmt/2", "added fmt/3"}
+	if len(changes) != len(expect) {
+		t.Fatalf("expecting %d changes, got %v", len(expect), changes)
+	}
+	for i, c := range changes {
+		if !strings.HasPrefix(c.String(), expect[i]) {
+			t.Errorf("expecting change %d to be %s, got %s", i, expect[i], c)
+		}
+	}
+	if what := changes[1].What; len(what) != 1 || what[0] != "namematcher" {
+		t.Errorf("expecting fmt/2's extensions to have changed, got %v", what)
+	}
+	if changes, _ = Diff(newer, newer); len(changes) != 0 {
+		t.Errorf("expecting no changes between a signature file and itself, got %v", changes)
+	}
+}
+
+func TestSignatureFormat(t *testing.T) {
+	legacy, err := os.ReadFile("./cmd/roy/data/default.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := ReadSignatureHeader(legacy)
+	if err != nil || h.Format != 1 {
+		t.Fatalf("expecting a format 1 header for the legacy signature file, got %v and %v", h, err)
+	}
+	s, err := LoadBytes(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir() + "/test.sig"
+	if err = s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, err = ReadSignatureHeader(current); err != nil || h.Format != SignatureFormat {
+		t.Fatalf("expecting a format %d header for the saved signature file, got %v and %v", SignatureFormat, h, err)
+	}
+	if _, err = LoadBytes(current); err != nil {
+		t.Fatal(err)
+	}
+	// a future format, which is reported by its number whatever sf it says it needs
+	future := append([]byte{}, current...)
+	v := config.Version()
+	future[h.Size-3], future[h.Size-2], future[h.Size-1] = SignatureFormat+1, byte(v[0]), byte(v[1])
+	_, err = LoadBytes(future)
+	var ie IncompatibleError
+	if !errors.As(err, &ie) || !errors.Is(err, ErrIncompatibleSignature) || !strings.Contains(err.Error(), fmt.Sprintf("signature format %d is newer", SignatureFormat+1)) {
+		t.Errorf("expecting an incompatible error for a future signature format, got %v", err)
+	}
+	// a known format that needs a newer sf
+	future[h.Size-3], future[h.Size-2] = SignatureFormat, 99
+	if _, err = LoadBytes(future); !errors.As(err, &ie) || !strings.Contains(err.Error(), "needs sf >= 99.") {
+		t.Errorf("expecting an incompatible error for a signature file that needs a newer sf, got %v", err)
+	}
+	// a legacy file made by an old version of sf
+	old := append([]byte{}, legacy...)
+	old[len(config.Magic())+1] = 0
+	if _, err = LoadBytes(old); !errors.Is(err, ErrIncompatibleSignature) {
+		t.Errorf("expecting an incompatible error for an old signature file, got %v", err)
+	}
+}
+
+func TestSections(t *testing.T) {
+	legacy, err := LoadFS(os.DirFS("./cmd/roy/data"), "default.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err = legacy.SaveWriter(buf); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	isLoaded := func(m core.Matcher) bool {
+		return m.(*lazyMatcher).buf == nil
+	}
+	// name only identification shouldn't load any sections
+	ctx := WithPolicy(context.Background(), Policy{Strategy: NameOnly})
+	if _, err = s.IdentifyContext(ctx, strings.NewReader("%PDF-1.4"), "test.pdf", ""); err != nil {
+		t.Fatal(err)
+	}
+	if isLoaded(s.cm) || isLoaded(s.bm) {
+		t.Error("expecting the container and byte matchers not to be loaded for name only identification")
+	}
+	// header identification loads the byte matcher but not the container matcher
+	ctx = WithPolicy(context.Background(), Policy{Strategy: Header})
+	if _, err = s.IdentifyContext(ctx, strings.NewReader("%PDF-1.4"), "test.pdf", ""); err != nil {
+		t.Fatal(err)
+	}
+	if isLoaded(s.cm) || !isLoaded(s.bm) {
+		t.Error("expecting just the byte matcher to be loaded for header identification")
+	}
+	ids, err := s.Identify(strings.NewReader(concurrentSamples[0]), "test.pdf", "")
+	if err != nil || len(ids) != 1 || ids[0].String() != "fmt/18" {
+		t.Errorf("expecting fmt/18, got %v and %v", ids, err)
+	}
+	// saving loads the remaining sections
+	buf.Reset()
+	if err = s.SaveWriter(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = LoadReader(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	// errors loading sections are reported when identifying
+	bad := s.bm.(*lazyMatcher)
+	bad.buf = bad.buf[:len(bad.buf)/2]
+	if _, err = s.Identify(strings.NewReader(concurrentSamples[0]), "test.pdf", ""); err == nil {
+		t.Error("expecting an error identifying with a bad byte matcher section")
+	}
+	// and by Blame, rather than panicking
+	for _, idx := range []int{0, -1, -2} {
+		if got := s.Blame(idx, 0, ""); got != s.bm.(*lazyMatcher).err.Error() {
+			t.Errorf("expecting Blame(%d) to give the error loading the byte matcher, got %q", idx, got)
+		}
+	}
+}
+
+var concurrentSamples = []string{
+	"%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n%%EOF\n",
+	"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+	"GIF89a\x01\x00\x01\x00",
+	"<?xml version=\"1.0\"?><root/>",
+	"just some plain text",
+}
+
+func TestConcurrentIdentify(t *testing.T) {
+	s := New()
+	config.SetHome("./cmd/roy/data")
+	p, err := pronom.New(config.Clear())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Add(p); err != nil {
+		t.Fatal(err)
+	}
+	expect := make([]string, len(concurrentSamples))
+	for i, v := range concurrentSamples {
+		ids, err := s.Identify(strings.NewReader(v), "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect[i] = ids[0].String()
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				for i, v := range concurrentSamples {
+					ids, err := s.Identify(strings.NewReader(v), "", "")
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if ids[0].String() != expect[i] {
+						t.Errorf("concurrent identify: expecting %s, got %s", expect[i], ids[0].String())
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestIdentifyFS(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	fsys := fstest.MapFS{"dir/test.doc": &fstest.MapFile{Data: []byte("test")}}
+	c, err := s.IdentifyFS(fsys, "dir/test.doc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c[0].String() != "fmt/3" {
+		t.Error("expecting fmt/3")
+	}
+	if _, err = s.IdentifyFS(fsys, "missing.doc", ""); err == nil {
+		t.Error("expecting an error for a missing file")
+	}
+}
+
+func TestIdentifyStream(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	fsys := fstest.MapFS{}
+	for _, n := range []string{"a.doc", "b.doc", "c/d.doc", "c/e.doc", "f.doc"} {
+		fsys[n] = &fstest.MapFile{Data: []byte("test")}
+	}
+	var paths []string
+	for r := range s.IdentifyStream(context.Background(), FSWalker(fsys, ".")) {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		if len(r.IDs) != 1 || r.IDs[0].String() != "fmt/3" {
+			t.Errorf("expecting fmt/3 for %s, got %v", r.Path, r.IDs)
+		}
+		paths = append(paths, r.Path)
+	}
+	if strings.Join(paths, ",") != "a.doc,b.doc,c/d.doc,c/e.doc,f.doc" {
+		t.Errorf("expecting results in walk order, got %v", paths)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for r := range s.IdentifyStream(ctx, FSWalker(fsys, ".")) {
+		t.Errorf("expecting no results once cancelled, got %v", r)
+	}
+}
+
 func TestIdentify(t *testing.T) {
 	s := New()
 	s.nm = testEMatcher{}
@@ -40,6 +327,222 @@ func TestIdentify(t *testing.T) {
 	}
 }
 
+func TestTrailing(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.cm = nil
+	s.ids = append(s.ids, testIdentifier{})
+	config.SetTrailing(true)
+	defer config.SetTrailing(false)
+	pdf := "%PDF-1.4\n%%EOF\n" + strings.Repeat("appended ", 10)
+	c, err := s.Identify(bytes.NewBufferString(pdf), "test.pdf", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := c[0].Warn(); w != "polyglot/trailing data: 90 bytes after the end of the PDF" {
+		t.Errorf("expecting a trailing data warning, got %q", w)
+	}
+	c, _ = s.Identify(bytes.NewBufferString("%PDF-1.4\n%%EOF\n"), "test.pdf", "")
+	if w := c[0].Warn(); w != "" {
+		t.Errorf("expecting no warning, got %q", w)
+	}
+}
+
+func TestMacros(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.cm = nil
+	s.ids = append(s.ids, testIdentifier{})
+	config.SetMacros(true)
+	defer config.SetMacros(false)
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, n := range []string{"[Content_Types].xml", "word/document.xml", "word/vbaProject.bin", "word/embeddings/oleObject1.bin"} {
+		f, _ := w.Create(n)
+		f.Write([]byte("content"))
+	}
+	w.Close()
+	c, err := s.Identify(&buf, "test.docm", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := c[0].Warn(); w != "macros/embedded objects: VBA macros, 1 embedded object" {
+		t.Errorf("expecting a macros warning, got %q", w)
+	}
+	c, _ = s.Identify(bytes.NewBufferString("%PDF-1.4\n%%EOF\n"), "test.pdf", "")
+	if w := c[0].Warn(); w != "" {
+		t.Errorf("expecting no warning, got %q", w)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.cm = nil
+	s.ids = append(s.ids, testIdentifier{})
+	c, err := s.Identify(bytes.NewBuffer(nil), "test.doc", "")
+	if err == nil || c[0].String() != "fmt/3" {
+		t.Errorf("expecting an empty source error and the usual match, got %v and %v", err, c)
+	}
+	config.SetEmpty(true)
+	defer config.SetEmpty(false)
+	c, err = s.Identify(bytes.NewBuffer(nil), "test.doc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c[0].String() != "EMPTY" || strings.Join(c[0].Values(), ",") != "a,EMPTY" {
+		t.Errorf("expecting an EMPTY match, got %s with values %v", c[0], c[0].Values())
+	}
+}
+
+func TestIdentifyContext(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c, err := s.IdentifyContext(ctx, bytes.NewBufferString("test"), "test.doc", "")
+	if err != context.Canceled || c != nil {
+		t.Errorf("expecting a cancelled error and no results, got %v and %v", err, c)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	var started, identified string
+	var results int
+	s.SetHooks(Hooks{
+		FileStart: func(name string) { started = name },
+		Result: func(name string, mt core.MatcherType, res core.Result) error {
+			results++
+			return nil
+		},
+		Identified: func(name string, ids []core.Identification, err error) { identified = ids[0].String() },
+	})
+	if _, err := s.Identify(bytes.NewBufferString("test"), "test.doc", ""); err != nil {
+		t.Fatal(err)
+	}
+	if started != "test.doc" || results == 0 || identified != "fmt/3" {
+		t.Errorf("hooks not fired, got %q, %d results, %q", started, results, identified)
+	}
+	abort := errors.New("abort")
+	var identifiedErr error
+	s.SetHooks(Hooks{
+		Result:     func(name string, mt core.MatcherType, res core.Result) error { return abort },
+		Identified: func(name string, ids []core.Identification, err error) { identifiedErr = err },
+	})
+	c, err := s.Identify(bytes.NewBufferString("test"), "test.doc", "")
+	if err != abort || c != nil {
+		t.Errorf("expecting an abort error and no results, got %v and %v", err, c)
+	}
+	if identifiedErr != abort {
+		t.Errorf("expecting the Identified hook to get the abort error, got %v", identifiedErr)
+	}
+}
+
+// metrics can be set while files are identified (run with -race)
+func TestSetMetrics(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			s.SetMetrics(NopMetrics{})
+			s.SetMetrics(nil)
+		}
+		close(done)
+	}()
+	for i := 0; i < 100; i++ {
+		if _, err := s.Identify(bytes.NewBufferString("test"), "test.doc", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func TestPanic(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	s.SetHooks(Hooks{
+		Result: func(name string, mt core.MatcherType, res core.Result) error {
+			if name == "bad.doc" {
+				panic("pathological file")
+			}
+			return nil
+		},
+	})
+	c, err := s.Identify(bytes.NewBufferString("test"), "bad.doc", "")
+	if c != nil || core.ErrorClass(err) != core.ClassPanic || !strings.Contains(err.Error(), "pathological file") {
+		t.Errorf("expecting a panic error and no results, got %v and %v", err, c)
+	}
+	if c, err = s.Identify(bytes.NewBufferString("test"), "good.doc", ""); err != nil || len(c) == 0 {
+		t.Errorf("expecting identification to carry on after a panic, got %v and %v", err, c)
+	}
+	// a matcher that fails part way through a scan reports its error
+	s.SetHooks(Hooks{})
+	s.bm = testFailMatcher{}
+	c, err = s.Identify(bytes.NewBufferString("test"), "test.doc", "")
+	if core.ErrorClass(err) != core.ClassPanic || len(c) == 0 {
+		t.Errorf("expecting a panic error and results, got %v and %v", err, c)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	s := New()
+	s.Configure(ScanBOF(1024), ScanEOF(512), NoMmap())
+	if l := s.buffers.Limits(); l.BOF != 1024 || l.EOF != 512 || !l.NoMmap || l.Stream == 0 {
+		t.Errorf("bad limits, got %+v", l)
+	}
+	s.Configure(PoolSize(1), StreamSlot(65536))
+	for i := 0; i < 2; i++ {
+		b, _ := s.Buffer(strings.NewReader("test"))
+		s.Put(b)
+	}
+	if st := s.PoolStats(); st.Hits != 1 || st.Misses != 1 || st.Idle != 1 {
+		t.Errorf("bad pool stats, got %+v", st)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	lims := &testLimitMatcher{}
+	s.bm = lims
+	s.ids = append(s.ids, testIdentifier{})
+	var hits []core.MatcherType
+	s.SetHooks(Hooks{
+		Result: func(name string, mt core.MatcherType, res core.Result) error {
+			hits = append(hits, mt)
+			return nil
+		},
+	})
+	ctx := WithPolicy(context.Background(), Policy{Strategy: NameOnly})
+	if _, err := s.IdentifyContext(ctx, bytes.NewBufferString("test"), "test.doc", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0] != core.NameMatcher || lims.called {
+		t.Errorf("expecting only a name match, got %v", hits)
+	}
+	ctx = WithPolicy(context.Background(), Policy{Strategy: Header, HeaderSize: 512})
+	if _, err := s.IdentifyContext(ctx, bytes.NewBufferString("test"), "test.doc", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !lims.called || lims.bof != 512 || lims.eof >= 0 {
+		t.Errorf("expecting a header scan of 512 bytes with no EOF scan, got %d and %d", lims.bof, lims.eof)
+	}
+}
+
 func TestLabel(t *testing.T) {
 	s := &Siegfried{ids: []core.Identifier{testIdentifier{}}}
 	res := s.Label(testIdentification{})
@@ -52,6 +555,134 @@ func TestLabel(t *testing.T) {
 	}
 }
 
+func TestCache(t *testing.T) {
+	s := New()
+	s.nm = testEMatcher{}
+	s.bm = testBMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	ids, err := s.Identify(bytes.NewBufferString("test"), "test.doc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCache("sha256")
+	sum := []byte("checksum")
+	c.Put(sum, "test.doc", "", ids)
+	if _, ok := c.Get(sum, filepath.Join("backup", "test.doc"), ""); !ok {
+		t.Error("expecting a cache hit for a file with the same checksum and name")
+	}
+	if _, ok := c.Get(sum, "other.doc", ""); ok {
+		t.Error("expecting a cache miss for a file with a different name")
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("expecting 1 hit and 1 miss, got %d and %d", hits, misses)
+	}
+	zsum := []byte("check\x00sum")
+	c.Put(zsum, "test.pdf", "application/pdf", ids)
+	if _, ok := c.Lookup(sum); !ok {
+		t.Error("expecting a lookup to find the results for a checksum")
+	}
+	if _, ok := c.Lookup([]byte("other")); ok {
+		t.Error("expecting a lookup to miss for an unknown checksum")
+	}
+	buf := &bytes.Buffer{}
+	if err := c.Save(buf, s); err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.Bytes()
+	c, err = LoadCache(bytes.NewReader(saved), s, "sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := c.Get(sum, "test.doc", "")
+	if !ok || len(got) != 1 || got[0].String() != ids[0].String() || got[0].Known() != ids[0].Known() ||
+		strings.Join(got[0].Values(), ",") != strings.Join(ids[0].Values(), ",") {
+		t.Errorf("expecting the loaded cache to give %v, got %v", ids, got)
+	}
+	if _, ok := c.Lookup(zsum); !ok {
+		t.Error("expecting a lookup in the loaded cache to find the results for a checksum with a zero byte")
+	}
+	if _, err = LoadCache(bytes.NewReader(saved), s, "md5"); !errors.Is(err, ErrStaleCache) {
+		t.Errorf("expecting a stale cache error for a different hash, got %v", err)
+	}
+	if _, err = LoadCache(bytes.NewReader(saved[:len(saved)-4]), s, "sha256"); err == nil || errors.Is(err, ErrStaleCache) {
+		t.Errorf("expecting an error loading a truncated cache, got %v", err)
+	}
+	// a cache saved with -noext is stale without it
+	config.SetSkipName(true)
+	buf.Reset()
+	err = c.Save(buf, s)
+	config.SetSkipName(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadCache(bytes.NewReader(buf.Bytes()), s, "sha256"); !errors.Is(err, ErrStaleCache) {
+		t.Errorf("expecting a stale cache error for different settings, got %v", err)
+	}
+	s.C = s.C.Add(time.Hour)
+	if _, err = LoadCache(bytes.NewReader(saved), s, "sha256"); !errors.Is(err, ErrStaleCache) {
+		t.Errorf("expecting a stale cache error for a different signature file, got %v", err)
+	}
+}
+
+// name matchers can match whole names and globs (e.g. freedesktop's README*), so a duplicate file with another name
+// (even with the same extension) must be identified again
+func TestCacheNames(t *testing.T) {
+	s, err := LoadFS(os.DirFS("./cmd/roy/data"), "freedesktop.sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCache("md5")
+	sum := []byte("checksum")
+	content := "some notes about this project\n"
+	for _, name := range []string{"README", "zzz"} {
+		want, err := s.Identify(strings.NewReader(content), name, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := c.Get(sum, name, "")
+		if !ok {
+			got, _ = s.Identify(strings.NewReader(content), name, "")
+			c.Put(sum, name, "", got)
+		}
+		if len(got) != len(want) || got[0].String() != want[0].String() {
+			t.Errorf("expecting %s to be %v with the cache, got %v", name, want, got)
+		}
+	}
+}
+
+func TestResult(t *testing.T) {
+	s := &Siegfried{ids: []core.Identifier{testIdentifier{}}}
+	r := s.Result(testIdentification{})
+	if r.Namespace != "a" || r.ID != "fmt/3" || !r.Known {
+		t.Errorf("bad result, got %+v", r)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	md, err := metadata.Read(strings.NewReader("puid,risk\nfmt/3,low\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	s.nm = testEMatcher{}
+	s.ids = append(s.ids, testIdentifier{})
+	s.mds = append(s.mds, md)
+	if f := s.Fields()[0]; len(f) != 3 || f[2] != "risk" {
+		t.Errorf("expecting metadata field, got %v", f)
+	}
+	c, err := s.Identify(bytes.NewBufferString("test"), "test.doc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := s.Label(c[0])
+	if len(res) != 3 || res[2][0] != "risk" || res[2][1] != "low" {
+		t.Errorf("bad label, got %v", res)
+	}
+	if r := s.Result(c[0]); r.Extra["risk"] != "low" {
+		t.Errorf("expecting metadata in result extras, got %+v", r)
+	}
+}
+
 // extension matcher test stub
 
 type testEMatcher struct{}
@@ -81,6 +712,44 @@ func (t testBMatcher) Identify(nm string, sb *siegreader.Buffer, hints ...core.H
 }
 func (t testBMatcher) String() string { return "" }
 
+type testFailMatcher struct{}
+
+func (t testFailMatcher) Identify(nm string, sb *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	ret := make(chan core.Result)
+	go func() {
+		ret <- testResult(1)
+		ret <- testFailure{}
+		close(ret)
+	}()
+	return ret, nil
+}
+
+func (t testFailMatcher) String() string { return "" }
+
+type testFailure struct{}
+
+func (tf testFailure) Index() int    { return -1 }
+func (tf testFailure) Basis() string { return "" }
+func (tf testFailure) Err() error {
+	return core.ClassifyError(core.ClassPanic, errors.New("scan panicked"))
+}
+
+// records the scan limits it is given
+
+type testLimitMatcher struct {
+	called   bool
+	bof, eof int
+}
+
+func (t *testLimitMatcher) Identify(nm string, sb *siegreader.Buffer, hints ...core.Hint) (chan core.Result, error) {
+	t.called = true
+	t.bof, t.eof = sb.ScanLimits()
+	ret := make(chan core.Result)
+	close(ret)
+	return ret, nil
+}
+func (t *testLimitMatcher) String() string { return "" }
+
 type testResult int
 
 func (tr testResult) Index() int    { return int(tr) }
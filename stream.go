@@ -0,0 +1,164 @@
+// Copyright 2026 Richard Lehane. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package siegfried
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/core"
+	"github.com/richardlehane/siegfried/pkg/walk"
+)
+
+// WalkFile is a file supplied by a Walker.
+// If Err is set, the file couldn't be accessed and it is reported with that error rather than identified.
+type WalkFile struct {
+	Path string
+	Size int64
+	Mod  time.Time
+	Open func() (io.ReadCloser, error)
+	Err  error
+}
+
+// A Walker calls fn for each file to identify, in the order that results should be reported.
+// It should stop and return the error if fn returns an error.
+type Walker func(fn func(WalkFile) error) error
+
+// DirWalker walks a directory tree on disk. Only regular files are identified.
+func DirWalker(root string) Walker {
+	return func(fn func(WalkFile) error) error {
+		return walk.Dir(root, walk.Options{}, func(e walk.Entry) error {
+			return visit(fn, e, func() (io.ReadCloser, error) { return os.Open(e.Path) })
+		})
+	}
+}
+
+// FSWalker walks a file system from root e.g. an embed.FS or a zip.Reader. Only regular files are identified.
+func FSWalker(fsys fs.FS, root string) Walker {
+	return func(fn func(WalkFile) error) error {
+		return walk.FS(fsys, root, walk.Options{}, func(e walk.Entry) error {
+			return visit(fn, e, func() (io.ReadCloser, error) { return fsys.Open(e.Path) })
+		})
+	}
+}
+
+// visit reports access errors and carries on walking
+func visit(fn func(WalkFile) error, e walk.Entry, open func() (io.ReadCloser, error)) error {
+	if e.Err != nil {
+		return fn(WalkFile{Path: e.Path, Err: e.Err})
+	}
+	if !e.Info.Mode().IsRegular() {
+		return nil
+	}
+	return fn(WalkFile{Path: e.Path, Size: e.Info.Size(), Mod: e.Info.ModTime(), Open: open})
+}
+
+// StreamResult is the result of identifying a file supplied by a Walker.
+type StreamResult struct {
+	Path string
+	Size int64
+	Mod  time.Time
+	IDs  []core.Identification
+	Err  error
+}
+
+// IdentifyStream identifies each file supplied by a Walker, using a pool of worker goroutines (one per CPU),
+// and returns a channel of results. Results are sent in walk order; the channel is closed once the walk is
+// complete. If the walker fails, a final result is sent with the walk error.
+// If the context is cancelled, files still to be identified are skipped and the channel is closed.
+func (s *Siegfried) IdentifyStream(ctx context.Context, w Walker) <-chan StreamResult {
+	out := make(chan StreamResult)
+	workers := runtime.GOMAXPROCS(0)
+	pending := make(chan chan StreamResult, workers)
+	jobs := make(chan streamJob)
+	// identify
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := range jobs {
+				j.res <- s.identifyWalkFile(ctx, j.f)
+			}
+		}()
+	}
+	// walk
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		err := w(func(f WalkFile) error {
+			res := make(chan StreamResult, 1)
+			select {
+			case pending <- res:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			if f.Err != nil {
+				res <- StreamResult{Path: f.Path, Size: f.Size, Mod: f.Mod, Err: f.Err}
+				return nil
+			}
+			if err := s.WaitForMemory(ctx); err != nil {
+				res <- StreamResult{Path: f.Path, Size: f.Size, Mod: f.Mod, Err: err}
+				return err
+			}
+			select {
+			case jobs <- streamJob{f, res}:
+			case <-ctx.Done():
+				res <- StreamResult{Path: f.Path, Size: f.Size, Mod: f.Mod, Err: ctx.Err()}
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil && ctx.Err() == nil {
+			res := make(chan StreamResult, 1)
+			res <- StreamResult{Err: err}
+			pending <- res
+		}
+	}()
+	// send results in order
+	go func() {
+		defer close(out)
+		for res := range pending {
+			if ctx.Err() != nil {
+				continue // drain so that the walk can finish
+			}
+			r := <-res
+			select {
+			case out <- r:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return out
+}
+
+// streamJob is a file for a worker to identify, with the channel to send its result on
+type streamJob struct {
+	f   WalkFile
+	res chan StreamResult
+}
+
+func (s *Siegfried) identifyWalkFile(ctx context.Context, f WalkFile) StreamResult {
+	r := StreamResult{Path: f.Path, Size: f.Size, Mod: f.Mod}
+	rc, err := f.Open()
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.IDs, r.Err = s.IdentifyContext(ctx, rc, f.Path, "")
+	rc.Close()
+	return r
+}